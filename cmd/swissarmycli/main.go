@@ -3,19 +3,81 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/HighonAces/swissarmycli/internal/apicalls"
 	"github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/bookmarks"
+	"github.com/HighonAces/swissarmycli/internal/config"
 	"github.com/HighonAces/swissarmycli/internal/k8s"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/server"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	"github.com/HighonAces/swissarmycli/internal/ui"
 	"github.com/HighonAces/swissarmycli/internal/validator"
 	"github.com/spf13/cobra"
 )
 
 func main() {
+	// Installed as kubectl-sac (or any kubectl-* name) the binary runs as a
+	// kubectl plugin: "kubectl sac <command>"
+	pluginMode := strings.HasPrefix(filepath.Base(os.Args[0]), "kubectl-")
+	var kubeconfig, kubeContext string
+	var sinks []string
+
 	var rootCmd = &cobra.Command{
 		Use:   "swissarmycli",
 		Short: "Swiss Army CLI - A multi-purpose CLI tool",
 		Long: `Swiss Army CLI is a versatile tool for platform engineering and DevOps tasks.
 It provides various utilities for working with Kubernetes, AWS, and more.`,
+		// Applies --no-color, --kubeconfig and --context, and lets namespace
+		// flags accept @alias bookmarks on every command
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if noColor, _ := cmd.Flags().GetBool("no-color"); noColor {
+				ui.DisableColor()
+			}
+			common.SetKubeconfigOverrides(kubeconfig, kubeContext)
+			// As a kubectl plugin, namespaced commands default to the
+			// context's namespace like kubectl does
+			if flag := cmd.Flags().Lookup("namespace"); pluginMode && flag != nil && !flag.Changed && flag.DefValue == "default" {
+				flag.Value.Set(common.ContextNamespace())
+			}
+			if flag := cmd.Flags().Lookup("namespace"); flag != nil && strings.HasPrefix(flag.Value.String(), bookmarks.Prefix) {
+				flag.Value.Set(resolveBookmark(flag.Value.String(), bookmarks.KindNamespace).Target)
+			}
+			if showAPICalls, _ := cmd.Flags().GetBool("show-api-calls"); showAPICalls {
+				apicalls.Enable()
+			}
+			if len(sinks) > 0 {
+				configureSinks(cmd, sinks)
+			}
+		},
+		// Delivers the output to the --sink destinations and prints the API
+		// requests counted with --show-api-calls
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			err := sink.Flush()
+			apicalls.Print(os.Stderr)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error delivering output: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+	var nonInteractive bool
+	rootCmd.PersistentFlags().BoolVar(&nonInteractive, "non-interactive", false, "Never prompt; fail when a choice is needed instead (for CI and automation)")
+	rootCmd.PersistentFlags().Bool("yes-prod", false, "Act in protected contexts (safety.protected_contexts, *prod* by default) without typing the context name")
+	rootCmd.PersistentFlags().Bool("no-color", false, "Disable colors in terminal UIs (also set by the NO_COLOR environment variable)")
+	rootCmd.PersistentFlags().StringVar(&kubeconfig, "kubeconfig", "", "Path to the kubeconfig file (default: $KUBECONFIG, which may list several files, or ~/.kube/config)")
+	rootCmd.PersistentFlags().StringVar(&kubeContext, "context", "", "Kubeconfig context to use (default: the current context)")
+	rootCmd.PersistentFlags().Bool("show-api-calls", false, "Print how many AWS and Kubernetes API requests the command made, by service and operation, on stderr")
+	rootCmd.PersistentFlags().StringArrayVar(&sinks, "sink", nil, "Deliver the command's structured output to stdout, file://PATH, s3://BUCKET/KEY or https://URL (POST); repeatable")
+	if pluginMode {
+		rootCmd.Annotations = map[string]string{cobra.CommandDisplayNameAnnotation: "kubectl sac"}
 	}
 
 	// --- Parent Connect command ---
@@ -28,61 +90,156 @@ It provides various utilities for working with Kubernetes, AWS, and more.`,
 	}
 
 	// --- Connect Node subcommand ---
+	var nodeOptions aws.ConnectNodeOptions
 	var connectNodeCmd = &cobra.Command{
-		Use:     "node [nodeName]",
-		Short:   "Connect to an AWS worker node using SSM",
-		Long:    `Connect to an AWS worker node in a Kubernetes cluster using AWS Systems Manager (SSM).`,
+		Use:   "node [nodeName]",
+		Short: "Connect to a worker node using SSM, or gcloud/az on GCE and Azure",
+		Long: `Connect to an AWS worker node in a Kubernetes cluster using AWS Systems Manager (SSM).
+The session-manager-plugin is used when installed; otherwise a built-in client
+opens the session, so neither the AWS CLI nor the plugin is required.
+Nodes whose providerID shows they run on GCE or Azure are connected to with
+gcloud compute ssh or az ssh vm instead.`,
 		Aliases: []string{"n", "nd"},
 		Args:    cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			nodeName := args[0]
-			err := aws.ConnectToNode(nodeName)
+			nodeName := resolveBookmark(args[0], bookmarks.KindNode).Target
+			err := aws.ConnectToNode(nodeName, nodeOptions)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error connecting to node: %v\n", err)
-				os.Exit(1)
+				result.Fail("Error connecting to node", err)
 			}
 		},
 	}
+	connectNodeCmd.Flags().BoolVar(&nodeOptions.Native, "native", false, "Use the built-in Session Manager client even if session-manager-plugin is installed")
 
 	// --- Connect Cluster subcommand ---
+	var clusterOptions aws.EKSConnectOptions
 	var connectClusterCmd = &cobra.Command{
 		Use:   "cluster [partial-cluster-name]",
 		Short: "Connect to an EKS cluster by updating kubeconfig",
-		Long: `Searches for EKS clusters across US regions (us-east-1, us-east-2, us-west-1, us-west-2)
-matching the partial name and updates kubeconfig for the selected cluster.`,
+		Long: `Searches for EKS clusters across the regions listed under aws.regions in the config
+file, or the US regions (us-east-1, us-east-2, us-west-1, us-west-2) without it,
+matching the partial name and updates kubeconfig for the selected cluster.
+Without the AWS CLI installed, the kubeconfig entry is written directly and
+authenticates through this binary.
+When several clusters match, use --region, --exact or --index to choose one
+without the interactive picker.`,
 		Aliases: []string{"c", "cl", "eks"},
 		Args:    cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			partialName := args[0]
-			// Get flags if any are added to this command in the future (e.g., specific profile)
+			bookmark := resolveBookmark(args[0], bookmarks.KindCluster)
+			if clusterOptions.Region == "" {
+				clusterOptions.Region = bookmark.Region
+			}
 			// For now, we assume the global AWS config/profile is used by the aws.ConnectToEKSCluster function.
-			// String flags can be retrieved using: profile, _ := cmd.Flags().GetString("profile")
+			clusterOptions.Selection.NonInteractive = nonInteractive
 
-			err := aws.ConnectToEKSCluster(partialName)
+			err := aws.ConnectToEKSCluster(bookmark.Target, clusterOptions)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error connecting to EKS cluster: %v\n", err)
-				os.Exit(1)
+				result.Fail("Error connecting to EKS cluster", err)
 			}
 		},
 	}
 
+	connectClusterCmd.Flags().StringVarP(&clusterOptions.Region, "region", "r", "", "Only search this region (default: aws.regions from the config file or all US regions)")
+	connectClusterCmd.Flags().BoolVar(&clusterOptions.Exact, "exact", false, "Match the cluster name exactly instead of as a substring")
+	connectClusterCmd.Flags().IntVar(&clusterOptions.Selection.Index, "index", 0, "Pick the Nth match (1-based, ordered by name then region) instead of prompting")
+
 	// Add subcommands to connectCmd
 	connectCmd.AddCommand(connectNodeCmd)
 	connectCmd.AddCommand(connectClusterCmd)
 
+	// --- Clusters command ---
+	var clustersCmd = &cobra.Command{
+		Use:   "clusters",
+		Short: "Inventory the EKS clusters of the AWS account",
+	}
+	var clustersListOptions aws.ClustersListOptions
+	var clustersListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List every EKS cluster across regions with version, support status and tags",
+		Long: `Scans the regions in parallel and describes every EKS cluster: Kubernetes and
+platform version, endpoint access, managed node group count, upgrade policy support
+tier, whether the version is in standard or extended support and until when, and
+tags. Regions default to aws.regions in the config file, or the US regions.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := aws.ListClusters(clustersListOptions); err != nil {
+				result.Fail("Error listing clusters", err)
+			}
+		},
+	}
+	clustersListCmd.Flags().StringSliceVarP(&clustersListOptions.Regions, "region", "r", nil, "Regions to scan (repeatable, default: aws.regions from the config file or all US regions)")
+	clustersListCmd.Flags().StringVarP(&clustersListOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	clustersListCmd.Flags().StringVarP(&clustersListOptions.Output, "output", "o", "table", "Output format (table, json or csv)")
+	outputFormats(clustersListCmd, "output", "table", "json", "csv")
+	clustersCmd.AddCommand(clustersListCmd)
+
+	// --- EOL Check command ---
+	var eolCheckOptions k8s.EOLCheckOptions
+	var eolCheckCmd = &cobra.Command{
+		Use:   "eol-check",
+		Short: "Report days until each cluster's Kubernetes version leaves EKS standard support",
+		Long: `Compares the Kubernetes version of the current context, the --contexts patterns or,
+with --inventory, every EKS cluster of the account with the EKS support calendar built
+into the binary, or refreshed online with --refresh. Reports the days left until
+standard support ends and the extended support cost on top of standard support.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.CheckEOL(eolCheckOptions)
+			if err != nil {
+				result.Exit("eol-check", eolCheckOptions.Output, "Error checking versions", err)
+			}
+		},
+	}
+	eolCheckCmd.Flags().StringSliceVar(&eolCheckOptions.Contexts, "contexts", nil, "Kubeconfig contexts to check, glob patterns allowed (repeatable, default: the current context or --context)")
+	eolCheckCmd.Flags().BoolVar(&eolCheckOptions.Inventory, "inventory", false, "Check every EKS cluster of the account instead of kubeconfig contexts")
+	eolCheckCmd.Flags().StringSliceVarP(&eolCheckOptions.Regions, "region", "r", nil, "Regions of --inventory (repeatable, default: aws.regions from the config file or all US regions)")
+	eolCheckCmd.Flags().StringVarP(&eolCheckOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	eolCheckCmd.Flags().BoolVar(&eolCheckOptions.Refresh, "refresh", false, "Update the bundled support calendar from endoflife.date")
+	eolCheckCmd.Flags().IntVar(&eolCheckOptions.WarnDays, "warn-days", 90, "Warn when standard support ends within this many days")
+	eolCheckCmd.Flags().StringVarP(&eolCheckOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(eolCheckCmd, "output", "table", "json")
+	eolCheckCmd.Flags().StringVar(&eolCheckOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+
+	var baselineCheckOptions k8s.BaselineCheckOptions
+	var baselineCheckCmd = &cobra.Command{
+		Use:   "baseline-check",
+		Short: "Verify clusters against a golden baseline spec",
+		Long: `Checks the current context, or every context matching --contexts, against a baseline
+spec declaring the minimum Kubernetes version, required addons and their minimum
+versions, labels and taints nodes must have, PodDisruptionBudgets and StorageClasses.
+Every violation is reported, so a fleet of clusters can be kept consistent.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.CheckBaseline(baselineCheckOptions)
+			if err != nil {
+				result.Exit("baseline-check", baselineCheckOptions.Output, "Error checking baseline", err)
+			}
+		},
+	}
+	baselineCheckCmd.Flags().StringVar(&baselineCheckOptions.Spec, "spec", "", "Baseline spec file (required)")
+	baselineCheckCmd.Flags().StringSliceVar(&baselineCheckOptions.Contexts, "contexts", nil, "Kubeconfig contexts to check, glob patterns allowed (repeatable, default: the current context or --context)")
+	baselineCheckCmd.Flags().StringVarP(&baselineCheckOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(baselineCheckCmd, "output", "table", "json")
+	baselineCheckCmd.Flags().StringVar(&baselineCheckOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+	baselineCheckCmd.MarkFlagRequired("spec")
+
 	//node usage command
+	var nodeUsageOptions k8s.NodeUsageOptions
 	var nodeUsageCmd = &cobra.Command{
 		Use:   "node-usage",
 		Short: "Display CPU and memory usage of all nodes",
-		Long:  `Display CPU and memory requests and limits for all nodes in the Kubernetes cluster.`,
+		Long: `Display CPU and memory requests and limits for all nodes in the Kubernetes cluster.
+Use --output csv or --append-to to record timestamped rows for trend tracking.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			err := k8s.ShowNodeUsage()
+			err := k8s.ShowNodeUsage(nodeUsageOptions)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error displaying node usage: %v\n", err)
-				os.Exit(1)
+				result.Fail("Error displaying node usage", err)
 			}
 		},
 	}
+	nodeUsageCmd.Flags().StringVarP(&nodeUsageOptions.Output, "output", "o", "table", "Output format (table or csv)")
+	outputFormats(nodeUsageCmd, "output", "table", "csv")
+	nodeUsageCmd.Flags().StringVar(&nodeUsageOptions.AppendTo, "append-to", "", "Append timestamped CSV rows to this file (implies --output csv)")
+	nodeUsageCmd.Flags().StringVar(&nodeUsageOptions.Custom.Columns, "columns", "", "Comma separated fields to print, each optionally HEADER:field (e.g. NODE:name,cpu_usage)")
+	nodeUsageCmd.Flags().StringVar(&nodeUsageOptions.Custom.Template, "template", "", "Go template printed for each node (e.g. '{{.name}} {{.cpu_usage}}')")
 
 	// --- ASG Status command ---
 	// Declare variables to hold flag values for asg-status
@@ -90,22 +247,35 @@ matching the partial name and updates kubeconfig for the selected cluster.`,
 	var asgProfile string
 	var asgRefreshInterval int // Renamed from 'refresh' for clarity
 	var asgStream bool         // Variable to hold the stream flag value
+	var asgRecord string
 
 	var asgStatusCmd = &cobra.Command{
-		Use:   "asg-status [ASG_NAME]",
+		Use:   "asg-status [ASG_NAME|NODE_GROUP]",
 		Short: "Check or monitor the status of an AWS Auto Scaling Group", // Updated Short description
 		Long: `Checks the current status of an AWS Auto Scaling Group.
 Optionally use the --stream flag to launch an interactive terminal dashboard
-to monitor the ASG, showing instances, states, and activities in real-time.`, // Updated Long description
+to monitor the ASG, showing instances, states, and activities in real-time.
+Scaling policies with their CloudWatch alarm states and upcoming scheduled
+actions are shown in both views. The ASG can also be given by the name of its
+node group, as listed by "asg map". With --record, every refresh of the monitor
+is appended to a file that "asg replay" plays back.`, // Updated Long description
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			asgName := args[0]
+			if asgRecord != "" && !asgStream {
+				result.Fail("Error", fmt.Errorf("--record requires --stream"))
+			}
+			bookmark := resolveBookmark(args[0], bookmarks.KindASG)
+			if asgRegion == "" {
+				asgRegion = bookmark.Region
+			}
+			asgName := resolveASG(bookmark.Target, asgProfile, asgRegion)
 
 			// Use the variables linked to the flags directly
 			options := aws.MonitorOptions{
 				RefreshInterval: asgRefreshInterval,
 				Region:          asgRegion,
 				Profile:         asgProfile,
+				Record:          asgRecord,
 			}
 
 			// Check the boolean variable linked to the --stream flag
@@ -114,8 +284,7 @@ to monitor the ASG, showing instances, states, and activities in real-time.`, //
 					asgName, options.Region, options.Profile, options.RefreshInterval)
 				err := aws.Monitor(asgName, options) // Call the streaming monitor function
 				if err != nil {
-					fmt.Fprintf(os.Stderr, "Error running monitor stream: %v\n", err)
-					os.Exit(1)
+					result.Fail("Error running monitor stream", err)
 				}
 				fmt.Println("ASG monitor stopped.")
 			} else {
@@ -123,8 +292,7 @@ to monitor the ASG, showing instances, states, and activities in real-time.`, //
 					asgName, options.Region, options.Profile)
 				err := aws.OnlyStatus(asgName, options) // Call the non-streaming status function
 				if err != nil {
-					fmt.Fprintf(os.Stderr, "Error checking ASG status: %v\n", err)
-					os.Exit(1)
+					result.Fail("Error checking ASG status", err)
 				}
 			}
 		},
@@ -139,109 +307,2358 @@ to monitor the ASG, showing instances, states, and activities in real-time.`, //
 	asgStatusCmd.Flags().IntVarP(&asgRefreshInterval, "interval", "i", 5, "Refresh interval in seconds (used with --stream)")
 	// Flag for Streaming - THIS IS THE FIX
 	asgStatusCmd.Flags().BoolVarP(&asgStream, "stream", "s", false, "Launch interactive monitor stream instead of just checking status once")
+	asgStatusCmd.Flags().StringVar(&asgRecord, "record", "", "Append every refresh of the monitor stream to this JSON Lines file, for asg replay")
+
+	// --- Parent ASG command ---
+	var asgCmd = &cobra.Command{
+		Use:   "asg",
+		Short: "Inspect and manage AWS Auto Scaling Groups",
+		Long:  `Provides subcommands for working with AWS Auto Scaling Groups beyond the status view of asg-status.`,
+	}
+
+	// --- ASG Drift subcommand ---
+	var driftOptions aws.DriftOptions
+	var asgDriftCmd = &cobra.Command{
+		Use:   "drift [ASG_NAME|NODE_GROUP]",
+		Short: "Find instances not running the ASG's current launch template version or AMI",
+		Long: `Compares each instance's launch template version and AMI against the version the ASG
+currently launches and lists outdated instances. Use --refresh to start an instance
+refresh that replaces them.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if driftOptions.Refresh {
+				guardContext(cmd)
+			}
+			bookmark := resolveBookmark(args[0], bookmarks.KindASG)
+			if driftOptions.Region == "" {
+				driftOptions.Region = bookmark.Region
+			}
+			err := aws.DetectASGDrift(resolveASG(bookmark.Target, driftOptions.Profile, driftOptions.Region), driftOptions)
+			if err != nil {
+				result.Exit("asg drift", driftOptions.Output, "Error checking ASG drift", err)
+			}
+		},
+	}
+	asgDriftCmd.Flags().StringVarP(&driftOptions.Region, "region", "r", "", "AWS region (optional, uses default configuration if not specified)")
+	asgDriftCmd.Flags().StringVarP(&driftOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	asgDriftCmd.Flags().BoolVar(&driftOptions.Refresh, "refresh", false, "Start an instance refresh if outdated instances are found")
+	asgDriftCmd.Flags().StringVarP(&driftOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(asgDriftCmd, "output", "table", "json")
+	asgDriftCmd.Flags().StringVar(&driftOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+
+	// --- ASG History subcommand ---
+	var historyOptions aws.HistoryOptions
+	var asgHistoryCmd = &cobra.Command{
+		Use:   "history [ASG_NAME|NODE_GROUP]",
+		Short: "Summarize an ASG's scaling activities over a time window",
+		Long: `Fetches every scaling activity of the ASG within --since and summarizes them by
+cause (health check failures, user requests, policy scaling, ...), with the
+mean time to replace an instance and the instances that were launched and
+terminated again within the window. asg-status only shows the last 10.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			bookmark := resolveBookmark(args[0], bookmarks.KindASG)
+			if historyOptions.Region == "" {
+				historyOptions.Region = bookmark.Region
+			}
+			if err := aws.ShowASGHistory(resolveASG(bookmark.Target, historyOptions.Profile, historyOptions.Region), historyOptions); err != nil {
+				result.Fail("Error fetching ASG history", err)
+			}
+		},
+	}
+	asgHistoryCmd.Flags().StringVarP(&historyOptions.Region, "region", "r", "", "AWS region (optional, uses default configuration if not specified)")
+	asgHistoryCmd.Flags().StringVarP(&historyOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	asgHistoryCmd.Flags().DurationVar(&historyOptions.Since, "since", 24*time.Hour, "How far back to look (at most 6 weeks are kept)")
+
+	// --- ASG Replay subcommand ---
+	var replayOptions aws.ReplayOptions
+	var asgReplayCmd = &cobra.Command{
+		Use:   "replay [FILE]",
+		Short: "Play back an ASG monitor recording made with asg-status --record",
+		Long: `Replays the snapshots recorded by asg-status --stream --record in the monitor
+dashboard at the pace they were recorded, so a scale event can be re-watched in a
+post-incident review. Space pauses, the arrow keys step through the snapshots and
++/- double or halve the speed.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := aws.Replay(args[0], replayOptions); err != nil {
+				result.Fail("Error replaying recording", err)
+			}
+		},
+	}
+	asgReplayCmd.Flags().Float64Var(&replayOptions.Speed, "speed", 1, "Playback speed, e.g. 10 plays ten times as fast as recorded")
+
+	// --- ASG Map subcommand ---
+	var asgMapOptions aws.ASGMapOptions
+	var asgMapCmd = &cobra.Command{
+		Use:   "map",
+		Short: "Map each ASG to its EKS node group or Karpenter NodePool and the nodes it backs",
+		Long: `Lists every ASG of the region with the EKS managed node group, Karpenter NodePool or
+eksctl node group it belongs to, read from its tags, and the nodes of the current
+cluster running on its instances. Karpenter NodePools that launch nodes without an
+ASG are listed too. The node group names work in place of ASG names in asg-status,
+asg drift and asg history, as nodegroup or cluster/nodegroup.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := aws.MapASGs(asgMapOptions); err != nil {
+				result.Fail("Error mapping ASGs", err)
+			}
+		},
+	}
+	asgMapCmd.Flags().StringVarP(&asgMapOptions.Region, "region", "r", "", "AWS region (optional, uses default configuration if not specified)")
+	asgMapCmd.Flags().StringVarP(&asgMapOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	asgMapCmd.Flags().StringVar(&asgMapOptions.Cluster, "cluster", "", "Only ASGs of this EKS cluster")
+	asgMapCmd.Flags().StringVarP(&asgMapOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(asgMapCmd, "output", "table", "json")
+
+	asgCmd.AddCommand(asgDriftCmd)
+	asgCmd.AddCommand(asgHistoryCmd)
+	asgCmd.AddCommand(asgReplayCmd)
+	asgCmd.AddCommand(asgMapCmd)
+
+	// --- Capacity Check command ---
+	var capacityOptions aws.CapacityCheckOptions
+	var capacityCheckCmd = &cobra.Command{
+		Use:   "capacity-check [INSTANCE_TYPE...]",
+		Short: "Check whether instance types are likely to launch in each availability zone",
+		Long: `For each instance type and availability zone, checks whether EC2 offers the type in
+the zone and whether Auto Scaling Groups recently failed to launch it with an
+InsufficientInstanceCapacity error, so you know which types and zones are likely
+to fail before scaling into them.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			capacityOptions.InstanceTypes = args
+			if capacityOptions.ASGName != "" {
+				capacityOptions.ASGName = resolveBookmark(capacityOptions.ASGName, bookmarks.KindASG).Target
+			}
+			err := aws.CheckCapacity(capacityOptions)
+			if err != nil {
+				result.Fail("Error checking capacity", err)
+			}
+		},
+	}
+	capacityCheckCmd.Flags().StringSliceVarP(&capacityOptions.Zones, "zones", "z", nil, "Availability zones to check, comma separated (default: all zones of the region)")
+	capacityCheckCmd.Flags().StringVar(&capacityOptions.ASGName, "asg", "", "Only look at this ASG's scaling activities (default: every ASG)")
+	capacityCheckCmd.Flags().DurationVar(&capacityOptions.Since, "since", 7*24*time.Hour, "How far back to look for capacity errors")
+	capacityCheckCmd.Flags().StringVarP(&capacityOptions.Region, "region", "r", "", "AWS region (optional, uses default configuration if not specified)")
+	capacityCheckCmd.Flags().StringVarP(&capacityOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+
+	// --- Run Preset command ---
+	var presetOptions aws.PresetOptions
+	var presetList bool
+	var runPresetCmd = &cobra.Command{
+		Use:   "run-preset [preset-name]",
+		Short: "Run a named SSM document preset on nodes selected by label",
+		Long: `Runs a named preset on every Kubernetes node matching a label selector using
+AWS Systems Manager Run Command, streaming each node's output as it finishes and
+printing a summary of successes and failures. Presets are defined in the config
+file; collect-sos, restart-kubelet and rotate-docker are built in.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if presetList || len(args) == 0 {
+				fmt.Println("Available presets:")
+				if err := aws.ListPresets(); err != nil {
+					result.Fail("Error listing presets", err)
+				}
+				return
+			}
+			guardContext(cmd)
+
+			err := aws.RunPreset(args[0], presetOptions)
+			if err != nil {
+				result.Fail("Error running preset", err)
+			}
+		},
+	}
+	runPresetCmd.Flags().StringVarP(&presetOptions.Selector, "selector", "l", "", "Kubernetes label selector for target nodes (default: all nodes)")
+	runPresetCmd.Flags().StringVarP(&presetOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	runPresetCmd.Flags().DurationVar(&presetOptions.Timeout, "timeout", 10*time.Minute, "How long to wait for results")
+	runPresetCmd.Flags().BoolVar(&presetList, "list", false, "List available presets")
+
+	// --- Node command ---
+	var nodeCmd = &cobra.Command{
+		Use:   "node",
+		Short: "Diagnose individual worker nodes",
+	}
+
+	var bootstrapLogsOptions aws.BootstrapLogsOptions
+	var bootstrapLogsCmd = &cobra.Command{
+		Use:   "bootstrap-logs [nodeName]",
+		Short: "Collect cloud-init, kubelet and containerd logs from a node into a bundle",
+		Long: `Collects cloud-init, nodeadm, kubelet and containerd logs and the kubelet config
+from a node using SSM Run Command, plus the EC2 console output, which is
+available even when SSM is down, and writes them to a .tar.gz bundle.
+The node can be a Kubernetes node name, an EC2 private DNS name or an instance
+ID, so nodes that never joined the cluster can be diagnosed as well.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			nodeName := resolveBookmark(args[0], bookmarks.KindNode).Target
+			if err := aws.CollectBootstrapLogs(nodeName, bootstrapLogsOptions); err != nil {
+				result.Fail("Error collecting bootstrap logs", err)
+			}
+		},
+	}
+	bootstrapLogsCmd.Flags().StringVarP(&bootstrapLogsOptions.Region, "region", "r", "", "AWS region, needed for unregistered nodes whose region can't be read from the name")
+	bootstrapLogsCmd.Flags().StringVarP(&bootstrapLogsOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	bootstrapLogsCmd.Flags().StringVar(&bootstrapLogsOptions.OutputDir, "output-dir", "", "Directory to write the bundle to (default: current directory)")
+	bootstrapLogsCmd.Flags().IntVar(&bootstrapLogsOptions.Lines, "lines", 2000, "Lines to collect from each log")
+	bootstrapLogsCmd.Flags().DurationVar(&bootstrapLogsOptions.Timeout, "timeout", 2*time.Minute, "How long to wait for SSM results")
+	nodeCmd.AddCommand(bootstrapLogsCmd)
+
+	var hardeningCheckOptions k8s.HardeningCheckOptions
+	var hardeningCheckCmd = &cobra.Command{
+		Use:   "hardening-check",
+		Short: "Audit IMDSv2, hop limit, EBS encryption, public IPs and SSM agent health of the nodes",
+		Long: `Verifies for every EC2 worker node that IMDSv2 is required, the IMDS hop limit
+keeps pods away from the node's credentials, its EBS volumes are encrypted, it has
+no public IP and its SSM agent is online and up to date. Prints a compliance table
+and the commands that fix each failure.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.CheckNodeHardening(hardeningCheckOptions)
+			if err != nil {
+				result.Exit("node hardening-check", hardeningCheckOptions.Output, "Error checking node hardening", err)
+			}
+		},
+	}
+	hardeningCheckCmd.Flags().StringVarP(&hardeningCheckOptions.Region, "region", "r", "", "AWS region of the cluster (default: taken from the node labels)")
+	hardeningCheckCmd.Flags().StringVarP(&hardeningCheckOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	hardeningCheckCmd.Flags().StringVarP(&hardeningCheckOptions.Selector, "selector", "l", "", "Only nodes matching this label selector")
+	hardeningCheckCmd.Flags().Int64Var(&hardeningCheckOptions.MaxHopLimit, "max-hop-limit", 1, "Highest acceptable IMDS hop limit; use 2 when pods need the node's IMDS")
+	hardeningCheckCmd.Flags().StringVarP(&hardeningCheckOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(hardeningCheckCmd, "output", "table", "json")
+	hardeningCheckCmd.Flags().StringVar(&hardeningCheckOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+	nodeCmd.AddCommand(hardeningCheckCmd)
+
+	var nodeSoftwareOptions k8s.NodeSoftwareOptions
+	var nodeSoftwareCmd = &cobra.Command{
+		Use:   "software [package]",
+		Short: "Report which version of a package or binary every node has installed",
+		Long: `Looks up a package such as containerd, runc or openssl on every node to answer
+whether a CVE affects the fleet. EC2 nodes are looked up in SSM Inventory first;
+nodes inventory has nothing for are queried with rpm or dpkg and the binary's
+--version, through SSM Run Command or a pod chrooted into the host. Packages
+whose name starts with the argument are listed. With --fixed-in, nodes running
+an older version of the package or binary are flagged.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			// Only --method inventory leaves the nodes alone
+			if nodeSoftwareOptions.Method != "inventory" {
+				guardContext(cmd)
+			}
+			if err := k8s.ShowNodeSoftware(args[0], nodeSoftwareOptions); err != nil {
+				result.Exit("node software", nodeSoftwareOptions.Output, "Error looking up node software", err)
+			}
+		},
+	}
+	nodeSoftwareCmd.Flags().StringVarP(&nodeSoftwareOptions.Selector, "selector", "l", "", "Only nodes matching this label selector")
+	nodeSoftwareCmd.Flags().StringVar(&nodeSoftwareOptions.FixedIn, "fixed-in", "", "Version that fixes the CVE; nodes with an older version are flagged")
+	nodeSoftwareCmd.Flags().StringVar(&nodeSoftwareOptions.Method, "method", "auto", "How to look up the package: inventory (SSM Inventory only), ssm, pod or auto (inventory, then SSM when the node's agent is online, else a pod)")
+	nodeSoftwareCmd.Flags().StringVarP(&nodeSoftwareOptions.Region, "region", "r", "", "AWS region of the nodes (default: taken from the node provider IDs)")
+	nodeSoftwareCmd.Flags().StringVarP(&nodeSoftwareOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	nodeSoftwareCmd.Flags().StringVarP(&nodeSoftwareOptions.Namespace, "namespace", "n", "kube-system", "Namespace of the collector pods (must admit privileged pods with a hostPath volume)")
+	nodeSoftwareCmd.Flags().StringVar(&nodeSoftwareOptions.Image, "image", k8s.DefaultDebugImage, "Image of the collector pods (needs chroot)")
+	nodeSoftwareCmd.Flags().IntVar(&nodeSoftwareOptions.Parallel, "parallel", 10, "Number of collector pods run at once")
+	nodeSoftwareCmd.Flags().DurationVar(&nodeSoftwareOptions.Timeout, "timeout", 2*time.Minute, "How long to wait for each node's result")
+	nodeSoftwareCmd.Flags().StringVarP(&nodeSoftwareOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(nodeSoftwareCmd, "output", "table", "json")
+	nodeSoftwareCmd.Flags().StringVar(&nodeSoftwareOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+	nodeCmd.AddCommand(nodeSoftwareCmd)
+
+	// --- Debug command ---
+	var debugOptions k8s.DebugOptions
+	var debugCmd = &cobra.Command{
+		Use:   "debug [pod]",
+		Short: "Start a toolbox container in a pod and open a shell in it",
+		Long: `Adds an ephemeral debug container to a running pod, sharing the process
+namespace of its first container (or --target), and attaches a shell to it.
+With --copy, a copy of the pod is created instead, with the toolbox as an
+extra container, probes removed and the security context relaxed; use this
+for pods that crash at startup or clusters without ephemeral containers.
+The copy is deleted when the shell exits unless --keep is set.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			guardContext(cmd)
+			if err := k8s.DebugPod(args[0], debugOptions); err != nil {
+				result.Fail("Error debugging pod", err)
+			}
+		},
+	}
+	debugCmd.Flags().StringVarP(&debugOptions.Namespace, "namespace", "n", "", "Namespace of the pod (default: default)")
+	debugCmd.Flags().StringVar(&debugOptions.Image, "image", k8s.DefaultDebugImage, "Toolbox image for the debug container")
+	debugCmd.Flags().StringVar(&debugOptions.Target, "target", "", "Container whose processes to share (default: the first container)")
+	debugCmd.Flags().StringVar(&debugOptions.Shell, "shell", "sh", "Shell to run in the debug container")
+	debugCmd.Flags().BoolVar(&debugOptions.Copy, "copy", false, "Debug a copy of the pod with a relaxed security context")
+	debugCmd.Flags().BoolVar(&debugOptions.Keep, "keep", false, "Keep the pod copy after the session ends")
+
+	// --- Restart command ---
+	var restartOptions k8s.RestartOptions
+	var restartCmd = &cobra.Command{
+		Use:   "restart [NAME...]",
+		Short: "Rolling restart of workloads by name or label selector",
+		Long: `Performs the equivalent of 'kubectl rollout restart' on the named workloads, or
+on every workload matching --selector, typically after rotating a config map
+or secret. At most --concurrency workloads are restarted at a time and, unless
+--wait=false, each rollout must finish before the next one starts.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			guardContext(cmd)
+			if err := k8s.RestartWorkloads(args, restartOptions); err != nil {
+				result.Fail("Error restarting workloads", err)
+			}
+		},
+	}
+	restartCmd.Flags().StringVarP(&restartOptions.Namespace, "namespace", "n", "", "Namespace (default: default for names, all namespaces for --selector)")
+	restartCmd.Flags().StringVarP(&restartOptions.Selector, "selector", "l", "", "Label selector for the workloads to restart")
+	restartCmd.Flags().StringVar(&restartOptions.Kind, "kind", "deployment", "Kind of workload: deployment, statefulset, daemonset or all")
+	restartCmd.Flags().IntVar(&restartOptions.Concurrency, "concurrency", 3, "Workloads restarted at the same time")
+	restartCmd.Flags().BoolVar(&restartOptions.Wait, "wait", true, "Wait for each rollout to finish")
+	restartCmd.Flags().DurationVar(&restartOptions.Timeout, "timeout", 10*time.Minute, "How long to wait for each rollout")
+	restartCmd.Flags().BoolVar(&restartOptions.DryRun, "dry-run", false, "List the workloads that would be restarted")
+
+	// --- Verify-reload command ---
+	var verifyReloadOptions k8s.VerifyReloadOptions
+	var verifyReloadCmd = &cobra.Command{
+		Use:   "verify-reload [deployment]",
+		Short: "Check whether a deployment's pods have picked up the latest ConfigMap or Secret content",
+		Long: `Lists the running pods of a deployment that still use old content of a config map
+or secret. Environment variables and subPath mounts are read when the container
+starts, so containers started before the last write are stale. Mounted volumes
+are updated lazily by the kubelet, so the mounted files are read in each
+container and compared with the current data. Checksum annotations of the pod
+template, as Helm charts set them, are compared with each pod's as well.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := k8s.VerifyReload(args[0], verifyReloadOptions); err != nil {
+				result.Fail("Error verifying reload", err)
+			}
+		},
+	}
+	verifyReloadCmd.Flags().StringVarP(&verifyReloadOptions.Namespace, "namespace", "n", "default", "Namespace of the deployment")
+	verifyReloadCmd.Flags().StringVar(&verifyReloadOptions.ConfigMap, "configmap", "", "ConfigMap to verify")
+	verifyReloadCmd.Flags().StringVar(&verifyReloadOptions.Secret, "secret", "", "Secret to verify")
+	verifyReloadCmd.Flags().BoolVar(&verifyReloadOptions.Exec, "exec", true, "Read the mounted files in the containers to compare them (needs cat in the image)")
+
+	// --- Apply command ---
+	var applyOptions k8s.ApplyOptions
+	var applyCmd = &cobra.Command{
+		Use:   "apply [PATH...]",
+		Short: "Server-side apply manifests and wait for the workloads to become ready",
+		Long: `Server-side applies the manifests in the given files or directories, then waits
+until every applied Deployment, StatefulSet and DaemonSet has rolled out and every Job
+has completed, with one progress display for all of them: kubectl apply --server-side
+and a kubectl rollout status per workload in one step. Namespaces and
+CustomResourceDefinitions are applied first. Exits with code 1 if any object fails to
+apply or any workload is not ready within --timeout.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			guardContext(cmd)
+			if err := k8s.Apply(args, applyOptions); err != nil {
+				result.Fail("Error applying manifests", err)
+			}
+		},
+	}
+	applyCmd.Flags().StringVarP(&applyOptions.Namespace, "namespace", "n", "default", "Namespace of namespaced objects that don't set one")
+	applyCmd.Flags().StringVar(&applyOptions.FieldManager, "field-manager", k8s.DefaultFieldManager, "Field manager the applied fields are owned by")
+	applyCmd.Flags().BoolVar(&applyOptions.Force, "force-conflicts", false, "Take over fields owned by other field managers")
+	applyCmd.Flags().BoolVar(&applyOptions.Wait, "wait", true, "Wait for the applied workloads to become ready")
+	applyCmd.Flags().DurationVar(&applyOptions.Timeout, "timeout", 5*time.Minute, "How long to wait for all workloads")
+	applyCmd.Flags().BoolVar(&applyOptions.DryRun, "dry-run", false, "Server-side dry run, validating without persisting anything")
+	applyCmd.Flags().StringVarP(&applyOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(applyCmd, "output", "table", "json")
+
+	var getCleanOptions k8s.GetCleanOptions
+	var getCleanCmd = &cobra.Command{
+		Use:   "get-clean [kind] [name]",
+		Short: "Print a live object as YAML without status, managedFields and defaulted fields",
+		Long: `Fetches a live object and prints it as YAML ready to commit to Git: status,
+managedFields, UIDs, resource versions, owner references, controller annotations such as
+last-applied-configuration, assigned fields such as cluster IPs, and fields left at
+their server default are removed. Without a name, every object of the kind in the
+namespace, or matching --selector, is printed as a multi-document YAML.`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var name string
+			if len(args) > 1 {
+				name = args[1]
+			}
+			if err := k8s.GetClean(args[0], name, getCleanOptions); err != nil {
+				result.Fail("Error getting "+args[0], err)
+			}
+		},
+	}
+	getCleanCmd.Flags().StringVarP(&getCleanOptions.Namespace, "namespace", "n", "default", "Namespace of namespaced objects")
+	getCleanCmd.Flags().StringVarP(&getCleanOptions.Selector, "selector", "l", "", "Label selector when no name is given")
+	getCleanCmd.Flags().BoolVar(&getCleanOptions.KeepDefaults, "keep-defaults", false, "Keep fields left at their server default")
+	getCleanCmd.Flags().BoolVar(&getCleanOptions.DropNamespace, "drop-namespace", false, "Remove metadata.namespace, for manifests applied to several namespaces")
+
+	// --- Chaos command ---
+	var chaosCmd = &cobra.Command{
+		Use:   "chaos",
+		Short: "Controlled disruption for resilience drills",
+	}
+
+	var chaosKillOptions k8s.ChaosKillOptions
+	var chaosKillPodsCmd = &cobra.Command{
+		Use:   "kill-pods",
+		Short: "Kill random ready pods matching a selector and measure recovery",
+		Long: `Deletes --count random ready pods matching --selector, one every --interval,
+then waits for the number of ready pods to recover and reports how long it took.
+Only namespaces listed under chaos.allowed_namespaces in the config file can be
+targeted, and the namespace name must be typed back (or passed with --confirm)
+before anything is deleted. A kill is skipped when it would leave fewer than
+--min-ready ready pods.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			guardContext(cmd)
+			chaosKillOptions.NonInteractive = nonInteractive
+			if err := k8s.ChaosKillPods(chaosKillOptions); err != nil {
+				result.Fail("Error killing pods", err)
+			}
+		},
+	}
+	chaosKillPodsCmd.Flags().StringVarP(&chaosKillOptions.Namespace, "namespace", "n", "default", "Namespace of the pods")
+	chaosKillPodsCmd.Flags().StringVarP(&chaosKillOptions.Selector, "selector", "l", "", "Label selector of the pods to kill (required)")
+	chaosKillPodsCmd.Flags().IntVar(&chaosKillOptions.Count, "count", 1, "Pods to kill in total")
+	chaosKillPodsCmd.Flags().DurationVar(&chaosKillOptions.Interval, "interval", 30*time.Second, "Wait between kills")
+	chaosKillPodsCmd.Flags().IntVar(&chaosKillOptions.MinReady, "min-ready", 1, "Never kill a pod when fewer ready pods would remain")
+	chaosKillPodsCmd.Flags().Int64Var(&chaosKillOptions.GracePeriod, "grace-period", -1, "Termination grace period in seconds (default: the pod's own)")
+	chaosKillPodsCmd.Flags().DurationVar(&chaosKillOptions.RecoveryTimeout, "recovery-timeout", 5*time.Minute, "How long to wait for the ready pods to recover")
+	chaosKillPodsCmd.Flags().StringVar(&chaosKillOptions.Confirm, "confirm", "", "Namespace name, confirming without a prompt")
+	chaosKillPodsCmd.Flags().BoolVar(&chaosKillOptions.DryRun, "dry-run", false, "Show the plan without killing anything")
+	chaosKillPodsCmd.MarkFlagRequired("selector")
+	chaosCmd.AddCommand(chaosKillPodsCmd)
+
+	var loadTestOptions k8s.LoadTestOptions
+	var loadTestCmd = &cobra.Command{
+		Use:   "loadtest [service]",
+		Short: "Send HTTP load to a Service and report latency, errors and scaling",
+		Long: `Sends requests at a fixed rate to a Service, through its load balancer when it
+has one or a port-forward to one of its pods otherwise, and reports latency
+percentiles, error rates and status codes. Progress lines every few seconds show
+the HPAs scaling the Service's workloads and the CPU usage of the nodes its pods
+run on, for a quick capacity sanity check without installing a load testing tool.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := k8s.LoadTest(args[0], loadTestOptions); err != nil {
+				result.Fail("Error running load test", err)
+			}
+		},
+	}
+	loadTestCmd.Flags().StringVarP(&loadTestOptions.Namespace, "namespace", "n", "default", "Namespace of the service")
+	loadTestCmd.Flags().IntVar(&loadTestOptions.RPS, "rps", 10, "Requests per second")
+	loadTestCmd.Flags().DurationVar(&loadTestOptions.Duration, "duration", 30*time.Second, "How long to send requests")
+	loadTestCmd.Flags().StringVar(&loadTestOptions.Path, "path", "/", "Request path")
+	loadTestCmd.Flags().StringVarP(&loadTestOptions.Method, "method", "X", "GET", "HTTP method")
+	loadTestCmd.Flags().Int32Var(&loadTestOptions.Port, "port", 0, "Service port (default: the first one)")
+	loadTestCmd.Flags().StringVar(&loadTestOptions.Scheme, "scheme", "http", "http or https")
+	loadTestCmd.Flags().StringVar(&loadTestOptions.Via, "via", "auto", "How to reach the service: auto, port-forward or lb")
+	loadTestCmd.Flags().IntVar(&loadTestOptions.MaxInFlight, "max-in-flight", 100, "Requests outstanding at once; further requests are dropped")
+	loadTestCmd.Flags().DurationVar(&loadTestOptions.Timeout, "timeout", 10*time.Second, "Timeout of each request")
+
+	var sloOptions k8s.SLOOptions
+	var sloCmd = &cobra.Command{
+		Use:   "slo [service]",
+		Short: "Report the remaining error budget and burn rate of a service",
+		Long: `Query Prometheus for the request and error rates of a service and report how
+much of the error budget of the SLO window is left, the burn rate over the last
+5 minutes, hour, 6 hours and day, and when the budget runs out at the current
+rate, to help decide how urgent a rollback is during an incident. The endpoint
+and the queries come from the prometheus section of the config file.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := k8s.ShowSLO(args[0], sloOptions); err != nil {
+				result.Fail("Error calculating error budget", err)
+			}
+		},
+	}
+	sloCmd.Flags().Float64Var(&sloOptions.Target, "target", 99.9, "Availability objective in percent")
+	sloCmd.Flags().StringVar(&sloOptions.Window, "window", "30d", "SLO window as a Prometheus duration")
+	sloCmd.Flags().StringVar(&sloOptions.URL, "url", "", "Prometheus URL (default: prometheus.url or prometheus.service of the config file)")
+	sloCmd.Flags().StringVar(&sloOptions.RequestsQuery, "requests-query", "", "PromQL for requests per second, with $service and $window (default: prometheus.requests_query of the config file)")
+	sloCmd.Flags().StringVar(&sloOptions.ErrorsQuery, "errors-query", "", "PromQL for failed requests per second, with $service and $window (default: prometheus.errors_query of the config file)")
+
+	var apiserverProbeOptions k8s.APIServerProbeOptions
+	var apiserverProbeCmd = &cobra.Command{
+		Use:   "apiserver-probe",
+		Short: "Measure API server latency and throttling",
+		Long: `Times list requests with different page sizes and gets by name against the API
+server of the current context, and reports p50/p95 latency per HTTP request and per
+whole list. Time spent on the client-side rate limiter, 429 responses and API
+Priority and Fairness rejections are reported separately, for when "kubectl feels
+slow" and it is unclear whether the client, the fairness queues or the server is to
+blame.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := k8s.ProbeAPIServer(apiserverProbeOptions); err != nil {
+				result.Fail("Error probing API server", err)
+			}
+		},
+	}
+	apiserverProbeCmd.Flags().StringVarP(&apiserverProbeOptions.Namespace, "namespace", "n", "", "Namespace to list in (default: all namespaces)")
+	apiserverProbeCmd.Flags().StringVar(&apiserverProbeOptions.Resource, "resource", "pods", "Resource to list: pods, configmaps, services, events, nodes, namespaces, endpointslices, deployments or replicasets")
+	apiserverProbeCmd.Flags().Int64SliceVar(&apiserverProbeOptions.PageSizes, "page-sizes", []int64{50, 500, 0}, "Page sizes of the list benchmarks, 0 lists without a limit")
+	apiserverProbeCmd.Flags().IntVar(&apiserverProbeOptions.Iterations, "iterations", 5, "Runs of each benchmark")
+	apiserverProbeCmd.Flags().IntVar(&apiserverProbeOptions.Gets, "gets", 10, "Objects fetched by name in the get benchmark")
+	apiserverProbeCmd.Flags().IntVar(&apiserverProbeOptions.Concurrency, "concurrency", 1, "Runs in flight at once")
+	apiserverProbeCmd.Flags().Float32Var(&apiserverProbeOptions.QPS, "qps", 5, "Client-side rate limit in requests per second (client-go's default)")
+	apiserverProbeCmd.Flags().IntVar(&apiserverProbeOptions.Burst, "burst", 10, "Client-side rate limit burst (client-go's default)")
+
+	var genLoadOptions k8s.GenLoadObjectsOptions
+	var genLoadObjectsCmd = &cobra.Command{
+		Use:   "gen-load-objects",
+		Short: "Create dummy namespaces, deployments and ConfigMaps to test behavior at scale",
+		Long: `Creates --namespaces namespaces, each with --deployments deployments and --configmaps
+ConfigMaps, to see how the cluster and this tool behave with many objects. Deployments
+have zero replicas by default, so only the API server and etcd are loaded. Every object
+is labeled swissarmycli.io/load-objects=true with the run ID in swissarmycli.io/load-run,
+and "gen-load-objects cleanup" deletes the generated namespaces again.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			guardContext(cmd)
+			if err := k8s.GenerateLoadObjects(genLoadOptions); err != nil {
+				result.Fail("Error generating load objects", err)
+			}
+		},
+	}
+	genLoadObjectsCmd.Flags().IntVar(&genLoadOptions.Namespaces, "namespaces", 10, "Namespaces to create")
+	genLoadObjectsCmd.Flags().IntVar(&genLoadOptions.Deployments, "deployments", 10, "Deployments per namespace")
+	genLoadObjectsCmd.Flags().IntVar(&genLoadOptions.ConfigMaps, "configmaps", 10, "ConfigMaps per namespace")
+	genLoadObjectsCmd.Flags().Int32Var(&genLoadOptions.Replicas, "replicas", 0, "Replicas per deployment (0 creates no pods)")
+	genLoadObjectsCmd.Flags().IntVar(&genLoadOptions.ConfigMapSize, "configmap-size", 1024, "Bytes of data in each ConfigMap")
+	genLoadObjectsCmd.Flags().StringVar(&genLoadOptions.Prefix, "prefix", "sac-load", "Name prefix of the namespaces")
+	genLoadObjectsCmd.Flags().StringVar(&genLoadOptions.RunID, "run-id", "", "ID of this run, used in names and labels (default: a timestamp)")
+	genLoadObjectsCmd.Flags().StringVar(&genLoadOptions.Image, "image", k8s.DefaultLoadImage, "Image of the deployments' pods")
+	genLoadObjectsCmd.Flags().IntVar(&genLoadOptions.Parallel, "parallel", 10, "Create requests in flight at once")
+	genLoadObjectsCmd.Flags().Float32Var(&genLoadOptions.QPS, "qps", 50, "Client-side rate limit in requests per second")
+	genLoadObjectsCmd.Flags().IntVar(&genLoadOptions.Burst, "burst", 100, "Client-side rate limit burst")
+	genLoadObjectsCmd.Flags().BoolVar(&genLoadOptions.DryRun, "dry-run", false, "Show what would be created without creating anything")
+
+	var cleanupLoadOptions k8s.CleanupLoadObjectsOptions
+	var cleanupLoadObjectsCmd = &cobra.Command{
+		Use:   "cleanup",
+		Short: "Delete the namespaces created by gen-load-objects",
+		Long: `Deletes every namespace labeled swissarmycli.io/load-objects=true, or only those of
+one run with --run-id, which takes the generated objects in them along.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			guardContext(cmd)
+			if err := k8s.CleanupLoadObjects(cleanupLoadOptions); err != nil {
+				result.Fail("Error cleaning up load objects", err)
+			}
+		},
+	}
+	cleanupLoadObjectsCmd.Flags().StringVar(&cleanupLoadOptions.RunID, "run-id", "", "Only delete this run (default: all runs)")
+	cleanupLoadObjectsCmd.Flags().BoolVar(&cleanupLoadOptions.Wait, "wait", false, "Wait until the namespaces are gone")
+	cleanupLoadObjectsCmd.Flags().DurationVar(&cleanupLoadOptions.Timeout, "timeout", 10*time.Minute, "How long --wait waits")
+	cleanupLoadObjectsCmd.Flags().BoolVar(&cleanupLoadOptions.DryRun, "dry-run", false, "List the namespaces without deleting them")
+	genLoadObjectsCmd.AddCommand(cleanupLoadObjectsCmd)
+
+	// --- Sandbox command ---
+	var sandboxCmd = &cobra.Command{
+		Use:   "sandbox",
+		Short: "Create and clean up short-lived namespaces for ad-hoc testing",
+		Long: `Sandboxes are namespaces for ad-hoc testing on shared clusters. Each one gets a
+resource quota, default container requests and limits, a network policy that only
+admits traffic from inside the namespace, and an expiry time after which
+"sandbox reap" deletes it. Run the reaper on a schedule to keep clusters tidy.`,
+	}
+
+	var sandboxOptions k8s.SandboxOptions
+	var sandboxCreateCmd = &cobra.Command{
+		Use:   "create [NAME]",
+		Short: "Create a sandbox namespace (default name: sandbox-<user>-<random>)",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			guardContext(cmd)
+			var name string
+			if len(args) > 0 {
+				name = args[0]
+			}
+			if err := k8s.CreateSandbox(name, sandboxOptions); err != nil {
+				result.Fail("Error creating sandbox", err)
+			}
+		},
+	}
+	sandboxCreateCmd.Flags().DurationVar(&sandboxOptions.TTL, "ttl", 24*time.Hour, "How long until the sandbox may be reaped")
+	sandboxCreateCmd.Flags().StringVar(&sandboxOptions.Owner, "owner", "", "Owner recorded on the sandbox (default: the local user)")
+	sandboxCreateCmd.Flags().StringVar(&sandboxOptions.CPU, "cpu", "4", "Quota of CPU requests and limits")
+	sandboxCreateCmd.Flags().StringVar(&sandboxOptions.Memory, "memory", "8Gi", "Quota of memory requests and limits")
+	sandboxCreateCmd.Flags().IntVar(&sandboxOptions.Pods, "pods", 20, "Quota of pods")
+	sandboxCreateCmd.Flags().StringVar(&sandboxOptions.NetworkPolicy, "network-policy", "isolated", "Network policy: isolated (ingress only from the namespace) or none")
+
+	var sandboxDestroyCmd = &cobra.Command{
+		Use:   "destroy NAME",
+		Short: "Delete a sandbox namespace",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			guardContext(cmd)
+			if err := k8s.DestroySandbox(args[0]); err != nil {
+				result.Fail("Error destroying sandbox", err)
+			}
+		},
+	}
+
+	var sandboxListCmd = &cobra.Command{
+		Use:     "list",
+		Short:   "List sandboxes with their owner and expiry",
+		Aliases: []string{"ls"},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := k8s.ListSandboxes(); err != nil {
+				result.Fail("Error listing sandboxes", err)
+			}
+		},
+	}
+
+	var sandboxReapOptions k8s.SandboxReapOptions
+	var sandboxReapCmd = &cobra.Command{
+		Use:   "reap",
+		Short: "Delete sandboxes whose expiry has passed",
+		Long: `Deletes every sandbox whose swissarmycli.io/expires-at time has passed by more than
+--grace. Meant to run on a schedule, e.g. as a CronJob or CI job with --non-interactive.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			guardContext(cmd)
+			if err := k8s.ReapSandboxes(sandboxReapOptions); err != nil {
+				result.Fail("Error reaping sandboxes", err)
+			}
+		},
+	}
+	sandboxReapCmd.Flags().DurationVar(&sandboxReapOptions.Grace, "grace", 0, "Extra time after expiry before a sandbox is deleted")
+	sandboxReapCmd.Flags().BoolVar(&sandboxReapOptions.DryRun, "dry-run", false, "List the expired sandboxes without deleting them")
+
+	sandboxCmd.AddCommand(sandboxCreateCmd)
+	sandboxCmd.AddCommand(sandboxDestroyCmd)
+	sandboxCmd.AddCommand(sandboxListCmd)
+	sandboxCmd.AddCommand(sandboxReapCmd)
+
+	// --- Events command ---
+	var eventsCmd = &cobra.Command{
+		Use:   "events",
+		Short: "Manage Kubernetes Events",
+	}
+
+	var eventsPruneOptions k8s.EventsPruneOptions
+	var eventsPruneCmd = &cobra.Command{
+		Use:   "prune",
+		Short: "Delete old Events in bulk and report namespaces with disproportionate event volume",
+		Long: `Lists the Events page by page, reports how many each namespace holds, its share of
+the cluster and its most frequent reason and object, and flags namespaces with
+--hot-factor times the median count or more. Then deletes the Events last seen
+longer than --older-than ago, within a client-side rate limit, for clusters
+where event storms degrade etcd.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			guardContext(cmd)
+			if err := k8s.PruneEvents(eventsPruneOptions); err != nil {
+				result.Fail("Error pruning events", err)
+			}
+		},
+	}
+	eventsPruneCmd.Flags().StringVarP(&eventsPruneOptions.Namespace, "namespace", "n", "", "Namespace to prune (default: all namespaces)")
+	eventsPruneCmd.Flags().DurationVar(&eventsPruneOptions.OlderThan, "older-than", time.Hour, "Delete events last seen longer ago than this")
+	eventsPruneCmd.Flags().Int64Var(&eventsPruneOptions.PageSize, "page-size", 500, "Events listed per request")
+	eventsPruneCmd.Flags().IntVar(&eventsPruneOptions.Parallel, "parallel", 4, "Delete requests in flight at once")
+	eventsPruneCmd.Flags().Float32Var(&eventsPruneOptions.QPS, "qps", 20, "Client-side rate limit in requests per second")
+	eventsPruneCmd.Flags().IntVar(&eventsPruneOptions.Burst, "burst", 40, "Client-side rate limit burst")
+	eventsPruneCmd.Flags().Float64Var(&eventsPruneOptions.HotFactor, "hot-factor", 10, "Flag namespaces with this many times the median event count")
+	eventsPruneCmd.Flags().IntVar(&eventsPruneOptions.Top, "top", 20, "Namespaces shown in the volume report (0 for all)")
+	eventsPruneCmd.Flags().BoolVar(&eventsPruneOptions.DryRun, "dry-run", false, "Report the volume and what would be deleted without deleting")
+	eventsCmd.AddCommand(eventsPruneCmd)
+
+	var timelineOptions k8s.TimelineOptions
+	var timelineCmd = &cobra.Command{
+		Use:   "timeline [pod]",
+		Short: "Reconstruct a pod's or rollout's life from events and status",
+		Long: `Rebuilds the life of a pod from its status and events: scheduled, image pulled,
+started, probes failing, restarts, evictions, with timestamps and the time between
+each step. With --deployment it merges the timelines of every pod of the rollout,
+including replaced pods whose events are still kept, and summarizes how long each
+pod took to get ready.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var podName string
+			if len(args) > 0 {
+				podName = args[0]
+			}
+			if err := k8s.ShowTimeline(podName, timelineOptions); err != nil {
+				result.Fail("Error building timeline", err)
+			}
+		},
+	}
+	timelineCmd.Flags().StringVarP(&timelineOptions.Namespace, "namespace", "n", "default", "Namespace of the pod or deployment")
+	timelineCmd.Flags().StringVarP(&timelineOptions.Deployment, "deployment", "d", "", "Reconstruct the rollout of this deployment")
+
+	// --- PVC command ---
+	var pvcCmd = &cobra.Command{
+		Use:   "pvc",
+		Short: "Manage PersistentVolumeClaims",
+	}
+
+	var pvcResizeOptions k8s.PVCResizeOptions
+	var pvcResizeCmd = &cobra.Command{
+		Use:   "resize [name]",
+		Short: "Grow a PVC and follow the resize through to the filesystem",
+		Long: `Checks that the PVC's StorageClass allows expansion and, for EBS volumes, that
+EBS will accept another modification (one every 6 hours), then patches the PVC
+and follows the resize: the EBS modification state from AWS, the controller
+resize and the filesystem expansion by the kubelet.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			guardContext(cmd)
+			if err := k8s.ResizePVC(args[0], pvcResizeOptions); err != nil {
+				result.Fail("Error resizing PVC", err)
+			}
+		},
+	}
+	pvcResizeCmd.Flags().StringVarP(&pvcResizeOptions.Namespace, "namespace", "n", "default", "Namespace of the PVC")
+	pvcResizeCmd.Flags().StringVar(&pvcResizeOptions.Size, "size", "", "New size, e.g. 200Gi (required)")
+	pvcResizeCmd.Flags().StringVarP(&pvcResizeOptions.Region, "region", "r", "", "AWS region (default: the region of the volume's zone)")
+	pvcResizeCmd.Flags().StringVarP(&pvcResizeOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	pvcResizeCmd.Flags().DurationVar(&pvcResizeOptions.Timeout, "timeout", 10*time.Minute, "How long to wait for the resize")
+	pvcResizeCmd.MarkFlagRequired("size")
+	pvcCmd.AddCommand(pvcResizeCmd)
+
+	// --- CRD command ---
+	var crdCmd = &cobra.Command{
+		Use:   "crd",
+		Short: "Explore CustomResourceDefinitions and their instances",
+	}
+
+	var crdBrowseOptions k8s.CRDBrowseOptions
+	var crdBrowseCmd = &cobra.Command{
+		Use:   "browse [crd]",
+		Short: "List CRDs, or the instances of one using its printer columns",
+		Long: `Without an argument, lists the installed CRDs with their scope, versions (the
+storage version marked with *) and instance counts. Given a CRD by full name,
+plural, singular, kind or short name, lists its instances as a table built from
+the additionalPrinterColumns of the CRD, like kubectl does for built-in types.
+With --fields the spec and status fields of its schema are shown instead.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			target := ""
+			if len(args) == 1 {
+				target = args[0]
+			}
+			if err := k8s.BrowseCRDs(target, crdBrowseOptions); err != nil {
+				result.Fail("Error browsing CRDs", err)
+			}
+		},
+	}
+	crdBrowseCmd.Flags().StringVarP(&crdBrowseOptions.Namespace, "namespace", "n", "", "Namespace of the instances (default: all namespaces)")
+	crdBrowseCmd.Flags().StringVarP(&crdBrowseOptions.Selector, "selector", "l", "", "Only instances matching this label selector")
+	crdBrowseCmd.Flags().StringVar(&crdBrowseOptions.Group, "group", "", "Only CRDs whose API group contains this, e.g. karpenter")
+	crdBrowseCmd.Flags().StringVar(&crdBrowseOptions.Version, "version", "", "Version to read instances with (default: the storage version)")
+	crdBrowseCmd.Flags().BoolVar(&crdBrowseOptions.Wide, "wide", false, "Also show the printer columns kubectl only shows with -o wide")
+	crdBrowseCmd.Flags().BoolVar(&crdBrowseOptions.Fields, "fields", false, "Show the spec and status fields of the CRD schema instead of its instances")
+	crdBrowseCmd.Flags().BoolVar(&crdBrowseOptions.Counts, "count", true, "Count the instances of every CRD when listing them")
+	crdCmd.AddCommand(crdBrowseCmd)
+
+	var operatorsOptions k8s.OperatorsOptions
+	var operatorsCmd = &cobra.Command{
+		Use:   "operators",
+		Short: "Show whether each operator is running, leading, reconciling and keeping up with its resources",
+		Long: `Find the operator Deployments in the cluster, from OLM labels, Helm releases that
+also install CRDs, leader election leases and flags, and report for each one
+in one shot: ready replicas and restarts, the OLM ClusterServiceVersion phase,
+who holds the leader lease and whether it is still renewed, error lines in the
+leader's recent logs, controller-runtime reconcile error counters when the
+metrics port is reachable, and custom resources of its CRDs stuck failing.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.CheckOperators(operatorsOptions)
+			if err != nil {
+				result.Exit("operators", operatorsOptions.Output, "Error checking operators", err)
+			}
+		},
+	}
+	operatorsCmd.Flags().StringVarP(&operatorsOptions.Namespace, "namespace", "n", "", "Namespace of the operators (default: all namespaces)")
+	operatorsCmd.Flags().StringVar(&operatorsOptions.Operator, "operator", "", "Only operators whose Deployment name contains this")
+	operatorsCmd.Flags().DurationVar(&operatorsOptions.LogWindow, "log-window", time.Hour, "How far back to count errors in the leader's logs (0 to skip logs)")
+	operatorsCmd.Flags().DurationVar(&operatorsOptions.StuckAfter, "stuck-after", 15*time.Minute, "How long a custom resource may fail before it is reported as stuck")
+	operatorsCmd.Flags().StringVarP(&operatorsOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(operatorsCmd, "output", "table", "json")
+	operatorsCmd.Flags().StringVar(&operatorsOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
 
 	// --- Validate command ---
+	var helmChartDir string
+	var helmValueFiles []string
 	var validateCmd = &cobra.Command{
 		Use:   "validate [filepath]",
-		Short: "Validate the syntax of a file (e.g., YAML)",
-		Long:  `Validates the syntax of a specified file. Currently supports YAML.`,
-		Args:  cobra.ExactArgs(1), // Requires exactly one argument: the filepath
+		Short: "Validate the syntax of a file (e.g., YAML) or a Helm chart",
+		Long: `Validates the syntax of a specified file. Currently supports YAML.
+With --helm, renders the chart in-process and validates every produced manifest
+for YAML syntax and Kubernetes schema.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if helmChartDir != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args) // Requires exactly one argument: the filepath
+		},
 		Run: func(cmd *cobra.Command, args []string) {
+			if helmChartDir != "" {
+				fmt.Printf("Rendering Helm chart: %s\n", helmChartDir)
+				err := validator.ValidateHelmChart(helmChartDir, helmValueFiles)
+				if err != nil {
+					result.Fail("Validation Error", err)
+				}
+				fmt.Printf("'%s' renders valid manifests.\n", helmChartDir)
+				return
+			}
+
 			filePath := args[0]
 			fmt.Printf("Validating YAML file: %s\n", filePath)
 			err := validator.ValidateYAMLFile(filePath)
 			if err != nil {
 				// The error from yaml.v3 often includes line numbers
-				fmt.Fprintf(os.Stderr, "Validation Error: %v\n", err)
-				os.Exit(1)
+				result.Fail("Validation Error", err)
 			}
 			fmt.Printf("'%s' is a valid YAML file.\n", filePath)
 		},
 	}
-	var secretNamespace string
+	validateCmd.Flags().StringVar(&helmChartDir, "helm", "", "Path to a Helm chart directory to render and validate")
+	validateCmd.Flags().StringArrayVarP(&helmValueFiles, "values", "f", nil, "Values file for --helm (can be repeated)")
+	// --- Lint command ---
+	var lintOptions validator.LintOptions
+	var lintCmd = &cobra.Command{
+		Use:   "lint [path...]",
+		Short: "Run best-practice checks against manifests or live workloads",
+		Long: `Run best-practice checks against manifest files or directories, or against live
+workloads in the cluster when no path is given: missing resource requests and
+limits, missing probes, hostPath mounts, privileged containers and default
+service account usage. With --policy-dir, custom Rego policies are evaluated
+against every object too. Exits non-zero when a finding reaches --fail-on.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			lintOptions.Paths = args
+			err := validator.Lint(lintOptions)
+			if err != nil {
+				result.Exit("lint", lintOptions.Output, "Lint failed", err)
+			}
+		},
+	}
+	lintCmd.Flags().StringVarP(&lintOptions.Namespace, "namespace", "n", "", "Namespace of live workloads to check (default: all namespaces)")
+	lintCmd.Flags().StringVarP(&lintOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(lintCmd, "output", "table", "json")
+	lintCmd.Flags().StringVar(&lintOptions.PolicyDir, "policy-dir", "", "Directory of Rego policies to evaluate against every object")
+	lintCmd.Flags().StringVar(&lintOptions.FailOn, "fail-on", "error", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+
+	var revealOptions k8s.RevealSecretOptions
+	var secretUsage bool
 	var revealSecretCmd = &cobra.Command{
 		Use:   "reveal-secret [secret-name]",
 		Short: "find, decode and print a secret",
-		Long:  "This command will find the secret if namespace is not given then decodes the secret and prints it",
-		Args:  cobra.ExactArgs(1),
+		Long: `This command will find the secret if namespace is not given then decodes the secret and prints it.
+With --mask only the ends of each value are shown, and with --copy one value is
+copied to the clipboard without being printed. Every reveal is recorded in the
+audit log (~/.swissarmycli/audit.log, or $SWISSARMYCLI_AUDIT_LOG).`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			secretName := args[0]
-			err := k8s.RevealSecret(secretName, secretNamespace)
+			revealOptions.Selection.NonInteractive = nonInteractive
+			var err error
+			if secretUsage {
+				err = k8s.ShowSecretUsage(secretName, revealOptions.Namespace, revealOptions.Selection)
+			} else {
+				guardContext(cmd)
+				err = k8s.RevealSecret(secretName, revealOptions)
+			}
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error revealing secret: %v\n", err)
-				os.Exit(1)
+				result.Fail("Error revealing secret", err)
 			}
 		},
 	}
-	revealSecretCmd.Flags().StringVarP(&secretNamespace, "namespace", "n", "", "Namespace of the secret")
+	revealSecretCmd.Flags().StringVarP(&revealOptions.Namespace, "namespace", "n", "", "Namespace of the secret")
+	revealSecretCmd.Flags().IntVar(&revealOptions.Selection.Index, "index", 0, "When several namespaces match, pick the Nth (1-based, ordered by namespace) instead of prompting")
+	revealSecretCmd.Flags().BoolVar(&revealOptions.Mask, "mask", false, "Show only the first and last 4 characters of each value")
+	revealSecretCmd.Flags().StringVar(&revealOptions.Copy, "copy", "", "Copy the value of this key to the clipboard instead of printing (--copy alone picks the key)")
+	revealSecretCmd.Flags().Lookup("copy").NoOptDefVal = k8s.CopyChooseKey
+	revealSecretCmd.Flags().BoolVar(&secretUsage, "usage", false, "List the pods, workloads and ServiceAccounts referencing the secret instead of printing its data")
 	var certNamespace string
+	var certSelection ui.Selection
+	var certControlPlane bool
+	var controlPlaneCertOptions k8s.ControlPlaneCertOptions
 	var checkCertCmd = &cobra.Command{
 		Use:   "check-cert [secret-name]",
 		Short: "Check TLS certificate details and expiry",
-		Long:  "Check TLS certificate details including expiry date from a Kubernetes secret",
-		Args:  cobra.ExactArgs(1),
+		Long: `Check TLS certificate details including expiry date from a Kubernetes secret.
+
+With --control-plane, check the certificates the API server relies on instead:
+the serving certificates of admission webhooks and aggregated API services,
+verified against their caBundle, and of the kubelets, verified against the
+cluster CA. Mismatches and expired certificates are errors, certificates
+expiring soon warnings.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if certControlPlane {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		Run: func(cmd *cobra.Command, args []string) {
+			if certControlPlane {
+				if err := k8s.CheckControlPlaneCerts(controlPlaneCertOptions); err != nil {
+					result.Exit("check-cert", controlPlaneCertOptions.Output, "Error checking control plane certificates", err)
+				}
+				return
+			}
 			secretName := args[0]
-			err := k8s.CheckTLSSecret(secretName, certNamespace)
+			certSelection.NonInteractive = nonInteractive
+			err := k8s.CheckTLSSecret(secretName, certNamespace, certSelection)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error checking certificate: %v\n", err)
-				os.Exit(1)
+				result.Fail("Error checking certificate", err)
 			}
 		},
 	}
 	checkCertCmd.Flags().StringVarP(&certNamespace, "namespace", "n", "", "Namespace of the secret")
-	var costEstimateCmd = &cobra.Command{
-		Use:   "cost-estimate",
-		Short: "Estimate costs for current cluster",
-		Long:  "Analyze current cluster resources and provide cost estimation",
+	checkCertCmd.Flags().IntVar(&certSelection.Index, "index", 0, "When several namespaces match, pick the Nth (1-based, ordered by namespace) instead of prompting")
+	checkCertCmd.Flags().BoolVar(&certControlPlane, "control-plane", false, "Check the webhook, APIService and kubelet serving certificates instead of a secret")
+	checkCertCmd.Flags().BoolVar(&controlPlaneCertOptions.Kubelets, "kubelets", true, "With --control-plane, also check the kubelets (they must be reachable from here)")
+	checkCertCmd.Flags().DurationVar(&controlPlaneCertOptions.Timeout, "timeout", 5*time.Second, "With --control-plane, how long each TLS handshake may take")
+	checkCertCmd.Flags().StringVarP(&controlPlaneCertOptions.Output, "output", "o", "table", "Output format with --control-plane (table or json)")
+	outputFormats(checkCertCmd, "output", "table", "json")
+	checkCertCmd.Flags().StringVar(&controlPlaneCertOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+	var secretAgeOptions k8s.SecretAgeOptions
+	var secretAgeCmd = &cobra.Command{
+		Use:   "secret-age",
+		Short: "List secrets by age and flag ones due for rotation",
+		Long: `List secrets with their creation and last update time, flag the ones older than
+the rotation policy in the config file (90 days by default) and show the expiry
+of any TLS certificate they contain.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			err := k8s.EstimateClusterCost()
+			err := k8s.ShowSecretAge(secretAgeOptions)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error estimating cluster cost: %v\n", err)
-				os.Exit(1)
+				result.Exit("secret-age", secretAgeOptions.Output, "Error checking secret age", err)
+			}
+		},
+	}
+	secretAgeCmd.Flags().StringVarP(&secretAgeOptions.Namespace, "namespace", "n", "", "Namespace to check (default: all namespaces)")
+	secretAgeCmd.Flags().StringVarP(&secretAgeOptions.Type, "type", "t", "", "Only secrets of this type (e.g. kubernetes.io/tls)")
+	secretAgeCmd.Flags().StringVarP(&secretAgeOptions.Annotation, "annotation", "a", "", "Only secrets with this annotation (key or key=value)")
+	secretAgeCmd.Flags().StringVarP(&secretAgeOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(secretAgeCmd, "output", "table", "json")
+	secretAgeCmd.Flags().StringVar(&secretAgeOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+	var extSecretsOptions k8s.ExternalSecretsOptions
+	var extSecretsCmd = &cobra.Command{
+		Use:   "extsecrets",
+		Short: "Show sync status of ExternalSecrets and SealedSecrets",
+		Long: `Inspect ExternalSecrets (external-secrets.io) and SealedSecrets (bitnami.com) and
+report their sync status, last refresh time and any error pulling from the
+backing store, to answer why a secret is stale.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.ShowExternalSecretsStatus(extSecretsOptions)
+			if err != nil {
+				result.Exit("extsecrets", extSecretsOptions.Output, "Error checking external secrets", err)
+			}
+		},
+	}
+	extSecretsCmd.Flags().StringVarP(&extSecretsOptions.Namespace, "namespace", "n", "", "Namespace to check (default: all namespaces)")
+	extSecretsCmd.Flags().StringVarP(&extSecretsOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(extSecretsCmd, "output", "table", "json")
+	extSecretsCmd.Flags().StringVar(&extSecretsOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+	var tlsSecretOptions k8s.TLSSecretOptions
+	var createTLSSecretCmd = &cobra.Command{
+		Use:   "create-tls-secret [secret-name]",
+		Short: "Validate a certificate and create or renew a TLS secret",
+		Long: `Validate that a certificate matches its private key and that its chain is
+complete, then create or update a kubernetes.io/tls secret annotated with the
+renewal time. The certificate and chain can be read from files or pulled from
+ACM by ARN.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			guardContext(cmd)
+			err := k8s.CreateTLSSecret(args[0], tlsSecretOptions)
+			if err != nil {
+				result.Fail("Error creating TLS secret", err)
+			}
+		},
+	}
+	createTLSSecretCmd.Flags().StringVarP(&tlsSecretOptions.Namespace, "namespace", "n", "", "Namespace of the secret (default: default)")
+	createTLSSecretCmd.Flags().StringVar(&tlsSecretOptions.CertFile, "cert", "", "Path to the PEM certificate (may include the chain)")
+	createTLSSecretCmd.Flags().StringVar(&tlsSecretOptions.KeyFile, "key", "", "Path to the PEM private key")
+	createTLSSecretCmd.Flags().StringVar(&tlsSecretOptions.ChainFile, "chain", "", "Path to the PEM intermediate chain (optional)")
+	createTLSSecretCmd.Flags().StringVar(&tlsSecretOptions.ACMArn, "acm-arn", "", "Pull the certificate and chain from ACM instead of --cert")
+	createTLSSecretCmd.Flags().StringVarP(&tlsSecretOptions.Profile, "profile", "p", "", "AWS profile name for --acm-arn (optional)")
+	var makeKubeconfigOptions k8s.MakeKubeconfigOptions
+	var makeKubeconfigCmd = &cobra.Command{
+		Use:   "make-kubeconfig",
+		Short: "Issue a bound ServiceAccount token and a minimal kubeconfig for CI",
+		Long: `Request a bound token for a ServiceAccount through the TokenRequest API and
+emit a minimal kubeconfig using it, for CI systems. The token expires after
+--duration (the API server may cap it) and stops working when the
+ServiceAccount is deleted. Before the kubeconfig is written, the token is used
+to list what it may do in its namespace and which broad cluster-wide
+permissions it holds. The kubeconfig goes to stdout unless --file is given,
+in which case the report goes to stderr.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			guardContext(cmd)
+			err := k8s.MakeKubeconfig(makeKubeconfigOptions)
+			if err != nil {
+				result.Fail("Error making kubeconfig", err)
+			}
+		},
+	}
+	makeKubeconfigCmd.Flags().StringVar(&makeKubeconfigOptions.ServiceAccount, "sa", "", "ServiceAccount to issue the token for")
+	makeKubeconfigCmd.Flags().StringVar(&makeKubeconfigOptions.Namespace, "ns", "", "Alias of --namespace")
+	makeKubeconfigCmd.Flags().StringVarP(&makeKubeconfigOptions.Namespace, "namespace", "n", "", "Namespace of the ServiceAccount (default: the context's namespace)")
+	makeKubeconfigCmd.Flags().DurationVar(&makeKubeconfigOptions.Duration, "duration", 8*time.Hour, "Token lifetime")
+	makeKubeconfigCmd.Flags().StringSliceVar(&makeKubeconfigOptions.Audiences, "audience", nil, "Token audience (repeatable, default: the API server's)")
+	makeKubeconfigCmd.Flags().BoolVar(&makeKubeconfigOptions.Create, "create", false, "Create the ServiceAccount if it doesn't exist")
+	makeKubeconfigCmd.Flags().StringVar(&makeKubeconfigOptions.Server, "server", "", "API server URL to put in the kubeconfig (default: the current context's)")
+	makeKubeconfigCmd.Flags().StringVar(&makeKubeconfigOptions.File, "file", "", "Write the kubeconfig to this file (mode 0600) instead of stdout")
+	makeKubeconfigCmd.Flags().MarkHidden("ns")
+	makeKubeconfigCmd.MarkFlagRequired("sa")
+	var acmCheckOptions k8s.ACMCheckOptions
+	var acmCheckCmd = &cobra.Command{
+		Use:   "acm-check",
+		Short: "List ACM certificates and cross-check them with cluster Ingresses",
+		Long: `List ACM certificates in the region with their expiry and validation status,
+show which cluster Ingresses they serve through load balancers, and flag
+in-cluster TLS secrets that duplicate or contradict ACM-issued certificates.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.CheckACMCertificates(acmCheckOptions)
+			if err != nil {
+				result.Exit("acm-check", acmCheckOptions.Output, "Error checking ACM certificates", err)
+			}
+		},
+	}
+	acmCheckCmd.Flags().StringVarP(&acmCheckOptions.Region, "region", "r", "", "AWS region (optional, uses default configuration if not specified)")
+	acmCheckCmd.Flags().StringVarP(&acmCheckOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	acmCheckCmd.Flags().StringVarP(&acmCheckOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(acmCheckCmd, "output", "table", "json")
+	acmCheckCmd.Flags().StringVar(&acmCheckOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+	var ingressConflictsOptions k8s.IngressConflictsOptions
+	var ingressConflictsCmd = &cobra.Command{
+		Use:   "ingress-conflicts",
+		Short: "Find conflicting rules, settings and certificates of Ingresses sharing an ALB",
+		Long: `Analyze the ALB Ingresses sharing a load balancer through the
+alb.ingress.kubernetes.io/group.name annotation for problems that otherwise only
+surface as AWS Load Balancer Controller errors: duplicate or invalid group.order,
+group and listener settings the Ingresses disagree on, the same host and path
+routed to different backends, rules shadowed by a broader rule evaluated first,
+listeners over the rule quota, and HTTPS hosts no certificate of the group covers.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.CheckIngressConflicts(ingressConflictsOptions)
+			if err != nil {
+				result.Exit("ingress-conflicts", ingressConflictsOptions.Output, "Error checking ingress conflicts", err)
+			}
+		},
+	}
+	ingressConflictsCmd.Flags().StringVar(&ingressConflictsOptions.Group, "group", "", "Only check this ingress group")
+	ingressConflictsCmd.Flags().BoolVar(&ingressConflictsOptions.ACM, "acm", true, "Check the hosts of HTTPS listeners against the certificates in ACM")
+	ingressConflictsCmd.Flags().StringVarP(&ingressConflictsOptions.Region, "region", "r", "", "AWS region (optional, uses default configuration if not specified)")
+	ingressConflictsCmd.Flags().StringVarP(&ingressConflictsOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	ingressConflictsCmd.Flags().StringVarP(&ingressConflictsOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(ingressConflictsCmd, "output", "table", "json")
+	ingressConflictsCmd.Flags().StringVar(&ingressConflictsOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+	var dnsRecordsOptions k8s.DNSRecordsOptions
+	var dnsRecordsCmd = &cobra.Command{
+		Use:   "dns-records",
+		Short: "List Route53 records managed by external-dns and flag dangling ones",
+		Long: `List the Route53 records external-dns manages, found through its TXT ownership
+records, and map them back to the Services and Ingresses they were created for.
+Records whose Service or Ingress no longer exists are dangling: they keep
+pointing at a load balancer that may be deleted and its name taken over.
+Records whose resource no longer asks for the name or points elsewhere, and
+ownership records without a record, are flagged as well.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.ListDNSRecords(dnsRecordsOptions)
+			if err != nil {
+				result.Exit("dns-records", dnsRecordsOptions.Output, "Error listing DNS records", err)
+			}
+		},
+	}
+	dnsRecordsCmd.Flags().StringVar(&dnsRecordsOptions.Zone, "zone", "", "Only this hosted zone, by name or ID (default: all zones)")
+	dnsRecordsCmd.Flags().StringVar(&dnsRecordsOptions.OwnerID, "owner-id", "", "Only records owned by this external-dns --txt-owner-id")
+	dnsRecordsCmd.Flags().StringVar(&dnsRecordsOptions.TXTPrefix, "txt-prefix", "", "The --txt-prefix external-dns runs with, if any")
+	dnsRecordsCmd.Flags().StringVarP(&dnsRecordsOptions.Region, "region", "r", "", "AWS region (optional, uses default configuration if not specified)")
+	dnsRecordsCmd.Flags().StringVarP(&dnsRecordsOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	dnsRecordsCmd.Flags().StringVarP(&dnsRecordsOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(dnsRecordsCmd, "output", "table", "json")
+	dnsRecordsCmd.Flags().StringVar(&dnsRecordsOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+	var endpointCheckOptions k8s.EndpointCheckOptions
+	var endpointCheckCmd = &cobra.Command{
+		Use:   "endpoint-check",
+		Short: "Probe the public endpoints of Ingresses and LoadBalancer Services from here",
+		Long: `Resolve and probe the public endpoints of Ingresses and LoadBalancer Services
+from this machine, for a quick external reachability report: whether the host
+resolves to the load balancer, the TCP connect and TLS handshake, the presented
+certificate checked like check-cert does, and the HTTP status and latency of
+each stage.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.CheckEndpoints(endpointCheckOptions)
+			if err != nil {
+				result.Exit("endpoint-check", endpointCheckOptions.Output, "Error checking endpoints", err)
+			}
+		},
+	}
+	endpointCheckCmd.Flags().StringVarP(&endpointCheckOptions.Namespace, "namespace", "n", "", "Namespace to check (default: all namespaces)")
+	endpointCheckCmd.Flags().StringVarP(&endpointCheckOptions.Selector, "selector", "l", "", "Only Ingresses and Services matching this label selector")
+	endpointCheckCmd.Flags().StringVar(&endpointCheckOptions.Kind, "kind", "all", "What to probe: ingress, service or all")
+	endpointCheckCmd.Flags().StringVar(&endpointCheckOptions.Path, "path", "/", "Path requested from HTTP and HTTPS endpoints")
+	endpointCheckCmd.Flags().StringVar(&endpointCheckOptions.Resolver, "resolver", "", "DNS server to resolve with instead of the system resolver, e.g. 8.8.8.8")
+	endpointCheckCmd.Flags().BoolVar(&endpointCheckOptions.Internal, "internal", false, "Also probe endpoints of internal load balancers")
+	endpointCheckCmd.Flags().DurationVar(&endpointCheckOptions.Timeout, "timeout", 10*time.Second, "How long each probe may take")
+	endpointCheckCmd.Flags().DurationVar(&endpointCheckOptions.SlowAfter, "slow-after", time.Second, "Total time above which an endpoint is reported as slow (0 to turn off)")
+	endpointCheckCmd.Flags().StringVarP(&endpointCheckOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(endpointCheckCmd, "output", "table", "json")
+	endpointCheckCmd.Flags().StringVar(&endpointCheckOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+	var refsCheckOptions k8s.RefsCheckOptions
+	var refsCheckCmd = &cobra.Command{
+		Use:   "refs-check",
+		Short: "Find workloads referencing missing ConfigMaps, Secrets, PVCs or keys",
+		Long: `Find pods and workloads referencing ConfigMaps, Secrets or PVCs that don't exist,
+or ConfigMap and Secret keys that are missing, through envFrom, env, volumes and
+imagePullSecrets. These are a common cause of CreateContainerConfigError.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.CheckReferences(refsCheckOptions)
+			if err != nil {
+				result.Exit("refs-check", refsCheckOptions.Output, "Error checking references", err)
+			}
+		},
+	}
+	refsCheckCmd.Flags().StringVarP(&refsCheckOptions.Namespace, "namespace", "n", "", "Namespace to check (default: all namespaces)")
+	refsCheckCmd.Flags().StringVarP(&refsCheckOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(refsCheckCmd, "output", "table", "json")
+	refsCheckCmd.Flags().StringVar(&refsCheckOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+	var valuesCheckOptions k8s.ValuesCheckOptions
+	var valuesCheckCmd = &cobra.Command{
+		Use:   "values-check",
+		Short: "Check that the Secrets and ConfigMaps a release references exist before deploying",
+		Long: `Render a Helm chart with its values files, or build a kustomize overlay, and verify
+that every ConfigMap, Secret, key and PVC its workloads reference exists in the
+target namespace, catching "key not found" failures before install instead of at
+pod start. Objects the release creates itself count as existing. Values files
+given without --chart are scanned for secretKeyRef, configMapKeyRef, envFrom and
+existingSecret style settings instead.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.CheckValues(valuesCheckOptions)
+			if err != nil {
+				result.Exit("values-check", valuesCheckOptions.Output, "Error checking values", err)
+			}
+		},
+	}
+	valuesCheckCmd.Flags().StringVar(&valuesCheckOptions.Chart, "chart", "", "Helm chart directory to render")
+	valuesCheckCmd.Flags().StringArrayVarP(&valuesCheckOptions.ValueFiles, "values", "f", nil, "Values file (can be repeated)")
+	valuesCheckCmd.Flags().StringVarP(&valuesCheckOptions.Kustomize, "kustomize", "k", "", "Kustomize overlay directory to build")
+	valuesCheckCmd.Flags().StringVarP(&valuesCheckOptions.Namespace, "namespace", "n", "default", "Namespace the release is deployed to")
+	valuesCheckCmd.Flags().StringVarP(&valuesCheckOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(valuesCheckCmd, "output", "table", "json")
+	valuesCheckCmd.Flags().StringVar(&valuesCheckOptions.FailOn, "fail-on", "error", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+	var graphOptions k8s.GraphOptions
+	var graphCmd = &cobra.Command{
+		Use:   "graph",
+		Short: "Export the dependency graph of a namespace as DOT, Mermaid or JSON",
+		Long: `Build the dependency graph of a namespace: Services, the workloads they select,
+their pods, the ConfigMaps, Secrets and PVCs the pods use and the nodes they run on.
+References to objects that don't exist are marked missing. Render DOT with Graphviz
+(dot -Tsvg) or paste Mermaid into Markdown for documentation and impact analysis.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := k8s.ExportGraph(graphOptions); err != nil {
+				result.Fail("Error exporting graph", err)
+			}
+		},
+	}
+	graphCmd.Flags().StringVarP(&graphOptions.Namespace, "namespace", "n", "default", "Namespace to graph")
+	graphCmd.Flags().StringVar(&graphOptions.Format, "format", "dot", "Output format (dot, mermaid or json)")
+	outputFormats(graphCmd, "format", "dot", "mermaid", "json")
+	var versionsOptions k8s.VersionsOptions
+	var versionsCmd = &cobra.Command{
+		Use:   "versions [app]",
+		Short: "Show which image tag each namespace or cluster runs for an app",
+		Long: `Lists the image tag of every Deployment, StatefulSet and DaemonSet labelled
+app.kubernetes.io/name=<app> or app=<app> (or matching a full label selector)
+in every namespace, and with --contexts in every matching kubeconfig context,
+and highlights the environments running an older tag than the newest one.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := k8s.ShowVersions(args[0], versionsOptions); err != nil {
+				result.Fail("Error showing versions", err)
+			}
+		},
+	}
+	versionsCmd.Flags().StringVarP(&versionsOptions.Namespace, "namespace", "n", "", "Only this namespace (default: all namespaces)")
+	versionsCmd.Flags().StringSliceVar(&versionsOptions.Contexts, "contexts", nil, "Kubeconfig contexts to check, glob patterns allowed (repeatable, default: the current context or --context)")
+	versionsCmd.Flags().StringVar(&versionsOptions.Container, "container", "", "Only this container")
+	var cisQuickOptions k8s.CISQuickOptions
+	var cisQuickCmd = &cobra.Command{
+		Use:   "cis-quick",
+		Short: "Run a practical subset of the CIS EKS Benchmark checks",
+		Long: `Run the CIS Amazon EKS Benchmark checks that can be done from outside the nodes:
+public API endpoint access, control plane audit logging, kubelet anonymous auth,
+RBAC bindings for anonymous users, IMDSv2 enforcement on nodes and admission of
+privileged pods. Each check passes or fails with a remediation hint.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.RunCISQuick(cisQuickOptions)
+			if err != nil {
+				result.Exit("cis-quick", cisQuickOptions.Output, "Error running CIS checks", err)
+			}
+		},
+	}
+	cisQuickCmd.Flags().StringVar(&cisQuickOptions.Cluster, "cluster", "", "EKS cluster name (default: taken from the kubeconfig context)")
+	cisQuickCmd.Flags().StringVarP(&cisQuickOptions.Region, "region", "r", "", "AWS region of the cluster (default: taken from the node labels)")
+	cisQuickCmd.Flags().StringVarP(&cisQuickOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	cisQuickCmd.Flags().StringVarP(&cisQuickOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(cisQuickCmd, "output", "table", "json")
+	cisQuickCmd.Flags().StringVar(&cisQuickOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+	var pssCheckOptions k8s.PSSCheckOptions
+	var pssCheckCmd = &cobra.Command{
+		Use:   "pss-check",
+		Short: "Report which workloads would violate a stricter Pod Security Standards level",
+		Long: `Evaluate the running pods of every namespace against the baseline and restricted
+Pod Security Standards and report, per workload, the fields that a stricter level
+than the namespace's enforced one would reject. Namespaces whose workloads already
+pass a stricter level are listed with the label that enforces it, to plan a
+progressive Pod Security Admission rollout.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := k8s.CheckPodSecurity(pssCheckOptions); err != nil {
+				result.Exit("pss-check", pssCheckOptions.Output, "Error checking pod security", err)
+			}
+		},
+	}
+	pssCheckCmd.Flags().StringVarP(&pssCheckOptions.Namespace, "namespace", "n", "", "Only check this namespace (default: all namespaces)")
+	pssCheckCmd.Flags().StringVar(&pssCheckOptions.Level, "level", "restricted", "Strictest level to evaluate (baseline or restricted)")
+	pssCheckCmd.Flags().StringVarP(&pssCheckOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(pssCheckCmd, "output", "table", "json")
+	pssCheckCmd.Flags().StringVar(&pssCheckOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+	var policyStatusOptions k8s.PolicyStatusOptions
+	var policyStatusCmd = &cobra.Command{
+		Use:   "policy-status",
+		Short: "Summarize Kyverno policies and Gatekeeper constraints, their violations and denials",
+		Long: `List the Kyverno ClusterPolicies and Policies and the Gatekeeper Constraints
+installed, whether they enforce or audit and whether they are ready, their audit
+violations by namespace from the policy reports and constraint status, and the
+admission requests they denied recently, from the events both engines emit.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.ShowPolicyStatus(policyStatusOptions)
+			if err != nil {
+				result.Fail("Error showing policy status", err)
+			}
+		},
+	}
+	policyStatusCmd.Flags().StringVarP(&policyStatusOptions.Namespace, "namespace", "n", "", "Only violations and denials in this namespace (default: all namespaces)")
+	policyStatusCmd.Flags().DurationVar(&policyStatusOptions.Since, "since", time.Hour, "How far back to look for denied admission requests")
+	policyStatusCmd.Flags().IntVar(&policyStatusOptions.Limit, "limit", 20, "Most denied requests to list")
+
+	var patchStatusOptions k8s.PatchStatusOptions
+	var patchStatusCmd = &cobra.Command{
+		Use:   "patch-status",
+		Short: "Report OS patch compliance and kernel versions of the nodes, grouped by AMI",
+		Long: `Reads each EC2 node's patch compliance from SSM Patch Manager, together with its
+kernel version and SSM agent status, and groups the nodes by AMI. Node groups with
+non-compliant nodes or old AMIs are listed as needing an AMI roll, with the command
+that rolls them.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := k8s.ShowPatchStatus(patchStatusOptions); err != nil {
+				result.Fail("Error checking patch status", err)
+			}
+		},
+	}
+	patchStatusCmd.Flags().StringVarP(&patchStatusOptions.Region, "region", "r", "", "AWS region of the cluster (default: taken from the node labels)")
+	patchStatusCmd.Flags().StringVarP(&patchStatusOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	patchStatusCmd.Flags().StringVarP(&patchStatusOptions.Selector, "selector", "l", "", "Only nodes matching this label selector")
+	var exposureOptions k8s.ExposureOptions
+	var exposureCmd = &cobra.Command{
+		Use:   "exposure",
+		Short: "Audit how the cluster is exposed to the internet",
+		Long: `Report the API endpoint access configuration, LoadBalancer and NodePort Services
+and whether they are internet-facing, security group rules on the nodes that
+are open to 0.0.0.0/0 or ::/0, and Ingresses serving plain HTTP.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.ShowExposure(exposureOptions)
+			if err != nil {
+				result.Exit("exposure", exposureOptions.Output, "Error auditing exposure", err)
+			}
+		},
+	}
+	exposureCmd.Flags().StringVar(&exposureOptions.Cluster, "cluster", "", "EKS cluster name (default: taken from the kubeconfig context)")
+	exposureCmd.Flags().StringVarP(&exposureOptions.Region, "region", "r", "", "AWS region of the cluster (default: taken from the node labels)")
+	exposureCmd.Flags().StringVarP(&exposureOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	exposureCmd.Flags().StringVarP(&exposureOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(exposureCmd, "output", "table", "json")
+	exposureCmd.Flags().StringVar(&exposureOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+	var scanImagesOptions k8s.ScanImagesOptions
+	var scanImagesCmd = &cobra.Command{
+		Use:   "scan-images",
+		Short: "Scan the images running in the cluster for vulnerabilities",
+		Long: `Collect the images the running pods use, scan each distinct image once with trivy
+or grype, and report the vulnerabilities per image and per workload. Images are
+scanned by the digest the pods actually run when the runtime reports it.
+
+The scanner, its path and extra arguments come from image_scan in the config
+file; --scanner overrides it. With --max-critical the command exits with code 2
+when the images have more critical vulnerabilities in total.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := k8s.ScanImages(scanImagesOptions); err != nil {
+				result.Exit("scan-images", scanImagesOptions.Output, "Error scanning images", err)
 			}
 		},
 	}
+	scanImagesCmd.Flags().StringVarP(&scanImagesOptions.Namespace, "namespace", "n", "", "Only scan images of this namespace (default: all namespaces)")
+	scanImagesCmd.Flags().StringVar(&scanImagesOptions.Scanner, "scanner", "", "Scanner to run, trivy or grype (default: image_scan.scanner of the config file, else trivy)")
+	scanImagesCmd.Flags().IntVar(&scanImagesOptions.Parallel, "parallel", 4, "Number of images scanned at once")
+	scanImagesCmd.Flags().IntVar(&scanImagesOptions.MaxCritical, "max-critical", -1, "Exit with code 2 when the images have more critical vulnerabilities than this (negative: no limit)")
+	scanImagesCmd.Flags().StringVarP(&scanImagesOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(scanImagesCmd, "output", "table", "json")
+	scanImagesCmd.Flags().StringVar(&scanImagesOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+	var arm64CheckOptions k8s.Arm64CheckOptions
+	var arm64CheckCmd = &cobra.Command{
+		Use:   "arm64-check",
+		Short: "Report the workloads blocking a move to Graviton (arm64) nodes",
+		Long: `Read the manifest of every image the running pods use, init containers included,
+from its registry to find out whether it has an arm64 variant, and report the
+workloads that can't run on Graviton nodes. DaemonSets without arm64 images are
+errors, since their pods would fail on every arm64 node. Workloads whose node
+selector or affinity keeps them off arm64 nodes are reported too.
+
+ECR registries are read with the AWS credentials of --profile; other registries
+anonymously.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := k8s.CheckArm64(arm64CheckOptions); err != nil {
+				result.Exit("arm64-check", arm64CheckOptions.Output, "Error checking arm64 readiness", err)
+			}
+		},
+	}
+	arm64CheckCmd.Flags().StringVarP(&arm64CheckOptions.Namespace, "namespace", "n", "", "Only check images of this namespace (default: all namespaces)")
+	arm64CheckCmd.Flags().IntVar(&arm64CheckOptions.Parallel, "parallel", 8, "Number of images inspected at once")
+	arm64CheckCmd.Flags().StringVarP(&arm64CheckOptions.Profile, "profile", "p", "", "AWS profile name for ECR registries (optional, uses default configuration if not specified)")
+	arm64CheckCmd.Flags().StringVarP(&arm64CheckOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(arm64CheckCmd, "output", "table", "json")
+	arm64CheckCmd.Flags().StringVar(&arm64CheckOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+	var conntrackCheckOptions k8s.ConntrackCheckOptions
+	var conntrackCheckCmd = &cobra.Command{
+		Use:   "conntrack-check",
+		Short: "Flag nodes near conntrack saturation or dropping DNS queries",
+		Long: `Collect the conntrack table usage and the insert_failed, drop and early_drop counters
+of every node, through SSM on EC2 nodes whose agent is online or through a short-lived
+privileged hostNetwork pod otherwise, together with the cache and forwarding metrics
+of node-local-dns. Nodes near a full conntrack table or losing packets to conntrack
+races are flagged: a recurring cause of intermittent timeouts, DNS ones in particular.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			guardContext(cmd)
+			if err := k8s.CheckConntrack(conntrackCheckOptions); err != nil {
+				result.Exit("conntrack-check", conntrackCheckOptions.Output, "Error checking conntrack", err)
+			}
+		},
+	}
+	conntrackCheckCmd.Flags().StringVarP(&conntrackCheckOptions.Selector, "selector", "l", "", "Only nodes matching this label selector")
+	conntrackCheckCmd.Flags().StringVar(&conntrackCheckOptions.Method, "method", "auto", "How to collect: ssm, pod, or auto (SSM when the node's agent is online, else a pod)")
+	conntrackCheckCmd.Flags().StringVarP(&conntrackCheckOptions.Region, "region", "r", "", "AWS region of the nodes (default: taken from the node provider IDs)")
+	conntrackCheckCmd.Flags().StringVarP(&conntrackCheckOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	conntrackCheckCmd.Flags().StringVarP(&conntrackCheckOptions.Namespace, "namespace", "n", "kube-system", "Namespace of the collector pods (must admit privileged hostNetwork pods)")
+	conntrackCheckCmd.Flags().StringVar(&conntrackCheckOptions.Image, "image", k8s.DefaultConntrackImage, "Image of the collector pods")
+	conntrackCheckCmd.Flags().IntVar(&conntrackCheckOptions.Threshold, "threshold", 80, "Conntrack table usage, in percent, that is flagged")
+	conntrackCheckCmd.Flags().IntVar(&conntrackCheckOptions.Parallel, "parallel", 10, "Number of collector pods run at once")
+	conntrackCheckCmd.Flags().DurationVar(&conntrackCheckOptions.Timeout, "timeout", 2*time.Minute, "How long to wait for each node's stats")
+	conntrackCheckCmd.Flags().StringVarP(&conntrackCheckOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(conntrackCheckCmd, "output", "table", "json")
+	conntrackCheckCmd.Flags().StringVar(&conntrackCheckOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+	var iptablesStatsOptions k8s.IPTablesStatsOptions
+	var iptablesStatsCmd = &cobra.Command{
+		Use:   "iptables-stats",
+		Short: "Report per-node iptables and IPVS rule counts and kube-proxy sync latency",
+		Long: `Count the iptables rules, kube-proxy's KUBE-* rules and the IPVS virtual services of
+every node, through SSM on EC2 nodes whose agent is online or a short-lived privileged
+hostNetwork pod otherwise, and read kube-proxy's proxy mode, rule sync and network
+programming latency from its metrics. Nodes with enough rules or slow enough syncs
+to delay programming new endpoints are flagged.
+
+With --method metrics nothing runs on the nodes; the rule count then comes from
+kube-proxy's metrics, which report the nat table from Kubernetes 1.28.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Only --method metrics leaves the nodes alone
+			if iptablesStatsOptions.Method != "metrics" {
+				guardContext(cmd)
+			}
+			if err := k8s.ShowIPTablesStats(iptablesStatsOptions); err != nil {
+				result.Exit("iptables-stats", iptablesStatsOptions.Output, "Error collecting iptables stats", err)
+			}
+		},
+	}
+	iptablesStatsCmd.Flags().StringVarP(&iptablesStatsOptions.Selector, "selector", "l", "", "Only nodes matching this label selector")
+	iptablesStatsCmd.Flags().StringVar(&iptablesStatsOptions.Method, "method", "auto", "How to count rules: ssm, pod, auto (SSM when the node's agent is online, else a pod) or metrics (kube-proxy metrics only)")
+	iptablesStatsCmd.Flags().StringVarP(&iptablesStatsOptions.Region, "region", "r", "", "AWS region of the nodes (default: taken from the node provider IDs)")
+	iptablesStatsCmd.Flags().StringVarP(&iptablesStatsOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	iptablesStatsCmd.Flags().StringVarP(&iptablesStatsOptions.Namespace, "namespace", "n", "kube-system", "Namespace of the collector pods (must admit privileged hostNetwork pods)")
+	iptablesStatsCmd.Flags().StringVar(&iptablesStatsOptions.Image, "image", k8s.DefaultDebugImage, "Image of the collector pods (needs iptables-save)")
+	iptablesStatsCmd.Flags().IntVar(&iptablesStatsOptions.MaxRules, "max-rules", 20000, "Rule count per node that is flagged")
+	iptablesStatsCmd.Flags().Float64Var(&iptablesStatsOptions.MaxSync, "max-sync", 1, "kube-proxy rule sync p99, in seconds, that is flagged")
+	iptablesStatsCmd.Flags().IntVar(&iptablesStatsOptions.Parallel, "parallel", 10, "Number of collector pods run at once")
+	iptablesStatsCmd.Flags().DurationVar(&iptablesStatsOptions.Timeout, "timeout", 2*time.Minute, "How long to wait for each node's rule counts")
+	iptablesStatsCmd.Flags().StringVarP(&iptablesStatsOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(iptablesStatsCmd, "output", "table", "json")
+	iptablesStatsCmd.Flags().StringVar(&iptablesStatsOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+	var ipLookupOptions k8s.IPLookupOptions
+	var ipLookupCmd = &cobra.Command{
+		Use:   "ip-lookup [ip]",
+		Short: "Find the pod, node, Service, ENI or load balancer an IP belongs to",
+		Long: `Search the cluster's pods, nodes and Services and the VPC's network interfaces for
+an IP address and print what owns it: a pod, node, Service, instance, load
+balancer, NAT gateway, VPC endpoint or EKS control plane ENI. Useful when
+chasing an address from flow logs or a security alert.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := k8s.LookupIP(args[0], ipLookupOptions); err != nil {
+				result.Fail("Error looking up IP", err)
+			}
+		},
+	}
+	ipLookupCmd.Flags().StringVarP(&ipLookupOptions.Region, "region", "r", "", "AWS region to search (default: taken from the node labels)")
+	ipLookupCmd.Flags().StringVarP(&ipLookupOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	var ownerOptions k8s.OwnerOptions
+	var ownerCmd = &cobra.Command{
+		Use:   "owner [resource]",
+		Short: "Find the repository and team that own a resource",
+		Long: `Tell who owns a resource, given as kind/name or kind name like kubectl. The resource
+is followed up its controllers (a pod to its ReplicaSet and Deployment), and the
+Argo CD tracking-id, Flux and Helm annotations and labels found on the way are
+followed to the Application, Kustomization, HelmRelease or Helm release, with the
+repository, path and revision it deploys from.
+
+The team comes from the team labels of the config file (ownership.team_labels),
+looked up on the resource, its controllers, its GitOps object and its namespace,
+and is mapped to a contact through ownership.contacts.`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := k8s.FindOwner(args, ownerOptions); err != nil {
+				result.Fail("Error finding owner", err)
+			}
+		},
+	}
+	ownerCmd.Flags().StringVarP(&ownerOptions.Namespace, "namespace", "n", "default", "Namespace of the resource")
+	var flowLogsOptions k8s.FlowLogsOptions
+	var flowLogsCmd = &cobra.Command{
+		Use:   "flowlogs [pod|node] [name]",
+		Short: "Summarize VPC flow logs of a pod or node",
+		Long: `Query the VPC flow logs of a pod's IPs, or of all ENIs of a node, with
+CloudWatch Logs Insights and summarize the top talkers, rejected connections
+and destination ports. Addresses are shown with the pod, node or Service they
+belong to.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := args[1]
+			if args[0] == "node" {
+				name = resolveBookmark(name, bookmarks.KindNode).Target
+			}
+			if err := k8s.ShowFlowLogs(args[0], name, flowLogsOptions); err != nil {
+				result.Fail("Error querying flow logs", err)
+			}
+		},
+	}
+	flowLogsCmd.Flags().StringVarP(&flowLogsOptions.Namespace, "namespace", "n", "default", "Namespace of the pod")
+	flowLogsCmd.Flags().DurationVar(&flowLogsOptions.Since, "since", time.Hour, "How far back to query")
+	flowLogsCmd.Flags().StringVar(&flowLogsOptions.LogGroup, "log-group", "", "CloudWatch Logs group of the flow logs (default: found from the VPC's flow logs)")
+	flowLogsCmd.Flags().StringVarP(&flowLogsOptions.Region, "region", "r", "", "AWS region (default: taken from the node labels)")
+	flowLogsCmd.Flags().StringVarP(&flowLogsOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	flowLogsCmd.Flags().IntVar(&flowLogsOptions.Limit, "limit", 10, "Rows per table")
+	var costEstimateCmd = &cobra.Command{
+		Use:   "cost-estimate",
+		Short: "Estimate costs for current cluster",
+		Long:  "Analyze current cluster resources and provide cost estimation",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.EstimateClusterCost()
+			if err != nil {
+				result.Fail("Error estimating cluster cost", err)
+			}
+		},
+	}
+
+	var teamReportOptions k8s.TeamReportOptions
+	var teamReportCmd = &cobra.Command{
+		Use:   "team-report",
+		Short: "Total pods, requests, usage, storage, load balancers and cost per team",
+		Long: `Group namespaces by a team label and total per team the running pods, their CPU
+and memory requests and usage, PVC storage, LoadBalancer services and the
+estimated monthly cost, priced from the same table as cost-estimate. A pod is
+charged its node's price split by its share of the node's allocatable CPU and
+memory; capacity no pod requests is reported as unallocated.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := k8s.ShowTeamReport(teamReportOptions); err != nil {
+				result.Fail("Error building team report", err)
+			}
+		},
+	}
+	teamReportCmd.Flags().StringVar(&teamReportOptions.Label, "label", "team", "Namespace label holding the team")
+	teamReportCmd.Flags().StringVarP(&teamReportOptions.Output, "output", "o", "table", "Output format (table, csv or json)")
+	outputFormats(teamReportCmd, "output", "table", "csv", "json")
+
+	// --- Tag audit command ---
+	var tagAuditOptions k8s.TagAuditOptions
+	var tagAuditCmd = &cobra.Command{
+		Use:   "tag-audit",
+		Short: "Find cluster resources missing the required cost-allocation tags",
+		Long: `Check that the EC2 instances of the cluster's nodes, their EBS volumes and those of
+PersistentVolumes, and the load balancers of LoadBalancer Services carry the
+cost-allocation tags of the tagging policy in the config file, and list the ones
+that don't with their estimated monthly cost.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.AuditCostTags(tagAuditOptions)
+			if err != nil {
+				result.Exit("tag-audit", tagAuditOptions.Output, "Error auditing tags", err)
+			}
+		},
+	}
+	tagAuditCmd.Flags().StringVarP(&tagAuditOptions.Region, "region", "r", "", "AWS region of the cluster (default: taken from the node labels)")
+	tagAuditCmd.Flags().StringVarP(&tagAuditOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	tagAuditCmd.Flags().StringSliceVar(&tagAuditOptions.Tags, "tags", nil, "Required tag keys (default: tagging.required_tags of the config file)")
+	tagAuditCmd.Flags().StringVarP(&tagAuditOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(tagAuditCmd, "output", "table", "json")
+	tagAuditCmd.Flags().StringVar(&tagAuditOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+
+	// --- RI Coverage command ---
+	var riCoverageOptions k8s.RICoverageOptions
+	var riCoverageCmd = &cobra.Command{
+		Use:   "ri-coverage",
+		Short: "Report Reserved Instance and Savings Plans coverage of the cluster's nodes",
+		Long: `Compare the cluster's running instance types against the Reserved Instance and
+Savings Plans coverage reported by Cost Explorer, and show the coverage percentage
+and estimated uncovered on-demand spend per instance type.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.ShowRICoverage(riCoverageOptions)
+			if err != nil {
+				result.Fail("Error checking RI coverage", err)
+			}
+		},
+	}
+	riCoverageCmd.Flags().StringVarP(&riCoverageOptions.Region, "region", "r", "", "AWS region of the cluster (default: taken from the node labels)")
+	riCoverageCmd.Flags().StringVarP(&riCoverageOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	riCoverageCmd.Flags().IntVar(&riCoverageOptions.Days, "days", 30, "Number of days of Cost Explorer data to look at")
+
+	// --- Fargate status command ---
+	var fargateStatusOptions k8s.FargateStatusOptions
+	var fargateStatusCmd = &cobra.Command{
+		Use:   "fargate-status",
+		Short: "Show EKS Fargate profiles and the cost of the pods running on Fargate",
+		Long: `List the cluster's Fargate profiles with the namespaces and labels they select,
+and the pods running on Fargate with the vCPU and memory size Fargate rounds their
+requests up to and the resulting cost per workload.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.ShowFargateStatus(fargateStatusOptions)
+			if err != nil {
+				result.Fail("Error showing Fargate status", err)
+			}
+		},
+	}
+	fargateStatusCmd.Flags().StringVar(&fargateStatusOptions.Cluster, "cluster", "", "EKS cluster name (default: taken from the kubeconfig context)")
+	fargateStatusCmd.Flags().StringVarP(&fargateStatusOptions.Region, "region", "r", "", "AWS region of the cluster (default: taken from the node labels)")
+	fargateStatusCmd.Flags().StringVarP(&fargateStatusOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+
+	var podDensityOptions k8s.PodDensityOptions
 	var podDensityCmd = &cobra.Command{
 		Use:   "pod-density",
 		Short: "Display pod density across nodes with deployment/daemonset/statefulset information",
-		Long:  "Show the number of pods per node along with their deployment/daemonset/statefulset names, resource requests and limits using an interactive table view",
+		Long: `Show the number of pods per node along with their deployment/daemonset/statefulset names, resource requests and limits.
+With --columns or --template, one row is printed per owner on each node.
+
+--output json prints the nodes and a flat row per owner on each node, ready for a
+Grafana JSON data source; --save writes the same to a file. --compare shows what
+changed since a saved run: pods per node, pods moved and requests grown per owner.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			err := k8s.ShowPodDensity()
+			err := k8s.ShowPodDensity(podDensityOptions)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error displaying pod density: %v\n", err)
-				os.Exit(1)
+				result.Fail("Error displaying pod density", err)
+			}
+		},
+	}
+
+	podDensityCmd.Flags().StringVar(&podDensityOptions.Custom.Columns, "columns", "", "Comma separated fields to print, each optionally HEADER:field (e.g. node,name,pod_count)")
+	podDensityCmd.Flags().StringVar(&podDensityOptions.Custom.Template, "template", "", "Go template printed for each owner on each node")
+	podDensityCmd.Flags().StringVarP(&podDensityOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(podDensityCmd, "output", "table", "json")
+	podDensityCmd.Flags().StringVar(&podDensityOptions.Save, "save", "", "Also write this run to a JSON file, for a later --compare")
+	podDensityCmd.Flags().StringVar(&podDensityOptions.Compare, "compare", "", "Show per-node and per-owner deltas against a run saved with --save")
+
+	var rebalanceOptions k8s.RebalanceOptions
+	var rebalanceCmd = &cobra.Command{
+		Use:   "rebalance",
+		Short: "Plan, and optionally run, pod evictions that even out node load",
+		Long: `Finds nodes whose CPU or memory requests are far above the cluster mean and
+proposes evictions of Deployment pods that can be rescheduled on less loaded
+nodes without risk: the Deployment keeps another ready replica, PodDisruptionBudgets
+allow the disruption, the pod keeps no local data and a less loaded node matches
+its selectors, tolerations and anti-affinity. With --execute the plan is carried
+out one eviction at a time, asking before each and waiting for the Deployment
+to be ready again.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if rebalanceOptions.Execute {
+				guardContext(cmd)
+			}
+			rebalanceOptions.NonInteractive = nonInteractive
+			if err := k8s.Rebalance(rebalanceOptions); err != nil {
+				result.Fail("Error rebalancing", err)
+			}
+		},
+	}
+	rebalanceCmd.Flags().Float64Var(&rebalanceOptions.Threshold, "threshold", 25, "Percentage points above the mean load at which a node counts as overloaded")
+	rebalanceCmd.Flags().IntVar(&rebalanceOptions.MaxEvictions, "max-evictions", 10, "Most evictions in the plan")
+	rebalanceCmd.Flags().BoolVar(&rebalanceOptions.Execute, "execute", false, "Evict the planned pods step by step")
+	rebalanceCmd.Flags().BoolVar(&rebalanceOptions.Yes, "yes", false, "With --execute, run every step without asking")
+	rebalanceCmd.Flags().DurationVar(&rebalanceOptions.Timeout, "timeout", 5*time.Minute, "How long to wait for each Deployment to be ready again")
+
+	var migrateOptions k8s.MigrateWorkloadsOptions
+	var migrateWorkloadsCmd = &cobra.Command{
+		Use:   "migrate-workloads",
+		Short: "Move the pods of one node group to another",
+		Long: `Plans, and with --execute carries out, moving every pod off the nodes of one
+EKS managed node group, Karpenter NodePool or eksctl node group onto another.
+Each pod is checked against the target nodes' labels and taints; Deployments
+and StatefulSets whose nodeSelector names the source group, or that don't
+tolerate the target group's taints, are patched with --update-workloads.
+Execution patches those workloads, cordons the source nodes and evicts their
+pods one node at a time, honouring PodDisruptionBudgets and waiting for the
+owners to be ready again, asking before each step. Rollback commands are
+printed at the end.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if migrateOptions.Execute {
+				guardContext(cmd)
+			}
+			migrateOptions.NonInteractive = nonInteractive
+			if err := k8s.MigrateWorkloads(migrateOptions); err != nil {
+				result.Fail("Error migrating workloads", err)
+			}
+		},
+	}
+	migrateWorkloadsCmd.Flags().StringVar(&migrateOptions.From, "from-nodegroup", "", "Node group or NodePool the pods leave (required)")
+	migrateWorkloadsCmd.Flags().StringVar(&migrateOptions.To, "to-nodegroup", "", "Node group or NodePool the pods move to (required)")
+	migrateWorkloadsCmd.Flags().BoolVar(&migrateOptions.UpdateWorkloads, "update-workloads", false, "Patch nodeSelectors and tolerations that keep pods off the target group")
+	migrateWorkloadsCmd.Flags().BoolVar(&migrateOptions.Execute, "execute", false, "Carry out the migration step by step")
+	migrateWorkloadsCmd.Flags().BoolVar(&migrateOptions.Yes, "yes", false, "With --execute, run every step without asking")
+	migrateWorkloadsCmd.Flags().DurationVar(&migrateOptions.Timeout, "timeout", 10*time.Minute, "How long to wait for each node's pods to be evicted and their owners ready again")
+
+	var dsOverheadCmd = &cobra.Command{
+		Use:   "ds-overhead",
+		Short: "Show DaemonSet resource overhead per node and across the fleet",
+		Long:  "Sum CPU and memory requests of all DaemonSet pods per node, show them as a percentage of allocatable and estimate their monthly cost using the embedded pricing data",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.ShowDaemonSetOverhead()
+			if err != nil {
+				result.Fail("Error displaying DaemonSet overhead", err)
+			}
+		},
+	}
+
+	var recommendOptions k8s.RecommendInstanceTypeOptions
+	var recommendInstanceTypeCmd = &cobra.Command{
+		Use:   "recommend-instance-type [NODE_GROUP]",
+		Short: "Suggest cheaper or better fitting instance types for a node group",
+		Long: `Analyses the CPU and memory requests of the pods running on a node group (EKS
+managed node group, Karpenter NodePool or eksctl node group), sizes each instance type
+of the candidate families, Graviton included, to fit them with the group's DaemonSets,
+and lists the cheapest fits with projected monthly savings from the pricing data`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.RecommendInstanceType(args[0], recommendOptions)
+			if err != nil {
+				result.Fail("Error recommending instance types", err)
+			}
+		},
+	}
+	recommendInstanceTypeCmd.Flags().StringSliceVar(&recommendOptions.Families, "families", nil, "Instance families to consider (default: m, c and r families from the 5th generation on, Graviton included)")
+	recommendInstanceTypeCmd.Flags().Float64Var(&recommendOptions.TargetUtilization, "target-utilization", 0.8, "Share of allocatable CPU and memory the requests may fill")
+	recommendInstanceTypeCmd.Flags().IntVar(&recommendOptions.MinNodes, "min-nodes", 2, "Fewest nodes to recommend, for availability")
+	recommendInstanceTypeCmd.Flags().IntVar(&recommendOptions.Top, "top", 10, "Number of instance types to show")
+	recommendInstanceTypeCmd.Flags().StringVarP(&recommendOptions.Region, "region", "r", "", "AWS region (default: region of the nodes)")
+	recommendInstanceTypeCmd.Flags().StringVarP(&recommendOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+
+	var topologyCheckOptions k8s.TopologyCheckOptions
+	var topologyCheckCmd = &cobra.Command{
+		Use:   "topology-check",
+		Short: "Audit pod anti-affinity and topology spread of deployments",
+		Long: `Evaluates pod anti-affinity and topologySpreadConstraints for each Deployment,
+reports workloads whose replicas all landed in a single AZ or on a single node,
+and checks whether the constraints can be satisfied with the current node pool.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.CheckTopology(topologyCheckOptions)
+			if err != nil {
+				result.Exit("topology-check", topologyCheckOptions.Output, "Error checking topology", err)
+			}
+		},
+	}
+	topologyCheckCmd.Flags().StringVarP(&topologyCheckOptions.Namespace, "namespace", "n", "", "Namespace to check (default: all namespaces)")
+	topologyCheckCmd.Flags().StringVarP(&topologyCheckOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(topologyCheckCmd, "output", "table", "json")
+	topologyCheckCmd.Flags().StringVar(&topologyCheckOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+
+	var criticalityCheckOptions k8s.CriticalityCheckOptions
+	var criticalityCheckCmd = &cobra.Command{
+		Use:   "criticality-check",
+		Short: "Verify that critical deployments have a warm standby",
+		Long: `Checks every Deployment listed under criticality.deployments in the config file
+for enough ready replicas spread over enough availability zones, a
+PodDisruptionBudget, resource requests, a priority class, and readiness and
+liveness probes, and prints one compliance report. Meant to run from a weekly
+cron with --output json and --fail-on.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.CheckCriticalWorkloads(criticalityCheckOptions)
+			if err != nil {
+				result.Exit("criticality-check", criticalityCheckOptions.Output, "Error checking critical workloads", err)
+			}
+		},
+	}
+	criticalityCheckCmd.Flags().IntVar(&criticalityCheckOptions.MinReplicas, "min-replicas", 0, "Minimum ready replicas (default: criticality.min_replicas of the config file, or 3)")
+	criticalityCheckCmd.Flags().IntVar(&criticalityCheckOptions.MinZones, "min-zones", 0, "Minimum zones the ready replicas must span (default: criticality.min_zones of the config file, or 2)")
+	criticalityCheckCmd.Flags().StringVarP(&criticalityCheckOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(criticalityCheckCmd, "output", "table", "json")
+	criticalityCheckCmd.Flags().StringVar(&criticalityCheckOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+
+	var azImpactOptions k8s.AZImpactOptions
+	var azImpactCmd = &cobra.Command{
+		Use:   "az-impact [zone]",
+		Short: "Simulate the loss of an availability zone",
+		Long: `Simulates losing every node in an availability zone and reports workloads that
+would lose replicas, PodDisruptionBudgets that would be violated, persistent volumes
+bound to the zone, and whether the remaining nodes have capacity for the displaced pods.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.SimulateZoneFailure(args[0], azImpactOptions)
+			if err != nil {
+				result.Exit("az-impact", azImpactOptions.Output, "Error simulating zone failure", err)
+			}
+		},
+	}
+	azImpactCmd.Flags().StringVarP(&azImpactOptions.Output, "output", "o", "table", "Output format (table or json)")
+	outputFormats(azImpactCmd, "output", "table", "json")
+	azImpactCmd.Flags().StringVar(&azImpactOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 (error, warning, info or none)")
+
+	var simulateTerminationOptions k8s.SimulateTerminationOptions
+	var simulateTerminationCmd = &cobra.Command{
+		Use:   "simulate-termination [node]",
+		Short: "Dry-run a spot interruption or ASG scale-in of a node",
+		Long: `Walk through what would happen if the node received a spot interruption or was
+scaled in by its ASG, without changing anything: the pods a termination handler
+would evict and their risks, the PodDisruptionBudgets that would hold up the
+drain, where the scheduler could place the pods, and the load balancer targets
+to drain. Everything is measured against the time the event leaves, the two
+minute spot notice or the ASG's termination lifecycle hook.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			bookmark := resolveBookmark(args[0], bookmarks.KindNode)
+			if simulateTerminationOptions.Region == "" {
+				simulateTerminationOptions.Region = bookmark.Region
+			}
+			err := k8s.SimulateTermination(bookmark.Target, simulateTerminationOptions)
+			if err != nil {
+				result.Fail("Error simulating termination", err)
+			}
+		},
+	}
+	simulateTerminationCmd.Flags().StringVar(&simulateTerminationOptions.Event, "event", "spot", "Event to simulate (spot or scale-in)")
+	simulateTerminationCmd.Flags().StringVarP(&simulateTerminationOptions.Region, "region", "r", "", "AWS region (default: taken from the node labels)")
+	simulateTerminationCmd.Flags().StringVarP(&simulateTerminationOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+
+	// --- LB command ---
+	var lbCmd = &cobra.Command{
+		Use:   "lb",
+		Short: "Work with the ALB and NLB target groups in front of the cluster",
+	}
+
+	var lbDrainOptions k8s.LBDrainOptions
+	var lbDrainTargetCmd = &cobra.Command{
+		Use:   "drain-target [instance|node|pod-ip]",
+		Short: "Deregister a target from its target groups and wait for it to drain",
+		Long: `Finds every ALB and NLB target group an instance, node or IP address is registered
+in, deregisters it and waits until connection draining has finished in each of
+them, then reports that it is safe to terminate. Use it during manual node
+replacement to avoid 5xx errors from connections cut mid-request. Nodes are
+matched through their instance ID. IP targets managed by the AWS Load Balancer
+Controller are registered again while their pod is ready.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if !lbDrainOptions.DryRun {
+				guardContext(cmd)
+			}
+			bookmark := resolveBookmark(args[0], bookmarks.KindNode)
+			if lbDrainOptions.Region == "" {
+				lbDrainOptions.Region = bookmark.Region
+			}
+			if err := k8s.DrainLBTarget(bookmark.Target, lbDrainOptions); err != nil {
+				result.Fail("Error draining target", err)
+			}
+		},
+	}
+	lbDrainTargetCmd.Flags().StringVarP(&lbDrainOptions.Region, "region", "r", "", "AWS region (default: taken from the node labels)")
+	lbDrainTargetCmd.Flags().StringVarP(&lbDrainOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	lbDrainTargetCmd.Flags().DurationVar(&lbDrainOptions.Timeout, "timeout", 0, "How long to wait for draining (default: the longest deregistration delay plus a minute)")
+	lbDrainTargetCmd.Flags().BoolVar(&lbDrainOptions.DryRun, "dry-run", false, "List the registrations without deregistering")
+	lbCmd.AddCommand(lbDrainTargetCmd)
+
+	// --- Rotate Nodes command ---
+	var rotateOptions k8s.RotateNodesOptions
+	var rotateNodesCmd = &cobra.Command{
+		Use:   "rotate-nodes [ASG_NAME]",
+		Short: "Cycle the nodes of an Auto Scaling Group one batch at a time",
+		Long: `Rotates every node of an AWS Auto Scaling Group: cordon, drain (respecting
+PodDisruptionBudgets), terminate through the ASG and wait for the replacement to
+become Ready before continuing with the next batch. Progress is saved to a state
+file so an interrupted rotation can be resumed by running the same command again.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			guardContext(cmd)
+			bookmark := resolveBookmark(args[0], bookmarks.KindASG)
+			rotateOptions.ASGName = bookmark.Target
+			if rotateOptions.Region == "" {
+				rotateOptions.Region = bookmark.Region
+			}
+			err := k8s.RotateNodes(rotateOptions)
+			if err != nil {
+				result.Fail("Error rotating nodes", err)
+			}
+		},
+	}
+	rotateNodesCmd.Flags().StringVarP(&rotateOptions.Region, "region", "r", "", "AWS region (optional, uses default configuration if not specified)")
+	rotateNodesCmd.Flags().StringVarP(&rotateOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+	rotateNodesCmd.Flags().IntVar(&rotateOptions.MaxUnavailable, "max-unavailable", 1, "Number of nodes rotated at the same time")
+	rotateNodesCmd.Flags().BoolVar(&rotateOptions.Decrement, "decrement", false, "Decrement desired capacity instead of replacing terminated instances")
+	rotateNodesCmd.Flags().StringVar(&rotateOptions.StateFile, "state-file", "", "Progress file used to resume an interrupted rotation (default: .rotate-nodes-<ASG_NAME>.json)")
+	rotateNodesCmd.Flags().DurationVar(&rotateOptions.Timeout, "timeout", 15*time.Minute, "Timeout for each drain and replacement wait")
+	rotateNodesCmd.Flags().BoolVar(&rotateOptions.UI, "ui", false, "Show progress in the interactive ASG monitor")
+
+	// --- Hibernate and Resume commands ---
+	var hibernateOptions k8s.HibernateOptions
+	resolveASGArgs := func(args []string) []string {
+		names := make([]string, len(args))
+		for i, arg := range args {
+			bookmark := resolveBookmark(arg, bookmarks.KindASG)
+			names[i] = bookmark.Target
+			if hibernateOptions.Region == "" {
+				hibernateOptions.Region = bookmark.Region
+			}
+		}
+		return names
+	}
+	var hibernateCmd = &cobra.Command{
+		Use:   "hibernate [ASG_NAME...]",
+		Short: "Scale ASGs and node groups to zero, e.g. overnight for dev clusters",
+		Long: `Scale the named Auto Scaling Groups, and with --cluster the cluster's EKS managed
+node groups, to zero. Their sizes are recorded in a tag so resume can restore them,
+and the projected savings are priced from the cost estimate table.
+With --schedule, a recurring scheduled action is created on each ASG instead.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			guardContext(cmd)
+			err := k8s.Hibernate(resolveASGArgs(args), hibernateOptions)
+			if err != nil {
+				result.Fail("Error hibernating", err)
+			}
+		},
+	}
+	var resumeCmd = &cobra.Command{
+		Use:   "resume [ASG_NAME...]",
+		Short: "Restore ASGs and node groups scaled to zero by hibernate",
+		Long: `Scale ASGs and EKS managed node groups hibernated with hibernate back to their
+recorded sizes. With --schedule, a recurring scheduled action restoring them is
+created on each ASG instead, e.g. to bring a dev cluster back every morning.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			guardContext(cmd)
+			err := k8s.Resume(resolveASGArgs(args), hibernateOptions)
+			if err != nil {
+				result.Fail("Error resuming", err)
+			}
+		},
+	}
+	for _, cmd := range []*cobra.Command{hibernateCmd, resumeCmd} {
+		cmd.Flags().StringVar(&hibernateOptions.Cluster, "cluster", "", "EKS cluster whose managed node groups to include")
+		cmd.Flags().StringSliceVar(&hibernateOptions.NodeGroups, "nodegroup", nil, "Only these managed node groups of --cluster (repeatable)")
+		cmd.Flags().StringVar(&hibernateOptions.Schedule, "schedule", "", "Cron expression (e.g. \"0 20 * * 1-5\") for a recurring scheduled action instead of acting now")
+		cmd.Flags().StringVar(&hibernateOptions.TimeZone, "timezone", "", "IANA time zone of --schedule (default: UTC)")
+		cmd.Flags().StringVarP(&hibernateOptions.Region, "region", "r", "", "AWS region (optional, uses default configuration if not specified)")
+		cmd.Flags().StringVarP(&hibernateOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+		cmd.Flags().BoolVar(&hibernateOptions.DryRun, "dry-run", false, "Show what would change without changing anything")
+	}
+
+	// --- Pending Watch command ---
+	var pendingWatchOptions k8s.PendingWatchOptions
+	var pendingWatchCmd = &cobra.Command{
+		Use:   "pending-watch",
+		Short: "Watch for pending pods and explain why they can't be scheduled",
+		Long: `Watch for pods the scheduler can't place and print the scheduler's reasons together
+with a plain explanation (capacity, taints, volume zone conflicts, affinity, quota).
+Optionally sends each explanation to a Slack incoming webhook. With --once it
+reports the pods pending now and exits, which also supports --output json and
+--fail-on.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.WatchPendingPods(pendingWatchOptions)
+			if err != nil {
+				result.Exit("pending-watch", pendingWatchOptions.Output, "Error watching pending pods", err)
+			}
+		},
+	}
+	pendingWatchCmd.Flags().StringVarP(&pendingWatchOptions.Namespace, "namespace", "n", "", "Namespace to watch (default: all namespaces)")
+	pendingWatchCmd.Flags().StringVar(&pendingWatchOptions.SlackWebhook, "slack-webhook", "", "Slack incoming webhook URL to notify")
+	pendingWatchCmd.Flags().BoolVar(&pendingWatchOptions.Once, "once", false, "Report the pods pending now and exit instead of watching")
+	pendingWatchCmd.Flags().StringVarP(&pendingWatchOptions.Output, "output", "o", "table", "Output format (table or json, json needs --once)")
+	outputFormats(pendingWatchCmd, "output", "table", "json")
+	pendingWatchCmd.Flags().StringVar(&pendingWatchOptions.FailOn, "fail-on", "none", "Lowest severity that causes exit code 2 with --once (error, warning, info or none)")
+
+	// --- Health command ---
+	var healthCmd = &cobra.Command{
+		Use:   "health",
+		Short: "Show a weighted health score for the cluster",
+		Long: `Aggregate node readiness, non-running pods, crashloops, TLS certificate expiry,
+subnet IP headroom and PDB coverage into a single weighted health score, with a
+one-screen summary and hints on which command to run next. Meant as the first
+command to run during on-call triage.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.ShowClusterHealth()
+			if err != nil {
+				result.Fail("Error checking cluster health", err)
+			}
+		},
+	}
+
+	// --- Doctor command ---
+	var doctorOptions k8s.DoctorOptions
+	var doctorCmd = &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that the local environment can run the commands",
+		Long: `Check the local environment and print the steps to fix what is wrong: whether
+this release matches the machine's architecture (an amd64 build under Rosetta on
+Apple silicon), the aws CLI, session-manager-plugin and kubectl versions and
+whether they are built for the same architecture, the AWS credentials and
+region, the kubeconfig and the connection to the cluster, kubectl's version
+skew from the server, metrics-server, the RBAC permissions the commands need and
+the IAM permissions, simulated against the caller's policies.
+Exits with status 1 when a check fails.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := k8s.RunDoctor(doctorOptions)
+			if err != nil {
+				result.Fail("Error", err)
 			}
 		},
 	}
+	doctorCmd.Flags().StringVarP(&doctorOptions.Profile, "profile", "p", "", "AWS profile to check")
+	doctorCmd.Flags().StringVarP(&doctorOptions.Region, "region", "r", "", "AWS region to check (default: the profile's region)")
+	doctorCmd.Flags().DurationVar(&doctorOptions.Timeout, "timeout", 10*time.Second, "How long to wait for the API server")
+
+	// --- Bookmarks command ---
+	var bookmarksCmd = &cobra.Command{
+		Use:   "bookmarks",
+		Short: "Save aliases for frequently used clusters, ASGs, nodes and namespaces",
+		Long: `Manage bookmarks: short aliases for clusters, ASGs, nodes and namespaces that commands
+accept as @alias, e.g. "swissarmycli connect cluster @prod-payments".
+Bookmarks are stored in ~/.swissarmycli/bookmarks.yaml, or shared with the team
+through the S3 bucket or DynamoDB table set under "bookmarks" in the config file.`,
+		Aliases: []string{"bm"},
+	}
+
+	var bookmarkRegion string
+	var bookmarkDescription string
+	var bookmarksAddCmd = &cobra.Command{
+		Use:   "add [alias] [kind] [target]",
+		Short: "Save or update a bookmark (kind: cluster, asg, node or namespace)",
+		Args:  cobra.ExactArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			location, err := bookmarks.Add(bookmarks.Bookmark{
+				Alias:       args[0],
+				Kind:        args[1],
+				Target:      args[2],
+				Region:      bookmarkRegion,
+				Description: bookmarkDescription,
+			})
+			if err != nil {
+				result.Fail("Error saving bookmark", err)
+			}
+			fmt.Printf("✅ Saved @%s → %s %s (%s)\n", args[0], args[1], args[2], location)
+		},
+	}
+	bookmarksAddCmd.Flags().StringVarP(&bookmarkRegion, "region", "r", "", "AWS region of the target, used when the command's --region is not set")
+	bookmarksAddCmd.Flags().StringVarP(&bookmarkDescription, "description", "d", "", "Description shown in the bookmark list")
+
+	var bookmarksListCmd = &cobra.Command{
+		Use:     "list",
+		Short:   "List bookmarks, pulling the shared ones if configured",
+		Aliases: []string{"ls"},
+		Run: func(cmd *cobra.Command, args []string) {
+			all, location, err := bookmarks.List()
+			if err != nil {
+				result.Fail("Error listing bookmarks", err)
+			}
+			if len(all) == 0 {
+				fmt.Printf("No bookmarks in %s.\n", location)
+				return
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ALIAS\tKIND\tTARGET\tREGION\tDESCRIPTION")
+			for _, bookmark := range all {
+				fmt.Fprintf(w, "@%s\t%s\t%s\t%s\t%s\n", bookmark.Alias, bookmark.Kind, bookmark.Target, bookmark.Region, bookmark.Description)
+			}
+			w.Flush()
+			fmt.Printf("\nSource: %s\n", location)
+		},
+	}
+
+	var bookmarksRemoveCmd = &cobra.Command{
+		Use:     "remove [alias]",
+		Short:   "Delete a bookmark",
+		Aliases: []string{"rm"},
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			location, err := bookmarks.Remove(args[0])
+			if err != nil {
+				result.Fail("Error removing bookmark", err)
+			}
+			fmt.Printf("✅ Removed @%s (%s)\n", strings.TrimPrefix(args[0], bookmarks.Prefix), location)
+		},
+	}
+
+	bookmarksCmd.AddCommand(bookmarksAddCmd)
+	bookmarksCmd.AddCommand(bookmarksListCmd)
+	bookmarksCmd.AddCommand(bookmarksRemoveCmd)
+
+	// --- Config command ---
+	var configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Encrypt and decrypt sensitive values of the config file",
+		Long: `Encrypt sensitive values of ~/.swissarmycli/config.yaml at rest, with a key kept
+in the OS keychain or with KMS envelope encryption, so the config can be synced
+between machines. Encrypted values are decrypted transparently whenever a
+command reads the config.`,
+	}
+
+	var configEncryptOptions config.EncryptOptions
+	var configEncryptCmd = &cobra.Command{
+		Use:   "encrypt [field...]",
+		Short: "Encrypt values of the config file in place, e.g. ownership.contacts",
+		Long: `Encrypt the values at the given dotted paths of the config file in place. A path
+to a mapping or list encrypts every string under it. With --kms the values are
+sealed with a data key from that KMS key, readable on any machine allowed to
+decrypt with it; otherwise with a key kept in the OS keychain (macOS security,
+or secret-tool on Linux), created on first use.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			configEncryptOptions.Method = config.MethodKeychain
+			if configEncryptOptions.KMSKey != "" {
+				configEncryptOptions.Method = config.MethodKMS
+			}
+			changed, err := config.EncryptFields(args, configEncryptOptions)
+			if err != nil {
+				result.Fail("Error encrypting config values", err)
+			}
+			if len(changed) == 0 {
+				fmt.Println("Nothing to encrypt, the values are already encrypted.")
+				return
+			}
+			for _, path := range changed {
+				fmt.Printf("🔒 %s\n", path)
+			}
+			fmt.Printf("✅ Encrypted %d values in %s with %s\n", len(changed), config.Path(), configEncryptOptions.Method)
+		},
+	}
+	configEncryptCmd.Flags().StringVar(&configEncryptOptions.KMSKey, "kms", "", "KMS key ID, ARN or alias to encrypt with (default: the OS keychain)")
+	configEncryptCmd.Flags().StringVarP(&configEncryptOptions.Region, "region", "r", "", "Region of the KMS key (default: the AWS configuration's)")
+	configEncryptCmd.Flags().StringVarP(&configEncryptOptions.Profile, "profile", "p", "", "AWS profile name (optional, uses default configuration if not specified)")
+
+	var configDecryptCmd = &cobra.Command{
+		Use:   "decrypt [field...]",
+		Short: "Write the plaintext of encrypted config values back into the file",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			changed, err := config.DecryptFields(args)
+			if err != nil {
+				result.Fail("Error decrypting config values", err)
+			}
+			if len(changed) == 0 {
+				fmt.Println("Nothing to decrypt, the values are not encrypted.")
+				return
+			}
+			for _, path := range changed {
+				fmt.Printf("🔓 %s\n", path)
+			}
+			fmt.Printf("✅ Decrypted %d values in %s\n", len(changed), config.Path())
+		},
+	}
+
+	configCmd.AddCommand(configEncryptCmd)
+	configCmd.AddCommand(configDecryptCmd)
+
+	// --- Serve command ---
+	var serveOptions server.Options
+	var serveCmd = &cobra.Command{
+		Use:   "serve",
+		Short: "Serve read-only reports over an HTTP/JSON API",
+		Long: `Expose node usage, pod density, certificate expiry, cost estimation and
+snapshot capture over an HTTP/JSON API so dashboards and bots can reuse them.
+Every /api request must send "Authorization: Bearer <token>"; the token comes
+from --token or the ` + server.TokenEnv + ` environment variable. The token is only
+sent in the clear to a loopback address: listening anywhere else needs --tls-cert
+and --tls-key.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := server.Run(serveOptions); err != nil {
+				result.Fail("Error running API server", err)
+			}
+		},
+	}
+	serveCmd.Flags().StringVar(&serveOptions.Addr, "addr", "127.0.0.1:8080", "Address to listen on; addresses other than loopback need --tls-cert and --tls-key")
+	serveCmd.Flags().StringVar(&serveOptions.Token, "token", "", "Bearer token required on every API request (default: $"+server.TokenEnv+")")
+	serveCmd.Flags().StringVar(&serveOptions.SnapshotDir, "snapshot-dir", "", "Directory for snapshots triggered through the API (default: current directory)")
+	serveCmd.Flags().StringVar(&serveOptions.TLSCert, "tls-cert", "", "PEM certificate file to serve HTTPS with")
+	serveCmd.Flags().StringVar(&serveOptions.TLSKey, "tls-key", "", "PEM private key file of --tls-cert")
+
+	// --- EKS Token command (hidden) ---
+	// Used as the kubeconfig exec credential plugin when connect cluster
+	// wrote the kubeconfig itself
+	var tokenCluster, tokenRegion, tokenProfile string
+	var eksTokenCmd = &cobra.Command{
+		Use:    "eks-token",
+		Short:  "Print an ExecCredential with a token for an EKS cluster",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := aws.PrintEKSExecCredential(tokenCluster, tokenRegion, tokenProfile); err != nil {
+				result.Fail("Error getting EKS token", err)
+			}
+		},
+	}
+	eksTokenCmd.Flags().StringVar(&tokenCluster, "cluster", "", "EKS cluster name")
+	eksTokenCmd.Flags().StringVarP(&tokenRegion, "region", "r", "", "AWS region of the cluster")
+	eksTokenCmd.Flags().StringVarP(&tokenProfile, "profile", "p", "", "AWS profile to use")
+	eksTokenCmd.MarkFlagRequired("cluster")
 
 	// --- Get Snapshot command ---
 	var snapshotFormat string
+	var snapshotOutputDir string
+	var snapshotDaemon bool
+	var snapshotEvery time.Duration
+	var snapshotRetain int
+	var snapshotS3Bucket string
+	var snapshotS3Prefix string
+	var snapshotProfile string
+	var snapshotRegion string
+	var snapshotMetrics bool
+	var snapshotCertificates bool
+	var snapshotOnAlert bool
+	var snapshotAlertOptions k8s.SnapshotAlertOptions
 	var getSnapshotCmd = &cobra.Command{
 		Use:   "getsnapshot",
 		Short: "Capture the current state of the EKS cluster",
-		Long:  "Collect cluster resources (nodes, services, deployments, pods, etc.) and save to file for state comparison",
+		Long: `Collect cluster resources (nodes, services, deployments, pods, etc.) and save to file for state comparison.
+Use --daemon to keep running and capture a snapshot periodically, pruning old
+snapshots and optionally uploading each one to S3. Use --on-alert to keep running
+and capture a snapshot when NotReady nodes, new crash loops or Warning events
+cross their thresholds, so the state is recorded as the cluster starts degrading.
+Use --metrics to add node and pod CPU and memory usage from the metrics API to
+the summary, and --certificates to add the expiry of every TLS secret.`,
+		Aliases: []string{"snapshot"},
 		Run: func(cmd *cobra.Command, args []string) {
-			err := k8s.GetClusterSnapshot(snapshotFormat)
+			if snapshotOnAlert {
+				snapshotAlertOptions.Format = snapshotFormat
+				snapshotAlertOptions.OutputDir = snapshotOutputDir
+				snapshotAlertOptions.Retain = snapshotRetain
+				snapshotAlertOptions.S3Bucket = snapshotS3Bucket
+				snapshotAlertOptions.S3Prefix = snapshotS3Prefix
+				snapshotAlertOptions.Profile = snapshotProfile
+				snapshotAlertOptions.Region = snapshotRegion
+				snapshotAlertOptions.Metrics = snapshotMetrics
+				snapshotAlertOptions.Certificates = snapshotCertificates
+				if err := k8s.RunSnapshotOnAlert(snapshotAlertOptions); err != nil {
+					result.Fail("Error watching for snapshot triggers", err)
+				}
+				return
+			}
+			if snapshotDaemon {
+				options := k8s.SnapshotDaemonOptions{
+					Format:       snapshotFormat,
+					OutputDir:    snapshotOutputDir,
+					Interval:     snapshotEvery,
+					Retain:       snapshotRetain,
+					S3Bucket:     snapshotS3Bucket,
+					S3Prefix:     snapshotS3Prefix,
+					Profile:      snapshotProfile,
+					Region:       snapshotRegion,
+					Metrics:      snapshotMetrics,
+					Certificates: snapshotCertificates,
+				}
+				if err := k8s.RunSnapshotDaemon(options); err != nil {
+					result.Fail("Error running snapshot daemon", err)
+				}
+				return
+			}
+
+			err := k8s.GetClusterSnapshot(snapshotFormat, snapshotOutputDir, snapshotMetrics, snapshotCertificates)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error capturing cluster snapshot: %v\n", err)
-				os.Exit(1)
+				result.Fail("Error capturing cluster snapshot", err)
+			}
+		},
+	}
+	getSnapshotCmd.Flags().StringVar(&snapshotFormat, "format", "yaml", "Output format (yaml, txt or html)")
+	outputFormats(getSnapshotCmd, "format", "yaml", "txt", "html")
+	getSnapshotCmd.Flags().StringVar(&snapshotOutputDir, "output-dir", "", "Directory to write snapshots to (default: current directory)")
+	getSnapshotCmd.Flags().BoolVar(&snapshotDaemon, "daemon", false, "Run continuously, capturing a snapshot on every interval")
+	getSnapshotCmd.Flags().DurationVar(&snapshotEvery, "every", time.Hour, "Interval between snapshots in daemon mode (e.g. 30m, 1h)")
+	getSnapshotCmd.Flags().IntVar(&snapshotRetain, "retain", 0, "Number of local snapshots to keep in daemon and on-alert mode (0 keeps all)")
+	getSnapshotCmd.Flags().StringVar(&snapshotS3Bucket, "s3-bucket", "", "S3 bucket to upload each snapshot to in daemon and on-alert mode (optional)")
+	getSnapshotCmd.Flags().StringVar(&snapshotS3Prefix, "s3-prefix", "", "Key prefix for uploaded snapshots")
+	getSnapshotCmd.Flags().StringVarP(&snapshotProfile, "profile", "p", "", "AWS profile for the S3 upload (optional, uses default configuration if not specified)")
+	getSnapshotCmd.Flags().StringVarP(&snapshotRegion, "region", "r", "", "AWS region to look up the S3 bucket from (optional, the bucket's own region is used for the upload)")
+	getSnapshotCmd.Flags().BoolVar(&snapshotMetrics, "metrics", false, "Include node and pod CPU and memory usage from the metrics API")
+	getSnapshotCmd.Flags().BoolVar(&snapshotCertificates, "certificates", false, "Include the expiry of every TLS secret (needs read access to secrets)")
+	getSnapshotCmd.Flags().BoolVar(&snapshotOnAlert, "on-alert", false, "Run continuously, capturing a snapshot when a trigger crosses its threshold")
+	getSnapshotCmd.Flags().DurationVar(&snapshotAlertOptions.CheckEvery, "check-every", 30*time.Second, "How often the triggers are evaluated in on-alert mode")
+	getSnapshotCmd.Flags().DurationVar(&snapshotAlertOptions.Window, "window", 5*time.Minute, "Period the crash loop and warning event triggers count over")
+	getSnapshotCmd.Flags().IntVar(&snapshotAlertOptions.NotReady, "not-ready", 2, "NotReady nodes that trigger a snapshot (0 turns the trigger off)")
+	getSnapshotCmd.Flags().IntVar(&snapshotAlertOptions.CrashLoops, "crashloops", 5, "Pods entering CrashLoopBackOff within the window that trigger a snapshot (0 turns the trigger off)")
+	getSnapshotCmd.Flags().IntVar(&snapshotAlertOptions.WarningEvents, "warning-events", 200, "Warning events within the window that trigger a snapshot (0 turns the trigger off)")
+	getSnapshotCmd.Flags().DurationVar(&snapshotAlertOptions.Cooldown, "cooldown", 15*time.Minute, "Minimum time between two triggered snapshots")
+	getSnapshotCmd.Flags().BoolVar(&snapshotAlertOptions.Once, "once", false, "Exit after the first triggered snapshot")
+	getSnapshotCmd.MarkFlagsMutuallyExclusive("daemon", "on-alert")
+
+	var snapshotDiffOptions k8s.SnapshotDiffOptions
+	var snapshotDiffCmd = &cobra.Command{
+		Use:   "diff [snapshot-a] [snapshot-b]",
+		Short: "Compare the configuration in two YAML snapshots, also of different clusters",
+		Long: `Compares workloads, services, PVCs, storage classes, ENIConfigs and Helm releases
+of two YAML snapshots, leaving out fields the API server and controllers set. When
+the snapshots are of different clusters, or with --cross-cluster, UIDs, IPs, node
+and instance names, AWS resource and account IDs and the cluster name are
+normalized, so staging can be compared with production.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := k8s.DiffSnapshots(args[0], args[1], snapshotDiffOptions); err != nil {
+				result.Fail("Error comparing snapshots", err)
 			}
 		},
 	}
-	getSnapshotCmd.Flags().StringVar(&snapshotFormat, "format", "yaml", "Output format (yaml or txt)")
+	snapshotDiffCmd.Flags().BoolVar(&snapshotDiffOptions.CrossCluster, "cross-cluster", false, "Normalize cluster specific values even when both snapshots are of the same cluster")
+	snapshotDiffCmd.Flags().StringSliceVar(&snapshotDiffOptions.Mappings, "map", nil, "FROM=TO replacement applied to the first snapshot, e.g. shop-staging=shop-prod (repeatable)")
+	snapshotDiffCmd.Flags().StringSliceVar(&snapshotDiffOptions.Ignore, "ignore", nil, "Field paths to leave out, e.g. spec.replicas (repeatable)")
+	getSnapshotCmd.AddCommand(snapshotDiffCmd)
 	rootCmd.AddCommand(connectCmd)
+	rootCmd.AddCommand(clustersCmd)
+	rootCmd.AddCommand(eolCheckCmd)
+	rootCmd.AddCommand(baselineCheckCmd)
 	rootCmd.AddCommand(nodeUsageCmd)
 	rootCmd.AddCommand(asgStatusCmd)
+	rootCmd.AddCommand(asgCmd)
+	rootCmd.AddCommand(capacityCheckCmd)
+	rootCmd.AddCommand(runPresetCmd)
+	rootCmd.AddCommand(nodeCmd)
+	rootCmd.AddCommand(debugCmd)
+	rootCmd.AddCommand(timelineCmd)
+	rootCmd.AddCommand(restartCmd)
+	rootCmd.AddCommand(verifyReloadCmd)
+	rootCmd.AddCommand(applyCmd)
+	rootCmd.AddCommand(getCleanCmd)
+	rootCmd.AddCommand(chaosCmd)
+	rootCmd.AddCommand(loadTestCmd)
+	rootCmd.AddCommand(sloCmd)
+	rootCmd.AddCommand(apiserverProbeCmd)
+	rootCmd.AddCommand(genLoadObjectsCmd)
+	rootCmd.AddCommand(sandboxCmd)
+	rootCmd.AddCommand(eventsCmd)
+	rootCmd.AddCommand(pvcCmd)
+	rootCmd.AddCommand(crdCmd)
+	rootCmd.AddCommand(operatorsCmd)
 	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(lintCmd)
 	rootCmd.AddCommand(revealSecretCmd)
-	rootCmd.AddCommand(checkCertCmd)	
+	rootCmd.AddCommand(checkCertCmd)
+	rootCmd.AddCommand(secretAgeCmd)
+	rootCmd.AddCommand(extSecretsCmd)
+	rootCmd.AddCommand(createTLSSecretCmd)
+	rootCmd.AddCommand(makeKubeconfigCmd)
+	rootCmd.AddCommand(acmCheckCmd)
+	rootCmd.AddCommand(ingressConflictsCmd)
+	rootCmd.AddCommand(dnsRecordsCmd)
+	rootCmd.AddCommand(endpointCheckCmd)
+	rootCmd.AddCommand(refsCheckCmd)
+	rootCmd.AddCommand(valuesCheckCmd)
+	rootCmd.AddCommand(graphCmd)
+	rootCmd.AddCommand(versionsCmd)
+	rootCmd.AddCommand(cisQuickCmd)
+	rootCmd.AddCommand(pssCheckCmd)
+	rootCmd.AddCommand(policyStatusCmd)
+	rootCmd.AddCommand(patchStatusCmd)
+	rootCmd.AddCommand(exposureCmd)
+	rootCmd.AddCommand(scanImagesCmd)
+	rootCmd.AddCommand(arm64CheckCmd)
+	rootCmd.AddCommand(conntrackCheckCmd)
+	rootCmd.AddCommand(iptablesStatsCmd)
+	rootCmd.AddCommand(ipLookupCmd)
+	rootCmd.AddCommand(ownerCmd)
+	rootCmd.AddCommand(flowLogsCmd)
 	rootCmd.AddCommand(costEstimateCmd)
+	rootCmd.AddCommand(teamReportCmd)
+	rootCmd.AddCommand(tagAuditCmd)
+	rootCmd.AddCommand(riCoverageCmd)
+	rootCmd.AddCommand(fargateStatusCmd)
 	rootCmd.AddCommand(podDensityCmd)
+	rootCmd.AddCommand(rebalanceCmd)
+	rootCmd.AddCommand(migrateWorkloadsCmd)
+	rootCmd.AddCommand(dsOverheadCmd)
+	rootCmd.AddCommand(recommendInstanceTypeCmd)
+	rootCmd.AddCommand(topologyCheckCmd)
+	rootCmd.AddCommand(criticalityCheckCmd)
+	rootCmd.AddCommand(azImpactCmd)
+	rootCmd.AddCommand(simulateTerminationCmd)
+	rootCmd.AddCommand(lbCmd)
+	rootCmd.AddCommand(rotateNodesCmd)
+	rootCmd.AddCommand(hibernateCmd)
+	rootCmd.AddCommand(resumeCmd)
+	rootCmd.AddCommand(pendingWatchCmd)
+	rootCmd.AddCommand(healthCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(bookmarksCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(eksTokenCmd)
 	rootCmd.AddCommand(getSnapshotCmd)
 
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error executing command: %v\n", err)
-		os.Exit(1)
+		result.Fail("Error executing command", err)
+	}
+}
+
+// configureSinks directs the structured output of cmd to the --sink
+// destinations. Commands with json output are switched to it unless
+// --output is given.
+func configureSinks(cmd *cobra.Command, values []string) {
+	format := ""
+	if flag := cmd.Flags().Lookup("output"); flag != nil {
+		if !flag.Changed && slices.Contains(flag.Annotations[formatsAnnotation], "json") {
+			flag.Value.Set("json")
+		}
+		format = flag.Value.String()
+	} else if flag := cmd.Flags().Lookup("format"); flag != nil {
+		format = flag.Value.String()
+	}
+	command := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().CommandPath()+" ")
+	if err := sink.Configure(strings.ReplaceAll(command, " ", "-"), format, values); err != nil {
+		result.Fail("Error", err)
+	}
+}
+
+// formatsAnnotation lists the formats an --output or --format flag accepts
+const formatsAnnotation = "swissarmycli_formats"
+
+// outputFormats declares the formats a command's --output or --format flag
+// accepts, so --sink can tell whether the command can switch to json.
+func outputFormats(cmd *cobra.Command, flag string, formats ...string) {
+	cmd.Flags().SetAnnotation(flag, formatsAnnotation, formats)
+}
+
+// guardContext shows which context a command that changes or reveals
+// something is about to act on, and asks for confirmation in protected
+// contexts. It exits when the user doesn't confirm.
+func guardContext(cmd *cobra.Command) {
+	options := k8s.ContextGuardOptions{
+		Action: strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "),
+	}
+	if flag := cmd.Flags().Lookup("namespace"); flag != nil {
+		options.Namespace = flag.Value.String()
+	}
+	options.YesProd, _ = cmd.Flags().GetBool("yes-prod")
+	options.NonInteractive, _ = cmd.Flags().GetBool("non-interactive")
+	options.DryRun, _ = cmd.Flags().GetBool("dry-run")
+	if err := k8s.GuardContext(options); err != nil {
+		result.Fail("Error", err)
+	}
+}
+
+// resolveASG turns a node group name into the name of its ASG, and passes
+// ASG names through unchanged.
+func resolveASG(name, profile, region string) string {
+	asgName, err := aws.ResolveASGName(name, profile, region)
+	if err != nil {
+		result.Fail("Error resolving ASG", err)
+	}
+	return asgName
+}
+
+// resolveBookmark turns an @alias argument into the bookmarked target, and
+// passes any other argument through unchanged.
+func resolveBookmark(ref, kind string) bookmarks.Bookmark {
+	bookmark, err := bookmarks.Resolve(ref, kind)
+	if err != nil {
+		result.Fail("Error resolving bookmark", err)
 	}
+	return bookmark
 }
@@ -0,0 +1,212 @@
+package apicalls
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// Providers the calls are counted for
+const (
+	ProviderAWS        = "aws"
+	ProviderKubernetes = "kubernetes"
+)
+
+// operation identifies what was called
+type operation struct {
+	provider string
+	service  string // AWS service, or API group and version
+	name     string // AWS operation, or verb and resource
+}
+
+// counter holds the counts of one operation
+type counter struct {
+	requests  int // HTTP requests, retries included
+	retries   int
+	throttled int // Requests rejected with a throttling error or HTTP 429
+}
+
+var (
+	mu      sync.Mutex
+	enabled bool
+	printed bool
+	counts  = make(map[operation]*counter)
+)
+
+// Enable starts counting. Until it is called, the instrumented sessions
+// and transports record nothing.
+func Enable() {
+	mu.Lock()
+	defer mu.Unlock()
+	enabled = true
+}
+
+func record(op operation, retry, throttled bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !enabled {
+		return
+	}
+	c := counts[op]
+	if c == nil {
+		c = &counter{}
+		counts[op] = c
+	}
+	c.requests++
+	if retry {
+		c.retries++
+	}
+	if throttled {
+		c.throttled++
+	}
+}
+
+// InstrumentSession counts every request made with clients of an AWS
+// session, by service and operation. Each attempt counts, so retries
+// after throttling show up as extra requests.
+func InstrumentSession(sess *session.Session) *session.Session {
+	sess.Handlers.CompleteAttempt.PushBack(func(r *request.Request) {
+		op := operation{provider: ProviderAWS, service: r.ClientInfo.ServiceName}
+		if r.Operation != nil {
+			op.name = r.Operation.Name
+		}
+		record(op, r.RetryCount > 0, r.Error != nil && request.IsErrorThrottle(r.Error))
+	})
+	return sess
+}
+
+// Transport wraps the transport of a Kubernetes client config to count its
+// requests by API group and by verb and resource. Use it with the config's
+// Wrap method. client-go retries 429 responses on its own, so for
+// Kubernetes the throttled requests are the retries.
+func Transport(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := rt.RoundTrip(req)
+		service, name := kubernetesOperation(req)
+		record(operation{provider: ProviderKubernetes, service: service, name: name},
+			false, resp != nil && resp.StatusCode == http.StatusTooManyRequests)
+		return resp, err
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// kubernetesOperation turns a request path into its API group and version,
+// and a kubectl-style verb with the resource and subresource, e.g. "apps/v1"
+// and "list deployments". Paths outside resource APIs count as discovery.
+func kubernetesOperation(req *http.Request) (string, string) {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	var service string
+	switch {
+	case len(segments) >= 3 && segments[0] == "api":
+		service, segments = "core/"+segments[1], segments[2:]
+	case len(segments) >= 4 && segments[0] == "apis":
+		service, segments = segments[1]+"/"+segments[2], segments[3:]
+	default:
+		return "discovery", strings.ToLower(req.Method) + " /" + strings.Join(segments, "/")
+	}
+	// namespaces/<name> scopes the resource that follows, except for the
+	// subresources of the namespace itself.
+	if len(segments) >= 3 && segments[0] == "namespaces" && segments[2] != "status" && segments[2] != "finalize" {
+		segments = segments[2:]
+	}
+	resource, named := segments[0], len(segments) > 1
+	if len(segments) > 2 {
+		resource += "/" + segments[2]
+	}
+
+	verb := strings.ToLower(req.Method)
+	switch req.Method {
+	case http.MethodGet:
+		switch {
+		case req.URL.Query().Get("watch") == "true" || req.URL.Query().Get("watch") == "1":
+			verb = "watch"
+		case named:
+			verb = "get"
+		default:
+			verb = "list"
+		}
+	case http.MethodPost:
+		verb = "create"
+	case http.MethodPut:
+		verb = "update"
+	case http.MethodPatch:
+		verb = "patch"
+	case http.MethodDelete:
+		verb = "delete"
+		if !named {
+			verb = "deletecollection"
+		}
+	}
+	return service, verb + " " + resource
+}
+
+// Print writes the counted requests as a table, busiest operations first,
+// followed by the totals per provider. It prints once, and nothing when
+// counting isn't enabled.
+func Print(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !enabled || printed {
+		return
+	}
+	printed = true
+
+	ops := make([]operation, 0, len(counts))
+	for op := range counts {
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if counts[ops[i]].requests != counts[ops[j]].requests {
+			return counts[ops[i]].requests > counts[ops[j]].requests
+		}
+		if ops[i].provider != ops[j].provider {
+			return ops[i].provider < ops[j].provider
+		}
+		if ops[i].service != ops[j].service {
+			return ops[i].service < ops[j].service
+		}
+		return ops[i].name < ops[j].name
+	})
+
+	fmt.Fprintln(w, "\n--- API Calls ---")
+	if len(ops) == 0 {
+		fmt.Fprintln(w, "No AWS or Kubernetes API requests were made.")
+		fmt.Fprintln(w, "----------------------------------------------------")
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROVIDER\tSERVICE\tOPERATION\tREQUESTS\tRETRIES\tTHROTTLED")
+	totals := make(map[string]*counter)
+	for _, op := range ops {
+		c := counts[op]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\n", op.provider, op.service, op.name, c.requests, c.retries, c.throttled)
+		total := totals[op.provider]
+		if total == nil {
+			total = &counter{}
+			totals[op.provider] = total
+		}
+		total.requests += c.requests
+		total.retries += c.retries
+		total.throttled += c.throttled
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+	for _, provider := range []string{ProviderAWS, ProviderKubernetes} {
+		if total := totals[provider]; total != nil {
+			fmt.Fprintf(w, "%s: %d requests, %d retries, %d throttled\n", provider, total.requests, total.retries, total.throttled)
+		}
+	}
+	fmt.Fprintln(w, "----------------------------------------------------")
+}
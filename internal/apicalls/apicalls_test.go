@@ -0,0 +1,34 @@
+package apicalls
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestKubernetesOperation(t *testing.T) {
+	tests := []struct {
+		method, target string
+		service, name  string
+	}{
+		{"GET", "/api/v1/nodes", "core/v1", "list nodes"},
+		{"GET", "/api/v1/nodes/ip-10-0-0-1", "core/v1", "get nodes"},
+		{"GET", "/api/v1/namespaces", "core/v1", "list namespaces"},
+		{"GET", "/api/v1/namespaces/kube-system", "core/v1", "get namespaces"},
+		{"GET", "/api/v1/namespaces/kube-system/pods", "core/v1", "list pods"},
+		{"GET", "/api/v1/namespaces/kube-system/pods?watch=true", "core/v1", "watch pods"},
+		{"GET", "/api/v1/namespaces/kube-system/pods/coredns/log", "core/v1", "get pods/log"},
+		{"PUT", "/api/v1/namespaces/foo/status", "core/v1", "update namespaces/status"},
+		{"PUT", "/api/v1/namespaces/foo/finalize", "core/v1", "update namespaces/finalize"},
+		{"PATCH", "/apis/apps/v1/namespaces/default/deployments/web", "apps/v1", "patch deployments"},
+		{"PUT", "/apis/apps/v1/namespaces/default/deployments/web/scale", "apps/v1", "update deployments/scale"},
+		{"DELETE", "/api/v1/namespaces/default/pods", "core/v1", "deletecollection pods"},
+		{"GET", "/version", "discovery", "get /version"},
+		{"GET", "/apis", "discovery", "get /apis"},
+	}
+	for _, tt := range tests {
+		service, name := kubernetesOperation(httptest.NewRequest(tt.method, tt.target, nil))
+		if service != tt.service || name != tt.name {
+			t.Errorf("%s %s: got %q %q, want %q %q", tt.method, tt.target, service, name, tt.service, tt.name)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"k8s.io/client-go/util/homedir"
+)
+
+// Entry records one access to sensitive data
+type Entry struct {
+	Time      time.Time `json:"time"`
+	User      string    `json:"user"`
+	Command   string    `json:"command"`
+	Context   string    `json:"context,omitempty"`
+	Cluster   string    `json:"cluster,omitempty"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Keys      []string  `json:"keys"`
+	Mode      string    `json:"mode"` // How the values were shown, e.g. plain, masked or clipboard
+}
+
+// Path returns the audit log location: $SWISSARMYCLI_AUDIT_LOG if set,
+// otherwise ~/.swissarmycli/audit.log.
+func Path() string {
+	if path := os.Getenv("SWISSARMYCLI_AUDIT_LOG"); path != "" {
+		return path
+	}
+	return filepath.Join(homedir.HomeDir(), ".swissarmycli", "audit.log")
+}
+
+// Record appends the entry to the audit log as one JSON line, filling in
+// the time and the local user when they're not set. The log is created
+// readable by the owner only.
+func Record(entry Entry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	if entry.User == "" {
+		if current, err := user.Current(); err == nil {
+			entry.User = current.Username
+		}
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
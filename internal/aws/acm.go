@@ -0,0 +1,146 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+)
+
+// ACMCertificate is the subset of an ACM certificate's details used for
+// inventory and cross-checks.
+type ACMCertificate struct {
+	Arn              string
+	DomainName       string
+	Names            []string // Subject alternative names, including DomainName
+	Status           string
+	Type             string
+	RenewalStatus    string
+	ValidationStatus string // Worst domain validation status for pending certificates
+	NotAfter         time.Time
+	InUseBy          []string
+}
+
+// GetACMCertificate returns the PEM encoded certificate and chain of an ACM
+// certificate. ACM never returns private keys of issued certificates, so the
+// key must be supplied separately.
+func GetACMCertificate(certificateArn, profile string) (string, string, error) {
+	parsed, err := arn.Parse(certificateArn)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid certificate ARN '%s': %w", certificateArn, err)
+	}
+
+	sess, err := NewSession(profile, parsed.Region)
+	if err != nil {
+		return "", "", err
+	}
+
+	output, err := acm.New(sess).GetCertificate(&acm.GetCertificateInput{
+		CertificateArn: aws.String(certificateArn),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get ACM certificate: %w", err)
+	}
+	return aws.StringValue(output.Certificate), aws.StringValue(output.CertificateChain), nil
+}
+
+// ListACMCertificates describes every certificate in the session's region,
+// whatever its key type or status.
+func ListACMCertificates(sess *session.Session) ([]ACMCertificate, error) {
+	acmSvc := acm.New(sess)
+
+	var arns []*string
+	input := &acm.ListCertificatesInput{
+		Includes: &acm.Filters{KeyTypes: aws.StringSlice(acm.KeyAlgorithm_Values())},
+	}
+	err := acmSvc.ListCertificatesPages(input, func(page *acm.ListCertificatesOutput, lastPage bool) bool {
+		for _, summary := range page.CertificateSummaryList {
+			arns = append(arns, summary.CertificateArn)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ACM certificates: %w", err)
+	}
+
+	var certificates []ACMCertificate
+	for _, certificateArn := range arns {
+		output, err := acmSvc.DescribeCertificate(&acm.DescribeCertificateInput{CertificateArn: certificateArn})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe certificate %s: %w", aws.StringValue(certificateArn), err)
+		}
+		detail := output.Certificate
+
+		certificate := ACMCertificate{
+			Arn:        aws.StringValue(detail.CertificateArn),
+			DomainName: aws.StringValue(detail.DomainName),
+			Names:      aws.StringValueSlice(detail.SubjectAlternativeNames),
+			Status:     aws.StringValue(detail.Status),
+			Type:       aws.StringValue(detail.Type),
+			NotAfter:   aws.TimeValue(detail.NotAfter),
+			InUseBy:    aws.StringValueSlice(detail.InUseBy),
+		}
+		if len(certificate.Names) == 0 {
+			certificate.Names = []string{certificate.DomainName}
+		}
+		if detail.RenewalSummary != nil {
+			certificate.RenewalStatus = aws.StringValue(detail.RenewalSummary.RenewalStatus)
+		}
+		for _, validation := range detail.DomainValidationOptions {
+			status := aws.StringValue(validation.ValidationStatus)
+			if status != acm.DomainStatusSuccess {
+				certificate.ValidationStatus = status
+				break
+			}
+			certificate.ValidationStatus = status
+		}
+		certificates = append(certificates, certificate)
+	}
+	return certificates, nil
+}
+
+// LoadBalancerIDsByDNS maps load balancer DNS names to their ARN, or to
+// "loadbalancer/<name>" for classic load balancers, which is the suffix of the
+// ARN ACM reports in InUseBy.
+func LoadBalancerIDsByDNS(sess *session.Session) (map[string]string, error) {
+	ids := make(map[string]string)
+
+	err := elbv2.New(sess).DescribeLoadBalancersPages(&elbv2.DescribeLoadBalancersInput{},
+		func(page *elbv2.DescribeLoadBalancersOutput, lastPage bool) bool {
+			for _, lb := range page.LoadBalancers {
+				ids[strings.ToLower(aws.StringValue(lb.DNSName))] = aws.StringValue(lb.LoadBalancerArn)
+			}
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe load balancers: %w", err)
+	}
+
+	err = elb.New(sess).DescribeLoadBalancersPages(&elb.DescribeLoadBalancersInput{},
+		func(page *elb.DescribeLoadBalancersOutput, lastPage bool) bool {
+			for _, lb := range page.LoadBalancerDescriptions {
+				ids[strings.ToLower(aws.StringValue(lb.DNSName))] = "loadbalancer/" + aws.StringValue(lb.LoadBalancerName)
+			}
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe classic load balancers: %w", err)
+	}
+	return ids, nil
+}
+
+// CertificateUsedBy reports whether ACM lists the load balancer ID in InUseBy.
+func CertificateUsedBy(certificate ACMCertificate, loadBalancerID string) bool {
+	for _, resource := range certificate.InUseBy {
+		if resource == loadBalancerID || strings.HasSuffix(resource, ":"+loadBalancerID) {
+			return true
+		}
+	}
+	return false
+}
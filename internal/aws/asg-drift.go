@@ -0,0 +1,207 @@
+package aws
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// DriftOptions contains options for the ASG drift check
+type DriftOptions struct {
+	Region  string
+	Profile string
+	Refresh bool   // Start an instance refresh when outdated instances are found
+	Output  string // table or json
+	FailOn  string // Lowest severity that fails the run: error, warning, info or none
+}
+
+// templateTarget is the launch template version and AMI new instances get
+type templateTarget struct {
+	name    string
+	version string
+	imageID string
+}
+
+// DetectASGDrift compares the launch template version and AMI of every
+// instance with what the ASG currently launches, and optionally starts an
+// instance refresh to replace the outdated ones.
+func DetectASGDrift(asgName string, options DriftOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	sess, err := NewSession(options.Profile, options.Region)
+	if err != nil {
+		return err
+	}
+
+	asgSvc := autoscaling.New(sess)
+	output, err := asgSvc.DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{aws.String(asgName)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe ASG: %w", err)
+	}
+	if len(output.AutoScalingGroups) == 0 {
+		return fmt.Errorf("ASG not found: %s", asgName)
+	}
+	asg := output.AutoScalingGroups[0]
+
+	spec := asg.LaunchTemplate
+	if spec == nil && asg.MixedInstancesPolicy != nil && asg.MixedInstancesPolicy.LaunchTemplate != nil {
+		spec = asg.MixedInstancesPolicy.LaunchTemplate.LaunchTemplateSpecification
+	}
+
+	var target *templateTarget
+	if spec != nil {
+		target, err = resolveLaunchTemplate(sess, spec)
+		if err != nil {
+			return err
+		}
+		if options.Output != "json" {
+			fmt.Printf("ASG '%s' launches %s version %s (AMI %s)\n", asgName, target.name, target.version, target.imageID)
+		}
+	} else if asg.LaunchConfigurationName != nil {
+		if options.Output != "json" {
+			fmt.Printf("ASG '%s' uses launch configuration %s\n", asgName, *asg.LaunchConfigurationName)
+		}
+	} else {
+		return fmt.Errorf("ASG '%s' has no launch template or launch configuration", asgName)
+	}
+
+	instanceAMIs := make(map[string]string)
+	var instanceIDs []*string
+	for _, instance := range asg.Instances {
+		instanceIDs = append(instanceIDs, instance.InstanceId)
+	}
+	if len(instanceIDs) > 0 {
+		ec2Output, err := ec2.New(sess).DescribeInstances(&ec2.DescribeInstancesInput{InstanceIds: instanceIDs})
+		if err != nil {
+			return fmt.Errorf("failed to describe instances: %w", err)
+		}
+		for _, reservation := range ec2Output.Reservations {
+			for _, instance := range reservation.Instances {
+				instanceAMIs[aws.StringValue(instance.InstanceId)] = aws.StringValue(instance.ImageId)
+			}
+		}
+	}
+
+	outdated := 0
+	var findings []result.Finding
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "INSTANCE\tAZ\tTEMPLATE/CONFIG\tVERSION\tAMI\tSTATUS")
+	for _, instance := range asg.Instances {
+		instanceID := aws.StringValue(instance.InstanceId)
+		ami := instanceAMIs[instanceID]
+		source, version := "-", "-"
+		var reasons []string
+
+		if instance.LaunchTemplate != nil {
+			source = aws.StringValue(instance.LaunchTemplate.LaunchTemplateName)
+			version = aws.StringValue(instance.LaunchTemplate.Version)
+		} else if instance.LaunchConfigurationName != nil {
+			source = aws.StringValue(instance.LaunchConfigurationName)
+		}
+
+		if target != nil {
+			if source != target.name {
+				reasons = append(reasons, "template")
+			} else if version != target.version {
+				reasons = append(reasons, "version")
+			}
+			if target.imageID != "" && ami != "" && ami != target.imageID {
+				reasons = append(reasons, "AMI")
+			}
+		} else if source != aws.StringValue(asg.LaunchConfigurationName) {
+			reasons = append(reasons, "launch config")
+		}
+
+		status := "✅ current"
+		if len(reasons) > 0 {
+			outdated++
+			status = fmt.Sprintf("⚠️  outdated (%s)", strings.Join(reasons, ", "))
+			findings = append(findings, result.Finding{
+				Check:    "instance-outdated",
+				Severity: result.SeverityWarning,
+				Resource: instanceID,
+				Message:  fmt.Sprintf("outdated %s", strings.Join(reasons, ", ")),
+				Details: map[string]string{
+					"asg": asgName, "zone": aws.StringValue(instance.AvailabilityZone),
+					"source": source, "version": version, "ami": ami,
+				},
+			})
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			instanceID, aws.StringValue(instance.AvailabilityZone), source, version, ami, status)
+	}
+	if options.Output == "json" {
+		if err := result.New("asg drift", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+	} else {
+		w.Flush()
+		fmt.Printf("\n%d of %d instances are outdated.\n", outdated, len(asg.Instances))
+	}
+	if outdated == 0 || !options.Refresh {
+		return result.Gate(findings, options.FailOn)
+	}
+
+	refresh, err := asgSvc.StartInstanceRefresh(&autoscaling.StartInstanceRefreshInput{
+		AutoScalingGroupName: aws.String(asgName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start instance refresh: %w", err)
+	}
+	// Keep stdout to the report in json mode
+	progress := os.Stdout
+	if options.Output == "json" {
+		progress = os.Stderr
+	}
+	fmt.Fprintf(progress, "Started instance refresh %s. Follow it with: swissarmycli asg-status %s --stream\n",
+		aws.StringValue(refresh.InstanceRefreshId), asgName)
+	return result.Gate(findings, options.FailOn)
+}
+
+// resolveLaunchTemplate turns aliases such as $Latest and $Default into the
+// concrete version number and AMI.
+func resolveLaunchTemplate(sess *session.Session, spec *autoscaling.LaunchTemplateSpecification) (*templateTarget, error) {
+	version := aws.StringValue(spec.Version)
+	if version == "" {
+		version = "$Default"
+	}
+
+	input := &ec2.DescribeLaunchTemplateVersionsInput{
+		Versions: []*string{aws.String(version)},
+	}
+	if spec.LaunchTemplateId != nil {
+		input.LaunchTemplateId = spec.LaunchTemplateId
+	} else {
+		input.LaunchTemplateName = spec.LaunchTemplateName
+	}
+
+	output, err := ec2.New(sess).DescribeLaunchTemplateVersions(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe launch template versions: %w", err)
+	}
+	if len(output.LaunchTemplateVersions) == 0 {
+		return nil, fmt.Errorf("launch template version %s not found", version)
+	}
+
+	ltVersion := output.LaunchTemplateVersions[0]
+	target := &templateTarget{
+		name:    aws.StringValue(ltVersion.LaunchTemplateName),
+		version: strconv.FormatInt(aws.Int64Value(ltVersion.VersionNumber), 10),
+	}
+	if ltVersion.LaunchTemplateData != nil {
+		target.imageID = aws.StringValue(ltVersion.LaunchTemplateData.ImageId)
+	}
+	return target, nil
+}
@@ -0,0 +1,229 @@
+package aws
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+// HistoryOptions contains options for the ASG activity digest
+type HistoryOptions struct {
+	Region  string
+	Profile string
+	Since   time.Duration
+}
+
+// historyActivity is a scaling activity classified by what it did and why
+type historyActivity struct {
+	launch     bool
+	instanceID string
+	cause      string
+	failed     bool
+	start      time.Time
+	end        time.Time
+}
+
+// causeCount counts the activities with one cause
+type causeCount struct {
+	launches     int
+	terminations int
+	failed       int
+}
+
+// replacementCauses are the causes whose terminations are followed by a
+// replacement rather than a smaller group
+var replacementCauses = map[string]bool{
+	"Health check failure": true,
+	"Spot interruption":    true,
+	"Instance refresh":     true,
+	"AZ rebalancing":       true,
+}
+
+var instanceIDPattern = regexp.MustCompile(`i-[0-9a-f]+`)
+
+// ShowASGHistory fetches every scaling activity of an ASG within the window
+// and summarizes them by cause, with the mean time to replace an instance
+// and the instances that were launched and terminated again in the window.
+// EC2 Auto Scaling keeps six weeks of activities.
+func ShowASGHistory(asgName string, options HistoryOptions) error {
+	if options.Since <= 0 {
+		return fmt.Errorf("--since must be greater than zero")
+	}
+	sess, err := NewSession(options.Profile, options.Region)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-options.Since)
+
+	var activities []historyActivity
+	input := &autoscaling.DescribeScalingActivitiesInput{
+		AutoScalingGroupName: aws.String(asgName),
+		MaxRecords:           aws.Int64(100),
+	}
+	// Activities come newest first, so paging stops at the first one older
+	// than the window
+	err = autoscaling.New(sess).DescribeScalingActivitiesPages(input, func(page *autoscaling.DescribeScalingActivitiesOutput, lastPage bool) bool {
+		for _, activity := range page.Activities {
+			start := aws.TimeValue(activity.StartTime)
+			if start.Before(cutoff) {
+				return false
+			}
+			description := aws.StringValue(activity.Description)
+			activities = append(activities, historyActivity{
+				launch:     strings.HasPrefix(description, "Launching"),
+				instanceID: instanceIDPattern.FindString(description),
+				cause:      classifyActivityCause(aws.StringValue(activity.Cause)),
+				failed:     aws.StringValue(activity.StatusCode) == autoscaling.ScalingActivityStatusCodeFailed,
+				start:      start,
+				end:        aws.TimeValue(activity.EndTime),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe scaling activities: %w", err)
+	}
+
+	fmt.Printf("Scaling activities of ASG '%s' since %s: %d\n", asgName, cutoff.Format("2006-01-02 15:04:05"), len(activities))
+	if len(activities) == 0 {
+		return nil
+	}
+	sort.Slice(activities, func(i, j int) bool { return activities[i].start.Before(activities[j].start) })
+
+	counts := make(map[string]*causeCount)
+	launches, terminations, failed := 0, 0, 0
+	for _, activity := range activities {
+		count := counts[activity.cause]
+		if count == nil {
+			count = &causeCount{}
+			counts[activity.cause] = count
+		}
+		switch {
+		case activity.failed:
+			count.failed++
+			failed++
+		case activity.launch:
+			count.launches++
+			launches++
+		default:
+			count.terminations++
+			terminations++
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nCAUSE\tLAUNCHES\tTERMINATIONS\tFAILED")
+	for _, cause := range sortedKeys(counts) {
+		count := counts[cause]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", cause, count.launches, count.terminations, count.failed)
+	}
+	w.Flush()
+
+	churned := churnedInstances(activities)
+	if len(churned) > 0 {
+		fmt.Println("\nInstances launched and terminated within the window:")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "INSTANCE\tLAUNCHED\tTERMINATED\tLIFETIME\tTERMINATION CAUSE")
+		for _, pair := range churned {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", pair[0].instanceID,
+				pair[0].start.Format("2006-01-02 15:04"), pair[1].start.Format("2006-01-02 15:04"),
+				pair[1].start.Sub(pair[0].start).Round(time.Minute), pair[1].cause)
+		}
+		w.Flush()
+	}
+
+	fmt.Println("\n--- ASG History Summary ---")
+	fmt.Printf("Launches: %d\n", launches)
+	fmt.Printf("Terminations: %d\n", terminations)
+	if failed > 0 {
+		fmt.Printf("❌ Failed activities: %d\n", failed)
+	}
+	if mean, replaced := meanReplacementTime(activities); replaced > 0 {
+		fmt.Printf("Mean replacement time: %s (%d replacements)\n", mean.Round(time.Second), replaced)
+	}
+	if len(churned) > 0 {
+		fmt.Printf("⚠️  Churned instances: %d\n", len(churned))
+	}
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// classifyActivityCause groups the free-form cause of a scaling activity.
+// Health checks come first since their causes also mention capacity changes.
+func classifyActivityCause(cause string) string {
+	lower := strings.ToLower(cause)
+	switch {
+	case strings.Contains(lower, "health-check") || strings.Contains(lower, "health check") || strings.Contains(lower, "unhealthy"):
+		return "Health check failure"
+	case strings.Contains(lower, "spot") || strings.Contains(lower, "rebalance recommendation"):
+		return "Spot interruption"
+	case strings.Contains(lower, "instance refresh"):
+		return "Instance refresh"
+	case strings.Contains(lower, "rebalanc"):
+		return "AZ rebalancing"
+	case strings.Contains(lower, "scheduled action"):
+		return "Scheduled action"
+	case strings.Contains(lower, "policy") || strings.Contains(lower, "alarm"):
+		return "Policy scaling"
+	case strings.Contains(lower, "user request"):
+		return "User request"
+	case strings.Contains(lower, "capacity from"):
+		return "Capacity change"
+	}
+	return "Other"
+}
+
+// churnedInstances pairs the launch and termination of instances that came
+// and went within the window, shortest lived first.
+func churnedInstances(activities []historyActivity) [][2]historyActivity {
+	launched := make(map[string]historyActivity)
+	var churned [][2]historyActivity
+	for _, activity := range activities {
+		if activity.instanceID == "" || activity.failed {
+			continue
+		}
+		if activity.launch {
+			launched[activity.instanceID] = activity
+		} else if launch, ok := launched[activity.instanceID]; ok {
+			churned = append(churned, [2]historyActivity{launch, activity})
+		}
+	}
+	sort.Slice(churned, func(i, j int) bool {
+		return churned[i][1].start.Sub(churned[i][0].start) < churned[j][1].start.Sub(churned[j][0].start)
+	})
+	return churned
+}
+
+// meanReplacementTime pairs each termination that the ASG replaces with the
+// next successful launch and averages the time from the termination starting
+// to the replacement being launched.
+func meanReplacementTime(activities []historyActivity) (time.Duration, int) {
+	used := make(map[int]bool)
+	var total time.Duration
+	replaced := 0
+	for _, termination := range activities {
+		if termination.launch || termination.failed || !replacementCauses[termination.cause] {
+			continue
+		}
+		for j, launch := range activities {
+			if used[j] || !launch.launch || launch.failed || launch.start.Before(termination.start) || launch.end.IsZero() {
+				continue
+			}
+			used[j] = true
+			total += launch.end.Sub(termination.start)
+			replaced++
+			break
+		}
+	}
+	if replaced == 0 {
+		return 0, 0
+	}
+	return total / time.Duration(replaced), replaced
+}
@@ -0,0 +1,139 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/HighonAces/swissarmycli/internal/apicalls"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+// NewSession creates an AWS session using the shared config, optionally
+// overriding the profile and region.
+func NewSession(profile, region string) (*session.Session, error) {
+	sessOptions := session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	}
+	if profile != "" {
+		sessOptions.Profile = profile
+	}
+
+	sess, err := session.NewSessionWithOptions(sessOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %w", err)
+	}
+	if region != "" {
+		sess.Config.Region = aws.String(region)
+	}
+	return apicalls.InstrumentSession(sess), nil
+}
+
+// GetASGInstanceIDs returns the IDs of all instances currently attached to the ASG.
+func GetASGInstanceIDs(sess *session.Session, asgName string) ([]string, error) {
+	output, err := autoscaling.New(sess).DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{aws.String(asgName)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(output.AutoScalingGroups) == 0 {
+		return nil, fmt.Errorf("ASG not found: %s", asgName)
+	}
+
+	var instanceIDs []string
+	for _, instance := range output.AutoScalingGroups[0].Instances {
+		instanceIDs = append(instanceIDs, aws.StringValue(instance.InstanceId))
+	}
+	return instanceIDs, nil
+}
+
+// GetASGCapacity returns the desired capacity and minimum size of the ASG.
+func GetASGCapacity(sess *session.Session, asgName string) (int64, int64, error) {
+	output, err := autoscaling.New(sess).DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{aws.String(asgName)},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(output.AutoScalingGroups) == 0 {
+		return 0, 0, fmt.Errorf("ASG not found: %s", asgName)
+	}
+	group := output.AutoScalingGroups[0]
+	return aws.Int64Value(group.DesiredCapacity), aws.Int64Value(group.MinSize), nil
+}
+
+// TerminateASGInstance terminates an instance through its ASG. When decrement
+// is false the ASG launches a replacement to keep the desired capacity.
+func TerminateASGInstance(sess *session.Session, instanceID string, decrement bool) error {
+	_, err := autoscaling.New(sess).TerminateInstanceInAutoScalingGroup(&autoscaling.TerminateInstanceInAutoScalingGroupInput{
+		InstanceId:                     aws.String(instanceID),
+		ShouldDecrementDesiredCapacity: aws.Bool(decrement),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to terminate instance %s: %w", instanceID, err)
+	}
+	return nil
+}
+
+// IsInstanceNotInASG reports whether TerminateASGInstance failed because
+// the instance is already gone or no longer managed by an ASG.
+func IsInstanceNotInASG(err error) bool {
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) || aerr.Code() != "ValidationError" {
+		return false
+	}
+	message := strings.ToLower(aerr.Message())
+	return strings.Contains(message, "not found") || strings.Contains(message, "not part of")
+}
+
+// InstanceIDFromProviderID extracts the EC2 instance ID from a node's
+// providerID (aws:///us-west-2a/i-1234567890abcdef0).
+func InstanceIDFromProviderID(providerID string) string {
+	return extractInstanceIDFromProviderID(providerID)
+}
+
+// LifecycleHook is a termination lifecycle hook holding an instance in
+// Terminating:Wait, the window a termination handler has to drain it
+type LifecycleHook struct {
+	Name             string
+	HeartbeatTimeout int64 // Seconds
+	DefaultResult    string
+}
+
+// TerminationLifecycleHooks returns the ASG the instance belongs to, empty
+// when it isn't in one, and the ASG's termination lifecycle hooks.
+func TerminationLifecycleHooks(sess *session.Session, instanceID string) (string, []LifecycleHook, error) {
+	client := autoscaling.New(sess)
+	output, err := client.DescribeAutoScalingInstances(&autoscaling.DescribeAutoScalingInstancesInput{
+		InstanceIds: []*string{aws.String(instanceID)},
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to describe instance %s: %w", instanceID, err)
+	}
+	if len(output.AutoScalingInstances) == 0 {
+		return "", nil, nil
+	}
+	asgName := aws.StringValue(output.AutoScalingInstances[0].AutoScalingGroupName)
+	hooks, err := client.DescribeLifecycleHooks(&autoscaling.DescribeLifecycleHooksInput{
+		AutoScalingGroupName: aws.String(asgName),
+	})
+	if err != nil {
+		return asgName, nil, fmt.Errorf("failed to describe lifecycle hooks of %s: %w", asgName, err)
+	}
+	var termination []LifecycleHook
+	for _, hook := range hooks.LifecycleHooks {
+		if aws.StringValue(hook.LifecycleTransition) != "autoscaling:EC2_INSTANCE_TERMINATING" {
+			continue
+		}
+		termination = append(termination, LifecycleHook{
+			Name:             aws.StringValue(hook.LifecycleHookName),
+			HeartbeatTimeout: aws.Int64Value(hook.HeartbeatTimeout),
+			DefaultResult:    aws.StringValue(hook.DefaultResult),
+		})
+	}
+	return asgName, termination, nil
+}
@@ -0,0 +1,283 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ASG tags naming the node group an ASG belongs to, by source, in the order
+// they are checked
+var asgGroupTags = []struct {
+	source     string
+	groupTag   string
+	clusterTag string
+}{
+	{"eks-managed", "eks:nodegroup-name", "eks:cluster-name"},
+	{"karpenter", "karpenter.sh/nodepool", ""},
+	{"karpenter", "karpenter.sh/provisioner-name", ""},
+	{"eksctl", "alpha.eksctl.io/nodegroup-name", "alpha.eksctl.io/cluster-name"},
+}
+
+// karpenterNodePoolLabel names the Karpenter NodePool that launched a node
+const karpenterNodePoolLabel = "karpenter.sh/nodepool"
+
+// ASGMapOptions contains options for mapping ASGs to node groups and nodes
+type ASGMapOptions struct {
+	Region  string
+	Profile string
+	Cluster string // Only ASGs of this cluster
+	Output  string // table or json
+}
+
+// ASGMapping is one ASG with the node group it backs and the Kubernetes
+// nodes running on its instances
+type ASGMapping struct {
+	ASG        string   `json:"asg"` // Empty for Karpenter NodePools, which launch instances without an ASG
+	Cluster    string   `json:"cluster"`
+	Group      string   `json:"nodeGroup"`
+	Source     string   `json:"source"` // eks-managed, karpenter, eksctl or self-managed
+	Desired    int64    `json:"desired"`
+	Min        int64    `json:"min"`
+	Max        int64    `json:"max"`
+	Instances  []string `json:"instances"`
+	Nodes      []string `json:"nodes"`
+	Unattached []string `json:"unregisteredInstances,omitempty"` // Instances without a Kubernetes node
+}
+
+// MapASGs prints every ASG of the region with the EKS managed node group,
+// Karpenter NodePool or eksctl node group it belongs to, read from its
+// tags, and the nodes of the current cluster running on its instances.
+// Karpenter NodePools whose nodes aren't in any ASG are listed too. The
+// node group names can be passed to asg-status instead of ASG names.
+func MapASGs(options ASGMapOptions) error {
+	if options.Output != "table" && options.Output != "json" {
+		return fmt.Errorf("invalid output format '%s' (must be table or json)", options.Output)
+	}
+	sess, err := NewSession(options.Profile, options.Region)
+	if err != nil {
+		return err
+	}
+	mappings, err := listASGMappings(sess)
+	if err != nil {
+		return err
+	}
+
+	// Nodes are matched to ASGs by instance ID; without cluster access the
+	// map still shows the node groups
+	nodesByInstance := make(map[string]string)
+	karpenterNodes := make(map[string][]string)
+	clusterReached := true
+	clientset, err := common.GetKubernetesClient()
+	if err == nil {
+		list, listErr := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+		err = listErr
+		if err == nil {
+			for _, node := range list.Items {
+				if instanceID := extractInstanceIDFromProviderID(node.Spec.ProviderID); instanceID != "" {
+					nodesByInstance[instanceID] = node.Name
+				}
+				if pool := node.Labels[karpenterNodePoolLabel]; pool != "" {
+					karpenterNodes[pool] = append(karpenterNodes[pool], node.Name)
+				}
+			}
+		}
+	}
+	if err != nil {
+		clusterReached = false
+		fmt.Fprintf(os.Stderr, "Warning: could not list Kubernetes nodes, showing ASGs only: %v\n", err)
+	}
+
+	var filtered []ASGMapping
+	inASG := make(map[string]bool)
+	for _, mapping := range mappings {
+		if options.Cluster != "" && mapping.Cluster != options.Cluster {
+			continue
+		}
+		for _, instanceID := range mapping.Instances {
+			if node, ok := nodesByInstance[instanceID]; ok {
+				mapping.Nodes = append(mapping.Nodes, node)
+				inASG[node] = true
+			} else if clusterReached {
+				mapping.Unattached = append(mapping.Unattached, instanceID)
+			}
+		}
+		filtered = append(filtered, mapping)
+	}
+	for _, pool := range sortedKeys(karpenterNodes) {
+		mapping := ASGMapping{Cluster: options.Cluster, Group: pool, Source: "karpenter"}
+		for _, node := range karpenterNodes[pool] {
+			if !inASG[node] {
+				mapping.Nodes = append(mapping.Nodes, node)
+			}
+		}
+		if len(mapping.Nodes) > 0 {
+			filtered = append(filtered, mapping)
+		}
+	}
+
+	if options.Output == "json" {
+		encoder := json.NewEncoder(sink.Output())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(filtered)
+	}
+	if len(filtered) == 0 {
+		fmt.Println("No ASGs found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ASG\tCLUSTER\tNODE GROUP\tSOURCE\tDESIRED/MIN/MAX\tINSTANCES\tNODES")
+	var unattached int
+	for _, mapping := range filtered {
+		capacity, instances := fmt.Sprintf("%d/%d/%d", mapping.Desired, mapping.Min, mapping.Max), fmt.Sprint(len(mapping.Instances))
+		nodes := fmt.Sprint(len(mapping.Nodes))
+		if mapping.ASG == "" {
+			capacity, instances = "-", "-"
+		}
+		switch {
+		case !clusterReached:
+			nodes = "-"
+		case len(mapping.Unattached) > 0:
+			nodes = fmt.Sprintf("⚠️ %d (%d instances not registered)", len(mapping.Nodes), len(mapping.Unattached))
+			unattached += len(mapping.Unattached)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", valueOrDash(mapping.ASG), valueOrDash(mapping.Cluster),
+			valueOrDash(mapping.Group), mapping.Source, capacity, instances, nodes)
+	}
+	w.Flush()
+
+	fmt.Println("\n--- ASG Map Summary ---")
+	groups := 0
+	for _, mapping := range filtered {
+		if mapping.ASG != "" {
+			groups++
+		}
+	}
+	fmt.Printf("ASGs: %d, Karpenter NodePools without ASG: %d\n", groups, len(filtered)-groups)
+	if unattached > 0 {
+		fmt.Printf("⚠️  %d instances have not registered as nodes of the current cluster; they may still be booting, or belong to another cluster\n", unattached)
+	}
+	fmt.Println("Target an ASG by its node group: swissarmycli asg-status <node group>")
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// listASGMappings describes every ASG of the region and reads the node
+// group each belongs to from its tags
+func listASGMappings(sess *session.Session) ([]ASGMapping, error) {
+	var mappings []ASGMapping
+	err := autoscaling.New(sess).DescribeAutoScalingGroupsPages(&autoscaling.DescribeAutoScalingGroupsInput{},
+		func(page *autoscaling.DescribeAutoScalingGroupsOutput, lastPage bool) bool {
+			for _, asg := range page.AutoScalingGroups {
+				mapping := ASGMapping{
+					ASG:     aws.StringValue(asg.AutoScalingGroupName),
+					Desired: aws.Int64Value(asg.DesiredCapacity),
+					Min:     aws.Int64Value(asg.MinSize),
+					Max:     aws.Int64Value(asg.MaxSize),
+				}
+				tags := make(map[string]string)
+				for _, tag := range asg.Tags {
+					tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+				}
+				mapping.Source, mapping.Group, mapping.Cluster = asgGroupFromTags(tags)
+				for _, instance := range asg.Instances {
+					mapping.Instances = append(mapping.Instances, aws.StringValue(instance.InstanceId))
+				}
+				mappings = append(mappings, mapping)
+			}
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe ASGs: %w", err)
+	}
+	sort.Slice(mappings, func(i, j int) bool {
+		if mappings[i].Cluster != mappings[j].Cluster {
+			return mappings[i].Cluster < mappings[j].Cluster
+		}
+		return mappings[i].Group < mappings[j].Group
+	})
+	return mappings, nil
+}
+
+// asgGroupFromTags returns the source, node group and cluster of an ASG.
+// ASGs of a cluster without a node group tag are self-managed.
+func asgGroupFromTags(tags map[string]string) (string, string, string) {
+	cluster := ""
+	for key, value := range tags {
+		if name, ok := strings.CutPrefix(key, "kubernetes.io/cluster/"); ok && (value == "owned" || value == "shared") {
+			cluster = name
+		}
+	}
+	for _, candidate := range asgGroupTags {
+		group := tags[candidate.groupTag]
+		if group == "" {
+			continue
+		}
+		if candidate.clusterTag != "" && tags[candidate.clusterTag] != "" {
+			cluster = tags[candidate.clusterTag]
+		}
+		return candidate.source, group, cluster
+	}
+	if cluster != "" {
+		return "self-managed", "", cluster
+	}
+	return "-", "", ""
+}
+
+// ResolveASGName returns the ASG of a node group, so commands taking an
+// ASG name also accept the node group name from asg map, optionally as
+// cluster/nodegroup. Names of existing ASGs are returned unchanged.
+func ResolveASGName(name, profile, region string) (string, error) {
+	sess, err := NewSession(profile, region)
+	if err != nil {
+		return "", err
+	}
+	output, err := autoscaling.New(sess).DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{aws.String(name)},
+	})
+	if err == nil && len(output.AutoScalingGroups) > 0 {
+		return name, nil
+	}
+
+	mappings, err := listASGMappings(sess)
+	if err != nil {
+		return "", err
+	}
+	cluster, group, qualified := strings.Cut(name, "/")
+	if !qualified {
+		group = name
+	}
+	var matches []string
+	for _, mapping := range mappings {
+		if mapping.Group == group && (!qualified || mapping.Cluster == cluster) {
+			matches = append(matches, mapping.ASG)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no ASG or node group named %s", name)
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("node group %s matches %d ASGs (%s); use cluster/nodegroup or the ASG name", name, len(matches), strings.Join(matches, ", "))
+}
+
+// valueOrDash returns "-" for empty table cells
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
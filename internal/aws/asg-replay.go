@@ -0,0 +1,210 @@
+package aws
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/ui"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// replayTick is how often the replay clock advances
+const replayTick = 100 * time.Millisecond
+
+// asgSnapshot is one refresh of the ASG monitor, one line of a recording
+type asgSnapshot struct {
+	Time time.Time `json:"time"`
+	ASG  ASGData   `json:"asg"`
+}
+
+// asgRecorder appends monitor refreshes to a JSON Lines file. A nil
+// recorder records nothing.
+type asgRecorder struct {
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// newASGRecorder opens the recording file for appending, so an interrupted
+// monitor can continue the same recording. It returns nil for an empty path.
+func newASGRecorder(path string) (*asgRecorder, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording %s: %w", path, err)
+	}
+	return &asgRecorder{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+// Write appends a snapshot of the ASG taken now
+func (r *asgRecorder) Write(asg ASGData) error {
+	if r == nil {
+		return nil
+	}
+	if err := r.encoder.Encode(asgSnapshot{Time: time.Now(), ASG: asg}); err != nil {
+		return fmt.Errorf("failed to record snapshot: %w", err)
+	}
+	return nil
+}
+
+// Close closes the recording file
+func (r *asgRecorder) Close() {
+	if r != nil {
+		r.file.Close()
+	}
+}
+
+// ReplayOptions contains options for replaying an ASG monitor recording
+type ReplayOptions struct {
+	Speed float64 // Playback speed, 2 plays twice as fast as recorded
+}
+
+// Replay plays a recording made with asg-status --stream --record back in
+// the monitor dashboard, at the pace the snapshots were recorded divided by
+// the speed. Playback can be paused, stepped through and sped up or slowed
+// down from the keyboard.
+func Replay(path string, options ReplayOptions) error {
+	if options.Speed <= 0 {
+		return fmt.Errorf("--speed must be greater than 0")
+	}
+	snapshots, err := readASGRecording(path)
+	if err != nil {
+		return err
+	}
+	theme, err := ui.LoadTheme()
+	if err != nil {
+		return err
+	}
+
+	app := tview.NewApplication()
+	flex := tview.NewFlex().SetDirection(tview.FlexRow)
+	dashboard := tview.NewTextView().
+		SetDynamicColors(true).
+		SetRegions(true).
+		SetWordWrap(true)
+	logView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetRegions(true).
+		SetWordWrap(true)
+	flex.AddItem(dashboard, 0, 1, false)
+	flex.AddItem(logView, 8, 1, false)
+
+	// The playback state is only touched on the UI goroutine
+	current := 0
+	clock := snapshots[0].Time // Recorded time being played
+	speed := options.Speed
+	paused := false
+	first, last := snapshots[0].Time, snapshots[len(snapshots)-1].Time
+
+	render := func() {
+		snapshot := snapshots[current]
+		dashboard.Clear()
+		renderASGDashboard(dashboard, snapshot.ASG, snapshot.Time, theme)
+
+		state := fmt.Sprintf("playing at %gx", speed)
+		switch {
+		case current == len(snapshots)-1:
+			state = "end of recording"
+		case paused:
+			state = "paused"
+		}
+		logView.Clear()
+		fmt.Fprintf(logView, "%s %s\n", theme.Paint(theme.Title, "REPLAY:"),
+			theme.Paint(theme.Muted, "space pause │ ←/→ step │ +/- speed │ q quit"))
+		fmt.Fprintf(logView, "%s Snapshot %d/%d at %s (%s into the recording, %s total), %s\n",
+			theme.Paint(theme.Muted, logTime(snapshot.Time.Local())), current+1, len(snapshots),
+			snapshot.Time.Local().Format("2006-01-02 15:04:05"),
+			snapshot.Time.Sub(first).Round(time.Second), last.Sub(first).Round(time.Second), state)
+		renderActivityLog(logView, snapshot.ASG, theme)
+	}
+	step := func(delta int) {
+		current = min(max(current+delta, 0), len(snapshots)-1)
+		clock = snapshots[current].Time
+		render()
+	}
+
+	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape || event.Rune() == 'q':
+			app.Stop()
+		case event.Rune() == ' ':
+			paused = !paused
+			render()
+		case event.Key() == tcell.KeyRight:
+			paused = true
+			step(1)
+		case event.Key() == tcell.KeyLeft:
+			paused = true
+			step(-1)
+		case event.Rune() == '+':
+			speed *= 2
+			render()
+		case event.Rune() == '-':
+			speed /= 2
+			render()
+		}
+		return event
+	})
+	render()
+
+	go func() {
+		ticker := time.NewTicker(replayTick)
+		defer ticker.Stop()
+		for range ticker.C {
+			app.QueueUpdateDraw(func() {
+				if paused || current == len(snapshots)-1 {
+					return
+				}
+				clock = clock.Add(time.Duration(float64(replayTick) * speed))
+				next := current
+				for next < len(snapshots)-1 && !snapshots[next+1].Time.After(clock) {
+					next++
+				}
+				if next != current {
+					current = next
+					render()
+				}
+			})
+		}
+	}()
+
+	if err := app.SetRoot(flex, true).EnableMouse(true).Run(); err != nil {
+		return fmt.Errorf("error running application: %v", err)
+	}
+	return nil
+}
+
+// readASGRecording reads the snapshots of a recording in recorded order
+func readASGRecording(path string) ([]asgSnapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer file.Close()
+
+	var snapshots []asgSnapshot
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var snapshot asgSnapshot
+		if err := json.Unmarshal(scanner.Bytes(), &snapshot); err != nil {
+			return nil, fmt.Errorf("failed to parse line %d of %s: %w", line, path, err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("recording %s has no snapshots", path)
+	}
+	return snapshots, nil
+}
@@ -0,0 +1,221 @@
+package aws
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// ScalingPolicyData holds a scaling policy of the ASG and the alarms driving it
+type ScalingPolicyData struct {
+	Name    string
+	Type    string // TargetTrackingScaling, StepScaling, SimpleScaling or PredictiveScaling
+	Trigger string // Target metric and value, or the adjustment applied
+	Enabled bool
+	Alarms  []AlarmData
+}
+
+// AlarmData holds a CloudWatch alarm and its current state
+type AlarmData struct {
+	Name  string
+	State string // OK, ALARM or INSUFFICIENT_DATA
+}
+
+// ScheduledActionData holds an upcoming scheduled action of the ASG
+type ScheduledActionData struct {
+	Name        string
+	NextRun     time.Time
+	Recurrence  string
+	MinSize     string
+	MaxSize     string
+	DesiredSize string
+}
+
+// maxAlarmNamesPerCall is the DescribeAlarms limit on alarm names per request
+const maxAlarmNamesPerCall = 100
+
+// fetchScalingPolicies returns the ASG's scaling policies, with the current
+// state of the CloudWatch alarms attached to each.
+func fetchScalingPolicies(sess *session.Session, asgName string) ([]ScalingPolicyData, error) {
+	svc := autoscaling.New(sess)
+
+	var policies []*autoscaling.ScalingPolicy
+	err := svc.DescribePoliciesPages(&autoscaling.DescribePoliciesInput{
+		AutoScalingGroupName: aws.String(asgName),
+	}, func(page *autoscaling.DescribePoliciesOutput, lastPage bool) bool {
+		policies = append(policies, page.ScalingPolicies...)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe scaling policies: %w", err)
+	}
+
+	var alarmNames []string
+	for _, policy := range policies {
+		for _, alarm := range policy.Alarms {
+			alarmNames = append(alarmNames, aws.StringValue(alarm.AlarmName))
+		}
+	}
+	alarmStates, err := fetchAlarmStates(sess, alarmNames)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []ScalingPolicyData
+	for _, policy := range policies {
+		data := ScalingPolicyData{
+			Name:    aws.StringValue(policy.PolicyName),
+			Type:    aws.StringValue(policy.PolicyType),
+			Trigger: describePolicyTrigger(policy),
+			Enabled: policy.Enabled == nil || *policy.Enabled,
+		}
+		for _, alarm := range policy.Alarms {
+			name := aws.StringValue(alarm.AlarmName)
+			state, ok := alarmStates[name]
+			if !ok {
+				state = "UNKNOWN"
+			}
+			data.Alarms = append(data.Alarms, AlarmData{Name: name, State: state})
+		}
+		result = append(result, data)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// fetchAlarmStates returns the state of each named CloudWatch alarm.
+func fetchAlarmStates(sess *session.Session, alarmNames []string) (map[string]string, error) {
+	states := make(map[string]string)
+	if len(alarmNames) == 0 {
+		return states, nil
+	}
+
+	svc := cloudwatch.New(sess)
+	for start := 0; start < len(alarmNames); start += maxAlarmNamesPerCall {
+		end := start + maxAlarmNamesPerCall
+		if end > len(alarmNames) {
+			end = len(alarmNames)
+		}
+		err := svc.DescribeAlarmsPages(&cloudwatch.DescribeAlarmsInput{
+			AlarmNames: aws.StringSlice(alarmNames[start:end]),
+		}, func(page *cloudwatch.DescribeAlarmsOutput, lastPage bool) bool {
+			for _, alarm := range page.MetricAlarms {
+				states[aws.StringValue(alarm.AlarmName)] = aws.StringValue(alarm.StateValue)
+			}
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe CloudWatch alarms: %w", err)
+		}
+	}
+	return states, nil
+}
+
+// describePolicyTrigger summarizes what makes a policy act, e.g.
+// "ASGAverageCPUUtilization @ 60" or "+2 (ChangeInCapacity)".
+func describePolicyTrigger(policy *autoscaling.ScalingPolicy) string {
+	if tt := policy.TargetTrackingConfiguration; tt != nil {
+		metric := "custom metric"
+		if tt.PredefinedMetricSpecification != nil {
+			metric = aws.StringValue(tt.PredefinedMetricSpecification.PredefinedMetricType)
+		} else if tt.CustomizedMetricSpecification != nil && tt.CustomizedMetricSpecification.MetricName != nil {
+			metric = aws.StringValue(tt.CustomizedMetricSpecification.MetricName)
+		}
+		trigger := fmt.Sprintf("%s @ %g", metric, aws.Float64Value(tt.TargetValue))
+		if aws.BoolValue(tt.DisableScaleIn) {
+			trigger += " (no scale-in)"
+		}
+		return trigger
+	}
+
+	if len(policy.StepAdjustments) > 0 {
+		var steps []string
+		for _, step := range policy.StepAdjustments {
+			lower, upper := "-inf", "+inf"
+			if step.MetricIntervalLowerBound != nil {
+				lower = fmt.Sprintf("%g", *step.MetricIntervalLowerBound)
+			}
+			if step.MetricIntervalUpperBound != nil {
+				upper = fmt.Sprintf("%g", *step.MetricIntervalUpperBound)
+			}
+			steps = append(steps, fmt.Sprintf("[%s,%s)→%+d", lower, upper, aws.Int64Value(step.ScalingAdjustment)))
+		}
+		return strings.Join(steps, " ") + " (" + aws.StringValue(policy.AdjustmentType) + ")"
+	}
+
+	if policy.ScalingAdjustment != nil {
+		return fmt.Sprintf("%+d (%s)", *policy.ScalingAdjustment, aws.StringValue(policy.AdjustmentType))
+	}
+	if policy.PredictiveScalingConfiguration != nil {
+		return "forecast based"
+	}
+	return "-"
+}
+
+// fetchScheduledActions returns the ASG's scheduled actions that have not
+// finished yet, soonest first.
+func fetchScheduledActions(sess *session.Session, asgName string) ([]ScheduledActionData, error) {
+	svc := autoscaling.New(sess)
+
+	var actions []ScheduledActionData
+	err := svc.DescribeScheduledActionsPages(&autoscaling.DescribeScheduledActionsInput{
+		AutoScalingGroupName: aws.String(asgName),
+	}, func(page *autoscaling.DescribeScheduledActionsOutput, lastPage bool) bool {
+		for _, action := range page.ScheduledUpdateGroupActions {
+			if action.EndTime != nil && action.EndTime.Before(time.Now()) {
+				continue
+			}
+			data := ScheduledActionData{
+				Name:        aws.StringValue(action.ScheduledActionName),
+				Recurrence:  aws.StringValue(action.Recurrence),
+				MinSize:     formatOptionalSize(action.MinSize),
+				MaxSize:     formatOptionalSize(action.MaxSize),
+				DesiredSize: formatOptionalSize(action.DesiredCapacity),
+			}
+			// StartTime holds the next run of recurring actions
+			if action.StartTime != nil {
+				data.NextRun = *action.StartTime
+			} else if action.Time != nil {
+				data.NextRun = *action.Time
+			}
+			if data.Recurrence != "" && action.TimeZone != nil {
+				data.Recurrence += " " + *action.TimeZone
+			}
+			actions = append(actions, data)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe scheduled actions: %w", err)
+	}
+
+	sort.Slice(actions, func(i, j int) bool { return actions[i].NextRun.Before(actions[j].NextRun) })
+	return actions, nil
+}
+
+// formatOptionalSize renders a size a scheduled action may leave unchanged
+func formatOptionalSize(size *int64) string {
+	if size == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d", *size)
+}
+
+// alarmSummary renders alarms as "name (STATE)" for display
+func alarmSummary(alarms []AlarmData) string {
+	if len(alarms) == 0 {
+		return "-"
+	}
+	var parts []string
+	for _, alarm := range alarms {
+		parts = append(parts, fmt.Sprintf("%s (%s)", alarm.Name, alarm.State))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -5,6 +5,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/HighonAces/swissarmycli/internal/apicalls"
+	"github.com/HighonAces/swissarmycli/internal/ui"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
@@ -23,6 +25,8 @@ type ASGData struct {
 	LaunchTemplate string
 	Instances      []InstanceData
 	Activities     []ActivityData
+	Policies       []ScalingPolicyData
+	Scheduled      []ScheduledActionData
 	CPUUtilization int // For demo or would be fetched from CloudWatch
 	NetworkUsage   int // For demo or would be fetched from CloudWatch
 	ScalingStatus  string
@@ -48,15 +52,31 @@ type ActivityData struct {
 	Description string
 }
 
+// maxExternalLogLines is how many progress messages MonitorWithLog keeps on screen
+const maxExternalLogLines = 8
+
 // MonitorOptions contains options for the ASG monitor
 type MonitorOptions struct {
 	RefreshInterval int
 	Region          string
 	Profile         string
+	Record          string // JSON Lines file every refresh is appended to, for asg replay
 }
 
 // Monitor starts a terminal-based monitor for an AWS Auto Scaling Group
 func Monitor(asgName string, options MonitorOptions) error {
+	return MonitorWithLog(asgName, options, nil)
+}
+
+// MonitorWithLog starts the ASG monitor and additionally shows every message
+// received on logs in the live log, so long-running operations against the
+// ASG can report their progress inside the dashboard.
+func MonitorWithLog(asgName string, options MonitorOptions, logs <-chan string) error {
+	theme, err := ui.LoadTheme()
+	if err != nil {
+		return err
+	}
+
 	// Create a new application
 	app := tview.NewApplication()
 
@@ -65,7 +85,6 @@ func Monitor(asgName string, options MonitorOptions) error {
 
 	// Initialize AWS session
 	var sess *session.Session
-	var err error
 
 	sessOptions := session.Options{
 		SharedConfigState: session.SharedConfigEnable,
@@ -79,6 +98,7 @@ func Monitor(asgName string, options MonitorOptions) error {
 	if err != nil {
 		return fmt.Errorf("failed to create AWS session: %v", err)
 	}
+	apicalls.InstrumentSession(sess)
 
 	if options.Region != "" {
 		sess.Config.Region = aws.String(options.Region)
@@ -90,6 +110,12 @@ func Monitor(asgName string, options MonitorOptions) error {
 		return fmt.Errorf("failed to fetch ASG data: %v", err)
 	}
 
+	recorder, err := newASGRecorder(options.Record)
+	if err != nil {
+		return err
+	}
+	defer recorder.Close()
+
 	// Create our main text view
 	dashboard := tview.NewTextView().
 		SetDynamicColors(true).
@@ -100,27 +126,45 @@ func Monitor(asgName string, options MonitorOptions) error {
 	logView := tview.NewTextView().
 		SetDynamicColors(true).
 		SetRegions(true).
-		SetWordWrap(true).
-		SetTextColor(tcell.ColorLightGray)
+		SetWordWrap(true)
 
 	// Add components to the flex container
+	logHeight := 7
+	if logs != nil {
+		logHeight = 7 + maxExternalLogLines
+	}
 	flex.AddItem(dashboard, 0, 1, false)
-	flex.AddItem(logView, 7, 1, false)
+	flex.AddItem(logView, logHeight, 1, false)
+
+	var externalLog []string
 
 	// Function to update the dashboard display
 	updateDashboard := func() {
 		dashboard.Clear()
-		renderASGDashboard(dashboard, asgData)
+		renderASGDashboard(dashboard, asgData, time.Now(), theme)
 
 		// Update the log with recent activity
 		logView.Clear()
-		fmt.Fprintf(logView, "[yellow]LIVE LOG:[white]\n")
-		fmt.Fprintf(logView, "[gray]%s[white] Monitoring ASG '%s'...\n", time.Now().Format("[15:04:05]"), asgData.Name)
+		fmt.Fprintf(logView, "%s\n", theme.Paint(theme.Title, "LIVE LOG:"))
+		fmt.Fprintf(logView, "%s Monitoring ASG '%s'...\n", theme.Paint(theme.Muted, logTime(time.Now())), asgData.Name)
+
+		renderActivityLog(logView, asgData, theme)
+
+		for _, line := range externalLog {
+			fmt.Fprintf(logView, "%s\n", theme.Paint(theme.OK, line))
+		}
+	}
 
-		// Add the most recent activities to the log
-		for i := 0; i < len(asgData.Activities) && i < 5; i++ {
-			activity := asgData.Activities[i]
-			fmt.Fprintf(logView, "[gray]%s[white] %s\n", activity.Time.Format("[15:04:05]"), activity.Description)
+	// refresh fetches new data, records it and redraws the dashboard
+	refresh := func() {
+		newData, err := fetchASGData(sess, asgName)
+		if err == nil {
+			asgData = newData
+			updateDashboard()
+			err = recorder.Write(asgData)
+		}
+		if err != nil {
+			fmt.Fprintf(logView, "%s Error refreshing data: %v\n", theme.Paint(theme.Error, logTime(time.Now())), tview.Escape(err.Error()))
 		}
 	}
 
@@ -129,20 +173,16 @@ func Monitor(asgName string, options MonitorOptions) error {
 		if event.Key() == tcell.KeyEscape || event.Rune() == 'q' {
 			app.Stop()
 		} else if event.Rune() == 'r' {
-			// Refresh data
-			newData, err := fetchASGData(sess, asgName)
-			if err == nil {
-				asgData = newData
-				updateDashboard()
-			} else {
-				fmt.Fprintf(logView, "[red]%s[white] Error refreshing data: %v\n", time.Now().Format("[15:04:05]"), err)
-			}
+			refresh()
 		}
 		return event
 	})
 
 	// Initial render
 	updateDashboard()
+	if err := recorder.Write(asgData); err != nil {
+		return err
+	}
 
 	// Set up a ticker to update the display periodically
 	refreshInterval := time.Duration(options.RefreshInterval) * time.Second
@@ -155,18 +195,25 @@ func Monitor(asgName string, options MonitorOptions) error {
 		for {
 			select {
 			case <-ticker.C:
+				app.QueueUpdateDraw(refresh)
+			}
+		}
+	}()
+
+	if logs != nil {
+		go func() {
+			for msg := range logs {
+				line := fmt.Sprintf("%s %s", logTime(time.Now()), tview.Escape(msg))
 				app.QueueUpdateDraw(func() {
-					newData, err := fetchASGData(sess, asgName)
-					if err == nil {
-						asgData = newData
-						updateDashboard()
-					} else {
-						fmt.Fprintf(logView, "[red]%s[white] Error refreshing data: %v\n", time.Now().Format("[15:04:05]"), err)
+					externalLog = append(externalLog, line)
+					if len(externalLog) > maxExternalLogLines {
+						externalLog = externalLog[len(externalLog)-maxExternalLogLines:]
 					}
+					updateDashboard()
 				})
 			}
-		}
-	}()
+		}()
+	}
 
 	// Set the flex container as the root of the application and start
 	if err := app.SetRoot(flex, true).EnableMouse(true).Run(); err != nil {
@@ -176,11 +223,12 @@ func Monitor(asgName string, options MonitorOptions) error {
 	return nil
 }
 
-// renderASGDashboard creates a formatted display of ASG information
-func renderASGDashboard(view *tview.TextView, asg ASGData) {
+// renderASGDashboard creates a formatted display of ASG information as of
+// now, which is the time of the snapshot when replaying a recording
+func renderASGDashboard(view *tview.TextView, asg ASGData, now time.Time, theme ui.Theme) {
 	// Header
 	fmt.Fprintf(view, "╔═══ r-refresh ═════════ AWS Auto Scaling Group Monitor ══════ q-quit ===═══════╗\n")
-	fmt.Fprintf(view, "║ ASG Name: %-56s Refreshed: %s ║\n", asg.Name, time.Now().Format("15:04:05"))
+	fmt.Fprintf(view, "║ ASG Name: %-56s Refreshed: %s ║\n", asg.Name, now.Local().Format("15:04:05"))
 	fmt.Fprintf(view, "╠═══════════════════════════════════════════════════════════════════════════════╣\n")
 
 	// ASG Status
@@ -205,7 +253,7 @@ func renderASGDashboard(view *tview.TextView, asg ASGData) {
 	fmt.Fprintf(view, "╟──────────────────────┼──────────┼─────────┼──────────┼─────────┼─────────╢\n")
 
 	for _, instance := range asg.Instances {
-		ageDuration := time.Since(instance.LaunchTime)
+		ageDuration := now.Sub(instance.LaunchTime)
 		ageStr := fmt.Sprintf("%dh %dm", int(ageDuration.Hours()), int(ageDuration.Minutes())%60)
 
 		fmt.Fprintf(view, "║ %-20s │ %-8s │ %-7s │ %-8s │ %-7s │ %-7s ║\n",
@@ -231,6 +279,47 @@ func renderASGDashboard(view *tview.TextView, asg ASGData) {
 			truncateString(activity.Description, 18))
 	}
 
+	// Scaling policies section
+	fmt.Fprintf(view, "╠═════════════════════════════ SCALING POLICIES ════════════════════════════════╣\n")
+	if len(asg.Policies) == 0 {
+		fmt.Fprintf(view, "║ %-77s ║\n", "No scaling policies")
+	}
+	for _, policy := range asg.Policies {
+		name := policy.Name
+		if !policy.Enabled {
+			name += " (disabled)"
+		}
+		fmt.Fprintf(view, "║ %-28s │ %-20s │ %-24s ║\n",
+			truncateString(name, 28),
+			truncateString(policy.Type, 20),
+			truncateString(policy.Trigger, 24))
+		for _, alarm := range policy.Alarms {
+			style := theme.OK
+			if alarm.State == "ALARM" {
+				style = theme.Error
+			} else if alarm.State != "OK" {
+				style = theme.Warning
+			}
+			fmt.Fprintf(view, "║   alarm %-50s %s ║\n",
+				truncateString(alarm.Name, 50), theme.Paint(style, fmt.Sprintf("%-17s", alarm.State)))
+		}
+	}
+
+	// Scheduled actions section
+	fmt.Fprintf(view, "╠═════════════════════════════ SCHEDULED ACTIONS ═══════════════════════════════╣\n")
+	if len(asg.Scheduled) == 0 {
+		fmt.Fprintf(view, "║ %-77s ║\n", "No scheduled actions")
+	}
+	for _, action := range asg.Scheduled {
+		fmt.Fprintf(view, "║ %-24s │ %-16s │ %-12s │ min %-3s max %-3s des %-3s ║\n",
+			truncateString(action.Name, 24),
+			action.NextRun.Local().Format("2006-01-02 15:04"),
+			truncateString(action.Recurrence, 12),
+			action.MinSize,
+			action.MaxSize,
+			action.DesiredSize)
+	}
+
 	// Metrics section
 	fmt.Fprintf(view, "╠═════════════════════════════ METRICS ═════════════════════════════════════════╣\n")
 
@@ -248,6 +337,20 @@ func renderASGDashboard(view *tview.TextView, asg ASGData) {
 	fmt.Fprintf(view, "╚═══════════════════════════════════════════════════════════════════════════════╝\n")
 }
 
+// renderActivityLog adds the most recent scaling activities to the live log
+func renderActivityLog(view *tview.TextView, asg ASGData, theme ui.Theme) {
+	for i := 0; i < len(asg.Activities) && i < 5; i++ {
+		activity := asg.Activities[i]
+		fmt.Fprintf(view, "%s %s\n", theme.Paint(theme.Muted, logTime(activity.Time)), activity.Description)
+	}
+}
+
+// logTime formats a live log timestamp, escaped so tview doesn't take the
+// brackets for a style tag
+func logTime(t time.Time) string {
+	return tview.Escape(t.Format("[15:04:05]"))
+}
+
 // createProgressBar creates a text-based progress bar
 func createProgressBar(current, max, width int) string {
 	filledWidth := int(float64(current) / float64(max) * float64(width))
@@ -382,6 +485,14 @@ func fetchASGData(sess *session.Session, asgName string) (ASGData, error) {
 		}
 	}
 
+	// Scaling policies and scheduled actions explain where scale events come from
+	if policies, err := fetchScalingPolicies(sess, asgName); err == nil {
+		asgData.Policies = policies
+	}
+	if scheduled, err := fetchScheduledActions(sess, asgName); err == nil {
+		asgData.Scheduled = scheduled
+	}
+
 	// For demo purposes, we'll set some mock values for CPU and network
 	// In a real app, you would get these from CloudWatch
 	asgData.CPUUtilization = 72
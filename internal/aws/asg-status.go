@@ -6,6 +6,7 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/HighonAces/swissarmycli/internal/apicalls"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 )
@@ -33,6 +34,7 @@ func OnlyStatus(asgName string, options MonitorOptions) error { // Uses MonitorO
 	if err != nil {
 		return fmt.Errorf("failed to create AWS session: %v", err)
 	}
+	apicalls.InstrumentSession(sess)
 
 	// Apply region if specified or use session's default
 	if options.Region != "" {
@@ -110,6 +112,47 @@ func OnlyStatus(asgName string, options MonitorOptions) error { // Uses MonitorO
 		}
 	}
 
+	// Scaling policies with the alarms that trigger them
+	fmt.Println("\n  Scaling Policies:")
+	if len(asgData.Policies) == 0 {
+		fmt.Println("    No scaling policies found.")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "    NAME\tTYPE\tTRIGGER\tENABLED\tALARMS")
+		for _, policy := range asgData.Policies {
+			fmt.Fprintf(w, "    %s\t%s\t%s\t%t\t%s\n",
+				policy.Name,
+				policy.Type,
+				policy.Trigger,
+				policy.Enabled,
+				alarmSummary(policy.Alarms))
+		}
+		w.Flush()
+	}
+
+	// Upcoming scheduled actions
+	fmt.Println("\n  Scheduled Actions:")
+	if len(asgData.Scheduled) == 0 {
+		fmt.Println("    No scheduled actions found.")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "    NAME\tNEXT RUN\tRECURRENCE\tMIN\tMAX\tDESIRED")
+		for _, action := range asgData.Scheduled {
+			recurrence := action.Recurrence
+			if recurrence == "" {
+				recurrence = "once"
+			}
+			fmt.Fprintf(w, "    %s\t%s\t%s\t%s\t%s\t%s\n",
+				action.Name,
+				action.NextRun.Local().Format("2006-01-02 15:04 MST"),
+				recurrence,
+				action.MinSize,
+				action.MaxSize,
+				action.DesiredSize)
+		}
+		w.Flush()
+	}
+
 	fmt.Println("--------------------------------------------------")
 
 	return nil // Success
@@ -0,0 +1,198 @@
+package aws
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// CapacityCheckOptions contains options for the EC2 capacity check
+type CapacityCheckOptions struct {
+	InstanceTypes []string
+	Zones         []string      // Availability zones to check (default: all zones of the region)
+	ASGName       string        // Only look at this ASG's activities (default: every ASG)
+	Since         time.Duration // How far back to look for capacity errors
+	Region        string
+	Profile       string
+}
+
+// capacityErrorPattern matches the type and zone in EC2 capacity errors such
+// as "We currently do not have sufficient m5.large capacity in the
+// Availability Zone you requested (us-east-1a)."
+var capacityErrorPattern = regexp.MustCompile(`sufficient (\S+) capacity in the Availability Zone you requested \(([a-z0-9-]+)\)`)
+
+// capacityFailure is a recent InsufficientInstanceCapacity error
+type capacityFailure struct {
+	count int
+	last  time.Time
+	asgs  map[string]bool
+}
+
+// CheckCapacity reports, for every instance type and availability zone,
+// whether the type is offered there and whether ASGs recently failed to
+// launch it for lack of capacity.
+func CheckCapacity(options CapacityCheckOptions) error {
+	if len(options.InstanceTypes) == 0 {
+		return fmt.Errorf("at least one instance type is required")
+	}
+
+	sess, err := NewSession(options.Profile, options.Region)
+	if err != nil {
+		return err
+	}
+
+	zones := options.Zones
+	if len(zones) == 0 {
+		zones, err = availableZones(sess)
+		if err != nil {
+			return err
+		}
+	}
+
+	offered, err := instanceTypeOfferings(sess, options.InstanceTypes)
+	if err != nil {
+		return err
+	}
+
+	failures, scanned, err := recentCapacityFailures(sess, options.ASGName, time.Now().Add(-options.Since))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Checked %d scaling activities from the last %s.\n\n", scanned, options.Since)
+
+	likelyToFail, risky := 0, 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "INSTANCE TYPE\tZONE\tOFFERED\tCAPACITY ERRORS\tLAST ERROR\tASGS\tVERDICT")
+	for _, instanceType := range options.InstanceTypes {
+		for _, zone := range zones {
+			isOffered := offered[instanceType+"/"+zone]
+			failure := failures[instanceType+"/"+zone]
+
+			errorCount, lastError, asgs := "0", "-", "-"
+			verdict := "✅ OK"
+			switch {
+			case !isOffered:
+				verdict = "❌ not offered in this zone"
+				likelyToFail++
+			case failure != nil:
+				errorCount = fmt.Sprintf("%d", failure.count)
+				lastError = failure.last.Local().Format("2006-01-02 15:04")
+				asgs = strings.Join(sortedKeys(failure.asgs), ",")
+				verdict = "⚠️  recent insufficient capacity"
+				risky++
+			}
+			fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\t%s\t%s\n",
+				instanceType, zone, isOffered, errorCount, lastError, asgs, verdict)
+		}
+	}
+	w.Flush()
+
+	fmt.Println("\n--- Capacity Check Summary ---")
+	fmt.Printf("Type/zone combinations checked: %d\n", len(options.InstanceTypes)*len(zones))
+	fmt.Printf("Not offered: %d\n", likelyToFail)
+	fmt.Printf("Recent capacity errors: %d\n", risky)
+	if risky > 0 {
+		fmt.Println("Consider adding more instance types or zones to the affected ASGs' mixed instances policy.")
+	}
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// availableZones returns the names of the region's available zones.
+func availableZones(sess *session.Session) ([]string, error) {
+	output, err := ec2.New(sess).DescribeAvailabilityZones(&ec2.DescribeAvailabilityZonesInput{
+		Filters: []*ec2.Filter{{Name: aws.String("state"), Values: aws.StringSlice([]string{"available"})}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe availability zones: %w", err)
+	}
+
+	var zones []string
+	for _, zone := range output.AvailabilityZones {
+		zones = append(zones, aws.StringValue(zone.ZoneName))
+	}
+	sort.Strings(zones)
+	return zones, nil
+}
+
+// instanceTypeOfferings returns which of the instance types are offered in
+// which zone, keyed by "type/zone".
+func instanceTypeOfferings(sess *session.Session, instanceTypes []string) (map[string]bool, error) {
+	offered := make(map[string]bool)
+	err := ec2.New(sess).DescribeInstanceTypeOfferingsPages(&ec2.DescribeInstanceTypeOfferingsInput{
+		LocationType: aws.String(ec2.LocationTypeAvailabilityZone),
+		Filters:      []*ec2.Filter{{Name: aws.String("instance-type"), Values: aws.StringSlice(instanceTypes)}},
+	}, func(page *ec2.DescribeInstanceTypeOfferingsOutput, lastPage bool) bool {
+		for _, offering := range page.InstanceTypeOfferings {
+			offered[aws.StringValue(offering.InstanceType)+"/"+aws.StringValue(offering.Location)] = true
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instance type offerings: %w", err)
+	}
+	return offered, nil
+}
+
+// recentCapacityFailures collects InsufficientInstanceCapacity errors from
+// scaling activities newer than since, keyed by "type/zone", and returns how
+// many activities were scanned.
+func recentCapacityFailures(sess *session.Session, asgName string, since time.Time) (map[string]*capacityFailure, int, error) {
+	input := &autoscaling.DescribeScalingActivitiesInput{}
+	if asgName != "" {
+		input.AutoScalingGroupName = aws.String(asgName)
+	}
+
+	failures := make(map[string]*capacityFailure)
+	scanned := 0
+	err := autoscaling.New(sess).DescribeScalingActivitiesPages(input, func(page *autoscaling.DescribeScalingActivitiesOutput, lastPage bool) bool {
+		for _, activity := range page.Activities {
+			// Activities come newest first
+			if activity.StartTime != nil && activity.StartTime.Before(since) {
+				return false
+			}
+			scanned++
+
+			match := capacityErrorPattern.FindStringSubmatch(aws.StringValue(activity.StatusMessage))
+			if match == nil {
+				continue
+			}
+			key := match[1] + "/" + match[2]
+			failure, exists := failures[key]
+			if !exists {
+				failure = &capacityFailure{asgs: make(map[string]bool)}
+				failures[key] = failure
+			}
+			failure.count++
+			failure.asgs[aws.StringValue(activity.AutoScalingGroupName)] = true
+			if activity.StartTime != nil && activity.StartTime.After(failure.last) {
+				failure.last = *activity.StartTime
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to describe scaling activities: %w", err)
+	}
+	return failures, scanned, nil
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](set map[string]V) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
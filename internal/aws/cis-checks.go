@@ -0,0 +1,64 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks"
+)
+
+// EKSClusterSecurity is the part of an EKS cluster's configuration the CIS
+// checks look at
+type EKSClusterSecurity struct {
+	EndpointPublicAccess  bool
+	EndpointPrivateAccess bool
+	PublicAccessCIDRs     []string
+	EnabledLogTypes       []string // Control plane log types sent to CloudWatch
+}
+
+// GetEKSClusterSecurity describes the endpoint access and control plane
+// logging configuration of an EKS cluster.
+func GetEKSClusterSecurity(sess *session.Session, clusterName string) (*EKSClusterSecurity, error) {
+	output, err := eks.New(sess).DescribeCluster(&eks.DescribeClusterInput{Name: aws.String(clusterName)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe cluster %s: %w", clusterName, err)
+	}
+	cluster := output.Cluster
+	security := &EKSClusterSecurity{}
+	if vpc := cluster.ResourcesVpcConfig; vpc != nil {
+		security.EndpointPublicAccess = aws.BoolValue(vpc.EndpointPublicAccess)
+		security.EndpointPrivateAccess = aws.BoolValue(vpc.EndpointPrivateAccess)
+		security.PublicAccessCIDRs = aws.StringValueSlice(vpc.PublicAccessCidrs)
+	}
+	if cluster.Logging != nil {
+		for _, setup := range cluster.Logging.ClusterLogging {
+			if aws.BoolValue(setup.Enabled) {
+				security.EnabledLogTypes = append(security.EnabledLogTypes, aws.StringValueSlice(setup.Types)...)
+			}
+		}
+	}
+	return security, nil
+}
+
+// GetInstanceMetadataTokens returns the IMDS HttpTokens setting, "required"
+// or "optional", of each instance by ID.
+func GetInstanceMetadataTokens(sess *session.Session, instanceIDs []string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	input := &ec2.DescribeInstancesInput{InstanceIds: aws.StringSlice(instanceIDs)}
+	err := ec2.New(sess).DescribeInstancesPages(input, func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				if instance.MetadataOptions != nil {
+					tokens[aws.StringValue(instance.InstanceId)] = aws.StringValue(instance.MetadataOptions.HttpTokens)
+				}
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instances: %w", err)
+	}
+	return tokens, nil
+}
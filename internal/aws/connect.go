@@ -2,112 +2,163 @@ package aws
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/providerid"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"os"
 	"os/exec"
+	"os/signal"
 	"strings"
 )
 
-var validUSRegions = map[string]bool{
-	"us-east-1": true,
-	"us-east-2": true,
-	"us-west-1": true,
-	"us-west-2": true,
+// ConnectNodeOptions holds the options for connect node
+type ConnectNodeOptions struct {
+	Native bool // Use the built-in client even if session-manager-plugin is installed
 }
 
-// ConnectToNode connects to an AWS worker node using SSM
-func ConnectToNode(nodeName string) error {
+// ConnectToNode opens a shell on the machine behind a Kubernetes node: an
+// SSM session for EC2 instances, or gcloud compute ssh and az ssh vm when
+// the node's providerID shows it runs on GCE or Azure.
+func ConnectToNode(nodeName string, options ConnectNodeOptions) error {
 	fmt.Printf("Connecting to node: %s\n", nodeName)
 
-	// TODO: Add code to get the instance ID from the node name
-	// This will be implemented later as mentioned
-	instanceID, region := getInstanceIDFromNodeName(nodeName)
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	node, err := clientset.CoreV1().Nodes().Get(context.TODO(), nodeName, v1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+
+	machine := providerid.Parse(node.Spec.ProviderID)
+	switch machine.Provider {
+	case providerid.AWS:
+		if machine.InstanceID == "" || machine.Region == "" {
+			return fmt.Errorf("could not find instance ID for node %s in providerID '%s'", nodeName, node.Spec.ProviderID)
+		}
+		fmt.Printf("Found instance ID: %s\n", machine.InstanceID)
+		fmt.Printf("Found region: %s\n", machine.Region)
+		return startSSMSession(machine.InstanceID, machine.Region, options.Native)
+	case providerid.GCE:
+		return runCloudShell("gcloud", "compute", "ssh", machine.InstanceID, "--zone", machine.Zone, "--project", machine.Project)
+	case providerid.Azure:
+		// az ssh vm can't address scale set instances by name, only by IP
+		if machine.ScaleSet != "" {
+			return runCloudShell("az", "ssh", "vm", "--ip", nodeInternalIP(node))
+		}
+		return runCloudShell("az", "ssh", "vm", "--resource-group", machine.ResourceGroup, "--name", machine.InstanceID)
+	}
+	return fmt.Errorf("node %s runs on %s, which connect node doesn't support; use ssh %s, or swissarmycli debug for a shell in a pod",
+		nodeName, machine.Provider, nodeInternalIP(node))
+}
 
-	if instanceID == "" {
-		return fmt.Errorf("could not find instance ID for node %s", nodeName)
+// runCloudShell hands the terminal to another cloud's CLI, e.g. gcloud
+// compute ssh, until its session ends.
+func runCloudShell(name string, args ...string) error {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return fmt.Errorf("%s not found in PATH, install it to connect to this node", name)
 	}
+	fmt.Printf("Running: %s %s\n", name, strings.Join(args, " "))
 
-	fmt.Printf("Found instance ID: %s\n", instanceID)
-	fmt.Printf("Found region: %s\n", region)
+	// Ctrl+C belongs to the remote shell, not to this process
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
 
-	// Start an SSM session
-	return startSSMSession(instanceID, region)
+	cmd := exec.Command(path, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
 }
 
-// Placeholder function that will be implemented later
-func getInstanceIDFromNodeName(nodeName string) (string, string) {
-	clientset, err := common.GetKubernetesClient() // Use the new public function
-	if err != nil {
-		fmt.Println("failed to create Kubernetes client: %w", err)
-		return "", ""
+func nodeInternalIP(node *corev1.Node) string {
+	for _, address := range node.Status.Addresses {
+		if address.Type == corev1.NodeInternalIP {
+			return address.Address
+		}
 	}
+	return "<node IP>"
+}
 
-	//node object now have all the node related info
-	node, err := clientset.CoreV1().Nodes().Get(context.TODO(), nodeName, v1.GetOptions{})
+// startSSMSession starts an SSM session to the specified instance. The
+// session-manager-plugin is used when installed; otherwise, or with native
+// set, the built-in client speaks the Session Manager protocol directly so
+// neither the AWS CLI nor the plugin is needed (e.g. on Windows or in
+// minimal containers).
+func startSSMSession(instanceID string, region string, native bool) error {
+	sess, err := NewSession("", region)
 	if err != nil {
-		panic(err.Error())
+		return err
 	}
 
-	providerID := node.Spec.ProviderID
-	const prefix = "aws:///"
-	if !strings.HasPrefix(providerID, prefix) {
-		fmt.Println("invalid providerID format")
-		return "", ""
-	}
-	parts := strings.Split(strings.TrimPrefix(providerID, prefix), "/") // Strip prefix and split the rest
+	// Ctrl+C belongs to the remote shell, not to this process
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
 
-	if len(parts) != 2 {
-		fmt.Println("unexpected providerID structure")
-		return "", ""
+	if !native {
+		if pluginPath, err := exec.LookPath("session-manager-plugin"); err == nil {
+			return startPluginSession(sess, pluginPath, instanceID, region)
+		}
+		fmt.Println("session-manager-plugin not found, using the built-in Session Manager client.")
 	}
-	az := parts[0]         // e.g. "us-west-2a"
-	instanceID := parts[1] // e.g. "i-0abc1234def56789"
 
-	if len(az) < 9 {
-		fmt.Println("invalid availability zone format")
-		return "", ""
+	fmt.Printf("Starting SSM session to instance %s in region %s...\n", instanceID, region)
+	if err := startNativeSession(sess, instanceID); err != nil {
+		return ssmSessionError(err, instanceID)
 	}
+	return nil
+}
 
-	// Take first 9 characters for region
-	region := az[:9]
-
-	// Validate against known US regions
-	if !validUSRegions[region] {
-		fmt.Printf("unknown or unsupported region: %s\n", region)
-		return "", ""
+// startPluginSession starts the session through the SSM API and hands it to
+// session-manager-plugin, the same way 'aws ssm start-session' does.
+func startPluginSession(sess *session.Session, pluginPath, instanceID, region string) error {
+	fmt.Printf("Attempting to start SSM session to instance %s in region %s via session-manager-plugin...\n", instanceID, region)
+	started, err := ssm.New(sess).StartSession(&ssm.StartSessionInput{Target: aws.String(instanceID)})
+	if err != nil {
+		return ssmSessionError(err, instanceID)
 	}
-	return instanceID, region
 
-}
+	response, err := json.Marshal(map[string]string{
+		"SessionId":  aws.StringValue(started.SessionId),
+		"TokenValue": aws.StringValue(started.TokenValue),
+		"StreamUrl":  aws.StringValue(started.StreamUrl),
+	})
+	if err != nil {
+		return err
+	}
+	request, err := json.Marshal(map[string]string{"Target": instanceID})
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("https://ssm.%s.amazonaws.com", region)
 
-// startSSMSession starts an SSM session to the specified instance
-func startSSMSession(instanceID string, region string) error {
-	// Load AWS configuration
-	fmt.Printf("Attempting to start SSM session to instance %s in region %s via AWS CLI...\n", instanceID, region)
-	// Construct the command to execute
-	// Using AWS-StartSSHSession document is common for interactive shells via SSM
-	cmd := exec.Command("aws", "ssm", "start-session",
-		"--target", instanceID,
-		"--region", region,
-	)
+	cmd := exec.Command(pluginPath, string(response), region, "StartSession",
+		os.Getenv("AWS_PROFILE"), string(request), endpoint)
 
 	// Connect the command's standard input, output, and error streams
 	// directly to the Go program's streams. This makes the session interactive.
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	err := cmd.Run()
-	if err != nil {
-		// Provide context about potential issues
-		return fmt.Errorf("failed to execute 'aws ssm start-session': %w. \nPossible causes:\n"+
-			"  - AWS CLI is not installed or not in PATH.\n"+
-			"  - AWS credentials are not configured correctly.\n"+
-			"  - Instance '%s' does not exist or is not managed by SSM.\n"+
-			"  - SSM Agent is not running on the instance.\n"+
-			"  - IAM permissions for SSM StartSession are missing for your user/role.\n"+
-			"  - IAM instance profile permissions are missing for the target instance.", err, instanceID)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run session-manager-plugin: %w", err)
 	}
-	return err
+	return nil
+}
+
+// ssmSessionError adds the usual causes of a failed session to err
+func ssmSessionError(err error, instanceID string) error {
+	// Provide context about potential issues
+	return fmt.Errorf("failed to start SSM session: %w. \nPossible causes:\n"+
+		"  - AWS credentials are not configured correctly.\n"+
+		"  - Instance '%s' does not exist or is not managed by SSM.\n"+
+		"  - SSM Agent is not running on the instance.\n"+
+		"  - IAM permissions for SSM StartSession are missing for your user/role.\n"+
+		"  - IAM instance profile permissions are missing for the target instance.", err, instanceID)
 }
@@ -0,0 +1,129 @@
+package aws
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/costexplorer"
+)
+
+// costExplorerRegion is the only region serving the Cost Explorer API
+const costExplorerRegion = "us-east-1"
+
+// ec2ComputeService is the Cost Explorer SERVICE value for EC2 instance usage
+const ec2ComputeService = "Amazon Elastic Compute Cloud - Compute"
+
+// GetCommitmentCoverage returns the Reserved Instance coverage of every
+// instance type and the Savings Plans coverage of every instance family in
+// the region between start and end, as fractions from 0 to 1, as reported by
+// Cost Explorer. Coverage is account wide, not per cluster.
+func GetCommitmentCoverage(sess *session.Session, region string, start, end time.Time) (reserved map[string]float64, savingsPlans map[string]float64, err error) {
+	svc := costexplorer.New(sess, aws.NewConfig().WithRegion(costExplorerRegion))
+	period := &costexplorer.DateInterval{
+		Start: aws.String(start.Format("2006-01-02")),
+		End:   aws.String(end.Format("2006-01-02")),
+	}
+	filter := &costexplorer.Expression{And: []*costexplorer.Expression{
+		{Dimensions: &costexplorer.DimensionValues{Key: aws.String(costexplorer.DimensionRegion), Values: aws.StringSlice([]string{region})}},
+		{Dimensions: &costexplorer.DimensionValues{Key: aws.String(costexplorer.DimensionService), Values: aws.StringSlice([]string{ec2ComputeService})}},
+	}}
+
+	// Reserved Instances, by instance type. Hours are summed over the period
+	// so the fraction is weighted by usage.
+	reservedHours := make(map[string]float64)
+	totalHours := make(map[string]float64)
+	input := &costexplorer.GetReservationCoverageInput{
+		TimePeriod:  period,
+		Granularity: aws.String(costexplorer.GranularityMonthly),
+		Filter:      filter,
+		GroupBy: []*costexplorer.GroupDefinition{{
+			Type: aws.String(costexplorer.GroupDefinitionTypeDimension),
+			Key:  aws.String(costexplorer.DimensionInstanceType),
+		}},
+	}
+	for {
+		output, err := svc.GetReservationCoverage(input)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get reservation coverage: %w", err)
+		}
+		for _, byTime := range output.CoveragesByTime {
+			for _, group := range byTime.Groups {
+				instanceType := groupAttribute(group.Attributes)
+				if instanceType == "" || group.Coverage == nil || group.Coverage.CoverageHours == nil {
+					continue
+				}
+				reservedHours[instanceType] += parseCostValue(group.Coverage.CoverageHours.ReservedHours)
+				totalHours[instanceType] += parseCostValue(group.Coverage.CoverageHours.TotalRunningHours)
+			}
+		}
+		if output.NextPageToken == nil {
+			break
+		}
+		input.NextPageToken = output.NextPageToken
+	}
+
+	reserved = make(map[string]float64)
+	for instanceType, total := range totalHours {
+		if total > 0 {
+			reserved[instanceType] = reservedHours[instanceType] / total
+		}
+	}
+
+	// Savings Plans, by instance family. Costs exclude RI covered usage.
+	coveredSpend := make(map[string]float64)
+	totalSpend := make(map[string]float64)
+	err = svc.GetSavingsPlansCoveragePages(&costexplorer.GetSavingsPlansCoverageInput{
+		TimePeriod:  period,
+		Granularity: aws.String(costexplorer.GranularityMonthly),
+		Filter:      filter,
+		GroupBy: []*costexplorer.GroupDefinition{{
+			Type: aws.String(costexplorer.GroupDefinitionTypeDimension),
+			Key:  aws.String(costexplorer.DimensionInstanceTypeFamily),
+		}},
+	}, func(page *costexplorer.GetSavingsPlansCoverageOutput, lastPage bool) bool {
+		for _, coverage := range page.SavingsPlansCoverages {
+			family := groupAttribute(coverage.Attributes)
+			if family == "" || coverage.Coverage == nil {
+				continue
+			}
+			coveredSpend[family] += parseCostValue(coverage.Coverage.SpendCoveredBySavingsPlans)
+			totalSpend[family] += parseCostValue(coverage.Coverage.TotalCost)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get Savings Plans coverage: %w", err)
+	}
+
+	savingsPlans = make(map[string]float64)
+	for family, total := range totalSpend {
+		if total > 0 {
+			savingsPlans[family] = coveredSpend[family] / total
+		}
+	}
+	return reserved, savingsPlans, nil
+}
+
+// parseCostValue parses the decimal strings Cost Explorer returns, treating
+// missing or invalid values as zero.
+func parseCostValue(value *string) float64 {
+	parsed, err := strconv.ParseFloat(aws.StringValue(value), 64)
+	if err != nil {
+		return 0
+	}
+	return parsed
+}
+
+// groupAttribute returns the value of the single dimension a coverage group
+// was grouped by, whatever casing Cost Explorer uses for its key.
+func groupAttribute(attributes map[string]*string) string {
+	for _, value := range attributes {
+		if aws.StringValue(value) != "" {
+			return aws.StringValue(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// EBSModificationCooldown is how long EBS requires between modifications of
+// the same volume
+const EBSModificationCooldown = 6 * time.Hour
+
+// VolumeModification is the latest modification of an EBS volume
+type VolumeModification struct {
+	State         string // modifying, optimizing, completed or failed
+	Progress      int64  // Percent
+	OriginalSize  int64  // GiB
+	TargetSize    int64  // GiB
+	StatusMessage string
+	StartTime     time.Time
+}
+
+// GetVolumeModification returns the latest modification of an EBS volume, or
+// nil when the volume has never been modified.
+func GetVolumeModification(sess *session.Session, volumeID string) (*VolumeModification, error) {
+	output, err := ec2.New(sess).DescribeVolumesModifications(&ec2.DescribeVolumesModificationsInput{
+		VolumeIds: aws.StringSlice([]string{volumeID}),
+	})
+	var aerr awserr.Error
+	if errors.As(err, &aerr) && aerr.Code() == "InvalidVolumeModification.NotFound" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe modifications of volume %s: %w", volumeID, err)
+	}
+
+	var latest *ec2.VolumeModification
+	for _, modification := range output.VolumesModifications {
+		if latest == nil || aws.TimeValue(modification.StartTime).After(aws.TimeValue(latest.StartTime)) {
+			latest = modification
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	return &VolumeModification{
+		State:         aws.StringValue(latest.ModificationState),
+		Progress:      aws.Int64Value(latest.Progress),
+		OriginalSize:  aws.Int64Value(latest.OriginalSize),
+		TargetSize:    aws.Int64Value(latest.TargetSize),
+		StatusMessage: aws.StringValue(latest.StatusMessage),
+		StartTime:     aws.TimeValue(latest.StartTime),
+	}, nil
+}
@@ -0,0 +1,28 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
+)
+
+// ECRAuthorization returns the Basic authorization token for the ECR
+// registry of the account in the region, as the registry API expects it
+// in the Authorization header
+func ECRAuthorization(profile, region, account string) (string, error) {
+	sess, err := NewSession(profile, region)
+	if err != nil {
+		return "", err
+	}
+	output, err := ecr.New(sess).GetAuthorizationToken(&ecr.GetAuthorizationTokenInput{
+		RegistryIds: []*string{aws.String(account)},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get ECR authorization token: %w", err)
+	}
+	if len(output.AuthorizationData) == 0 {
+		return "", fmt.Errorf("no ECR authorization token for account %s", account)
+	}
+	return aws.StringValue(output.AuthorizationData[0].AuthorizationToken), nil
+}
@@ -0,0 +1,293 @@
+package aws
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/config"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+)
+
+// describeClusterParallel is how many clusters of a region are described at once
+const describeClusterParallel = 5
+
+// ClustersListOptions contains options for the cross-region cluster inventory
+type ClustersListOptions struct {
+	Regions []string // Default: aws.regions in the config file, then the US regions
+	Profile string
+	Output  string // table, json or csv
+}
+
+// ClusterInventory is one EKS cluster of the inventory
+type ClusterInventory struct {
+	Name            string            `json:"name"`
+	Region          string            `json:"region"`
+	Version         string            `json:"version"`
+	PlatformVersion string            `json:"platformVersion"`
+	Status          string            `json:"status"`
+	EndpointAccess  string            `json:"endpointAccess"` // public, private or public+private
+	PublicCIDRs     []string          `json:"publicAccessCidrs,omitempty"`
+	NodeGroups      int               `json:"nodeGroups"`
+	SupportType     string            `json:"supportType"`           // STANDARD or EXTENDED upgrade policy
+	VersionSupport  string            `json:"versionSupport"`        // standard, extended, unsupported or unknown
+	SupportEnds     string            `json:"supportEnds,omitempty"` // End of the current support phase
+	Created         time.Time         `json:"created"`
+	Tags            map[string]string `json:"tags,omitempty"`
+	Error           string            `json:"error,omitempty"`
+}
+
+// SearchRegions returns the regions scanned for clusters: the given ones, else
+// aws.regions from the config file, else usRegionsToSearch.
+func SearchRegions(regions []string) ([]string, error) {
+	if len(regions) > 0 {
+		return regions, nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	awsConfig, err := cfg.AWS()
+	if err != nil {
+		return nil, err
+	}
+	if len(awsConfig.Regions) > 0 {
+		return awsConfig.Regions, nil
+	}
+	return usRegionsToSearch, nil
+}
+
+// ListClusters prints every EKS cluster of the account across the regions,
+// with its version and how long that version is supported, endpoint access,
+// managed node group count and tags. Regions are scanned in parallel; a
+// region that can't be listed is reported and skipped.
+func ListClusters(options ClustersListOptions) error {
+	if options.Output != "table" && options.Output != "json" && options.Output != "csv" {
+		return fmt.Errorf("invalid output format '%s' (must be table, json or csv)", options.Output)
+	}
+	regions, err := SearchRegions(options.Regions)
+	if err != nil {
+		return err
+	}
+	calendar, err := BundledEKSCalendar()
+	if err != nil {
+		return err
+	}
+	if options.Output == "table" {
+		fmt.Printf("Scanning %d regions for EKS clusters: %s\n", len(regions), strings.Join(regions, ", "))
+	}
+	clusters, regionErrors := CollectClusterInventory(regions, options.Profile, calendar)
+
+	switch options.Output {
+	case "json":
+		encoder := json.NewEncoder(sink.Output())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(clusters)
+	case "csv":
+		return writeClustersCSV(clusters)
+	}
+
+	if len(clusters) == 0 {
+		fmt.Println("No EKS clusters found.")
+		return nil
+	}
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tREGION\tVERSION\tSTATUS\tENDPOINT\tNODEGROUPS\tSUPPORT TIER\tVERSION SUPPORT\tTAGS")
+	versionCounts := make(map[string]int)
+	var extended, unsupported int
+	for _, cluster := range clusters {
+		if cluster.Error != "" {
+			fmt.Fprintf(w, "%s\t%s\t-\t❌ %s\t-\t-\t-\t-\t-\n", cluster.Name, cluster.Region, cluster.Error)
+			continue
+		}
+		versionCounts[cluster.Version]++
+		support := cluster.VersionSupport
+		switch cluster.VersionSupport {
+		case SupportStandard:
+			support = "✅ standard until " + cluster.SupportEnds
+		case SupportExtended:
+			support = "⚠️ extended until " + cluster.SupportEnds
+			extended++
+		case SupportUnsupported:
+			support = "❌ unsupported since " + cluster.SupportEnds
+			unsupported++
+		}
+		tags := formatTags(cluster.Tags)
+		if tags == "" {
+			tags = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			cluster.Name, cluster.Region, cluster.Version, cluster.Status, cluster.EndpointAccess,
+			cluster.NodeGroups, cluster.SupportType, support, truncateString(tags, 60))
+	}
+	w.Flush()
+
+	fmt.Println("\n--- EKS Clusters Summary ---")
+	fmt.Printf("Clusters: %d in %d regions\n", len(clusters), len(regions)-len(regionErrors))
+	var versions []string
+	for _, version := range sortedKeys(versionCounts) {
+		versions = append(versions, fmt.Sprintf("%s (%d)", version, versionCounts[version]))
+	}
+	fmt.Printf("Versions: %s\n", strings.Join(versions, ", "))
+	if extended > 0 {
+		fmt.Printf("⚠️  %d clusters are on extended support, which is billed at a higher hourly rate\n", extended)
+	}
+	if unsupported > 0 {
+		fmt.Printf("❌ %d clusters are past extended support and will be upgraded by EKS\n", unsupported)
+	}
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// CollectClusterInventory lists and describes the EKS clusters of the
+// regions in parallel, ordered by region and name, with the version support
+// phases taken from the calendar. A region that can't be listed is warned
+// about on stderr and returned in the errors by region.
+func CollectClusterInventory(regions []string, profile string, calendar map[string]EKSVersion) ([]ClusterInventory, map[string]error) {
+	var mu sync.Mutex
+	var clusters []ClusterInventory
+	regionErrors := make(map[string]error)
+	var wg sync.WaitGroup
+	for _, region := range regions {
+		wg.Add(1)
+		go func(region string) {
+			defer wg.Done()
+			found, err := listRegionClusters(profile, region, calendar)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				regionErrors[region] = err
+			}
+			clusters = append(clusters, found...)
+		}(region)
+	}
+	wg.Wait()
+	for _, region := range sortedKeys(regionErrors) {
+		fmt.Fprintf(os.Stderr, "Warning: could not list clusters in region %s: %v\n", region, regionErrors[region])
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Region != clusters[j].Region {
+			return clusters[i].Region < clusters[j].Region
+		}
+		return clusters[i].Name < clusters[j].Name
+	})
+	return clusters, regionErrors
+}
+
+// listRegionClusters lists and describes the clusters of one region
+func listRegionClusters(profile, region string, calendar map[string]EKSVersion) ([]ClusterInventory, error) {
+	sess, err := NewSession(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	client := eks.New(sess)
+	var names []string
+	err = client.ListClustersPages(&eks.ListClustersInput{}, func(page *eks.ListClustersOutput, lastPage bool) bool {
+		names = append(names, aws.StringValueSlice(page.Clusters)...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	clusters := make([]ClusterInventory, len(names))
+	semaphore := make(chan struct{}, describeClusterParallel)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			clusters[i] = describeInventoryCluster(client, name, region, calendar)
+		}(i, name)
+	}
+	wg.Wait()
+	return clusters, nil
+}
+
+// describeInventoryCluster fetches one cluster and counts its node groups
+func describeInventoryCluster(client *eks.EKS, name, region string, calendar map[string]EKSVersion) ClusterInventory {
+	inventory := ClusterInventory{Name: name, Region: region}
+	output, err := client.DescribeCluster(&eks.DescribeClusterInput{Name: aws.String(name)})
+	if err != nil {
+		inventory.Error = fmt.Sprintf("failed to describe cluster: %v", err)
+		return inventory
+	}
+	cluster := output.Cluster
+	inventory.Version = aws.StringValue(cluster.Version)
+	inventory.PlatformVersion = aws.StringValue(cluster.PlatformVersion)
+	inventory.Status = aws.StringValue(cluster.Status)
+	inventory.Created = aws.TimeValue(cluster.CreatedAt)
+	inventory.Tags = aws.StringValueMap(cluster.Tags)
+	if vpc := cluster.ResourcesVpcConfig; vpc != nil {
+		var access []string
+		if aws.BoolValue(vpc.EndpointPublicAccess) {
+			access = append(access, "public")
+			inventory.PublicCIDRs = aws.StringValueSlice(vpc.PublicAccessCidrs)
+		}
+		if aws.BoolValue(vpc.EndpointPrivateAccess) {
+			access = append(access, "private")
+		}
+		inventory.EndpointAccess = strings.Join(access, "+")
+	}
+	// Clusters created before upgrade policies existed are on extended support
+	inventory.SupportType = eks.SupportTypeExtended
+	if cluster.UpgradePolicy != nil && cluster.UpgradePolicy.SupportType != nil {
+		inventory.SupportType = aws.StringValue(cluster.UpgradePolicy.SupportType)
+	}
+	inventory.VersionSupport = SupportUnknown
+	if version, ok := calendar[inventory.Version]; ok {
+		var ends time.Time
+		inventory.VersionSupport, ends = version.Phase(time.Now())
+		inventory.SupportEnds = ends.Format(time.DateOnly)
+	}
+
+	err = client.ListNodegroupsPages(&eks.ListNodegroupsInput{ClusterName: aws.String(name)}, func(page *eks.ListNodegroupsOutput, lastPage bool) bool {
+		inventory.NodeGroups += len(page.Nodegroups)
+		return true
+	})
+	if err != nil {
+		inventory.Error = fmt.Sprintf("failed to list node groups: %v", err)
+	}
+	return inventory
+}
+
+// writeClustersCSV prints the inventory as CSV, tags as key=value pairs
+// separated by semicolons
+func writeClustersCSV(clusters []ClusterInventory) error {
+	writer := csv.NewWriter(sink.Output())
+	writer.Write([]string{"name", "region", "version", "platform_version", "status", "endpoint_access", "public_access_cidrs",
+		"node_groups", "support_type", "version_support", "support_ends", "created", "tags", "error"})
+	for _, cluster := range clusters {
+		created := ""
+		if !cluster.Created.IsZero() {
+			created = cluster.Created.UTC().Format(time.RFC3339)
+		}
+		writer.Write([]string{cluster.Name, cluster.Region, cluster.Version, cluster.PlatformVersion, cluster.Status,
+			cluster.EndpointAccess, strings.Join(cluster.PublicCIDRs, ";"), strconv.Itoa(cluster.NodeGroups),
+			cluster.SupportType, cluster.VersionSupport, cluster.SupportEnds, created,
+			strings.ReplaceAll(formatTags(cluster.Tags), ",", ";"), cluster.Error})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatTags renders tags as comma separated key=value pairs in key order
+func formatTags(tags map[string]string) string {
+	var pairs []string
+	for _, key := range sortedKeys(tags) {
+		pairs = append(pairs, key+"="+tags[key])
+	}
+	return strings.Join(pairs, ",")
+}
@@ -1,13 +1,14 @@
 package aws
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"os/exec"
-	"strconv"
+	"sort"
 	"strings"
 
+	"github.com/HighonAces/swissarmycli/internal/apicalls"
+	"github.com/HighonAces/swissarmycli/internal/ui"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/eks"
@@ -22,9 +23,24 @@ type EKSClusterInfo struct {
 // usRegionsToSearch defines the AWS regions to scan for EKS clusters.
 var usRegionsToSearch = []string{"us-east-1", "us-east-2", "us-west-1", "us-west-2"}
 
+// EKSConnectOptions narrows down which cluster ConnectToEKSCluster picks
+type EKSConnectOptions struct {
+	Region    string // Only search this region instead of SearchRegions
+	Exact     bool   // Match the cluster name exactly instead of as a substring
+	Selection ui.Selection
+}
+
 // ConnectToEKSCluster finds an EKS cluster and updates kubeconfig.
-func ConnectToEKSCluster(partialName string) error {
-	fmt.Printf("Searching for EKS clusters containing '%s' in regions: %s...\n", partialName, strings.Join(usRegionsToSearch, ", "))
+func ConnectToEKSCluster(partialName string, options EKSConnectOptions) error {
+	var only []string
+	if options.Region != "" {
+		only = []string{options.Region}
+	}
+	regions, err := SearchRegions(only)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Searching for EKS clusters containing '%s' in regions: %s...\n", partialName, strings.Join(regions, ", "))
 
 	var matchingClusters []EKSClusterInfo
 	// Create a base session. We'll override the region for each iteration.
@@ -41,7 +57,8 @@ func ConnectToEKSCluster(partialName string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create base AWS session: %w", err)
 	}
-	for _, region := range usRegionsToSearch {
+	apicalls.InstrumentSession(baseSess)
+	for _, region := range regions {
 		fmt.Printf("Checking region: %s\n", region)
 		// It's more efficient to create a new service client per region
 		// than creating a new session object every time if only region changes.
@@ -58,7 +75,7 @@ func ConnectToEKSCluster(partialName string) error {
 				for _, clusterNamePtr := range page.Clusters {
 					if clusterNamePtr != nil {
 						clusterName := *clusterNamePtr
-						if strings.Contains(strings.ToLower(clusterName), strings.ToLower(partialName)) {
+						if clusterNameMatches(clusterName, partialName, options.Exact) {
 							matchingClusters = append(matchingClusters, EKSClusterInfo{
 								Name:   clusterName,
 								Region: region,
@@ -76,39 +93,66 @@ func ConnectToEKSCluster(partialName string) error {
 	}
 
 	if len(matchingClusters) == 0 {
+		if options.Selection.NonInteractive {
+			return fmt.Errorf("no EKS clusters found matching '%s'", partialName)
+		}
 		fmt.Printf("No EKS clusters found matching '%s'.\n", partialName)
 		return nil
 	}
 
+	// Stable order so --index picks the same cluster every run
+	sort.Slice(matchingClusters, func(i, j int) bool {
+		if matchingClusters[i].Name != matchingClusters[j].Name {
+			return matchingClusters[i].Name < matchingClusters[j].Name
+		}
+		return matchingClusters[i].Region < matchingClusters[j].Region
+	})
+
 	var selectedCluster EKSClusterInfo
 	if len(matchingClusters) == 1 {
 		selectedCluster = matchingClusters[0]
 		fmt.Printf("Found one matching cluster: %s (%s)\n", selectedCluster.Name, selectedCluster.Region)
 	} else {
-		fmt.Println("\nMultiple EKS clusters found. Please select one:")
+		items := make([]ui.Item, len(matchingClusters))
 		for i, cluster := range matchingClusters {
-			fmt.Printf("  %d. %s (%s)\n", i+1, cluster.Name, cluster.Region)
-		}
-		reader := bufio.NewReader(os.Stdin)
-		for {
-			fmt.Print("Enter number: ")
-			inputStr, _ := reader.ReadString('\n')
-			inputStr = strings.TrimSpace(inputStr)
-			choice, err := strconv.Atoi(inputStr)
-			if err != nil || choice < 1 || choice > len(matchingClusters) {
-				fmt.Println("Invalid selection. Please enter a number from the list.")
-				continue
+			items[i] = ui.Item{
+				Label:   fmt.Sprintf("%s (%s)", cluster.Name, cluster.Region),
+				Preview: fmt.Sprintf("Cluster: %s\nRegion: %s", cluster.Name, cluster.Region),
 			}
-			selectedCluster = matchingClusters[choice-1]
-			break
 		}
+		choice, err := ui.Choose("EKS clusters", items, options.Selection)
+		if err != nil {
+			return err
+		}
+		selectedCluster = matchingClusters[choice]
 	}
 
 	fmt.Printf("Updating kubeconfig for cluster: %s in region %s...\n", selectedCluster.Name, selectedCluster.Region)
-	return updateKubeconfigForEKS(selectedCluster.Name, selectedCluster.Region)
+	regionalSess := baseSess.Copy(&aws.Config{Region: aws.String(selectedCluster.Region)})
+	return updateKubeconfigForEKS(regionalSess, selectedCluster.Name, selectedCluster.Region)
+}
+
+// clusterNameMatches reports whether a cluster name matches the search term,
+// case insensitively and as a substring unless exact is set.
+func clusterNameMatches(clusterName, term string, exact bool) bool {
+	if exact {
+		return clusterName == term
+	}
+	return strings.Contains(strings.ToLower(clusterName), strings.ToLower(term))
 }
 
-func updateKubeconfigForEKS(clusterName string, region string) error {
+// updateKubeconfigForEKS uses 'aws eks update-kubeconfig' when the AWS CLI
+// is installed and writes the kubeconfig entries itself otherwise.
+func updateKubeconfigForEKS(sess *session.Session, clusterName string, region string) error {
+	if _, err := exec.LookPath("aws"); err != nil {
+		fmt.Println("AWS CLI not found, writing kubeconfig directly.")
+		if err := writeKubeconfig(sess, clusterName, region); err != nil {
+			return err
+		}
+		fmt.Printf("Kubeconfig updated successfully for cluster %s (%s).\n", clusterName, region)
+		return nil
+	}
+
 	cmd := exec.Command("aws", "eks", "update-kubeconfig",
 		"--name", clusterName,
 		"--region", region,
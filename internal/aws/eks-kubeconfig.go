@@ -0,0 +1,124 @@
+package aws
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/sts"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientauthv1beta1 "k8s.io/client-go/pkg/apis/clientauthentication/v1beta1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// eksTokenPrefix and eksClusterHeader are what the EKS authenticator expects
+// in a bearer token built from a presigned GetCallerIdentity request
+const (
+	eksTokenPrefix   = "k8s-aws-v1."
+	eksClusterHeader = "x-k8s-aws-id"
+)
+
+// eksTokenLifetime is how long EKS accepts a token; the presigned URL is
+// valid for a minute but EKS honours it for 15.
+const eksTokenLifetime = 14 * time.Minute
+
+// writeKubeconfig adds the cluster to kubeconfig without the AWS CLI. The
+// entries mirror those of 'aws eks update-kubeconfig', except that the
+// credentials come from this binary's hidden eks-token command.
+func writeKubeconfig(sess *session.Session, clusterName, region string) error {
+	described, err := eks.New(sess).DescribeCluster(&eks.DescribeClusterInput{Name: aws.String(clusterName)})
+	if err != nil {
+		return fmt.Errorf("failed to describe cluster %s: %w", clusterName, err)
+	}
+	cluster := described.Cluster
+	if cluster.CertificateAuthority == nil || cluster.Endpoint == nil {
+		return fmt.Errorf("cluster %s has no endpoint yet, is it still creating?", clusterName)
+	}
+	caData, err := base64.StdEncoding.DecodeString(aws.StringValue(cluster.CertificateAuthority.Data))
+	if err != nil {
+		return fmt.Errorf("invalid certificate authority for cluster %s: %w", clusterName, err)
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate swissarmycli executable: %w", err)
+	}
+
+	pathOptions := clientcmd.NewDefaultPathOptions()
+	config, err := pathOptions.GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	name := aws.StringValue(cluster.Arn)
+	kubeCluster := clientcmdapi.NewCluster()
+	kubeCluster.Server = aws.StringValue(cluster.Endpoint)
+	kubeCluster.CertificateAuthorityData = caData
+	config.Clusters[name] = kubeCluster
+
+	authInfo := clientcmdapi.NewAuthInfo()
+	authInfo.Exec = &clientcmdapi.ExecConfig{
+		APIVersion:      clientauthv1beta1.SchemeGroupVersion.String(),
+		Command:         executable,
+		Args:            []string{"eks-token", "--cluster", clusterName, "--region", region},
+		InteractiveMode: clientcmdapi.NeverExecInteractiveMode,
+	}
+	if profile := os.Getenv("AWS_PROFILE"); profile != "" {
+		authInfo.Exec.Env = []clientcmdapi.ExecEnvVar{{Name: "AWS_PROFILE", Value: profile}}
+	}
+	config.AuthInfos[name] = authInfo
+
+	context := clientcmdapi.NewContext()
+	context.Cluster = name
+	context.AuthInfo = name
+	config.Contexts[name] = context
+	config.CurrentContext = name
+
+	if err := clientcmd.ModifyConfig(pathOptions, *config, true); err != nil {
+		return fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	return nil
+}
+
+// GetEKSToken returns a bearer token for an EKS cluster, the same token
+// 'aws eks get-token' produces, and when it expires.
+func GetEKSToken(clusterName, region, profile string) (string, time.Time, error) {
+	sess, err := NewSession(profile, region)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	request, _ := sts.New(sess).GetCallerIdentityRequest(&sts.GetCallerIdentityInput{})
+	request.HTTPRequest.Header.Add(eksClusterHeader, clusterName)
+	presigned, err := request.Presign(60 * time.Second)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to presign token request: %w", err)
+	}
+	token := eksTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(presigned))
+	return token, time.Now().Add(eksTokenLifetime), nil
+}
+
+// PrintEKSExecCredential writes an ExecCredential for the cluster to stdout,
+// for kubectl's exec credential plugin mechanism.
+func PrintEKSExecCredential(clusterName, region, profile string) error {
+	token, expires, err := GetEKSToken(clusterName, region, profile)
+	if err != nil {
+		return err
+	}
+	expiration := v1.NewTime(expires)
+	credential := clientauthv1beta1.ExecCredential{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: clientauthv1beta1.SchemeGroupVersion.String(),
+			Kind:       "ExecCredential",
+		},
+		Status: &clientauthv1beta1.ExecCredentialStatus{
+			Token:               token,
+			ExpirationTimestamp: &expiration,
+		},
+	}
+	return json.NewEncoder(os.Stdout).Encode(credential)
+}
@@ -0,0 +1,134 @@
+package aws
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+//go:embed eks-versions.json
+var eksVersionsData []byte
+
+// EKSCalendarURL is where FetchEKSCalendar reads the current EKS support
+// calendar from
+const EKSCalendarURL = "https://endoflife.date/api/amazon-eks.json"
+
+// EKSVersion is the support calendar of one EKS Kubernetes version
+type EKSVersion struct {
+	Version     string
+	Release     time.Time
+	StandardEnd time.Time // Extended support starts the day after
+	ExtendedEnd time.Time
+}
+
+// Support phases of an EKS version
+const (
+	SupportStandard    = "standard"
+	SupportExtended    = "extended"
+	SupportUnsupported = "unsupported"
+	SupportUnknown     = "unknown"
+)
+
+// Phase returns the support phase of the version at now and the day that
+// phase ends, or for unsupported versions the day extended support ended.
+func (v EKSVersion) Phase(now time.Time) (string, time.Time) {
+	switch {
+	case now.Before(v.StandardEnd):
+		return SupportStandard, v.StandardEnd
+	case now.Before(v.ExtendedEnd):
+		return SupportExtended, v.ExtendedEnd
+	default:
+		return SupportUnsupported, v.ExtendedEnd
+	}
+}
+
+// BundledEKSCalendar returns the support calendar built into the binary, by
+// version.
+func BundledEKSCalendar() (map[string]EKSVersion, error) {
+	var entries []struct {
+		Version     string `json:"version"`
+		Release     string `json:"release"`
+		StandardEnd string `json:"standard_end"`
+		ExtendedEnd string `json:"extended_end"`
+	}
+	if err := json.Unmarshal(eksVersionsData, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse the bundled EKS calendar: %w", err)
+	}
+	calendar := make(map[string]EKSVersion)
+	for _, entry := range entries {
+		version := EKSVersion{Version: entry.Version}
+		var errs [3]error
+		version.Release, errs[0] = time.Parse(time.DateOnly, entry.Release)
+		version.StandardEnd, errs[1] = time.Parse(time.DateOnly, entry.StandardEnd)
+		version.ExtendedEnd, errs[2] = time.Parse(time.DateOnly, entry.ExtendedEnd)
+		for _, err := range errs {
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse the bundled EKS calendar entry of %s: %w", entry.Version, err)
+			}
+		}
+		calendar[entry.Version] = version
+	}
+	return calendar, nil
+}
+
+// FetchEKSCalendar reads the current support calendar from EKSCalendarURL,
+// to pick up versions released after the binary was built. Versions whose
+// dates aren't announced yet are left out.
+func FetchEKSCalendar() (map[string]EKSVersion, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(EKSCalendarURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the EKS calendar: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch the EKS calendar: %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the EKS calendar: %w", err)
+	}
+
+	// eol is the end of standard support; extendedSupport its end, or a
+	// boolean while no date is announced
+	var cycles []struct {
+		Cycle           string          `json:"cycle"`
+		ReleaseDate     string          `json:"releaseDate"`
+		EOL             json.RawMessage `json:"eol"`
+		ExtendedSupport json.RawMessage `json:"extendedSupport"`
+	}
+	if err := json.Unmarshal(body, &cycles); err != nil {
+		return nil, fmt.Errorf("failed to parse the EKS calendar: %w", err)
+	}
+	calendar := make(map[string]EKSVersion)
+	for _, cycle := range cycles {
+		standardEnd, ok := parseCalendarDate(cycle.EOL)
+		if !ok {
+			continue
+		}
+		extendedEnd, ok := parseCalendarDate(cycle.ExtendedSupport)
+		if !ok {
+			extendedEnd = standardEnd.AddDate(1, 0, 0)
+		}
+		release, _ := time.Parse(time.DateOnly, cycle.ReleaseDate)
+		calendar[cycle.Cycle] = EKSVersion{Version: cycle.Cycle, Release: release, StandardEnd: standardEnd, ExtendedEnd: extendedEnd}
+	}
+	if len(calendar) == 0 {
+		return nil, fmt.Errorf("the EKS calendar at %s lists no versions", EKSCalendarURL)
+	}
+	return calendar, nil
+}
+
+// parseCalendarDate parses a JSON string holding a date, and reports false
+// for anything else
+func parseCalendarDate(raw json.RawMessage) (time.Time, bool) {
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return time.Time{}, false
+	}
+	date, err := time.Parse(time.DateOnly, value)
+	return date, err == nil
+}
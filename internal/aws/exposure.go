@@ -0,0 +1,147 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+)
+
+// OpenIngressRule is a security group rule allowing traffic from anywhere
+type OpenIngressRule struct {
+	GroupID   string
+	GroupName string
+	Protocol  string // tcp, udp, icmp or all
+	FromPort  int64  // -1 with ToPort -1 means every port
+	ToPort    int64
+	Source    string // 0.0.0.0/0 or ::/0
+}
+
+// Covers reports whether the rule lets traffic through to the port.
+func (r OpenIngressRule) Covers(port int64) bool {
+	if r.Protocol == "all" || (r.FromPort == -1 && r.ToPort == -1) {
+		return true
+	}
+	return port >= r.FromPort && port <= r.ToPort
+}
+
+// Ports renders the rule's port range.
+func (r OpenIngressRule) Ports() string {
+	switch {
+	case r.Protocol == "all" || (r.FromPort == -1 && r.ToPort == -1):
+		return "all"
+	case r.FromPort == r.ToPort:
+		return fmt.Sprintf("%d", r.FromPort)
+	}
+	return fmt.Sprintf("%d-%d", r.FromPort, r.ToPort)
+}
+
+// LoadBalancerSchemesByDNS maps load balancer DNS names to their scheme,
+// internet-facing or internal.
+func LoadBalancerSchemesByDNS(sess *session.Session) (map[string]string, error) {
+	schemes := make(map[string]string)
+
+	err := elbv2.New(sess).DescribeLoadBalancersPages(&elbv2.DescribeLoadBalancersInput{},
+		func(page *elbv2.DescribeLoadBalancersOutput, lastPage bool) bool {
+			for _, lb := range page.LoadBalancers {
+				schemes[strings.ToLower(aws.StringValue(lb.DNSName))] = aws.StringValue(lb.Scheme)
+			}
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe load balancers: %w", err)
+	}
+
+	err = elb.New(sess).DescribeLoadBalancersPages(&elb.DescribeLoadBalancersInput{},
+		func(page *elb.DescribeLoadBalancersOutput, lastPage bool) bool {
+			for _, lb := range page.LoadBalancerDescriptions {
+				schemes[strings.ToLower(aws.StringValue(lb.DNSName))] = aws.StringValue(lb.Scheme)
+			}
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe classic load balancers: %w", err)
+	}
+	return schemes, nil
+}
+
+// FindOpenIngressRules returns the rules of the instances' security groups
+// that allow traffic from 0.0.0.0/0 or ::/0.
+func FindOpenIngressRules(sess *session.Session, instanceIDs []string) ([]OpenIngressRule, error) {
+	ec2Svc := ec2.New(sess)
+	groupIDs := make(map[string]bool)
+	err := ec2Svc.DescribeInstancesPages(&ec2.DescribeInstancesInput{InstanceIds: aws.StringSlice(instanceIDs)},
+		func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+			for _, reservation := range page.Reservations {
+				for _, instance := range reservation.Instances {
+					// Interfaces can have their own groups, e.g. branch ENIs
+					for _, iface := range instance.NetworkInterfaces {
+						for _, group := range iface.Groups {
+							groupIDs[aws.StringValue(group.GroupId)] = true
+						}
+					}
+					for _, group := range instance.SecurityGroups {
+						groupIDs[aws.StringValue(group.GroupId)] = true
+					}
+				}
+			}
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instances: %w", err)
+	}
+	if len(groupIDs) == 0 {
+		return nil, nil
+	}
+
+	output, err := ec2Svc.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{
+		GroupIds: aws.StringSlice(sortedKeys(groupIDs)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe security groups: %w", err)
+	}
+
+	var rules []OpenIngressRule
+	for _, group := range output.SecurityGroups {
+		for _, permission := range group.IpPermissions {
+			var sources []string
+			for _, ipRange := range permission.IpRanges {
+				if aws.StringValue(ipRange.CidrIp) == "0.0.0.0/0" {
+					sources = append(sources, "0.0.0.0/0")
+				}
+			}
+			for _, ipRange := range permission.Ipv6Ranges {
+				if aws.StringValue(ipRange.CidrIpv6) == "::/0" {
+					sources = append(sources, "::/0")
+				}
+			}
+
+			protocol := aws.StringValue(permission.IpProtocol)
+			if protocol == "-1" {
+				protocol = "all"
+			}
+			fromPort, toPort := int64(-1), int64(-1)
+			if permission.FromPort != nil {
+				fromPort = aws.Int64Value(permission.FromPort)
+			}
+			if permission.ToPort != nil {
+				toPort = aws.Int64Value(permission.ToPort)
+			}
+			for _, source := range sources {
+				rules = append(rules, OpenIngressRule{
+					GroupID:   aws.StringValue(group.GroupId),
+					GroupName: aws.StringValue(group.GroupName),
+					Protocol:  protocol,
+					FromPort:  fromPort,
+					ToPort:    toPort,
+					Source:    source,
+				})
+			}
+		}
+	}
+	return rules, nil
+}
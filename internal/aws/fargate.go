@@ -0,0 +1,61 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/eks"
+)
+
+// FargateProfile is an EKS Fargate profile with the pods it selects
+type FargateProfile struct {
+	Name      string
+	Status    string
+	Selectors []FargateSelector
+	Subnets   []string
+}
+
+// FargateSelector matches pods by namespace and, optionally, labels
+type FargateSelector struct {
+	Namespace string
+	Labels    map[string]string
+}
+
+// ListFargateProfiles returns the Fargate profiles of an EKS cluster.
+func ListFargateProfiles(sess *session.Session, clusterName string) ([]FargateProfile, error) {
+	client := eks.New(sess)
+	var names []*string
+	err := client.ListFargateProfilesPages(&eks.ListFargateProfilesInput{ClusterName: aws.String(clusterName)},
+		func(page *eks.ListFargateProfilesOutput, lastPage bool) bool {
+			names = append(names, page.FargateProfileNames...)
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Fargate profiles of %s: %w", clusterName, err)
+	}
+
+	var profiles []FargateProfile
+	for _, name := range names {
+		output, err := client.DescribeFargateProfile(&eks.DescribeFargateProfileInput{
+			ClusterName:        aws.String(clusterName),
+			FargateProfileName: name,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe Fargate profile %s: %w", aws.StringValue(name), err)
+		}
+		profile := FargateProfile{
+			Name:    aws.StringValue(output.FargateProfile.FargateProfileName),
+			Status:  aws.StringValue(output.FargateProfile.Status),
+			Subnets: aws.StringValueSlice(output.FargateProfile.Subnets),
+		}
+		for _, selector := range output.FargateProfile.Selectors {
+			profile.Selectors = append(profile.Selectors, FargateSelector{
+				Namespace: aws.StringValue(selector.Namespace),
+				Labels:    aws.StringValueMap(selector.Labels),
+			})
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
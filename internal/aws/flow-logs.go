@@ -0,0 +1,124 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// insightsQueryTimeout bounds how long Logs Insights queries may run
+const insightsQueryTimeout = 5 * time.Minute
+
+// InstanceNetwork is where an instance sits in the VPC
+type InstanceNetwork struct {
+	VpcID             string
+	SubnetID          string
+	NetworkInterfaces []string // IDs of every attached ENI
+}
+
+// GetInstanceNetwork returns the VPC, subnet and ENIs of an instance.
+func GetInstanceNetwork(sess *session.Session, instanceID string) (*InstanceNetwork, error) {
+	output, err := ec2.New(sess).DescribeInstances(&ec2.DescribeInstancesInput{
+		InstanceIds: aws.StringSlice([]string{instanceID}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instance %s: %w", instanceID, err)
+	}
+	for _, reservation := range output.Reservations {
+		for _, instance := range reservation.Instances {
+			network := &InstanceNetwork{
+				VpcID:    aws.StringValue(instance.VpcId),
+				SubnetID: aws.StringValue(instance.SubnetId),
+			}
+			for _, eni := range instance.NetworkInterfaces {
+				network.NetworkInterfaces = append(network.NetworkInterfaces, aws.StringValue(eni.NetworkInterfaceId))
+			}
+			return network, nil
+		}
+	}
+	return nil, fmt.Errorf("instance %s not found", instanceID)
+}
+
+// FindFlowLogGroup returns the CloudWatch Logs group that flow logs of the
+// VPC or subnet are delivered to, or an empty string when there is none.
+func FindFlowLogGroup(sess *session.Session, vpcID, subnetID string) (string, error) {
+	output, err := ec2.New(sess).DescribeFlowLogs(&ec2.DescribeFlowLogsInput{
+		Filter: []*ec2.Filter{{Name: aws.String("resource-id"), Values: aws.StringSlice([]string{vpcID, subnetID})}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe flow logs: %w", err)
+	}
+	for _, flowLog := range output.FlowLogs {
+		if aws.StringValue(flowLog.LogDestinationType) == ec2.LogDestinationTypeCloudWatchLogs &&
+			aws.StringValue(flowLog.TrafficType) != ec2.TrafficTypeAccept {
+			return aws.StringValue(flowLog.LogGroupName), nil
+		}
+	}
+	for _, flowLog := range output.FlowLogs {
+		if aws.StringValue(flowLog.LogDestinationType) == ec2.LogDestinationTypeCloudWatchLogs {
+			return aws.StringValue(flowLog.LogGroupName), nil
+		}
+	}
+	return "", nil
+}
+
+// RunInsightsQueries runs Logs Insights queries over a log group at the same
+// time and returns the rows of each, as field name to value.
+func RunInsightsQueries(sess *session.Session, logGroup string, queries []string, start, end time.Time) ([][]map[string]string, error) {
+	logsSvc := cloudwatchlogs.New(sess)
+	queryIDs := make([]string, len(queries))
+	for i, query := range queries {
+		output, err := logsSvc.StartQuery(&cloudwatchlogs.StartQueryInput{
+			LogGroupName: aws.String(logGroup),
+			QueryString:  aws.String(query),
+			StartTime:    aws.Int64(start.Unix()),
+			EndTime:      aws.Int64(end.Unix()),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to start Logs Insights query: %w", err)
+		}
+		queryIDs[i] = aws.StringValue(output.QueryId)
+	}
+
+	results := make([][]map[string]string, len(queries))
+	pending := len(queries)
+	done := make([]bool, len(queries))
+	deadline := time.Now().Add(insightsQueryTimeout)
+	for pending > 0 {
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("Logs Insights queries did not finish within %s", insightsQueryTimeout)
+		}
+		time.Sleep(2 * time.Second)
+		for i, queryID := range queryIDs {
+			if done[i] {
+				continue
+			}
+			output, err := logsSvc.GetQueryResults(&cloudwatchlogs.GetQueryResultsInput{QueryId: aws.String(queryID)})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get Logs Insights results: %w", err)
+			}
+			switch aws.StringValue(output.Status) {
+			case cloudwatchlogs.QueryStatusComplete:
+			case cloudwatchlogs.QueryStatusFailed, cloudwatchlogs.QueryStatusCancelled, cloudwatchlogs.QueryStatusTimeout:
+				return nil, fmt.Errorf("Logs Insights query %s", aws.StringValue(output.Status))
+			default:
+				continue
+			}
+
+			for _, fields := range output.Results {
+				row := make(map[string]string)
+				for _, field := range fields {
+					row[aws.StringValue(field.Field)] = aws.StringValue(field.Value)
+				}
+				results[i] = append(results[i], row)
+			}
+			done[i] = true
+			pending--
+		}
+	}
+	return results, nil
+}
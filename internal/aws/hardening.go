@@ -0,0 +1,96 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// volumeFilterValues is the most instance IDs passed in one volume filter
+const volumeFilterValues = 100
+
+// InstanceHardening is the security relevant configuration of an EC2
+// instance
+type InstanceHardening struct {
+	InstanceID         string
+	HTTPTokens         string // IMDS session tokens: required or optional
+	HTTPEndpoint       string // IMDS enabled or disabled
+	HopLimit           int64  // IMDS PUT response hop limit
+	PublicIP           string
+	SubnetID           string
+	InstanceProfile    string // Name of the instance profile, empty when there is none
+	Volumes            int
+	UnencryptedVolumes []string
+}
+
+// GetInstanceHardening describes the metadata options, public IP, instance
+// profile and EBS volume encryption of each instance by ID.
+func GetInstanceHardening(sess *session.Session, instanceIDs []string) (map[string]*InstanceHardening, error) {
+	client := ec2.New(sess)
+	hardening := make(map[string]*InstanceHardening)
+	input := &ec2.DescribeInstancesInput{InstanceIds: aws.StringSlice(instanceIDs)}
+	err := client.DescribeInstancesPages(input, func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				entry := &InstanceHardening{
+					InstanceID: aws.StringValue(instance.InstanceId),
+					PublicIP:   aws.StringValue(instance.PublicIpAddress),
+					SubnetID:   aws.StringValue(instance.SubnetId),
+				}
+				if options := instance.MetadataOptions; options != nil {
+					entry.HTTPTokens = aws.StringValue(options.HttpTokens)
+					entry.HTTPEndpoint = aws.StringValue(options.HttpEndpoint)
+					entry.HopLimit = aws.Int64Value(options.HttpPutResponseHopLimit)
+				}
+				if profile := instance.IamInstanceProfile; profile != nil {
+					arn := aws.StringValue(profile.Arn)
+					entry.InstanceProfile = arn[strings.LastIndex(arn, "/")+1:]
+				}
+				hardening[entry.InstanceID] = entry
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instances: %w", err)
+	}
+
+	for start := 0; start < len(instanceIDs); start += volumeFilterValues {
+		chunk := instanceIDs[start:min(start+volumeFilterValues, len(instanceIDs))]
+		volumesInput := &ec2.DescribeVolumesInput{
+			Filters: []*ec2.Filter{{Name: aws.String("attachment.instance-id"), Values: aws.StringSlice(chunk)}},
+		}
+		err := client.DescribeVolumesPages(volumesInput, func(page *ec2.DescribeVolumesOutput, lastPage bool) bool {
+			for _, volume := range page.Volumes {
+				for _, attachment := range volume.Attachments {
+					entry, ok := hardening[aws.StringValue(attachment.InstanceId)]
+					if !ok {
+						continue
+					}
+					entry.Volumes++
+					if !aws.BoolValue(volume.Encrypted) {
+						entry.UnencryptedVolumes = append(entry.UnencryptedVolumes, aws.StringValue(volume.VolumeId))
+					}
+				}
+			}
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe volumes: %w", err)
+		}
+	}
+	return hardening, nil
+}
+
+// EBSEncryptionByDefault reports whether new EBS volumes of the region are
+// encrypted by default.
+func EBSEncryptionByDefault(sess *session.Session) (bool, error) {
+	output, err := ec2.New(sess).GetEbsEncryptionByDefault(&ec2.GetEbsEncryptionByDefaultInput{})
+	if err != nil {
+		return false, fmt.Errorf("failed to get EBS encryption by default: %w", err)
+	}
+	return aws.BoolValue(output.EbsEncryptionByDefault), nil
+}
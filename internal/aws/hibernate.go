@@ -0,0 +1,306 @@
+package aws
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/eks"
+)
+
+// hibernatedSizesTag records the sizes a group had before it was hibernated
+const hibernatedSizesTag = "swissarmycli/hibernated-sizes"
+
+// Names of the scheduled actions created by hibernate and resume --schedule
+const (
+	HibernateActionName = "swissarmycli-hibernate"
+	ResumeActionName    = "swissarmycli-resume"
+)
+
+// GroupSizes is the scaling configuration of an ASG or node group
+type GroupSizes struct {
+	Min     int64
+	Max     int64
+	Desired int64
+}
+
+func (s GroupSizes) String() string {
+	return fmt.Sprintf("min %d, max %d, desired %d", s.Min, s.Max, s.Desired)
+}
+
+func (s GroupSizes) tagValue() string {
+	return fmt.Sprintf("min=%d,max=%d,desired=%d", s.Min, s.Max, s.Desired)
+}
+
+func parseGroupSizes(value string) (*GroupSizes, error) {
+	sizes := &GroupSizes{}
+	for _, field := range strings.Split(value, ",") {
+		key, number, _ := strings.Cut(field, "=")
+		size, err := strconv.ParseInt(number, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s tag '%s'", hibernatedSizesTag, value)
+		}
+		switch key {
+		case "min":
+			sizes.Min = size
+		case "max":
+			sizes.Max = size
+		case "desired":
+			sizes.Desired = size
+		default:
+			return nil, fmt.Errorf("invalid %s tag '%s'", hibernatedSizesTag, value)
+		}
+	}
+	return sizes, nil
+}
+
+// ScalableGroup is an ASG, or an EKS managed node group together with the
+// ASG EKS runs it in
+type ScalableGroup struct {
+	ASGName       string
+	Cluster       string // Set for managed node groups
+	NodeGroup     string
+	NodeGroupARN  string
+	Sizes         GroupSizes     // Current sizes
+	Recorded      *GroupSizes    // Sizes before hibernation, nil when not hibernated
+	InstanceTypes map[string]int // Running instances by type
+}
+
+// Name is how the group is shown: the ASG, or cluster/node group
+func (g ScalableGroup) Name() string {
+	if g.NodeGroup != "" {
+		return g.Cluster + "/" + g.NodeGroup
+	}
+	return g.ASGName
+}
+
+// Hibernated reports whether the group is scaled to zero with its prior
+// sizes recorded.
+func (g ScalableGroup) Hibernated() bool {
+	return g.Recorded != nil && g.Sizes.Desired == 0
+}
+
+// FindScalableGroups describes the named ASGs and the managed node groups of
+// cluster, all of them unless nodeGroups names some.
+func FindScalableGroups(sess *session.Session, asgNames []string, cluster string, nodeGroups []string) ([]*ScalableGroup, error) {
+	var groups []*ScalableGroup
+	byASG := make(map[string]*ScalableGroup)
+	for _, name := range asgNames {
+		group := &ScalableGroup{ASGName: name}
+		groups = append(groups, group)
+		byASG[name] = group
+	}
+
+	if cluster != "" {
+		eksSvc := eks.New(sess)
+		if len(nodeGroups) == 0 {
+			err := eksSvc.ListNodegroupsPages(&eks.ListNodegroupsInput{ClusterName: aws.String(cluster)},
+				func(page *eks.ListNodegroupsOutput, lastPage bool) bool {
+					nodeGroups = append(nodeGroups, aws.StringValueSlice(page.Nodegroups)...)
+					return true
+				})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list node groups of cluster %s: %w", cluster, err)
+			}
+		}
+		for _, name := range nodeGroups {
+			output, err := eksSvc.DescribeNodegroup(&eks.DescribeNodegroupInput{
+				ClusterName:   aws.String(cluster),
+				NodegroupName: aws.String(name),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to describe node group %s: %w", name, err)
+			}
+			nodeGroup := output.Nodegroup
+			group := &ScalableGroup{
+				Cluster:      cluster,
+				NodeGroup:    name,
+				NodeGroupARN: aws.StringValue(nodeGroup.NodegroupArn),
+			}
+			if value, ok := nodeGroup.Tags[hibernatedSizesTag]; ok {
+				if group.Recorded, err = parseGroupSizes(aws.StringValue(value)); err != nil {
+					return nil, err
+				}
+			}
+			if nodeGroup.Resources == nil || len(nodeGroup.Resources.AutoScalingGroups) == 0 {
+				return nil, fmt.Errorf("node group %s has no Auto Scaling group yet", name)
+			}
+			group.ASGName = aws.StringValue(nodeGroup.Resources.AutoScalingGroups[0].Name)
+			groups = append(groups, group)
+			byASG[group.ASGName] = group
+		}
+	}
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	names := sortedKeys(byASG)
+	asgSvc := autoscaling.New(sess)
+	for start := 0; start < len(names); start += 50 {
+		batch := names[start:min(start+50, len(names))]
+		err := asgSvc.DescribeAutoScalingGroupsPages(&autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: aws.StringSlice(batch),
+		}, func(page *autoscaling.DescribeAutoScalingGroupsOutput, lastPage bool) bool {
+			for _, asg := range page.AutoScalingGroups {
+				group := byASG[aws.StringValue(asg.AutoScalingGroupName)]
+				if group == nil {
+					continue
+				}
+				group.Sizes = GroupSizes{
+					Min:     aws.Int64Value(asg.MinSize),
+					Max:     aws.Int64Value(asg.MaxSize),
+					Desired: aws.Int64Value(asg.DesiredCapacity),
+				}
+				group.InstanceTypes = make(map[string]int)
+				for _, instance := range asg.Instances {
+					group.InstanceTypes[aws.StringValue(instance.InstanceType)]++
+				}
+				for _, tag := range asg.Tags {
+					if group.NodeGroup == "" && aws.StringValue(tag.Key) == hibernatedSizesTag {
+						group.Recorded, _ = parseGroupSizes(aws.StringValue(tag.Value))
+					}
+				}
+			}
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe Auto Scaling groups: %w", err)
+		}
+	}
+	for _, group := range groups {
+		if group.InstanceTypes == nil {
+			return nil, fmt.Errorf("ASG not found: %s", group.ASGName)
+		}
+	}
+	return groups, nil
+}
+
+// RecordGroupSizes tags the group with its current sizes, so resume can
+// restore them. A group that is already hibernated keeps its record.
+func RecordGroupSizes(sess *session.Session, group *ScalableGroup) error {
+	if group.Hibernated() {
+		return nil
+	}
+	value := group.Sizes.tagValue()
+	if group.NodeGroup != "" {
+		_, err := eks.New(sess).TagResource(&eks.TagResourceInput{
+			ResourceArn: aws.String(group.NodeGroupARN),
+			Tags:        map[string]*string{hibernatedSizesTag: aws.String(value)},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to tag node group %s: %w", group.NodeGroup, err)
+		}
+	} else {
+		_, err := autoscaling.New(sess).CreateOrUpdateTags(&autoscaling.CreateOrUpdateTagsInput{
+			Tags: []*autoscaling.Tag{{
+				ResourceId:        aws.String(group.ASGName),
+				ResourceType:      aws.String("auto-scaling-group"),
+				Key:               aws.String(hibernatedSizesTag),
+				Value:             aws.String(value),
+				PropagateAtLaunch: aws.Bool(false),
+			}},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to tag ASG %s: %w", group.ASGName, err)
+		}
+	}
+	sizes := group.Sizes
+	group.Recorded = &sizes
+	return nil
+}
+
+// HibernateGroup records the group's sizes and scales it to zero. The
+// maximum is kept, as EKS requires node groups to have one of at least 1.
+func HibernateGroup(sess *session.Session, group *ScalableGroup) error {
+	if err := RecordGroupSizes(sess, group); err != nil {
+		return err
+	}
+	return scaleGroup(sess, group, GroupSizes{Min: 0, Max: group.Sizes.Max, Desired: 0})
+}
+
+// ResumeGroup scales the group back to its recorded sizes and removes the
+// record.
+func ResumeGroup(sess *session.Session, group *ScalableGroup) error {
+	if group.Recorded == nil {
+		return fmt.Errorf("%s has no recorded sizes to resume to", group.Name())
+	}
+	if err := scaleGroup(sess, group, *group.Recorded); err != nil {
+		return err
+	}
+
+	if group.NodeGroup != "" {
+		_, err := eks.New(sess).UntagResource(&eks.UntagResourceInput{
+			ResourceArn: aws.String(group.NodeGroupARN),
+			TagKeys:     aws.StringSlice([]string{hibernatedSizesTag}),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to untag node group %s: %w", group.NodeGroup, err)
+		}
+		return nil
+	}
+	_, err := autoscaling.New(sess).DeleteTags(&autoscaling.DeleteTagsInput{
+		Tags: []*autoscaling.Tag{{
+			ResourceId:   aws.String(group.ASGName),
+			ResourceType: aws.String("auto-scaling-group"),
+			Key:          aws.String(hibernatedSizesTag),
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to untag ASG %s: %w", group.ASGName, err)
+	}
+	return nil
+}
+
+// scaleGroup sets the sizes through EKS for managed node groups, which
+// would otherwise undo the change, and on the ASG for everything else.
+func scaleGroup(sess *session.Session, group *ScalableGroup, sizes GroupSizes) error {
+	if group.NodeGroup != "" {
+		_, err := eks.New(sess).UpdateNodegroupConfig(&eks.UpdateNodegroupConfigInput{
+			ClusterName:   aws.String(group.Cluster),
+			NodegroupName: aws.String(group.NodeGroup),
+			ScalingConfig: &eks.NodegroupScalingConfig{
+				MinSize:     aws.Int64(sizes.Min),
+				MaxSize:     aws.Int64(sizes.Max),
+				DesiredSize: aws.Int64(sizes.Desired),
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scale node group %s: %w", group.NodeGroup, err)
+		}
+		return nil
+	}
+	_, err := autoscaling.New(sess).UpdateAutoScalingGroup(&autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: aws.String(group.ASGName),
+		MinSize:              aws.Int64(sizes.Min),
+		MaxSize:              aws.Int64(sizes.Max),
+		DesiredCapacity:      aws.Int64(sizes.Desired),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scale ASG %s: %w", group.ASGName, err)
+	}
+	return nil
+}
+
+// ScheduleGroupSizes creates or replaces a recurring scheduled action on the
+// group's ASG that sets it to sizes. recurrence is a cron expression
+// evaluated in timeZone (an IANA name, UTC when empty).
+func ScheduleGroupSizes(sess *session.Session, group *ScalableGroup, actionName string, sizes GroupSizes, recurrence, timeZone string) error {
+	input := &autoscaling.PutScheduledUpdateGroupActionInput{
+		AutoScalingGroupName: aws.String(group.ASGName),
+		ScheduledActionName:  aws.String(actionName),
+		Recurrence:           aws.String(recurrence),
+		MinSize:              aws.Int64(sizes.Min),
+		MaxSize:              aws.Int64(sizes.Max),
+		DesiredCapacity:      aws.Int64(sizes.Desired),
+	}
+	if timeZone != "" {
+		input.TimeZone = aws.String(timeZone)
+	}
+	if _, err := autoscaling.New(sess).PutScheduledUpdateGroupAction(input); err != nil {
+		return fmt.Errorf("failed to schedule %s on ASG %s: %w", actionName, group.ASGName, err)
+	}
+	return nil
+}
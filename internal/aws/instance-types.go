@@ -0,0 +1,89 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// describeInstanceTypesBatch is the most instance types one
+// DescribeInstanceTypes call accepts
+const describeInstanceTypesBatch = 100
+
+// InstanceTypeSpec is the size of an EC2 instance type
+type InstanceTypeSpec struct {
+	Name         string
+	VCPUs        int64
+	MemoryGiB    float64
+	Architecture string // x86_64 or arm64
+	MaxPods      int    // With the VPC CNI, as the EKS AMI computes it
+}
+
+// DescribeInstanceTypeSpecs returns the vCPUs, memory, architecture and
+// maximum pods of the instance types offered in the region. Types the
+// region doesn't offer are left out.
+func DescribeInstanceTypeSpecs(profile, region string, types []string) (map[string]InstanceTypeSpec, error) {
+	sess, err := NewSession(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	client := ec2.New(sess)
+	specs := make(map[string]InstanceTypeSpec)
+	for start := 0; start < len(types); start += describeInstanceTypesBatch {
+		end := min(start+describeInstanceTypesBatch, len(types))
+		var offered []*string
+		// Unknown types fail the whole call, so only offered ones are asked for
+		err := client.DescribeInstanceTypeOfferingsPages(&ec2.DescribeInstanceTypeOfferingsInput{
+			Filters: []*ec2.Filter{{Name: aws.String("instance-type"), Values: aws.StringSlice(types[start:end])}},
+		}, func(page *ec2.DescribeInstanceTypeOfferingsOutput, lastPage bool) bool {
+			for _, offering := range page.InstanceTypeOfferings {
+				offered = append(offered, offering.InstanceType)
+			}
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe instance type offerings: %w", err)
+		}
+		if len(offered) == 0 {
+			continue
+		}
+		err = client.DescribeInstanceTypesPages(&ec2.DescribeInstanceTypesInput{InstanceTypes: offered},
+			func(page *ec2.DescribeInstanceTypesOutput, lastPage bool) bool {
+				for _, info := range page.InstanceTypes {
+					spec := InstanceTypeSpec{Name: aws.StringValue(info.InstanceType)}
+					if info.VCpuInfo != nil {
+						spec.VCPUs = aws.Int64Value(info.VCpuInfo.DefaultVCpus)
+					}
+					if info.MemoryInfo != nil {
+						spec.MemoryGiB = float64(aws.Int64Value(info.MemoryInfo.SizeInMiB)) / 1024
+					}
+					if info.ProcessorInfo != nil && len(info.ProcessorInfo.SupportedArchitectures) > 0 {
+						spec.Architecture = aws.StringValue(info.ProcessorInfo.SupportedArchitectures[0])
+					}
+					if info.NetworkInfo != nil {
+						spec.MaxPods = eksMaxPods(aws.Int64Value(info.NetworkInfo.MaximumNetworkInterfaces),
+							aws.Int64Value(info.NetworkInfo.Ipv4AddressesPerInterface), spec.VCPUs)
+					}
+					specs[spec.Name] = spec
+				}
+				return true
+			})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe instance types: %w", err)
+		}
+	}
+	return specs, nil
+}
+
+// eksMaxPods is the pod limit of a node using the VPC CNI without prefix
+// delegation: one IP per pod on every ENI, except the ENI's own, plus two
+// host network pods, capped at 110 below 30 vCPUs and 250 above.
+func eksMaxPods(enis, ipsPerENI, vcpus int64) int {
+	maxPods := int(enis*(ipsPerENI-1) + 2)
+	limit := 110
+	if vcpus >= 30 {
+		limit = 250
+	}
+	return min(maxPods, limit)
+}
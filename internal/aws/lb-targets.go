@@ -0,0 +1,135 @@
+package aws
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+)
+
+// TargetRegistration is a target registered in an ALB or NLB target group
+type TargetRegistration struct {
+	TargetGroupArn   string
+	TargetGroupName  string
+	TargetID         string // Instance ID or IP address
+	Port             int64
+	AvailabilityZone string // Set for IP targets outside the VPC
+	State            string // initial, healthy, unhealthy, unused, draining or unavailable
+	DrainDelay       int64  // deregistration_delay.timeout_seconds of the target group
+}
+
+// FindTargetRegistrations returns every registration of the given target IDs
+// across all target groups of the region.
+func FindTargetRegistrations(sess *session.Session, targetIDs []string) ([]TargetRegistration, error) {
+	wanted := make(map[string]bool)
+	for _, id := range targetIDs {
+		wanted[id] = true
+	}
+	client := elbv2.New(sess)
+	var groups []*elbv2.TargetGroup
+	err := client.DescribeTargetGroupsPages(&elbv2.DescribeTargetGroupsInput{},
+		func(page *elbv2.DescribeTargetGroupsOutput, lastPage bool) bool {
+			groups = append(groups, page.TargetGroups...)
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe target groups: %w", err)
+	}
+
+	var registrations []TargetRegistration
+	for _, group := range groups {
+		if aws.StringValue(group.TargetType) == elbv2.TargetTypeEnumLambda || aws.StringValue(group.TargetType) == elbv2.TargetTypeEnumAlb {
+			continue
+		}
+		health, err := client.DescribeTargetHealth(&elbv2.DescribeTargetHealthInput{TargetGroupArn: group.TargetGroupArn})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe targets of %s: %w", aws.StringValue(group.TargetGroupName), err)
+		}
+		var matched []TargetRegistration
+		for _, description := range health.TargetHealthDescriptions {
+			if description.Target == nil || !wanted[aws.StringValue(description.Target.Id)] {
+				continue
+			}
+			registration := TargetRegistration{
+				TargetGroupArn:   aws.StringValue(group.TargetGroupArn),
+				TargetGroupName:  aws.StringValue(group.TargetGroupName),
+				TargetID:         aws.StringValue(description.Target.Id),
+				Port:             aws.Int64Value(description.Target.Port),
+				AvailabilityZone: aws.StringValue(description.Target.AvailabilityZone),
+			}
+			if description.TargetHealth != nil {
+				registration.State = aws.StringValue(description.TargetHealth.State)
+			}
+			matched = append(matched, registration)
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		delay, err := targetGroupDrainDelay(client, group.TargetGroupArn)
+		if err != nil {
+			return nil, err
+		}
+		for i := range matched {
+			matched[i].DrainDelay = delay
+		}
+		registrations = append(registrations, matched...)
+	}
+	return registrations, nil
+}
+
+// targetGroupDrainDelay returns how long, in seconds, the target group keeps
+// deregistered targets draining (300 unless changed).
+func targetGroupDrainDelay(client *elbv2.ELBV2, targetGroupArn *string) (int64, error) {
+	output, err := client.DescribeTargetGroupAttributes(&elbv2.DescribeTargetGroupAttributesInput{TargetGroupArn: targetGroupArn})
+	if err != nil {
+		return 0, fmt.Errorf("failed to describe attributes of %s: %w", aws.StringValue(targetGroupArn), err)
+	}
+	for _, attribute := range output.Attributes {
+		if aws.StringValue(attribute.Key) == "deregistration_delay.timeout_seconds" {
+			return strconv.ParseInt(aws.StringValue(attribute.Value), 10, 64)
+		}
+	}
+	return 300, nil
+}
+
+// targetDescription identifies the registration in API calls
+func (r TargetRegistration) targetDescription() *elbv2.TargetDescription {
+	target := &elbv2.TargetDescription{Id: aws.String(r.TargetID), Port: aws.Int64(r.Port)}
+	if r.AvailabilityZone != "" {
+		target.AvailabilityZone = aws.String(r.AvailabilityZone)
+	}
+	return target
+}
+
+// DeregisterTarget removes the registration from its target group. The
+// target keeps serving in-flight connections while it drains.
+func DeregisterTarget(sess *session.Session, registration TargetRegistration) error {
+	_, err := elbv2.New(sess).DeregisterTargets(&elbv2.DeregisterTargetsInput{
+		TargetGroupArn: aws.String(registration.TargetGroupArn),
+		Targets:        []*elbv2.TargetDescription{registration.targetDescription()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to deregister %s:%d from %s: %w", registration.TargetID, registration.Port, registration.TargetGroupName, err)
+	}
+	return nil
+}
+
+// TargetState returns the current state of the registration; "unused" once
+// the target has finished draining and is no longer registered.
+func TargetState(sess *session.Session, registration TargetRegistration) (string, error) {
+	output, err := elbv2.New(sess).DescribeTargetHealth(&elbv2.DescribeTargetHealthInput{
+		TargetGroupArn: aws.String(registration.TargetGroupArn),
+		Targets:        []*elbv2.TargetDescription{registration.targetDescription()},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe target health in %s: %w", registration.TargetGroupName, err)
+	}
+	for _, description := range output.TargetHealthDescriptions {
+		if description.TargetHealth != nil {
+			return aws.StringValue(description.TargetHealth.State), nil
+		}
+	}
+	return elbv2.TargetHealthStateEnumUnused, nil
+}
@@ -0,0 +1,97 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// NetworkInterfaceInfo is an ENI together with a description of what owns it
+type NetworkInterfaceInfo struct {
+	ID               string
+	Owner            string // What the ENI belongs to, e.g. a load balancer or instance
+	Description      string
+	InterfaceType    string
+	Status           string
+	InstanceID       string
+	PrivateIP        string
+	PublicIP         string
+	SubnetID         string
+	VpcID            string
+	AvailabilityZone string
+	SecurityGroups   []string
+}
+
+// FindNetworkInterfacesByIP returns the ENIs that have the IP as a private
+// (primary or secondary) or public address.
+func FindNetworkInterfacesByIP(sess *session.Session, ip string) ([]NetworkInterfaceInfo, error) {
+	ec2Svc := ec2.New(sess)
+	seen := make(map[string]bool)
+	var interfaces []NetworkInterfaceInfo
+	// Filters are ANDed, so private and public addresses need a call each
+	for _, filter := range []string{"addresses.private-ip-address", "association.public-ip"} {
+		output, err := ec2Svc.DescribeNetworkInterfaces(&ec2.DescribeNetworkInterfacesInput{
+			Filters: []*ec2.Filter{{Name: aws.String(filter), Values: aws.StringSlice([]string{ip})}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe network interfaces: %w", err)
+		}
+		for _, eni := range output.NetworkInterfaces {
+			id := aws.StringValue(eni.NetworkInterfaceId)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			info := NetworkInterfaceInfo{
+				ID:               id,
+				Description:      aws.StringValue(eni.Description),
+				InterfaceType:    aws.StringValue(eni.InterfaceType),
+				Status:           aws.StringValue(eni.Status),
+				PrivateIP:        aws.StringValue(eni.PrivateIpAddress),
+				SubnetID:         aws.StringValue(eni.SubnetId),
+				VpcID:            aws.StringValue(eni.VpcId),
+				AvailabilityZone: aws.StringValue(eni.AvailabilityZone),
+			}
+			if eni.Attachment != nil {
+				info.InstanceID = aws.StringValue(eni.Attachment.InstanceId)
+			}
+			if eni.Association != nil {
+				info.PublicIP = aws.StringValue(eni.Association.PublicIp)
+			}
+			for _, group := range eni.Groups {
+				info.SecurityGroups = append(info.SecurityGroups, aws.StringValue(group.GroupId))
+			}
+			info.Owner = networkInterfaceOwner(info)
+			interfaces = append(interfaces, info)
+		}
+	}
+	return interfaces, nil
+}
+
+// networkInterfaceOwner works out what an ENI belongs to from its
+// description and type, which AWS services fill in predictably.
+func networkInterfaceOwner(eni NetworkInterfaceInfo) string {
+	description := eni.Description
+	switch {
+	case strings.HasPrefix(description, "ELB "):
+		// ELB app/<name>/<id>, ELB net/<name>/<id> or ELB <classic name>
+		return "load balancer " + strings.TrimPrefix(description, "ELB ")
+	case strings.HasPrefix(description, "aws-K8S-"):
+		return "VPC CNI secondary ENI of instance " + eni.InstanceID
+	case strings.HasPrefix(description, "Amazon EKS "):
+		return "EKS control plane ENI of cluster " + strings.TrimPrefix(description, "Amazon EKS ")
+	case eni.InterfaceType == "nat_gateway" || strings.HasPrefix(description, "Interface for NAT Gateway "):
+		return "NAT gateway " + strings.TrimPrefix(description, "Interface for NAT Gateway ")
+	case eni.InterfaceType == "vpc_endpoint":
+		return "VPC endpoint"
+	case eni.InstanceID != "":
+		return "instance " + eni.InstanceID
+	case eni.InterfaceType != "" && eni.InterfaceType != "interface":
+		return eni.InterfaceType
+	}
+	return "unattached ENI"
+}
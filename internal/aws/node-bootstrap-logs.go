@@ -0,0 +1,310 @@
+package aws
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BootstrapLogsOptions contains options for collecting node bootstrap logs
+type BootstrapLogsOptions struct {
+	Region    string // Needed when the node never registered with the cluster
+	Profile   string
+	OutputDir string
+	Lines     int // Journal lines and file lines to collect per log
+	Timeout   time.Duration
+}
+
+// bootstrapLog is one log collected from the node. Output is gzipped and
+// base64 encoded on the node so more of it fits in the 24,000 characters SSM
+// returns inline.
+type bootstrapLog struct {
+	file    string
+	command string
+}
+
+func bootstrapLogSources(lines int) []bootstrapLog {
+	journal := func(units ...string) string {
+		return fmt.Sprintf("journalctl -u %s --no-pager -n %d", strings.Join(units, " -u "), lines)
+	}
+	file := func(path string) string {
+		return fmt.Sprintf("tail -n %d %s", lines, path)
+	}
+	return []bootstrapLog{
+		{"cloud-init.log", file("/var/log/cloud-init.log")},
+		{"cloud-init-output.log", file("/var/log/cloud-init-output.log")},
+		{"nodeadm.log", journal("nodeadm-config", "nodeadm-run")}, // AL2023 bootstrap
+		{"kubelet.log", journal("kubelet")},
+		{"containerd.log", journal("containerd")},
+		{"kubelet-config.json", "cat /etc/kubernetes/kubelet/config.json /etc/kubernetes/kubelet/config.json.d/* 2>/dev/null"},
+	}
+}
+
+// CollectBootstrapLogs pulls cloud-init, nodeadm, kubelet and containerd logs
+// from a node with SSM, plus the EC2 console output which is available even
+// when SSM is not, and writes them to a .tar.gz bundle. The node may be given
+// by Kubernetes node name, EC2 private DNS name or instance ID, so nodes that
+// never joined the cluster can be diagnosed too.
+func CollectBootstrapLogs(node string, options BootstrapLogsOptions) error {
+	if options.Lines <= 0 {
+		return fmt.Errorf("--lines must be greater than zero")
+	}
+	instanceID, region, err := resolveNodeInstance(node, options.Region, options.Profile)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Collecting bootstrap logs from %s (%s, %s)...\n", node, instanceID, region)
+
+	sess, err := NewSession(options.Profile, region)
+	if err != nil {
+		return err
+	}
+	files := make(map[string][]byte)
+	var notes []string
+
+	console, err := ec2.New(sess).GetConsoleOutput(&ec2.GetConsoleOutputInput{
+		InstanceId: aws.String(instanceID),
+		Latest:     aws.Bool(true),
+	})
+	if err != nil {
+		notes = append(notes, fmt.Sprintf("console output: %v", err))
+	} else if decoded, err := base64.StdEncoding.DecodeString(aws.StringValue(console.Output)); err == nil && len(decoded) > 0 {
+		files["console-output.log"] = decoded
+		fmt.Println("✅ console-output.log")
+	} else {
+		notes = append(notes, "console output: empty (it can take a few minutes after boot to appear)")
+	}
+
+	ssmSvc := ssm.New(sess)
+	online, err := ssmOnline(ssmSvc, instanceID)
+	switch {
+	case err != nil:
+		notes = append(notes, fmt.Sprintf("SSM: %v", err))
+	case !online:
+		notes = append(notes, "SSM: the agent is not online, only the console output was collected")
+	default:
+		for name, content := range collectSSMLogs(ssmSvc, instanceID, options, &notes) {
+			files[name] = content
+		}
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("nothing could be collected from %s:\n  %s", instanceID, strings.Join(notes, "\n  "))
+	}
+	if len(notes) > 0 {
+		files["NOTES.txt"] = []byte(strings.Join(notes, "\n") + "\n")
+	}
+
+	path, err := writeLogBundle(options.OutputDir, node, files)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\n--- Bootstrap Logs Summary ---")
+	fmt.Printf("Files collected: %d\n", len(files))
+	for _, note := range notes {
+		fmt.Printf("⚠️  %s\n", note)
+	}
+	fmt.Printf("Bundle: %s\n", path)
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// resolveNodeInstance finds the instance behind a node. Registered nodes are
+// looked up in Kubernetes; otherwise the argument is taken as an instance ID
+// or an EC2 private DNS name, whose region can be read from the name itself.
+func resolveNodeInstance(node, region, profile string) (string, string, error) {
+	if clientset, err := common.GetKubernetesClient(); err == nil {
+		k8sNode, err := clientset.CoreV1().Nodes().Get(context.TODO(), node, v1.GetOptions{})
+		if err == nil {
+			instanceID := extractInstanceIDFromProviderID(k8sNode.Spec.ProviderID)
+			nodeRegion := extractRegionFromProviderID(k8sNode.Spec.ProviderID)
+			if instanceID == "" {
+				return "", "", fmt.Errorf("node %s is not an EC2 instance", node)
+			}
+			return instanceID, nodeRegion, nil
+		}
+		if !apierrors.IsNotFound(err) {
+			return "", "", fmt.Errorf("failed to get node %s: %w", node, err)
+		}
+	}
+
+	if region == "" {
+		region = regionFromPrivateDNS(node)
+	}
+	if region == "" {
+		return "", "", fmt.Errorf("node %s is not registered with the cluster; pass --region", node)
+	}
+	if strings.HasPrefix(node, "i-") {
+		return node, region, nil
+	}
+
+	sess, err := NewSession(profile, region)
+	if err != nil {
+		return "", "", err
+	}
+	output, err := ec2.New(sess).DescribeInstances(&ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{{Name: aws.String("private-dns-name"), Values: aws.StringSlice([]string{node})}},
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to look up instance %s: %w", node, err)
+	}
+	for _, reservation := range output.Reservations {
+		for _, instance := range reservation.Instances {
+			return aws.StringValue(instance.InstanceId), region, nil
+		}
+	}
+	return "", "", fmt.Errorf("no node or instance named %s in %s", node, region)
+}
+
+// regionFromPrivateDNS reads the region from names like
+// ip-10-0-1-2.us-west-2.compute.internal; us-east-1 uses ec2.internal.
+func regionFromPrivateDNS(name string) string {
+	if strings.HasSuffix(name, ".ec2.internal") {
+		return "us-east-1"
+	}
+	parts := strings.Split(name, ".")
+	if len(parts) == 4 && strings.HasSuffix(name, ".compute.internal") {
+		return parts[1]
+	}
+	return ""
+}
+
+// ssmOnline reports whether the SSM agent on the instance is connected.
+func ssmOnline(ssmSvc *ssm.SSM, instanceID string) (bool, error) {
+	output, err := ssmSvc.DescribeInstanceInformation(&ssm.DescribeInstanceInformationInput{
+		Filters: []*ssm.InstanceInformationStringFilter{{
+			Key:    aws.String("InstanceIds"),
+			Values: aws.StringSlice([]string{instanceID}),
+		}},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to get SSM status: %w", err)
+	}
+	for _, info := range output.InstanceInformationList {
+		if aws.StringValue(info.PingStatus) == ssm.PingStatusOnline {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// collectSSMLogs runs one command per log and returns the decoded output of
+// those that succeeded. Problems are added to notes rather than failing, so
+// one missing log doesn't lose the others.
+func collectSSMLogs(ssmSvc *ssm.SSM, instanceID string, options BootstrapLogsOptions, notes *[]string) map[string][]byte {
+	files := make(map[string][]byte)
+	commandIDs := make(map[string]string)
+	for _, source := range bootstrapLogSources(options.Lines) {
+		output, err := ssmSvc.SendCommand(&ssm.SendCommandInput{
+			DocumentName: aws.String("AWS-RunShellScript"),
+			InstanceIds:  aws.StringSlice([]string{instanceID}),
+			Parameters: map[string][]*string{
+				"commands": aws.StringSlice([]string{"(" + source.command + ") 2>&1 | gzip -c | base64 -w0"}),
+			},
+			Comment: aws.String("swissarmycli node bootstrap-logs"),
+		})
+		if err != nil {
+			*notes = append(*notes, fmt.Sprintf("%s: failed to send SSM command: %v", source.file, err))
+			continue
+		}
+		commandIDs[source.file] = aws.StringValue(output.Command.CommandId)
+	}
+
+	deadline := time.Now().Add(options.Timeout)
+	for _, source := range bootstrapLogSources(options.Lines) {
+		file := source.file
+		commandID, ok := commandIDs[file]
+		if !ok {
+			continue
+		}
+		invocation, err := waitForInvocation(ssmSvc, commandID, instanceID, time.Until(deadline))
+		if err != nil {
+			*notes = append(*notes, fmt.Sprintf("%s: %v", file, err))
+			continue
+		}
+		if status := aws.StringValue(invocation.Status); status != ssm.CommandInvocationStatusSuccess {
+			*notes = append(*notes, fmt.Sprintf("%s: command %s", file, status))
+			continue
+		}
+		content, err := decodeLogOutput(aws.StringValue(invocation.StandardOutputContent))
+		if err != nil {
+			*notes = append(*notes, fmt.Sprintf("%s: %v, lower --lines to fit SSM's output limit", file, err))
+			continue
+		}
+		files[file] = content
+		fmt.Printf("✅ %s\n", file)
+	}
+	return files
+}
+
+func decodeLogOutput(output string) ([]byte, error) {
+	compressed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(output))
+	if err != nil {
+		return nil, fmt.Errorf("output was truncated")
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("output was truncated")
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// writeLogBundle writes the files to <node>-bootstrap-<timestamp>.tar.gz
+func writeLogBundle(outputDir, node string, files map[string][]byte) (string, error) {
+	if outputDir == "" {
+		outputDir = "."
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory '%s': %w", outputDir, err)
+	}
+	name := fmt.Sprintf("%s-bootstrap-%s", node, time.Now().Format("20060102-150405"))
+	path := filepath.Join(outputDir, name+".tar.gz")
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create bundle '%s': %w", path, err)
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	archive := tar.NewWriter(gz)
+
+	for _, fileName := range sortedKeys(files) {
+		content := files[fileName]
+		header := &tar.Header{
+			Name:    name + "/" + fileName,
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: time.Now(),
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return "", fmt.Errorf("failed to write bundle: %w", err)
+		}
+		if _, err := archive.Write(content); err != nil {
+			return "", fmt.Errorf("failed to write bundle: %w", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return "", fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return "", fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return path, nil
+}
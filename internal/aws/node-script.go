@@ -0,0 +1,99 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// ssmInstancesPerCommand is the most instances one SendCommand call targets
+const ssmInstancesPerCommand = 50
+
+// ScriptResult is what a shell script printed on one instance, or why it
+// could not be run there.
+type ScriptResult struct {
+	Output string
+	Err    error
+}
+
+// RunShellScript runs the commands with AWS-RunShellScript on every
+// instance and waits for them to finish, returning the result per instance
+// ID. An instance that fails or times out gets an error in its result
+// instead of failing the others.
+func RunShellScript(sess *session.Session, instanceIDs []string, commands []string, comment string, timeout time.Duration) map[string]ScriptResult {
+	client := ssm.New(sess)
+	results := make(map[string]ScriptResult)
+	commandIDs := make(map[string]string)
+	for start := 0; start < len(instanceIDs); start += ssmInstancesPerCommand {
+		chunk := instanceIDs[start:min(start+ssmInstancesPerCommand, len(instanceIDs))]
+		output, err := client.SendCommand(&ssm.SendCommandInput{
+			DocumentName:   aws.String("AWS-RunShellScript"),
+			InstanceIds:    aws.StringSlice(chunk),
+			Parameters:     map[string][]*string{"commands": aws.StringSlice(commands)},
+			Comment:        aws.String(comment),
+			TimeoutSeconds: aws.Int64(int64(max(timeout.Seconds(), 30))),
+		})
+		for _, instanceID := range chunk {
+			if err != nil {
+				results[instanceID] = ScriptResult{Err: fmt.Errorf("failed to send SSM command: %w", err)}
+			} else {
+				commandIDs[instanceID] = aws.StringValue(output.Command.CommandId)
+			}
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for _, instanceID := range instanceIDs {
+		commandID, ok := commandIDs[instanceID]
+		if !ok {
+			continue
+		}
+		invocation, err := waitForInvocation(client, commandID, instanceID, time.Until(deadline))
+		if err != nil {
+			results[instanceID] = ScriptResult{Err: err}
+			continue
+		}
+		if status := aws.StringValue(invocation.Status); status != ssm.CommandInvocationStatusSuccess {
+			err := fmt.Errorf("command %s", status)
+			if stderr := strings.TrimSpace(aws.StringValue(invocation.StandardErrorContent)); stderr != "" {
+				err = fmt.Errorf("command %s: %s", status, stderr)
+			}
+			results[instanceID] = ScriptResult{Err: err}
+			continue
+		}
+		results[instanceID] = ScriptResult{Output: aws.StringValue(invocation.StandardOutputContent)}
+	}
+	return results
+}
+
+// waitForInvocation polls the invocation of an SSM command on an instance
+// until it reaches a final state and returns it, whether it succeeded or
+// not. It fails when the invocation is still running after timeout. The
+// commands of several instances run at the same time, so callers waiting
+// for each in turn share one deadline.
+func waitForInvocation(ssmClient *ssm.SSM, commandID, instanceID string, timeout time.Duration) (*ssm.GetCommandInvocationOutput, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		invocation, err := ssmClient.GetCommandInvocation(&ssm.GetCommandInvocationInput{
+			CommandId:  aws.String(commandID),
+			InstanceId: aws.String(instanceID),
+		})
+		// The invocation may not be registered yet right after SendCommand
+		if err == nil {
+			switch aws.StringValue(invocation.Status) {
+			case ssm.CommandInvocationStatusPending, ssm.CommandInvocationStatusInProgress,
+				ssm.CommandInvocationStatusDelayed, ssm.CommandInvocationStatusCancelling:
+			default:
+				return invocation, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for SSM")
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
@@ -0,0 +1,166 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// ssmInstanceIDsPerCall is the most instance IDs the SSM describe calls take
+// at once
+const ssmInstanceIDsPerCall = 40
+
+// SSMInstanceStatus is what SSM inventory knows about a managed instance
+type SSMInstanceStatus struct {
+	PingStatus      string // Online, ConnectionLost or Inactive
+	LastPing        time.Time
+	AgentVersion    string
+	AgentLatest     bool
+	PlatformName    string
+	PlatformVersion string
+}
+
+// InstancePatchState is the outcome of the last Patch Manager scan or
+// install on an instance
+type InstancePatchState struct {
+	BaselineID             string
+	Operation              string // Scan or Install
+	OperationEnd           time.Time
+	Installed              int64
+	Missing                int64
+	Failed                 int64
+	InstalledPendingReboot int64
+	CriticalNonCompliant   int64
+	SecurityNonCompliant   int64
+	OtherNonCompliant      int64
+}
+
+// Compliant reports whether the instance has no missing or failed patches
+// and no installed patches waiting for a reboot.
+func (s InstancePatchState) Compliant() bool {
+	return s.Missing == 0 && s.Failed == 0 && s.InstalledPendingReboot == 0 &&
+		s.CriticalNonCompliant == 0 && s.SecurityNonCompliant == 0 && s.OtherNonCompliant == 0
+}
+
+// InstanceImage is the AMI an instance was launched from
+type InstanceImage struct {
+	ImageID string
+	Name    string    // Empty when the AMI is deregistered or not shared with the account
+	Created time.Time // Zero when the AMI is unknown
+}
+
+// GetSSMInstanceStatus returns the SSM agent status of the managed
+// instances among instanceIDs. Instances SSM doesn't manage are missing
+// from the map.
+func GetSSMInstanceStatus(sess *session.Session, instanceIDs []string) (map[string]SSMInstanceStatus, error) {
+	client := ssm.New(sess)
+	statuses := make(map[string]SSMInstanceStatus)
+	for start := 0; start < len(instanceIDs); start += ssmInstanceIDsPerCall {
+		chunk := instanceIDs[start:min(start+ssmInstanceIDsPerCall, len(instanceIDs))]
+		input := &ssm.DescribeInstanceInformationInput{
+			Filters: []*ssm.InstanceInformationStringFilter{{
+				Key:    aws.String("InstanceIds"),
+				Values: aws.StringSlice(chunk),
+			}},
+		}
+		err := client.DescribeInstanceInformationPages(input, func(page *ssm.DescribeInstanceInformationOutput, lastPage bool) bool {
+			for _, info := range page.InstanceInformationList {
+				statuses[aws.StringValue(info.InstanceId)] = SSMInstanceStatus{
+					PingStatus:      aws.StringValue(info.PingStatus),
+					LastPing:        aws.TimeValue(info.LastPingDateTime),
+					AgentVersion:    aws.StringValue(info.AgentVersion),
+					AgentLatest:     aws.BoolValue(info.IsLatestVersion),
+					PlatformName:    aws.StringValue(info.PlatformName),
+					PlatformVersion: aws.StringValue(info.PlatformVersion),
+				}
+			}
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get SSM status: %w", err)
+		}
+	}
+	return statuses, nil
+}
+
+// GetInstancePatchStates returns the Patch Manager state of the instances
+// that have been scanned at least once.
+func GetInstancePatchStates(sess *session.Session, instanceIDs []string) (map[string]InstancePatchState, error) {
+	client := ssm.New(sess)
+	states := make(map[string]InstancePatchState)
+	for start := 0; start < len(instanceIDs); start += ssmInstanceIDsPerCall {
+		chunk := instanceIDs[start:min(start+ssmInstanceIDsPerCall, len(instanceIDs))]
+		input := &ssm.DescribeInstancePatchStatesInput{InstanceIds: aws.StringSlice(chunk)}
+		err := client.DescribeInstancePatchStatesPages(input, func(page *ssm.DescribeInstancePatchStatesOutput, lastPage bool) bool {
+			for _, state := range page.InstancePatchStates {
+				states[aws.StringValue(state.InstanceId)] = InstancePatchState{
+					BaselineID:             aws.StringValue(state.BaselineId),
+					Operation:              aws.StringValue(state.Operation),
+					OperationEnd:           aws.TimeValue(state.OperationEndTime),
+					Installed:              aws.Int64Value(state.InstalledCount),
+					Missing:                aws.Int64Value(state.MissingCount),
+					Failed:                 aws.Int64Value(state.FailedCount),
+					InstalledPendingReboot: aws.Int64Value(state.InstalledPendingRebootCount),
+					CriticalNonCompliant:   aws.Int64Value(state.CriticalNonCompliantCount),
+					SecurityNonCompliant:   aws.Int64Value(state.SecurityNonCompliantCount),
+					OtherNonCompliant:      aws.Int64Value(state.OtherNonCompliantCount),
+				}
+			}
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get patch states: %w", err)
+		}
+	}
+	return states, nil
+}
+
+// GetInstanceImages returns the AMI each instance runs, with the AMI's name
+// and creation date when it can still be described.
+func GetInstanceImages(sess *session.Session, instanceIDs []string) (map[string]InstanceImage, error) {
+	client := ec2.New(sess)
+	images := make(map[string]InstanceImage)
+	imageIDs := make(map[string]bool)
+	input := &ec2.DescribeInstancesInput{InstanceIds: aws.StringSlice(instanceIDs)}
+	err := client.DescribeInstancesPages(input, func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				imageID := aws.StringValue(instance.ImageId)
+				images[aws.StringValue(instance.InstanceId)] = InstanceImage{ImageID: imageID}
+				imageIDs[imageID] = true
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instances: %w", err)
+	}
+
+	details := make(map[string]InstanceImage)
+	// A filter instead of ImageIds, which fails the whole call when one of the
+	// AMIs has been deregistered since
+	output, err := client.DescribeImages(&ec2.DescribeImagesInput{
+		Filters: []*ec2.Filter{{Name: aws.String("image-id"), Values: aws.StringSlice(sortedKeys(imageIDs))}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe images: %w", err)
+	}
+	for _, image := range output.Images {
+		created, _ := time.Parse(time.RFC3339, aws.StringValue(image.CreationDate))
+		details[aws.StringValue(image.ImageId)] = InstanceImage{
+			ImageID: aws.StringValue(image.ImageId),
+			Name:    aws.StringValue(image.Name),
+			Created: created,
+		}
+	}
+	for instanceID, image := range images {
+		if detail, ok := details[image.ImageID]; ok {
+			images[instanceID] = detail
+		}
+	}
+	return images, nil
+}
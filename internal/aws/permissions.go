@@ -0,0 +1,72 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+// CallerIdentity is who a session's credentials belong to
+type CallerIdentity struct {
+	Account string
+	Arn     string // As STS reports it, e.g. an assumed-role session
+}
+
+// GetCallerIdentity returns who the session's credentials belong to, which
+// also proves they are valid.
+func GetCallerIdentity(sess *session.Session) (CallerIdentity, error) {
+	output, err := sts.New(sess).GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return CallerIdentity{}, err
+	}
+	return CallerIdentity{Account: aws.StringValue(output.Account), Arn: aws.StringValue(output.Arn)}, nil
+}
+
+// PrincipalARN turns the ARN of an assumed-role session into the ARN of
+// its IAM role, with the role's path, which the policy simulator needs.
+// User ARNs are returned unchanged.
+func PrincipalARN(sess *session.Session, callerArn string) (string, error) {
+	parts := strings.SplitN(callerArn, ":", 6)
+	if len(parts) != 6 || !strings.HasPrefix(parts[5], "assumed-role/") {
+		return callerArn, nil
+	}
+	roleName := strings.Split(strings.TrimPrefix(parts[5], "assumed-role/"), "/")[0]
+	role, err := iam.New(sess).GetRole(&iam.GetRoleInput{RoleName: aws.String(roleName)})
+	if err != nil {
+		// Without iam:GetRole, guess a role without a path
+		return fmt.Sprintf("arn:%s:iam::%s:role/%s", parts[1], parts[4], roleName), nil
+	}
+	return aws.StringValue(role.Role.Arn), nil
+}
+
+// SimulatePermissions runs the IAM policy simulator for the principal and
+// returns the actions its policies don't allow, sorted as given.
+func SimulatePermissions(sess *session.Session, principalArn string, actions []string) ([]string, error) {
+	allowed := make(map[string]bool)
+	input := &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String(principalArn),
+		ActionNames:     aws.StringSlice(actions),
+	}
+	err := iam.New(sess).SimulatePrincipalPolicyPages(input, func(page *iam.SimulatePolicyResponse, lastPage bool) bool {
+		for _, result := range page.EvaluationResults {
+			if aws.StringValue(result.EvalDecision) == iam.PolicyEvaluationDecisionTypeAllowed {
+				allowed[aws.StringValue(result.EvalActionName)] = true
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate the policies of %s: %w", principalArn, err)
+	}
+	var denied []string
+	for _, action := range actions {
+		if !allowed[action] {
+			denied = append(denied, action)
+		}
+	}
+	return denied, nil
+}
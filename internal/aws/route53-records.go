@@ -0,0 +1,80 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// HostedZone is a Route53 hosted zone
+type HostedZone struct {
+	ID      string // Without the /hostedzone/ prefix
+	Name    string // Without the trailing dot
+	Private bool
+}
+
+// DNSRecord is one record set of a hosted zone
+type DNSRecord struct {
+	Name          string // Without the trailing dot, lower case
+	Type          string
+	SetIdentifier string
+	Values        []string // Record values, TXT values without quotes
+	AliasTarget   string   // DNS name of the alias target, without the trailing dot
+}
+
+// ListHostedZones returns every hosted zone of the account. Route53 is
+// global, so the session's region doesn't matter.
+func ListHostedZones(sess *session.Session) ([]HostedZone, error) {
+	var zones []HostedZone
+	err := route53.New(sess).ListHostedZonesPages(&route53.ListHostedZonesInput{}, func(page *route53.ListHostedZonesOutput, lastPage bool) bool {
+		for _, zone := range page.HostedZones {
+			zones = append(zones, HostedZone{
+				ID:      strings.TrimPrefix(aws.StringValue(zone.Id), "/hostedzone/"),
+				Name:    strings.TrimSuffix(aws.StringValue(zone.Name), "."),
+				Private: zone.Config != nil && aws.BoolValue(zone.Config.PrivateZone),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hosted zones: %w", err)
+	}
+	return zones, nil
+}
+
+// ListZoneRecords returns every record set of a hosted zone.
+func ListZoneRecords(sess *session.Session, zoneID string) ([]DNSRecord, error) {
+	var records []DNSRecord
+	input := &route53.ListResourceRecordSetsInput{HostedZoneId: aws.String(zoneID)}
+	err := route53.New(sess).ListResourceRecordSetsPages(input, func(page *route53.ListResourceRecordSetsOutput, lastPage bool) bool {
+		for _, set := range page.ResourceRecordSets {
+			record := DNSRecord{
+				Name:          normalizeRecordName(aws.StringValue(set.Name)),
+				Type:          aws.StringValue(set.Type),
+				SetIdentifier: aws.StringValue(set.SetIdentifier),
+			}
+			for _, value := range set.ResourceRecords {
+				record.Values = append(record.Values, strings.Trim(aws.StringValue(value.Value), `"`))
+			}
+			if set.AliasTarget != nil {
+				record.AliasTarget = strings.TrimSuffix(strings.ToLower(aws.StringValue(set.AliasTarget.DNSName)), ".")
+			}
+			records = append(records, record)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list records of hosted zone %s: %w", zoneID, err)
+	}
+	return records, nil
+}
+
+// normalizeRecordName lower-cases a record name, drops the trailing dot and
+// decodes the \052 escape Route53 returns for wildcards.
+func normalizeRecordName(name string) string {
+	name = strings.ReplaceAll(name, `\052`, "*")
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
@@ -0,0 +1,54 @@
+package aws
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// UploadFileToS3 uploads a local file to the given bucket, encrypted with
+// SSE-S3. The object key is built from the prefix (if any) and the file's base
+// name. The bucket's own region is looked up, so region is only a hint.
+func UploadFileToS3(profile, region, bucket, prefix, filePath string) (string, error) {
+	sess, err := NewSession(profile, region)
+	if err != nil {
+		return "", err
+	}
+	hint := aws.StringValue(sess.Config.Region)
+	if hint == "" {
+		hint = "us-east-1"
+	}
+	bucketRegion, err := s3manager.GetBucketRegion(aws.BackgroundContext(), sess, bucket, hint)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the region of bucket %s: %w", bucket, err)
+	}
+	sess.Config.Region = aws.String(bucketRegion)
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file '%s': %w", filePath, err)
+	}
+	defer file.Close()
+
+	key := filepath.Base(filePath)
+	if prefix != "" {
+		key = strings.TrimSuffix(prefix, "/") + "/" + key
+	}
+
+	_, err = s3.New(sess).PutObject(&s3.PutObjectInput{
+		Bucket:               aws.String(bucket),
+		Key:                  aws.String(key),
+		Body:                 file,
+		ServerSideEncryption: aws.String(s3.ServerSideEncryptionAes256),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload '%s' to s3://%s/%s: %w", filePath, bucket, key, err)
+	}
+
+	return fmt.Sprintf("s3://%s/%s", bucket, key), nil
+}
@@ -0,0 +1,74 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// InstalledApplication is a package SSM Inventory recorded on an instance
+type InstalledApplication struct {
+	Name         string
+	Version      string // Version and release, e.g. 1.7.11-1.amzn2023.0.1
+	Architecture string
+}
+
+// InstanceApplications is the result of the inventory lookup of one instance
+type InstanceApplications struct {
+	CaptureTime  string // When the inventory was last collected, empty when it never was
+	Applications []InstalledApplication
+}
+
+// GetInstalledApplications returns the packages of the AWS:Application
+// inventory whose name starts with prefix, per instance ID. Instances that
+// SSM doesn't manage, or whose inventory was never collected, have an empty
+// CaptureTime. Inventory has to be turned on, usually with a State Manager
+// association of AWS-GatherSoftwareInventory.
+func GetInstalledApplications(sess *session.Session, instanceIDs []string, prefix string) (map[string]InstanceApplications, error) {
+	client := ssm.New(sess)
+	inventories := make(map[string]InstanceApplications)
+	for _, instanceID := range instanceIDs {
+		inventory := InstanceApplications{}
+		input := &ssm.ListInventoryEntriesInput{
+			InstanceId: aws.String(instanceID),
+			TypeName:   aws.String("AWS:Application"),
+			Filters: []*ssm.InventoryFilter{{
+				Key:    aws.String("Name"),
+				Values: aws.StringSlice([]string{prefix}),
+				Type:   aws.String(ssm.InventoryQueryOperatorTypeBeginWith),
+			}},
+		}
+		for {
+			page, err := client.ListInventoryEntries(input)
+			if err != nil {
+				var aerr awserr.Error
+				if errors.As(err, &aerr) && aerr.Code() == ssm.ErrCodeInvalidInstanceId {
+					break
+				}
+				return nil, fmt.Errorf("failed to list the inventory of %s: %w", instanceID, err)
+			}
+			inventory.CaptureTime = aws.StringValue(page.CaptureTime)
+			for _, entry := range page.Entries {
+				version := aws.StringValue(entry["Version"])
+				if release := aws.StringValue(entry["Release"]); release != "" {
+					version += "-" + release
+				}
+				inventory.Applications = append(inventory.Applications, InstalledApplication{
+					Name:         aws.StringValue(entry["Name"]),
+					Version:      version,
+					Architecture: aws.StringValue(entry["Architecture"]),
+				})
+			}
+			if aws.StringValue(page.NextToken) == "" {
+				break
+			}
+			input.NextToken = page.NextToken
+		}
+		inventories[instanceID] = inventory
+	}
+	return inventories, nil
+}
@@ -0,0 +1,264 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/config"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// builtinPresets are available without a config file; presets with the same
+// name in the config file take precedence.
+var builtinPresets = map[string]config.Preset{
+	"collect-sos": {
+		Description: "Collect an sosreport-style bundle of node logs into /tmp",
+		Commands: []string{
+			"set -e",
+			"OUT=/tmp/node-logs-$(hostname)-$(date +%Y%m%d%H%M%S)",
+			"mkdir -p $OUT",
+			"journalctl -u kubelet --no-pager -n 2000 > $OUT/kubelet.log || true",
+			"journalctl -u containerd --no-pager -n 2000 > $OUT/containerd.log || true",
+			"dmesg > $OUT/dmesg.log || true",
+			"cp -r /var/log/aws-routed-eni $OUT/ 2>/dev/null || true",
+			"tar czf $OUT.tar.gz -C /tmp $(basename $OUT)",
+			"echo Collected $OUT.tar.gz",
+		},
+	},
+	"restart-kubelet": {
+		Description: "Restart the kubelet service",
+		Commands:    []string{"systemctl restart kubelet", "systemctl is-active kubelet"},
+	},
+	"rotate-docker": {
+		Description: "Restart the container runtime and prune unused images",
+		Commands: []string{
+			"systemctl restart containerd 2>/dev/null || systemctl restart docker",
+			"crictl rmi --prune 2>/dev/null || docker image prune -af",
+		},
+	},
+}
+
+// PresetOptions contains options for running an SSM preset
+type PresetOptions struct {
+	Selector string // Kubernetes label selector for target nodes
+	Profile  string
+	Timeout  time.Duration
+}
+
+type presetTarget struct {
+	nodeName   string
+	instanceID string
+	region     string
+}
+
+// ListPresets prints the built-in and configured presets.
+func ListPresets() error {
+	presets, err := loadPresets()
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("  %-20s %s\n", name, presets[name].Description)
+	}
+	return nil
+}
+
+// RunPreset executes the named SSM preset on every node matching the label
+// selector and prints each node's output as it finishes.
+func RunPreset(presetName string, options PresetOptions) error {
+	presets, err := loadPresets()
+	if err != nil {
+		return err
+	}
+	preset, ok := presets[presetName]
+	if !ok {
+		return fmt.Errorf("unknown preset '%s' (see --list for available presets)", presetName)
+	}
+
+	targets, err := findPresetTargets(options.Selector)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no nodes match selector '%s'", options.Selector)
+	}
+
+	document := preset.Document
+	if document == "" {
+		document = "AWS-RunShellScript"
+	}
+	parameters := make(map[string][]*string)
+	for key, values := range preset.Parameters {
+		parameters[key] = aws.StringSlice(values)
+	}
+	if len(preset.Commands) > 0 {
+		parameters["commands"] = aws.StringSlice(preset.Commands)
+	}
+
+	fmt.Printf("Running preset '%s' (%s) on %d node(s)...\n", presetName, document, len(targets))
+
+	// SSM commands are regional, so send one command per region
+	byRegion := make(map[string][]presetTarget)
+	for _, target := range targets {
+		byRegion[target.region] = append(byRegion[target.region], target)
+	}
+
+	var succeeded, failed []string
+	for region, regionTargets := range byRegion {
+		sess, err := NewSession(options.Profile, region)
+		if err != nil {
+			return err
+		}
+		ssmSvc := ssm.New(sess)
+
+		commandIDs, err := sendPresetCommand(ssmSvc, document, parameters, preset.TimeoutSeconds, regionTargets)
+		if err != nil {
+			return err
+		}
+
+		ok, notOk := waitForPresetResults(ssmSvc, commandIDs, regionTargets, options.Timeout)
+		succeeded = append(succeeded, ok...)
+		failed = append(failed, notOk...)
+	}
+
+	fmt.Println("\n--- Preset Summary ---")
+	fmt.Printf("✅ Succeeded: %d\n", len(succeeded))
+	fmt.Printf("❌ Failed: %d\n", len(failed))
+	for _, nodeName := range failed {
+		fmt.Printf("  - %s\n", nodeName)
+	}
+	fmt.Println("----------------------------------------------------")
+
+	if len(failed) > 0 {
+		return fmt.Errorf("preset failed on %d of %d nodes", len(failed), len(targets))
+	}
+	return nil
+}
+
+func loadPresets() (map[string]config.Preset, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	configured, err := cfg.Presets()
+	if err != nil {
+		return nil, err
+	}
+
+	presets := make(map[string]config.Preset)
+	for name, preset := range builtinPresets {
+		presets[name] = preset
+	}
+	for name, preset := range configured {
+		presets[name] = preset
+	}
+	return presets, nil
+}
+
+func findPresetTargets(selector string) ([]presetTarget, error) {
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), v1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	var targets []presetTarget
+	for _, node := range nodes.Items {
+		instanceID := extractInstanceIDFromProviderID(node.Spec.ProviderID)
+		region := extractRegionFromProviderID(node.Spec.ProviderID)
+		if instanceID == "" || region == "" {
+			fmt.Fprintf(os.Stderr, "Warning: skipping node %s, not an EC2 instance\n", node.Name)
+			continue
+		}
+		targets = append(targets, presetTarget{nodeName: node.Name, instanceID: instanceID, region: region})
+	}
+	return targets, nil
+}
+
+// sendPresetCommand sends the document to the targets in batches of 50, the
+// SendCommand limit, and returns the command ID used for each instance.
+func sendPresetCommand(ssmSvc *ssm.SSM, document string, parameters map[string][]*string, timeoutSeconds int64, targets []presetTarget) (map[string]string, error) {
+	const batchSize = 50
+	commandIDs := make(map[string]string)
+
+	for start := 0; start < len(targets); start += batchSize {
+		end := start + batchSize
+		if end > len(targets) {
+			end = len(targets)
+		}
+
+		var instanceIDs []*string
+		for _, target := range targets[start:end] {
+			instanceIDs = append(instanceIDs, aws.String(target.instanceID))
+		}
+
+		input := &ssm.SendCommandInput{
+			DocumentName: aws.String(document),
+			InstanceIds:  instanceIDs,
+			Parameters:   parameters,
+			Comment:      aws.String("swissarmycli run-preset"),
+		}
+		if timeoutSeconds > 0 {
+			input.TimeoutSeconds = aws.Int64(timeoutSeconds)
+		}
+
+		output, err := ssmSvc.SendCommand(input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send SSM command: %w", err)
+		}
+		for _, target := range targets[start:end] {
+			commandIDs[target.instanceID] = aws.StringValue(output.Command.CommandId)
+		}
+	}
+	return commandIDs, nil
+}
+
+// waitForPresetResults waits for every invocation to reach a final state,
+// printing each node's output in turn.
+func waitForPresetResults(ssmSvc *ssm.SSM, commandIDs map[string]string, targets []presetTarget, timeout time.Duration) ([]string, []string) {
+	var succeeded, failed []string
+	deadline := time.Now().Add(timeout)
+	for _, target := range targets {
+		invocation, err := waitForInvocation(ssmSvc, commandIDs[target.instanceID], target.instanceID, time.Until(deadline))
+		if err != nil {
+			fmt.Printf("\n=== %s (%s): timed out waiting for result ===\n", target.nodeName, target.instanceID)
+			failed = append(failed, target.nodeName)
+			continue
+		}
+		status := aws.StringValue(invocation.Status)
+		printPresetOutput(target, status, invocation)
+		if status == ssm.CommandInvocationStatusSuccess {
+			succeeded = append(succeeded, target.nodeName)
+		} else {
+			failed = append(failed, target.nodeName)
+		}
+	}
+	return succeeded, failed
+}
+
+func printPresetOutput(target presetTarget, status string, invocation *ssm.GetCommandInvocationOutput) {
+	fmt.Printf("\n=== %s (%s): %s ===\n", target.nodeName, target.instanceID, status)
+	if stdout := strings.TrimSpace(aws.StringValue(invocation.StandardOutputContent)); stdout != "" {
+		fmt.Println(stdout)
+	}
+	if stderr := strings.TrimSpace(aws.StringValue(invocation.StandardErrorContent)); stderr != "" {
+		fmt.Fprintf(os.Stderr, "[stderr] %s\n", stderr)
+	}
+}
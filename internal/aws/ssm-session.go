@@ -0,0 +1,364 @@
+package aws
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/google/uuid"
+	"golang.org/x/net/websocket"
+	"golang.org/x/term"
+)
+
+// Session Manager data channel message types
+const (
+	ssmInputStreamData  = "input_stream_data"
+	ssmOutputStreamData = "output_stream_data"
+	ssmAcknowledge      = "acknowledge"
+	ssmChannelClosed    = "channel_closed"
+)
+
+// Session Manager payload types
+const (
+	ssmPayloadOutput            = 1
+	ssmPayloadSize              = 3
+	ssmPayloadHandshakeRequest  = 5
+	ssmPayloadHandshakeResponse = 6
+	ssmPayloadHandshakeComplete = 7
+	ssmPayloadStdErr            = 11
+)
+
+// ssmClientVersion is reported to the agent during the handshake
+const ssmClientVersion = "1.2.0.0"
+
+// Offsets of the binary message header fields. The header length field
+// holds the offset of the payload length.
+const (
+	ssmMessageTypeLength = 32
+	ssmHeaderLength      = 116
+	ssmPayloadOffset     = ssmHeaderLength + 4
+)
+
+// ssmMessage is one message of the Session Manager data channel
+type ssmMessage struct {
+	messageType    string
+	schemaVersion  uint32
+	createdDate    uint64 // Milliseconds since the epoch
+	sequenceNumber int64
+	flags          uint64
+	messageID      uuid.UUID
+	payloadType    uint32
+	payload        []byte
+}
+
+// marshal encodes the message in the data channel's binary format.
+func (m ssmMessage) marshal() []byte {
+	buf := make([]byte, ssmPayloadOffset+len(m.payload))
+	binary.BigEndian.PutUint32(buf[0:], ssmHeaderLength)
+	copy(buf[4:4+ssmMessageTypeLength], []byte(fmt.Sprintf("%-32s", m.messageType)))
+	binary.BigEndian.PutUint32(buf[36:], m.schemaVersion)
+	binary.BigEndian.PutUint64(buf[40:], m.createdDate)
+	binary.BigEndian.PutUint64(buf[48:], uint64(m.sequenceNumber))
+	binary.BigEndian.PutUint64(buf[56:], m.flags)
+	// The message ID is written least significant half first
+	copy(buf[64:72], m.messageID[8:16])
+	copy(buf[72:80], m.messageID[0:8])
+	digest := sha256.Sum256(m.payload)
+	copy(buf[80:112], digest[:])
+	binary.BigEndian.PutUint32(buf[112:], m.payloadType)
+	binary.BigEndian.PutUint32(buf[116:], uint32(len(m.payload)))
+	copy(buf[ssmPayloadOffset:], m.payload)
+	return buf
+}
+
+// parseSSMMessage decodes a binary data channel message.
+func parseSSMMessage(data []byte) (ssmMessage, error) {
+	if len(data) < ssmPayloadOffset {
+		return ssmMessage{}, fmt.Errorf("session message too short (%d bytes)", len(data))
+	}
+	var m ssmMessage
+	m.messageType = strings.TrimRight(string(data[4:4+ssmMessageTypeLength]), " \x00")
+	m.schemaVersion = binary.BigEndian.Uint32(data[36:])
+	m.createdDate = binary.BigEndian.Uint64(data[40:])
+	m.sequenceNumber = int64(binary.BigEndian.Uint64(data[48:]))
+	m.flags = binary.BigEndian.Uint64(data[56:])
+	copy(m.messageID[8:16], data[64:72])
+	copy(m.messageID[0:8], data[72:80])
+	m.payloadType = binary.BigEndian.Uint32(data[112:])
+	length := int(binary.BigEndian.Uint32(data[116:]))
+	if len(data) < ssmPayloadOffset+length {
+		return ssmMessage{}, fmt.Errorf("session message truncated")
+	}
+	m.payload = data[ssmPayloadOffset : ssmPayloadOffset+length]
+	return m, nil
+}
+
+// ssmDataChannel is an interactive shell session spoken directly over the
+// Session Manager WebSocket, without session-manager-plugin.
+type ssmDataChannel struct {
+	ws       *websocket.Conn
+	writeMu  sync.Mutex
+	sequence int64 // Next input sequence number
+
+	expected int64                // Next output sequence number to print
+	pending  map[int64]ssmMessage // Output received ahead of expected
+	ready    chan struct{}        // Closed when the handshake completes
+	once     sync.Once
+}
+
+// startNativeSession opens an interactive shell on the instance using the
+// built-in Session Manager client. It supports the standard shell session
+// only; sessions requiring KMS encryption need session-manager-plugin.
+func startNativeSession(sess *session.Session, instanceID string) error {
+	svc := ssm.New(sess)
+	started, err := svc.StartSession(&ssm.StartSessionInput{Target: aws.String(instanceID)})
+	if err != nil {
+		return fmt.Errorf("failed to start SSM session: %w", err)
+	}
+	defer svc.TerminateSession(&ssm.TerminateSessionInput{SessionId: started.SessionId})
+
+	streamURL := aws.StringValue(started.StreamUrl)
+	config, err := websocket.NewConfig(streamURL, strings.Replace(streamURL, "wss://", "https://", 1))
+	if err != nil {
+		return fmt.Errorf("invalid session stream URL: %w", err)
+	}
+	ws, err := websocket.DialConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to open session stream: %w", err)
+	}
+	defer ws.Close()
+
+	channel := &ssmDataChannel{
+		ws:      ws,
+		pending: make(map[int64]ssmMessage),
+		ready:   make(chan struct{}),
+	}
+	open, err := json.Marshal(map[string]string{
+		"MessageSchemaVersion": "1.0",
+		"RequestId":            uuid.New().String(),
+		"TokenValue":           aws.StringValue(started.TokenValue),
+		"ClientId":             uuid.New().String(),
+		"ClientVersion":        ssmClientVersion,
+	})
+	if err != nil {
+		return err
+	}
+	if err := websocket.Message.Send(ws, string(open)); err != nil {
+		return fmt.Errorf("failed to open data channel: %w", err)
+	}
+
+	// Raw mode passes every key, including Ctrl+C, to the remote shell
+	stdin := int(os.Stdin.Fd())
+	if term.IsTerminal(stdin) {
+		state, err := term.MakeRaw(stdin)
+		if err != nil {
+			return fmt.Errorf("failed to set terminal to raw mode: %w", err)
+		}
+		defer term.Restore(stdin, state)
+	}
+
+	go channel.forwardInput(os.Stdin)
+	go channel.forwardTerminalSize(stdin)
+	return channel.receive()
+}
+
+// receive processes messages from the agent until the channel closes.
+func (c *ssmDataChannel) receive() error {
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(c.ws, &data); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("session stream failed: %w", err)
+		}
+		message, err := parseSSMMessage(data)
+		if err != nil {
+			return err
+		}
+
+		switch message.messageType {
+		case ssmOutputStreamData:
+			if err := c.acknowledge(message); err != nil {
+				return err
+			}
+			// Messages can arrive out of order; print them in sequence
+			if message.sequenceNumber < c.expected {
+				continue
+			}
+			c.pending[message.sequenceNumber] = message
+			for {
+				next, ok := c.pending[c.expected]
+				if !ok {
+					break
+				}
+				delete(c.pending, c.expected)
+				c.expected++
+				if err := c.handleOutput(next); err != nil {
+					return err
+				}
+			}
+		case ssmChannelClosed:
+			var closed struct {
+				Output string `json:"Output"`
+			}
+			if json.Unmarshal(message.payload, &closed) == nil && closed.Output != "" {
+				fmt.Fprintf(os.Stderr, "\r\n%s\r\n", closed.Output)
+			}
+			return nil
+		}
+	}
+}
+
+func (c *ssmDataChannel) handleOutput(message ssmMessage) error {
+	switch message.payloadType {
+	case ssmPayloadOutput:
+		os.Stdout.Write(message.payload)
+	case ssmPayloadStdErr:
+		os.Stderr.Write(message.payload)
+	case ssmPayloadHandshakeRequest:
+		return c.handshake(message.payload)
+	case ssmPayloadHandshakeComplete:
+		c.once.Do(func() { close(c.ready) })
+	}
+	return nil
+}
+
+// handshake answers the agent's requested client actions. Only the session
+// type is supported; KMS encrypted sessions are refused.
+func (c *ssmDataChannel) handshake(payload []byte) error {
+	var request struct {
+		RequestedClientActions []struct {
+			ActionType string `json:"ActionType"`
+		} `json:"RequestedClientActions"`
+	}
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return fmt.Errorf("invalid handshake request: %w", err)
+	}
+
+	type processedAction struct {
+		ActionType   string `json:"ActionType"`
+		ActionStatus int    `json:"ActionStatus"` // 1 success, 3 unsupported
+		Error        string `json:"Error,omitempty"`
+	}
+	response := struct {
+		ClientVersion          string            `json:"ClientVersion"`
+		ProcessedClientActions []processedAction `json:"ProcessedClientActions"`
+		Errors                 []string          `json:"Errors"`
+	}{ClientVersion: ssmClientVersion, Errors: []string{}}
+
+	var unsupported []string
+	for _, action := range request.RequestedClientActions {
+		if action.ActionType == "SessionType" {
+			response.ProcessedClientActions = append(response.ProcessedClientActions, processedAction{action.ActionType, 1, ""})
+			continue
+		}
+		unsupported = append(unsupported, action.ActionType)
+		response.ProcessedClientActions = append(response.ProcessedClientActions,
+			processedAction{action.ActionType, 3, "not supported by the built-in client"})
+	}
+
+	content, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	if err := c.sendInput(ssmPayloadHandshakeResponse, content); err != nil {
+		return err
+	}
+	if len(unsupported) > 0 {
+		return fmt.Errorf("the session requires %s, which the built-in client does not support; install session-manager-plugin", strings.Join(unsupported, ", "))
+	}
+	return nil
+}
+
+// acknowledge confirms receipt of an output message so the agent does not
+// resend it.
+func (c *ssmDataChannel) acknowledge(message ssmMessage) error {
+	content, err := json.Marshal(map[string]interface{}{
+		"AcknowledgedMessageType":           message.messageType,
+		"AcknowledgedMessageId":             message.messageID.String(),
+		"AcknowledgedMessageSequenceNumber": message.sequenceNumber,
+		"IsSequentialMessage":               true,
+	})
+	if err != nil {
+		return err
+	}
+	return c.send(ssmMessage{
+		messageType: ssmAcknowledge,
+		flags:       3,
+		payload:     content,
+	})
+}
+
+// sendInput sends a payload on the input stream with the next sequence number.
+func (c *ssmDataChannel) sendInput(payloadType uint32, payload []byte) error {
+	c.writeMu.Lock()
+	sequence := c.sequence
+	c.sequence++
+	c.writeMu.Unlock()
+
+	return c.send(ssmMessage{
+		messageType:    ssmInputStreamData,
+		sequenceNumber: sequence,
+		payloadType:    payloadType,
+		payload:        payload,
+	})
+}
+
+func (c *ssmDataChannel) send(message ssmMessage) error {
+	message.schemaVersion = 1
+	message.createdDate = uint64(time.Now().UnixMilli())
+	message.messageID = uuid.New()
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := websocket.Message.Send(c.ws, message.marshal()); err != nil {
+		return fmt.Errorf("failed to send to session: %w", err)
+	}
+	return nil
+}
+
+// forwardInput sends keystrokes to the remote shell once the handshake is done.
+func (c *ssmDataChannel) forwardInput(input io.Reader) {
+	<-c.ready
+	buf := make([]byte, 1024)
+	for {
+		n, err := input.Read(buf)
+		if n > 0 {
+			if sendErr := c.sendInput(ssmPayloadOutput, bytes.Clone(buf[:n])); sendErr != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// forwardTerminalSize keeps the remote terminal the size of the local one.
+// Polling works the same on every platform, unlike SIGWINCH.
+func (c *ssmDataChannel) forwardTerminalSize(fd int) {
+	<-c.ready
+	lastCols, lastRows := 0, 0
+	for {
+		cols, rows, err := term.GetSize(fd)
+		if err == nil && (cols != lastCols || rows != lastRows) {
+			content, _ := json.Marshal(map[string]int{"cols": cols, "rows": rows})
+			if c.sendInput(ssmPayloadSize, content) != nil {
+				return
+			}
+			lastCols, lastRows = cols, rows
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
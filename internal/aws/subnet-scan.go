@@ -0,0 +1,311 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// subnetScanWorkers bounds how many regions are scanned at the same time
+const subnetScanWorkers = 4
+
+// describeBatchSize is how many IDs go into the filter of one Describe call
+const describeBatchSize = 200
+
+// SubnetScanner looks up subnets in any number of regions. It keeps one
+// session per region, scans regions concurrently and remembers every subnet
+// it has described, so later lookups of the same subnets cost no API calls.
+// It is safe for concurrent use.
+type SubnetScanner struct {
+	mu       sync.Mutex
+	sessions map[string]*session.Session
+	subnets  map[string]*ec2.Subnet
+}
+
+// NewSubnetScanner returns a scanner with empty caches.
+func NewSubnetScanner() *SubnetScanner {
+	return &SubnetScanner{
+		sessions: make(map[string]*session.Session),
+		subnets:  make(map[string]*ec2.Subnet),
+	}
+}
+
+// session returns the session of a region, creating it on first use. An
+// empty region is the default region of the AWS configuration.
+func (s *SubnetScanner) session(region string) (*session.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess, ok := s.sessions[region]; ok {
+		return sess, nil
+	}
+	sess, err := NewSession("", region)
+	if err != nil {
+		return nil, err
+	}
+	s.sessions[region] = sess
+	return sess, nil
+}
+
+func (s *SubnetScanner) remember(subnets []*ec2.Subnet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, subnet := range subnets {
+		s.subnets[aws.StringValue(subnet.SubnetId)] = subnet
+	}
+}
+
+// DescribeSubnets returns the subnets of a region with the given IDs, keyed
+// by ID. Subnets not described before are fetched in batches; IDs that
+// don't exist are left out instead of failing the whole batch.
+func (s *SubnetScanner) DescribeSubnets(region string, subnetIDs []string) (map[string]*ec2.Subnet, error) {
+	found := make(map[string]*ec2.Subnet)
+	queued := make(map[string]bool)
+	var missing []string
+	s.mu.Lock()
+	for _, subnetID := range subnetIDs {
+		if subnet, ok := s.subnets[subnetID]; ok {
+			found[subnetID] = subnet
+		} else if !queued[subnetID] {
+			queued[subnetID] = true
+			missing = append(missing, subnetID)
+		}
+	}
+	s.mu.Unlock()
+	if len(missing) == 0 {
+		return found, nil
+	}
+
+	sess, err := s.session(region)
+	if err != nil {
+		return found, err
+	}
+	ec2Svc := ec2.New(sess)
+	for start := 0; start < len(missing); start += describeBatchSize {
+		batch := missing[start:min(start+describeBatchSize, len(missing))]
+		var described []*ec2.Subnet
+		err := ec2Svc.DescribeSubnetsPages(&ec2.DescribeSubnetsInput{
+			Filters: []*ec2.Filter{{Name: aws.String("subnet-id"), Values: aws.StringSlice(batch)}},
+		}, func(page *ec2.DescribeSubnetsOutput, lastPage bool) bool {
+			described = append(described, page.Subnets...)
+			return true
+		})
+		if err != nil {
+			return found, fmt.Errorf("failed to describe subnets in %s: %w", regionLabel(sess), err)
+		}
+		s.remember(described)
+		for _, subnet := range described {
+			found[aws.StringValue(subnet.SubnetId)] = subnet
+		}
+	}
+	return found, nil
+}
+
+// DescribeENIConfigSubnets describes the subnets of ENIConfigs, given as
+// ENIConfig name to subnet ID. ENIConfigs are conventionally named after
+// their availability zone, which gives the region to look in. Regions that
+// fail are reported in the error without stopping the others.
+func (s *SubnetScanner) DescribeENIConfigSubnets(subnetByENIConfig map[string]string) (map[string]*ec2.Subnet, error) {
+	subnetsByRegion := make(map[string][]string)
+	var errs []error
+	for name, subnetID := range subnetByENIConfig {
+		region := extractRegionFromName(name)
+		if region == "" {
+			errs = append(errs, fmt.Errorf("could not extract region from ENIConfig name: %s", name))
+			continue
+		}
+		subnetsByRegion[region] = append(subnetsByRegion[region], subnetID)
+	}
+
+	var mu sync.Mutex
+	found := make(map[string]*ec2.Subnet)
+	err := forEachRegion(sortedKeys(subnetsByRegion), func(region string) error {
+		subnets, err := s.DescribeSubnets(region, subnetsByRegion[region])
+		mu.Lock()
+		defer mu.Unlock()
+		for subnetID, subnet := range subnets {
+			found[subnetID] = subnet
+		}
+		return err
+	})
+	return found, errors.Join(append(errs, err)...)
+}
+
+// FindSecondarySubnets returns the subnets of a region that hold pod IPs
+// and are tagged as secondary (custom networking) subnets, keyed by ID.
+// Every subnet of the region is remembered for later lookups.
+func (s *SubnetScanner) FindSecondarySubnets(region string, pods []corev1.Pod) (map[string]*ec2.Subnet, error) {
+	var podIPs []net.IP
+	seen := make(map[string]bool)
+	for _, pod := range pods {
+		addresses := []string{pod.Status.PodIP}
+		for _, podIP := range pod.Status.PodIPs {
+			addresses = append(addresses, podIP.IP)
+		}
+		for _, address := range addresses {
+			if address == "" || seen[address] {
+				continue
+			}
+			seen[address] = true
+			if ip := net.ParseIP(address); ip != nil {
+				podIPs = append(podIPs, ip)
+			}
+		}
+	}
+
+	sess, err := s.session(region)
+	if err != nil {
+		return nil, err
+	}
+	var all []*ec2.Subnet
+	err = ec2.New(sess).DescribeSubnetsPages(&ec2.DescribeSubnetsInput{}, func(page *ec2.DescribeSubnetsOutput, lastPage bool) bool {
+		all = append(all, page.Subnets...)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe subnets in %s: %w", regionLabel(sess), err)
+	}
+	s.remember(all)
+
+	secondary := make(map[string]*ec2.Subnet)
+	for _, subnet := range all {
+		if subnet.CidrBlock == nil || !isSecondarySubnet(subnet) {
+			continue
+		}
+		_, cidr, err := net.ParseCIDR(*subnet.CidrBlock)
+		if err != nil {
+			continue
+		}
+		for _, ip := range podIPs {
+			if cidr.Contains(ip) {
+				secondary[aws.StringValue(subnet.SubnetId)] = subnet
+				break
+			}
+		}
+	}
+	return secondary, nil
+}
+
+// NodeSubnetInfo returns the subnets the nodes' instances run in with their
+// free IPs, sorted by subnet ID. Nodes are grouped by the region in their
+// provider ID and the regions scanned concurrently; regions that fail are
+// reported in the error while the others still count.
+func (s *SubnetScanner) NodeSubnetInfo(nodes []corev1.Node) ([]NodeSubnetInfo, error) {
+	nodesByRegion := make(map[string][]corev1.Node)
+	for _, node := range nodes {
+		if region := extractRegionFromProviderID(node.Spec.ProviderID); region != "" {
+			nodesByRegion[region] = append(nodesByRegion[region], node)
+		}
+	}
+
+	var mu sync.Mutex
+	var infos []NodeSubnetInfo
+	err := forEachRegion(sortedKeys(nodesByRegion), func(region string) error {
+		regionInfos, err := s.nodeSubnetsInRegion(region, nodesByRegion[region])
+		mu.Lock()
+		defer mu.Unlock()
+		infos = append(infos, regionInfos...)
+		return err
+	})
+	sort.Slice(infos, func(i, j int) bool { return infos[i].SubnetID < infos[j].SubnetID })
+	return infos, err
+}
+
+func (s *SubnetScanner) nodeSubnetsInRegion(region string, nodes []corev1.Node) ([]NodeSubnetInfo, error) {
+	nodeByInstance := make(map[string]string)
+	var instanceIDs []string
+	for _, node := range nodes {
+		if instanceID := extractInstanceIDFromProviderID(node.Spec.ProviderID); instanceID != "" {
+			nodeByInstance[instanceID] = node.Name
+			instanceIDs = append(instanceIDs, instanceID)
+		}
+	}
+	if len(instanceIDs) == 0 {
+		return nil, nil
+	}
+
+	sess, err := s.session(region)
+	if err != nil {
+		return nil, err
+	}
+	ec2Svc := ec2.New(sess)
+	subnetNodes := make(map[string][]string)
+	for start := 0; start < len(instanceIDs); start += describeBatchSize {
+		batch := instanceIDs[start:min(start+describeBatchSize, len(instanceIDs))]
+		err := ec2Svc.DescribeInstancesPages(&ec2.DescribeInstancesInput{
+			Filters: []*ec2.Filter{{Name: aws.String("instance-id"), Values: aws.StringSlice(batch)}},
+		}, func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+			for _, reservation := range page.Reservations {
+				for _, instance := range reservation.Instances {
+					if instance.SubnetId == nil {
+						continue
+					}
+					subnetID := *instance.SubnetId
+					subnetNodes[subnetID] = append(subnetNodes[subnetID], nodeByInstance[aws.StringValue(instance.InstanceId)])
+				}
+			}
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe instances in %s: %w", region, err)
+		}
+	}
+
+	subnets, err := s.DescribeSubnets(region, sortedKeys(subnetNodes))
+	var infos []NodeSubnetInfo
+	for subnetID, nodeNames := range subnetNodes {
+		subnet, ok := subnets[subnetID]
+		if !ok || subnet.AvailableIpAddressCount == nil {
+			continue
+		}
+		sort.Strings(nodeNames)
+		infos = append(infos, NodeSubnetInfo{
+			SubnetID:     subnetID,
+			AvailableIPs: int(*subnet.AvailableIpAddressCount),
+			NodeCount:    len(nodeNames),
+			NodeNames:    nodeNames,
+		})
+	}
+	return infos, err
+}
+
+// forEachRegion runs scan for every region on a pool of subnetScanWorkers
+// workers and joins the errors of the regions that failed.
+func forEachRegion(regions []string, scan func(region string) error) error {
+	jobs := make(chan string)
+	var mu sync.Mutex
+	var errs []error
+	var wg sync.WaitGroup
+	for i := 0; i < min(subnetScanWorkers, len(regions)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for region := range jobs {
+				if err := scan(region); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, region := range regions {
+		jobs <- region
+	}
+	close(jobs)
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func regionLabel(sess *session.Session) string {
+	if region := aws.StringValue(sess.Config.Region); region != "" {
+		return region
+	}
+	return "the default region"
+}
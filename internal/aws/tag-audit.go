@@ -0,0 +1,193 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+)
+
+// loadBalancersPerTagCall is the most load balancers the DescribeTags calls
+// of both ELB APIs take at once
+const loadBalancersPerTagCall = 20
+
+// TaggedResource is an AWS resource of the cluster with its tags
+type TaggedResource struct {
+	ID     string // Instance ID, volume ID or load balancer name
+	ARN    string // Load balancers of the elbv2 API only
+	Kind   string // instance, volume or load-balancer
+	Type   string // Instance type, volume type, or application, network, gateway or classic
+	SizeGB int64  // Volumes only
+	Tags   map[string]string
+}
+
+// GetInstanceTags returns the instances by ID with their type and tags.
+func GetInstanceTags(sess *session.Session, instanceIDs []string) ([]TaggedResource, error) {
+	var resources []TaggedResource
+	input := &ec2.DescribeInstancesInput{InstanceIds: aws.StringSlice(instanceIDs)}
+	err := ec2.New(sess).DescribeInstancesPages(input, func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				resources = append(resources, TaggedResource{
+					ID:   aws.StringValue(instance.InstanceId),
+					Kind: "instance",
+					Type: aws.StringValue(instance.InstanceType),
+					Tags: ec2TagMap(instance.Tags),
+				})
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instances: %w", err)
+	}
+	return resources, nil
+}
+
+// GetVolumeTags returns the volumes attached to the instances together with
+// the volumes by ID, each once, with their type, size and tags. Volume IDs
+// that no longer exist are skipped.
+func GetVolumeTags(sess *session.Session, instanceIDs, volumeIDs []string) ([]TaggedResource, error) {
+	client := ec2.New(sess)
+	seen := make(map[string]bool)
+	var resources []TaggedResource
+	describe := func(filter string, values []string) error {
+		for start := 0; start < len(values); start += volumeFilterValues {
+			chunk := values[start:min(start+volumeFilterValues, len(values))]
+			input := &ec2.DescribeVolumesInput{
+				Filters: []*ec2.Filter{{Name: aws.String(filter), Values: aws.StringSlice(chunk)}},
+			}
+			err := client.DescribeVolumesPages(input, func(page *ec2.DescribeVolumesOutput, lastPage bool) bool {
+				for _, volume := range page.Volumes {
+					volumeID := aws.StringValue(volume.VolumeId)
+					if seen[volumeID] {
+						continue
+					}
+					seen[volumeID] = true
+					resources = append(resources, TaggedResource{
+						ID:     volumeID,
+						Kind:   "volume",
+						Type:   aws.StringValue(volume.VolumeType),
+						SizeGB: aws.Int64Value(volume.Size),
+						Tags:   ec2TagMap(volume.Tags),
+					})
+				}
+				return true
+			})
+			if err != nil {
+				return fmt.Errorf("failed to describe volumes: %w", err)
+			}
+		}
+		return nil
+	}
+	if err := describe("attachment.instance-id", instanceIDs); err != nil {
+		return nil, err
+	}
+	// A filter instead of VolumeIds, which fails the whole call when one of
+	// the volumes has been deleted since
+	if err := describe("volume-id", volumeIDs); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// GetLoadBalancerTags returns the load balancers, of both ELB APIs, whose DNS
+// name is one of dnsNames, with their type and tags. The map goes from the
+// lower-cased DNS name to the load balancer.
+func GetLoadBalancerTags(sess *session.Session, dnsNames []string) (map[string]TaggedResource, error) {
+	wanted := make(map[string]bool)
+	for _, name := range dnsNames {
+		wanted[strings.ToLower(name)] = true
+	}
+	resources := make(map[string]TaggedResource)
+
+	v2Client := elbv2.New(sess)
+	dnsByARN := make(map[string]string)
+	err := v2Client.DescribeLoadBalancersPages(&elbv2.DescribeLoadBalancersInput{},
+		func(page *elbv2.DescribeLoadBalancersOutput, lastPage bool) bool {
+			for _, lb := range page.LoadBalancers {
+				dnsName := strings.ToLower(aws.StringValue(lb.DNSName))
+				if !wanted[dnsName] {
+					continue
+				}
+				arn := aws.StringValue(lb.LoadBalancerArn)
+				dnsByARN[arn] = dnsName
+				resources[dnsName] = TaggedResource{
+					ID:   aws.StringValue(lb.LoadBalancerName),
+					ARN:  arn,
+					Kind: "load-balancer",
+					Type: aws.StringValue(lb.Type),
+					Tags: make(map[string]string),
+				}
+			}
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe load balancers: %w", err)
+	}
+	arns := sortedKeys(dnsByARN)
+	for start := 0; start < len(arns); start += loadBalancersPerTagCall {
+		chunk := arns[start:min(start+loadBalancersPerTagCall, len(arns))]
+		output, err := v2Client.DescribeTags(&elbv2.DescribeTagsInput{ResourceArns: aws.StringSlice(chunk)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe load balancer tags: %w", err)
+		}
+		for _, description := range output.TagDescriptions {
+			resource := resources[dnsByARN[aws.StringValue(description.ResourceArn)]]
+			for _, tag := range description.Tags {
+				resource.Tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+		}
+	}
+
+	classicClient := elb.New(sess)
+	dnsByName := make(map[string]string)
+	err = classicClient.DescribeLoadBalancersPages(&elb.DescribeLoadBalancersInput{},
+		func(page *elb.DescribeLoadBalancersOutput, lastPage bool) bool {
+			for _, lb := range page.LoadBalancerDescriptions {
+				dnsName := strings.ToLower(aws.StringValue(lb.DNSName))
+				if !wanted[dnsName] {
+					continue
+				}
+				name := aws.StringValue(lb.LoadBalancerName)
+				dnsByName[name] = dnsName
+				resources[dnsName] = TaggedResource{
+					ID:   name,
+					Kind: "load-balancer",
+					Type: "classic",
+					Tags: make(map[string]string),
+				}
+			}
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe classic load balancers: %w", err)
+	}
+	names := sortedKeys(dnsByName)
+	for start := 0; start < len(names); start += loadBalancersPerTagCall {
+		chunk := names[start:min(start+loadBalancersPerTagCall, len(names))]
+		output, err := classicClient.DescribeTags(&elb.DescribeTagsInput{LoadBalancerNames: aws.StringSlice(chunk)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe classic load balancer tags: %w", err)
+		}
+		for _, description := range output.TagDescriptions {
+			resource := resources[dnsByName[aws.StringValue(description.LoadBalancerName)]]
+			for _, tag := range description.Tags {
+				resource.Tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+		}
+	}
+	return resources, nil
+}
+
+func ec2TagMap(tags []*ec2.Tag) map[string]string {
+	tagMap := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		tagMap[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	return tagMap
+}
@@ -0,0 +1,308 @@
+// Package bookmarks stores short aliases for frequently used targets such as
+// clusters, ASGs, nodes and namespaces. Commands accept "@alias" wherever
+// such a target is expected.
+package bookmarks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/HighonAces/swissarmycli/internal/config"
+	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/util/homedir"
+)
+
+// Kinds of targets a bookmark can point to
+const (
+	KindCluster   = "cluster"
+	KindASG       = "asg"
+	KindNode      = "node"
+	KindNamespace = "namespace"
+)
+
+// Kinds lists the valid bookmark kinds
+var Kinds = []string{KindCluster, KindASG, KindNode, KindNamespace}
+
+// Prefix marks an argument as a bookmark reference, e.g. @prod-payments
+const Prefix = "@"
+
+// Bookmark maps an alias to a target
+type Bookmark struct {
+	Alias       string `yaml:"alias" dynamodbav:"alias"`
+	Kind        string `yaml:"kind" dynamodbav:"kind"`
+	Target      string `yaml:"target" dynamodbav:"target"`
+	Region      string `yaml:"region,omitempty" dynamodbav:"region,omitempty"`
+	Description string `yaml:"description,omitempty" dynamodbav:"description,omitempty"`
+}
+
+// backend is where bookmarks are kept: the local file, or a shared S3 object
+// or DynamoDB table
+type backend interface {
+	List() ([]Bookmark, error)
+	Put(bookmark Bookmark) error
+	Delete(alias string) error
+	Location() string
+}
+
+// List returns every bookmark and where they are stored. Shared bookmarks
+// are also copied to the local file so resolving them needs no AWS call.
+func List() ([]Bookmark, string, error) {
+	store, err := open()
+	if err != nil {
+		return nil, "", err
+	}
+	all, err := pull(store)
+	if err != nil {
+		return nil, "", err
+	}
+	sortBookmarks(all)
+	return all, store.Location(), nil
+}
+
+// Add saves a bookmark, replacing any bookmark with the same alias, and
+// returns where it was stored.
+func Add(bookmark Bookmark) (string, error) {
+	if err := Validate(bookmark); err != nil {
+		return "", err
+	}
+	store, err := open()
+	if err != nil {
+		return "", err
+	}
+	if err := store.Put(bookmark); err != nil {
+		return "", err
+	}
+	if _, err := pull(store); err != nil {
+		return "", err
+	}
+	return store.Location(), nil
+}
+
+// Remove deletes a bookmark and returns where it was stored.
+func Remove(alias string) (string, error) {
+	alias = strings.TrimPrefix(alias, Prefix)
+	store, err := open()
+	if err != nil {
+		return "", err
+	}
+	if err := store.Delete(alias); err != nil {
+		return "", err
+	}
+	if _, err := pull(store); err != nil {
+		return "", err
+	}
+	return store.Location(), nil
+}
+
+// open returns the shared store configured in the config file, or the local
+// file store when no sharing is configured.
+func open() (backend, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	sync, err := cfg.Bookmarks()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case sync.S3Bucket != "" && sync.DynamoDBTable != "":
+		return nil, fmt.Errorf("bookmarks: set either s3_bucket or dynamodb_table, not both")
+	case sync.S3Bucket != "":
+		return newS3Store(sync)
+	case sync.DynamoDBTable != "":
+		return newDynamoDBStore(sync)
+	default:
+		return fileStore{path: cachePath()}, nil
+	}
+}
+
+// Validate checks that a bookmark is complete and of a known kind.
+func Validate(bookmark Bookmark) error {
+	if bookmark.Alias == "" || strings.HasPrefix(bookmark.Alias, Prefix) {
+		return fmt.Errorf("invalid alias '%s': give the alias without the leading %s", bookmark.Alias, Prefix)
+	}
+	if bookmark.Target == "" {
+		return fmt.Errorf("bookmark '%s' has no target", bookmark.Alias)
+	}
+	for _, kind := range Kinds {
+		if bookmark.Kind == kind {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid kind '%s', must be one of %s", bookmark.Kind, strings.Join(Kinds, ", "))
+}
+
+// Resolve returns the bookmark ref points to when it starts with @, or a
+// bookmark with ref itself as the target otherwise. The local copy is tried
+// first so resolving is fast; shared stores are only read on a miss.
+func Resolve(ref, kind string) (Bookmark, error) {
+	if !strings.HasPrefix(ref, Prefix) {
+		return Bookmark{Kind: kind, Target: ref}, nil
+	}
+	alias := strings.TrimPrefix(ref, Prefix)
+
+	cached, err := fileStore{path: cachePath()}.List()
+	if err != nil {
+		return Bookmark{}, err
+	}
+	bookmark, found := find(cached, alias)
+	if !found {
+		store, err := open()
+		if err != nil {
+			return Bookmark{}, err
+		}
+		if _, isFile := store.(fileStore); !isFile {
+			all, err := pull(store)
+			if err != nil {
+				return Bookmark{}, err
+			}
+			bookmark, found = find(all, alias)
+		}
+	}
+	if !found {
+		return Bookmark{}, fmt.Errorf("no bookmark named '%s'", alias)
+	}
+	if bookmark.Kind != kind {
+		return Bookmark{}, fmt.Errorf("bookmark '%s' is a %s, expected a %s", alias, bookmark.Kind, kind)
+	}
+	return bookmark, nil
+}
+
+// pull reads every bookmark from store and refreshes the local copy with them.
+func pull(store backend) ([]Bookmark, error) {
+	all, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	if _, isFile := store.(fileStore); !isFile {
+		if err := (fileStore{path: cachePath()}).save(all); err != nil {
+			return nil, err
+		}
+	}
+	return all, nil
+}
+
+func find(all []Bookmark, alias string) (Bookmark, bool) {
+	for _, bookmark := range all {
+		if bookmark.Alias == alias {
+			return bookmark, true
+		}
+	}
+	return Bookmark{}, false
+}
+
+// sortBookmarks orders bookmarks by kind, then alias
+func sortBookmarks(all []Bookmark) {
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Kind != all[j].Kind {
+			return all[i].Kind < all[j].Kind
+		}
+		return all[i].Alias < all[j].Alias
+	})
+}
+
+// cachePath is the local bookmarks file. With a shared store configured it
+// holds the last pulled copy.
+func cachePath() string {
+	return filepath.Join(homedir.HomeDir(), ".swissarmycli", "bookmarks.yaml")
+}
+
+// bookmarkFile is the YAML layout used by the local file and the S3 object
+type bookmarkFile struct {
+	Bookmarks []Bookmark `yaml:"bookmarks"`
+}
+
+func decodeBookmarks(content []byte) ([]Bookmark, error) {
+	var file bookmarkFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, err
+	}
+	return file.Bookmarks, nil
+}
+
+func encodeBookmarks(all []Bookmark) ([]byte, error) {
+	sortBookmarks(all)
+	return yaml.Marshal(bookmarkFile{Bookmarks: all})
+}
+
+// upsert replaces the bookmark with the same alias, or appends it
+func upsert(all []Bookmark, bookmark Bookmark) []Bookmark {
+	for i := range all {
+		if all[i].Alias == bookmark.Alias {
+			all[i] = bookmark
+			return all
+		}
+	}
+	return append(all, bookmark)
+}
+
+// remove drops the bookmark with the given alias and reports whether it existed
+func remove(all []Bookmark, alias string) ([]Bookmark, bool) {
+	for i := range all {
+		if all[i].Alias == alias {
+			return append(all[:i], all[i+1:]...), true
+		}
+	}
+	return all, false
+}
+
+// fileStore keeps bookmarks in a local YAML file
+type fileStore struct {
+	path string
+}
+
+func (s fileStore) Location() string { return s.path }
+
+func (s fileStore) List() ([]Bookmark, error) {
+	content, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bookmarks file '%s': %w", s.path, err)
+	}
+	all, err := decodeBookmarks(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bookmarks file '%s': %w", s.path, err)
+	}
+	return all, nil
+}
+
+func (s fileStore) Put(bookmark Bookmark) error {
+	all, err := s.List()
+	if err != nil {
+		return err
+	}
+	return s.save(upsert(all, bookmark))
+}
+
+func (s fileStore) Delete(alias string) error {
+	all, err := s.List()
+	if err != nil {
+		return err
+	}
+	all, found := remove(all, alias)
+	if !found {
+		return fmt.Errorf("no bookmark named '%s'", alias)
+	}
+	return s.save(all)
+}
+
+func (s fileStore) save(all []Bookmark) error {
+	content, err := encodeBookmarks(all)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for '%s': %w", s.path, err)
+	}
+	if err := os.WriteFile(s.path, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write bookmarks file '%s': %w", s.path, err)
+	}
+	return nil
+}
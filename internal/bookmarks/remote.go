@@ -0,0 +1,174 @@
+package bookmarks
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// defaultS3Key is used when the config does not set s3_key
+const defaultS3Key = "swissarmycli/bookmarks.yaml"
+
+// s3Store keeps all bookmarks in a single YAML object. Changes read, modify
+// and write the whole object, so two people editing at the same moment can
+// overwrite each other; use DynamoDB if that matters.
+type s3Store struct {
+	svc    *s3.S3
+	bucket string
+	key    string
+}
+
+func newS3Store(sync config.BookmarkSync) (backend, error) {
+	sess, err := awsutils.NewSession(sync.Profile, sync.Region)
+	if err != nil {
+		return nil, err
+	}
+	key := sync.S3Key
+	if key == "" {
+		key = defaultS3Key
+	}
+	return s3Store{svc: s3.New(sess), bucket: sync.S3Bucket, key: key}, nil
+}
+
+func (s s3Store) Location() string { return fmt.Sprintf("s3://%s/%s", s.bucket, s.key) }
+
+func (s s3Store) List() ([]Bookmark, error) {
+	output, err := s.svc.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key),
+	})
+	var aerr awserr.Error
+	if errors.As(err, &aerr) && aerr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, nil // Nobody has saved a bookmark yet
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bookmarks from %s: %w", s.Location(), err)
+	}
+	defer output.Body.Close()
+
+	content, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bookmarks from %s: %w", s.Location(), err)
+	}
+	all, err := decodeBookmarks(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bookmarks in %s: %w", s.Location(), err)
+	}
+	return all, nil
+}
+
+func (s s3Store) Put(bookmark Bookmark) error {
+	all, err := s.List()
+	if err != nil {
+		return err
+	}
+	return s.save(upsert(all, bookmark))
+}
+
+func (s s3Store) Delete(alias string) error {
+	all, err := s.List()
+	if err != nil {
+		return err
+	}
+	all, found := remove(all, alias)
+	if !found {
+		return fmt.Errorf("no bookmark named '%s'", alias)
+	}
+	return s.save(all)
+}
+
+func (s s3Store) save(all []Bookmark) error {
+	content, err := encodeBookmarks(all)
+	if err != nil {
+		return err
+	}
+	_, err = s.svc.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(s.key),
+		Body:        bytes.NewReader(content),
+		ContentType: aws.String("application/yaml"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write bookmarks to %s: %w", s.Location(), err)
+	}
+	return nil
+}
+
+// dynamoDBStore keeps one item per bookmark, keyed by alias, so concurrent
+// edits of different bookmarks don't conflict.
+type dynamoDBStore struct {
+	svc   *dynamodb.DynamoDB
+	table string
+}
+
+func newDynamoDBStore(sync config.BookmarkSync) (backend, error) {
+	sess, err := awsutils.NewSession(sync.Profile, sync.Region)
+	if err != nil {
+		return nil, err
+	}
+	return dynamoDBStore{svc: dynamodb.New(sess), table: sync.DynamoDBTable}, nil
+}
+
+func (s dynamoDBStore) Location() string { return "dynamodb://" + s.table }
+
+func (s dynamoDBStore) List() ([]Bookmark, error) {
+	var all []Bookmark
+	var decodeErr error
+	err := s.svc.ScanPages(&dynamodb.ScanInput{
+		TableName: aws.String(s.table),
+	}, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		var items []Bookmark
+		if decodeErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &items); decodeErr != nil {
+			return false
+		}
+		all = append(all, items...)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bookmarks from %s: %w", s.Location(), err)
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("invalid bookmarks in %s: %w", s.Location(), decodeErr)
+	}
+	sortBookmarks(all)
+	return all, nil
+}
+
+func (s dynamoDBStore) Put(bookmark Bookmark) error {
+	item, err := dynamodbattribute.MarshalMap(bookmark)
+	if err != nil {
+		return err
+	}
+	_, err = s.svc.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save bookmark '%s' to %s: %w", bookmark.Alias, s.Location(), err)
+	}
+	return nil
+}
+
+func (s dynamoDBStore) Delete(alias string) error {
+	output, err := s.svc.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName:    aws.String(s.table),
+		Key:          map[string]*dynamodb.AttributeValue{"alias": {S: aws.String(alias)}},
+		ReturnValues: aws.String(dynamodb.ReturnValueAllOld),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete bookmark '%s' from %s: %w", alias, s.Location(), err)
+	}
+	if len(output.Attributes) == 0 {
+		return fmt.Errorf("no bookmark named '%s'", alias)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+// Package columns prints the rows of tabular commands with user-chosen
+// columns or a Go template, in the spirit of kubectl's custom-columns and
+// go-template output.
+package columns
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+)
+
+// Options selects the custom output of a command
+type Options struct {
+	Columns  string // Comma separated fields, each optionally HEADER:field
+	Template string // Go template executed once per row
+}
+
+// Enabled reports whether custom output was asked for.
+func (o Options) Enabled() bool {
+	return o.Columns != "" || o.Template != ""
+}
+
+// column is one field of the --columns list
+type column struct {
+	header string
+	path   []string
+}
+
+// Printer prints rows as chosen by Options. Fields are named by the JSON
+// names of the rows' fields, so the same names work in both forms.
+type Printer struct {
+	columns  []column
+	template *template.Template
+}
+
+// New parses the columns or template, so mistakes are reported before any
+// data is fetched.
+func New(options Options) (*Printer, error) {
+	if options.Columns != "" && options.Template != "" {
+		return nil, fmt.Errorf("--columns and --template can't be used together")
+	}
+	printer := &Printer{}
+	if options.Template != "" {
+		text := options.Template
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		tmpl, err := template.New("row").Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse --template: %w", err)
+		}
+		printer.template = tmpl
+		return printer, nil
+	}
+
+	for _, spec := range strings.Split(options.Columns, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		header, field, found := strings.Cut(spec, ":")
+		if !found {
+			field = header
+			header = strings.ToUpper(strings.ReplaceAll(strings.TrimPrefix(field, "."), "_", " "))
+		}
+		field = strings.TrimPrefix(strings.TrimSpace(field), ".")
+		if field == "" {
+			return nil, fmt.Errorf("column '%s' has no field", spec)
+		}
+		printer.columns = append(printer.columns, column{header: strings.TrimSpace(header), path: strings.Split(field, ".")})
+	}
+	if len(printer.columns) == 0 {
+		return nil, fmt.Errorf("--columns lists no fields")
+	}
+	return printer, nil
+}
+
+// Print writes rows, a slice of structs or struct pointers, to w.
+func (p *Printer) Print(w io.Writer, rows interface{}) error {
+	records, err := toRecords(rows)
+	if err != nil {
+		return err
+	}
+
+	if p.template != nil {
+		for _, record := range records {
+			if err := p.template.Execute(w, record); err != nil {
+				return fmt.Errorf("failed to execute --template: %w", err)
+			}
+		}
+		return nil
+	}
+
+	// Every row is resolved before printing so a bad field prints nothing
+	lines := make([]string, 0, len(records)+1)
+	headers := make([]string, len(p.columns))
+	for i, col := range p.columns {
+		headers[i] = col.header
+	}
+	lines = append(lines, strings.Join(headers, "\t"))
+	for _, record := range records {
+		values := make([]string, len(p.columns))
+		for i, col := range p.columns {
+			value, ok := lookup(record, col.path)
+			if !ok {
+				return fmt.Errorf("unknown field '%s', available fields: %s", strings.Join(col.path, "."), strings.Join(fieldNames(record), ", "))
+			}
+			values[i] = formatValue(value)
+		}
+		lines = append(lines, strings.Join(values, "\t"))
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, line := range lines {
+		fmt.Fprintln(tw, line)
+	}
+	return tw.Flush()
+}
+
+// toRecords converts rows to their JSON form, keyed by field name.
+func toRecords(rows interface{}) ([]map[string]interface{}, error) {
+	value := reflect.ValueOf(rows)
+	if value.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("rows must be a slice, got %T", rows)
+	}
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode rows: %w", err)
+	}
+	var records []map[string]interface{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode rows: %w", err)
+	}
+	return records, nil
+}
+
+func lookup(record map[string]interface{}, path []string) (interface{}, bool) {
+	var current interface{} = record
+	for _, key := range path {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = object[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func fieldNames(record map[string]interface{}) []string {
+	names := make([]string, 0, len(record))
+	for name := range record {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatValue prints numbers with at most two decimals, like the regular
+// tables do, and anything nested as JSON.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "<none>"
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		if v == math.Trunc(v) {
+			return strconv.FormatFloat(v, 'f', 0, 64)
+		}
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,374 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/util/homedir"
+)
+
+// Config holds user settings read from the swissarmycli config file. Each
+// section is decoded when it is read, so encrypted values are only
+// decrypted for the commands that use them.
+type Config struct {
+	path     string
+	document *yaml.Node // Top-level mapping of the file, nil without a file
+	mu       sync.Mutex
+}
+
+// layout is the shape of the config file. Load decodes the file into it,
+// encrypted values and all, to report mistakes before any command runs.
+type layout struct {
+	Presets        map[string]Preset `yaml:"presets"`
+	SecretRotation RotationPolicy    `yaml:"secret_rotation"`
+	Lint           LintConfig        `yaml:"lint"`
+	Bookmarks      BookmarkSync      `yaml:"bookmarks"`
+	Theme          ThemeConfig       `yaml:"theme"`
+	Chaos          ChaosConfig       `yaml:"chaos"`
+	Safety         SafetyConfig      `yaml:"safety"`
+	Tagging        TaggingPolicy     `yaml:"tagging"`
+	Criticality    CriticalityConfig `yaml:"criticality"`
+	ImageScan      ImageScanConfig   `yaml:"image_scan"`
+	Ownership      OwnershipConfig   `yaml:"ownership"`
+	AWS            AWSConfig         `yaml:"aws"`
+	Prometheus     PrometheusConfig  `yaml:"prometheus"`
+}
+
+// AWSConfig holds account-wide AWS settings.
+type AWSConfig struct {
+	Regions []string `yaml:"regions"` // Regions scanned by commands that search the whole account
+}
+
+// Default queries of PrometheusConfig. $service and $window are replaced
+// with the service and a range such as 5m or 30d.
+const (
+	DefaultSLORequestsQuery = `sum(rate(http_requests_total{service="$service"}[$window]))`
+	DefaultSLOErrorsQuery   = `sum(rate(http_requests_total{service="$service",code=~"5.."}[$window]))`
+)
+
+// PrometheusConfig tells slo where to query a service's request and error
+// rates.
+type PrometheusConfig struct {
+	URL            string `yaml:"url"`              // Queried directly, e.g. https://prometheus.example.com
+	Service        string `yaml:"service"`          // namespace/name:port of an in-cluster Prometheus, port-forwarded when URL is empty
+	BearerTokenEnv string `yaml:"bearer_token_env"` // Environment variable holding a token sent with every query
+	RequestsQuery  string `yaml:"requests_query"`   // Requests per second of $service over $window
+	ErrorsQuery    string `yaml:"errors_query"`     // Failed requests per second of $service over $window
+}
+
+// Queries returns the requests and errors queries, falling back to
+// DefaultSLORequestsQuery and DefaultSLOErrorsQuery.
+func (p PrometheusConfig) Queries() (string, string) {
+	requests, errors := p.RequestsQuery, p.ErrorsQuery
+	if requests == "" {
+		requests = DefaultSLORequestsQuery
+	}
+	if errors == "" {
+		errors = DefaultSLOErrorsQuery
+	}
+	return requests, errors
+}
+
+// DefaultTeamLabels are the labels owner reads a team from when the config
+// does not list any.
+var DefaultTeamLabels = []string{"team", "owner", "app.kubernetes.io/team"}
+
+// OwnershipConfig tells owner where teams are recorded and how to reach them.
+type OwnershipConfig struct {
+	TeamLabels []string          `yaml:"team_labels"` // Label or annotation keys holding the team, most specific first
+	Contacts   map[string]string `yaml:"contacts"`    // Team to its pager, channel or email
+}
+
+// Labels returns the team label keys, falling back to DefaultTeamLabels.
+func (o OwnershipConfig) Labels() []string {
+	if len(o.TeamLabels) > 0 {
+		return o.TeamLabels
+	}
+	return DefaultTeamLabels
+}
+
+// ImageScanConfig selects the vulnerability scanner scan-images runs.
+type ImageScanConfig struct {
+	Scanner   string   `yaml:"scanner"`    // trivy or grype, defaults to trivy
+	Path      string   `yaml:"path"`       // Scanner binary, looked up in PATH when empty
+	ExtraArgs []string `yaml:"extra_args"` // Added to every scan, e.g. --offline-scan
+}
+
+// Defaults of CriticalityConfig
+const (
+	DefaultCriticalMinReplicas = 3
+	DefaultCriticalMinZones    = 2
+)
+
+// CriticalityConfig lists the Deployments that must always be able to take
+// over for a lost replica or zone, and the redundancy they need.
+type CriticalityConfig struct {
+	Deployments []string `yaml:"deployments"` // namespace/name
+	MinReplicas int      `yaml:"min_replicas"`
+	MinZones    int      `yaml:"min_zones"`
+}
+
+// Replicas returns the minimum ready replicas, falling back to
+// DefaultCriticalMinReplicas.
+func (c CriticalityConfig) Replicas() int {
+	if c.MinReplicas > 0 {
+		return c.MinReplicas
+	}
+	return DefaultCriticalMinReplicas
+}
+
+// Zones returns the minimum number of zones the ready replicas must span,
+// falling back to DefaultCriticalMinZones.
+func (c CriticalityConfig) Zones() int {
+	if c.MinZones > 0 {
+		return c.MinZones
+	}
+	return DefaultCriticalMinZones
+}
+
+// DefaultRequiredTags is used when the config does not list required
+// cost-allocation tags.
+var DefaultRequiredTags = []string{"Environment", "Team", "CostCenter"}
+
+// TaggingPolicy lists the cost-allocation tags every AWS resource of the
+// cluster has to carry.
+type TaggingPolicy struct {
+	RequiredTags  []string            `yaml:"required_tags"`
+	AllowedValues map[string][]string `yaml:"allowed_values"` // Tag key to its permitted values; keys not listed accept any non-empty value
+}
+
+// Required returns the required tag keys, falling back to
+// DefaultRequiredTags when the key is absent.
+func (p TaggingPolicy) Required() []string {
+	if len(p.RequiredTags) == 0 {
+		return DefaultRequiredTags
+	}
+	return p.RequiredTags
+}
+
+// DefaultProtectedContexts is used when the config does not list protected
+// contexts.
+var DefaultProtectedContexts = []string{"*prod*"}
+
+// SafetyConfig decides which kubeconfig contexts need an explicit go-ahead
+// before anything is changed or revealed in them.
+type SafetyConfig struct {
+	ProtectedContexts []string `yaml:"protected_contexts"` // Glob patterns, * matches anything; an empty list protects nothing
+}
+
+// Protected returns the protected context patterns, falling back to
+// DefaultProtectedContexts when the key is absent.
+func (s SafetyConfig) Protected() []string {
+	if s.ProtectedContexts == nil {
+		return DefaultProtectedContexts
+	}
+	return s.ProtectedContexts
+}
+
+// ChaosConfig limits where the chaos commands may disrupt workloads.
+type ChaosConfig struct {
+	AllowedNamespaces []string `yaml:"allowed_namespaces"` // Chaos is refused everywhere else, and everywhere when empty
+}
+
+// ThemeConfig selects the colors of the terminal UIs.
+type ThemeConfig struct {
+	Palette string            `yaml:"palette"` // default, high-contrast, colorblind or none
+	Colors  map[string]string `yaml:"colors"`  // Role (title, muted, ok, warning, error) to tview style tag
+}
+
+// BookmarkSync shares bookmarks through an S3 object or a DynamoDB table so
+// the whole team uses the same aliases. At most one backend should be set;
+// without either, bookmarks are kept only in the local file.
+type BookmarkSync struct {
+	S3Bucket      string `yaml:"s3_bucket"`
+	S3Key         string `yaml:"s3_key"`         // Defaults to swissarmycli/bookmarks.yaml
+	DynamoDBTable string `yaml:"dynamodb_table"` // Partition key "alias" (string)
+	Region        string `yaml:"region"`
+	Profile       string `yaml:"profile"`
+}
+
+// LintConfig turns lint checks off or changes their severity.
+type LintConfig struct {
+	Disabled   []string          `yaml:"disabled"`   // Check IDs to skip
+	Severities map[string]string `yaml:"severities"` // Check ID to error, warning or info
+}
+
+// DefaultSecretMaxAgeDays is used when the config does not set a rotation threshold.
+const DefaultSecretMaxAgeDays = 90
+
+// RotationPolicy defines how old a secret may get before it should be rotated.
+type RotationPolicy struct {
+	MaxAgeDays int            `yaml:"max_age_days"`
+	Types      map[string]int `yaml:"types"` // Per secret type overrides of MaxAgeDays
+}
+
+// MaxAgeFor returns the rotation threshold in days for a secret type.
+func (p RotationPolicy) MaxAgeFor(secretType string) int {
+	if days, ok := p.Types[secretType]; ok && days > 0 {
+		return days
+	}
+	if p.MaxAgeDays > 0 {
+		return p.MaxAgeDays
+	}
+	return DefaultSecretMaxAgeDays
+}
+
+// Preset maps a short name to an SSM document run on cluster nodes.
+type Preset struct {
+	Description    string              `yaml:"description"`
+	Document       string              `yaml:"document"`   // Defaults to AWS-RunShellScript
+	Commands       []string            `yaml:"commands"`   // Shorthand for the "commands" parameter
+	Parameters     map[string][]string `yaml:"parameters"` // Additional document parameters
+	TimeoutSeconds int64               `yaml:"timeout_seconds"`
+}
+
+// Path returns the config file location: $SWISSARMYCLI_CONFIG if set,
+// otherwise ~/.swissarmycli/config.yaml.
+func Path() string {
+	if path := os.Getenv("SWISSARMYCLI_CONFIG"); path != "" {
+		return path
+	}
+	return filepath.Join(homedir.HomeDir(), ".swissarmycli", "config.yaml")
+}
+
+// Load reads the config file. A missing file is not an error and yields an
+// empty config so every command works without one. Encrypted values are
+// decrypted when their section is read, see EncryptFields.
+func Load() (*Config, error) {
+	path := Path()
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Config{path: path}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file '%s': %w", path, err)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("invalid config file '%s': %w", path, err)
+	}
+	if len(document.Content) == 0 {
+		return &Config{path: path}, nil
+	}
+	var check layout
+	if err := document.Decode(&check); err != nil {
+		return nil, fmt.Errorf("invalid config file '%s': %w", path, err)
+	}
+	return &Config{path: path, document: document.Content[0]}, nil
+}
+
+// section decrypts and decodes the value of a top-level key into out, which
+// is left alone when the key is absent.
+func (c *Config) section(key string, out interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.document == nil {
+		return nil
+	}
+	node := mappingValue(c.document, key)
+	if node == nil {
+		return nil
+	}
+	if err := decryptNodes(node, key); err != nil {
+		return fmt.Errorf("config file '%s': %w", c.path, err)
+	}
+	if err := node.Decode(out); err != nil {
+		return fmt.Errorf("invalid config file '%s': %w", c.path, err)
+	}
+	return nil
+}
+
+// Presets returns the presets section.
+func (c *Config) Presets() (map[string]Preset, error) {
+	var presets map[string]Preset
+	err := c.section("presets", &presets)
+	return presets, err
+}
+
+// SecretRotation returns the secret_rotation section.
+func (c *Config) SecretRotation() (RotationPolicy, error) {
+	var policy RotationPolicy
+	err := c.section("secret_rotation", &policy)
+	return policy, err
+}
+
+// Lint returns the lint section.
+func (c *Config) Lint() (LintConfig, error) {
+	var lint LintConfig
+	err := c.section("lint", &lint)
+	return lint, err
+}
+
+// Bookmarks returns the bookmarks section.
+func (c *Config) Bookmarks() (BookmarkSync, error) {
+	var sync BookmarkSync
+	err := c.section("bookmarks", &sync)
+	return sync, err
+}
+
+// Theme returns the theme section.
+func (c *Config) Theme() (ThemeConfig, error) {
+	var theme ThemeConfig
+	err := c.section("theme", &theme)
+	return theme, err
+}
+
+// Chaos returns the chaos section.
+func (c *Config) Chaos() (ChaosConfig, error) {
+	var chaos ChaosConfig
+	err := c.section("chaos", &chaos)
+	return chaos, err
+}
+
+// Safety returns the safety section.
+func (c *Config) Safety() (SafetyConfig, error) {
+	var safety SafetyConfig
+	err := c.section("safety", &safety)
+	return safety, err
+}
+
+// Tagging returns the tagging section.
+func (c *Config) Tagging() (TaggingPolicy, error) {
+	var policy TaggingPolicy
+	err := c.section("tagging", &policy)
+	return policy, err
+}
+
+// Criticality returns the criticality section.
+func (c *Config) Criticality() (CriticalityConfig, error) {
+	var criticality CriticalityConfig
+	err := c.section("criticality", &criticality)
+	return criticality, err
+}
+
+// ImageScan returns the image_scan section.
+func (c *Config) ImageScan() (ImageScanConfig, error) {
+	var imageScan ImageScanConfig
+	err := c.section("image_scan", &imageScan)
+	return imageScan, err
+}
+
+// Ownership returns the ownership section.
+func (c *Config) Ownership() (OwnershipConfig, error) {
+	var ownership OwnershipConfig
+	err := c.section("ownership", &ownership)
+	return ownership, err
+}
+
+// AWS returns the aws section.
+func (c *Config) AWS() (AWSConfig, error) {
+	var aws AWSConfig
+	err := c.section("aws", &aws)
+	return aws, err
+}
+
+// Prometheus returns the prometheus section.
+func (c *Config) Prometheus() (PrometheusConfig, error) {
+	var prometheus PrometheusConfig
+	err := c.section("prometheus", &prometheus)
+	return prometheus, err
+}
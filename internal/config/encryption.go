@@ -0,0 +1,448 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/HighonAces/swissarmycli/internal/apicalls"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"gopkg.in/yaml.v3"
+)
+
+// Encrypted config values are strings of the form
+//
+//	enc:kms:<region>:<base64 envelope>
+//	enc:keychain:<base64 nonce and ciphertext>
+//
+// A KMS envelope holds a data key encrypted by KMS followed by the value
+// sealed with that key using AES-GCM, so anyone allowed to decrypt with the
+// KMS key can read the config on any machine. Keychain values are sealed
+// with a key kept in the OS keychain of the machine they were encrypted on.
+const (
+	encryptedPrefix = "enc:"
+	MethodKMS       = "kms"
+	MethodKeychain  = "keychain"
+)
+
+// Where the keychain key is stored
+const (
+	keychainService = "swissarmycli"
+	keychainAccount = "config-key"
+)
+
+// kmsEncryptionContext is bound to every data key, so ciphertexts made for
+// other purposes with the same KMS key can't be passed off as config values
+var kmsEncryptionContext = map[string]*string{"purpose": aws.String("swissarmycli-config")}
+
+// EncryptOptions selects how config values are encrypted
+type EncryptOptions struct {
+	Method  string // kms or keychain
+	KMSKey  string // Key ID, ARN or alias of the KMS key
+	Region  string // Region of the KMS key, the default region when empty
+	Profile string
+}
+
+var (
+	decryptMu     sync.Mutex
+	decryptCache  = make(map[string]string)
+	keychainCache []byte
+)
+
+// IsEncrypted reports whether a config value is encrypted.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// decryptNodes replaces every encrypted scalar under node with its
+// plaintext. Values are cached for the process, since Load runs once per
+// command that reads the config.
+func decryptNodes(node *yaml.Node, path string) error {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for i, child := range node.Content {
+			childPath := path
+			if node.Kind == yaml.SequenceNode {
+				childPath = fmt.Sprintf("%s[%d]", path, i)
+			}
+			if err := decryptNodes(child, childPath); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := decryptNodes(node.Content[i+1], joinPath(path, node.Content[i].Value)); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if !IsEncrypted(node.Value) {
+			return nil
+		}
+		plaintext, err := decryptValue(node.Value)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", path, err)
+		}
+		node.Value, node.Tag, node.Style = plaintext, "!!str", 0
+	}
+	return nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// decryptValue decrypts one enc: value.
+func decryptValue(value string) (string, error) {
+	decryptMu.Lock()
+	defer decryptMu.Unlock()
+	if plaintext, ok := decryptCache[value]; ok {
+		return plaintext, nil
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(value, encryptedPrefix), ":", 3)
+	var plaintext []byte
+	var err error
+	switch {
+	case parts[0] == MethodKMS && len(parts) == 3:
+		plaintext, err = kmsOpen(parts[1], parts[2])
+	case parts[0] == MethodKeychain && len(parts) == 2:
+		var key []byte
+		if key, err = keychainKey(false); err == nil {
+			plaintext, err = gcmOpen(key, parts[1])
+		}
+	default:
+		return "", fmt.Errorf("unknown encryption method '%s' (must be %s or %s)", parts[0], MethodKMS, MethodKeychain)
+	}
+	if err != nil {
+		return "", err
+	}
+	decryptCache[value] = string(plaintext)
+	return string(plaintext), nil
+}
+
+// encryptValue encrypts one plaintext value.
+func encryptValue(plaintext string, options EncryptOptions) (string, error) {
+	switch options.Method {
+	case MethodKMS:
+		if options.KMSKey == "" {
+			return "", fmt.Errorf("a KMS key is required to encrypt with kms")
+		}
+		sess, err := kmsSession(options.Region, options.Profile)
+		if err != nil {
+			return "", err
+		}
+		dataKey, err := kms.New(sess).GenerateDataKey(&kms.GenerateDataKeyInput{
+			KeyId:             aws.String(options.KMSKey),
+			KeySpec:           aws.String(kms.DataKeySpecAes256),
+			EncryptionContext: kmsEncryptionContext,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to generate a data key with %s: %w", options.KMSKey, err)
+		}
+		sealed, err := gcmSeal(dataKey.Plaintext, []byte(plaintext))
+		if err != nil {
+			return "", err
+		}
+		var envelope bytes.Buffer
+		binary.Write(&envelope, binary.BigEndian, uint16(len(dataKey.CiphertextBlob)))
+		envelope.Write(dataKey.CiphertextBlob)
+		envelope.Write(sealed)
+		return encryptedPrefix + MethodKMS + ":" + aws.StringValue(sess.Config.Region) + ":" +
+			base64.StdEncoding.EncodeToString(envelope.Bytes()), nil
+	case MethodKeychain:
+		key, err := keychainKey(true)
+		if err != nil {
+			return "", err
+		}
+		sealed, err := gcmSeal(key, []byte(plaintext))
+		if err != nil {
+			return "", err
+		}
+		return encryptedPrefix + MethodKeychain + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+	}
+	return "", fmt.Errorf("unknown encryption method '%s' (must be %s or %s)", options.Method, MethodKMS, MethodKeychain)
+}
+
+func kmsSession(region, profile string) (*session.Session, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+		Profile:           profile,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %w", err)
+	}
+	if region != "" {
+		sess.Config.Region = aws.String(region)
+	}
+	if aws.StringValue(sess.Config.Region) == "" {
+		return nil, fmt.Errorf("no AWS region configured for the KMS key")
+	}
+	return apicalls.InstrumentSession(sess), nil
+}
+
+// kmsOpen decrypts the data key of an envelope with KMS and opens the value
+// with it. The credentials come from the default chain or $AWS_PROFILE.
+func kmsOpen(region, encoded string) ([]byte, error) {
+	envelope, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(envelope) < 2 {
+		return nil, fmt.Errorf("malformed kms envelope")
+	}
+	keyLength := int(binary.BigEndian.Uint16(envelope))
+	if len(envelope) < 2+keyLength {
+		return nil, fmt.Errorf("malformed kms envelope")
+	}
+	sess, err := kmsSession(region, "")
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := kms.New(sess).Decrypt(&kms.DecryptInput{
+		CiphertextBlob:    envelope[2 : 2+keyLength],
+		EncryptionContext: kmsEncryptionContext,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the data key with KMS: %w", err)
+	}
+	return gcmOpenRaw(dataKey.Plaintext, envelope[2+keyLength:])
+}
+
+// gcmSeal encrypts with AES-GCM and returns the nonce followed by the
+// ciphertext.
+func gcmSeal(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func gcmOpen(key []byte, encoded string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("malformed keychain value")
+	}
+	return gcmOpenRaw(key, sealed)
+}
+
+func gcmOpenRaw(key, sealed []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("wrong key or tampered value")
+	}
+	return plaintext, nil
+}
+
+// keychainKey reads the config key from the OS keychain: the login
+// keychain through security on macOS, the Secret Service through
+// secret-tool on Linux. With create, a missing key is generated and stored.
+func keychainKey(create bool) ([]byte, error) {
+	if keychainCache != nil {
+		return keychainCache, nil
+	}
+	var lookup *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		lookup = exec.Command("security", "find-generic-password", "-s", keychainService, "-a", keychainAccount, "-w")
+	case "linux":
+		lookup = exec.Command("secret-tool", "lookup", "service", keychainService, "account", keychainAccount)
+	default:
+		return nil, fmt.Errorf("no supported OS keychain on %s, use kms instead", runtime.GOOS)
+	}
+	output, err := lookup.Output()
+	if err == nil && len(bytes.TrimSpace(output)) > 0 {
+		key, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(output)))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("the config key in the keychain (%s/%s) is malformed", keychainService, keychainAccount)
+		}
+		keychainCache = key
+		return key, nil
+	}
+	var execErr *exec.Error
+	if errors.As(err, &execErr) {
+		return nil, fmt.Errorf("%s not found, it is needed to read the OS keychain", lookup.Path)
+	}
+	if !create {
+		return nil, fmt.Errorf("no config key in the keychain (%s/%s); values encrypted on another machine need its key or kms", keychainService, keychainAccount)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(key)
+	var store *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		// -w without a value prompts for the password and its confirmation, so
+		// the key is read from stdin instead of showing up in the process list
+		store = exec.Command("security", "add-generic-password", "-U", "-s", keychainService, "-a", keychainAccount, "-w")
+		store.Stdin = strings.NewReader(encoded + "\n" + encoded + "\n")
+		detachFromTerminal(store)
+	} else {
+		store = exec.Command("secret-tool", "store", "--label=swissarmycli config key", "service", keychainService, "account", keychainAccount)
+		store.Stdin = strings.NewReader(encoded)
+	}
+	if output, err := store.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to store the config key in the keychain: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	keychainCache = key
+	return key, nil
+}
+
+// EncryptFields encrypts the values at the given dotted paths of the config
+// file in place, e.g. ownership.contacts.payments. A path to a mapping or
+// list encrypts every string under it. Values already encrypted are left
+// alone. It returns the paths of the values it encrypted.
+func EncryptFields(paths []string, options EncryptOptions) ([]string, error) {
+	return rewriteFields(paths, func(node *yaml.Node) (bool, error) {
+		if IsEncrypted(node.Value) || node.Tag != "!!str" {
+			return false, nil
+		}
+		encrypted, err := encryptValue(node.Value, options)
+		if err != nil {
+			return false, err
+		}
+		node.Value, node.Style = encrypted, 0
+		return true, nil
+	})
+}
+
+// DecryptFields writes the plaintext of the encrypted values at the given
+// paths back into the config file. It returns the paths it decrypted.
+func DecryptFields(paths []string) ([]string, error) {
+	return rewriteFields(paths, func(node *yaml.Node) (bool, error) {
+		if !IsEncrypted(node.Value) {
+			return false, nil
+		}
+		plaintext, err := decryptValue(node.Value)
+		if err != nil {
+			return false, err
+		}
+		node.Value, node.Style = plaintext, 0
+		return true, nil
+	})
+}
+
+// rewriteFields applies change to every scalar under the given paths of the
+// config file and writes the file back, keeping its comments.
+func rewriteFields(paths []string, change func(*yaml.Node) (bool, error)) ([]string, error) {
+	path := Path()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file '%s': %w", path, err)
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("invalid config file '%s': %w", path, err)
+	}
+	if len(document.Content) == 0 {
+		return nil, fmt.Errorf("config file '%s' is empty", path)
+	}
+
+	var changed []string
+	for _, fieldPath := range paths {
+		node := document.Content[0]
+		for _, key := range strings.Split(fieldPath, ".") {
+			node = mappingValue(node, key)
+			if node == nil {
+				return nil, fmt.Errorf("%s not found in the config file", fieldPath)
+			}
+		}
+		err := walkScalars(node, fieldPath, func(scalar *yaml.Node, scalarPath string) error {
+			ok, err := change(scalar)
+			if err != nil {
+				return fmt.Errorf("%s: %w", scalarPath, err)
+			}
+			if ok {
+				changed = append(changed, scalarPath)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, fmt.Errorf("failed to encode config file: %w", err)
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write config file '%s': %w", path, err)
+	}
+	// WriteFile keeps the mode of an existing file
+	if err := os.Chmod(path, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to restrict config file '%s': %w", path, err)
+	}
+	return changed, nil
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func walkScalars(node *yaml.Node, path string, fn func(*yaml.Node, string) error) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return fn(node, path)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := walkScalars(node.Content[i+1], joinPath(path, node.Content[i].Value), fn); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			if err := walkScalars(child, fmt.Sprintf("%s[%d]", path, i), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
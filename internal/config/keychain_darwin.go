@@ -0,0 +1,13 @@
+package config
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detachFromTerminal starts cmd in a new session without a controlling
+// terminal, so security reads the password prompts from stdin rather than
+// from /dev/tty.
+func detachFromTerminal(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
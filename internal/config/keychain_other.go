@@ -0,0 +1,8 @@
+//go:build !darwin
+
+package config
+
+import "os/exec"
+
+// detachFromTerminal is only needed for the macOS keychain.
+func detachFromTerminal(cmd *exec.Cmd) {}
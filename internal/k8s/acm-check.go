@@ -0,0 +1,307 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const albCertificateAnnotation = "alb.ingress.kubernetes.io/certificate-arn"
+
+// ACMCheckOptions contains options for the ACM certificate cross-check
+type ACMCheckOptions struct {
+	Region  string
+	Profile string
+	Output  string // table or json
+	FailOn  string // Lowest severity that fails the run: error, warning, info or none
+}
+
+// ingressTLS records how a single Ingress terminates TLS
+type ingressTLS struct {
+	name           string // namespace/name
+	loadBalancerID string
+	secrets        []string // namespace/name of referenced TLS secrets
+	certArns       []string // ACM certificates from the ALB annotation
+}
+
+// CheckACMCertificates lists ACM certificates with their expiry and validation
+// status, shows which cluster Ingresses they serve through load balancers and
+// flags in-cluster TLS secrets that duplicate or contradict them.
+func CheckACMCertificates(options ACMCheckOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	sess, err := awsutils.NewSession(options.Profile, options.Region)
+	if err != nil {
+		return err
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	if options.Output != "json" {
+		fmt.Println("Fetching ACM certificates and load balancers...")
+	}
+	certificates, err := awsutils.ListACMCertificates(sess)
+	if err != nil {
+		return err
+	}
+	loadBalancerIDs, err := awsutils.LoadBalancerIDsByDNS(sess)
+	if err != nil {
+		return err
+	}
+
+	ingresses, err := clientset.NetworkingV1().Ingresses("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list ingresses: %w", err)
+	}
+
+	var ingressInfos []ingressTLS
+	for _, ingress := range ingresses.Items {
+		info := ingressTLS{name: ingress.Namespace + "/" + ingress.Name}
+		for _, lb := range ingress.Status.LoadBalancer.Ingress {
+			if id, ok := loadBalancerIDs[strings.ToLower(lb.Hostname)]; ok {
+				info.loadBalancerID = id
+			}
+		}
+		for _, tls := range ingress.Spec.TLS {
+			if tls.SecretName != "" {
+				info.secrets = append(info.secrets, ingress.Namespace+"/"+tls.SecretName)
+			}
+		}
+		for _, certArn := range strings.Split(ingress.Annotations[albCertificateAnnotation], ",") {
+			if certArn = strings.TrimSpace(certArn); certArn != "" {
+				info.certArns = append(info.certArns, certArn)
+			}
+		}
+		ingressInfos = append(ingressInfos, info)
+	}
+
+	now := time.Now()
+	sort.Slice(certificates, func(i, j int) bool {
+		return certificates[i].NotAfter.Before(certificates[j].NotAfter)
+	})
+
+	var findings []result.Finding
+	expiring, unused, notIssued := 0, 0, 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if options.Output != "json" {
+		fmt.Printf("\n=== ACM Certificates (%d) ===\n", len(certificates))
+		fmt.Fprintln(w, "DOMAIN\tTYPE\tSTATUS\tVALIDATION\tEXPIRES\tDAYS LEFT\tIN USE BY\tINGRESSES\tFLAGS")
+	}
+	for _, certificate := range certificates {
+		addFinding := func(check, severity, message string) {
+			findings = append(findings, result.Finding{
+				Check: check, Severity: severity, Resource: certificate.Arn, Message: message,
+				Details: map[string]string{"domain": certificate.DomainName, "type": certificate.Type},
+			})
+		}
+		var served []string
+		for _, info := range ingressInfos {
+			if ingressUsesCertificate(info, certificate) {
+				served = append(served, info.name)
+			}
+		}
+
+		var flags []string
+		daysLeft := "-"
+		expires := "-"
+		if !certificate.NotAfter.IsZero() {
+			days := int(certificate.NotAfter.Sub(now).Hours() / 24)
+			daysLeft = fmt.Sprintf("%d", days)
+			expires = certificate.NotAfter.Format("2006-01-02")
+			if days <= certExpiryWarningDays {
+				expiring++
+				flags = append(flags, "expiring")
+				addFinding("acm-expiring", result.SeverityWarning, fmt.Sprintf("%s expires in %d days", certificate.DomainName, days))
+			}
+		}
+		if certificate.Status != "ISSUED" {
+			notIssued++
+			flags = append(flags, strings.ToLower(certificate.Status))
+			addFinding("acm-not-issued", result.SeverityError, fmt.Sprintf("%s is %s", certificate.DomainName, certificate.Status))
+		}
+		if certificate.RenewalStatus == "FAILED" {
+			flags = append(flags, "renewal failed")
+			addFinding("acm-renewal-failed", result.SeverityError, fmt.Sprintf("managed renewal of %s failed", certificate.DomainName))
+		}
+		if len(certificate.InUseBy) == 0 {
+			unused++
+			flags = append(flags, "unused")
+			addFinding("acm-unused", result.SeverityInfo, fmt.Sprintf("%s is not attached to any resource", certificate.DomainName))
+		}
+		if options.Output == "json" {
+			continue
+		}
+
+		flagText := "✅"
+		if len(flags) > 0 {
+			flagText = "⚠️  " + strings.Join(flags, ", ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			certificate.DomainName, certificate.Type, certificate.Status, valueOrDash(certificate.ValidationStatus),
+			expires, daysLeft, len(certificate.InUseBy), valueOrDash(strings.Join(served, ", ")), flagText)
+	}
+	if options.Output != "json" {
+		w.Flush()
+	}
+
+	conflicts, err := printTLSSecretOverlap(clientset, certificates, ingressInfos, options.Output, &findings)
+	if err != nil {
+		return err
+	}
+	if options.Output == "json" {
+		if err := result.New("acm-check", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	fmt.Println("\n--- ACM Check Summary ---")
+	fmt.Printf("ACM certificates: %d\n", len(certificates))
+	fmt.Printf("Expiring within %d days: %d\n", certExpiryWarningDays, expiring)
+	fmt.Printf("Not issued: %d\n", notIssued)
+	fmt.Printf("Not attached to any resource: %d\n", unused)
+	fmt.Printf("TLS secrets duplicating or contradicting ACM: %d\n", conflicts)
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// printTLSSecretOverlap lists in-cluster TLS secrets covering the same names as
+// an ACM certificate, adds them to findings and returns how many were found.
+func printTLSSecretOverlap(clientset *kubernetes.Clientset, certificates []awsutils.ACMCertificate, ingressInfos []ingressTLS, output string, findings *[]result.Finding) (int, error) {
+	secretList, err := clientset.CoreV1().Secrets("").List(context.TODO(), metav1.ListOptions{FieldSelector: "type=kubernetes.io/tls"})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list TLS secrets: %w", err)
+	}
+
+	type overlap struct {
+		secret  string
+		names   string
+		expires string
+		acmCert string
+		finding string
+	}
+	var overlaps []overlap
+
+	for i := range secretList.Items {
+		secret := &secretList.Items[i]
+		certData, _ := findCertData(secret)
+		if certData == nil {
+			continue
+		}
+		cert, err := parsePEMCertificate(certData)
+		if err != nil {
+			continue
+		}
+		names := cert.DNSNames
+		if len(names) == 0 && cert.Subject.CommonName != "" {
+			names = []string{cert.Subject.CommonName}
+		}
+		secretKey := secret.Namespace + "/" + secret.Name
+
+		for _, certificate := range certificates {
+			if !namesOverlap(names, certificate.Names) {
+				continue
+			}
+
+			finding := "duplicates ACM certificate"
+			for _, info := range ingressInfos {
+				if containsString(info.secrets, secretKey) && ingressUsesCertificate(info, certificate) {
+					finding = fmt.Sprintf("contradicts ACM: %s terminates TLS at the load balancer, secret is not served", info.name)
+					break
+				}
+			}
+			if cert.NotAfter.Before(time.Now()) {
+				finding += " (secret expired)"
+			} else if !certificate.NotAfter.IsZero() && cert.NotAfter.Before(certificate.NotAfter) {
+				finding += " (secret expires first)"
+			}
+
+			overlaps = append(overlaps, overlap{
+				secret:  secretKey,
+				names:   strings.Join(names, ","),
+				expires: cert.NotAfter.Format("2006-01-02"),
+				acmCert: certificate.DomainName + " (" + certificate.Arn[strings.LastIndex(certificate.Arn, "/")+1:] + ")",
+				finding: finding,
+			})
+			*findings = append(*findings, result.Finding{
+				Check: "tls-secret-overlap", Severity: result.SeverityWarning, Resource: "Secret/" + secretKey, Message: finding,
+				Details: map[string]string{"acmCertificate": certificate.Arn, "expires": cert.NotAfter.Format("2006-01-02")},
+			})
+		}
+	}
+
+	if output == "json" {
+		return len(overlaps), nil
+	}
+	fmt.Printf("\n=== In-Cluster TLS Secrets Overlapping ACM (%d) ===\n", len(overlaps))
+	if len(overlaps) == 0 {
+		return 0, nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SECRET\tDNS NAMES\tEXPIRES\tACM CERTIFICATE\tFINDING")
+	for _, o := range overlaps {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t⚠️  %s\n", o.secret, o.names, o.expires, o.acmCert, o.finding)
+	}
+	w.Flush()
+	return len(overlaps), nil
+}
+
+// ingressUsesCertificate reports whether the Ingress is served by the ACM
+// certificate, either through its ALB annotation or because ACM lists the
+// Ingress's load balancer as a user of the certificate.
+func ingressUsesCertificate(info ingressTLS, certificate awsutils.ACMCertificate) bool {
+	if containsString(info.certArns, certificate.Arn) {
+		return true
+	}
+	return info.loadBalancerID != "" && awsutils.CertificateUsedBy(certificate, info.loadBalancerID)
+}
+
+// namesOverlap reports whether any name in a is covered by a name in b or
+// vice versa, honouring single-label wildcards.
+func namesOverlap(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if hostMatches(x, y) || hostMatches(y, x) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hostMatches reports whether host is covered by pattern, which may be a
+// wildcard such as *.example.com.
+func hostMatches(pattern, host string) bool {
+	pattern, host = strings.ToLower(pattern), strings.ToLower(host)
+	if pattern == host {
+		return true
+	}
+	if !strings.HasPrefix(pattern, "*.") {
+		return false
+	}
+	dot := strings.Index(host, ".")
+	return dot > 0 && host[dot:] == pattern[1:]
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
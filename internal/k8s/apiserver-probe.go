@@ -0,0 +1,426 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// Response headers set by API Priority and Fairness on every request it
+// classified, rejected ones included
+const (
+	apfFlowSchemaHeader    = "X-Kubernetes-PF-FlowSchema-UID"
+	apfPriorityLevelHeader = "X-Kubernetes-PF-PriorityLevel-UID"
+)
+
+// apfRejectedMetric counts the requests API Priority and Fairness rejected
+const apfRejectedMetric = "apiserver_flowcontrol_rejected_requests_total"
+
+// probeResources are the resources apiserver-probe can list
+var probeResources = map[string]schema.GroupVersionResource{
+	"pods":           {Version: "v1", Resource: "pods"},
+	"configmaps":     {Version: "v1", Resource: "configmaps"},
+	"services":       {Version: "v1", Resource: "services"},
+	"events":         {Version: "v1", Resource: "events"},
+	"nodes":          {Version: "v1", Resource: "nodes"},
+	"namespaces":     {Version: "v1", Resource: "namespaces"},
+	"endpointslices": {Group: "discovery.k8s.io", Version: "v1", Resource: "endpointslices"},
+	"deployments":    {Group: "apps", Version: "v1", Resource: "deployments"},
+	"replicasets":    {Group: "apps", Version: "v1", Resource: "replicasets"},
+}
+
+// APIServerProbeOptions contains options for the API server probe
+type APIServerProbeOptions struct {
+	Namespace   string  // All namespaces when empty
+	Resource    string  // One of probeResources
+	PageSizes   []int64 // Limits of the list benchmarks, 0 lists without one
+	Iterations  int     // Runs of each benchmark
+	Gets        int     // Objects fetched by name in the get benchmark
+	Concurrency int     // Runs in flight at once
+	QPS         float32 // Client-side rate limit
+	Burst       int
+}
+
+// probeResult is the outcome of one benchmark
+type probeResult struct {
+	name       string
+	objects    int             // Objects returned by one run
+	requests   []time.Duration // HTTP round trips, sorted
+	operations []time.Duration // Whole runs including throttling and retries, sorted
+	failed     int
+	err        error
+}
+
+// probeStats records every HTTP round trip the probe makes
+type probeStats struct {
+	mu             sync.Mutex
+	latencies      []time.Duration // Since the last take
+	all            []time.Duration
+	statuses       map[int]int
+	tooManyReqs    int // 429 responses
+	apfRejected    int // 429 responses classified by priority and fairness
+	flowSchemas    map[string]int
+	priorityLevels map[string]int
+}
+
+// probeTransport times the requests of one client into the shared stats
+type probeTransport struct {
+	next  http.RoundTripper
+	stats *probeStats
+}
+
+func (t *probeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	t.stats.record(resp, time.Since(start))
+	return resp, nil
+}
+
+func (s *probeStats) record(resp *http.Response, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latencies = append(s.latencies, elapsed)
+	s.all = append(s.all, elapsed)
+	s.statuses[resp.StatusCode]++
+	flowSchema, priorityLevel := resp.Header.Get(apfFlowSchemaHeader), resp.Header.Get(apfPriorityLevelHeader)
+	if flowSchema != "" {
+		s.flowSchemas[flowSchema]++
+		s.priorityLevels[priorityLevel]++
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		s.tooManyReqs++
+		if flowSchema != "" {
+			s.apfRejected++
+		}
+	}
+}
+
+// take returns the round trips since the last call, sorted.
+func (s *probeStats) take() []time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	latencies := s.latencies
+	s.latencies = nil
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	return latencies
+}
+
+// probeRateLimiter is client-go's token bucket, timing how long requests
+// wait on it
+type probeRateLimiter struct {
+	flowcontrol.RateLimiter
+	mu        sync.Mutex
+	throttled int
+	waited    time.Duration
+}
+
+func (l *probeRateLimiter) Wait(ctx context.Context) error {
+	start := time.Now()
+	err := l.RateLimiter.Wait(ctx)
+	if waited := time.Since(start); waited > time.Millisecond {
+		l.mu.Lock()
+		l.throttled++
+		l.waited += waited
+		l.mu.Unlock()
+	}
+	return err
+}
+
+// ProbeAPIServer measures how fast the API server answers list and get
+// requests of the current context. Lists run with each page size, following
+// continue tokens to the end, and gets fetch objects by name. Every HTTP
+// round trip is timed, and the time requests spend on the client-side rate
+// limiter and the 429s the server answers with, API Priority and Fairness
+// rejections among them, are reported separately, so that "kubectl feels
+// slow" can be traced to the client, the server's fairness queues or the
+// server itself.
+func ProbeAPIServer(options APIServerProbeOptions) error {
+	gvr, ok := probeResources[options.Resource]
+	if !ok {
+		return fmt.Errorf("unsupported resource %q, use one of %s", options.Resource, strings.Join(sortedKeys(probeResources), ", "))
+	}
+	if options.Iterations < 1 || options.Concurrency < 1 {
+		return fmt.Errorf("--iterations and --concurrency must be at least 1")
+	}
+	namespace := options.Namespace
+	if gvr.Resource == "nodes" || gvr.Resource == "namespaces" {
+		namespace = ""
+	}
+
+	config, err := common.GetRESTConfig()
+	if err != nil {
+		return err
+	}
+	limiter := &probeRateLimiter{RateLimiter: flowcontrol.NewTokenBucketRateLimiter(options.QPS, options.Burst)}
+	config.RateLimiter = limiter
+	stats := &probeStats{statuses: make(map[int]int), flowSchemas: make(map[string]int), priorityLevels: make(map[string]int)}
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &probeTransport{next: rt, stats: stats}
+	})
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating dynamic client: %w", err)
+	}
+	ctx := context.TODO()
+	resources := dynamicClient.Resource(gvr).Namespace(namespace)
+
+	scope := "all namespaces"
+	if namespace != "" {
+		scope = "namespace " + namespace
+	}
+	fmt.Printf("Probing %s: %s in %s, %d iterations, concurrency %d, client rate limit %g qps / burst %d\n",
+		config.Host, options.Resource, scope, options.Iterations, options.Concurrency, options.QPS, options.Burst)
+
+	var results []probeResult
+	run := func(name string, count int, op func(i int) (int, error)) {
+		fmt.Printf("  %s...\n", name)
+		stats.take()
+		result := probeBenchmark(name, count, options.Concurrency, op)
+		result.requests = stats.take()
+		results = append(results, result)
+	}
+
+	run("version", options.Iterations, func(int) (int, error) {
+		_, err := clientset.Discovery().ServerVersion()
+		return 0, err
+	})
+
+	type objectRef struct{ namespace, name string }
+	var refs []objectRef
+	var refsMu sync.Mutex
+	for _, pageSize := range options.PageSizes {
+		name := fmt.Sprintf("list limit=%d", pageSize)
+		if pageSize == 0 {
+			name = "list no limit"
+		}
+		run(name, options.Iterations, func(int) (int, error) {
+			objects, continueToken := 0, ""
+			for {
+				list, err := resources.List(ctx, metav1.ListOptions{Limit: pageSize, Continue: continueToken})
+				if err != nil {
+					return objects, err
+				}
+				objects += len(list.Items)
+				refsMu.Lock()
+				for _, item := range list.Items {
+					if len(refs) < options.Gets {
+						refs = append(refs, objectRef{item.GetNamespace(), item.GetName()})
+					}
+				}
+				refsMu.Unlock()
+				continueToken = list.GetContinue()
+				if continueToken == "" {
+					return objects, nil
+				}
+			}
+		})
+	}
+
+	if len(refs) > 0 {
+		run(fmt.Sprintf("get by name (%d objects)", len(refs)), options.Iterations*len(refs), func(i int) (int, error) {
+			ref := refs[i%len(refs)]
+			_, err := dynamicClient.Resource(gvr).Namespace(ref.namespace).Get(ctx, ref.name, metav1.GetOptions{})
+			return 1, err
+		})
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BENCHMARK\tRUNS\tOBJECTS\tREQUESTS\tREQUEST p50\tREQUEST p95\tRUN p50\tRUN p95\tERRORS")
+	for _, result := range results {
+		requestP50, requestP95, runP50, runP95 := "-", "-", "-", "-"
+		if len(result.requests) > 0 {
+			requestP50, requestP95 = formatLatency(percentile(result.requests, 50)), formatLatency(percentile(result.requests, 95))
+		}
+		if len(result.operations) > 0 {
+			runP50, runP95 = formatLatency(percentile(result.operations, 50)), formatLatency(percentile(result.operations, 95))
+		}
+		objects := "-"
+		if result.name != "version" {
+			objects = strconv.Itoa(result.objects)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%s\t%s\t%s\t%s\t%d\n", result.name, len(result.operations)+result.failed, objects,
+			len(result.requests), requestP50, requestP95, runP50, runP95, result.failed)
+	}
+	w.Flush()
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Printf("❌ %s: %v\n", result.name, result.err)
+		}
+	}
+
+	all := append([]time.Duration(nil), stats.all...)
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+
+	fmt.Println("\n--- API Server Probe Summary ---")
+	if len(all) > 0 {
+		fmt.Printf("Requests: %d, p50 %s, p95 %s, max %s\n", len(all),
+			formatLatency(percentile(all, 50)), formatLatency(percentile(all, 95)), formatLatency(all[len(all)-1]))
+		var codes []string
+		for _, code := range sortedStatusCodes(stats.statuses) {
+			codes = append(codes, fmt.Sprintf("%d×%d", code, stats.statuses[code]))
+		}
+		fmt.Printf("Status codes: %s\n", strings.Join(codes, ", "))
+	}
+	if limiter.throttled > 0 {
+		fmt.Printf("⚠️  Client-side throttling: %d of %d requests waited on the rate limiter, %s in total; raise --qps/--burst to take it out\n",
+			limiter.throttled, len(all), limiter.waited.Round(time.Millisecond))
+	} else {
+		fmt.Println("✅ No client-side throttling")
+	}
+	if stats.tooManyReqs > 0 {
+		fmt.Printf("❌ Server-side throttling: %d responses were 429 Too Many Requests, %d of them rejected by API Priority and Fairness\n",
+			stats.tooManyReqs, stats.apfRejected)
+	} else {
+		fmt.Println("✅ No 429 Too Many Requests from the API server")
+	}
+	printAPFClassification(ctx, stats)
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// probeBenchmark runs op count times on concurrency workers. op returns the
+// number of objects it got back.
+func probeBenchmark(name string, count, concurrency int, op func(i int) (int, error)) probeResult {
+	result := probeResult{name: name}
+	var mu sync.Mutex
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				start := time.Now()
+				objects, err := op(i)
+				elapsed := time.Since(start)
+				mu.Lock()
+				if err != nil {
+					result.failed++
+					if result.err == nil {
+						result.err = err
+					}
+				} else {
+					result.operations = append(result.operations, elapsed)
+					result.objects = objects
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < count; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	sort.Slice(result.operations, func(i, j int) bool { return result.operations[i] < result.operations[j] })
+	return result
+}
+
+// printAPFClassification shows the flow schemas and priority levels the
+// probe's requests were classified into, and the rejections the API server
+// counted per priority level since it started. Names and metrics are read
+// with a separate client, so they don't count towards the benchmarks; both
+// are skipped quietly when RBAC doesn't allow them.
+func printAPFClassification(ctx context.Context, stats *probeStats) {
+	if len(stats.flowSchemas) == 0 {
+		fmt.Println("API Priority and Fairness: not enabled, or requests are exempt")
+		return
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return
+	}
+	flowSchemaNames := make(map[string]string)
+	if flowSchemas, err := clientset.FlowcontrolV1().FlowSchemas().List(ctx, metav1.ListOptions{}); err == nil {
+		for _, flowSchema := range flowSchemas.Items {
+			flowSchemaNames[string(flowSchema.UID)] = flowSchema.Name
+		}
+	}
+	priorityLevelNames := make(map[string]string)
+	if priorityLevels, err := clientset.FlowcontrolV1().PriorityLevelConfigurations().List(ctx, metav1.ListOptions{}); err == nil {
+		for _, priorityLevel := range priorityLevels.Items {
+			priorityLevelNames[string(priorityLevel.UID)] = priorityLevel.Name
+		}
+	}
+	describe := func(counts map[string]int, names map[string]string) string {
+		var parts []string
+		for _, uid := range sortedKeys(counts) {
+			name := names[uid]
+			if name == "" {
+				name = uid
+			}
+			parts = append(parts, fmt.Sprintf("%s (%d)", name, counts[uid]))
+		}
+		return strings.Join(parts, ", ")
+	}
+	fmt.Printf("Flow schemas: %s\n", describe(stats.flowSchemas, flowSchemaNames))
+	fmt.Printf("Priority levels: %s\n", describe(stats.priorityLevels, priorityLevelNames))
+
+	rejections, err := apfRejections(ctx, clientset)
+	if err != nil {
+		fmt.Printf("Server-side rejection counters unavailable: %v\n", err)
+		return
+	}
+	if len(rejections) == 0 {
+		fmt.Println("✅ The API server has not rejected any request since it started")
+		return
+	}
+	fmt.Println("⚠️  Requests rejected by the API server since it started (one replica's counters):")
+	for _, key := range sortedKeys(rejections) {
+		fmt.Printf("   %s: %.0f\n", key, rejections[key])
+	}
+}
+
+var promLabelPattern = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// apfRejections sums apiserver_flowcontrol_rejected_requests_total of the
+// API server's /metrics per priority level and reason.
+func apfRejections(ctx context.Context, clientset *kubernetes.Clientset) (map[string]float64, error) {
+	raw, err := clientset.CoreV1().RESTClient().Get().AbsPath("/metrics").DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /metrics: %w", err)
+	}
+	totals := make(map[string]float64)
+	for _, line := range strings.Split(string(raw), "\n") {
+		if !strings.HasPrefix(line, apfRejectedMetric+"{") {
+			continue
+		}
+		closing := strings.LastIndex(line, "}")
+		if closing < 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(line[closing+1:]), 64)
+		if err != nil || value == 0 {
+			continue
+		}
+		labels := make(map[string]string)
+		for _, match := range promLabelPattern.FindAllStringSubmatch(line[:closing], -1) {
+			labels[match[1]] = match[2]
+		}
+		totals[fmt.Sprintf("%s (%s)", labels["priority_level"], labels["reason"])] += value
+	}
+	return totals, nil
+}
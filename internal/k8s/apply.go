@@ -0,0 +1,415 @@
+package k8s
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// DefaultFieldManager is the field manager apply owns its fields as
+const DefaultFieldManager = "swissarmycli"
+
+// applyFirstKinds are applied before everything else, so the namespaces
+// and custom resource types the other objects need exist
+var applyFirstKinds = []string{"Namespace", "CustomResourceDefinition"}
+
+// ApplyOptions contains options for applying manifests
+type ApplyOptions struct {
+	Namespace    string // For namespaced objects that don't set one
+	FieldManager string
+	Force        bool // Take over fields owned by other field managers
+	Wait         bool // Wait for applied workloads to roll out
+	Timeout      time.Duration
+	DryRun       bool   // Server-side dry run
+	Output       string // table or json
+}
+
+// ApplyResult is what happened to one object of the manifests
+type ApplyResult struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Source    string `json:"source"`
+	Action    string `json:"action"`           // created, configured, unchanged or failed
+	Ready     string `json:"ready,omitempty"`  // ready, timed out or failed for workloads waited on
+	Waited    string `json:"waited,omitempty"` // How long the workload took to become ready
+	Progress  string `json:"progress,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// applyObject is one document of the manifests
+type applyObject struct {
+	object *unstructured.Unstructured
+	source string
+}
+
+// Apply server-side applies every manifest under the paths, then waits
+// until the Deployments, StatefulSets and DaemonSets it applied have rolled
+// out and its Jobs have completed, printing the progress of all of them in
+// one place. It is kubectl apply --server-side followed by kubectl rollout
+// status for each workload, in one step.
+func Apply(paths []string, options ApplyOptions) error {
+	if options.Output != "table" && options.Output != "json" {
+		return fmt.Errorf("invalid output format '%s' (must be table or json)", options.Output)
+	}
+	if options.FieldManager == "" {
+		options.FieldManager = DefaultFieldManager
+	}
+	objects, err := readApplyObjects(paths)
+	if err != nil {
+		return err
+	}
+	if len(objects) == 0 {
+		return fmt.Errorf("no manifests found in %s", strings.Join(paths, ", "))
+	}
+
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	dynamicClient, err := common.GetDynamicClient()
+	if err != nil {
+		return err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(clientset.Discovery()))
+
+	results := make([]*ApplyResult, len(objects))
+	var failed int
+	for i, item := range objects {
+		result, err := applyManifestObject(dynamicClient, mapper, item, options)
+		if err != nil {
+			result.Action = "failed"
+			result.Error = err.Error()
+			failed++
+		}
+		results[i] = result
+		if options.Output == "table" {
+			line := fmt.Sprintf("%s %s", strings.ToLower(result.Kind), objectRef(result.Namespace, result.Name))
+			if err != nil {
+				fmt.Printf("❌ %s: %v\n", line, err)
+			} else {
+				fmt.Printf("%s %s\n", line, result.Action)
+			}
+		}
+	}
+
+	var timedOut int
+	if options.Wait && !options.DryRun {
+		timedOut = waitForApplied(clientset, results, options)
+	}
+
+	if options.Output == "json" {
+		encoder := json.NewEncoder(sink.Output())
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			return err
+		}
+	} else {
+		printApplyResults(results, options)
+	}
+	if failed > 0 || timedOut > 0 {
+		return fmt.Errorf("%d objects failed to apply, %d workloads did not become ready", failed, timedOut)
+	}
+	return nil
+}
+
+// applyManifestObject server-side applies one object. It returns the result
+// even on error, so the object can be reported.
+func applyManifestObject(dynamicClient dynamic.Interface, mapper *restmapper.DeferredDiscoveryRESTMapper, item applyObject, options ApplyOptions) (*ApplyResult, error) {
+	object := item.object
+	result := &ApplyResult{Kind: object.GetKind(), Namespace: object.GetNamespace(), Name: object.GetName(), Source: item.source}
+	gvk := object.GroupVersionKind()
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		// The type may come from a CustomResourceDefinition applied just now
+		mapper.Reset()
+		mapping, err = mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	if err != nil {
+		return result, fmt.Errorf("unknown resource type %s: %w", gvk, err)
+	}
+
+	var resources dynamic.ResourceInterface = dynamicClient.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if result.Namespace == "" {
+			result.Namespace = options.Namespace
+			object.SetNamespace(options.Namespace)
+		}
+		resources = dynamicClient.Resource(mapping.Resource).Namespace(result.Namespace)
+	} else {
+		result.Namespace = ""
+	}
+
+	ctx := context.TODO()
+	existing, err := resources.Get(ctx, result.Name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return result, fmt.Errorf("failed to get: %w", err)
+	}
+	data, err := json.Marshal(object.Object)
+	if err != nil {
+		return result, fmt.Errorf("failed to encode: %w", err)
+	}
+	patchOptions := metav1.PatchOptions{FieldManager: options.FieldManager, Force: &options.Force}
+	if options.DryRun {
+		patchOptions.DryRun = []string{metav1.DryRunAll}
+	}
+	applied, err := resources.Patch(ctx, result.Name, types.ApplyPatchType, data, patchOptions)
+	if err != nil {
+		return result, fmt.Errorf("failed to apply: %w", err)
+	}
+
+	switch {
+	case existing == nil:
+		result.Action = "created"
+	case applied.GetResourceVersion() == existing.GetResourceVersion():
+		result.Action = "unchanged"
+	default:
+		result.Action = "configured"
+	}
+	if options.DryRun {
+		result.Action += " (server dry run)"
+	}
+	return result, nil
+}
+
+// waitForApplied polls the applied workloads until all of them are ready or
+// the timeout passes, printing one progress line per poll. It returns how
+// many did not become ready.
+func waitForApplied(clientset *kubernetes.Clientset, results []*ApplyResult, options ApplyOptions) int {
+	var pending []*ApplyResult
+	for _, result := range results {
+		if result.Error == "" && (isRolloutKind(result.Kind) || result.Kind == "Job") {
+			pending = append(pending, result)
+		}
+	}
+	if len(pending) == 0 {
+		return 0
+	}
+	total := len(pending)
+	if options.Output == "table" {
+		fmt.Printf("\nWaiting for %d workloads (timeout %s)...\n", total, options.Timeout)
+	}
+
+	start := time.Now()
+	deadline := start.Add(options.Timeout)
+	for {
+		var waiting []*ApplyResult
+		var progress []string
+		for _, result := range pending {
+			done, status, err := applyWorkloadReady(clientset, result)
+			result.Progress = status
+			switch {
+			case err != nil:
+				result.Ready = "failed"
+				result.Error = err.Error()
+			case done:
+				result.Ready = "ready"
+				result.Waited = time.Since(start).Round(time.Second).String()
+			default:
+				waiting = append(waiting, result)
+				progress = append(progress, fmt.Sprintf("%s %s (%s)", strings.ToLower(result.Kind), objectRef(result.Namespace, result.Name), status))
+			}
+		}
+		pending = waiting
+		if len(pending) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			for _, result := range pending {
+				result.Ready = "timed out"
+			}
+			break
+		}
+		if options.Output == "table" {
+			fmt.Printf("  [%s] %d/%d ready, waiting for %s\n", time.Since(start).Round(time.Second), total-len(pending), total, strings.Join(progress, ", "))
+		}
+		time.Sleep(5 * time.Second)
+	}
+
+	notReady := 0
+	for _, result := range results {
+		if result.Ready == "timed out" || result.Ready == "failed" {
+			notReady++
+		}
+	}
+	return notReady
+}
+
+// applyWorkloadReady checks one workload like kubectl rollout status, or
+// kubectl wait --for=condition=complete for Jobs. A failed Job is an error.
+func applyWorkloadReady(clientset *kubernetes.Clientset, result *ApplyResult) (bool, string, error) {
+	if result.Kind != "Job" {
+		return rolloutComplete(clientset, restartTarget{strings.ToLower(result.Kind), result.Namespace, result.Name})
+	}
+	job, err := clientset.BatchV1().Jobs(result.Namespace).Get(context.TODO(), result.Name, metav1.GetOptions{})
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get job: %w", err)
+	}
+	completions := int32(1)
+	if job.Spec.Completions != nil {
+		completions = *job.Spec.Completions
+	}
+	progress := fmt.Sprintf("%d/%d succeeded, %d failed", job.Status.Succeeded, completions, job.Status.Failed)
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, progress, nil
+		case batchv1.JobFailed:
+			return false, progress, fmt.Errorf("job failed: %s", condition.Message)
+		}
+	}
+	return false, progress, nil
+}
+
+// printApplyResults prints the results table and summary
+func printApplyResults(results []*ApplyResult, options ApplyOptions) {
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAMESPACE\tNAME\tACTION\tREADY")
+	actions := make(map[string]int)
+	for _, result := range results {
+		actions[result.Action]++
+		ready := "-"
+		switch result.Ready {
+		case "ready":
+			ready = "✅ after " + result.Waited
+		case "timed out":
+			ready = "❌ timed out (" + result.Progress + ")"
+		case "failed":
+			ready = "❌ " + result.Error
+		}
+		action := result.Action
+		if result.Action == "failed" {
+			action = "❌ " + result.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", result.Kind, valueOrDash(result.Namespace), result.Name, action, ready)
+	}
+	w.Flush()
+
+	fmt.Println("\n--- Apply Summary ---")
+	var counts []string
+	for _, action := range sortedKeys(actions) {
+		counts = append(counts, fmt.Sprintf("%s: %d", action, actions[action]))
+	}
+	fmt.Printf("Objects: %d (%s)\n", len(results), strings.Join(counts, ", "))
+	if options.DryRun {
+		fmt.Println("Server dry run, nothing was changed")
+	}
+	fmt.Println("----------------------------------------------------")
+}
+
+// readApplyObjects reads every object from the given files, or from
+// .yaml/.yml/.json files under the given directories, with List items
+// expanded. Namespaces and CustomResourceDefinitions are ordered first, the
+// rest keeps the order of the files.
+func readApplyObjects(paths []string) ([]applyObject, error) {
+	var files []string
+	for _, path := range paths {
+		err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			ext := filepath.Ext(file)
+			if !info.IsDir() && (ext == ".yaml" || ext == ".yml" || ext == ".json") {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read '%s': %w", path, err)
+		}
+	}
+
+	var objects []applyObject
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file '%s': %w", file, err)
+		}
+		reader := utilyaml.NewYAMLReader(bufio.NewReader(f))
+		for {
+			doc, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				f.Close()
+				return nil, fmt.Errorf("invalid YAML in '%s': %w", file, err)
+			}
+			var raw map[string]interface{}
+			if err := sigsyaml.Unmarshal(doc, &raw); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("invalid YAML in '%s': %w", file, err)
+			}
+			if raw == nil {
+				continue
+			}
+			object := &unstructured.Unstructured{Object: raw}
+			if !object.IsList() {
+				objects = append(objects, applyObject{object: object, source: file})
+				continue
+			}
+			err = object.EachListItem(func(item runtime.Object) error {
+				objects = append(objects, applyObject{object: item.(*unstructured.Unstructured), source: file})
+				return nil
+			})
+			if err != nil {
+				f.Close()
+				return nil, fmt.Errorf("invalid List in '%s': %w", file, err)
+			}
+		}
+		f.Close()
+	}
+	for _, item := range objects {
+		if item.object.GetKind() == "" || item.object.GetName() == "" {
+			return nil, fmt.Errorf("an object in '%s' has no kind or name", item.source)
+		}
+	}
+
+	sort.SliceStable(objects, func(i, j int) bool {
+		return containsString(applyFirstKinds, objects[i].object.GetKind()) && !containsString(applyFirstKinds, objects[j].object.GetKind())
+	})
+	return objects, nil
+}
+
+// isRolloutKind reports whether kubectl rollout status supports the kind
+func isRolloutKind(kind string) bool {
+	return kind == "Deployment" || kind == "StatefulSet" || kind == "DaemonSet"
+}
+
+// objectRef renders namespace/name, or the name of cluster-scoped objects
+func objectRef(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
@@ -0,0 +1,328 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// archLabel is the well-known node label of the CPU architecture
+const archLabel = "kubernetes.io/arch"
+
+// Arm64CheckOptions contains options for the Graviton readiness check
+type Arm64CheckOptions struct {
+	Namespace string // All namespaces when empty
+	Parallel  int    // Images inspected at once
+	Profile   string // AWS profile for pulling ECR manifests
+	Output    string // table or json
+	FailOn    string // Lowest severity that fails the run: error, warning, info or none
+}
+
+// archImage is one image running in the cluster and the platforms its
+// registry offers
+type archImage struct {
+	ref       string // What was inspected, the digest the pods run when known
+	image     string // As written in the pod spec
+	platforms []imagePlatform
+	err       error
+}
+
+// hasArm64 reports whether the image can run on Graviton nodes
+func (i *archImage) hasArm64() bool {
+	for _, platform := range i.platforms {
+		if platform.OS == "linux" && platform.Architecture == "arm64" {
+			return true
+		}
+	}
+	return false
+}
+
+// archWorkload is a workload with the images of its containers
+type archWorkload struct {
+	name       string // namespace/kind/name
+	kind       string
+	containers map[string]string // Container, marked (init) for init containers, to image ref
+	pinned     bool              // Scheduling requires a non-arm64 node
+}
+
+// CheckArm64 inspects the manifest of every image the running pods'
+// containers and init containers use, and reports the workloads that
+// can't move to Graviton nodes because an image has no arm64 variant.
+// DaemonSets are errors unless kept off arm64 nodes: their pods land on
+// every node, so they block adding any arm64 node. Workloads whose node selector or affinity keeps
+// them off arm64 nodes are reported too.
+func CheckArm64(options Arm64CheckOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	images, workloads, err := collectArchInventory(options.Namespace)
+	if err != nil {
+		return err
+	}
+	if len(workloads) == 0 {
+		fmt.Println("No running pods found.")
+		return nil
+	}
+	refs := sortedKeys(images)
+	if options.Output != "json" {
+		fmt.Printf("Inspecting %d images...\n", len(refs))
+	}
+
+	registry := newRegistryClient(options.Profile)
+	semaphore := make(chan struct{}, max(options.Parallel, 1))
+	var wg sync.WaitGroup
+	for _, ref := range refs {
+		wg.Add(1)
+		go func(image *archImage) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			image.platforms, image.err = registry.imagePlatforms(image.ref)
+		}(images[ref])
+	}
+	wg.Wait()
+
+	var findings []result.Finding
+	names := sortedKeys(workloads)
+	blocking := make(map[string][]string) // Workload to its containers without arm64
+	unknown := make(map[string][]string)
+	for _, name := range names {
+		workload := workloads[name]
+		for _, container := range sortedKeys(workload.containers) {
+			image := images[workload.containers[container]]
+			switch {
+			case image.err != nil:
+				unknown[name] = append(unknown[name], fmt.Sprintf("%s (%s)", container, image.image))
+			case !image.hasArm64():
+				blocking[name] = append(blocking[name], fmt.Sprintf("%s (%s)", container, image.image))
+			}
+		}
+		if len(blocking[name]) > 0 {
+			severity, message := result.SeverityWarning, "no arm64 image for "
+			if workload.kind == "DaemonSet" && !workload.pinned {
+				severity, message = result.SeverityError, "DaemonSet runs on every node; no arm64 image for "
+			}
+			findings = append(findings, result.Finding{
+				Check:    "arm64-missing",
+				Severity: severity,
+				Resource: name,
+				Message:  message + strings.Join(blocking[name], ", "),
+			})
+		}
+		if workload.pinned {
+			findings = append(findings, result.Finding{
+				Check:    "arm64-pinned",
+				Severity: result.SeverityInfo,
+				Resource: name,
+				Message:  fmt.Sprintf("node selector or affinity on %s excludes arm64 nodes", archLabel),
+			})
+		}
+		if len(unknown[name]) > 0 {
+			findings = append(findings, result.Finding{
+				Check:    "inspect-failed",
+				Severity: result.SeverityInfo,
+				Resource: name,
+				Message:  "could not read the manifest of " + strings.Join(unknown[name], ", "),
+			})
+		}
+	}
+
+	if options.Output == "json" {
+		if err := result.New("arm64-check", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "IMAGE\tARM64\tPLATFORMS")
+	var withArm64, withoutArm64, failed int
+	for _, ref := range refs {
+		image := images[ref]
+		var platforms []string
+		for _, platform := range image.platforms {
+			platforms = append(platforms, platform.String())
+		}
+		status := "✅"
+		switch {
+		case image.err != nil:
+			status, platforms = "⚠️  unknown", []string{image.err.Error()}
+			failed++
+		case !image.hasArm64():
+			status = "❌"
+			withoutArm64++
+		default:
+			withArm64++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", image.image, status, strings.Join(platforms, ", "))
+	}
+	w.Flush()
+
+	if len(blocking) > 0 {
+		fmt.Println("\nWorkloads blocking a Graviton migration:")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "WORKLOAD\tCONTAINERS WITHOUT ARM64")
+		for _, name := range names {
+			if len(blocking[name]) > 0 {
+				fmt.Fprintf(w, "%s\t%s\n", name, strings.Join(blocking[name], ", "))
+			}
+		}
+		w.Flush()
+	}
+
+	var daemonSets, pinned int
+	for _, name := range names {
+		if len(blocking[name]) > 0 && workloads[name].kind == "DaemonSet" && !workloads[name].pinned {
+			daemonSets++
+		}
+		if workloads[name].pinned {
+			pinned++
+		}
+	}
+	fmt.Println("\n--- arm64 Readiness Summary ---")
+	fmt.Printf("Images: %d with arm64, %d without, %d unknown\n", withArm64, withoutArm64, failed)
+	undecided := countUnblocked(unknown, blocking)
+	fmt.Printf("Workloads: %d ready, %d blocked, %d unknown\n", len(names)-len(blocking)-undecided, len(blocking), undecided)
+	if daemonSets > 0 {
+		fmt.Printf("❌ %d DaemonSets without arm64 images would fail on every Graviton node; exclude them with a %s node affinity or rebuild them first\n", daemonSets, archLabel)
+	}
+	if pinned > 0 {
+		fmt.Printf("⚠️  %d workloads are kept off arm64 nodes by their %s selector or affinity\n", pinned, archLabel)
+	}
+	if failed > 0 {
+		fmt.Printf("⚠️  %d images could not be inspected; registries other than ECR are read anonymously\n", failed)
+	}
+	if len(blocking) == 0 && failed == 0 {
+		fmt.Println("✅ Every running image has an arm64 variant")
+	}
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// countUnblocked counts the workloads with uninspected images and no known
+// blocker
+func countUnblocked(unknown, blocking map[string][]string) int {
+	count := 0
+	for name := range unknown {
+		if len(blocking[name]) == 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// collectArchInventory returns the images of the running pods' containers
+// and init containers keyed by the reference to inspect, and the workloads
+// running them.
+func collectArchInventory(namespace string) (map[string]*archImage, map[string]*archWorkload, error) {
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	replicaSets, err := clientset.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list replicasets: %w", err)
+	}
+	rsOwnerCache := make(map[string]string)
+	for _, rs := range replicaSets.Items {
+		for _, owner := range rs.OwnerReferences {
+			if owner.Kind == "Deployment" {
+				rsOwnerCache[rs.Namespace+"/"+rs.Name] = owner.Name
+			}
+		}
+	}
+
+	images := make(map[string]*archImage)
+	workloads := make(map[string]*archWorkload)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		owner, ownerType := getPodOwnerFast(pod, rsOwnerCache)
+		name := fmt.Sprintf("%s/%s/%s", pod.Namespace, ownerType, owner)
+		if workloads[name] == nil {
+			workloads[name] = &archWorkload{name: name, kind: ownerType, containers: make(map[string]string)}
+		}
+		workload := workloads[name]
+		workload.pinned = workload.pinned || excludesArm64(pod)
+
+		specImages := make(map[string]string)
+		for _, container := range pod.Spec.InitContainers {
+			specImages[container.Name+" (init)"] = container.Image
+		}
+		for _, container := range pod.Spec.Containers {
+			specImages[container.Name] = container.Image
+		}
+		statuses := make(map[string]string)
+		for _, status := range pod.Status.InitContainerStatuses {
+			statuses[status.Name+" (init)"] = status.ImageID
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			statuses[status.Name] = status.ImageID
+		}
+		for container, image := range specImages {
+			ref := runningImageRef(image, statuses[container])
+			if images[ref] == nil {
+				images[ref] = &archImage{ref: ref, image: image}
+			}
+			workload.containers[container] = ref
+		}
+	}
+	return images, workloads, nil
+}
+
+// excludesArm64 reports whether the pod's node selector or required node
+// affinity on kubernetes.io/arch rules out arm64 nodes
+func excludesArm64(pod *corev1.Pod) bool {
+	if arch, ok := pod.Spec.NodeSelector[archLabel]; ok && arch != "arm64" {
+		return true
+	}
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return false
+	}
+	operators := map[corev1.NodeSelectorOperator]selection.Operator{
+		corev1.NodeSelectorOpIn:           selection.In,
+		corev1.NodeSelectorOpNotIn:        selection.NotIn,
+		corev1.NodeSelectorOpExists:       selection.Exists,
+		corev1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+	}
+	arm64Node := labels.Set{archLabel: "arm64"}
+	// Terms are ORed: arm64 is excluded only when every term rules it out
+	terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	for _, term := range terms {
+		excluded := false
+		for _, expression := range term.MatchExpressions {
+			operator, ok := operators[expression.Operator]
+			if expression.Key != archLabel || !ok {
+				continue
+			}
+			requirement, err := labels.NewRequirement(expression.Key, operator, expression.Values)
+			if err == nil && !requirement.Matches(arm64Node) {
+				excluded = true
+			}
+		}
+		if !excluded {
+			return false
+		}
+	}
+	return len(terms) > 0
+}
@@ -0,0 +1,426 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/providerid"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const legacyZoneLabel = "failure-domain.beta.kubernetes.io/zone"
+
+// AZImpactOptions contains options for the zone failure simulation
+type AZImpactOptions struct {
+	Output string // table or json
+	FailOn string // Lowest severity that fails the run: error, warning, info or none
+}
+
+type workloadImpact struct {
+	namespace string
+	name      string
+	kind      string
+	desired   int32
+	running   int
+	lost      int
+}
+
+type nodeCapacity struct {
+	name      string
+	freeCPU   float64
+	freeMemGi float64
+}
+
+// SimulateZoneFailure reports what would break if every node in the given
+// availability zone disappeared: workloads losing replicas, violated PDBs,
+// PVs pinned to the zone and whether the remaining nodes can absorb the pods.
+func SimulateZoneFailure(zone string, options AZImpactOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	ctx := context.TODO()
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get nodes: %w", err)
+	}
+	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get pods: %w", err)
+	}
+	deployments, err := clientset.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get deployments: %w", err)
+	}
+	statefulsets, err := clientset.AppsV1().StatefulSets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get statefulsets: %w", err)
+	}
+	replicaSets, err := clientset.AppsV1().ReplicaSets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get replicasets: %w", err)
+	}
+	pdbs, err := clientset.PolicyV1().PodDisruptionBudgets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get pod disruption budgets: %w", err)
+	}
+	pvs, err := clientset.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get PVs: %w", err)
+	}
+
+	lostNodes := make(map[string]bool)
+	knownZones := make(map[string]bool)
+	for _, node := range nodes.Items {
+		nodeZone := getNodeZone(node)
+		knownZones[nodeZone] = true
+		if nodeZone == zone {
+			lostNodes[node.Name] = true
+		}
+	}
+	if len(lostNodes) == 0 {
+		var zones []string
+		for z := range knownZones {
+			if z != "" {
+				zones = append(zones, z)
+			}
+		}
+		sort.Strings(zones)
+		return fmt.Errorf("no nodes found in zone '%s' (known zones: %s)", zone, strings.Join(zones, ", "))
+	}
+
+	if options.Output != "json" {
+		fmt.Printf("Simulating loss of zone %s (%d of %d nodes)...\n", zone, len(lostNodes), len(nodes.Items))
+	}
+
+	rsOwnerCache := make(map[string]string)
+	for _, rs := range replicaSets.Items {
+		for _, owner := range rs.OwnerReferences {
+			if owner.Kind == "Deployment" {
+				rsOwnerCache[rs.Namespace+"/"+rs.Name] = owner.Name
+			}
+		}
+	}
+
+	workloads := make(map[string]*workloadImpact)
+	for _, dep := range deployments.Items {
+		impact := &workloadImpact{namespace: dep.Namespace, name: dep.Name, kind: "Deployment"}
+		if dep.Spec.Replicas != nil {
+			impact.desired = *dep.Spec.Replicas
+		}
+		workloads[dep.Namespace+"/Deployment/"+dep.Name] = impact
+	}
+	for _, sts := range statefulsets.Items {
+		impact := &workloadImpact{namespace: sts.Namespace, name: sts.Name, kind: "StatefulSet"}
+		if sts.Spec.Replicas != nil {
+			impact.desired = *sts.Spec.Replicas
+		}
+		workloads[sts.Namespace+"/StatefulSet/"+sts.Name] = impact
+	}
+
+	var runningPods, displacedPods []corev1.Pod
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.Spec.NodeName == "" {
+			continue
+		}
+		runningPods = append(runningPods, pod)
+
+		owner, ownerType := getPodOwnerFast(&pod, rsOwnerCache)
+		impact := workloads[pod.Namespace+"/"+ownerType+"/"+owner]
+		if impact != nil {
+			impact.running++
+		}
+
+		if lostNodes[pod.Spec.NodeName] {
+			if impact != nil {
+				impact.lost++
+			}
+			// DaemonSet pods go away with their node and do not need rescheduling
+			if ownerType != "DaemonSet" {
+				displacedPods = append(displacedPods, pod)
+			}
+		}
+	}
+
+	var findings []result.Finding
+	printWorkloadImpact(workloads, options.Output, &findings)
+	printPDBImpact(pdbs.Items, runningPods, lostNodes, options.Output, &findings)
+	printZonalPVs(pvs.Items, zone, options.Output, &findings)
+	printRescheduleCapacity(nodes.Items, runningPods, displacedPods, lostNodes, options.Output, &findings)
+
+	if options.Output == "json" {
+		if err := result.New("az-impact", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+	}
+	return result.Gate(findings, options.FailOn)
+}
+
+// nodeMachine returns the provider, instance and zone of the machine behind
+// a node on any cloud, taking the zone from the node labels when the
+// provider ID doesn't carry it.
+func nodeMachine(node corev1.Node) providerid.Node {
+	machine := providerid.Parse(node.Spec.ProviderID)
+	if machine.Zone == "" {
+		machine.Zone = getNodeZone(node)
+	}
+	return machine
+}
+
+func getNodeZone(node corev1.Node) string {
+	if zone := node.Labels[zoneLabel]; zone != "" {
+		return zone
+	}
+	return node.Labels[legacyZoneLabel]
+}
+
+func printWorkloadImpact(workloads map[string]*workloadImpact, output string, findings *[]result.Finding) {
+	var affected []*workloadImpact
+	for _, impact := range workloads {
+		if impact.lost > 0 {
+			affected = append(affected, impact)
+		}
+	}
+	sort.Slice(affected, func(i, j int) bool {
+		return affected[i].running-affected[i].lost < affected[j].running-affected[j].lost
+	})
+
+	for _, impact := range affected {
+		remaining := impact.running - impact.lost
+		finding := result.Finding{
+			Check:    "zone-degraded",
+			Severity: result.SeverityWarning,
+			Resource: impact.kind + "/" + impact.namespace + "/" + impact.name,
+			Message:  fmt.Sprintf("loses %d of %d running replicas", impact.lost, impact.running),
+			Details:  map[string]string{"desired": fmt.Sprint(impact.desired), "remaining": fmt.Sprint(remaining)},
+		}
+		if remaining == 0 {
+			finding.Check = "zone-outage"
+			finding.Severity = result.SeverityError
+			finding.Message = "loses all running replicas"
+		}
+		*findings = append(*findings, finding)
+	}
+	if output == "json" {
+		return
+	}
+
+	fmt.Printf("\n=== AFFECTED WORKLOADS (%d) ===\n", len(affected))
+	if len(affected) == 0 {
+		fmt.Println("No Deployment or StatefulSet replicas run in this zone.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tKIND\tDESIRED\tRUNNING\tLOST\tREMAINING\tIMPACT")
+	for _, impact := range affected {
+		remaining := impact.running - impact.lost
+		status := "degraded"
+		if remaining == 0 {
+			status = "❌ OUTAGE"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t%s\n",
+			impact.namespace, impact.name, impact.kind, impact.desired,
+			impact.running, impact.lost, remaining, status)
+	}
+	w.Flush()
+}
+
+func printPDBImpact(pdbs []policyv1.PodDisruptionBudget, runningPods []corev1.Pod, lostNodes map[string]bool, output string, findings *[]result.Finding) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	violated := 0
+	for _, pdb := range pdbs {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+
+		lost := 0
+		for _, pod := range runningPods {
+			if pod.Namespace == pdb.Namespace && lostNodes[pod.Spec.NodeName] && selector.Matches(labels.Set(pod.Labels)) {
+				lost++
+			}
+		}
+		remaining := int(pdb.Status.CurrentHealthy) - lost
+		if lost == 0 || remaining >= int(pdb.Status.DesiredHealthy) {
+			continue
+		}
+
+		*findings = append(*findings, result.Finding{
+			Check:    "pdb-violated",
+			Severity: result.SeverityError,
+			Resource: "PodDisruptionBudget/" + pdb.Namespace + "/" + pdb.Name,
+			Message:  fmt.Sprintf("%d healthy pods after zone loss, %d desired", remaining, pdb.Status.DesiredHealthy),
+		})
+		if violated == 0 {
+			fmt.Fprintln(w, "NAMESPACE\tPDB\tDESIRED HEALTHY\tCURRENT HEALTHY\tAFTER ZONE LOSS")
+		}
+		violated++
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\n",
+			pdb.Namespace, pdb.Name, pdb.Status.DesiredHealthy, pdb.Status.CurrentHealthy, remaining)
+	}
+	if output == "json" {
+		return
+	}
+
+	fmt.Printf("\n=== VIOLATED PDBS (%d) ===\n", violated)
+	if violated == 0 {
+		fmt.Println("No PodDisruptionBudgets would be violated.")
+		return
+	}
+	w.Flush()
+}
+
+func printZonalPVs(pvs []corev1.PersistentVolume, zone, output string, findings *[]result.Finding) {
+	var zonal []corev1.PersistentVolume
+	for _, pv := range pvs {
+		if persistentVolumeInZone(pv, zone) {
+			zonal = append(zonal, pv)
+			*findings = append(*findings, result.Finding{
+				Check:    "zonal-volume",
+				Severity: result.SeverityInfo,
+				Resource: "PersistentVolume/" + pv.Name,
+				Message:  fmt.Sprintf("bound to %s, its pods cannot be rescheduled to another zone", zone),
+			})
+		}
+	}
+	if output == "json" {
+		return
+	}
+
+	fmt.Printf("\n=== PERSISTENT VOLUMES IN %s (%d) ===\n", zone, len(zonal))
+	if len(zonal) == 0 {
+		fmt.Println("No persistent volumes are bound to this zone.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PV\tSIZE\tSTATUS\tCLAIM")
+	for _, pv := range zonal {
+		claim := "-"
+		if pv.Spec.ClaimRef != nil {
+			claim = pv.Spec.ClaimRef.Namespace + "/" + pv.Spec.ClaimRef.Name
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", pv.Name, pv.Spec.Capacity.Storage().String(), pv.Status.Phase, claim)
+	}
+	w.Flush()
+	fmt.Println("Pods using these volumes cannot be rescheduled to another zone.")
+}
+
+// persistentVolumeInZone checks both the zone labels and the node affinity
+// that CSI drivers use to pin a volume to a zone.
+func persistentVolumeInZone(pv corev1.PersistentVolume, zone string) bool {
+	if pv.Labels[zoneLabel] == zone || pv.Labels[legacyZoneLabel] == zone {
+		return true
+	}
+	if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return false
+	}
+	for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+		for _, expr := range term.MatchExpressions {
+			if !strings.HasSuffix(expr.Key, "zone") || expr.Operator != corev1.NodeSelectorOpIn {
+				continue
+			}
+			for _, value := range expr.Values {
+				if value == zone {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// printRescheduleCapacity bin-packs the displaced pods onto the free
+// allocatable capacity of the surviving nodes, largest CPU request first.
+func printRescheduleCapacity(nodes []corev1.Node, runningPods, displacedPods []corev1.Pod, lostNodes map[string]bool, output string, findings *[]result.Finding) {
+	capacity := make(map[string]*nodeCapacity)
+	for _, node := range nodes {
+		if lostNodes[node.Name] || node.Spec.Unschedulable || getNodeReadyStatus(node) != "True" {
+			continue
+		}
+		capacity[node.Name] = &nodeCapacity{
+			name:      node.Name,
+			freeCPU:   float64(node.Status.Allocatable.Cpu().MilliValue()) / 1000,
+			freeMemGi: float64(node.Status.Allocatable.Memory().Value()) / (1024 * 1024 * 1024),
+		}
+	}
+	for i := range runningPods {
+		if nc := capacity[runningPods[i].Spec.NodeName]; nc != nil {
+			cpu, memory := podRequests(&runningPods[i])
+			nc.freeCPU -= cpu
+			nc.freeMemGi -= memory
+		}
+	}
+
+	var survivors []*nodeCapacity
+	var totalFreeCPU, totalFreeMem float64
+	for _, nc := range capacity {
+		survivors = append(survivors, nc)
+		totalFreeCPU += nc.freeCPU
+		totalFreeMem += nc.freeMemGi
+	}
+	sort.Slice(survivors, func(i, j int) bool { return survivors[i].name < survivors[j].name })
+
+	sort.Slice(displacedPods, func(i, j int) bool {
+		cpuI, _ := podRequests(&displacedPods[i])
+		cpuJ, _ := podRequests(&displacedPods[j])
+		return cpuI > cpuJ
+	})
+
+	var neededCPU, neededMem float64
+	var unschedulable []string
+	for i := range displacedPods {
+		cpu, memory := podRequests(&displacedPods[i])
+		neededCPU += cpu
+		neededMem += memory
+
+		placed := false
+		for _, nc := range survivors {
+			if nc.freeCPU >= cpu && nc.freeMemGi >= memory {
+				nc.freeCPU -= cpu
+				nc.freeMemGi -= memory
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			unschedulable = append(unschedulable, displacedPods[i].Namespace+"/"+displacedPods[i].Name)
+			*findings = append(*findings, result.Finding{
+				Check:    "reschedule-capacity",
+				Severity: result.SeverityWarning,
+				Resource: "Pod/" + displacedPods[i].Namespace + "/" + displacedPods[i].Name,
+				Message:  "would not fit on the remaining nodes without scaling up",
+			})
+		}
+	}
+	if output == "json" {
+		return
+	}
+
+	fmt.Printf("\n=== RESCHEDULING CAPACITY ===\n")
+	fmt.Printf("Displaced pods: %d (%.2f CPU, %.2fGi memory requested)\n", len(displacedPods), neededCPU, neededMem)
+	fmt.Printf("Free on remaining %d nodes: %.2f CPU, %.2fGi memory\n", len(survivors), totalFreeCPU, totalFreeMem)
+	if len(unschedulable) == 0 {
+		fmt.Println("✅ Remaining nodes have enough capacity to reschedule all displaced pods.")
+		return
+	}
+	fmt.Printf("⚠️  %d pods would not fit on the remaining nodes without scaling up:\n", len(unschedulable))
+	for _, name := range unschedulable {
+		fmt.Printf("  - %s\n", name)
+	}
+}
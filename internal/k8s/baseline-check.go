@@ -0,0 +1,504 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// defaultStorageClassAnnotation marks the default StorageClass of a cluster
+const defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+
+// Sections of a baseline, in the order of the table columns
+var baselineSections = []string{"version", "addons", "nodes", "pdbs", "storage-classes"}
+
+// BaselineCheckOptions contains options for checking clusters against a baseline
+type BaselineCheckOptions struct {
+	Spec     string   // Baseline spec file
+	Contexts []string // Kubeconfig context patterns, the current context when empty
+	Output   string   // table or json
+	FailOn   string   // Lowest severity that fails the run: error, warning, info or none
+}
+
+// baselineSpec is what every cluster must have
+type baselineSpec struct {
+	Kubernetes struct {
+		MinVersion string `yaml:"min_version"`
+	} `yaml:"kubernetes"`
+	Addons         []baselineAddon        `yaml:"addons"`
+	Nodes          []baselineNodeRule     `yaml:"nodes"`
+	PDBs           []baselinePDB          `yaml:"pdbs"`
+	StorageClasses []baselineStorageClass `yaml:"storage_classes"`
+}
+
+// baselineAddon is a Deployment or DaemonSet that must run at a minimum
+// version
+type baselineAddon struct {
+	Name       string `yaml:"name"`
+	Namespace  string `yaml:"namespace"`   // Default: kube-system
+	Kind       string `yaml:"kind"`        // Deployment or DaemonSet, default: Deployment
+	Workload   string `yaml:"workload"`    // Default: the name
+	Container  string `yaml:"container"`   // Container whose image tag is the version, default: the first
+	MinVersion string `yaml:"min_version"` // Compared with the image tag, ignoring suffixes such as -eksbuild.1
+}
+
+// baselineNodeRule is labels and taints the nodes matching a selector must
+// have
+type baselineNodeRule struct {
+	Selector string            `yaml:"selector"` // Label selector, every node when empty
+	Labels   map[string]string `yaml:"labels"`   // An empty value only requires the label to exist
+	Taints   []corev1.Taint    `yaml:"taints"`
+}
+
+// baselinePDB is a PodDisruptionBudget that must exist
+type baselinePDB struct {
+	Namespace string `yaml:"namespace"`
+	Name      string `yaml:"name"`
+}
+
+// baselineStorageClass is a StorageClass that must exist
+type baselineStorageClass struct {
+	Name              string `yaml:"name"`
+	Provisioner       string `yaml:"provisioner"`
+	Default           bool   `yaml:"default"`
+	VolumeBindingMode string `yaml:"volume_binding_mode"`
+}
+
+// baselineResult is the outcome of the baseline on one cluster
+type baselineResult struct {
+	context  string
+	version  string
+	passed   map[string]int // By section
+	total    map[string]int
+	findings []result.Finding
+	err      error
+}
+
+// CheckBaseline verifies the current cluster, or every cluster matching the
+// context patterns, against a baseline spec declaring the minimum
+// Kubernetes version, required addons and their minimum versions, the
+// labels and taints of node groups, PodDisruptionBudgets and StorageClasses,
+// and reports every violation. It keeps a fleet of clusters consistent.
+func CheckBaseline(options BaselineCheckOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	spec, err := readBaselineSpec(options.Spec)
+	if err != nil {
+		return err
+	}
+
+	contexts := []string{""}
+	if len(options.Contexts) > 0 {
+		all, err := common.ListContexts()
+		if err != nil {
+			return err
+		}
+		contexts = nil
+		for _, name := range all {
+			if _, ok := matchContextPattern(options.Contexts, name); ok {
+				contexts = append(contexts, name)
+			}
+		}
+		if len(contexts) == 0 {
+			return fmt.Errorf("no kubeconfig context matches %s", strings.Join(options.Contexts, ", "))
+		}
+	}
+
+	results := make([]baselineResult, len(contexts))
+	var wg sync.WaitGroup
+	for i, contextName := range contexts {
+		wg.Add(1)
+		go func(i int, contextName string) {
+			defer wg.Done()
+			results[i] = checkClusterBaseline(contextName, spec)
+		}(i, contextName)
+	}
+	wg.Wait()
+	sort.Slice(results, func(i, j int) bool { return results[i].context < results[j].context })
+
+	var findings []result.Finding
+	for _, cluster := range results {
+		if cluster.err != nil {
+			findings = append(findings, result.Finding{
+				Check: "collection-failed", Severity: result.SeverityError, Resource: cluster.context,
+				Message: cluster.err.Error(), Details: map[string]string{"context": cluster.context},
+			})
+			continue
+		}
+		findings = append(findings, cluster.findings...)
+	}
+
+	if options.Output == "json" {
+		if err := result.New("baseline-check", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	fmt.Printf("Checking %d clusters against %s\n\n", len(results), options.Spec)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tVERSION\tADDONS\tNODES\tPDBS\tSTORAGE CLASSES")
+	compliant := 0
+	for _, cluster := range results {
+		if cluster.err != nil {
+			fmt.Fprintf(w, "%s\t❌ %v\t\t\t\t\n", cluster.context, cluster.err)
+			continue
+		}
+		fmt.Fprint(w, cluster.context)
+		allPassed := true
+		for _, section := range baselineSections {
+			passed, total := cluster.passed[section], cluster.total[section]
+			cell := "-"
+			switch {
+			case section == "version" && total == 0:
+				cell = cluster.version
+			case section == "version" && passed == total:
+				cell = "✅ " + cluster.version
+			case section == "version":
+				cell = "❌ " + cluster.version
+			case total > 0 && passed == total:
+				cell = fmt.Sprintf("✅ %d/%d", passed, total)
+			case total > 0:
+				cell = fmt.Sprintf("❌ %d/%d", passed, total)
+			}
+			fmt.Fprintf(w, "\t%s", cell)
+			allPassed = allPassed && passed == total
+		}
+		fmt.Fprintln(w)
+		if allPassed {
+			compliant++
+		}
+	}
+	w.Flush()
+
+	if len(findings) > 0 {
+		fmt.Println("\nFindings:")
+		for _, finding := range findings {
+			resource := finding.Resource
+			if len(results) > 1 && finding.Check != "collection-failed" {
+				resource = finding.Details["context"] + ": " + resource
+			}
+			fmt.Printf("  %s %s: %s\n", result.Label(finding.Severity), resource, finding.Message)
+		}
+	}
+
+	fmt.Println("\n--- Baseline Summary ---")
+	fmt.Printf("Clusters: %d, compliant: %d\n", len(results), compliant)
+	if compliant == len(results) {
+		fmt.Println("✅ Every cluster matches the baseline")
+	} else {
+		fmt.Printf("❌ %d clusters drift from the baseline\n", len(results)-compliant)
+	}
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// readBaselineSpec reads a baseline spec file, rejecting unknown fields so
+// a misspelled requirement isn't silently skipped
+func readBaselineSpec(path string) (*baselineSpec, error) {
+	if path == "" {
+		return nil, fmt.Errorf("--spec is required")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline spec '%s': %w", path, err)
+	}
+	var spec baselineSpec
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("invalid baseline spec '%s': %w", path, err)
+	}
+	for i := range spec.Addons {
+		addon := &spec.Addons[i]
+		if addon.Name == "" {
+			return nil, fmt.Errorf("invalid baseline spec '%s': addon %d has no name", path, i+1)
+		}
+		if addon.Namespace == "" {
+			addon.Namespace = "kube-system"
+		}
+		if addon.Kind == "" {
+			addon.Kind = "Deployment"
+		}
+		if addon.Kind != "Deployment" && addon.Kind != "DaemonSet" {
+			return nil, fmt.Errorf("invalid baseline spec '%s': addon %s has kind %s (must be Deployment or DaemonSet)", path, addon.Name, addon.Kind)
+		}
+		if addon.Workload == "" {
+			addon.Workload = addon.Name
+		}
+	}
+	for _, pdb := range spec.PDBs {
+		if pdb.Namespace == "" || pdb.Name == "" {
+			return nil, fmt.Errorf("invalid baseline spec '%s': every PDB needs a namespace and name", path)
+		}
+	}
+	return &spec, nil
+}
+
+// checkClusterBaseline checks one cluster, the current context when
+// contextName is empty
+func checkClusterBaseline(contextName string, spec *baselineSpec) baselineResult {
+	var clientset *kubernetes.Clientset
+	var err error
+	if contextName == "" {
+		clientset, err = common.GetKubernetesClient()
+		contextName = "current context"
+		if current, _, currentErr := common.CurrentContext(); currentErr == nil {
+			contextName = current
+		}
+	} else {
+		clientset, err = common.GetKubernetesClientForContext(contextName)
+	}
+	cluster := baselineResult{context: contextName, passed: make(map[string]int), total: make(map[string]int)}
+	if err != nil {
+		cluster.err = err
+		return cluster
+	}
+
+	// fail records a violation of a section's requirement, pass a met one
+	fail := func(section, check, severity, resource, message string) {
+		cluster.total[section]++
+		cluster.findings = append(cluster.findings, result.Finding{
+			Check: check, Severity: severity, Resource: resource, Message: message,
+			Details: map[string]string{"context": contextName},
+		})
+	}
+	pass := func(section string) {
+		cluster.total[section]++
+		cluster.passed[section]++
+	}
+
+	info, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		cluster.err = fmt.Errorf("failed to get server version: %w", err)
+		return cluster
+	}
+	cluster.version = info.Major + "." + strings.TrimRight(info.Minor, "+")
+	if spec.Kubernetes.MinVersion != "" {
+		if compareVersionTags(cluster.version, spec.Kubernetes.MinVersion) < 0 {
+			fail("version", "baseline-version", result.SeverityError, "cluster",
+				fmt.Sprintf("runs Kubernetes %s, the baseline requires at least %s", cluster.version, spec.Kubernetes.MinVersion))
+		} else {
+			pass("version")
+		}
+	}
+
+	ctx := context.TODO()
+	for _, addon := range spec.Addons {
+		resource := fmt.Sprintf("%s/%s/%s", addon.Namespace, addon.Kind, addon.Workload)
+		var podSpec corev1.PodSpec
+		var desired, ready int32
+		if addon.Kind == "DaemonSet" {
+			daemonSet, getErr := clientset.AppsV1().DaemonSets(addon.Namespace).Get(ctx, addon.Workload, metav1.GetOptions{})
+			err = getErr
+			if err == nil {
+				podSpec, desired, ready = daemonSet.Spec.Template.Spec, daemonSet.Status.DesiredNumberScheduled, daemonSet.Status.NumberReady
+			}
+		} else {
+			deployment, getErr := clientset.AppsV1().Deployments(addon.Namespace).Get(ctx, addon.Workload, metav1.GetOptions{})
+			err = getErr
+			if err == nil {
+				podSpec, ready = deployment.Spec.Template.Spec, deployment.Status.ReadyReplicas
+				desired = 1
+				if deployment.Spec.Replicas != nil {
+					desired = *deployment.Spec.Replicas
+				}
+			}
+		}
+		if apierrors.IsNotFound(err) {
+			fail("addons", "baseline-addon", result.SeverityError, resource, fmt.Sprintf("required addon %s is not installed", addon.Name))
+			continue
+		}
+		if err != nil {
+			fail("addons", "baseline-addon", result.SeverityError, resource, fmt.Sprintf("failed to get addon %s: %v", addon.Name, err))
+			continue
+		}
+
+		violated := false
+		if ready < desired {
+			fail("addons", "baseline-addon-ready", result.SeverityWarning, resource, fmt.Sprintf("addon %s has %d of %d pods ready", addon.Name, ready, desired))
+			violated = true
+		}
+		if addon.MinVersion != "" {
+			tag, ok := addonImageTag(podSpec, addon.Container)
+			switch {
+			case !ok:
+				fail("addons", "baseline-addon-version", result.SeverityError, resource, fmt.Sprintf("addon %s has no container %s", addon.Name, addon.Container))
+				violated = true
+			case !versionAtLeast(tag, addon.MinVersion):
+				if _, _, parses := parseVersionTag(tag); !parses {
+					fail("addons", "baseline-addon-version", result.SeverityInfo, resource, fmt.Sprintf("addon %s runs image tag %s, which is not a version to compare with %s", addon.Name, tag, addon.MinVersion))
+				} else {
+					fail("addons", "baseline-addon-version", result.SeverityError, resource, fmt.Sprintf("addon %s runs %s, the baseline requires at least %s", addon.Name, tag, addon.MinVersion))
+				}
+				violated = true
+			}
+		}
+		if !violated {
+			pass("addons")
+		}
+	}
+
+	if len(spec.Nodes) > 0 {
+		for _, rule := range spec.Nodes {
+			resource := "nodes"
+			if rule.Selector != "" {
+				resource = "nodes " + rule.Selector
+			}
+			nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: rule.Selector})
+			if err != nil {
+				fail("nodes", "baseline-nodes", result.SeverityError, resource, fmt.Sprintf("failed to list nodes: %v", err))
+				continue
+			}
+			if len(nodes.Items) == 0 {
+				fail("nodes", "baseline-nodes", result.SeverityWarning, resource, "no node matches the selector")
+				continue
+			}
+			violated := false
+			for _, key := range sortedKeys(rule.Labels) {
+				want := rule.Labels[key]
+				var missing []string
+				for _, node := range nodes.Items {
+					value, ok := node.Labels[key]
+					if !ok || (want != "" && value != want) {
+						missing = append(missing, node.Name)
+					}
+				}
+				if len(missing) > 0 {
+					label := key
+					if want != "" {
+						label += "=" + want
+					}
+					fail("nodes", "baseline-node-label", result.SeverityError, resource,
+						fmt.Sprintf("%d of %d nodes lack label %s: %s", len(missing), len(nodes.Items), label, summarizeNames(missing)))
+					violated = true
+				}
+			}
+			for _, taint := range rule.Taints {
+				var missing []string
+				for _, node := range nodes.Items {
+					if !nodeHasTaint(node, taint) {
+						missing = append(missing, node.Name)
+					}
+				}
+				if len(missing) > 0 {
+					fail("nodes", "baseline-node-taint", result.SeverityError, resource,
+						fmt.Sprintf("%d of %d nodes lack taint %s: %s", len(missing), len(nodes.Items), taint.ToString(), summarizeNames(missing)))
+					violated = true
+				}
+			}
+			if !violated {
+				pass("nodes")
+			}
+		}
+	}
+
+	for _, pdb := range spec.PDBs {
+		resource := fmt.Sprintf("%s/PodDisruptionBudget/%s", pdb.Namespace, pdb.Name)
+		_, err := clientset.PolicyV1().PodDisruptionBudgets(pdb.Namespace).Get(ctx, pdb.Name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			fail("pdbs", "baseline-pdb", result.SeverityError, resource, "required PodDisruptionBudget does not exist")
+		case err != nil:
+			fail("pdbs", "baseline-pdb", result.SeverityError, resource, fmt.Sprintf("failed to get PodDisruptionBudget: %v", err))
+		default:
+			pass("pdbs")
+		}
+	}
+
+	for _, required := range spec.StorageClasses {
+		resource := "StorageClass/" + required.Name
+		class, err := clientset.StorageV1().StorageClasses().Get(ctx, required.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			fail("storage-classes", "baseline-storage-class", result.SeverityError, resource, "required StorageClass does not exist")
+			continue
+		}
+		if err != nil {
+			fail("storage-classes", "baseline-storage-class", result.SeverityError, resource, fmt.Sprintf("failed to get StorageClass: %v", err))
+			continue
+		}
+		var problems []string
+		if required.Provisioner != "" && class.Provisioner != required.Provisioner {
+			problems = append(problems, fmt.Sprintf("provisioner is %s, not %s", class.Provisioner, required.Provisioner))
+		}
+		if required.Default && class.Annotations[defaultStorageClassAnnotation] != "true" {
+			problems = append(problems, "it is not the default StorageClass")
+		}
+		mode := ""
+		if class.VolumeBindingMode != nil {
+			mode = string(*class.VolumeBindingMode)
+		}
+		if required.VolumeBindingMode != "" && mode != required.VolumeBindingMode {
+			problems = append(problems, fmt.Sprintf("volume binding mode is %s, not %s", valueOrDash(mode), required.VolumeBindingMode))
+		}
+		if len(problems) > 0 {
+			fail("storage-classes", "baseline-storage-class", result.SeverityError, resource, strings.Join(problems, "; "))
+		} else {
+			pass("storage-classes")
+		}
+	}
+	return cluster
+}
+
+// addonImageTag returns the image tag of the named container, or of the
+// first container when no name is given
+func addonImageTag(spec corev1.PodSpec, container string) (string, bool) {
+	for _, c := range spec.Containers {
+		if container == "" || c.Name == container {
+			return imageTag(c.Image), true
+		}
+	}
+	return "", false
+}
+
+// versionAtLeast reports whether a version tag is at least the minimum.
+// Suffixes of the tag such as -eksbuild.1 are ignored unless the minimum
+// has one too.
+func versionAtLeast(tag, minimum string) bool {
+	if _, _, ok := parseVersionTag(tag); !ok {
+		return false
+	}
+	if _, prerelease, _ := parseVersionTag(minimum); prerelease == "" {
+		if i := strings.IndexAny(tag, "-+"); i >= 0 {
+			tag = tag[:i]
+		}
+	}
+	return compareVersionTags(tag, minimum) >= 0
+}
+
+// nodeHasTaint reports whether a node has the taint; an empty value or
+// effect in the required taint matches any
+func nodeHasTaint(node corev1.Node, required corev1.Taint) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == required.Key &&
+			(required.Value == "" || taint.Value == required.Value) &&
+			(required.Effect == "" || taint.Effect == required.Effect) {
+			return true
+		}
+	}
+	return false
+}
+
+// summarizeNames lists the first few names and counts the rest
+func summarizeNames(names []string) string {
+	const shown = 3
+	if len(names) <= shown {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:shown], ", "), len(names)-shown)
+}
@@ -0,0 +1,203 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/config"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/ui"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ChaosKillOptions contains options for killing pods in a resilience drill
+type ChaosKillOptions struct {
+	Namespace       string
+	Selector        string
+	Count           int           // Pods to kill in total
+	Interval        time.Duration // Wait between kills
+	MinReady        int           // Never kill when fewer ready pods would remain
+	GracePeriod     int64         // Seconds, -1 for the pod's own setting
+	RecoveryTimeout time.Duration // How long to wait for the ready count to recover
+	Confirm         string        // Typed confirmation given up front
+	NonInteractive  bool
+	DryRun          bool
+}
+
+// ChaosKillPods deletes Count random ready pods matching the selector, one
+// every Interval, then waits for the number of ready pods to get back to
+// where it started and reports how long that took. It only runs in
+// namespaces allowlisted under chaos.allowed_namespaces in the config file,
+// and only after the namespace name is typed back.
+func ChaosKillPods(options ChaosKillOptions) error {
+	if options.Selector == "" {
+		return fmt.Errorf("--selector is required, chaos never targets a whole namespace")
+	}
+	if options.Count < 1 {
+		return fmt.Errorf("--count must be at least 1")
+	}
+	if options.MinReady < 0 {
+		return fmt.Errorf("--min-ready can't be negative")
+	}
+	if options.Namespace == "" {
+		options.Namespace = "default"
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	chaos, err := cfg.Chaos()
+	if err != nil {
+		return err
+	}
+	if !containsString(chaos.AllowedNamespaces, options.Namespace) {
+		return fmt.Errorf("namespace %s is not in chaos.allowed_namespaces of %s", options.Namespace, config.Path())
+	}
+
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ready, err := readyChaosPods(ctx, clientset, options)
+	if err != nil {
+		return err
+	}
+	baseline := len(ready)
+	clusterName, _ := getClusterName()
+	fmt.Printf("Cluster: %s\n", valueOrDash(clusterName))
+	fmt.Printf("Pods matching %s in %s: %d ready\n", options.Selector, options.Namespace, baseline)
+	fmt.Printf("Plan: kill %d pod(s), one every %s, keeping at least %d ready\n", options.Count, options.Interval, options.MinReady)
+	if baseline <= options.MinReady {
+		return fmt.Errorf("only %d ready pods match, killing one would leave fewer than --min-ready %d", baseline, options.MinReady)
+	}
+	if options.DryRun {
+		fmt.Println("Dry run, nothing killed.")
+		return nil
+	}
+	prompt := fmt.Sprintf("⚠️  This deletes pods in namespace %s of cluster %s.", options.Namespace, valueOrDash(clusterName))
+	if err := ui.ConfirmTyped(prompt, options.Namespace, options.Confirm, "--confirm "+options.Namespace, options.NonInteractive); err != nil {
+		return err
+	}
+
+	// Stop killing on Ctrl+C; the recovery wait is skipped as well
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+	go func() {
+		if _, ok := <-stop; ok {
+			fmt.Println("\nInterrupted, not killing further pods...")
+			cancel()
+		}
+	}()
+
+	deleteOptions := metav1.DeleteOptions{}
+	if options.GracePeriod >= 0 {
+		deleteOptions.GracePeriodSeconds = &options.GracePeriod
+	}
+	start := time.Now()
+	var killed, skipped int
+	for i := 0; i < options.Count && ctx.Err() == nil; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(options.Interval):
+			case <-ctx.Done():
+				continue
+			}
+		}
+		ready, err := readyChaosPods(ctx, clientset, options)
+		if err != nil {
+			return err
+		}
+		timestamp := time.Now().Format("15:04:05")
+		if len(ready) <= options.MinReady {
+			fmt.Printf("%s ⚠️  %d ready, not killing below --min-ready %d\n", timestamp, len(ready), options.MinReady)
+			skipped++
+			continue
+		}
+		pod := ready[rand.Intn(len(ready))]
+		if err := clientset.CoreV1().Pods(options.Namespace).Delete(ctx, pod.Name, deleteOptions); err != nil {
+			fmt.Printf("%s ❌ %s: %v\n", timestamp, pod.Name, err)
+			skipped++
+			continue
+		}
+		killed++
+		fmt.Printf("%s killed %s on %s (%d ready before)\n", timestamp, pod.Name, valueOrDash(pod.Spec.NodeName), len(ready))
+	}
+
+	var recovered time.Duration
+	if killed > 0 && ctx.Err() == nil {
+		fmt.Printf("Waiting up to %s for %d ready pods...\n", options.RecoveryTimeout, baseline)
+		recovered = waitForChaosRecovery(ctx, clientset, options, baseline, start)
+	}
+
+	fmt.Println("\n--- Chaos Summary ---")
+	fmt.Printf("Killed: %d\n", killed)
+	if skipped > 0 {
+		fmt.Printf("⚠️  Skipped: %d\n", skipped)
+	}
+	switch {
+	case killed == 0:
+	case recovered > 0:
+		fmt.Printf("✅ Recovered to %d ready pods %s after the first kill\n", baseline, recovered.Round(time.Second))
+	default:
+		fmt.Printf("❌ Not back to %d ready pods\n", baseline)
+	}
+	fmt.Printf("Watch scheduling and cluster state with: swissarmycli pending-watch -n %s, swissarmycli health\n", options.Namespace)
+	fmt.Println("----------------------------------------------------")
+	if killed > 0 && recovered == 0 {
+		return fmt.Errorf("pods matching %s did not recover to %d ready", options.Selector, baseline)
+	}
+	return nil
+}
+
+// readyChaosPods lists the ready pods matching the selector that aren't
+// already being deleted.
+func readyChaosPods(ctx context.Context, clientset *kubernetes.Clientset, options ChaosKillOptions) ([]corev1.Pod, error) {
+	pods, err := clientset.CoreV1().Pods(options.Namespace).List(ctx, metav1.ListOptions{LabelSelector: options.Selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	var ready []corev1.Pod
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				ready = append(ready, pod)
+				break
+			}
+		}
+	}
+	return ready, nil
+}
+
+// waitForChaosRecovery polls until baseline pods are ready again and returns
+// the time since start, or 0 when the timeout or an interrupt comes first.
+func waitForChaosRecovery(ctx context.Context, clientset *kubernetes.Clientset, options ChaosKillOptions, baseline int, start time.Time) time.Duration {
+	deadline := time.After(options.RecoveryTimeout)
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		if ready, err := readyChaosPods(ctx, clientset, options); err == nil && len(ready) >= baseline {
+			return time.Since(start)
+		}
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			return 0
+		case <-ctx.Done():
+			return 0
+		}
+	}
+}
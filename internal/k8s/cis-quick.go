@@ -0,0 +1,411 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	"github.com/aws/aws-sdk-go/aws/session"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CISQuickOptions contains options for the CIS quick checks
+type CISQuickOptions struct {
+	Cluster string // EKS cluster name, defaults to the one in the kubeconfig context
+	Region  string // Defaults to the region of the cluster's nodes
+	Profile string
+	Output  string // table or json
+	FailOn  string // Lowest severity that fails the run: error, warning, info or none
+}
+
+// Check outcomes
+const (
+	cisPass = "pass"
+	cisFail = "fail"
+	cisSkip = "skip"
+)
+
+// cisCheck is the outcome of one check
+type cisCheck struct {
+	id          string
+	name        string
+	control     string // CIS Amazon EKS Benchmark recommendation, when there is one
+	status      string
+	severity    string // Severity when failed
+	detail      string
+	resources   []string // Failing resources
+	remediation string
+}
+
+// RunCISQuick runs a practical subset of the CIS Amazon EKS Benchmark that can
+// be checked from outside the nodes, through the Kubernetes and AWS APIs,
+// and reports pass or fail with remediation hints.
+func RunCISQuick(options CISQuickOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	region := options.Region
+	if region == "" && len(nodes.Items) > 0 {
+		region = nodes.Items[0].Labels["topology.kubernetes.io/region"]
+	}
+	cluster := options.Cluster
+	if cluster == "" {
+		if name, err := getClusterName(); err == nil && name != "unknown" {
+			cluster = name
+		}
+	}
+	sess, err := awsutils.NewSession(options.Profile, region)
+	if err != nil {
+		return err
+	}
+
+	endpoint, auditLogging := checkEKSClusterConfig(sess, cluster)
+	checks := []cisCheck{
+		endpoint,
+		auditLogging,
+		checkKubeletAnonymousAuth(ctx, clientset, nodes.Items),
+		checkAnonymousBindings(ctx, clientset),
+		checkNodeIMDSv2(sess, nodes.Items),
+		checkPrivilegedAdmission(ctx, clientset),
+	}
+
+	var findings []result.Finding
+	passed, failed, skipped := 0, 0, 0
+	for _, check := range checks {
+		switch check.status {
+		case cisPass:
+			passed++
+		case cisSkip:
+			skipped++
+		case cisFail:
+			failed++
+			resource := cluster
+			if len(check.resources) > 0 {
+				resource = strings.Join(check.resources, ", ")
+			}
+			details := map[string]string{"remediation": check.remediation}
+			if check.control != "" {
+				details["cis_control"] = check.control
+			}
+			findings = append(findings, result.Finding{
+				Check:    check.id,
+				Severity: check.severity,
+				Resource: resource,
+				Message:  check.detail,
+				Details:  details,
+			})
+		}
+	}
+	if options.Output == "json" {
+		if err := result.New("cis-quick", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tCIS\tRESULT\tDETAIL")
+	for _, check := range checks {
+		status := "✅ pass"
+		switch check.status {
+		case cisFail:
+			status = "❌ fail"
+		case cisSkip:
+			status = "⚠️  skipped"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", check.name, valueOrDash(check.control), status, check.detail)
+	}
+	w.Flush()
+
+	if failed > 0 {
+		fmt.Println("\nRemediation:")
+		for _, check := range checks {
+			if check.status != cisFail {
+				continue
+			}
+			fmt.Printf("\n%s\n  %s\n", check.name, check.remediation)
+			for i, resource := range check.resources {
+				if i == 10 {
+					fmt.Printf("  ... and %d more\n", len(check.resources)-10)
+					break
+				}
+				fmt.Printf("  - %s\n", resource)
+			}
+		}
+	}
+
+	fmt.Println("\n--- CIS Quick Check Summary ---")
+	fmt.Printf("✅ Passed: %d\n", passed)
+	fmt.Printf("❌ Failed: %d\n", failed)
+	if skipped > 0 {
+		fmt.Printf("⚠️  Skipped: %d\n", skipped)
+	}
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// checkEKSClusterConfig checks the API server endpoint access and the audit
+// log configuration of the EKS cluster.
+func checkEKSClusterConfig(sess *session.Session, cluster string) (cisCheck, cisCheck) {
+	endpoint := cisCheck{
+		id:          "public-endpoint",
+		name:        "API endpoint not open to the internet",
+		control:     "5.4.2",
+		severity:    result.SeverityError,
+		remediation: "Disable public access, or restrict it to known CIDRs: aws eks update-cluster-config --name " + cluster + " --resources-vpc-config endpointPrivateAccess=true,publicAccessCidrs=<cidr>",
+	}
+	audit := cisCheck{
+		id:          "audit-logging",
+		name:        "Control plane audit logging enabled",
+		control:     "2.1.1",
+		severity:    result.SeverityWarning,
+		remediation: `Enable audit logs: aws eks update-cluster-config --name ` + cluster + ` --logging '{"clusterLogging":[{"types":["audit"],"enabled":true}]}'`,
+	}
+	if cluster == "" {
+		endpoint.status, endpoint.detail = cisSkip, "cluster name unknown, pass --cluster"
+		audit.status, audit.detail = cisSkip, endpoint.detail
+		return endpoint, audit
+	}
+	security, err := awsutils.GetEKSClusterSecurity(sess, cluster)
+	if err != nil {
+		endpoint.status, endpoint.detail = cisSkip, err.Error()
+		audit.status, audit.detail = cisSkip, err.Error()
+		return endpoint, audit
+	}
+
+	switch {
+	case !security.EndpointPublicAccess:
+		endpoint.status, endpoint.detail = cisPass, "private endpoint only"
+	case containsString(security.PublicAccessCIDRs, "0.0.0.0/0"):
+		endpoint.status, endpoint.detail = cisFail, "public endpoint open to 0.0.0.0/0"
+	default:
+		endpoint.status = cisPass
+		endpoint.detail = fmt.Sprintf("public endpoint restricted to %s", strings.Join(security.PublicAccessCIDRs, ", "))
+	}
+
+	if containsString(security.EnabledLogTypes, "audit") {
+		audit.status, audit.detail = cisPass, "audit logs sent to CloudWatch"
+	} else {
+		audit.status, audit.detail = cisFail, "audit logs are not enabled"
+	}
+	return endpoint, audit
+}
+
+// checkKubeletAnonymousAuth reads each kubelet's running configuration
+// through the API server's node proxy.
+func checkKubeletAnonymousAuth(ctx context.Context, clientset *kubernetes.Clientset, nodes []corev1.Node) cisCheck {
+	check := cisCheck{
+		id:          "kubelet-anonymous-auth",
+		name:        "Kubelet anonymous auth disabled",
+		control:     "3.2.1",
+		severity:    result.SeverityError,
+		remediation: "Set authentication.anonymous.enabled: false in the kubelet config of the node group's launch template or AMI.",
+	}
+	checked := 0
+	for _, node := range nodes {
+		raw, err := clientset.CoreV1().RESTClient().Get().
+			Resource("nodes").Name(node.Name).SubResource("proxy").Suffix("configz").
+			DoRaw(ctx)
+		if err != nil {
+			continue // Fargate nodes and nodes that are down don't serve configz
+		}
+		var configz struct {
+			KubeletConfig struct {
+				Authentication struct {
+					Anonymous struct {
+						Enabled *bool `json:"enabled"`
+					} `json:"anonymous"`
+				} `json:"authentication"`
+			} `json:"kubeletconfig"`
+		}
+		if err := json.Unmarshal(raw, &configz); err != nil {
+			continue
+		}
+		checked++
+		// The kubelet allows anonymous requests unless told otherwise
+		if enabled := configz.KubeletConfig.Authentication.Anonymous.Enabled; enabled == nil || *enabled {
+			check.resources = append(check.resources, "node/"+node.Name)
+		}
+	}
+
+	switch {
+	case checked == 0:
+		check.status, check.detail = cisSkip, "no kubelet config readable through the node proxy"
+	case len(check.resources) > 0:
+		check.status = cisFail
+		check.detail = fmt.Sprintf("%d of %d nodes allow anonymous requests", len(check.resources), checked)
+	default:
+		check.status = cisPass
+		check.detail = fmt.Sprintf("%d nodes checked", checked)
+	}
+	return check
+}
+
+// checkAnonymousBindings looks for RBAC bindings granting anything to
+// anonymous or unauthenticated users beyond the default public info.
+func checkAnonymousBindings(ctx context.Context, clientset *kubernetes.Clientset) cisCheck {
+	check := cisCheck{
+		id:          "anonymous-rbac",
+		name:        "No RBAC bindings for anonymous users",
+		severity:    result.SeverityError,
+		remediation: "Remove system:anonymous and system:unauthenticated from these bindings' subjects.",
+	}
+	anonymous := func(subjects []rbacv1.Subject) bool {
+		for _, subject := range subjects {
+			if subject.Name == "system:anonymous" || subject.Name == "system:unauthenticated" {
+				return true
+			}
+		}
+		return false
+	}
+
+	clusterBindings, err := clientset.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		check.status, check.detail = cisSkip, fmt.Sprintf("failed to list cluster role bindings: %v", err)
+		return check
+	}
+	for _, binding := range clusterBindings.Items {
+		// Bound to system:unauthenticated by default so health checks work
+		if binding.RoleRef.Name == "system:public-info-viewer" {
+			continue
+		}
+		if anonymous(binding.Subjects) {
+			check.resources = append(check.resources, "ClusterRoleBinding/"+binding.Name)
+		}
+	}
+	bindings, err := clientset.RbacV1().RoleBindings("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		check.status, check.detail = cisSkip, fmt.Sprintf("failed to list role bindings: %v", err)
+		return check
+	}
+	for _, binding := range bindings.Items {
+		if anonymous(binding.Subjects) {
+			check.resources = append(check.resources, "RoleBinding/"+binding.Namespace+"/"+binding.Name)
+		}
+	}
+
+	if len(check.resources) > 0 {
+		check.status = cisFail
+		check.detail = fmt.Sprintf("%d binding(s) grant access to anonymous users", len(check.resources))
+	} else {
+		check.status, check.detail = cisPass, "only the default public-info-viewer binding"
+	}
+	return check
+}
+
+// checkNodeIMDSv2 checks that every EC2 node requires IMDSv2 session tokens.
+func checkNodeIMDSv2(sess *session.Session, nodes []corev1.Node) cisCheck {
+	check := cisCheck{
+		id:          "imdsv2",
+		name:        "IMDSv2 required on nodes",
+		severity:    result.SeverityError,
+		remediation: "Set metadata_options http_tokens=required in the node group's launch template, or run: aws ec2 modify-instance-metadata-options --instance-id <id> --http-tokens required",
+	}
+	nodeByInstance := make(map[string]string)
+	var instanceIDs []string
+	for _, node := range nodes {
+		if instanceID := awsutils.InstanceIDFromProviderID(node.Spec.ProviderID); instanceID != "" {
+			nodeByInstance[instanceID] = node.Name
+			instanceIDs = append(instanceIDs, instanceID)
+		}
+	}
+	if len(instanceIDs) == 0 {
+		check.status, check.detail = cisSkip, "no EC2 nodes"
+		return check
+	}
+	tokens, err := awsutils.GetInstanceMetadataTokens(sess, instanceIDs)
+	if err != nil {
+		check.status, check.detail = cisSkip, err.Error()
+		return check
+	}
+	for _, instanceID := range instanceIDs {
+		if setting, ok := tokens[instanceID]; ok && setting != "required" {
+			check.resources = append(check.resources, fmt.Sprintf("node/%s (%s)", nodeByInstance[instanceID], instanceID))
+		}
+	}
+
+	if len(check.resources) > 0 {
+		check.status = cisFail
+		check.detail = fmt.Sprintf("%d of %d nodes allow IMDSv1", len(check.resources), len(instanceIDs))
+	} else {
+		check.status, check.detail = cisPass, fmt.Sprintf("%d nodes checked", len(instanceIDs))
+	}
+	return check
+}
+
+// checkPrivilegedAdmission checks that every namespace except kube-system
+// enforces at least the baseline Pod Security Standard, which rejects
+// privileged pods.
+func checkPrivilegedAdmission(ctx context.Context, clientset *kubernetes.Clientset) cisCheck {
+	check := cisCheck{
+		id:          "privileged-pods",
+		name:        "Privileged pods not admitted",
+		control:     "4.2.1",
+		severity:    result.SeverityWarning,
+		remediation: "Enforce the baseline Pod Security Standard: kubectl label namespace <namespace> pod-security.kubernetes.io/enforce=baseline. If Kyverno or Gatekeeper blocks privileged pods instead, this can be ignored.",
+	}
+	namespaces, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		check.status, check.detail = cisSkip, fmt.Sprintf("failed to list namespaces: %v", err)
+		return check
+	}
+	checked := 0
+	for _, namespace := range namespaces.Items {
+		if namespace.Name == "kube-system" {
+			continue // Runs the CNI and kube-proxy, which need privileges
+		}
+		checked++
+		level := namespace.Labels["pod-security.kubernetes.io/enforce"]
+		if level != "baseline" && level != "restricted" {
+			check.resources = append(check.resources, "namespace/"+namespace.Name)
+		}
+	}
+
+	privileged := 0
+	if pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{}); err == nil {
+		for _, pod := range pods.Items {
+			if pod.Namespace != "kube-system" && podIsPrivileged(pod) {
+				privileged++
+			}
+		}
+	}
+
+	if len(check.resources) > 0 {
+		check.status = cisFail
+		check.detail = fmt.Sprintf("%d of %d namespaces don't enforce baseline, %d privileged pods running",
+			len(check.resources), checked, privileged)
+	} else {
+		check.status, check.detail = cisPass, fmt.Sprintf("%d namespaces enforce baseline or restricted", checked)
+	}
+	return check
+}
+
+func podIsPrivileged(pod corev1.Pod) bool {
+	containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for _, container := range containers {
+		if container.SecurityContext != nil && container.SecurityContext.Privileged != nil && *container.SecurityContext.Privileged {
+			return true
+		}
+	}
+	return false
+}
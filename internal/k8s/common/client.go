@@ -2,30 +2,130 @@ package common
 
 import (
 	"fmt"
+	"github.com/HighonAces/swissarmycli/internal/apicalls"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/util/homedir"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/metrics/pkg/client/clientset/versioned"
-	"os"
-	"path/filepath"
+	"sort"
 )
 
-func loadKubeConfig() (*rest.Config, error) {
-	home := homedir.HomeDir()
-	kubeconfigPath := filepath.Join(home, ".kube", "config")
+// Kubeconfig file and context that replace $KUBECONFIG and its current
+// context, from the global --kubeconfig and --context flags
+var (
+	kubeconfigOverride string
+	contextOverride    string
+)
 
-	if kubeconfigEnv := os.Getenv("KUBECONFIG"); kubeconfigEnv != "" {
-		kubeconfigPath = kubeconfigEnv
-	}
+// SetKubeconfigOverrides makes every client load an explicit kubeconfig file
+// and connect through an explicit context, like kubectl's --kubeconfig and
+// --context flags. Empty values keep the defaults.
+func SetKubeconfigOverrides(kubeconfig, context string) {
+	kubeconfigOverride = kubeconfig
+	contextOverride = context
+}
 
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+// ClientConfig returns the kubeconfig the clients are built from. Like
+// kubectl, it merges the files listed in $KUBECONFIG, or reads
+// ~/.kube/config, unless --kubeconfig names a file.
+func ClientConfig() clientcmd.ClientConfig {
+	return clientConfigForContext(contextOverride)
+}
+
+func clientConfigForContext(contextName string) clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfigOverride
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{CurrentContext: contextName})
+}
+
+func loadKubeConfig() (*rest.Config, error) {
+	config, err := ClientConfig().ClientConfig()
 	if err != nil {
 		return nil, fmt.Errorf("error building kubeconfig: %w", err)
 	}
+	config.Wrap(apicalls.Transport)
 	return config, nil
 }
 
+// RawConfig returns the merged kubeconfig with the current context set to
+// the one the clients connect through.
+func RawConfig() (clientcmdapi.Config, error) {
+	kubeconfig, err := ClientConfig().RawConfig()
+	if err != nil {
+		return clientcmdapi.Config{}, fmt.Errorf("error reading kubeconfig: %w", err)
+	}
+	if contextOverride != "" {
+		kubeconfig.CurrentContext = contextOverride
+	}
+	return kubeconfig, nil
+}
+
+// CurrentContext returns the name of the kubeconfig context the clients
+// connect through, and the cluster it points at.
+func CurrentContext() (string, string, error) {
+	kubeconfig, err := RawConfig()
+	if err != nil {
+		return "", "", err
+	}
+	if kubeconfig.CurrentContext == "" {
+		return "", "", fmt.Errorf("no current context set in the kubeconfig")
+	}
+	context, ok := kubeconfig.Contexts[kubeconfig.CurrentContext]
+	if !ok {
+		return "", "", fmt.Errorf("context %s not found in the kubeconfig", kubeconfig.CurrentContext)
+	}
+	return kubeconfig.CurrentContext, context.Cluster, nil
+}
+
+// ContextNamespace returns the namespace of the current context, or
+// "default" when it doesn't set one, the namespace kubectl works in when
+// none is given.
+func ContextNamespace() string {
+	namespace, _, err := ClientConfig().Namespace()
+	if err != nil || namespace == "" {
+		return "default"
+	}
+	return namespace
+}
+
+// ListContexts returns the names of all contexts in the kubeconfig.
+func ListContexts() ([]string, error) {
+	kubeconfig, err := RawConfig()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range kubeconfig.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// GetKubernetesClientForContext creates a Kubernetes clientset for a named
+// kubeconfig context instead of the current one, for commands that fan out
+// over several clusters.
+func GetKubernetesClientForContext(contextName string) (*kubernetes.Clientset, error) {
+	config, err := clientConfigForContext(contextName).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error building kubeconfig for context %s: %w", contextName, err)
+	}
+	config.Wrap(apicalls.Transport)
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+	return clientset, nil
+}
+
+// GetRESTConfig returns the client config for the current kubeconfig, for
+// clients that talk to the API server directly such as exec and attach.
+func GetRESTConfig() (*rest.Config, error) {
+	return loadKubeConfig()
+}
+
 func GetKubernetesClient() (*kubernetes.Clientset, error) {
 	config, err := loadKubeConfig()
 	if err != nil {
@@ -58,3 +158,17 @@ func GetMetricsClient() (*versioned.Clientset, error) {
 	}
 	return metricsClient, nil
 }
+
+// GetDynamicClient creates a dynamic client for custom resources.
+func GetDynamicClient() (dynamic.Interface, error) {
+	config, err := loadKubeConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating dynamic client: %w", err)
+	}
+	return dynamicClient, nil
+}
@@ -0,0 +1,419 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultConntrackImage runs the collector pods; it only needs sh, cat,
+// grep and dmesg
+const DefaultConntrackImage = "busybox:1.36"
+
+// nodeLocalDNSMetricsPort is where node-local-dns serves its Prometheus
+// metrics
+const nodeLocalDNSMetricsPort = "9253"
+
+// conntrackScript prints the conntrack table usage, whether the kernel
+// logged a full table, and the per-CPU conntrack statistics of the node's
+// network namespace.
+var conntrackScript = []string{
+	`echo "count $(cat /proc/sys/net/netfilter/nf_conntrack_count 2>/dev/null)"`,
+	`echo "max $(cat /proc/sys/net/netfilter/nf_conntrack_max 2>/dev/null)"`,
+	`if dmesg >/dev/null 2>&1; then echo "table_full $(dmesg | grep -c 'nf_conntrack: table full')"; fi`,
+	`cat /proc/net/stat/nf_conntrack 2>/dev/null`,
+}
+
+// ConntrackCheckOptions contains options for the conntrack and node-local
+// DNS check
+type ConntrackCheckOptions struct {
+	Selector  string // Only nodes matching this label selector
+	Method    string // auto, ssm or pod
+	Region    string // Defaults to the region in the nodes' provider IDs
+	Profile   string
+	Namespace string // Where collector pods run; must admit hostNetwork and privileged pods
+	Image     string
+	Threshold int // Conntrack table usage, in percent, that is flagged
+	Parallel  int // Collector pods run at once
+	Timeout   time.Duration
+	Output    string // table or json
+	FailOn    string // Lowest severity that fails the run: error, warning, info or none
+}
+
+// conntrackStats is the conntrack state of one node. The counters are
+// cumulative since the node booted.
+type conntrackStats struct {
+	count        int64
+	max          int64
+	insertFailed int64 // Lost races between packets of the same new flow, the classic UDP DNS drop
+	drop         int64 // Packets dropped because the table was full
+	earlyDrop    int64 // Entries evicted early to make room
+	tableFull    int64 // "table full" kernel messages, -1 when the kernel log could not be read
+}
+
+// nodeLocalDNSStats are the cache and forwarding metrics of the
+// node-local-dns pod of one node
+type nodeLocalDNSStats struct {
+	pod       string
+	hits      float64
+	misses    float64
+	rejects   float64 // Queries refused because too many were being forwarded
+	servfail  float64
+	responses float64
+	err       error
+}
+
+// conntrackNode is what was collected from one node
+type conntrackNode struct {
+	name     string
+	method   string // ssm or pod
+	stats    conntrackStats
+	err      error
+	dns      *nodeLocalDNSStats // nil when no node-local-dns pod runs on the node
+	usagePct float64
+}
+
+// CheckConntrack collects the conntrack table usage and drop counters of
+// every node, through SSM or a short-lived hostNetwork pod, together with the
+// cache and forwarding metrics of node-local-dns, and flags nodes close to
+// conntrack saturation or dropping DNS queries: a recurring cause of
+// intermittent timeouts that don't show up in any pod's logs.
+func CheckConntrack(options ConntrackCheckOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	if options.Image == "" {
+		options.Image = DefaultConntrackImage
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+	nodeList, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: options.Selector})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	var nodes []*conntrackNode
+	var targets []corev1.Node
+	var skipped []string
+	for _, node := range nodeList.Items {
+		if isFargateNode(node) {
+			skipped = append(skipped, node.Name)
+			continue
+		}
+		targets = append(targets, node)
+		nodes = append(nodes, &conntrackNode{name: node.Name})
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("no nodes to check (Fargate nodes can't be checked)")
+	}
+	if options.Output != "json" {
+		fmt.Printf("Collecting conntrack stats from %d nodes...\n", len(nodes))
+	}
+	outputs, err := runNodeScript(clientset, targets, conntrackScript, nodeScriptOptions{
+		Method:    options.Method,
+		Region:    options.Region,
+		Profile:   options.Profile,
+		Namespace: options.Namespace,
+		Image:     options.Image,
+		Parallel:  options.Parallel,
+		Timeout:   options.Timeout,
+		Name:      "conntrack-check",
+	})
+	if err != nil {
+		return err
+	}
+	for _, entry := range nodes {
+		output := outputs[entry.name]
+		entry.method, entry.err = output.method, output.err
+		if entry.err == nil {
+			entry.stats, entry.err = parseConntrackOutput(output.output)
+		}
+	}
+
+	dnsPods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{LabelSelector: "k8s-app=node-local-dns"})
+	if err != nil {
+		return fmt.Errorf("failed to list node-local-dns pods: %w", err)
+	}
+	dnsByNode := make(map[string]*nodeLocalDNSStats)
+	for _, pod := range dnsPods.Items {
+		if pod.Status.Phase == corev1.PodRunning {
+			dnsByNode[pod.Spec.NodeName] = collectNodeLocalDNSStats(clientset, pod)
+		}
+	}
+
+	var findings []result.Finding
+	if len(dnsPods.Items) == 0 {
+		findings = append(findings, result.Finding{
+			Check:    "nodelocaldns-not-installed",
+			Severity: result.SeverityInfo,
+			Resource: "cluster",
+			Message:  "node-local-dns is not installed, so every DNS query of every pod creates a UDP conntrack entry towards CoreDNS",
+		})
+	}
+	for _, entry := range nodes {
+		entry.dns = dnsByNode[entry.name]
+		resource := "node/" + entry.name
+		if entry.err != nil {
+			findings = append(findings, result.Finding{
+				Check:    "collection-failed",
+				Severity: result.SeverityInfo,
+				Resource: resource,
+				Message:  entry.err.Error(),
+				Details:  map[string]string{"method": entry.method},
+			})
+			continue
+		}
+		stats := entry.stats
+		if stats.max > 0 {
+			entry.usagePct = float64(stats.count) / float64(stats.max) * 100
+		}
+		details := map[string]string{
+			"count":         strconv.FormatInt(stats.count, 10),
+			"max":           strconv.FormatInt(stats.max, 10),
+			"insert_failed": strconv.FormatInt(stats.insertFailed, 10),
+			"drop":          strconv.FormatInt(stats.drop, 10),
+			"early_drop":    strconv.FormatInt(stats.earlyDrop, 10),
+		}
+		if stats.drop > 0 || stats.earlyDrop > 0 || stats.tableFull > 0 {
+			findings = append(findings, result.Finding{
+				Check:    "conntrack-table-full",
+				Severity: result.SeverityError,
+				Resource: resource,
+				Message: fmt.Sprintf("the conntrack table filled up since boot: %d packets dropped, %d entries evicted early; raise net.netfilter.nf_conntrack_max or spread the connections",
+					stats.drop, stats.earlyDrop),
+				Details: details,
+			})
+		}
+		if entry.usagePct >= float64(options.Threshold) {
+			findings = append(findings, result.Finding{
+				Check:    "conntrack-usage",
+				Severity: result.SeverityWarning,
+				Resource: resource,
+				Message:  fmt.Sprintf("conntrack table %.0f%% full (%d of %d entries)", entry.usagePct, stats.count, stats.max),
+				Details:  details,
+			})
+		}
+		if stats.insertFailed > 0 {
+			message := fmt.Sprintf("%d conntrack insertions failed since boot; this race drops UDP packets, typically DNS queries that then time out after 5s", stats.insertFailed)
+			if entry.dns == nil {
+				message += "; node-local-dns avoids it"
+			}
+			findings = append(findings, result.Finding{
+				Check:    "conntrack-insert-failed",
+				Severity: result.SeverityWarning,
+				Resource: resource,
+				Message:  message,
+				Details:  details,
+			})
+		}
+
+		switch dns := entry.dns; {
+		case dns == nil && len(dnsPods.Items) > 0:
+			findings = append(findings, result.Finding{
+				Check:    "nodelocaldns-missing",
+				Severity: result.SeverityWarning,
+				Resource: resource,
+				Message:  "no running node-local-dns pod, so the node's pods can't resolve names through the local cache",
+			})
+		case dns == nil:
+		case dns.err != nil:
+			findings = append(findings, result.Finding{
+				Check:    "collection-failed",
+				Severity: result.SeverityInfo,
+				Resource: "pod/" + dns.pod,
+				Message:  "failed to read node-local-dns metrics: " + dns.err.Error(),
+			})
+		case dns.rejects > 0:
+			findings = append(findings, result.Finding{
+				Check:    "nodelocaldns-forward-rejects",
+				Severity: result.SeverityWarning,
+				Resource: "pod/" + dns.pod,
+				Message:  fmt.Sprintf("node-local-dns refused %.0f queries because too many were being forwarded upstream; raise max_concurrent or check CoreDNS latency", dns.rejects),
+			})
+		case dns.responses > 0 && dns.servfail/dns.responses > 0.01:
+			findings = append(findings, result.Finding{
+				Check:    "nodelocaldns-servfail",
+				Severity: result.SeverityWarning,
+				Resource: "pod/" + dns.pod,
+				Message:  fmt.Sprintf("%.1f%% of node-local-dns responses are SERVFAIL", dns.servfail/dns.responses*100),
+			})
+		}
+	}
+
+	if options.Output == "json" {
+		if err := result.New("conntrack-check", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tVIA\tCONNTRACK\tUSAGE\tINSERT FAILED\tDROPS\tDNS CACHE HITS\tDNS REJECTS")
+	saturated, failed := 0, 0
+	for _, entry := range nodes {
+		if entry.err != nil {
+			failed++
+			fmt.Fprintf(w, "%s\t%s\t⚠️  %s\t\t\t\t\t\n", entry.name, entry.method, entry.err)
+			continue
+		}
+		stats := entry.stats
+		usage := fmt.Sprintf("✅ %.0f%%", entry.usagePct)
+		if entry.usagePct >= float64(options.Threshold) || stats.drop > 0 || stats.earlyDrop > 0 {
+			usage = fmt.Sprintf("❌ %.0f%%", entry.usagePct)
+			saturated++
+		}
+		hits, rejects := "-", "-"
+		if dns := entry.dns; dns != nil && dns.err == nil {
+			if total := dns.hits + dns.misses; total > 0 {
+				hits = fmt.Sprintf("%.0f%%", dns.hits/total*100)
+			}
+			rejects = fmt.Sprintf("%.0f", dns.rejects)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d/%d\t%s\t%d\t%d\t%s\t%s\n", entry.name, entry.method, stats.count, stats.max, usage,
+			stats.insertFailed, stats.drop+stats.earlyDrop, hits, rejects)
+	}
+	w.Flush()
+
+	if len(findings) > 0 {
+		fmt.Println("\nFindings:")
+		for _, finding := range findings {
+			fmt.Printf("  %s %s: %s\n", result.Label(finding.Severity), finding.Resource, finding.Message)
+		}
+	}
+
+	fmt.Println("\n--- Conntrack Summary ---")
+	fmt.Printf("Nodes checked: %d, failed: %d", len(nodes)-failed, failed)
+	if len(skipped) > 0 {
+		fmt.Printf(", Fargate nodes skipped: %d", len(skipped))
+	}
+	fmt.Println()
+	if saturated == 0 {
+		fmt.Printf("✅ No node is above %d%% conntrack usage or has dropped packets\n", options.Threshold)
+	} else {
+		fmt.Printf("❌ %d nodes are near conntrack saturation or have dropped packets\n", saturated)
+	}
+	if len(dnsPods.Items) == 0 {
+		fmt.Println("ℹ️  node-local-dns is not installed")
+	}
+	fmt.Println("Counters are cumulative since each node booted.")
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// parseConntrackOutput reads the output of conntrackScript. The statistics
+// of /proc/net/stat/nf_conntrack are one hexadecimal row per CPU under a
+// header naming the columns, which vary between kernels.
+func parseConntrackOutput(output string) (conntrackStats, error) {
+	stats := conntrackStats{tableFull: -1}
+	var header []string
+	haveCount := false
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "count", "max", "table_full":
+			if len(fields) != 2 {
+				continue
+			}
+			value, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				continue
+			}
+			switch fields[0] {
+			case "count":
+				stats.count, haveCount = value, true
+			case "max":
+				stats.max = value
+			case "table_full":
+				stats.tableFull = value
+			}
+			continue
+		case "entries":
+			header = fields
+			continue
+		}
+		for i, column := range header {
+			if i >= len(fields) {
+				break
+			}
+			value, err := strconv.ParseInt(fields[i], 16, 64)
+			if err != nil {
+				continue
+			}
+			switch column {
+			case "insert_failed":
+				stats.insertFailed += value
+			case "drop":
+				stats.drop += value
+			case "early_drop":
+				stats.earlyDrop += value
+			}
+		}
+	}
+	if !haveCount {
+		return stats, fmt.Errorf("nf_conntrack is not loaded on the node")
+	}
+	return stats, nil
+}
+
+// collectNodeLocalDNSStats reads the Prometheus metrics of a node-local-dns
+// pod through the API server's pod proxy.
+func collectNodeLocalDNSStats(clientset *kubernetes.Clientset, pod corev1.Pod) *nodeLocalDNSStats {
+	stats := &nodeLocalDNSStats{pod: pod.Namespace + "/" + pod.Name}
+	metrics, err := clientset.CoreV1().Pods(pod.Namespace).ProxyGet("http", pod.Name, nodeLocalDNSMetricsPort, "/metrics", nil).DoRaw(context.TODO())
+	if err != nil {
+		stats.err = err
+		return stats
+	}
+	text := string(metrics)
+	stats.hits = sumPrometheusMetric(text, "coredns_cache_hits_total", "")
+	stats.misses = sumPrometheusMetric(text, "coredns_cache_misses_total", "")
+	stats.rejects = sumPrometheusMetric(text, "coredns_forward_max_concurrent_rejects_total", "")
+	stats.servfail = sumPrometheusMetric(text, "coredns_dns_responses_total", `rcode="SERVFAIL"`)
+	stats.responses = sumPrometheusMetric(text, "coredns_dns_responses_total", "")
+	return stats
+}
+
+// sumPrometheusMetric adds up every series of a metric in the Prometheus
+// text format, keeping only series whose labels contain label when set.
+func sumPrometheusMetric(text, name, label string) float64 {
+	sum := 0.0
+	for _, line := range strings.Split(text, "\n") {
+		rest, found := strings.CutPrefix(line, name)
+		if !found || rest == "" || (rest[0] != '{' && rest[0] != ' ') {
+			continue
+		}
+		labels := ""
+		if end := strings.LastIndex(rest, "}"); rest[0] == '{' && end > 0 {
+			labels, rest = rest[:end], rest[end+1:]
+		}
+		if label != "" && !strings.Contains(labels, label) {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		if value, err := strconv.ParseFloat(fields[0], 64); err == nil {
+			sum += value
+		}
+	}
+	return sum
+}
@@ -0,0 +1,77 @@
+package k8s
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/HighonAces/swissarmycli/internal/config"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/ui"
+)
+
+// ContextGuardOptions describes the command about to change or reveal
+// something in the current context
+type ContextGuardOptions struct {
+	Action         string // Command path shown in the banner, e.g. "chaos kill-pods"
+	Namespace      string // Shown in the banner when set
+	YesProd        bool   // Go ahead in protected contexts without asking
+	NonInteractive bool
+	DryRun         bool // Show the banner but never ask, nothing is changed
+}
+
+// GuardContext prints a banner naming the context the command is about to
+// act on. When the context, or the cluster it points at, matches one of the
+// safety.protected_contexts patterns of the config file (*prod* by default),
+// the context name has to be typed back unless --yes-prod was given.
+func GuardContext(options ContextGuardOptions) error {
+	contextName, cluster, err := common.CurrentContext()
+	if err != nil {
+		return fmt.Errorf("failed to determine the current context: %w", err)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	safety, err := cfg.Safety()
+	if err != nil {
+		return err
+	}
+	pattern, protected := matchContextPattern(safety.Protected(), contextName, cluster)
+
+	banner := fmt.Sprintf("⎈ %s → context %s", options.Action, contextName)
+	if options.Namespace != "" {
+		banner += fmt.Sprintf(", namespace %s", options.Namespace)
+	}
+	if protected {
+		banner += fmt.Sprintf(" (protected by %q)", pattern)
+	}
+	ui.Banner(banner, protected)
+
+	if !protected || options.YesProd || options.DryRun {
+		return nil
+	}
+	prompt := fmt.Sprintf("⚠️  %s is a protected context.", contextName)
+	return ui.ConfirmTyped(prompt, contextName, "", "--yes-prod", options.NonInteractive)
+}
+
+// matchContextPattern returns the first pattern matching one of the context
+// or cluster names. Patterns are globs where * matches any run of characters,
+// slashes of EKS ARNs included, and are compared case-insensitively.
+func matchContextPattern(patterns []string, names ...string) (string, bool) {
+	for _, pattern := range patterns {
+		expression := regexp.QuoteMeta(pattern)
+		expression = strings.ReplaceAll(expression, `\*`, ".*")
+		expression = strings.ReplaceAll(expression, `\?`, ".")
+		re, err := regexp.Compile("(?i)^" + expression + "$")
+		if err != nil {
+			continue
+		}
+		for _, name := range names {
+			if name != "" && re.MatchString(name) {
+				return pattern, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,493 @@
+package k8s
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+// apiServiceResource is the aggregated API registration
+var apiServiceResource = schema.GroupVersionResource{Group: "apiregistration.k8s.io", Version: "v1", Resource: "apiservices"}
+
+// ControlPlaneCertOptions contains options for checking the certificates
+// the API server trusts
+type ControlPlaneCertOptions struct {
+	Kubelets bool          // Also check the kubelets' serving certificates
+	Timeout  time.Duration // For each TLS handshake
+	Output   string        // table or json
+	FailOn   string        // Lowest severity that fails the run: error, warning, info or none
+}
+
+// certEndpoint is a server the API server calls over TLS: a webhook, an
+// aggregated API service or a kubelet
+type certEndpoint struct {
+	kind       string // ValidatingWebhook, MutatingWebhook, APIService or Kubelet
+	name       string
+	target     string // Service, URL or node address called
+	serverName string // Name the API server verifies the certificate for
+	caBundle   []byte // PEM CAs the API server trusts for it, nil for the cluster CA
+	insecure   bool   // The API server skips verification
+	chain      []*x509.Certificate
+	err        error
+	findings   []result.Finding
+}
+
+// CheckControlPlaneCerts connects to every admission webhook, aggregated
+// API service and kubelet the API server calls, reads the certificate it
+// presents and verifies it the way the API server does: against the
+// caBundle of the webhook or APIService, or the cluster CA for kubelets,
+// for the service DNS name. Mismatched caBundles and expired certificates
+// break admission, kubectl top or kubectl logs without any alert, so they
+// are errors; certificates expiring soon are warnings. Services are reached
+// through a port-forward to one of their pods, kubelets directly.
+func CheckControlPlaneCerts(options ControlPlaneCertOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // Ends the port-forwards
+
+	endpoints, err := collectWebhookEndpoints(ctx, clientset)
+	if err != nil {
+		return err
+	}
+	apiServices, err := collectAPIServiceEndpoints(ctx)
+	if err != nil {
+		return err
+	}
+	endpoints = append(endpoints, apiServices...)
+	var kubelets []*certEndpoint
+	var clusterCA *x509.CertPool
+	if options.Kubelets {
+		if kubelets, clusterCA, err = collectKubeletEndpoints(ctx, clientset); err != nil {
+			return err
+		}
+		endpoints = append(endpoints, kubelets...)
+	}
+	if options.Output != "json" {
+		fmt.Printf("Checking %d webhook and APIService endpoints and %d kubelets...\n", len(endpoints)-len(kubelets), len(kubelets))
+	}
+
+	// Webhooks of one configuration usually share a service; each is
+	// fetched once
+	fetcher := &certFetcher{clientset: clientset, timeout: options.Timeout, chains: make(map[string]*certFetch)}
+	semaphore := make(chan struct{}, 8)
+	var wg sync.WaitGroup
+	for _, endpoint := range endpoints {
+		if endpoint.err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(endpoint *certEndpoint) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			endpoint.chain, endpoint.err = fetcher.fetch(ctx, endpoint.target, endpoint.serverName)
+		}(endpoint)
+	}
+	wg.Wait()
+
+	var findings []result.Finding
+	unreachableKubelets := 0
+	for _, endpoint := range endpoints {
+		if endpoint.kind == "Kubelet" && endpoint.err != nil {
+			unreachableKubelets++
+			continue
+		}
+		evaluateCertEndpoint(endpoint, clusterCA)
+		findings = append(findings, endpoint.findings...)
+	}
+	if unreachableKubelets > 0 {
+		findings = append(findings, result.Finding{
+			Check:    "kubelet-unreachable",
+			Severity: result.SeverityInfo,
+			Resource: "kubelets",
+			Message:  fmt.Sprintf("%d of %d kubelets could not be reached from here; run from inside the VPC to check them", unreachableKubelets, len(kubelets)),
+		})
+	}
+
+	if options.Output == "json" {
+		if err := result.New("check-cert", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tENDPOINT\tEXPIRES\tSTATUS")
+	for _, endpoint := range endpoints {
+		if endpoint.kind == "Kubelet" && endpoint.err != nil {
+			continue
+		}
+		expires := "-"
+		if len(endpoint.chain) > 0 {
+			notAfter := endpoint.chain[0].NotAfter
+			expires = fmt.Sprintf("%s (%dd)", notAfter.Format(time.DateOnly), int(time.Until(notAfter).Hours()/24))
+		}
+		status, highest := "✅ ok", ""
+		for _, finding := range endpoint.findings {
+			if result.Rank(finding.Severity) > result.Rank(highest) {
+				highest = finding.Severity
+			}
+		}
+		if highest != "" {
+			status = result.Label(highest)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", endpoint.kind, endpoint.name, endpoint.target, expires, status)
+	}
+	w.Flush()
+
+	counts := make(map[string]int)
+	if len(findings) > 0 {
+		fmt.Println("\nFindings:")
+		for _, finding := range findings {
+			counts[finding.Severity]++
+			fmt.Printf("  %s %s: %s\n", result.Label(finding.Severity), finding.Resource, finding.Message)
+		}
+	}
+
+	fmt.Println("\n--- Control Plane Certificate Summary ---")
+	fmt.Printf("Endpoints: %d webhooks and APIServices, %d of %d kubelets reached\n",
+		len(endpoints)-len(kubelets), len(kubelets)-unreachableKubelets, len(kubelets))
+	fmt.Printf("Findings: %d errors, %d warnings, %d info\n", counts[result.SeverityError], counts[result.SeverityWarning], counts[result.SeverityInfo])
+	if counts[result.SeverityError] == 0 && counts[result.SeverityWarning] == 0 {
+		fmt.Printf("✅ Every reachable certificate verifies and is valid for more than %d days\n", certExpiryWarningDays)
+	}
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// collectWebhookEndpoints lists the webhooks of every validating and
+// mutating webhook configuration
+func collectWebhookEndpoints(ctx context.Context, clientset *kubernetes.Clientset) ([]*certEndpoint, error) {
+	validating, err := clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list validating webhook configurations: %w", err)
+	}
+	mutating, err := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mutating webhook configurations: %w", err)
+	}
+	var endpoints []*certEndpoint
+	for _, configuration := range validating.Items {
+		for _, webhook := range configuration.Webhooks {
+			endpoints = append(endpoints, webhookEndpoint("ValidatingWebhook", configuration.Name+"/"+webhook.Name, webhook.ClientConfig))
+		}
+	}
+	for _, configuration := range mutating.Items {
+		for _, webhook := range configuration.Webhooks {
+			endpoints = append(endpoints, webhookEndpoint("MutatingWebhook", configuration.Name+"/"+webhook.Name, webhook.ClientConfig))
+		}
+	}
+	return endpoints, nil
+}
+
+func webhookEndpoint(kind, name string, config admissionregistrationv1.WebhookClientConfig) *certEndpoint {
+	endpoint := &certEndpoint{kind: kind, name: name, caBundle: config.CABundle}
+	switch {
+	case config.Service != nil:
+		port := int32(443)
+		if config.Service.Port != nil {
+			port = *config.Service.Port
+		}
+		endpoint.target = fmt.Sprintf("service/%s/%s:%d", config.Service.Namespace, config.Service.Name, port)
+		endpoint.serverName = fmt.Sprintf("%s.%s.svc", config.Service.Name, config.Service.Namespace)
+	case config.URL != nil:
+		parsed, err := url.Parse(*config.URL)
+		if err != nil {
+			endpoint.target, endpoint.err = *config.URL, fmt.Errorf("invalid webhook URL: %w", err)
+			return endpoint
+		}
+		endpoint.target, endpoint.serverName = parsed.Host, parsed.Hostname()
+		if parsed.Port() == "" {
+			endpoint.target = net.JoinHostPort(parsed.Hostname(), "443")
+		}
+	default:
+		endpoint.err = fmt.Errorf("webhook has neither a service nor a URL")
+	}
+	return endpoint
+}
+
+// collectAPIServiceEndpoints lists the APIServices served by an aggregated
+// API server; the built-in groups served locally are skipped
+func collectAPIServiceEndpoints(ctx context.Context) ([]*certEndpoint, error) {
+	client, err := common.GetDynamicClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	list, err := client.Resource(apiServiceResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list APIServices: %w", err)
+	}
+	var endpoints []*certEndpoint
+	for _, item := range list.Items {
+		service, found, _ := unstructured.NestedMap(item.Object, "spec", "service")
+		if !found || service == nil {
+			continue
+		}
+		namespace, _ := service["namespace"].(string)
+		name, _ := service["name"].(string)
+		port := int64(443)
+		if value, ok := service["port"].(int64); ok {
+			port = value
+		}
+		endpoint := &certEndpoint{
+			kind:       "APIService",
+			name:       item.GetName(),
+			target:     fmt.Sprintf("service/%s/%s:%d", namespace, name, port),
+			serverName: fmt.Sprintf("%s.%s.svc", name, namespace),
+		}
+		endpoint.insecure, _, _ = unstructured.NestedBool(item.Object, "spec", "insecureSkipTLSVerify")
+		if encoded, _, _ := unstructured.NestedString(item.Object, "spec", "caBundle"); encoded != "" {
+			endpoint.caBundle, err = base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				endpoint.err = fmt.Errorf("caBundle is not valid base64: %w", err)
+			}
+		}
+		conditions, _, _ := unstructured.NestedSlice(item.Object, "status", "conditions")
+		for _, raw := range conditions {
+			condition, _ := raw.(map[string]any)
+			if condition["type"] == "Available" && condition["status"] != "True" {
+				endpoint.findings = append(endpoint.findings, result.Finding{
+					Check:    "apiservice-unavailable",
+					Severity: result.SeverityError,
+					Resource: "APIService/" + endpoint.name,
+					Message:  fmt.Sprintf("not available: %v", condition["message"]),
+				})
+			}
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints, nil
+}
+
+// collectKubeletEndpoints returns the serving endpoint of every node's
+// kubelet and the cluster CA that signs bootstrapped kubelet certificates
+func collectKubeletEndpoints(ctx context.Context, clientset *kubernetes.Clientset) ([]*certEndpoint, *x509.CertPool, error) {
+	config, err := common.GetRESTConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	caData := config.CAData
+	if len(caData) == 0 && config.CAFile != "" {
+		if caData, err = os.ReadFile(config.CAFile); err != nil {
+			return nil, nil, fmt.Errorf("failed to read cluster CA: %w", err)
+		}
+	}
+	clusterCA := x509.NewCertPool()
+	clusterCA.AppendCertsFromPEM(caData)
+
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	var endpoints []*certEndpoint
+	for _, node := range nodes.Items {
+		port := node.Status.DaemonEndpoints.KubeletEndpoint.Port
+		if port == 0 {
+			port = 10250
+		}
+		for _, address := range node.Status.Addresses {
+			if address.Type == corev1.NodeInternalIP {
+				endpoints = append(endpoints, &certEndpoint{
+					kind:       "Kubelet",
+					name:       node.Name,
+					target:     net.JoinHostPort(address.Address, fmt.Sprint(port)),
+					serverName: address.Address,
+				})
+				break
+			}
+		}
+	}
+	return endpoints, clusterCA, nil
+}
+
+// certFetch is the chain one target presented, fetched once
+type certFetch struct {
+	once  sync.Once
+	chain []*x509.Certificate
+	err   error
+}
+
+// certFetcher reads the certificate chains servers present
+type certFetcher struct {
+	clientset *kubernetes.Clientset
+	timeout   time.Duration
+	mu        sync.Mutex
+	chains    map[string]*certFetch
+}
+
+// fetch returns the chain a target presents. Service targets,
+// service/<namespace>/<name>:<port>, are reached through a port-forward.
+func (f *certFetcher) fetch(ctx context.Context, target, serverName string) ([]*x509.Certificate, error) {
+	f.mu.Lock()
+	entry, ok := f.chains[target]
+	if !ok {
+		entry = &certFetch{}
+		f.chains[target] = entry
+	}
+	f.mu.Unlock()
+	entry.once.Do(func() {
+		address := target
+		if service, ok := strings.CutPrefix(target, "service/"); ok {
+			address, entry.err = f.forward(ctx, service)
+			if entry.err != nil {
+				return
+			}
+		}
+		entry.chain, entry.err = peerCertificates(address, serverName, f.timeout)
+	})
+	return entry.chain, entry.err
+}
+
+func (f *certFetcher) forward(ctx context.Context, service string) (string, error) {
+	namespace, rest, _ := strings.Cut(service, "/")
+	name, portText, _ := strings.Cut(rest, ":")
+	var port int32
+	fmt.Sscan(portText, &port)
+	svc, err := f.clientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get service %s/%s: %w", namespace, name, err)
+	}
+	servicePort, err := loadTestServicePort(svc, port)
+	if err != nil {
+		return "", err
+	}
+	return forwardServicePort(ctx, f.clientset, svc, servicePort)
+}
+
+// peerCertificates completes a TLS handshake without verifying and returns
+// the chain the server presented, to be verified like the API server would
+func peerCertificates(address, serverName string, timeout time.Duration) ([]*x509.Certificate, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", address, &tls.Config{ServerName: serverName, InsecureSkipVerify: true})
+	if err != nil {
+		return nil, fmt.Errorf("TLS handshake failed: %w", err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates, nil
+}
+
+// evaluateCertEndpoint verifies the chain an endpoint presented against
+// the CAs the API server trusts for it and checks the expiry of both
+func evaluateCertEndpoint(endpoint *certEndpoint, clusterCA *x509.CertPool) {
+	resource := endpoint.kind + "/" + endpoint.name
+	add := func(check, severity, message string) {
+		endpoint.findings = append(endpoint.findings, result.Finding{
+			Check:    check,
+			Severity: severity,
+			Resource: resource,
+			Message:  message,
+			Details:  map[string]string{"endpoint": endpoint.target},
+		})
+	}
+	if endpoint.err != nil {
+		add("cert-unreachable", result.SeverityWarning, endpoint.err.Error())
+		return
+	}
+	if len(endpoint.chain) == 0 {
+		add("cert-unreachable", result.SeverityWarning, "the server presented no certificate")
+		return
+	}
+	leaf := endpoint.chain[0]
+	switch days := int(time.Until(leaf.NotAfter).Hours() / 24); {
+	case time.Now().After(leaf.NotAfter):
+		add("cert-expired", result.SeverityError, fmt.Sprintf("serving certificate expired %s", leaf.NotAfter.Format(time.DateOnly)))
+	case days <= certExpiryWarningDays:
+		add("cert-expiring", result.SeverityWarning, fmt.Sprintf("serving certificate expires in %d days", days))
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range endpoint.chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	roots := clusterCA
+	if endpoint.kind != "Kubelet" {
+		if endpoint.insecure {
+			add("apiservice-insecure", result.SeverityWarning, "insecureSkipTLSVerify is set; the API server doesn't verify this server")
+			return
+		}
+		if len(endpoint.caBundle) == 0 {
+			if endpoint.kind == "APIService" || strings.HasPrefix(endpoint.target, "service/") {
+				add("cabundle-missing", result.SeverityError, "caBundle is empty; the API server can't verify the serving certificate")
+				return
+			}
+			// URL webhooks without a caBundle use the system roots
+			roots = nil
+		} else {
+			roots = x509.NewCertPool()
+			cas, err := parsePEMCertificates(endpoint.caBundle)
+			if err != nil {
+				add("cabundle-invalid", result.SeverityError, "caBundle is unusable: "+err.Error())
+				return
+			}
+			for _, ca := range cas {
+				roots.AddCert(ca)
+				if time.Now().After(ca.NotAfter) {
+					add("cabundle-expired", result.SeverityError, fmt.Sprintf("caBundle CA %s expired %s", ca.Subject.CommonName, ca.NotAfter.Format(time.DateOnly)))
+				}
+			}
+		}
+	}
+
+	if endpoint.kind == "Kubelet" {
+		// Kubelets without serverTLSBootstrap serve a self-signed certificate
+		if _, err := leaf.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates}); err != nil {
+			add("kubelet-self-signed", result.SeverityInfo, fmt.Sprintf("serving certificate is not signed by the cluster CA (issuer %s); clients must skip verification", leaf.Issuer.CommonName))
+			return
+		}
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{DNSName: endpoint.serverName, Roots: roots, Intermediates: intermediates})
+	var hostnameErr x509.HostnameError
+	var authorityErr x509.UnknownAuthorityError
+	switch {
+	case err == nil:
+	case errors.As(err, &hostnameErr):
+		add("cert-name-mismatch", result.SeverityError, fmt.Sprintf("serving certificate is not valid for %s (names: %s)", endpoint.serverName, strings.Join(certNames(leaf), ", ")))
+	case errors.As(err, &authorityErr) && roots == nil:
+		add("cert-untrusted", result.SeverityError, fmt.Sprintf("serving certificate (issuer %s) is not signed by a publicly trusted CA", leaf.Issuer.CommonName))
+	case errors.As(err, &authorityErr):
+		add("cabundle-mismatch", result.SeverityError, fmt.Sprintf("serving certificate (issuer %s) is not signed by a CA of the caBundle", leaf.Issuer.CommonName))
+	default:
+		add("cert-invalid", result.SeverityError, err.Error())
+	}
+}
+
+// certNames lists the DNS and IP names a certificate is valid for
+func certNames(cert *x509.Certificate) []string {
+	names := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return []string{"none"}
+	}
+	return names
+}
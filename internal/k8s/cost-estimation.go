@@ -9,6 +9,7 @@ import (
 
 	"github.com/HighonAces/swissarmycli/internal/k8s/common"
 	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/client-go/kubernetes"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -20,35 +21,38 @@ type PricingConfig struct {
 	EC2Pricing map[string]float64 `json:"ec2_pricing"`
 	EBSPricing map[string]float64 `json:"ebs_pricing"`
 	LBPricing  map[string]float64 `json:"lb_pricing"`
+	FargatePricing map[string]float64 `json:"fargate_pricing"` // Hourly rates per vCPU and per GB of memory
+	EKSPricing map[string]float64 `json:"eks_pricing"` // Hourly control plane rates in standard and extended support
 }
 
 type ClusterCostInfo struct {
-	Region        string
-	EC2Instances  []EC2Instance
-	EBSVolumes    []EBSVolume
-	LoadBalancers []LoadBalancer
-	TotalCost     float64
+	Region        string         `json:"region"`
+	EC2Instances  []EC2Instance  `json:"ec2_instances"`
+	EBSVolumes    []EBSVolume    `json:"ebs_volumes"`
+	LoadBalancers []LoadBalancer `json:"load_balancers"`
+	FargateWorkloads []FargateWorkload `json:"fargate_workloads,omitempty"`
+	TotalCost     float64        `json:"total_monthly_cost"`
 }
 
 type EC2Instance struct {
-	InstanceType string
-	Count        int
-	HourlyCost   float64
-	MonthlyCost  float64
+	InstanceType string  `json:"instance_type"`
+	Count        int     `json:"count"`
+	HourlyCost   float64 `json:"hourly_cost"`
+	MonthlyCost  float64 `json:"monthly_cost"`
 }
 
 type EBSVolume struct {
-	VolumeType  string
-	SizeGB      int64
-	Count       int
-	MonthlyCost float64
+	VolumeType  string  `json:"volume_type"`
+	SizeGB      int64   `json:"size_gb"`
+	Count       int     `json:"count"`
+	MonthlyCost float64 `json:"monthly_cost"`
 }
 
 type LoadBalancer struct {
-	Type        string
-	Count       int
-	HourlyCost  float64
-	MonthlyCost float64
+	Type        string  `json:"type"`
+	Count       int     `json:"count"`
+	HourlyCost  float64 `json:"hourly_cost"`
+	MonthlyCost float64 `json:"monthly_cost"`
 }
 
 func loadPricingConfig() (*PricingConfig, error) {
@@ -60,16 +64,28 @@ func loadPricingConfig() (*PricingConfig, error) {
 }
 
 func EstimateClusterCost() error {
+	costInfo, err := CollectClusterCost()
+	if err != nil {
+		return err
+	}
+
+	printCostEstimation(costInfo)
+	return nil
+}
+
+// CollectClusterCost returns the estimated monthly cost of the cluster's
+// instances, volumes and load balancers, priced from the embedded table.
+func CollectClusterCost() (*ClusterCostInfo, error) {
 	clientset, err := common.GetKubernetesClient()
 	if err != nil {
-		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
 
 	costInfo := &ClusterCostInfo{}
 
 	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to list nodes: %w", err)
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
 	}
 	if len(nodes.Items) > 0 {
 		costInfo.Region = nodes.Items[0].Labels["topology.kubernetes.io/region"]
@@ -78,23 +94,25 @@ func EstimateClusterCost() error {
 	fmt.Printf("Analyzing cluster in region: %s\n", costInfo.Region)
 
 	if err := getEC2InstancesFromNodes(clientset, costInfo); err != nil {
-		return fmt.Errorf("failed to get EC2 instances: %w", err)
+		return nil, fmt.Errorf("failed to get EC2 instances: %w", err)
 	}
 
 	if err := getEBSVolumesFromPVs(clientset, costInfo); err != nil {
-		return fmt.Errorf("failed to get EBS volumes: %w", err)
+		return nil, fmt.Errorf("failed to get EBS volumes: %w", err)
 	}
 
 	if err := getLoadBalancersFromServices(clientset, costInfo); err != nil {
-		return fmt.Errorf("failed to get load balancers: %w", err)
+		return nil, fmt.Errorf("failed to get load balancers: %w", err)
 	}
 
-	if err := calculateCosts(costInfo); err != nil {
-		return fmt.Errorf("failed to calculate costs: %w", err)
+	if err := getFargateWorkloadsFromPods(clientset, costInfo); err != nil {
+		return nil, fmt.Errorf("failed to get Fargate pods: %w", err)
 	}
 
-	printCostEstimation(costInfo)
-	return nil
+	if err := calculateCosts(costInfo); err != nil {
+		return nil, fmt.Errorf("failed to calculate costs: %w", err)
+	}
+	return costInfo, nil
 }
 
 func getEC2InstancesFromNodes(clientset *kubernetes.Clientset, costInfo *ClusterCostInfo) error {
@@ -105,10 +123,7 @@ func getEC2InstancesFromNodes(clientset *kubernetes.Clientset, costInfo *Cluster
 
 	instanceCounts := make(map[string]int)
 	for _, node := range nodes.Items {
-		instanceType := node.Labels["node.kubernetes.io/instance-type"]
-		if instanceType == "" {
-			instanceType = node.Labels["beta.kubernetes.io/instance-type"]
-		}
+		instanceType := getNodeInstanceType(node)
 		if instanceType != "" {
 			instanceCounts[instanceType]++
 		}
@@ -135,16 +150,7 @@ func getEBSVolumesFromPVs(clientset *kubernetes.Clientset, costInfo *ClusterCost
 		return err
 	}
 
-	scToVolumeType := make(map[string]string)
-	for _, sc := range scList.Items {
-		if sc.Provisioner == "ebs.csi.aws.com" || sc.Provisioner == "kubernetes.io/aws-ebs" {
-			volumeType := sc.Parameters["type"]
-			if volumeType == "" {
-				volumeType = "gp3"
-			}
-			scToVolumeType[sc.Name] = volumeType
-		}
-	}
+	scToVolumeType := ebsVolumeTypes(scList.Items)
 
 	volumeInfo := make(map[string]int64)
 	for _, pv := range pvs.Items {
@@ -178,17 +184,7 @@ func getLoadBalancersFromServices(clientset *kubernetes.Clientset, costInfo *Clu
 	lbCounts := make(map[string]int)
 	for _, svc := range services.Items {
 		if svc.Spec.Type == v1.ServiceTypeLoadBalancer {
-			lbType := "classic"
-			
-			if lbTypeAnnotation, ok := svc.Annotations["service.beta.kubernetes.io/aws-load-balancer-type"]; ok {
-				if strings.Contains(lbTypeAnnotation, "nlb") {
-					lbType = "network"
-				} else if strings.Contains(lbTypeAnnotation, "alb") {
-					lbType = "application"
-				}
-			}
-			
-			lbCounts[lbType]++
+			lbCounts[serviceLoadBalancerType(svc)]++
 		}
 	}
 
@@ -202,6 +198,34 @@ func getLoadBalancersFromServices(clientset *kubernetes.Clientset, costInfo *Clu
 	return nil
 }
 
+// ebsVolumeTypes maps the EBS storage classes to the ebs_pricing key of the
+// volumes they provision.
+func ebsVolumeTypes(storageClasses []storagev1.StorageClass) map[string]string {
+	scToVolumeType := make(map[string]string)
+	for _, sc := range storageClasses {
+		if sc.Provisioner == "ebs.csi.aws.com" || sc.Provisioner == "kubernetes.io/aws-ebs" {
+			volumeType := sc.Parameters["type"]
+			if volumeType == "" {
+				volumeType = "gp3"
+			}
+			scToVolumeType[sc.Name] = volumeType
+		}
+	}
+	return scToVolumeType
+}
+
+// serviceLoadBalancerType returns the lb_pricing key of the load balancer a
+// LoadBalancer service provisions: classic unless annotated as an NLB or ALB.
+func serviceLoadBalancerType(svc v1.Service) string {
+	lbTypeAnnotation := svc.Annotations["service.beta.kubernetes.io/aws-load-balancer-type"]
+	if strings.Contains(lbTypeAnnotation, "nlb") {
+		return "network"
+	} else if strings.Contains(lbTypeAnnotation, "alb") {
+		return "application"
+	}
+	return "classic"
+}
+
 func calculateCosts(costInfo *ClusterCostInfo) error {
 	pricing, err := loadPricingConfig()
 	if err != nil {
@@ -240,6 +264,11 @@ func calculateCosts(costInfo *ClusterCostInfo) error {
 		costInfo.TotalCost += costInfo.LoadBalancers[i].MonthlyCost
 	}
 
+	// Fargate workloads are priced when they are collected
+	for _, workload := range costInfo.FargateWorkloads {
+		costInfo.TotalCost += workload.MonthlyCost
+	}
+
 	return nil
 }
 
@@ -265,6 +294,14 @@ func printCostEstimation(costInfo *ClusterCostInfo) {
 			lb.Type, lb.Count, lb.HourlyCost, lb.MonthlyCost)
 	}
 	
+	if len(costInfo.FargateWorkloads) > 0 {
+		fmt.Printf("\nFargate:\n")
+		for _, workload := range costInfo.FargateWorkloads {
+			fmt.Printf("  %s/%s: %d pods - %.2f vCPU, %.1f GB - $%.2f/month\n",
+				workload.Namespace, workload.Name, workload.Pods, workload.VCPU, workload.MemoryGB, workload.MonthlyCost)
+		}
+	}
+
 	fmt.Printf("\nEstimated Monthly Total: $%.2f\n", costInfo.TotalCost)
 	fmt.Println("----------------------------------------------------")
 }
@@ -0,0 +1,501 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+var crdGVR = schema.GroupVersionResource{
+	Group:    "apiextensions.k8s.io",
+	Version:  "v1",
+	Resource: "customresourcedefinitions",
+}
+
+// CRDBrowseOptions contains options for listing CRDs and their instances
+type CRDBrowseOptions struct {
+	Namespace string // Namespace of the instances, all namespaces when empty
+	Selector  string // Label selector for the instances
+	Group     string // Only CRDs whose API group contains this
+	Version   string // Version to read instances with, the storage version when empty
+	Wide      bool   // Also show printer columns with a priority above 0
+	Fields    bool   // Print the spec and status fields of the schema instead of instances
+	Counts    bool   // Count the instances of every CRD when listing them
+}
+
+// crdInfo is the part of a CustomResourceDefinition the browser uses
+type crdInfo struct {
+	name       string // plural.group
+	group      string
+	kind       string
+	plural     string
+	singular   string
+	shortNames []string
+	namespaced bool
+	created    string
+	versions   []crdVersion
+}
+
+type crdVersion struct {
+	name       string
+	served     bool
+	storage    bool
+	deprecated bool
+	columns    []crdColumn
+	schema     map[string]interface{} // openAPIV3Schema
+}
+
+// crdColumn is one additionalPrinterColumn of a version
+type crdColumn struct {
+	name     string
+	colType  string // string, integer, number, boolean or date
+	jsonPath string
+	priority int64
+}
+
+// BrowseCRDs lists the installed CustomResourceDefinitions with their
+// versions and instance counts or, given a CRD by name, plural, kind or
+// short name, renders its instances as a table using the printer columns
+// the CRD declares, the way kubectl would.
+func BrowseCRDs(target string, options CRDBrowseOptions) error {
+	dynamicClient, err := common.GetDynamicClient()
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	list, err := dynamicClient.Resource(crdGVR).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list CRDs: %w", err)
+	}
+	var crds []crdInfo
+	for _, item := range list.Items {
+		crd := parseCRD(item)
+		if options.Group == "" || strings.Contains(crd.group, options.Group) {
+			crds = append(crds, crd)
+		}
+	}
+	sort.Slice(crds, func(i, j int) bool {
+		if crds[i].group != crds[j].group {
+			return crds[i].group < crds[j].group
+		}
+		return crds[i].plural < crds[j].plural
+	})
+
+	if target == "" {
+		return listCRDs(dynamicClient, crds, options)
+	}
+	crd, err := findCRD(crds, target)
+	if err != nil {
+		return err
+	}
+	version, err := crdBrowseVersion(crd, options.Version)
+	if err != nil {
+		return err
+	}
+	if options.Fields {
+		printCRDFields(crd, version)
+		return nil
+	}
+	return listCRDInstances(dynamicClient, crd, version, options)
+}
+
+// parseCRD reads a CRD from its unstructured form.
+func parseCRD(item unstructured.Unstructured) crdInfo {
+	crd := crdInfo{name: item.GetName(), created: item.GetCreationTimestamp().UTC().Format(time.RFC3339)}
+	crd.group, _, _ = unstructured.NestedString(item.Object, "spec", "group")
+	crd.kind, _, _ = unstructured.NestedString(item.Object, "spec", "names", "kind")
+	crd.plural, _, _ = unstructured.NestedString(item.Object, "spec", "names", "plural")
+	crd.singular, _, _ = unstructured.NestedString(item.Object, "spec", "names", "singular")
+	crd.shortNames, _, _ = unstructured.NestedStringSlice(item.Object, "spec", "names", "shortNames")
+	scope, _, _ := unstructured.NestedString(item.Object, "spec", "scope")
+	crd.namespaced = scope == "Namespaced"
+
+	versions, _, _ := unstructured.NestedSlice(item.Object, "spec", "versions")
+	for _, raw := range versions {
+		v, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		version := crdVersion{}
+		version.name, _, _ = unstructured.NestedString(v, "name")
+		version.served, _, _ = unstructured.NestedBool(v, "served")
+		version.storage, _, _ = unstructured.NestedBool(v, "storage")
+		version.deprecated, _, _ = unstructured.NestedBool(v, "deprecated")
+		version.schema, _, _ = unstructured.NestedMap(v, "schema", "openAPIV3Schema")
+		columns, _, _ := unstructured.NestedSlice(v, "additionalPrinterColumns")
+		for _, rawColumn := range columns {
+			c, ok := rawColumn.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			column := crdColumn{}
+			column.name, _, _ = unstructured.NestedString(c, "name")
+			column.colType, _, _ = unstructured.NestedString(c, "type")
+			column.jsonPath, _, _ = unstructured.NestedString(c, "jsonPath")
+			column.priority, _, _ = unstructured.NestedInt64(c, "priority")
+			version.columns = append(version.columns, column)
+		}
+		crd.versions = append(crd.versions, version)
+	}
+	return crd
+}
+
+// listCRDs prints every CRD with its versions and, optionally, how many
+// instances it has.
+func listCRDs(dynamicClient dynamic.Interface, crds []crdInfo, options CRDBrowseOptions) error {
+	if len(crds) == 0 {
+		fmt.Println("No CRDs installed.")
+		return nil
+	}
+
+	counts := make([]int, len(crds))
+	if options.Counts {
+		semaphore := make(chan struct{}, 8)
+		var wg sync.WaitGroup
+		for i := range crds {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
+				counts[i] = countCRDInstances(dynamicClient, crds[i], options.Namespace)
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tKIND\tSCOPE\tVERSIONS\tINSTANCES\tAGE")
+	total, empty, groups := 0, 0, make(map[string]bool)
+	for i, crd := range crds {
+		groups[crd.group] = true
+		scope := "Cluster"
+		if crd.namespaced {
+			scope = "Namespaced"
+		}
+		var versions []string
+		for _, version := range crd.versions {
+			name := version.name
+			if version.storage {
+				name += "*"
+			}
+			if !version.served {
+				name += " (not served)"
+			} else if version.deprecated {
+				name += " (deprecated)"
+			}
+			versions = append(versions, name)
+		}
+		instances := "-"
+		if options.Counts {
+			instances = "?"
+			if counts[i] >= 0 {
+				instances = fmt.Sprintf("%d", counts[i])
+				total += counts[i]
+				if counts[i] == 0 {
+					empty++
+				}
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", crd.name, crd.kind, scope, strings.Join(versions, ","), instances, formatAge(crd.created))
+	}
+	w.Flush()
+
+	fmt.Println("\n--- CRD Summary ---")
+	fmt.Printf("CRDs: %d in %d API groups (* marks the storage version)\n", len(crds), len(groups))
+	if options.Counts {
+		fmt.Printf("Instances: %d, CRDs without instances: %d\n", total, empty)
+	}
+	fmt.Println("Run 'swissarmycli crd browse <name>' to list the instances of one")
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// countCRDInstances counts the instances of a CRD in its storage version,
+// from the remaining item count of a one-item page when the API server
+// reports it. It returns -1 when they can't be listed.
+func countCRDInstances(dynamicClient dynamic.Interface, crd crdInfo, namespace string) int {
+	version, err := crdBrowseVersion(crd, "")
+	if err != nil {
+		return -1
+	}
+	resource := crdResource(dynamicClient, crd, version, namespace)
+	list, err := resource.List(context.TODO(), metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return -1
+	}
+	if list.GetContinue() == "" {
+		return len(list.Items)
+	}
+	if remaining := list.GetRemainingItemCount(); remaining != nil {
+		return len(list.Items) + int(*remaining)
+	}
+	count, continueToken := 0, ""
+	for {
+		page, err := resource.List(context.TODO(), metav1.ListOptions{Limit: 500, Continue: continueToken})
+		if err != nil {
+			return -1
+		}
+		count += len(page.Items)
+		if continueToken = page.GetContinue(); continueToken == "" {
+			return count
+		}
+	}
+}
+
+func crdResource(dynamicClient dynamic.Interface, crd crdInfo, version crdVersion, namespace string) dynamic.ResourceInterface {
+	gvr := schema.GroupVersionResource{Group: crd.group, Version: version.name, Resource: crd.plural}
+	if crd.namespaced {
+		return dynamicClient.Resource(gvr).Namespace(namespace)
+	}
+	return dynamicClient.Resource(gvr)
+}
+
+// findCRD finds a CRD by its full name, plural, singular, kind or short
+// name, case-insensitively.
+func findCRD(crds []crdInfo, target string) (crdInfo, error) {
+	var matches []crdInfo
+	for _, crd := range crds {
+		names := append([]string{crd.name, crd.plural, crd.singular, crd.kind, crd.plural + "." + crd.group}, crd.shortNames...)
+		for _, name := range names {
+			if strings.EqualFold(name, target) {
+				matches = append(matches, crd)
+				break
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return crdInfo{}, fmt.Errorf("no CRD named %s, run 'swissarmycli crd browse' to list them", target)
+	case 1:
+		return matches[0], nil
+	}
+	var names []string
+	for _, crd := range matches {
+		names = append(names, crd.name)
+	}
+	return crdInfo{}, fmt.Errorf("%s matches several CRDs, use the full name: %s", target, strings.Join(names, ", "))
+}
+
+// crdBrowseVersion returns the requested version, or the storage version if
+// it is served, or else the first served one.
+func crdBrowseVersion(crd crdInfo, requested string) (crdVersion, error) {
+	var served []string
+	for _, version := range crd.versions {
+		if requested != "" && version.name == requested {
+			if !version.served {
+				return crdVersion{}, fmt.Errorf("version %s of %s is not served", requested, crd.name)
+			}
+			return version, nil
+		}
+		if version.served {
+			served = append(served, version.name)
+		}
+	}
+	if requested != "" {
+		return crdVersion{}, fmt.Errorf("%s has no version %s (served: %s)", crd.name, requested, strings.Join(served, ", "))
+	}
+	for _, version := range crd.versions {
+		if version.storage && version.served {
+			return version, nil
+		}
+	}
+	for _, version := range crd.versions {
+		if version.served {
+			return version, nil
+		}
+	}
+	return crdVersion{}, fmt.Errorf("%s has no served version", crd.name)
+}
+
+// listCRDInstances prints the instances of a CRD with its printer columns.
+// Without printer columns it shows the Ready condition when the schema has
+// status conditions, and the age, like kubectl.
+func listCRDInstances(dynamicClient dynamic.Interface, crd crdInfo, version crdVersion, options CRDBrowseOptions) error {
+	list, err := crdResource(dynamicClient, crd, version, options.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: options.Selector})
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", crd.name, err)
+	}
+
+	type column struct {
+		crdColumn
+		parser *jsonpath.JSONPath
+	}
+	var columns []column
+	for _, c := range version.columns {
+		if c.priority > 0 && !options.Wide {
+			continue
+		}
+		parser := jsonpath.New(c.name).AllowMissingKeys(true)
+		if err := parser.Parse("{" + c.jsonPath + "}"); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping column %s, invalid jsonPath %s: %v\n", c.name, c.jsonPath, err)
+			continue
+		}
+		columns = append(columns, column{c, parser})
+	}
+	if len(version.columns) == 0 {
+		if schemaHasPath(version.schema, "status", "conditions") {
+			parser := jsonpath.New("Ready").AllowMissingKeys(true)
+			parser.Parse(`{.status.conditions[?(@.type=="Ready")].status}`)
+			columns = append(columns, column{crdColumn{name: "Ready", colType: "string"}, parser})
+		}
+		parser := jsonpath.New("Age")
+		parser.Parse("{.metadata.creationTimestamp}")
+		columns = append(columns, column{crdColumn{name: "Age", colType: "date"}, parser})
+	}
+
+	fmt.Printf("=== %s (%d) %s/%s ===\n", crd.kind, len(list.Items), crd.group, version.name)
+	if version.deprecated {
+		fmt.Printf("⚠️  %s/%s is deprecated\n", crd.group, version.name)
+	}
+	if len(list.Items) == 0 {
+		return nil
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		if list.Items[i].GetNamespace() != list.Items[j].GetNamespace() {
+			return list.Items[i].GetNamespace() < list.Items[j].GetNamespace()
+		}
+		return list.Items[i].GetName() < list.Items[j].GetName()
+	})
+
+	showNamespace := crd.namespaced && options.Namespace == ""
+	header := []string{"NAME"}
+	if showNamespace {
+		header = append([]string{"NAMESPACE"}, header...)
+	}
+	for _, c := range columns {
+		header = append(header, strings.ToUpper(c.name))
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, item := range list.Items {
+		row := []string{item.GetName()}
+		if showNamespace {
+			row = append([]string{item.GetNamespace()}, row...)
+		}
+		for _, c := range columns {
+			row = append(row, printerColumnValue(c.parser, c.colType, item.Object))
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+	return nil
+}
+
+// printerColumnValue evaluates a printer column on an object and formats
+// the result by the column type; several results are joined with commas.
+func printerColumnValue(parser *jsonpath.JSONPath, colType string, object map[string]interface{}) string {
+	results, err := parser.FindResults(object)
+	if err != nil || len(results) == 0 {
+		return "<none>"
+	}
+	var values []string
+	for _, value := range results[0] {
+		if !value.IsValid() || !value.CanInterface() {
+			continue
+		}
+		switch v := value.Interface().(type) {
+		case nil:
+		case string:
+			if colType == "date" {
+				values = append(values, formatAge(v))
+			} else {
+				values = append(values, v)
+			}
+		case map[string]interface{}, []interface{}:
+			data, _ := json.Marshal(v)
+			values = append(values, string(data))
+		default:
+			values = append(values, fmt.Sprintf("%v", v))
+		}
+	}
+	if len(values) == 0 {
+		return "<none>"
+	}
+	return strings.Join(values, ",")
+}
+
+// schemaHasPath reports whether an OpenAPI schema declares the nested
+// property.
+func schemaHasPath(schemaMap map[string]interface{}, path ...string) bool {
+	current := schemaMap
+	for _, name := range path {
+		next, found, _ := unstructured.NestedMap(current, "properties", name)
+		if !found {
+			return false
+		}
+		current = next
+	}
+	return true
+}
+
+// printCRDFields prints the spec and status fields of a version's schema,
+// two levels deep, with their types and the start of their description.
+func printCRDFields(crd crdInfo, version crdVersion) {
+	fmt.Printf("=== %s %s/%s ===\n", crd.kind, crd.group, version.name)
+	if version.schema == nil {
+		fmt.Println("The CRD has no schema.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FIELD\tTYPE\tREQUIRED\tDESCRIPTION")
+	for _, top := range []string{"spec", "status"} {
+		property, found, _ := unstructured.NestedMap(version.schema, "properties", top)
+		if found {
+			printSchemaFields(w, top, property, 0, false)
+		}
+	}
+	w.Flush()
+	if len(version.columns) > 0 {
+		var names []string
+		for _, column := range version.columns {
+			names = append(names, column.name+" ("+column.jsonPath+")")
+		}
+		fmt.Printf("\nPrinter columns: %s\n", strings.Join(names, ", "))
+	}
+}
+
+func printSchemaFields(w *tabwriter.Writer, path string, property map[string]interface{}, depth int, required bool) {
+	fieldType, _, _ := unstructured.NestedString(property, "type")
+	description, _, _ := unstructured.NestedString(property, "description")
+	if items, found, _ := unstructured.NestedMap(property, "items"); found && fieldType == "array" {
+		itemType, _, _ := unstructured.NestedString(items, "type")
+		fieldType = "[]" + itemType
+		property = items // Fields of the elements are listed below the array
+	}
+	if first, _, ok := strings.Cut(description, "\n"); ok {
+		description = first
+	}
+	if len(description) > 80 {
+		description = description[:77] + "..."
+	}
+	requiredText := ""
+	if required {
+		requiredText = "yes"
+	}
+	fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\n", strings.Repeat("  ", depth), path, valueOrDash(fieldType), requiredText, description)
+
+	if depth >= 2 {
+		return
+	}
+	properties, _, _ := unstructured.NestedMap(property, "properties")
+	requiredFields, _, _ := unstructured.NestedStringSlice(property, "required")
+	for _, name := range sortedKeys(properties) {
+		child, ok := properties[name].(map[string]interface{})
+		if ok {
+			printSchemaFields(w, name, child, depth+1, containsString(requiredFields, name))
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/HighonAces/swissarmycli/internal/config"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// CriticalityCheckOptions contains options for the critical workload check
+type CriticalityCheckOptions struct {
+	MinReplicas int    // Overrides criticality.min_replicas of the config file when set
+	MinZones    int    // Overrides criticality.min_zones of the config file when set
+	Output      string // table or json
+	FailOn      string // Lowest severity that fails the run: error, warning, info or none
+}
+
+// criticalityCheck is the outcome of one check on one Deployment
+type criticalityCheck struct {
+	id       string
+	passed   bool
+	severity string // Severity when failed
+	cell     string // Table cell
+	message  string // Why it failed
+}
+
+// CheckCriticalWorkloads verifies that every Deployment listed under
+// criticality.deployments in the config file can lose a pod or a zone
+// without an outage: enough ready replicas spread over enough zones, a
+// PodDisruptionBudget, resource requests, a priority class, and readiness
+// and liveness probes on every container.
+func CheckCriticalWorkloads(options CriticalityCheckOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	policy, err := cfg.Criticality()
+	if err != nil {
+		return err
+	}
+	if len(policy.Deployments) == 0 {
+		return fmt.Errorf("no critical deployments configured; list them as namespace/name under criticality.deployments in %s", config.Path())
+	}
+	minReplicas, minZones := policy.Replicas(), policy.Zones()
+	if options.MinReplicas > 0 {
+		minReplicas = options.MinReplicas
+	}
+	if options.MinZones > 0 {
+		minZones = options.MinZones
+	}
+
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	nodeZones := make(map[string]string)
+	for _, node := range nodes.Items {
+		nodeZones[node.Name] = getNodeZone(node)
+	}
+
+	checksByDeployment := make(map[string][]criticalityCheck)
+	var findings []result.Finding
+	for _, ref := range policy.Deployments {
+		namespace, name, ok := strings.Cut(ref, "/")
+		if !ok || namespace == "" || name == "" {
+			return fmt.Errorf("invalid critical deployment '%s', expected namespace/name", ref)
+		}
+		resource := fmt.Sprintf("%s/Deployment/%s", namespace, name)
+		deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			findings = append(findings, result.Finding{
+				Check:    "deployment-exists",
+				Severity: result.SeverityError,
+				Resource: resource,
+				Message:  "the critical deployment does not exist",
+			})
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get deployment %s: %w", ref, err)
+		}
+		selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+		if err != nil {
+			return fmt.Errorf("invalid selector of deployment %s: %w", ref, err)
+		}
+		pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return fmt.Errorf("failed to list pods of deployment %s: %w", ref, err)
+		}
+		pdbs, err := clientset.PolicyV1().PodDisruptionBudgets(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list PodDisruptionBudgets in %s: %w", namespace, err)
+		}
+
+		checks := runCriticalityChecks(deployment, pods.Items, pdbs.Items, nodeZones, minReplicas, minZones)
+		checksByDeployment[ref] = checks
+		for _, check := range checks {
+			if !check.passed {
+				findings = append(findings, result.Finding{
+					Check:    check.id,
+					Severity: check.severity,
+					Resource: resource,
+					Message:  check.message,
+				})
+			}
+		}
+	}
+
+	if options.Output == "json" {
+		if err := result.New("criticality-check", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	fmt.Printf("Critical deployments need %d ready replicas in %d zones\n\n", minReplicas, minZones)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DEPLOYMENT\tREPLICAS\tZONES\tPDB\tREQUESTS\tPRIORITY CLASS\tPROBES")
+	compliant := 0
+	for _, ref := range policy.Deployments {
+		checks, ok := checksByDeployment[ref]
+		if !ok {
+			fmt.Fprintf(w, "%s\t❌ not found\t\t\t\t\t\n", ref)
+			continue
+		}
+		fmt.Fprint(w, ref)
+		allPassed := true
+		for _, check := range checks {
+			fmt.Fprintf(w, "\t%s", check.cell)
+			allPassed = allPassed && check.passed
+		}
+		fmt.Fprintln(w)
+		if allPassed {
+			compliant++
+		}
+	}
+	w.Flush()
+
+	if len(findings) > 0 {
+		fmt.Println("\nFindings:")
+		for _, finding := range findings {
+			fmt.Printf("  %s %s: %s\n", result.Label(finding.Severity), finding.Resource, finding.Message)
+		}
+	}
+
+	fmt.Println("\n--- Criticality Summary ---")
+	fmt.Printf("Critical deployments: %d, compliant: %d\n", len(policy.Deployments), compliant)
+	if compliant == len(policy.Deployments) {
+		fmt.Println("✅ Every critical deployment has a warm standby")
+	} else {
+		fmt.Printf("❌ %d critical deployments could go down with a single pod, node or zone\n", len(policy.Deployments)-compliant)
+	}
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// runCriticalityChecks checks one Deployment, in the order of the table
+// columns.
+func runCriticalityChecks(deployment *appsv1.Deployment, pods []corev1.Pod, pdbs []policyv1.PodDisruptionBudget, nodeZones map[string]string, minReplicas, minZones int) []criticalityCheck {
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	ready := int(deployment.Status.ReadyReplicas)
+
+	replicas := criticalityCheck{id: "replicas", severity: result.SeverityError, passed: true, cell: fmt.Sprintf("✅ %d/%d", ready, desired)}
+	switch {
+	case int(desired) < minReplicas:
+		replicas.passed, replicas.cell = false, fmt.Sprintf("❌ %d/%d", ready, desired)
+		replicas.message = fmt.Sprintf("runs %d replicas, needs at least %d", desired, minReplicas)
+	case ready < minReplicas:
+		replicas.passed, replicas.cell = false, fmt.Sprintf("❌ %d/%d", ready, desired)
+		replicas.message = fmt.Sprintf("only %d of %d replicas are ready, needs at least %d", ready, desired, minReplicas)
+	}
+
+	zones := make(map[string]bool)
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || pod.DeletionTimestamp != nil {
+			continue
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue && nodeZones[pod.Spec.NodeName] != "" {
+				zones[nodeZones[pod.Spec.NodeName]] = true
+			}
+		}
+	}
+	spread := criticalityCheck{id: "zones", severity: result.SeverityError, passed: true, cell: fmt.Sprintf("✅ %d", len(zones))}
+	if len(zones) < minZones {
+		spread.passed, spread.cell = false, fmt.Sprintf("❌ %d", len(zones))
+		spread.message = fmt.Sprintf("ready replicas run in %d zones (%s), needs at least %d; add a topologySpreadConstraint on %s",
+			len(zones), valueOrDash(strings.Join(sortedKeys(zones), ",")), minZones, zoneLabel)
+	}
+
+	budget := criticalityCheck{id: "pdb", severity: result.SeverityError, cell: "❌ none", message: "no PodDisruptionBudget covers its pods"}
+	podLabels := labels.Set(deployment.Spec.Template.Labels)
+	for _, pdb := range pdbs {
+		if selectorMatches(pdb.Spec.Selector, podLabels) {
+			budget.passed, budget.cell, budget.message = true, "✅ "+pdb.Name, ""
+			break
+		}
+	}
+
+	podSpec := deployment.Spec.Template.Spec
+	var noRequests, noReadiness, noLiveness []string
+	for _, container := range podSpec.Containers {
+		requests := container.Resources.Requests
+		if requests.Cpu().IsZero() || requests.Memory().IsZero() {
+			noRequests = append(noRequests, container.Name)
+		}
+		if container.ReadinessProbe == nil {
+			noReadiness = append(noReadiness, container.Name)
+		}
+		if container.LivenessProbe == nil {
+			noLiveness = append(noLiveness, container.Name)
+		}
+	}
+
+	requests := criticalityCheck{id: "requests", severity: result.SeverityWarning, passed: true, cell: "✅"}
+	if len(noRequests) > 0 {
+		requests.passed, requests.cell = false, "❌ "+strings.Join(noRequests, ",")
+		requests.message = "containers without CPU and memory requests: " + strings.Join(noRequests, ", ")
+	}
+
+	priority := criticalityCheck{id: "priority-class", severity: result.SeverityWarning, passed: true, cell: "✅ " + podSpec.PriorityClassName}
+	if podSpec.PriorityClassName == "" {
+		priority.passed, priority.cell = false, "❌ none"
+		priority.message = "no priority class, so its pods can be preempted by anything and are not scheduled first"
+	}
+
+	probes := criticalityCheck{id: "probes", severity: result.SeverityWarning, passed: true, cell: "✅"}
+	var missing []string
+	if len(noReadiness) > 0 {
+		missing = append(missing, "readiness on "+strings.Join(noReadiness, ","))
+	}
+	if len(noLiveness) > 0 {
+		missing = append(missing, "liveness on "+strings.Join(noLiveness, ","))
+	}
+	if len(missing) > 0 {
+		probes.passed, probes.cell = false, "❌ "+strings.Join(missing, "; ")
+		probes.message = "missing probes: " + strings.Join(missing, "; ")
+	}
+
+	return []criticalityCheck{replicas, spread, budget, requests, priority, probes}
+}
@@ -0,0 +1,288 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/ui"
+	"golang.org/x/term"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// DefaultDebugImage is a toolbox image with common network and process tools
+const DefaultDebugImage = "nicolaka/netshoot"
+
+// debugStartTimeout is how long to wait for the debug container to start,
+// including pulling its image
+const debugStartTimeout = 3 * time.Minute
+
+// DebugOptions contains options for the debug command
+type DebugOptions struct {
+	Namespace string
+	Image     string
+	Target    string // Container whose processes the ephemeral container shares, default the first
+	Shell     string
+	Copy      bool // Debug a copy of the pod with a relaxed security context instead
+	Keep      bool // Keep the copy after the session ends
+}
+
+// DebugPod starts a toolbox container next to a pod's containers and attaches
+// a shell to it. By default an ephemeral container is added to the running
+// pod; with Copy, a copy of the pod is created with the toolbox as an extra
+// container, probes removed and the security context relaxed, which also
+// works for pods that crash at startup.
+func DebugPod(podName string, options DebugOptions) error {
+	if options.Namespace == "" {
+		options.Namespace = "default"
+	}
+	if options.Image == "" {
+		options.Image = DefaultDebugImage
+	}
+	if options.Shell == "" {
+		options.Shell = "sh"
+	}
+
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+	pod, err := clientset.CoreV1().Pods(options.Namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get pod %s/%s: %w", options.Namespace, podName, err)
+	}
+
+	debugPod, container, err := startDebugContainer(clientset, pod, options)
+	if err != nil {
+		return err
+	}
+	if options.Copy && !options.Keep {
+		defer func() {
+			fmt.Printf("Deleting debug pod %s...\n", debugPod)
+			err := clientset.CoreV1().Pods(options.Namespace).Delete(context.TODO(), debugPod, metav1.DeleteOptions{})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to delete debug pod %s: %v\n", debugPod, err)
+			}
+		}()
+	}
+
+	if err := waitForContainerRunning(clientset, options.Namespace, debugPod, container); err != nil {
+		return err
+	}
+
+	if !ui.IsInteractive() {
+		fmt.Printf("Debug container %s is running in pod %s. Attach with:\n", container, debugPod)
+		fmt.Printf("  kubectl attach -it -n %s %s -c %s\n", options.Namespace, debugPod, container)
+		return nil
+	}
+	fmt.Printf("Attaching to %s in pod %s, exit the shell to end the session...\n", container, debugPod)
+	return attachToContainer(clientset, options.Namespace, debugPod, container)
+}
+
+// startDebugContainer adds the debug container and returns the name of the
+// pod and container to attach to.
+func startDebugContainer(clientset *kubernetes.Clientset, pod *corev1.Pod, options DebugOptions) (string, string, error) {
+	ctx := context.TODO()
+	name := "debugger-" + utilrand.String(5)
+	base := corev1.EphemeralContainerCommon{
+		Name:                     name,
+		Image:                    options.Image,
+		Command:                  []string{options.Shell},
+		Stdin:                    true,
+		TTY:                      true,
+		ImagePullPolicy:          corev1.PullIfNotPresent,
+		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+	}
+
+	if !options.Copy {
+		target := options.Target
+		if target == "" {
+			target = pod.Spec.Containers[0].Name
+		}
+		fmt.Printf("Adding ephemeral container %s (%s) to pod %s, targeting container %s...\n",
+			name, options.Image, pod.Name, target)
+		updated := pod.DeepCopy()
+		updated.Spec.EphemeralContainers = append(updated.Spec.EphemeralContainers, corev1.EphemeralContainer{
+			EphemeralContainerCommon: base,
+			TargetContainerName:      target,
+		})
+		_, err := clientset.CoreV1().Pods(pod.Namespace).UpdateEphemeralContainers(ctx, pod.Name, updated, metav1.UpdateOptions{})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to add ephemeral container (use --copy if the cluster doesn't allow them): %w", err)
+		}
+		return pod.Name, name, nil
+	}
+
+	copied := debugCopyOf(pod, corev1.Container(base))
+	fmt.Printf("Creating debug copy %s of pod %s with container %s (%s)...\n", copied.Name, pod.Name, name, options.Image)
+	if _, err := clientset.CoreV1().Pods(pod.Namespace).Create(ctx, copied, metav1.CreateOptions{}); err != nil {
+		return "", "", fmt.Errorf("failed to create debug pod: %w", err)
+	}
+	return copied.Name, name, nil
+}
+
+// debugCopyOf returns a copy of pod with the debug container added. Labels
+// are dropped so Services and controllers ignore the copy, probes are removed
+// so it isn't restarted while being debugged, and the security context allows
+// running as root and tracing processes.
+func debugCopyOf(pod *corev1.Pod, debugContainer corev1.Container) *corev1.Pod {
+	copied := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        pod.Name + "-debug-" + utilrand.String(5),
+			Namespace:   pod.Namespace,
+			Annotations: map[string]string{"swissarmycli/debug-copy-of": pod.Name},
+		},
+		Spec: *pod.Spec.DeepCopy(),
+	}
+	spec := &copied.Spec
+	spec.NodeName = ""
+	spec.EphemeralContainers = nil
+	spec.RestartPolicy = corev1.RestartPolicyNever
+	shareProcesses := true
+	spec.ShareProcessNamespace = &shareProcesses
+	if spec.SecurityContext != nil {
+		spec.SecurityContext.RunAsNonRoot = nil
+		spec.SecurityContext.RunAsUser = nil
+	}
+
+	for i := range spec.Containers {
+		container := &spec.Containers[i]
+		container.LivenessProbe = nil
+		container.ReadinessProbe = nil
+		container.StartupProbe = nil
+		relaxSecurityContext(container)
+	}
+	relaxSecurityContext(&debugContainer)
+	spec.Containers = append(spec.Containers, debugContainer)
+	return copied
+}
+
+func relaxSecurityContext(container *corev1.Container) {
+	allow := true
+	readOnly := false
+	if container.SecurityContext == nil {
+		container.SecurityContext = &corev1.SecurityContext{}
+	}
+	securityContext := container.SecurityContext
+	securityContext.RunAsNonRoot = nil
+	securityContext.RunAsUser = nil
+	securityContext.AllowPrivilegeEscalation = &allow
+	securityContext.ReadOnlyRootFilesystem = &readOnly
+	if securityContext.Capabilities == nil {
+		securityContext.Capabilities = &corev1.Capabilities{}
+	}
+	securityContext.Capabilities.Drop = nil
+	securityContext.Capabilities.Add = append(securityContext.Capabilities.Add, "SYS_PTRACE", "NET_ADMIN")
+}
+
+// waitForContainerRunning waits until the named container or ephemeral
+// container of the pod is running.
+func waitForContainerRunning(clientset *kubernetes.Clientset, namespace, podName, container string) error {
+	fmt.Print("Waiting for the debug container to start")
+	deadline := time.Now().Add(debugStartTimeout)
+	for time.Now().Before(deadline) {
+		pod, err := clientset.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		if err != nil {
+			fmt.Println()
+			return fmt.Errorf("failed to get pod %s: %w", podName, err)
+		}
+		statuses := append(pod.Status.ContainerStatuses, pod.Status.EphemeralContainerStatuses...)
+		for _, status := range statuses {
+			if status.Name != container {
+				continue
+			}
+			if status.State.Running != nil {
+				fmt.Println(" ✅")
+				return nil
+			}
+			if terminated := status.State.Terminated; terminated != nil {
+				fmt.Println()
+				return fmt.Errorf("debug container exited: %s %s", terminated.Reason, terminated.Message)
+			}
+			if waiting := status.State.Waiting; waiting != nil && (waiting.Reason == "ErrImagePull" || waiting.Reason == "ImagePullBackOff") {
+				fmt.Println()
+				return fmt.Errorf("failed to pull debug image: %s", waiting.Message)
+			}
+		}
+		fmt.Print(".")
+		time.Sleep(2 * time.Second)
+	}
+	fmt.Println()
+	return fmt.Errorf("debug container did not start within %s", debugStartTimeout)
+}
+
+// attachToContainer connects the terminal to the container's TTY.
+func attachToContainer(clientset *kubernetes.Clientset, namespace, podName, container string) error {
+	config, err := common.GetRESTConfig()
+	if err != nil {
+		return err
+	}
+	request := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(podName).
+		SubResource("attach").
+		VersionedParams(&corev1.PodAttachOptions{
+			Container: container,
+			Stdin:     true,
+			Stdout:    true,
+			TTY:       true,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(config, "POST", request.URL())
+	if err != nil {
+		return fmt.Errorf("failed to attach to %s: %w", container, err)
+	}
+
+	stdin := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(stdin)
+	if err != nil {
+		return fmt.Errorf("failed to set terminal to raw mode: %w", err)
+	}
+	defer term.Restore(stdin, state)
+
+	sizes := &terminalSizeQueue{fd: stdin}
+	err = executor.StreamWithContext(context.Background(), remotecommand.StreamOptions{
+		Stdin:             os.Stdin,
+		Stdout:            os.Stdout,
+		Tty:               true,
+		TerminalSizeQueue: sizes,
+	})
+	if err != nil {
+		return fmt.Errorf("debug session failed: %w", err)
+	}
+	return nil
+}
+
+// terminalSizeQueue reports the local terminal size whenever it changes.
+// Polling works the same on every platform, unlike SIGWINCH.
+type terminalSizeQueue struct {
+	fd         int
+	width      int
+	height     int
+	hasStarted bool
+}
+
+func (q *terminalSizeQueue) Next() *remotecommand.TerminalSize {
+	for {
+		if q.hasStarted {
+			time.Sleep(500 * time.Millisecond)
+		}
+		q.hasStarted = true
+		width, height, err := term.GetSize(q.fd)
+		if err != nil {
+			return nil
+		}
+		if width != q.width || height != q.height {
+			q.width, q.height = width, height
+			return &remotecommand.TerminalSize{Width: uint16(width), Height: uint16(height)}
+		}
+	}
+}
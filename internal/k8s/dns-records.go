@@ -0,0 +1,448 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	externalDNSHostnameAnnotation         = "external-dns.alpha.kubernetes.io/hostname"
+	externalDNSInternalHostnameAnnotation = "external-dns.alpha.kubernetes.io/internal-hostname"
+	externalDNSTargetAnnotation           = "external-dns.alpha.kubernetes.io/target"
+)
+
+// Record types external-dns prefixes to the name of its ownership records
+var externalDNSTypePrefixes = []string{"a", "aaaa", "cname", "ns", "mx", "srv", "txt", "ptr", "naptr"}
+
+// DNS record statuses
+const (
+	dnsRecordOK        = "ok"
+	dnsRecordDangling  = "dangling"
+	dnsRecordStale     = "stale"
+	dnsRecordMismatch  = "target mismatch"
+	dnsRecordUnchecked = "not checked"
+)
+
+// DNSRecordsOptions contains options for the external-dns record inventory
+type DNSRecordsOptions struct {
+	Zone      string // Only this hosted zone, by name or ID
+	OwnerID   string // Only records of this external-dns --txt-owner-id
+	TXTPrefix string // external-dns --txt-prefix, when set
+	Region    string
+	Profile   string
+	Output    string // table or json
+	FailOn    string // Lowest severity that fails the run: error, warning, info or none
+}
+
+// managedRecord is a record external-dns owns, and what backs it
+type managedRecord struct {
+	zone     string
+	record   awsutils.DNSRecord
+	owner    string
+	resource string // kind/namespace/name from the ownership record
+	status   string
+	detail   string
+}
+
+// dnsSource is what a Service or Ingress asks external-dns for
+type dnsSource struct {
+	exists  bool
+	hosts   []string
+	targets []string // Load balancer hostnames and IPs, or the target annotation
+}
+
+// ListDNSRecords lists the Route53 records managed by external-dns, found
+// through its TXT ownership records, and maps them back to the Services and
+// Ingresses they were created for. Records whose resource no longer exists
+// are dangling; they keep pointing at a load balancer that may be gone and
+// its name reused by someone else. Records whose resource no longer asks for
+// the name, or points elsewhere, are reported as well.
+func ListDNSRecords(options DNSRecordsOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	sess, err := awsutils.NewSession(options.Profile, options.Region)
+	if err != nil {
+		return err
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	zones, err := awsutils.ListHostedZones(sess)
+	if err != nil {
+		return err
+	}
+	var selected []awsutils.HostedZone
+	for _, zone := range zones {
+		if options.Zone == "" || options.Zone == zone.ID || strings.TrimSuffix(options.Zone, ".") == zone.Name {
+			selected = append(selected, zone)
+		}
+	}
+	if len(selected) == 0 {
+		if options.Zone != "" {
+			return fmt.Errorf("hosted zone %s not found", options.Zone)
+		}
+		return fmt.Errorf("no hosted zones found")
+	}
+
+	var managed []*managedRecord
+	var orphans []managedRecord
+	for _, zone := range selected {
+		records, err := awsutils.ListZoneRecords(sess, zone.ID)
+		if err != nil {
+			return err
+		}
+		zoneManaged, zoneOrphans := matchOwnershipRecords(zone.Name, records, options)
+		managed = append(managed, zoneManaged...)
+		orphans = append(orphans, zoneOrphans...)
+	}
+
+	sources := make(map[string]*dnsSource)
+	for _, record := range managed {
+		if _, ok := sources[record.resource]; !ok {
+			source, err := lookupDNSSource(clientset, record.resource)
+			if err != nil {
+				return err
+			}
+			sources[record.resource] = source
+		}
+		classifyManagedRecord(record, sources[record.resource])
+	}
+	sort.SliceStable(managed, func(i, j int) bool {
+		if managed[i].record.Name != managed[j].record.Name {
+			return managed[i].record.Name < managed[j].record.Name
+		}
+		return managed[i].record.Type < managed[j].record.Type
+	})
+
+	findings := dnsRecordFindings(managed, orphans)
+	if options.Output == "json" {
+		if err := result.New("dns-records", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	if len(managed) == 0 {
+		fmt.Printf("No external-dns records found in %d hosted zones.\n", len(selected))
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		header := "NAME\tTYPE\tTARGET\tRESOURCE\tSTATUS"
+		if options.OwnerID == "" {
+			header = "NAME\tTYPE\tTARGET\tOWNER\tRESOURCE\tSTATUS"
+		}
+		fmt.Fprintln(w, header)
+		for _, record := range managed {
+			owner := ""
+			if options.OwnerID == "" {
+				owner = record.owner + "\t"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s%s\t%s\n", record.record.Name, record.record.Type, truncateList(recordTargets(record.record), 2),
+				owner, valueOrDash(record.resource), dnsRecordStatusLabel(record))
+		}
+		w.Flush()
+	}
+
+	if len(orphans) > 0 {
+		fmt.Printf("\n=== Ownership Records Without a Record (%d) ===\n", len(orphans))
+		for _, orphan := range orphans {
+			fmt.Printf("⚠️  %s (owner %s, resource %s)\n", orphan.record.Name, orphan.owner, valueOrDash(orphan.resource))
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, record := range managed {
+		counts[record.status]++
+	}
+	fmt.Println("\n--- DNS Records Summary ---")
+	fmt.Printf("Hosted zones: %d\n", len(selected))
+	fmt.Printf("Records managed by external-dns: %d\n", len(managed))
+	fmt.Printf("Dangling: %d, stale: %d, target mismatch: %d, not checked: %d\n",
+		counts[dnsRecordDangling], counts[dnsRecordStale], counts[dnsRecordMismatch], counts[dnsRecordUnchecked])
+	fmt.Printf("Ownership records without a record: %d\n", len(orphans))
+	if counts[dnsRecordDangling] > 0 {
+		fmt.Println("Delete dangling records, and their ownership records, unless external-dns is about to: with --policy=upsert-only it never deletes them")
+	}
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// matchOwnershipRecords pairs the external-dns ownership TXT records of a
+// zone with the records they own. Both the old format, a TXT record with the
+// name of the record, and the newer one, with the record type prefixed to
+// the first label, are understood. Ownership records without a record are
+// returned separately.
+func matchOwnershipRecords(zone string, records []awsutils.DNSRecord, options DNSRecordsOptions) ([]*managedRecord, []managedRecord) {
+	byName := make(map[string][]awsutils.DNSRecord)
+	for _, record := range records {
+		if record.Type != "TXT" {
+			byName[record.Name] = append(byName[record.Name], record)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var managed []*managedRecord
+	var orphans []managedRecord
+	for _, txt := range records {
+		if txt.Type != "TXT" {
+			continue
+		}
+		owner, resource, ok := parseOwnershipRecord(txt.Values)
+		if !ok || (options.OwnerID != "" && owner != options.OwnerID) {
+			continue
+		}
+		name := strings.TrimPrefix(txt.Name, strings.ToLower(options.TXTPrefix))
+		owned := byName[name]
+		if len(owned) == 0 {
+			if recordType, rest := splitTypePrefix(name); recordType != "" {
+				for _, record := range byName[rest] {
+					if strings.EqualFold(record.Type, recordType) {
+						owned = append(owned, record)
+					}
+				}
+			}
+		}
+		if len(owned) == 0 {
+			orphans = append(orphans, managedRecord{zone: zone, record: txt, owner: owner, resource: resource})
+			continue
+		}
+		for _, record := range owned {
+			key := record.Name + "|" + record.Type + "|" + record.SetIdentifier
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			managed = append(managed, &managedRecord{zone: zone, record: record, owner: owner, resource: resource})
+		}
+	}
+	return managed, orphans
+}
+
+// parseOwnershipRecord reads the owner and resource from the value of an
+// external-dns TXT record, e.g.
+// "heritage=external-dns,external-dns/owner=prod,external-dns/resource=ingress/web/shop".
+func parseOwnershipRecord(values []string) (string, string, bool) {
+	for _, value := range values {
+		labels := make(map[string]string)
+		for _, pair := range strings.Split(value, ",") {
+			if key, val, ok := strings.Cut(strings.TrimSpace(pair), "="); ok {
+				labels[key] = val
+			}
+		}
+		if labels["heritage"] == "external-dns" {
+			return labels["external-dns/owner"], labels["external-dns/resource"], true
+		}
+	}
+	return "", "", false
+}
+
+// splitTypePrefix splits the record type external-dns prefixes to the first
+// label of newer ownership records, "cname-app.example.com" or
+// "*.cname-example.com" for wildcards, from the record name.
+func splitTypePrefix(name string) (string, string) {
+	wildcard := ""
+	if strings.HasPrefix(name, "*.") {
+		wildcard, name = "*.", name[2:]
+	}
+	for _, recordType := range externalDNSTypePrefixes {
+		if rest, ok := strings.CutPrefix(name, recordType+"-"); ok && rest != "" {
+			return recordType, wildcard + rest
+		}
+	}
+	return "", ""
+}
+
+// lookupDNSSource reads the hostnames and targets a resource of an ownership
+// record asks external-dns for. Resources other than Services and Ingresses
+// are returned as nil.
+func lookupDNSSource(clientset *kubernetes.Clientset, resource string) (*dnsSource, error) {
+	parts := strings.SplitN(resource, "/", 3)
+	if len(parts) != 3 {
+		return nil, nil
+	}
+	kind, namespace, name := parts[0], parts[1], parts[2]
+	ctx := context.TODO()
+	source := &dnsSource{exists: true}
+
+	switch kind {
+	case "ingress":
+		ingress, err := clientset.NetworkingV1().Ingresses(namespace).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return &dnsSource{}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ingress %s/%s: %w", namespace, name, err)
+		}
+		for _, rule := range ingress.Spec.Rules {
+			source.hosts = append(source.hosts, rule.Host)
+		}
+		for _, tls := range ingress.Spec.TLS {
+			source.hosts = append(source.hosts, tls.Hosts...)
+		}
+		source.hosts = append(source.hosts, splitAnnotationList(ingress.Annotations[externalDNSHostnameAnnotation])...)
+		var lbIngress []corev1.LoadBalancerIngress
+		for _, lb := range ingress.Status.LoadBalancer.Ingress {
+			lbIngress = append(lbIngress, corev1.LoadBalancerIngress{Hostname: lb.Hostname, IP: lb.IP})
+		}
+		source.targets = loadBalancerTargets(lbIngress, ingress.Annotations)
+	case "service":
+		service, err := clientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return &dnsSource{}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get service %s/%s: %w", namespace, name, err)
+		}
+		source.hosts = append(splitAnnotationList(service.Annotations[externalDNSHostnameAnnotation]),
+			splitAnnotationList(service.Annotations[externalDNSInternalHostnameAnnotation])...)
+		source.targets = loadBalancerTargets(service.Status.LoadBalancer.Ingress, service.Annotations)
+	default:
+		return nil, nil
+	}
+	for i := range source.hosts {
+		source.hosts[i] = strings.TrimSuffix(strings.ToLower(source.hosts[i]), ".")
+	}
+	return source, nil
+}
+
+// loadBalancerTargets returns the target annotation, or else the hostnames
+// and IPs of the load balancer status.
+func loadBalancerTargets(status []corev1.LoadBalancerIngress, annotations map[string]string) []string {
+	if targets := splitAnnotationList(annotations[externalDNSTargetAnnotation]); len(targets) > 0 {
+		for i := range targets {
+			targets[i] = strings.TrimSuffix(strings.ToLower(targets[i]), ".")
+		}
+		return targets
+	}
+	var targets []string
+	for _, lb := range status {
+		if lb.Hostname != "" {
+			targets = append(targets, strings.ToLower(lb.Hostname))
+		}
+		if lb.IP != "" {
+			targets = append(targets, lb.IP)
+		}
+	}
+	return targets
+}
+
+func splitAnnotationList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// classifyManagedRecord sets the status of a record from its source.
+func classifyManagedRecord(record *managedRecord, source *dnsSource) {
+	switch {
+	case source == nil:
+		record.status = dnsRecordUnchecked
+		if record.resource == "" {
+			record.detail = "the ownership record names no resource"
+		} else {
+			record.detail = "only Services and Ingresses are checked"
+		}
+	case !source.exists:
+		record.status = dnsRecordDangling
+		record.detail = record.resource + " no longer exists"
+	case !containsString(source.hosts, record.record.Name):
+		record.status = dnsRecordStale
+		record.detail = record.resource + " no longer asks for " + record.record.Name
+	case len(source.targets) > 0 && !recordPointsAt(record.record, source.targets):
+		record.status = dnsRecordMismatch
+		record.detail = fmt.Sprintf("points at %s, %s is at %s", truncateList(recordTargets(record.record), 2), record.resource, truncateList(source.targets, 2))
+	default:
+		record.status = dnsRecordOK
+	}
+}
+
+// recordTargets returns the alias target or the values of a record.
+func recordTargets(record awsutils.DNSRecord) []string {
+	if record.AliasTarget != "" {
+		return []string{record.AliasTarget}
+	}
+	return record.Values
+}
+
+// recordPointsAt reports whether a record resolves to one of the targets.
+// Alias records to load balancers carry a dualstack. prefix.
+func recordPointsAt(record awsutils.DNSRecord, targets []string) bool {
+	for _, value := range recordTargets(record) {
+		value = strings.TrimPrefix(strings.TrimSuffix(strings.ToLower(value), "."), "dualstack.")
+		if containsString(targets, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func dnsRecordStatusLabel(record *managedRecord) string {
+	switch record.status {
+	case dnsRecordOK:
+		return "✅"
+	case dnsRecordDangling:
+		return "❌ " + record.detail
+	case dnsRecordUnchecked:
+		return "ℹ️  " + record.status
+	default:
+		return "⚠️  " + record.detail
+	}
+}
+
+// dnsRecordFindings turns dangling, stale and mismatched records and
+// orphaned ownership records into findings.
+func dnsRecordFindings(managed []*managedRecord, orphans []managedRecord) []result.Finding {
+	var findings []result.Finding
+	for _, record := range managed {
+		check, severity := "", ""
+		switch record.status {
+		case dnsRecordDangling:
+			check, severity = "dangling-record", result.SeverityError
+		case dnsRecordStale:
+			check, severity = "stale-record", result.SeverityWarning
+		case dnsRecordMismatch:
+			check, severity = "target-mismatch", result.SeverityWarning
+		case dnsRecordUnchecked:
+			check, severity = "unchecked-record", result.SeverityInfo
+		default:
+			continue
+		}
+		findings = append(findings, result.Finding{
+			Check:    check,
+			Severity: severity,
+			Resource: record.record.Name,
+			Message:  record.detail,
+			Details: map[string]string{"zone": record.zone, "type": record.record.Type, "owner": record.owner,
+				"resource": record.resource, "targets": strings.Join(recordTargets(record.record), ",")},
+		})
+	}
+	for _, orphan := range orphans {
+		findings = append(findings, result.Finding{
+			Check:    "orphaned-ownership-record",
+			Severity: result.SeverityWarning,
+			Resource: orphan.record.Name,
+			Message:  "ownership TXT record without the record it owns",
+			Details:  map[string]string{"zone": orphan.zone, "owner": orphan.owner, "resource": orphan.resource},
+		})
+	}
+	return findings
+}
@@ -0,0 +1,582 @@
+package k8s
+
+import (
+	"context"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Statuses of a doctor check
+const (
+	doctorOK      = "ok"
+	doctorWarning = "warning"
+	doctorFailed  = "failed"
+	doctorInfo    = "info"
+	doctorSkipped = "skipped"
+)
+
+// DoctorOptions contains options for checking the local environment
+type DoctorOptions struct {
+	Profile string
+	Region  string
+	Timeout time.Duration // How long to wait for the API server
+}
+
+// doctorCheck is the outcome of one check of the local environment
+type doctorCheck struct {
+	name   string
+	status string
+	detail string
+	fix    string // What to do when the check doesn't pass
+}
+
+// doctorPermission is an IAM action the commands need
+type doctorPermission struct {
+	action   string
+	commands string
+}
+
+// doctorPermissions are simulated against the caller's IAM policies
+var doctorPermissions = []doctorPermission{
+	{"eks:ListClusters", "connect cluster, clusters list"},
+	{"eks:DescribeCluster", "connect cluster, eks-token"},
+	{"ec2:DescribeInstances", "connect node, asg-status"},
+	{"ec2:DescribeSubnets", "health, ip-lookup"},
+	{"ec2:DescribeNetworkInterfaces", "ip-lookup, flowlogs"},
+	{"autoscaling:DescribeAutoScalingGroups", "asg-status, asg map"},
+	{"autoscaling:DescribeScalingActivities", "asg history"},
+	{"ssm:StartSession", "connect node"},
+	{"ssm:SendCommand", "node bootstrap-logs, node software"},
+	{"elasticloadbalancing:DescribeLoadBalancers", "endpoint-check, lb drain"},
+	{"elasticloadbalancing:DescribeTargetHealth", "endpoint-check, lb drain"},
+	{"acm:ListCertificates", "acm-check"},
+	{"route53:ListHostedZones", "dns-records"},
+	{"ecr:GetAuthorizationToken", "scan-images, arm64-check"},
+}
+
+// doctorAccessChecks are the Kubernetes permissions the commands need
+var doctorAccessChecks = []authorizationv1.ResourceAttributes{
+	{Verb: "list", Resource: "nodes"},
+	{Verb: "list", Resource: "pods"},
+	{Verb: "list", Resource: "events"},
+	{Verb: "create", Resource: "pods", Subresource: "eviction"},
+}
+
+// RunDoctor checks that the local environment can run the commands: the
+// release matches the machine's architecture, the aws CLI,
+// session-manager-plugin and kubectl are installed for the same
+// architecture, the AWS credentials work, the kubeconfig reaches the
+// cluster, metrics-server answers, and the IAM and RBAC permissions the
+// commands need are granted. Every problem found comes with the steps to
+// fix it. Checks that depend on a failed one are skipped.
+func RunDoctor(options DoctorOptions) error {
+	fmt.Println("Checking the local environment...")
+	checks := []doctorCheck{checkBinaryArch()}
+
+	checks = append(checks, checkAWSCLI(), checkSessionManagerPlugin())
+	kubectlCheck, kubectlMinor := checkKubectl()
+	checks = append(checks, kubectlCheck)
+
+	sess, err := awsutils.NewSession(options.Profile, options.Region)
+	var identity awsutils.CallerIdentity
+	credentialsCheck := doctorCheck{name: "aws-credentials"}
+	if err != nil {
+		credentialsCheck.status, credentialsCheck.detail = doctorFailed, err.Error()
+		credentialsCheck.fix = "Check ~/.aws/config for syntax errors"
+	} else {
+		credentialsCheck, identity = checkAWSCredentials(sess, options.Profile)
+	}
+	checks = append(checks, credentialsCheck, checkAWSRegion(sess))
+
+	kubeconfigCheck := checkKubeconfigFile()
+	clusterCheck, clientset, serverMinor := checkClusterAccess(options.Timeout)
+	checks = append(checks, kubeconfigCheck, clusterCheck)
+
+	if kubectlMinor >= 0 && serverMinor >= 0 {
+		checks = append(checks, checkVersionSkew(kubectlMinor, serverMinor))
+	}
+	if clientset != nil {
+		checks = append(checks, checkMetricsServer(clientset), checkRBAC(clientset))
+	} else {
+		checks = append(checks,
+			doctorCheck{name: "metrics-server", status: doctorSkipped, detail: "cluster not reachable"},
+			doctorCheck{name: "rbac", status: doctorSkipped, detail: "cluster not reachable"})
+	}
+	if credentialsCheck.status == doctorOK {
+		checks = append(checks, checkIAMPermissions(sess, identity))
+	} else {
+		checks = append(checks, doctorCheck{name: "iam-permissions", status: doctorSkipped, detail: "no valid AWS credentials"})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nCHECK\tSTATUS\tDETAIL")
+	counts := make(map[string]int)
+	for _, check := range checks {
+		counts[check.status]++
+		fmt.Fprintf(w, "%s\t%s %s\t%s\n", check.name, doctorIcon(check.status), check.status, check.detail)
+	}
+	w.Flush()
+
+	var fixes []string
+	for _, check := range checks {
+		if check.fix != "" && (check.status == doctorFailed || check.status == doctorWarning) {
+			fixes = append(fixes, fmt.Sprintf("  %-18s %s", check.name+":", check.fix))
+		}
+	}
+	if len(fixes) > 0 {
+		fmt.Println("\nHow to fix:")
+		fmt.Println(strings.Join(fixes, "\n"))
+	}
+
+	fmt.Println("\n--- Doctor Summary ---")
+	fmt.Printf("Binary: swissarmycli %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	if identity.Arn != "" {
+		fmt.Printf("AWS identity: %s (account %s)\n", identity.Arn, identity.Account)
+	}
+	fmt.Printf("Checks: %d ok, %d warnings, %d failed, %d skipped\n", counts[doctorOK]+counts[doctorInfo], counts[doctorWarning], counts[doctorFailed], counts[doctorSkipped])
+	if counts[doctorFailed] == 0 && counts[doctorWarning] == 0 {
+		fmt.Println("✅ The environment is ready")
+	}
+	fmt.Println("----------------------------------------------------")
+	if counts[doctorFailed] > 0 {
+		return fmt.Errorf("%d checks failed", counts[doctorFailed])
+	}
+	return nil
+}
+
+func doctorIcon(status string) string {
+	switch status {
+	case doctorOK:
+		return "✅"
+	case doctorWarning:
+		return "⚠️ "
+	case doctorFailed:
+		return "❌"
+	default:
+		return "ℹ️ "
+	}
+}
+
+// checkBinaryArch flags an amd64 build running under Rosetta on Apple
+// silicon, which works but is slower and makes the arch checks of the
+// other tools misleading.
+func checkBinaryArch() doctorCheck {
+	check := doctorCheck{name: "binary", status: doctorOK, detail: runtime.GOOS + "/" + runtime.GOARCH}
+	if runtime.GOOS == "darwin" && runtime.GOARCH == "amd64" {
+		output, err := exec.Command("sysctl", "-n", "sysctl.proc_translated").Output()
+		if err == nil && strings.TrimSpace(string(output)) == "1" {
+			check.status = doctorWarning
+			check.detail += ", running under Rosetta on Apple silicon"
+			check.fix = "Install the darwin-arm64 release of swissarmycli"
+		}
+	}
+	return check
+}
+
+// checkAWSCLI reports the aws CLI version. It is optional: connect cluster
+// writes kubeconfig entries that authenticate through this binary without
+// it. Version 1 lacks 'aws sso login' and EKS token support of current
+// clusters.
+func checkAWSCLI() doctorCheck {
+	check := doctorCheck{name: "aws-cli"}
+	path, err := exec.LookPath("aws")
+	if err != nil {
+		check.status, check.detail = doctorInfo, "not installed, the built-in EKS authentication is used"
+		return check
+	}
+	output, err := exec.Command(path, "--version").CombinedOutput()
+	if err != nil {
+		check.status, check.detail = doctorFailed, fmt.Sprintf("%s does not run: %s", path, firstLine(string(output), err))
+		check.fix = "Reinstall the aws CLI: https://docs.aws.amazon.com/cli/latest/userguide/getting-started-install.html"
+		return check
+	}
+	version := firstLine(string(output), nil)
+	if fields := strings.Fields(version); len(fields) > 0 {
+		version = fields[0]
+	}
+	check.status, check.detail = doctorOK, version
+	if strings.HasPrefix(version, "aws-cli/1.") {
+		check.status = doctorWarning
+		check.fix = "Upgrade to aws CLI v2, which supports 'aws sso login': https://docs.aws.amazon.com/cli/latest/userguide/getting-started-install.html"
+	}
+	checkToolArch(&check, path, "aws CLI")
+	return check
+}
+
+// checkSessionManagerPlugin reports the session-manager-plugin version and
+// architecture. connect node falls back to the built-in client without it.
+func checkSessionManagerPlugin() doctorCheck {
+	check := doctorCheck{name: "ssm-plugin"}
+	path, err := exec.LookPath("session-manager-plugin")
+	if err != nil {
+		check.status, check.detail = doctorInfo, "not installed, connect node uses the built-in client"
+		check.fix = "Sessions requiring KMS encryption need it: https://docs.aws.amazon.com/systems-manager/latest/userguide/session-manager-working-with-install-plugin.html"
+		return check
+	}
+	output, err := exec.Command(path, "--version").CombinedOutput()
+	if err != nil {
+		check.status, check.detail = doctorFailed, fmt.Sprintf("%s does not run: %s", path, firstLine(string(output), err))
+		check.fix = "Reinstall session-manager-plugin for " + runtime.GOOS + "/" + runtime.GOARCH + ", or use connect node --native"
+		return check
+	}
+	check.status, check.detail = doctorOK, strings.TrimSpace(string(output))
+	checkToolArch(&check, path, "session-manager-plugin")
+	return check
+}
+
+// checkKubectl reports the kubectl client version and returns its minor
+// version, or -1 when kubectl isn't usable.
+func checkKubectl() (doctorCheck, int) {
+	check := doctorCheck{name: "kubectl"}
+	path, err := exec.LookPath("kubectl")
+	if err != nil {
+		check.status, check.detail = doctorWarning, "not installed"
+		check.fix = "Install kubectl to follow up on findings: https://kubernetes.io/docs/tasks/tools/"
+		return check, -1
+	}
+	output, err := exec.Command(path, "version", "--client", "-o", "json").Output()
+	var version struct {
+		ClientVersion struct {
+			Minor      string `json:"minor"`
+			GitVersion string `json:"gitVersion"`
+		} `json:"clientVersion"`
+	}
+	if err != nil || json.Unmarshal(output, &version) != nil {
+		check.status, check.detail = doctorFailed, fmt.Sprintf("%s does not run: %s", path, firstLine(string(output), err))
+		check.fix = "Reinstall kubectl for " + runtime.GOOS + "/" + runtime.GOARCH + ": https://kubernetes.io/docs/tasks/tools/"
+		return check, -1
+	}
+	check.status, check.detail = doctorOK, version.ClientVersion.GitVersion
+	checkToolArch(&check, path, "kubectl")
+	return check, parseMinor(version.ClientVersion.Minor)
+}
+
+// checkToolArch compares the architecture of a tool's executable with the
+// machine's. On Linux a mismatched binary doesn't run at all; on macOS it
+// runs under Rosetta. Scripts and unreadable binaries are left alone.
+func checkToolArch(check *doctorCheck, path, tool string) {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	archs, err := executableArchs(path)
+	if err != nil || len(archs) == 0 || containsString(archs, runtime.GOARCH) {
+		return
+	}
+	check.detail += fmt.Sprintf(" (%s build)", strings.Join(archs, ", "))
+	if check.status == doctorOK {
+		check.status = doctorWarning
+		if runtime.GOOS == "linux" {
+			check.status = doctorFailed
+		}
+	}
+	fix := fmt.Sprintf("Install the %s/%s build of the %s", runtime.GOOS, runtime.GOARCH, tool)
+	if check.fix != "" {
+		fix = check.fix + "; " + fix
+	}
+	check.fix = fix
+}
+
+// executableArchs returns the Go architecture names an executable is built
+// for, several for a macOS universal binary.
+func executableArchs(path string) ([]string, error) {
+	if file, err := elf.Open(path); err == nil {
+		defer file.Close()
+		switch file.Machine {
+		case elf.EM_X86_64:
+			return []string{"amd64"}, nil
+		case elf.EM_AARCH64:
+			return []string{"arm64"}, nil
+		case elf.EM_386:
+			return []string{"386"}, nil
+		case elf.EM_ARM:
+			return []string{"arm"}, nil
+		}
+		return []string{file.Machine.String()}, nil
+	}
+	if file, err := macho.OpenFat(path); err == nil {
+		defer file.Close()
+		var archs []string
+		for _, arch := range file.Arches {
+			archs = append(archs, machoArch(arch.Cpu))
+		}
+		return archs, nil
+	}
+	if file, err := macho.Open(path); err == nil {
+		defer file.Close()
+		return []string{machoArch(file.Cpu)}, nil
+	}
+	if file, err := pe.Open(path); err == nil {
+		defer file.Close()
+		switch file.Machine {
+		case pe.IMAGE_FILE_MACHINE_AMD64:
+			return []string{"amd64"}, nil
+		case pe.IMAGE_FILE_MACHINE_ARM64:
+			return []string{"arm64"}, nil
+		case pe.IMAGE_FILE_MACHINE_I386:
+			return []string{"386"}, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not a known executable format", path)
+}
+
+func machoArch(cpu macho.Cpu) string {
+	switch cpu {
+	case macho.CpuAmd64:
+		return "amd64"
+	case macho.CpuArm64:
+		return "arm64"
+	}
+	return cpu.String()
+}
+
+// checkAWSCredentials calls STS to prove the credentials work.
+func checkAWSCredentials(sess *session.Session, profile string) (doctorCheck, awsutils.CallerIdentity) {
+	check := doctorCheck{name: "aws-credentials"}
+	identity, err := awsutils.GetCallerIdentity(sess)
+	if err != nil {
+		check.status, check.detail = doctorFailed, firstLine(err.Error(), nil)
+		loginProfile := ""
+		if profile != "" {
+			loginProfile = " --profile " + profile
+		}
+		switch message := err.Error(); {
+		case strings.Contains(message, "SSO") || strings.Contains(message, "sso") || strings.Contains(message, "token"):
+			check.fix = "Run 'aws sso login" + loginProfile + "'"
+		case strings.Contains(message, "NoCredentialProviders"):
+			check.fix = "Run 'aws configure" + loginProfile + "' or 'aws sso login" + loginProfile + "', or pass --profile"
+		case strings.Contains(message, "ExpiredToken"):
+			check.fix = "The session expired; refresh it with 'aws sso login" + loginProfile + "' or your credential helper"
+		default:
+			check.fix = "Check the credentials of the profile with 'aws sts get-caller-identity" + loginProfile + "'"
+		}
+		return check, identity
+	}
+	check.status, check.detail = doctorOK, identity.Arn
+	return check, identity
+}
+
+// checkAWSRegion checks that a region is configured, which every AWS
+// command without --region needs.
+func checkAWSRegion(sess *session.Session) doctorCheck {
+	check := doctorCheck{name: "aws-region"}
+	if sess == nil {
+		check.status, check.detail = doctorSkipped, "no AWS session"
+		return check
+	}
+	if region := aws.StringValue(sess.Config.Region); region != "" {
+		check.status, check.detail = doctorOK, region
+		return check
+	}
+	check.status, check.detail = doctorWarning, "no default region"
+	check.fix = "Set region in ~/.aws/config or export AWS_REGION, or pass --region to each command"
+	return check
+}
+
+// checkKubeconfigFile checks that a kubeconfig exists, isn't readable by
+// other users and has a current context.
+func checkKubeconfigFile() doctorCheck {
+	check := doctorCheck{name: "kubeconfig"}
+	access := common.ClientConfig().ConfigAccess()
+	paths := access.GetLoadingPrecedence()
+	if explicit := access.GetExplicitFile(); explicit != "" {
+		paths = []string{explicit}
+	}
+	var found, exposed []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		found = append(found, path)
+		if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+			exposed = append(exposed, path)
+		}
+	}
+	if len(found) == 0 {
+		check.status, check.detail = doctorFailed, "not found: "+strings.Join(paths, ", ")
+		check.fix = "Run 'swissarmycli connect cluster <name>' to write one"
+		return check
+	}
+	contextName, _, err := common.CurrentContext()
+	if err != nil {
+		check.status, check.detail = doctorFailed, err.Error()
+		check.fix = "Run 'swissarmycli connect cluster <name>' or 'kubectl config use-context <context>'"
+		return check
+	}
+	check.status, check.detail = doctorOK, fmt.Sprintf("%s, context %s", strings.Join(found, ", "), contextName)
+	if len(exposed) > 0 {
+		check.status = doctorWarning
+		check.detail += ", readable by other users"
+		check.fix = "Run 'chmod 600 " + strings.Join(exposed, " ") + "'"
+	}
+	return check
+}
+
+// checkClusterAccess connects to the API server of the current context and
+// returns a client and the server's minor version, or -1 when it isn't
+// reachable.
+func checkClusterAccess(timeout time.Duration) (doctorCheck, *kubernetes.Clientset, int) {
+	check := doctorCheck{name: "cluster-access"}
+	config, err := common.GetRESTConfig()
+	if err != nil {
+		check.status, check.detail = doctorSkipped, "no usable kubeconfig"
+		return check, nil, -1
+	}
+	config.Timeout = timeout
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		check.status, check.detail = doctorFailed, err.Error()
+		return check, nil, -1
+	}
+	version, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		check.status, check.detail = doctorFailed, firstLine(err.Error(), nil)
+		switch message := err.Error(); {
+		case strings.Contains(message, "executable file not found") || strings.Contains(message, "no such file or directory"):
+			check.fix = "The kubeconfig's credential plugin is missing; run 'swissarmycli connect cluster <name>' to rewrite the entry"
+		case strings.Contains(message, "exec format error"):
+			check.fix = "The kubeconfig's credential plugin is built for another architecture; install the " + runtime.GOOS + "/" + runtime.GOARCH + " build"
+		case apierrors.IsUnauthorized(err) || strings.Contains(message, "Unauthorized"):
+			check.fix = "Refresh the AWS credentials ('aws sso login') and check that your IAM principal has an access entry or aws-auth mapping on the cluster"
+		case strings.Contains(message, "x509"):
+			check.fix = "The cluster's CA changed or the entry is stale; run 'swissarmycli connect cluster <name>' to rewrite it"
+		case strings.Contains(message, "no such host") || strings.Contains(message, "timeout") || strings.Contains(message, "deadline exceeded") || strings.Contains(message, "i/o timeout"):
+			check.fix = "The API server isn't reachable; connect to the VPN if the endpoint is private, or check the endpoint's public access CIDRs"
+		default:
+			check.fix = "Run 'kubectl get --raw /version -v 6' to see the failing request"
+		}
+		return check, nil, -1
+	}
+	check.status, check.detail = doctorOK, fmt.Sprintf("%s, server %s", config.Host, version.GitVersion)
+	return check, clientset, parseMinor(version.Minor)
+}
+
+// checkVersionSkew warns when kubectl is outside the supported skew of one
+// minor version from the API server.
+func checkVersionSkew(clientMinor, serverMinor int) doctorCheck {
+	check := doctorCheck{name: "kubectl-skew", status: doctorOK, detail: fmt.Sprintf("kubectl 1.%d, server 1.%d", clientMinor, serverMinor)}
+	if skew := clientMinor - serverMinor; skew > 1 || skew < -1 {
+		check.status = doctorWarning
+		check.fix = fmt.Sprintf("Install kubectl 1.%d, 1.%d or 1.%d to match the cluster", serverMinor-1, serverMinor, serverMinor+1)
+	}
+	return check
+}
+
+// checkMetricsServer checks that the metrics API is served, which
+// node-usage and the pod-density commands read.
+func checkMetricsServer(clientset *kubernetes.Clientset) doctorCheck {
+	check := doctorCheck{name: "metrics-server"}
+	resources, err := clientset.Discovery().ServerResourcesForGroupVersion("metrics.k8s.io/v1beta1")
+	if err != nil {
+		check.status, check.detail = doctorWarning, "metrics.k8s.io is not served"
+		if !apierrors.IsNotFound(err) {
+			check.detail = firstLine(err.Error(), nil)
+		}
+		check.fix = "Install metrics-server: kubectl apply -f https://github.com/kubernetes-sigs/metrics-server/releases/latest/download/components.yaml"
+		return check
+	}
+	check.status, check.detail = doctorOK, fmt.Sprintf("metrics.k8s.io/v1beta1 serves %d resources", len(resources.APIResources))
+	return check
+}
+
+// checkRBAC asks the API server whether the current user may do what the
+// read-only and node commands need, cluster-wide.
+func checkRBAC(clientset *kubernetes.Clientset) doctorCheck {
+	check := doctorCheck{name: "rbac"}
+	var denied []string
+	for _, attributes := range doctorAccessChecks {
+		attributes := attributes
+		review, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(context.TODO(), &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &attributes},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			check.status, check.detail = doctorWarning, "could not review access: "+firstLine(err.Error(), nil)
+			return check
+		}
+		if !review.Status.Allowed {
+			resource := attributes.Resource
+			if attributes.Subresource != "" {
+				resource += "/" + attributes.Subresource
+			}
+			denied = append(denied, attributes.Verb+" "+resource)
+		}
+	}
+	if len(denied) > 0 {
+		check.status, check.detail = doctorWarning, "denied: "+strings.Join(denied, ", ")
+		check.fix = "Ask a cluster admin for a ClusterRole granting " + strings.Join(denied, ", ")
+		return check
+	}
+	check.status, check.detail = doctorOK, fmt.Sprintf("all %d cluster-wide checks allowed", len(doctorAccessChecks))
+	return check
+}
+
+// checkIAMPermissions simulates the caller's IAM policies for the actions
+// the commands use. Resource-level conditions and SCPs aren't evaluated,
+// so an allowed action can still be denied at call time.
+func checkIAMPermissions(sess *session.Session, identity awsutils.CallerIdentity) doctorCheck {
+	check := doctorCheck{name: "iam-permissions"}
+	principal, _ := awsutils.PrincipalARN(sess, identity.Arn)
+	actions := make([]string, len(doctorPermissions))
+	for i, permission := range doctorPermissions {
+		actions[i] = permission.action
+	}
+	denied, err := awsutils.SimulatePermissions(sess, principal, actions)
+	if err != nil {
+		check.status, check.detail = doctorInfo, "could not simulate policies, iam:SimulatePrincipalPolicy is needed"
+		return check
+	}
+	if len(denied) == 0 {
+		check.status, check.detail = doctorOK, fmt.Sprintf("all %d actions allowed", len(actions))
+		return check
+	}
+	var affected []string
+	for _, permission := range doctorPermissions {
+		if containsString(denied, permission.action) {
+			affected = append(affected, fmt.Sprintf("%s (%s)", permission.action, permission.commands))
+		}
+	}
+	check.status, check.detail = doctorWarning, fmt.Sprintf("%d of %d actions denied: %s", len(denied), len(actions), truncateList(denied, 4))
+	check.fix = "Grant " + principal + ": " + strings.Join(affected, "; ")
+	return check
+}
+
+// parseMinor parses a Kubernetes minor version such as "29+", or returns
+// -1.
+func parseMinor(minor string) int {
+	var value int
+	if _, err := fmt.Sscanf(strings.TrimRight(minor, "+"), "%d", &value); err != nil {
+		return -1
+	}
+	return value
+}
+
+// firstLine returns the first line of a command's output, or the error
+// when there is no output.
+func firstLine(output string, err error) string {
+	if line := strings.TrimSpace(strings.SplitN(strings.TrimSpace(output), "\n", 2)[0]); line != "" {
+		return line
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type dsOverheadInfo struct {
+	nodeName          string
+	instanceType      string
+	podCount          int
+	cpuRequests       float64
+	memoryRequests    float64
+	cpuAllocatable    float64
+	memoryAllocatable float64
+	monthlyNodeCost   float64
+	monthlyCost       float64
+}
+
+// ShowDaemonSetOverhead sums the requests of DaemonSet pods on every node and
+// shows them as a share of the node's allocatable resources and cost.
+func ShowDaemonSetOverhead() error {
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get nodes: %w", err)
+	}
+	pods, err := clientset.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get pods: %w", err)
+	}
+
+	pricing, err := loadPricingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load pricing config: %w", err)
+	}
+
+	overhead := make(map[string]*dsOverheadInfo)
+	for _, node := range nodes.Items {
+		info := &dsOverheadInfo{
+			nodeName:          node.Name,
+			instanceType:      getNodeInstanceType(node),
+			cpuAllocatable:    float64(node.Status.Allocatable.Cpu().MilliValue()) / 1000,
+			memoryAllocatable: float64(node.Status.Allocatable.Memory().Value()) / (1024 * 1024 * 1024),
+		}
+		if price, ok := pricing.EC2Pricing[info.instanceType]; ok {
+			info.monthlyNodeCost = price * 730
+		}
+		overhead[node.Name] = info
+	}
+
+	dsCounts := make(map[string]int)
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.Spec.NodeName == "" {
+			continue
+		}
+		dsName := daemonSetOwner(pod)
+		if dsName == "" {
+			continue
+		}
+		info := overhead[pod.Spec.NodeName]
+		if info == nil {
+			continue
+		}
+
+		info.podCount++
+		dsCounts[pod.Namespace+"/"+dsName]++
+		cpu, memory := podRequests(&pod)
+		info.cpuRequests += cpu
+		info.memoryRequests += memory
+	}
+
+	var infos []*dsOverheadInfo
+	var totalCPU, totalMemory, totalCPUAlloc, totalMemoryAlloc, totalCost float64
+	for _, info := range overhead {
+		// The node is sized by whichever resource the DaemonSets consume more of,
+		// so attribute that share of the node's price to them.
+		info.monthlyCost = info.monthlyNodeCost * maxFloat(
+			safeRatio(info.cpuRequests, info.cpuAllocatable),
+			safeRatio(info.memoryRequests, info.memoryAllocatable))
+
+		totalCPU += info.cpuRequests
+		totalMemory += info.memoryRequests
+		totalCPUAlloc += info.cpuAllocatable
+		totalMemoryAlloc += info.memoryAllocatable
+		totalCost += info.monthlyCost
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].nodeName < infos[j].nodeName
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tINSTANCE TYPE\tDS PODS\tCPU REQUESTS\tMEMORY REQUESTS\tEST. MONTHLY COST")
+	for _, info := range infos {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%.2f (%.0f%%)\t%.2fGi (%.0f%%)\t$%.2f\n",
+			info.nodeName,
+			info.instanceType,
+			info.podCount,
+			info.cpuRequests, safeRatio(info.cpuRequests, info.cpuAllocatable)*100,
+			info.memoryRequests, safeRatio(info.memoryRequests, info.memoryAllocatable)*100,
+			info.monthlyCost)
+	}
+	w.Flush()
+
+	fmt.Printf("\n--- DaemonSet Overhead Across Fleet (%d nodes, %d DaemonSets) ---\n", len(infos), len(dsCounts))
+	fmt.Printf("CPU: %.2f of %.2f allocatable cores (%.1f%%)\n", totalCPU, totalCPUAlloc, safeRatio(totalCPU, totalCPUAlloc)*100)
+	fmt.Printf("Memory: %.2fGi of %.2fGi allocatable (%.1f%%)\n", totalMemory, totalMemoryAlloc, safeRatio(totalMemory, totalMemoryAlloc)*100)
+	fmt.Printf("Estimated Monthly Cost: $%.2f\n", totalCost)
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+func daemonSetOwner(pod corev1.Pod) string {
+	for _, owner := range pod.OwnerReferences {
+		if owner.Kind == "DaemonSet" {
+			return owner.Name
+		}
+	}
+	return ""
+}
+
+func getNodeInstanceType(node corev1.Node) string {
+	instanceType := node.Labels["node.kubernetes.io/instance-type"]
+	if instanceType == "" {
+		instanceType = node.Labels["beta.kubernetes.io/instance-type"]
+	}
+	return instanceType
+}
+
+func safeRatio(value, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return value / total
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,489 @@
+package k8s
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EndpointCheckOptions contains options for probing the public endpoints of
+// Ingresses and LoadBalancer Services
+type EndpointCheckOptions struct {
+	Namespace string
+	Selector  string
+	Kind      string        // ingress, service or all
+	Path      string        // Path requested from HTTP and HTTPS endpoints
+	Resolver  string        // DNS server to resolve with instead of the system resolver
+	Internal  bool          // Also probe endpoints of internal load balancers
+	Timeout   time.Duration // For each probe, from DNS lookup to the first response byte
+	SlowAfter time.Duration // Total time above which an endpoint counts as slow
+	Output    string        // table or json
+	FailOn    string        // Lowest severity that fails the run: error, warning, info or none
+}
+
+// probeEndpoint is one host and port of an Ingress or Service, and what
+// probing it from here found
+type probeEndpoint struct {
+	kind      string // Ingress or Service
+	name      string // namespace/name
+	host      string
+	port      int
+	scheme    string   // https, http or tcp
+	lbTargets []string // Hostnames and IPs of the load balancer in the resource status
+
+	addresses   []string
+	lbAddresses []string
+	dnsTime     time.Duration
+	connectTime time.Duration
+	tlsTime     time.Duration
+	firstByte   time.Duration
+	statusCode  int
+	location    string
+	chain       []*x509.Certificate
+	stage       string // Stage that failed: dns, connect, tls or http
+	err         error
+	findings    []result.Finding
+}
+
+func (e *probeEndpoint) url() string {
+	switch {
+	case e.scheme == "https" && e.port == 443, e.scheme == "http" && e.port == 80:
+		return e.scheme + "://" + e.host
+	case e.scheme == "tcp":
+		return "tcp://" + net.JoinHostPort(e.host, strconv.Itoa(e.port))
+	}
+	return e.scheme + "://" + net.JoinHostPort(e.host, strconv.Itoa(e.port))
+}
+
+func (e *probeEndpoint) total() time.Duration {
+	return e.dnsTime + e.connectTime + e.tlsTime + e.firstByte
+}
+
+// CheckEndpoints probes the public endpoints of Ingresses and LoadBalancer
+// Services from this machine: it resolves each host, compares the answer
+// with the load balancer's addresses to catch records that haven't
+// propagated or point elsewhere, connects, completes the TLS handshake and
+// checks the presented certificate like check-cert does, then requests the
+// path and records the status and the latency of every stage.
+func CheckEndpoints(options EndpointCheckOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	if options.Kind != "all" && options.Kind != "ingress" && options.Kind != "service" {
+		return fmt.Errorf("unsupported kind '%s' (must be ingress, service or all)", options.Kind)
+	}
+	if options.Path == "" || !strings.HasPrefix(options.Path, "/") {
+		options.Path = "/" + options.Path
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+	listOptions := metav1.ListOptions{LabelSelector: options.Selector}
+
+	var endpoints []*probeEndpoint
+	var findings []result.Finding
+	skipped := 0
+	if options.Kind != "service" {
+		ingresses, err := clientset.NetworkingV1().Ingresses(options.Namespace).List(ctx, listOptions)
+		if err != nil {
+			return fmt.Errorf("failed to list ingresses: %w", err)
+		}
+		for _, ingress := range ingresses.Items {
+			if !options.Internal && ingressScheme(ingress, nil) == "internal" {
+				skipped++
+				continue
+			}
+			found := ingressEndpoints(ingress)
+			if len(found) == 0 {
+				findings = append(findings, notProvisionedFinding("Ingress", ingress.Namespace+"/"+ingress.Name))
+			}
+			endpoints = append(endpoints, found...)
+		}
+	}
+	if options.Kind != "ingress" {
+		services, err := clientset.CoreV1().Services(options.Namespace).List(ctx, listOptions)
+		if err != nil {
+			return fmt.Errorf("failed to list services: %w", err)
+		}
+		for _, service := range services.Items {
+			if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+				continue
+			}
+			if !options.Internal && serviceScheme(service, nil) == "internal" {
+				skipped++
+				continue
+			}
+			found := serviceEndpoints(service)
+			if len(found) == 0 {
+				findings = append(findings, notProvisionedFinding("Service", service.Namespace+"/"+service.Name))
+			}
+			endpoints = append(endpoints, found...)
+		}
+	}
+	if len(endpoints) == 0 && len(findings) == 0 {
+		return fmt.Errorf("no public Ingress or LoadBalancer Service endpoints found")
+	}
+
+	resolver := net.DefaultResolver
+	if options.Resolver != "" {
+		server := options.Resolver
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		resolver = &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{Timeout: options.Timeout}).DialContext(ctx, network, server)
+		}}
+	}
+	if options.Output != "json" {
+		fmt.Printf("Probing %d endpoints...\n", len(endpoints))
+	}
+	semaphore := make(chan struct{}, 8)
+	var wg sync.WaitGroup
+	for _, endpoint := range endpoints {
+		wg.Add(1)
+		go func(endpoint *probeEndpoint) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			probe(endpoint, resolver, options)
+		}(endpoint)
+	}
+	wg.Wait()
+
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		if endpoints[i].name != endpoints[j].name {
+			return endpoints[i].name < endpoints[j].name
+		}
+		return endpoints[i].url() < endpoints[j].url()
+	})
+	slow := 0
+	for _, endpoint := range endpoints {
+		evaluateProbe(endpoint, options)
+		findings = append(findings, endpoint.findings...)
+		if endpoint.err == nil && options.SlowAfter > 0 && endpoint.total() > options.SlowAfter {
+			slow++
+		}
+	}
+
+	if options.Output == "json" {
+		if err := result.New("endpoint-check", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RESOURCE\tURL\tADDRESS\tDNS\tCONNECT\tTLS\tRESPONSE\tCERT EXPIRES\tRESULT")
+	failed := 0
+	for _, endpoint := range endpoints {
+		address, connect, handshake, response, expires := "-", "-", "-", "-", "-"
+		if len(endpoint.addresses) > 0 {
+			address = truncateList(endpoint.addresses, 1)
+		}
+		if endpoint.connectTime > 0 {
+			connect = formatLatency(endpoint.connectTime)
+		}
+		if endpoint.tlsTime > 0 {
+			handshake = formatLatency(endpoint.tlsTime)
+		}
+		if endpoint.statusCode > 0 {
+			response = fmt.Sprintf("%d in %s", endpoint.statusCode, formatLatency(endpoint.firstByte))
+		}
+		if len(endpoint.chain) > 0 {
+			notAfter := endpoint.chain[0].NotAfter
+			expires = fmt.Sprintf("%s (%dd)", notAfter.Format(time.DateOnly), int(time.Until(notAfter).Hours()/24))
+		}
+		status, highest := "✅ ok", ""
+		for _, finding := range endpoint.findings {
+			if result.Rank(finding.Severity) > result.Rank(highest) {
+				highest = finding.Severity
+			}
+		}
+		if highest != "" {
+			status = result.Label(highest)
+		}
+		if highest == result.SeverityError {
+			failed++
+		}
+		fmt.Fprintf(w, "%s/%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", endpoint.kind, endpoint.name, endpoint.url(), address,
+			formatLatency(endpoint.dnsTime), connect, handshake, response, expires, status)
+	}
+	w.Flush()
+
+	counts := make(map[string]int)
+	if len(findings) > 0 {
+		fmt.Println("\nFindings:")
+		for _, finding := range findings {
+			counts[finding.Severity]++
+			fmt.Printf("  %s %s: %s\n", result.Label(finding.Severity), finding.Resource, finding.Message)
+		}
+	}
+
+	fmt.Println("\n--- Endpoint Check Summary ---")
+	fmt.Printf("Endpoints probed: %d, failing: %d, slower than %s: %d\n", len(endpoints), failed, options.SlowAfter, slow)
+	if skipped > 0 {
+		fmt.Printf("Internal load balancers skipped: %d (use --internal from inside the VPC)\n", skipped)
+	}
+	fmt.Printf("Findings: %d errors, %d warnings, %d info\n", counts[result.SeverityError], counts[result.SeverityWarning], counts[result.SeverityInfo])
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// ingressEndpoints returns an endpoint per host of an Ingress, or its load
+// balancer's own name when the rules name no host. Hosts with TLS, or all
+// hosts when the ALB has a certificate, are probed over HTTPS.
+func ingressEndpoints(ingress networkingv1.Ingress) []*probeEndpoint {
+	var targets []string
+	for _, lb := range ingress.Status.LoadBalancer.Ingress {
+		targets = append(targets, loadBalancerAddress(lb.Hostname, lb.IP))
+	}
+	tlsHosts := make(map[string]bool)
+	for _, tls := range ingress.Spec.TLS {
+		for _, host := range tls.Hosts {
+			tlsHosts[strings.ToLower(host)] = true
+		}
+	}
+	albHTTPS := ingress.Annotations[albCertificateAnnotation] != "" ||
+		strings.Contains(ingress.Annotations[albAnnotationPrefix+"listen-ports"], "HTTPS")
+
+	var hosts []string
+	for _, rule := range ingress.Spec.Rules {
+		host := strings.ToLower(rule.Host)
+		if host != "" && !strings.HasPrefix(host, "*") && !containsString(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) == 0 && len(targets) > 0 {
+		hosts = targets[:1]
+	}
+
+	var endpoints []*probeEndpoint
+	for _, host := range hosts {
+		endpoint := &probeEndpoint{kind: "Ingress", name: ingress.Namespace + "/" + ingress.Name, host: host, port: 80, scheme: "http", lbTargets: targets}
+		if tlsHosts[host] || albHTTPS {
+			endpoint.port, endpoint.scheme = 443, "https"
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+// serviceEndpoints returns an endpoint per TCP port and host of a
+// LoadBalancer Service, the external-dns hostnames or else the load
+// balancer's own name. Ports are probed over HTTPS or HTTP when their
+// number, name or appProtocol says so, and with a plain connect otherwise.
+func serviceEndpoints(service corev1.Service) []*probeEndpoint {
+	var targets []string
+	for _, lb := range service.Status.LoadBalancer.Ingress {
+		targets = append(targets, loadBalancerAddress(lb.Hostname, lb.IP))
+	}
+	hosts := splitAnnotationList(strings.ToLower(service.Annotations[externalDNSHostnameAnnotation]))
+	if len(hosts) == 0 && len(targets) > 0 {
+		hosts = targets[:1]
+	}
+
+	var endpoints []*probeEndpoint
+	for _, port := range service.Spec.Ports {
+		if port.Protocol != "" && port.Protocol != corev1.ProtocolTCP {
+			continue
+		}
+		protocol := strings.ToLower(port.Name)
+		if port.AppProtocol != nil {
+			protocol = strings.ToLower(*port.AppProtocol)
+		}
+		scheme := "tcp"
+		switch {
+		case port.Port == 443 || port.Port == 8443 || strings.Contains(protocol, "https"):
+			scheme = "https"
+		case port.Port == 80 || port.Port == 8080 || strings.Contains(protocol, "http"):
+			scheme = "http"
+		}
+		for _, host := range hosts {
+			if strings.HasPrefix(host, "*") {
+				continue
+			}
+			endpoints = append(endpoints, &probeEndpoint{kind: "Service", name: service.Namespace + "/" + service.Name,
+				host: host, port: int(port.Port), scheme: scheme, lbTargets: targets})
+		}
+	}
+	return endpoints
+}
+
+func loadBalancerAddress(hostname, ip string) string {
+	if hostname != "" {
+		return strings.ToLower(hostname)
+	}
+	return ip
+}
+
+func notProvisionedFinding(kind, name string) result.Finding {
+	return result.Finding{
+		Check:    "endpoint-not-provisioned",
+		Severity: result.SeverityWarning,
+		Resource: kind + "/" + name,
+		Message:  "has no load balancer address and no host to probe",
+	}
+}
+
+// probe resolves, connects to and requests an endpoint, timing each stage.
+// The chain is read without verification so that certificate problems are
+// reported by evaluateProbe instead of failing the request.
+func probe(endpoint *probeEndpoint, resolver *net.Resolver, options EndpointCheckOptions) {
+	ctx, cancel := context.WithTimeout(context.Background(), options.Timeout)
+	defer cancel()
+	fail := func(stage string, err error) {
+		endpoint.stage, endpoint.err = stage, err
+	}
+
+	start := time.Now()
+	addresses, err := resolver.LookupHost(ctx, endpoint.host)
+	endpoint.dnsTime = time.Since(start)
+	if err != nil {
+		fail("dns", err)
+		return
+	}
+	endpoint.addresses = addresses
+	for _, target := range endpoint.lbTargets {
+		if target == endpoint.host {
+			continue
+		}
+		if resolved, err := resolver.LookupHost(ctx, target); err == nil {
+			endpoint.lbAddresses = append(endpoint.lbAddresses, resolved...)
+		}
+	}
+
+	start = time.Now()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", net.JoinHostPort(addresses[0], strconv.Itoa(endpoint.port)))
+	endpoint.connectTime = time.Since(start)
+	if err != nil {
+		fail("connect", err)
+		return
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if endpoint.scheme == "tcp" {
+		return
+	}
+
+	if endpoint.scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: endpoint.host, InsecureSkipVerify: true, NextProtos: []string{"http/1.1"}})
+		start = time.Now()
+		err := tlsConn.HandshakeContext(ctx)
+		endpoint.tlsTime = time.Since(start)
+		if err != nil {
+			fail("tls", err)
+			return
+		}
+		endpoint.chain = tlsConn.ConnectionState().PeerCertificates
+		conn = tlsConn
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.url()+options.Path, nil)
+	if err != nil {
+		fail("http", err)
+		return
+	}
+	request.Close = true
+	request.Header.Set("User-Agent", "swissarmycli-endpoint-check")
+	start = time.Now()
+	if err := request.Write(conn); err != nil {
+		fail("http", err)
+		return
+	}
+	response, err := http.ReadResponse(bufio.NewReader(conn), request)
+	endpoint.firstByte = time.Since(start)
+	if err != nil {
+		fail("http", err)
+		return
+	}
+	response.Body.Close()
+	endpoint.statusCode = response.StatusCode
+	endpoint.location = response.Header.Get("Location")
+}
+
+// evaluateProbe turns what probing an endpoint found into findings.
+func evaluateProbe(endpoint *probeEndpoint, options EndpointCheckOptions) {
+	resource := endpoint.kind + "/" + endpoint.name
+	add := func(check, severity, message string) {
+		endpoint.findings = append(endpoint.findings, result.Finding{
+			Check:    check,
+			Severity: severity,
+			Resource: resource,
+			Message:  message,
+			Details:  map[string]string{"url": endpoint.url()},
+		})
+	}
+
+	if endpoint.stage == "dns" {
+		add("dns-unresolved", result.SeverityError, fmt.Sprintf("%s does not resolve: %v", endpoint.host, endpoint.err))
+		return
+	}
+	if len(endpoint.lbAddresses) > 0 && !sharesAddress(endpoint.addresses, endpoint.lbAddresses) {
+		add("dns-mismatch", result.SeverityWarning, fmt.Sprintf("%s resolves to %s, the load balancer to %s; the record has not propagated or points elsewhere",
+			endpoint.host, truncateList(endpoint.addresses, 3), truncateList(endpoint.lbAddresses, 3)))
+	}
+
+	if len(endpoint.chain) > 0 {
+		cert := &certEndpoint{kind: endpoint.kind, name: endpoint.name, target: endpoint.url(), serverName: endpoint.host, chain: endpoint.chain}
+		evaluateCertEndpoint(cert, nil)
+		endpoint.findings = append(endpoint.findings, cert.findings...)
+	}
+
+	switch endpoint.stage {
+	case "connect":
+		add("endpoint-unreachable", result.SeverityError, fmt.Sprintf("connecting to port %d failed: %v", endpoint.port, endpoint.err))
+		return
+	case "tls":
+		add("tls-handshake-failed", result.SeverityError, fmt.Sprintf("TLS handshake failed: %v", endpoint.err))
+		return
+	case "http":
+		add("http-failed", result.SeverityError, fmt.Sprintf("requesting %s failed: %v", options.Path, endpoint.err))
+		return
+	}
+
+	switch code := endpoint.statusCode; {
+	case code >= 500:
+		add("http-error", result.SeverityError, fmt.Sprintf("returns %d for %s", code, options.Path))
+	case code >= 400:
+		add("http-client-error", result.SeverityInfo, fmt.Sprintf("returns %d for %s", code, options.Path))
+	case code >= 300 && endpoint.location != "":
+		add("http-redirect", result.SeverityInfo, fmt.Sprintf("redirects %s to %s", options.Path, endpoint.location))
+	}
+	if options.SlowAfter > 0 && endpoint.total() > options.SlowAfter {
+		add("endpoint-slow", result.SeverityWarning, fmt.Sprintf("took %s, more than %s (DNS %s, connect %s, TLS %s, response %s)",
+			formatLatency(endpoint.total()), options.SlowAfter, formatLatency(endpoint.dnsTime), formatLatency(endpoint.connectTime),
+			formatLatency(endpoint.tlsTime), formatLatency(endpoint.firstByte)))
+	}
+}
+
+func sharesAddress(a, b []string) bool {
+	for _, address := range a {
+		if containsString(b, address) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,312 @@
+package k8s
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"k8s.io/client-go/kubernetes"
+)
+
+// EOLCheckOptions contains options for the Kubernetes version end-of-life check
+type EOLCheckOptions struct {
+	Contexts  []string // Kubeconfig context patterns, the current context when empty
+	Inventory bool     // Check every EKS cluster of the account instead of kubeconfig contexts
+	Regions   []string // Regions of the inventory, see awsutils.SearchRegions
+	Profile   string
+	Refresh   bool // Update the bundled support calendar from awsutils.EKSCalendarURL
+	WarnDays  int  // Warn when standard support ends within this many days
+	Output    string
+	FailOn    string
+}
+
+// eolRow is the support status of one cluster's version
+type eolRow struct {
+	cluster     string // Context name, or name (region) with --inventory
+	version     string // major.minor
+	eks         bool
+	supportTier string // STANDARD or EXTENDED upgrade policy, empty when unknown
+	phase       string
+	calendar    awsutils.EKSVersion
+	daysLeft    int     // Until standard support ends, negative once it has
+	monthly     float64 // Extra control plane cost per month in extended support
+	err         error
+}
+
+// CheckEOL compares the Kubernetes version of each cluster, reached through
+// kubeconfig contexts or found in the account's EKS inventory, with the EKS
+// support calendar. It reports the days left until standard support ends
+// and what extended support costs on top of standard support, priced from
+// the cost estimate table. Clusters whose upgrade policy is STANDARD are
+// upgraded by EKS instead and never pay for extended support.
+func CheckEOL(options EOLCheckOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	calendar, err := awsutils.BundledEKSCalendar()
+	if err != nil {
+		return err
+	}
+	calendarSource := "bundled calendar"
+	if options.Refresh {
+		online, err := awsutils.FetchEKSCalendar()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v, using the bundled calendar\n", err)
+		} else {
+			for version, entry := range online {
+				calendar[version] = entry
+			}
+			calendarSource = awsutils.EKSCalendarURL
+		}
+	}
+	pricing, err := loadPricingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load pricing config: %w", err)
+	}
+	extraHourly := pricing.EKSPricing["extended"] - pricing.EKSPricing["standard"]
+
+	var rows []eolRow
+	if options.Inventory {
+		rows, err = collectInventoryEOLRows(options)
+	} else {
+		rows, err = collectContextEOLRows(options)
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var findings []result.Finding
+	for i := range rows {
+		row := &rows[i]
+		if row.err != nil {
+			findings = append(findings, result.Finding{
+				Check: "collection-failed", Severity: result.SeverityInfo, Resource: row.cluster,
+				Message: row.err.Error(),
+			})
+			continue
+		}
+		entry, ok := calendar[row.version]
+		if !ok {
+			row.phase = awsutils.SupportUnknown
+			findings = append(findings, result.Finding{
+				Check: "eol-unknown-version", Severity: result.SeverityInfo, Resource: row.cluster,
+				Message: fmt.Sprintf("%s is not in the %s, try --refresh", row.version, calendarSource),
+			})
+			continue
+		}
+		row.calendar = entry
+		row.phase, _ = entry.Phase(now)
+		row.daysLeft = int(entry.StandardEnd.Sub(now).Hours() / 24)
+		if row.eks && row.supportTier != eks.SupportTypeStandard {
+			row.monthly = extraHourly * 730
+		}
+		details := map[string]string{
+			"version":         row.version,
+			"standardEnd":     entry.StandardEnd.Format(time.DateOnly),
+			"extendedEnd":     entry.ExtendedEnd.Format(time.DateOnly),
+			"daysLeft":        strconv.Itoa(row.daysLeft),
+			"extendedMonthly": fmt.Sprintf("%.2f", row.monthly),
+		}
+
+		switch row.phase {
+		case awsutils.SupportUnsupported:
+			findings = append(findings, result.Finding{
+				Check: "eol-unsupported", Severity: result.SeverityError, Resource: row.cluster, Details: details,
+				Message: fmt.Sprintf("%s is past extended support since %s and is upgraded automatically", row.version, entry.ExtendedEnd.Format(time.DateOnly)),
+			})
+		case awsutils.SupportExtended:
+			message := fmt.Sprintf("%s is in extended support until %s", row.version, entry.ExtendedEnd.Format(time.DateOnly))
+			if row.monthly > 0 {
+				message += fmt.Sprintf(", costing $%.2f/month on top of standard support", row.monthly)
+			}
+			findings = append(findings, result.Finding{
+				Check: "eol-extended-support", Severity: result.SeverityWarning, Resource: row.cluster, Details: details, Message: message,
+			})
+		case awsutils.SupportStandard:
+			if row.daysLeft > options.WarnDays {
+				continue
+			}
+			message := fmt.Sprintf("standard support of %s ends in %d days on %s", row.version, row.daysLeft, entry.StandardEnd.Format(time.DateOnly))
+			switch {
+			case row.supportTier == eks.SupportTypeStandard:
+				message += ", when EKS upgrades it automatically"
+			case row.monthly > 0:
+				message += fmt.Sprintf(", then extended support costs $%.2f/month", row.monthly)
+			}
+			findings = append(findings, result.Finding{
+				Check: "eol-standard-ending", Severity: result.SeverityWarning, Resource: row.cluster, Details: details, Message: message,
+			})
+		}
+	}
+
+	if options.Output == "json" {
+		if err := result.New("eol-check", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	fmt.Printf("Support dates from the %s\n\n", calendarSource)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tVERSION\tSUPPORT TIER\tPHASE\tSTANDARD ENDS\tDAYS LEFT\tEXTENDED ENDS\tEXTENDED COST/MO")
+	var currentCost, projectedCost float64
+	phases := make(map[string]int)
+	for _, row := range rows {
+		if row.err != nil {
+			fmt.Fprintf(w, "%s\t-\t-\t❌ %v\t-\t-\t-\t-\n", row.cluster, row.err)
+			continue
+		}
+		phases[row.phase]++
+		tier := valueOrDash(row.supportTier)
+		if !row.eks {
+			tier = "not EKS"
+		}
+		if row.phase == awsutils.SupportUnknown {
+			fmt.Fprintf(w, "%s\t%s\t%s\t❔ unknown\t-\t-\t-\t-\n", row.cluster, row.version, tier)
+			continue
+		}
+		phase := "✅ standard"
+		switch {
+		case row.phase == awsutils.SupportUnsupported:
+			phase = "❌ unsupported"
+		case row.phase == awsutils.SupportExtended:
+			phase = "⚠️ extended"
+			currentCost += row.monthly
+		case row.daysLeft <= options.WarnDays:
+			phase = "⚠️ standard"
+			projectedCost += row.monthly
+		}
+		cost := "-"
+		if row.monthly > 0 {
+			cost = fmt.Sprintf("$%.2f", row.monthly)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n", row.cluster, row.version, tier, phase,
+			row.calendar.StandardEnd.Format(time.DateOnly), row.daysLeft, row.calendar.ExtendedEnd.Format(time.DateOnly), cost)
+	}
+	w.Flush()
+
+	if len(findings) > 0 {
+		fmt.Println("\nFindings:")
+		for _, finding := range findings {
+			fmt.Printf("  %s %s: %s\n", result.Label(finding.Severity), finding.Resource, finding.Message)
+		}
+	}
+
+	fmt.Println("\n--- EOL Summary ---")
+	fmt.Printf("Clusters: %d (standard: %d, extended: %d, unsupported: %d, unknown: %d)\n", len(rows),
+		phases[awsutils.SupportStandard], phases[awsutils.SupportExtended], phases[awsutils.SupportUnsupported], phases[awsutils.SupportUnknown])
+	if currentCost > 0 {
+		fmt.Printf("⚠️  Extended support costs $%.2f/month now\n", currentCost)
+	}
+	if projectedCost > 0 {
+		fmt.Printf("⚠️  Another $%.2f/month once standard support ends within %d days, unless upgraded first\n", projectedCost, options.WarnDays)
+	}
+	if currentCost == 0 && projectedCost == 0 && phases[awsutils.SupportUnsupported] == 0 {
+		fmt.Printf("✅ No cluster leaves standard support within %d days\n", options.WarnDays)
+	}
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// collectContextEOLRows reads the server version of each matching
+// kubeconfig context, or of the current context
+func collectContextEOLRows(options EOLCheckOptions) ([]eolRow, error) {
+	contexts := []string{""}
+	if len(options.Contexts) > 0 {
+		all, err := common.ListContexts()
+		if err != nil {
+			return nil, err
+		}
+		contexts = nil
+		for _, name := range all {
+			if _, ok := matchContextPattern(options.Contexts, name); ok {
+				contexts = append(contexts, name)
+			}
+		}
+		if len(contexts) == 0 {
+			return nil, fmt.Errorf("no kubeconfig context matches %s", strings.Join(options.Contexts, ", "))
+		}
+	}
+
+	rows := make([]eolRow, len(contexts))
+	var wg sync.WaitGroup
+	for i, contextName := range contexts {
+		wg.Add(1)
+		go func(i int, contextName string) {
+			defer wg.Done()
+			rows[i] = contextEOLRow(contextName)
+		}(i, contextName)
+	}
+	wg.Wait()
+	sort.Slice(rows, func(i, j int) bool { return rows[i].cluster < rows[j].cluster })
+	return rows, nil
+}
+
+// contextEOLRow reads the server version of one context, the current one
+// when contextName is empty
+func contextEOLRow(contextName string) eolRow {
+	var clientset *kubernetes.Clientset
+	var err error
+	if contextName == "" {
+		clientset, err = common.GetKubernetesClient()
+		contextName = "current context"
+		if current, _, currentErr := common.CurrentContext(); currentErr == nil {
+			contextName = current
+		}
+	} else {
+		clientset, err = common.GetKubernetesClientForContext(contextName)
+	}
+	row := eolRow{cluster: contextName}
+	if err != nil {
+		row.err = err
+		return row
+	}
+	info, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		row.err = fmt.Errorf("failed to get server version: %w", err)
+		return row
+	}
+	// EKS reports minor versions like "29+"
+	row.version = info.Major + "." + strings.TrimRight(info.Minor, "+")
+	row.eks = strings.Contains(info.GitVersion, "-eks-")
+	return row
+}
+
+// collectInventoryEOLRows finds every EKS cluster of the account
+func collectInventoryEOLRows(options EOLCheckOptions) ([]eolRow, error) {
+	regions, err := awsutils.SearchRegions(options.Regions)
+	if err != nil {
+		return nil, err
+	}
+	if options.Output != "json" {
+		fmt.Printf("Scanning %d regions for EKS clusters: %s\n", len(regions), strings.Join(regions, ", "))
+	}
+	// The support phases of the inventory are recomputed from the possibly
+	// refreshed calendar, so none is passed here
+	clusters, _ := awsutils.CollectClusterInventory(regions, options.Profile, nil)
+	var rows []eolRow
+	for _, cluster := range clusters {
+		row := eolRow{
+			cluster:     fmt.Sprintf("%s (%s)", cluster.Name, cluster.Region),
+			version:     cluster.Version,
+			eks:         true,
+			supportTier: cluster.SupportType,
+		}
+		if cluster.Version == "" && cluster.Error != "" {
+			row.err = fmt.Errorf("%s", cluster.Error)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
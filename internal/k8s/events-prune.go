@@ -0,0 +1,223 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// EventsPruneOptions contains options for pruning old Events
+type EventsPruneOptions struct {
+	Namespace string
+	OlderThan time.Duration // Events last seen longer ago than this are deleted
+	PageSize  int64         // Events listed per request
+	Parallel  int           // Delete requests in flight at once
+	QPS       float32       // Client-side rate limit
+	Burst     int
+	HotFactor float64 // Namespaces with this many times the median event count are flagged
+	Top       int     // Namespaces shown in the volume report
+	DryRun    bool
+}
+
+// eventVolume is the event volume of one namespace
+type eventVolume struct {
+	namespace   string
+	events      int
+	occurrences int64 // Sum of the event counts
+	old         []string
+	oldest      time.Time
+	reasons     map[string]int
+	objects     map[string]int
+}
+
+// PruneEvents lists the Events page by page, reports how many each
+// namespace holds and flags namespaces producing far more than the rest,
+// then deletes the Events last seen longer than OlderThan ago. Deletes are
+// rate limited so a cleanup after an event storm doesn't add to the load
+// on etcd it is meant to relieve.
+func PruneEvents(options EventsPruneOptions) error {
+	if options.OlderThan <= 0 {
+		return fmt.Errorf("--older-than must be positive")
+	}
+	config, err := common.GetRESTConfig()
+	if err != nil {
+		return err
+	}
+	config.QPS = options.QPS
+	config.Burst = options.Burst
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+
+	volumes := make(map[string]*eventVolume)
+	total, pages := 0, 0
+	var oldest time.Time
+	cutoff := time.Now().Add(-options.OlderThan)
+	continueToken := ""
+	for {
+		list, err := clientset.CoreV1().Events(options.Namespace).List(ctx, metav1.ListOptions{Limit: options.PageSize, Continue: continueToken})
+		if err != nil {
+			return fmt.Errorf("failed to list events: %w", err)
+		}
+		pages++
+		for _, event := range list.Items {
+			volume := volumes[event.Namespace]
+			if volume == nil {
+				volume = &eventVolume{namespace: event.Namespace, reasons: make(map[string]int), objects: make(map[string]int)}
+				volumes[event.Namespace] = volume
+			}
+			_, last, count := eventTimes(event)
+			volume.events++
+			volume.occurrences += int64(count)
+			volume.reasons[event.Reason]++
+			volume.objects[event.InvolvedObject.Kind+"/"+event.InvolvedObject.Name]++
+			if last.Before(cutoff) {
+				volume.old = append(volume.old, event.Name)
+			}
+			if volume.oldest.IsZero() || last.Before(volume.oldest) {
+				volume.oldest = last
+			}
+			if oldest.IsZero() || last.Before(oldest) {
+				oldest = last
+			}
+			total++
+		}
+		if continueToken = list.GetContinue(); continueToken == "" {
+			break
+		}
+		fmt.Printf("\rListed %d events...", total)
+	}
+	if pages > 1 {
+		fmt.Println()
+	}
+	if total == 0 {
+		fmt.Println("No events found.")
+		return nil
+	}
+
+	var sorted []*eventVolume
+	var counts []int
+	toDelete := 0
+	for _, volume := range volumes {
+		sorted = append(sorted, volume)
+		counts = append(counts, volume.events)
+		toDelete += len(volume.old)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].events > sorted[j].events })
+	sort.Ints(counts)
+	median := counts[len(counts)/2]
+
+	fmt.Println("\nEvent volume by namespace:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "NAMESPACE\tEVENTS\tSHARE\tOCCURRENCES\tOLDER THAN %s\tOLDEST\tTOP REASON\tTOP OBJECT\tVOLUME\n", options.OlderThan)
+	hot := 0
+	for i, volume := range sorted {
+		status := "✅ normal"
+		if len(sorted) > 2 && volume.events >= 100 && float64(volume.events) >= options.HotFactor*float64(max(median, 1)) {
+			status = fmt.Sprintf("⚠️  %.0fx the median", float64(volume.events)/float64(max(median, 1)))
+			hot++
+		}
+		if options.Top > 0 && i >= options.Top {
+			continue
+		}
+		reason := topEntry(volume.reasons)
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\t%d\t%d\t%s\t%s (%d)\t%s (%d)\t%s\n", volume.namespace, volume.events,
+			100*float64(volume.events)/float64(total), volume.occurrences, len(volume.old),
+			formatAge(volume.oldest.UTC().Format(time.RFC3339)), valueOrDash(reason), volume.reasons[reason],
+			topEntry(volume.objects), volume.objects[topEntry(volume.objects)], status)
+	}
+	w.Flush()
+	if options.Top > 0 && len(sorted) > options.Top {
+		fmt.Printf("... and %d more namespaces\n", len(sorted)-options.Top)
+	}
+
+	deleted, failed := 0, 0
+	var elapsed time.Duration
+	if !options.DryRun && toDelete > 0 {
+		fmt.Printf("\nDeleting %d events last seen more than %s ago (%.0f requests/s)...\n", toDelete, options.OlderThan, options.QPS)
+		start := time.Now()
+		deleted, failed = deleteEvents(ctx, clientset, sorted, max(options.Parallel, 1), toDelete)
+		elapsed = time.Since(start)
+		fmt.Println()
+	}
+
+	fmt.Println("\n--- Events Prune Summary ---")
+	fmt.Printf("Events: %d in %d namespaces, oldest last seen %s\n", total, len(volumes), formatAge(oldest.UTC().Format(time.RFC3339)))
+	fmt.Printf("Namespaces with %.0fx the median volume (%d events) or more: %d\n", options.HotFactor, median, hot)
+	switch {
+	case options.DryRun:
+		fmt.Printf("Dry run: %d events older than %s would be deleted\n", toDelete, options.OlderThan)
+	case toDelete == 0:
+		fmt.Printf("No events older than %s\n", options.OlderThan)
+	default:
+		fmt.Printf("Deleted: %d, failed: %d, in %s\n", deleted, failed, elapsed.Round(time.Second))
+	}
+	if time.Since(oldest) > 2*time.Hour {
+		fmt.Println("ℹ️  Events outlive the API server's default 1h --event-ttl; busy namespaces keep old events alive by repeating them")
+	}
+	fmt.Println("----------------------------------------------------")
+	if failed > 0 {
+		return fmt.Errorf("%d events failed to be deleted", failed)
+	}
+	return nil
+}
+
+// deleteEvents deletes the old events of every namespace with parallel
+// workers, within the client's rate limit, and returns how many were
+// deleted and how many failed. Events that expired meanwhile count as
+// deleted.
+func deleteEvents(ctx context.Context, clientset *kubernetes.Clientset, volumes []*eventVolume, parallel, total int) (int, int) {
+	type eventRef struct{ namespace, name string }
+	jobs := make(chan eventRef)
+	var deleted, failed atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ref := range jobs {
+				err := clientset.CoreV1().Events(ref.namespace).Delete(ctx, ref.name, metav1.DeleteOptions{})
+				if err != nil && !apierrors.IsNotFound(err) {
+					failed.Add(1)
+					continue
+				}
+				if done := deleted.Add(1); done%100 == 0 {
+					fmt.Printf("\rDeleted %d/%d events...", done, total)
+				}
+			}
+		}()
+	}
+	for _, volume := range volumes {
+		for _, name := range volume.old {
+			jobs <- eventRef{volume.namespace, name}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	fmt.Printf("\rDeleted %d/%d events...", deleted.Load(), total)
+	return int(deleted.Load()), int(failed.Load())
+}
+
+// topEntry returns the key with the highest count, the alphabetically
+// first on ties.
+func topEntry(counts map[string]int) string {
+	best := ""
+	for key, count := range counts {
+		if best == "" || count > counts[best] || (count == counts[best] && key < best) {
+			best = key
+		}
+	}
+	return best
+}
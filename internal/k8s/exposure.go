@@ -0,0 +1,305 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NodePort range of the API server, which EKS doesn't allow changing
+const (
+	nodePortMin = 30000
+	nodePortMax = 32767
+)
+
+// ExposureOptions contains options for the external exposure report
+type ExposureOptions struct {
+	Cluster string // EKS cluster name, defaults to the one in the kubeconfig context
+	Region  string // Defaults to the region of the cluster's nodes
+	Profile string
+	Output  string // table or json
+	FailOn  string // Lowest severity that fails the run: error, warning, info or none
+}
+
+// ShowExposure reports how the cluster is reachable from outside: the API
+// endpoint access configuration, LoadBalancer and NodePort Services,
+// security group rules open to the internet on the nodes, and Ingresses
+// serving plain HTTP.
+func ShowExposure(options ExposureOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	services, err := clientset.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+	ingresses, err := clientset.NetworkingV1().Ingresses("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list ingresses: %w", err)
+	}
+
+	region := options.Region
+	if region == "" && len(nodes.Items) > 0 {
+		region = nodes.Items[0].Labels["topology.kubernetes.io/region"]
+	}
+	cluster := options.Cluster
+	if cluster == "" {
+		if name, err := getClusterName(); err == nil && name != "unknown" {
+			cluster = name
+		}
+	}
+	sess, err := awsutils.NewSession(options.Profile, region)
+	if err != nil {
+		return err
+	}
+
+	var findings []result.Finding
+	var warnings []string
+	addFinding := func(check, severity, resource, message string) {
+		findings = append(findings, result.Finding{Check: check, Severity: severity, Resource: resource, Message: message})
+	}
+
+	// API endpoint
+	endpoint := "unknown (pass --cluster)"
+	if cluster != "" {
+		security, err := awsutils.GetEKSClusterSecurity(sess, cluster)
+		if err != nil {
+			warnings = append(warnings, err.Error())
+			endpoint = "unknown"
+		} else if !security.EndpointPublicAccess {
+			endpoint = "private only"
+		} else {
+			endpoint = "public, allowed from " + strings.Join(security.PublicAccessCIDRs, ", ")
+			if security.EndpointPrivateAccess {
+				endpoint += "; private access enabled"
+			}
+			if containsString(security.PublicAccessCIDRs, "0.0.0.0/0") {
+				addFinding("public-endpoint", result.SeverityError, "cluster/"+cluster, "API endpoint is reachable from the whole internet")
+			}
+		}
+	}
+
+	// Node security groups
+	var instanceIDs []string
+	for _, node := range nodes.Items {
+		if instanceID := awsutils.InstanceIDFromProviderID(node.Spec.ProviderID); instanceID != "" {
+			instanceIDs = append(instanceIDs, instanceID)
+		}
+	}
+	var openRules []awsutils.OpenIngressRule
+	if len(instanceIDs) > 0 {
+		openRules, err = awsutils.FindOpenIngressRules(sess, instanceIDs)
+		if err != nil {
+			warnings = append(warnings, err.Error())
+		}
+	}
+	for _, rule := range openRules {
+		message := fmt.Sprintf("%s %s open to %s", rule.Protocol, rule.Ports(), rule.Source)
+		if rule.Covers(nodePortMin) || rule.Covers(nodePortMax) {
+			message += ", including NodePorts"
+		}
+		addFinding("open-security-group", result.SeverityWarning, rule.GroupID, message)
+	}
+
+	schemes, err := awsutils.LoadBalancerSchemesByDNS(sess)
+	if err != nil {
+		warnings = append(warnings, err.Error())
+	}
+
+	// Services
+	exposedServices := 0
+	var serviceRows []string
+	for _, service := range services.Items {
+		if service.Spec.Type != corev1.ServiceTypeLoadBalancer && service.Spec.Type != corev1.ServiceTypeNodePort {
+			continue
+		}
+		resource := service.Namespace + "/" + service.Name
+		var ports []string
+		reachableNodePort := false
+		for _, port := range service.Spec.Ports {
+			ports = append(ports, fmt.Sprintf("%d/%s", port.Port, port.Protocol))
+			for _, rule := range openRules {
+				if port.NodePort != 0 && rule.Covers(int64(port.NodePort)) {
+					reachableNodePort = true
+				}
+			}
+		}
+
+		scheme := "-"
+		exposed := reachableNodePort
+		if service.Spec.Type == corev1.ServiceTypeLoadBalancer {
+			scheme = serviceScheme(service, schemes)
+			if scheme == "internet-facing" {
+				exposed = true
+				if len(service.Spec.LoadBalancerSourceRanges) == 0 {
+					addFinding("internet-facing-service", result.SeverityWarning, resource, "internet-facing load balancer without loadBalancerSourceRanges")
+				}
+			}
+		}
+		if exposed {
+			exposedServices++
+		}
+		if reachableNodePort {
+			addFinding("open-node-port", result.SeverityWarning, resource, "NodePort is open to the internet through a node security group")
+		}
+		sourceRanges := "-"
+		if len(service.Spec.LoadBalancerSourceRanges) > 0 {
+			sourceRanges = strings.Join(service.Spec.LoadBalancerSourceRanges, ",")
+		}
+		serviceRows = append(serviceRows, fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s",
+			service.Namespace, service.Name, service.Spec.Type, scheme, strings.Join(ports, ","), sourceRanges))
+	}
+
+	// Ingresses
+	plainIngresses := 0
+	var ingressRows []string
+	for _, ingress := range ingresses.Items {
+		if ingressHasTLS(ingress) {
+			continue
+		}
+		plainIngresses++
+		scheme := ingressScheme(ingress, schemes)
+		severity := result.SeverityInfo
+		if scheme == "internet-facing" {
+			severity = result.SeverityWarning
+		}
+		addFinding("ingress-without-tls", severity, ingress.Namespace+"/"+ingress.Name, "Ingress serves plain HTTP without TLS")
+
+		var hosts []string
+		for _, rule := range ingress.Spec.Rules {
+			if rule.Host != "" {
+				hosts = append(hosts, rule.Host)
+			}
+		}
+		if len(hosts) == 0 {
+			hosts = []string{"*"}
+		}
+		ingressRows = append(ingressRows, fmt.Sprintf("%s\t%s\t%s\t%s", ingress.Namespace, ingress.Name, strings.Join(hosts, ","), scheme))
+	}
+
+	if options.Output == "json" {
+		if err := result.New("exposure", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	fmt.Printf("API endpoint: %s\n", endpoint)
+	if len(openRules) > 0 {
+		fmt.Println("\nNode security group rules open to the internet:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "GROUP\tNAME\tPROTOCOL\tPORTS\tSOURCE")
+		for _, rule := range openRules {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", rule.GroupID, rule.GroupName, rule.Protocol, rule.Ports(), rule.Source)
+		}
+		w.Flush()
+	}
+	if len(serviceRows) > 0 {
+		fmt.Println("\nLoadBalancer and NodePort Services:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAMESPACE\tSERVICE\tTYPE\tSCHEME\tPORTS\tSOURCE RANGES")
+		for _, row := range serviceRows {
+			fmt.Fprintln(w, row)
+		}
+		w.Flush()
+	}
+	if len(ingressRows) > 0 {
+		fmt.Println("\nIngresses without TLS:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAMESPACE\tINGRESS\tHOSTS\tSCHEME")
+		for _, row := range ingressRows {
+			fmt.Fprintln(w, row)
+		}
+		w.Flush()
+	}
+	if len(findings) > 0 {
+		fmt.Println("\nFindings:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SEVERITY\tRESOURCE\tMESSAGE")
+		for _, finding := range findings {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", result.Label(finding.Severity), finding.Resource, finding.Message)
+		}
+		w.Flush()
+	}
+
+	fmt.Println("\n--- Exposure Summary ---")
+	fmt.Printf("API endpoint: %s\n", endpoint)
+	fmt.Printf("Open node security group rules: %d\n", len(openRules))
+	fmt.Printf("Internet-facing Services: %d\n", exposedServices)
+	fmt.Printf("Ingresses without TLS: %d\n", plainIngresses)
+	for _, warning := range warnings {
+		fmt.Printf("⚠️  %s\n", warning)
+	}
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// serviceScheme returns whether a LoadBalancer Service's load balancer is
+// internet-facing or internal, from AWS when it has been provisioned and
+// from its annotations otherwise.
+func serviceScheme(service corev1.Service, schemes map[string]string) string {
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if scheme, ok := schemes[strings.ToLower(ingress.Hostname)]; ok {
+			return scheme
+		}
+	}
+	annotations := service.Annotations
+	if scheme := annotations["service.beta.kubernetes.io/aws-load-balancer-scheme"]; scheme != "" {
+		return scheme
+	}
+	if annotations["service.beta.kubernetes.io/aws-load-balancer-internal"] == "true" {
+		return "internal"
+	}
+	// The AWS Load Balancer Controller creates internal NLBs by default, the
+	// in-tree cloud provider internet-facing ones
+	loadBalancerType := annotations["service.beta.kubernetes.io/aws-load-balancer-type"]
+	if loadBalancerType == "external" || loadBalancerType == "nlb-ip" ||
+		(service.Spec.LoadBalancerClass != nil && *service.Spec.LoadBalancerClass == "service.k8s.aws/nlb") {
+		return "internal"
+	}
+	return "internet-facing"
+}
+
+// ingressScheme does the same as serviceScheme for Ingresses; ALBs are
+// internal unless the scheme annotation says otherwise.
+func ingressScheme(ingress networkingv1.Ingress, schemes map[string]string) string {
+	for _, lb := range ingress.Status.LoadBalancer.Ingress {
+		if scheme, ok := schemes[strings.ToLower(lb.Hostname)]; ok {
+			return scheme
+		}
+	}
+	if scheme := ingress.Annotations["alb.ingress.kubernetes.io/scheme"]; scheme != "" {
+		return scheme
+	}
+	return "unknown"
+}
+
+// ingressHasTLS reports whether an Ingress terminates TLS, either through
+// spec.tls or an ACM certificate on its ALB.
+func ingressHasTLS(ingress networkingv1.Ingress) bool {
+	if len(ingress.Spec.TLS) > 0 {
+		return true
+	}
+	return ingress.Annotations["alb.ingress.kubernetes.io/certificate-arn"] != "" ||
+		strings.Contains(ingress.Annotations["alb.ingress.kubernetes.io/listen-ports"], "HTTPS")
+}
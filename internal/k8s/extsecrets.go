@@ -0,0 +1,291 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// externalSecretVersions are tried in order; v1 replaced v1beta1 in newer
+// external-secrets releases.
+var externalSecretVersions = []string{"v1", "v1beta1"}
+
+var sealedSecretGVR = schema.GroupVersionResource{
+	Group:    "bitnami.com",
+	Version:  "v1alpha1",
+	Resource: "sealedsecrets",
+}
+
+// ExternalSecretsOptions contains options for the external secrets report
+type ExternalSecretsOptions struct {
+	Namespace string
+	Output    string // table or json
+	FailOn    string // Lowest severity that fails the run: error, warning, info or none
+}
+
+// ShowExternalSecretsStatus reports the sync status of ExternalSecrets and
+// SealedSecrets, including when they last refreshed and why they are failing.
+func ShowExternalSecretsStatus(options ExternalSecretsOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	dynamicClient, err := common.GetDynamicClient()
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	var findings []result.Finding
+	externalTotal, externalFailing, err := printExternalSecrets(dynamicClient, clientset, options, &findings)
+	if err != nil {
+		return err
+	}
+	sealedTotal, sealedFailing, err := printSealedSecrets(dynamicClient, clientset, options, &findings)
+	if err != nil {
+		return err
+	}
+	if options.Output == "json" {
+		if err := result.New("extsecrets", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	fmt.Println("\n--- External Secrets Summary ---")
+	fmt.Printf("ExternalSecrets: %d (%d not ready)\n", externalTotal, externalFailing)
+	fmt.Printf("SealedSecrets: %d (%d not synced)\n", sealedTotal, sealedFailing)
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+func printExternalSecrets(dynamicClient dynamic.Interface, clientset *kubernetes.Clientset, options ExternalSecretsOptions, findings *[]result.Finding) (int, int, error) {
+	namespace := options.Namespace
+	var list *unstructured.UnstructuredList
+	var err error
+	for _, version := range externalSecretVersions {
+		gvr := schema.GroupVersionResource{Group: "external-secrets.io", Version: version, Resource: "externalsecrets"}
+		list, err = dynamicClient.Resource(gvr).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err == nil || !apierrors.IsNotFound(err) {
+			break
+		}
+	}
+	if apierrors.IsNotFound(err) {
+		if options.Output != "json" {
+			fmt.Println("ExternalSecrets: external-secrets.io CRDs not installed, skipping.")
+		}
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list ExternalSecrets: %w", err)
+	}
+
+	if options.Output != "json" {
+		fmt.Printf("\n=== ExternalSecrets (%d) ===\n", len(list.Items))
+	}
+	if len(list.Items) == 0 {
+		return 0, 0, nil
+	}
+
+	failing := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if options.Output != "json" {
+		fmt.Fprintln(w, "NAMESPACE\tNAME\tSTORE\tTARGET SECRET\tREFRESH INTERVAL\tLAST REFRESH\tSTATUS\tMESSAGE")
+	}
+	for _, item := range list.Items {
+		storeKind, _, _ := unstructured.NestedString(item.Object, "spec", "secretStoreRef", "kind")
+		storeName, _, _ := unstructured.NestedString(item.Object, "spec", "secretStoreRef", "name")
+		if storeKind == "" {
+			storeKind = "SecretStore"
+		}
+		interval, _, _ := unstructured.NestedString(item.Object, "spec", "refreshInterval")
+		refreshTime, _, _ := unstructured.NestedString(item.Object, "status", "refreshTime")
+		target, _, _ := unstructured.NestedString(item.Object, "spec", "target", "name")
+		if target == "" {
+			target = item.GetName()
+		}
+
+		ready, reason, message := findCondition(item, "Ready")
+		status := "✅ Ready"
+		resource := "ExternalSecret/" + item.GetNamespace() + "/" + item.GetName()
+		details := map[string]string{"store": storeKind + "/" + storeName, "target": target, "lastRefresh": refreshTime}
+		if ready != "True" {
+			failing++
+			status = "❌ " + conditionLabel(ready, reason)
+			*findings = append(*findings, result.Finding{Check: "externalsecret-not-ready", Severity: result.SeverityError,
+				Resource: resource, Message: conditionLabel(ready, reason) + ": " + valueOrDash(message), Details: details})
+		} else if !secretExists(clientset, item.GetNamespace(), target) {
+			failing++
+			status = "⚠️  Secret missing"
+			*findings = append(*findings, result.Finding{Check: "externalsecret-secret-missing", Severity: result.SeverityWarning,
+				Resource: resource, Message: fmt.Sprintf("target secret %s does not exist", target), Details: details})
+		}
+		if options.Output == "json" {
+			continue
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s/%s\t%s\t%s\t%s\t%s\t%s\n",
+			item.GetNamespace(), item.GetName(), storeKind, storeName, target,
+			valueOrDash(interval), formatAge(refreshTime), status, valueOrDash(message))
+	}
+	if options.Output != "json" {
+		w.Flush()
+	}
+	return len(list.Items), failing, nil
+}
+
+func printSealedSecrets(dynamicClient dynamic.Interface, clientset *kubernetes.Clientset, options ExternalSecretsOptions, findings *[]result.Finding) (int, int, error) {
+	list, err := dynamicClient.Resource(sealedSecretGVR).Namespace(options.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		if options.Output != "json" {
+			fmt.Println("SealedSecrets: bitnami.com CRDs not installed, skipping.")
+		}
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list SealedSecrets: %w", err)
+	}
+
+	if options.Output != "json" {
+		fmt.Printf("\n=== SealedSecrets (%d) ===\n", len(list.Items))
+	}
+	if len(list.Items) == 0 {
+		return 0, 0, nil
+	}
+
+	failing := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if options.Output != "json" {
+		fmt.Fprintln(w, "NAMESPACE\tNAME\tLAST UPDATE\tSTATUS\tMESSAGE")
+	}
+	for _, item := range list.Items {
+		synced, reason, message := findCondition(item, "Synced")
+		_, lastUpdate := conditionTimes(item, "Synced")
+		observed, _, _ := unstructured.NestedInt64(item.Object, "status", "observedGeneration")
+
+		// Older controllers don't set conditions on success, so fall back to
+		// checking the unsealed secret exists.
+		status := "✅ Synced"
+		finding := result.Finding{Resource: "SealedSecret/" + item.GetNamespace() + "/" + item.GetName()}
+		switch {
+		case synced == "False":
+			failing++
+			status = "❌ " + conditionLabel(synced, reason)
+			finding.Check, finding.Severity, finding.Message = "sealedsecret-not-synced", result.SeverityError, conditionLabel(synced, reason)+": "+valueOrDash(message)
+		case observed != 0 && observed < item.GetGeneration():
+			failing++
+			status = "⚠️  Pending (controller has not processed latest spec)"
+			finding.Check, finding.Severity, finding.Message = "sealedsecret-pending", result.SeverityWarning, "controller has not processed the latest spec"
+		case !secretExists(clientset, item.GetNamespace(), item.GetName()):
+			failing++
+			status = "⚠️  Secret missing"
+			finding.Check, finding.Severity, finding.Message = "sealedsecret-secret-missing", result.SeverityWarning, "unsealed secret does not exist"
+		}
+		if finding.Check != "" {
+			*findings = append(*findings, finding)
+		}
+		if options.Output == "json" {
+			continue
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			item.GetNamespace(), item.GetName(), formatAge(lastUpdate), status, valueOrDash(message))
+	}
+	if options.Output != "json" {
+		w.Flush()
+	}
+	return len(list.Items), failing, nil
+}
+
+// findCondition returns the status, reason and message of a status condition.
+func findCondition(item unstructured.Unstructured, conditionType string) (string, string, string) {
+	conditions, _, _ := unstructured.NestedSlice(item.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != conditionType {
+			continue
+		}
+		status, _ := condition["status"].(string)
+		reason, _ := condition["reason"].(string)
+		message, _ := condition["message"].(string)
+		return status, reason, message
+	}
+	return "", "", ""
+}
+
+// conditionTimes returns the lastTransitionTime and lastUpdateTime of a status condition.
+func conditionTimes(item unstructured.Unstructured, conditionType string) (string, string) {
+	conditions, _, _ := unstructured.NestedSlice(item.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != conditionType {
+			continue
+		}
+		transition, _ := condition["lastTransitionTime"].(string)
+		update, _ := condition["lastUpdateTime"].(string)
+		if update == "" {
+			update = transition
+		}
+		return transition, update
+	}
+	return "", ""
+}
+
+func conditionLabel(status, reason string) string {
+	if status == "" {
+		return "Unknown"
+	}
+	if reason != "" {
+		return reason
+	}
+	return "Not ready"
+}
+
+func secretExists(clientset *kubernetes.Clientset, namespace, name string) bool {
+	_, err := clientset.CoreV1().Secrets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	return err == nil
+}
+
+// formatAge renders an RFC3339 timestamp as a relative age like "3h ago".
+func formatAge(timestamp string) string {
+	if timestamp == "" {
+		return "-"
+	}
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return timestamp
+	}
+
+	age := time.Since(t)
+	switch {
+	case age < time.Minute:
+		return fmt.Sprintf("%ds ago", int(age.Seconds()))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age.Minutes()))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(age.Hours()/24))
+	}
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,350 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	fargateProfileLabel       = "eks.amazonaws.com/fargate-profile"
+	fargateCapacityAnnotation = "CapacityProvisioned" // e.g. "0.25vCPU 0.5GB", set by Fargate on scheduled pods
+	fargateScheduler          = "fargate-scheduler"
+	fargateMemoryOverheadGB   = 0.25 // Added by Fargate for the kubelet, kube-proxy and containerd
+	fargateMinimumVCPU        = 0.25
+	fargateMinimumMemoryGB    = 0.5
+)
+
+// fargateSizes are the vCPU sizes of Fargate with the memory each allows,
+// in GB
+var fargateSizes = []struct {
+	vcpu   float64
+	memory []float64
+}{
+	{0.25, []float64{0.5, 1, 2}},
+	{0.5, gbRange(1, 4, 1)},
+	{1, gbRange(2, 8, 1)},
+	{2, gbRange(4, 16, 1)},
+	{4, gbRange(8, 30, 1)},
+	{8, gbRange(16, 60, 4)},
+	{16, gbRange(32, 120, 8)},
+}
+
+func gbRange(from, to, step float64) []float64 {
+	var sizes []float64
+	for size := from; size <= to; size += step {
+		sizes = append(sizes, size)
+	}
+	return sizes
+}
+
+// FargateWorkload is the billed Fargate capacity of one workload's pods
+type FargateWorkload struct {
+	Namespace   string  `json:"namespace"`
+	Name        string  `json:"name"`
+	Kind        string  `json:"kind"`
+	Pods        int     `json:"pods"`
+	VCPU        float64 `json:"vcpu"`
+	MemoryGB    float64 `json:"memory_gb"`
+	HourlyCost  float64 `json:"hourly_cost"`
+	MonthlyCost float64 `json:"monthly_cost"`
+}
+
+// FargateStatusOptions contains options for the Fargate report
+type FargateStatusOptions struct {
+	Cluster string // Defaults to the cluster of the kubeconfig context
+	Region  string // Defaults to the region of the cluster's nodes
+	Profile string
+}
+
+// fargatePod is a pod running, or waiting to run, on Fargate
+type fargatePod struct {
+	pod              corev1.Pod
+	requestedVCPU    float64
+	requestedGB      float64 // Including the memory overhead
+	billedVCPU       float64
+	billedGB         float64
+	owner, ownerKind string
+}
+
+// ShowFargateStatus lists the cluster's Fargate profiles with their
+// selectors, the pods running on Fargate with the size they are billed for
+// after Fargate rounds their requests up, and the cost per workload.
+func ShowFargateStatus(options FargateStatusOptions) error {
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	region := options.Region
+	if region == "" && len(nodes.Items) > 0 {
+		region = nodes.Items[0].Labels["topology.kubernetes.io/region"]
+	}
+	cluster := options.Cluster
+	if cluster == "" {
+		if name, err := getClusterName(); err == nil && name != "unknown" {
+			cluster = name
+		}
+	}
+
+	pods, err := collectFargatePods(ctx, clientset)
+	if err != nil {
+		return err
+	}
+	pricing, err := loadPricingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load pricing config: %w", err)
+	}
+	podsPerProfile := make(map[string]int)
+	for _, pod := range pods {
+		podsPerProfile[pod.pod.Labels[fargateProfileLabel]]++
+	}
+
+	sess, err := awsutils.NewSession(options.Profile, region)
+	if err != nil {
+		return err
+	}
+	profiles, err := awsutils.ListFargateProfiles(sess, cluster)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+	} else if len(profiles) == 0 {
+		fmt.Printf("Cluster %s has no Fargate profiles.\n", cluster)
+	} else {
+		fmt.Printf("Fargate profiles of %s:\n", cluster)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PROFILE\tSTATUS\tSELECTORS\tSUBNETS\tPODS")
+		for _, profile := range profiles {
+			var selectors []string
+			for _, selector := range profile.Selectors {
+				text := selector.Namespace
+				if len(selector.Labels) > 0 {
+					var labels []string
+					for _, key := range sortedKeys(selector.Labels) {
+						labels = append(labels, key+"="+selector.Labels[key])
+					}
+					text += " [" + strings.Join(labels, ",") + "]"
+				}
+				selectors = append(selectors, text)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\n", profile.Name, profile.Status, strings.Join(selectors, "; "), len(profile.Subnets), podsPerProfile[profile.Name])
+		}
+		w.Flush()
+	}
+
+	if len(pods) == 0 {
+		fmt.Println("\nNo pods are running on Fargate.")
+		return nil
+	}
+
+	fmt.Println("\nPods on Fargate:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "POD\tPROFILE\tSTATUS\tREQUESTED\tBILLED\t$/MONTH")
+	var pending int
+	var requestedCost, billedCost float64
+	for _, pod := range pods {
+		if pod.pod.Status.Phase == corev1.PodPending {
+			pending++
+		}
+		cost := fargateHourlyCost(pricing, pod.billedVCPU, pod.billedGB) * 730
+		billedCost += cost
+		requestedCost += fargateHourlyCost(pricing, pod.requestedVCPU, pod.requestedGB) * 730
+		fmt.Fprintf(w, "%s/%s\t%s\t%s\t%s\t%s\t%.2f\n", pod.pod.Namespace, pod.pod.Name, valueOrDash(pod.pod.Labels[fargateProfileLabel]),
+			pod.pod.Status.Phase, formatFargateSize(pod.requestedVCPU, pod.requestedGB), formatFargateSize(pod.billedVCPU, pod.billedGB), cost)
+	}
+	w.Flush()
+
+	workloads := fargateWorkloads(pods, pricing)
+	fmt.Println("\nCost per workload:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WORKLOAD\tPODS\tVCPU\tMEMORY\t$/MONTH")
+	for _, workload := range workloads {
+		fmt.Fprintf(w, "%s/%s/%s\t%d\t%.2f\t%.1fGB\t%.2f\n", workload.Namespace, workload.Kind, workload.Name, workload.Pods, workload.VCPU, workload.MemoryGB, workload.MonthlyCost)
+	}
+	w.Flush()
+
+	fmt.Println("\n--- Fargate Summary ---")
+	fmt.Printf("Pods: %d", len(pods))
+	if pending > 0 {
+		fmt.Printf(" (%d pending)", pending)
+	}
+	fmt.Println()
+	fmt.Printf("Estimated monthly cost: $%.2f\n", billedCost)
+	if overhead := billedCost - requestedCost; overhead >= 0.01 {
+		fmt.Printf("⚠️  Rounding up to Fargate sizes adds $%.2f/month; requests just above a size boundary are the cheapest to fix\n", overhead)
+	}
+	fmt.Println("Fargate pods are included in cost-estimate.")
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// collectFargatePods returns the pods running on Fargate nodes or waiting
+// for the Fargate scheduler, with their requested and billed sizes.
+func collectFargatePods(ctx context.Context, clientset *kubernetes.Clientset) ([]fargatePod, error) {
+	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	replicaSets, err := clientset.AppsV1().ReplicaSets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replicasets: %w", err)
+	}
+	rsOwnerCache := make(map[string]string)
+	for _, rs := range replicaSets.Items {
+		for _, owner := range rs.OwnerReferences {
+			if owner.Kind == "Deployment" {
+				rsOwnerCache[rs.Namespace+"/"+rs.Name] = owner.Name
+			}
+		}
+	}
+
+	var fargatePods []fargatePod
+	for i := range pods.Items {
+		pod := pods.Items[i]
+		onFargate := strings.HasPrefix(pod.Spec.NodeName, "fargate-") || pod.Spec.SchedulerName == fargateScheduler
+		if !onFargate || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		entry := fargatePod{pod: pod}
+		entry.owner, entry.ownerKind = getPodOwnerFast(&pod, rsOwnerCache)
+		entry.requestedVCPU, entry.requestedGB = fargatePodRequests(pod)
+		if vcpu, memory, ok := parseFargateCapacity(pod.Annotations[fargateCapacityAnnotation]); ok {
+			entry.billedVCPU, entry.billedGB = vcpu, memory
+		} else {
+			entry.billedVCPU, entry.billedGB = fargateBillingSize(entry.requestedVCPU, entry.requestedGB)
+		}
+		fargatePods = append(fargatePods, entry)
+	}
+	sort.Slice(fargatePods, func(i, j int) bool {
+		a, b := fargatePods[i].pod, fargatePods[j].pod
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return fargatePods, nil
+}
+
+// fargatePodRequests returns the vCPU and memory Fargate sizes a pod by: the
+// larger of the summed requests of its containers and the largest init
+// container, plus the memory Fargate reserves for its own components.
+func fargatePodRequests(pod corev1.Pod) (float64, float64) {
+	request := func(container corev1.Container) (float64, float64) {
+		cpu, memory := container.Resources.Requests.Cpu(), container.Resources.Requests.Memory()
+		return float64(cpu.MilliValue()) / 1000, float64(memory.Value()) / (1024 * 1024 * 1024)
+	}
+	var vcpu, memory float64
+	for _, container := range pod.Spec.Containers {
+		cpu, mem := request(container)
+		vcpu += cpu
+		memory += mem
+	}
+	for _, container := range pod.Spec.InitContainers {
+		cpu, mem := request(container)
+		vcpu = math.Max(vcpu, cpu)
+		memory = math.Max(memory, mem)
+	}
+	return vcpu, memory + fargateMemoryOverheadGB
+}
+
+// fargateBillingSize rounds a pod's vCPU and memory up to the smallest
+// Fargate size that fits both.
+func fargateBillingSize(vcpu, memory float64) (float64, float64) {
+	vcpu = math.Max(vcpu, fargateMinimumVCPU)
+	memory = math.Max(memory, fargateMinimumMemoryGB)
+	for _, size := range fargateSizes {
+		if size.vcpu < vcpu {
+			continue
+		}
+		for _, gb := range size.memory {
+			if gb >= memory {
+				return size.vcpu, gb
+			}
+		}
+	}
+	largest := fargateSizes[len(fargateSizes)-1]
+	return largest.vcpu, largest.memory[len(largest.memory)-1]
+}
+
+// parseFargateCapacity reads the CapacityProvisioned annotation, e.g.
+// "0.25vCPU 0.5GB".
+func parseFargateCapacity(value string) (float64, float64, bool) {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return 0, 0, false
+	}
+	vcpu, err := strconv.ParseFloat(strings.TrimSuffix(fields[0], "vCPU"), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	memory, err := strconv.ParseFloat(strings.TrimSuffix(fields[1], "GB"), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return vcpu, memory, true
+}
+
+// fargateHourlyCost prices a pod size with the fargate_pricing rates of the
+// pricing table.
+func fargateHourlyCost(pricing *PricingConfig, vcpu, memory float64) float64 {
+	return vcpu*pricing.FargatePricing["vcpu"] + memory*pricing.FargatePricing["memory_gb"]
+}
+
+func formatFargateSize(vcpu, memory float64) string {
+	return fmt.Sprintf("%s vCPU / %sGB", strconv.FormatFloat(vcpu, 'f', -1, 64), strconv.FormatFloat(math.Round(memory*100)/100, 'f', -1, 64))
+}
+
+// fargateWorkloads adds up the billed size and cost of the pods per owning
+// workload, most expensive first.
+func fargateWorkloads(pods []fargatePod, pricing *PricingConfig) []FargateWorkload {
+	byOwner := make(map[string]*FargateWorkload)
+	for _, pod := range pods {
+		key := pod.pod.Namespace + "/" + pod.ownerKind + "/" + pod.owner
+		workload, ok := byOwner[key]
+		if !ok {
+			workload = &FargateWorkload{Namespace: pod.pod.Namespace, Name: pod.owner, Kind: pod.ownerKind}
+			byOwner[key] = workload
+		}
+		workload.Pods++
+		workload.VCPU += pod.billedVCPU
+		workload.MemoryGB += pod.billedGB
+	}
+	var workloads []FargateWorkload
+	for _, key := range sortedKeys(byOwner) {
+		workload := byOwner[key]
+		workload.HourlyCost = fargateHourlyCost(pricing, workload.VCPU, workload.MemoryGB)
+		workload.MonthlyCost = workload.HourlyCost * 730
+		workloads = append(workloads, *workload)
+	}
+	sort.SliceStable(workloads, func(i, j int) bool { return workloads[i].MonthlyCost > workloads[j].MonthlyCost })
+	return workloads
+}
+
+// getFargateWorkloadsFromPods adds the cluster's Fargate pods, grouped per
+// workload and priced, to the cost estimate.
+func getFargateWorkloadsFromPods(clientset *kubernetes.Clientset, costInfo *ClusterCostInfo) error {
+	pods, err := collectFargatePods(context.TODO(), clientset)
+	if err != nil {
+		return err
+	}
+	pricing, err := loadPricingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load pricing config: %w", err)
+	}
+	costInfo.FargateWorkloads = fargateWorkloads(pods, pricing)
+	return nil
+}
@@ -0,0 +1,258 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// FlowLogsOptions contains options for querying VPC flow logs
+type FlowLogsOptions struct {
+	Namespace string // Namespace of the pod
+	Since     time.Duration
+	LogGroup  string // Defaults to the log group the VPC's flow logs go to
+	Region    string // Defaults to the region of the node
+	Profile   string
+	Limit     int // Rows per table
+}
+
+// ShowFlowLogs queries the VPC flow logs of a pod's IPs or a node's ENIs
+// with CloudWatch Logs Insights and summarizes the top talkers, rejected
+// connections and destination ports, naming the pods, nodes and Services
+// behind the addresses.
+func ShowFlowLogs(kind, name string, options FlowLogsOptions) error {
+	if options.Since <= 0 {
+		return fmt.Errorf("--since must be greater than zero")
+	}
+	if options.Limit < 1 {
+		return fmt.Errorf("--limit must be at least 1")
+	}
+	if options.Namespace == "" {
+		options.Namespace = "default"
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+
+	var podIPs []string
+	nodeName := name
+	switch kind {
+	case "pod":
+		pod, err := clientset.CoreV1().Pods(options.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get pod %s/%s: %w", options.Namespace, name, err)
+		}
+		for _, podIP := range pod.Status.PodIPs {
+			podIPs = append(podIPs, podIP.IP)
+		}
+		if len(podIPs) == 0 {
+			return fmt.Errorf("pod %s has no IP yet", name)
+		}
+		if pod.Spec.HostNetwork {
+			fmt.Println("⚠️  The pod uses the host network, so its flows can't be told apart from the node's.")
+		}
+		nodeName = pod.Spec.NodeName
+	case "node":
+	default:
+		return fmt.Errorf("unsupported kind '%s' (must be pod or node)", kind)
+	}
+
+	node, err := clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+	instanceID := awsutils.InstanceIDFromProviderID(node.Spec.ProviderID)
+	if instanceID == "" {
+		return fmt.Errorf("node %s is not an EC2 instance", nodeName)
+	}
+	region := options.Region
+	if region == "" {
+		region = node.Labels["topology.kubernetes.io/region"]
+	}
+	sess, err := awsutils.NewSession(options.Profile, region)
+	if err != nil {
+		return err
+	}
+	network, err := awsutils.GetInstanceNetwork(sess, instanceID)
+	if err != nil {
+		return err
+	}
+	logGroup := options.LogGroup
+	if logGroup == "" {
+		logGroup, err = awsutils.FindFlowLogGroup(sess, network.VpcID, network.SubnetID)
+		if err != nil {
+			return err
+		}
+		if logGroup == "" {
+			return fmt.Errorf("no flow logs delivered to CloudWatch Logs found for %s; pass --log-group", network.VpcID)
+		}
+	}
+
+	// A pod's traffic is matched by address, a node's by its ENIs, which
+	// also carry the traffic of the pods running on it
+	var filter string
+	if kind == "pod" {
+		quoted := quoteAll(podIPs)
+		filter = fmt.Sprintf("srcAddr in [%s] or dstAddr in [%s]", quoted, quoted)
+	} else {
+		filter = fmt.Sprintf("interfaceId in [%s]", quoteAll(network.NetworkInterfaces))
+	}
+	queries := []string{
+		fmt.Sprintf("filter %s | stats count(*) as flows, sum(bytes) as totalBytes by action", filter),
+		fmt.Sprintf("filter %s | stats sum(bytes) as totalBytes, sum(packets) as totalPackets by srcAddr, dstAddr | sort totalBytes desc | limit %d", filter, options.Limit),
+		fmt.Sprintf("filter (%s) and action = \"REJECT\" | stats count(*) as flows by srcAddr, dstAddr, dstPort, protocol | sort flows desc | limit %d", filter, options.Limit),
+		fmt.Sprintf("filter %s | stats count(*) as flows, sum(bytes) as totalBytes by dstPort, protocol | sort flows desc | limit %d", filter, options.Limit),
+	}
+
+	end := time.Now()
+	start := end.Add(-options.Since)
+	fmt.Printf("Querying flow logs in %s for %s %s since %s...\n", logGroup, kind, name, start.Format("2006-01-02 15:04:05"))
+	results, err := awsutils.RunInsightsQueries(sess, logGroup, queries, start, end)
+	if err != nil {
+		return err
+	}
+	names, err := ipNames(clientset)
+	if err != nil {
+		return err
+	}
+	address := func(ip string) string {
+		if owner, ok := names[ip]; ok {
+			return fmt.Sprintf("%s (%s)", ip, owner)
+		}
+		return ip
+	}
+
+	fmt.Println("\nTop talkers:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tDESTINATION\tBYTES\tPACKETS")
+	for _, row := range results[1] {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", address(row["srcAddr"]), address(row["dstAddr"]), formatFlowBytes(row["totalBytes"]), row["totalPackets"])
+	}
+	w.Flush()
+
+	if len(results[2]) > 0 {
+		fmt.Println("\nRejected connections:")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SOURCE\tDESTINATION\tPORT\tPROTOCOL\tFLOWS")
+		for _, row := range results[2] {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", address(row["srcAddr"]), address(row["dstAddr"]), row["dstPort"], protocolName(row["protocol"]), row["flows"])
+		}
+		w.Flush()
+	}
+
+	fmt.Println("\nDestination ports:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PORT\tPROTOCOL\tFLOWS\tBYTES")
+	for _, row := range results[3] {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", row["dstPort"], protocolName(row["protocol"]), row["flows"], formatFlowBytes(row["totalBytes"]))
+	}
+	w.Flush()
+
+	fmt.Println("\n--- Flow Logs Summary ---")
+	for _, row := range results[0] {
+		label := "✅ Accepted"
+		if row["action"] == "REJECT" {
+			label = "❌ Rejected"
+		}
+		fmt.Printf("%s: %s flows, %s\n", label, row["flows"], formatFlowBytes(row["totalBytes"]))
+	}
+	if len(results[0]) == 0 {
+		fmt.Println("No flows recorded in the window.")
+	}
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// ipNames maps the IPs of pods, nodes and Services to their names.
+func ipNames(clientset *kubernetes.Clientset) (map[string]string, error) {
+	ctx := context.TODO()
+	names := make(map[string]string)
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	for _, node := range nodes.Items {
+		for _, address := range node.Status.Addresses {
+			if address.Type == corev1.NodeInternalIP || address.Type == corev1.NodeExternalIP {
+				names[address.Address] = "node " + node.Name
+			}
+		}
+	}
+	// Host network pods share the node's IP, which keeps the node's name
+	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		if pod.Spec.HostNetwork {
+			continue
+		}
+		for _, podIP := range pod.Status.PodIPs {
+			names[podIP.IP] = "pod " + pod.Namespace + "/" + pod.Name
+		}
+	}
+	services, err := clientset.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	for _, service := range services.Items {
+		for _, clusterIP := range service.Spec.ClusterIPs {
+			if clusterIP != corev1.ClusterIPNone {
+				names[clusterIP] = "service " + service.Namespace + "/" + service.Name
+			}
+		}
+	}
+	return names, nil
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = strconv.Quote(value)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// protocolName names the IANA protocol numbers flow logs record.
+func protocolName(number string) string {
+	switch number {
+	case "6":
+		return "TCP"
+	case "17":
+		return "UDP"
+	case "1":
+		return "ICMP"
+	case "58":
+		return "ICMPv6"
+	}
+	return number
+}
+
+func formatFlowBytes(value string) string {
+	bytes, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value
+	}
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	unit := 0
+	for bytes >= 1024 && unit < len(units)-1 {
+		bytes /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%.0f B", bytes)
+	}
+	return fmt.Sprintf("%.1f %s", bytes, units[unit])
+}
@@ -0,0 +1,306 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// loadObjectsLabel marks every object created by gen-load-objects
+	loadObjectsLabel = "swissarmycli.io/load-objects"
+	// loadRunLabel holds the run ID, so one run can be cleaned up on its own
+	loadRunLabel = "swissarmycli.io/load-run"
+	// DefaultLoadImage is the image of generated deployments with replicas
+	DefaultLoadImage = "registry.k8s.io/pause:3.10"
+)
+
+// GenLoadObjectsOptions contains options for generating dummy objects
+type GenLoadObjectsOptions struct {
+	Namespaces    int    // Namespaces to create
+	Deployments   int    // Deployments per namespace
+	ConfigMaps    int    // ConfigMaps per namespace
+	Replicas      int32  // Replicas per deployment, 0 creates no pods
+	ConfigMapSize int    // Bytes of data in each ConfigMap
+	Prefix        string // Name prefix of the namespaces
+	RunID         string // Defaults to a timestamp
+	Image         string // Image of the deployments' pods
+	Parallel      int    // Create requests in flight at once
+	QPS           float32
+	Burst         int
+	DryRun        bool
+}
+
+// CleanupLoadObjectsOptions contains options for deleting generated objects
+type CleanupLoadObjectsOptions struct {
+	RunID   string // Only this run, default all runs
+	Wait    bool   // Wait until the namespaces are gone
+	Timeout time.Duration
+	DryRun  bool
+}
+
+// GenerateLoadObjects creates namespaces filled with deployments and
+// ConfigMaps, to see how the cluster and this tool behave with many
+// objects. Every object carries the load-objects label and the run ID, and
+// they all live in the generated namespaces, so cleanup only has to delete
+// those namespaces. Deployments default to zero replicas so the load is on
+// the API server and etcd rather than on the nodes.
+func GenerateLoadObjects(options GenLoadObjectsOptions) error {
+	if options.Namespaces < 1 {
+		return fmt.Errorf("--namespaces must be at least 1")
+	}
+	if options.Deployments < 0 || options.ConfigMaps < 0 || options.Replicas < 0 || options.ConfigMapSize < 0 {
+		return fmt.Errorf("--deployments, --configmaps, --replicas and --configmap-size can't be negative")
+	}
+	if options.ConfigMapSize > 1000*1024 {
+		return fmt.Errorf("--configmap-size can't exceed the 1 MiB ConfigMap limit")
+	}
+	if options.RunID == "" {
+		options.RunID = time.Now().UTC().Format("20060102-150405")
+	}
+	if options.Image == "" {
+		options.Image = DefaultLoadImage
+	}
+	labels := map[string]string{loadObjectsLabel: "true", loadRunLabel: options.RunID}
+
+	total := options.Namespaces * (1 + options.Deployments + options.ConfigMaps)
+	fmt.Printf("Run %s: %d namespaces with %d deployments (%d replicas each) and %d ConfigMaps (%d bytes each) per namespace, %d objects\n",
+		options.RunID, options.Namespaces, options.Deployments, options.Replicas, options.ConfigMaps, options.ConfigMapSize, total)
+	if options.Replicas > 0 {
+		fmt.Printf("⚠️  %d pods will be scheduled\n", options.Namespaces*options.Deployments*int(options.Replicas))
+	}
+	if options.DryRun {
+		fmt.Printf("Dry run: first namespace would be %s, nothing was created.\n", loadNamespaceName(options, 0))
+		return nil
+	}
+
+	config, err := common.GetRESTConfig()
+	if err != nil {
+		return err
+	}
+	config.QPS = options.QPS
+	config.Burst = options.Burst
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+
+	data := map[string]string{"data": strings.Repeat("x", options.ConfigMapSize)}
+	var created, failed atomic.Int64
+	var errMu sync.Mutex
+	errorCounts := make(map[string]int)
+	record := func(kind string, err error) {
+		if err == nil {
+			created.Add(1)
+			return
+		}
+		failed.Add(1)
+		errMu.Lock()
+		errorCounts[fmt.Sprintf("%s: %s", kind, apierrors.ReasonForError(err))]++
+		errMu.Unlock()
+	}
+
+	start := time.Now()
+	jobs := make(chan func())
+	var wg sync.WaitGroup
+	for i := 0; i < max(options.Parallel, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				job()
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				fmt.Printf("  %d/%d created, %d failed\n", created.Load(), total, failed.Load())
+			}
+		}
+	}()
+
+	for n := 0; n < options.Namespaces; n++ {
+		namespace := loadNamespaceName(options, n)
+		_, err := clientset.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: namespace, Labels: labels},
+		}, metav1.CreateOptions{})
+		record("namespace", err)
+		if err != nil {
+			if n == 0 {
+				// Most likely forbidden, the rest would fail the same way
+				close(jobs)
+				wg.Wait()
+				close(done)
+				return fmt.Errorf("failed to create namespace %s: %w", namespace, err)
+			}
+			continue
+		}
+		for i := 0; i < options.ConfigMaps; i++ {
+			name := fmt.Sprintf("load-cm-%d", i)
+			jobs <- func() {
+				_, err := clientset.CoreV1().ConfigMaps(namespace).Create(ctx, &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+					Data:       data,
+				}, metav1.CreateOptions{})
+				record("configmap", err)
+			}
+		}
+		for i := 0; i < options.Deployments; i++ {
+			deployment := loadDeployment(fmt.Sprintf("load-deploy-%d", i), labels, options)
+			jobs <- func() {
+				_, err := clientset.AppsV1().Deployments(namespace).Create(ctx, deployment, metav1.CreateOptions{})
+				record("deployment", err)
+			}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	close(done)
+	elapsed := time.Since(start)
+
+	if len(errorCounts) > 0 {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "FAILURE\tCOUNT")
+		for _, key := range sortedKeys(errorCounts) {
+			fmt.Fprintf(w, "%s\t%d\n", key, errorCounts[key])
+		}
+		w.Flush()
+	}
+
+	fmt.Println("\n--- Load Objects Summary ---")
+	fmt.Printf("Run ID:   %s\n", options.RunID)
+	fmt.Printf("Created:  %d/%d objects in %s (%.1f objects/s)\n", created.Load(), total, elapsed.Round(time.Millisecond), float64(created.Load())/elapsed.Seconds())
+	if failed.Load() > 0 {
+		fmt.Printf("❌ Failed: %d\n", failed.Load())
+	}
+	fmt.Printf("Clean up: swissarmycli gen-load-objects cleanup --run-id %s\n", options.RunID)
+	fmt.Println("----------------------------------------------------")
+	if failed.Load() > 0 {
+		return fmt.Errorf("%d objects failed to be created", failed.Load())
+	}
+	return nil
+}
+
+// CleanupLoadObjects deletes the namespaces created by GenerateLoadObjects,
+// of one run or of all runs, which takes everything in them along.
+func CleanupLoadObjects(options CleanupLoadObjectsOptions) error {
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return err
+	}
+	ctx := context.TODO()
+	selector := loadObjectsLabel + "=true"
+	if options.RunID != "" {
+		selector += "," + loadRunLabel + "=" + options.RunID
+	}
+	namespaces, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	if len(namespaces.Items) == 0 {
+		fmt.Println("No generated namespaces found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tRUN\tSTATUS\tACTION")
+	var failed int
+	for _, namespace := range namespaces.Items {
+		action := "delete"
+		switch {
+		case namespace.Status.Phase == corev1.NamespaceTerminating:
+			action = "already terminating"
+		case options.DryRun:
+			action = "would delete"
+		default:
+			if err := clientset.CoreV1().Namespaces().Delete(ctx, namespace.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				action = fmt.Sprintf("❌ %v", err)
+				failed++
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", namespace.Name, namespace.Labels[loadRunLabel], namespace.Status.Phase, action)
+	}
+	w.Flush()
+
+	if options.Wait && !options.DryRun {
+		fmt.Printf("\nWaiting for %d namespaces to be deleted...\n", len(namespaces.Items))
+		deadline := time.Now().Add(options.Timeout)
+		for {
+			remaining, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				return fmt.Errorf("failed to list namespaces: %w", err)
+			}
+			if len(remaining.Items) == 0 {
+				fmt.Println("✅ All generated namespaces are gone")
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timed out with %d namespaces still terminating", len(remaining.Items))
+			}
+			time.Sleep(5 * time.Second)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d namespaces failed to be deleted", failed)
+	}
+	return nil
+}
+
+// loadNamespaceName is the name of the n-th generated namespace
+func loadNamespaceName(options GenLoadObjectsOptions, n int) string {
+	return fmt.Sprintf("%s-%s-%d", options.Prefix, options.RunID, n)
+}
+
+// loadDeployment is a deployment of pause containers with tiny requests, so
+// replicas schedule even on a busy cluster.
+func loadDeployment(name string, labels map[string]string, options GenLoadObjectsOptions) *appsv1.Deployment {
+	replicas := options.Replicas
+	podLabels := map[string]string{"app": name}
+	for key, value := range labels {
+		podLabels[key] = value
+	}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: podLabels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  "pause",
+						Image: options.Image,
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("1m"),
+								corev1.ResourceMemory: resource.MustParse("8Mi"),
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,388 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"sigs.k8s.io/yaml"
+)
+
+// GetCleanOptions contains options for fetching objects without server noise
+type GetCleanOptions struct {
+	Namespace     string
+	Selector      string // Label selector when no name is given
+	KeepDefaults  bool   // Only strip server-populated metadata and status
+	DropNamespace bool   // Remove metadata.namespace, for manifests applied to several namespaces
+}
+
+// serverMetadataFields are the metadata fields the API server populates
+var serverMetadataFields = []string{
+	"uid", "resourceVersion", "generation", "creationTimestamp", "deletionTimestamp",
+	"deletionGracePeriodSeconds", "managedFields", "selfLink", "ownerReferences", "generateName",
+}
+
+// serverAnnotationPrefixes are annotations written by controllers and
+// kubectl rather than by whoever owns the object
+var serverAnnotationPrefixes = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"kubectl.kubernetes.io/restartedAt",
+	"deployment.kubernetes.io/",
+	"pv.kubernetes.io/",
+	"volume.beta.kubernetes.io/storage-provisioner",
+	"volume.kubernetes.io/storage-provisioner",
+	"volume.kubernetes.io/selected-node",
+	"control-plane.alpha.kubernetes.io/leader",
+	"autoscaling.alpha.kubernetes.io/",
+	"endpoints.kubernetes.io/last-change-trigger-time",
+}
+
+// jobGeneratedLabels are the labels the Job controller adds to its selector
+// and pod template
+var jobGeneratedLabels = []string{"controller-uid", "job-name", "batch.kubernetes.io/controller-uid", "batch.kubernetes.io/job-name"}
+
+// fieldDefault is a field the API server fills in when it isn't set, and
+// the value it fills in
+type fieldDefault struct {
+	path  []string
+	value interface{}
+}
+
+// podSpecDefaults are the defaults of a pod spec
+var podSpecDefaults = []fieldDefault{
+	{[]string{"dnsPolicy"}, "ClusterFirst"},
+	{[]string{"restartPolicy"}, "Always"},
+	{[]string{"schedulerName"}, "default-scheduler"},
+	{[]string{"securityContext"}, map[string]interface{}{}},
+	{[]string{"terminationGracePeriodSeconds"}, int64(30)},
+	{[]string{"enableServiceLinks"}, true},
+	{[]string{"preemptionPolicy"}, "PreemptLowerPriority"},
+	{[]string{"priority"}, int64(0)},
+}
+
+// containerDefaults are the defaults of a container
+var containerDefaults = []fieldDefault{
+	{[]string{"terminationMessagePath"}, "/dev/termination-log"},
+	{[]string{"terminationMessagePolicy"}, "File"},
+	{[]string{"resources"}, map[string]interface{}{}},
+}
+
+// probeDefaults are the defaults of liveness, readiness and startup probes
+var probeDefaults = []fieldDefault{
+	{[]string{"timeoutSeconds"}, int64(1)},
+	{[]string{"periodSeconds"}, int64(10)},
+	{[]string{"successThreshold"}, int64(1)},
+	{[]string{"failureThreshold"}, int64(3)},
+}
+
+// specDefaults are the defaults of the spec of each kind, apart from its
+// pod template
+var specDefaults = map[string][]fieldDefault{
+	"Deployment": {
+		{[]string{"progressDeadlineSeconds"}, int64(600)},
+		{[]string{"revisionHistoryLimit"}, int64(10)},
+		{[]string{"strategy"}, map[string]interface{}{
+			"type":          "RollingUpdate",
+			"rollingUpdate": map[string]interface{}{"maxSurge": "25%", "maxUnavailable": "25%"},
+		}},
+	},
+	"StatefulSet": {
+		{[]string{"podManagementPolicy"}, "OrderedReady"},
+		{[]string{"revisionHistoryLimit"}, int64(10)},
+		{[]string{"updateStrategy"}, map[string]interface{}{
+			"type":          "RollingUpdate",
+			"rollingUpdate": map[string]interface{}{"partition": int64(0)},
+		}},
+		{[]string{"persistentVolumeClaimRetentionPolicy"}, map[string]interface{}{"whenDeleted": "Retain", "whenScaled": "Retain"}},
+	},
+	"DaemonSet": {
+		{[]string{"revisionHistoryLimit"}, int64(10)},
+		{[]string{"updateStrategy"}, map[string]interface{}{
+			"type":          "RollingUpdate",
+			"rollingUpdate": map[string]interface{}{"maxSurge": int64(0), "maxUnavailable": int64(1)},
+		}},
+	},
+	"Job": {
+		{[]string{"backoffLimit"}, int64(6)},
+		{[]string{"completions"}, int64(1)},
+		{[]string{"parallelism"}, int64(1)},
+		{[]string{"completionMode"}, "NonIndexed"},
+		{[]string{"suspend"}, false},
+		{[]string{"manualSelector"}, false},
+		{[]string{"podReplacementPolicy"}, "TerminatingOrFailed"},
+	},
+	"CronJob": {
+		{[]string{"concurrencyPolicy"}, "Allow"},
+		{[]string{"failedJobsHistoryLimit"}, int64(1)},
+		{[]string{"successfulJobsHistoryLimit"}, int64(3)},
+		{[]string{"suspend"}, false},
+	},
+	"Service": {
+		{[]string{"type"}, "ClusterIP"},
+		{[]string{"sessionAffinity"}, "None"},
+		{[]string{"internalTrafficPolicy"}, "Cluster"},
+		{[]string{"ipFamilyPolicy"}, "SingleStack"},
+	},
+	"PersistentVolumeClaim": {
+		{[]string{"volumeMode"}, "Filesystem"},
+	},
+	"Namespace": {
+		{[]string{"finalizers"}, []interface{}{"kubernetes"}},
+	},
+}
+
+// podTemplatePaths is where each kind keeps its pod template
+var podTemplatePaths = map[string][]string{
+	"Deployment":  {"spec", "template"},
+	"ReplicaSet":  {"spec", "template"},
+	"StatefulSet": {"spec", "template"},
+	"DaemonSet":   {"spec", "template"},
+	"Job":         {"spec", "template"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template"},
+}
+
+// GetClean fetches a live object, or every object of the kind matching the
+// selector, and prints it as YAML without what the API server adds: status,
+// managedFields, UIDs, resource versions, controller annotations and,
+// unless KeepDefaults is set, fields left at the value the server defaults
+// them to. The output is ready to commit to Git instead of hand-editing
+// kubectl get -o yaml.
+func GetClean(kind, name string, options GetCleanOptions) error {
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	dynamicClient, err := common.GetDynamicClient()
+	if err != nil {
+		return err
+	}
+	discoveryClient := memory.NewMemCacheClient(clientset.Discovery())
+	mapper := restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(discoveryClient), discoveryClient, nil)
+	mapping, err := resolveResourceKind(mapper, kind)
+	if err != nil {
+		return err
+	}
+
+	var resources dynamic.ResourceInterface = dynamicClient.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		resources = dynamicClient.Resource(mapping.Resource).Namespace(options.Namespace)
+	}
+	ctx := context.TODO()
+	var objects []unstructured.Unstructured
+	if name != "" {
+		object, err := resources.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get %s %s: %w", mapping.GroupVersionKind.Kind, name, err)
+		}
+		objects = append(objects, *object)
+	} else {
+		list, err := resources.List(ctx, metav1.ListOptions{LabelSelector: options.Selector})
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", mapping.Resource.Resource, err)
+		}
+		if len(list.Items) == 0 {
+			return fmt.Errorf("no %s found", mapping.Resource.Resource)
+		}
+		objects = list.Items
+	}
+
+	for i := range objects {
+		cleanObject(objects[i].Object, options)
+		data, err := yaml.Marshal(objects[i].Object)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", objects[i].GetName(), err)
+		}
+		if i > 0 {
+			fmt.Println("---")
+		}
+		os.Stdout.Write(data)
+	}
+	return nil
+}
+
+// resolveResourceKind maps what a user types for a kind, like deploy,
+// deployment, Deployment or deployments.apps, to its resource
+func resolveResourceKind(mapper meta.RESTMapper, kind string) (*meta.RESTMapping, error) {
+	gvr, groupResource := schema.ParseResourceArg(strings.ToLower(kind))
+	var err error
+	var resource schema.GroupVersionResource
+	if gvr != nil {
+		resource, err = mapper.ResourceFor(*gvr)
+	}
+	if gvr == nil || err != nil {
+		resource, err = mapper.ResourceFor(groupResource.WithVersion(""))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unknown resource type %s: %w", kind, err)
+	}
+	gvk, err := mapper.KindFor(resource)
+	if err != nil {
+		return nil, fmt.Errorf("unknown resource type %s: %w", kind, err)
+	}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("unknown resource type %s: %w", kind, err)
+	}
+	return mapping, nil
+}
+
+// cleanObject removes server-populated fields from an object in place
+func cleanObject(object map[string]interface{}, options GetCleanOptions) {
+	kind, _, _ := unstructured.NestedString(object, "kind")
+	delete(object, "status")
+	cleanMetadata(object, []string{"metadata"})
+	if options.DropNamespace {
+		unstructured.RemoveNestedField(object, "metadata", "namespace")
+	}
+
+	switch kind {
+	case "Service":
+		// Cluster IPs are assigned, except for headless services
+		if clusterIP, _, _ := unstructured.NestedString(object, "spec", "clusterIP"); clusterIP != "None" {
+			unstructured.RemoveNestedField(object, "spec", "clusterIP")
+			unstructured.RemoveNestedField(object, "spec", "clusterIPs")
+		}
+		unstructured.RemoveNestedField(object, "spec", "ipFamilies")
+	case "PersistentVolumeClaim":
+		// The volume is bound by the cluster
+		unstructured.RemoveNestedField(object, "spec", "volumeName")
+	case "Namespace":
+		removeLabels(object, []string{"metadata"}, "kubernetes.io/metadata.name")
+	case "Job":
+		if manual, _, _ := unstructured.NestedBool(object, "spec", "manualSelector"); !manual {
+			unstructured.RemoveNestedField(object, "spec", "selector")
+		}
+		removeLabels(object, []string{"metadata"}, jobGeneratedLabels...)
+		removeLabels(object, []string{"spec", "template", "metadata"}, jobGeneratedLabels...)
+	}
+	if path, ok := podTemplatePaths[kind]; ok {
+		cleanMetadata(object, append(append([]string{}, path...), "metadata"))
+	}
+	if options.KeepDefaults {
+		return
+	}
+
+	spec, ok := object["spec"].(map[string]interface{})
+	if ok {
+		removeDefaults(spec, specDefaults[kind])
+		if len(spec) == 0 {
+			delete(object, "spec")
+		}
+	}
+	if ports, found, _ := unstructured.NestedSlice(object, "spec", "ports"); found && kind == "Service" {
+		for _, port := range ports {
+			if port, ok := port.(map[string]interface{}); ok {
+				removeDefaults(port, []fieldDefault{{[]string{"protocol"}, "TCP"}})
+				if reflect.DeepEqual(port["targetPort"], port["port"]) {
+					delete(port, "targetPort")
+				}
+			}
+		}
+		unstructured.SetNestedSlice(object, ports, "spec", "ports")
+	}
+	if kind == "Pod" {
+		cleanPodSpec(spec)
+	} else if path, ok := podTemplatePaths[kind]; ok {
+		podSpec, found, _ := unstructured.NestedFieldNoCopy(object, append(append([]string{}, path...), "spec")...)
+		if podSpec, ok := podSpec.(map[string]interface{}); found && ok {
+			cleanPodSpec(podSpec)
+		}
+	}
+}
+
+// cleanMetadata removes the server-populated fields and annotations of the
+// metadata at path
+func cleanMetadata(object map[string]interface{}, path []string) {
+	metadata, found, _ := unstructured.NestedFieldNoCopy(object, path...)
+	fields, ok := metadata.(map[string]interface{})
+	if !found || !ok {
+		return
+	}
+	for _, field := range serverMetadataFields {
+		delete(fields, field)
+	}
+	if annotations, ok := fields["annotations"].(map[string]interface{}); ok {
+		for key := range annotations {
+			for _, prefix := range serverAnnotationPrefixes {
+				if strings.HasPrefix(key, prefix) {
+					delete(annotations, key)
+				}
+			}
+		}
+		if len(annotations) == 0 {
+			delete(fields, "annotations")
+		}
+	}
+	if len(fields) == 0 {
+		unstructured.RemoveNestedField(object, path...)
+	}
+}
+
+// removeLabels deletes labels from the metadata at path
+func removeLabels(object map[string]interface{}, path []string, keys ...string) {
+	labelsPath := append(append([]string{}, path...), "labels")
+	labels, found, _ := unstructured.NestedFieldNoCopy(object, labelsPath...)
+	values, ok := labels.(map[string]interface{})
+	if !found || !ok {
+		return
+	}
+	for _, key := range keys {
+		delete(values, key)
+	}
+	if len(values) == 0 {
+		unstructured.RemoveNestedField(object, labelsPath...)
+	}
+}
+
+// cleanPodSpec removes the defaulted fields of a pod spec and its containers
+func cleanPodSpec(spec map[string]interface{}) {
+	removeDefaults(spec, podSpecDefaults)
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		containers, _ := spec[field].([]interface{})
+		for _, container := range containers {
+			container, ok := container.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			removeDefaults(container, containerDefaults)
+			// Images tagged latest or untagged default to Always
+			image, _ := container["image"].(string)
+			pullDefault := "IfNotPresent"
+			if !strings.Contains(image, "@") && (!strings.Contains(image[strings.LastIndex(image, "/")+1:], ":") || strings.HasSuffix(image, ":latest")) {
+				pullDefault = "Always"
+			}
+			removeDefaults(container, []fieldDefault{{[]string{"imagePullPolicy"}, pullDefault}})
+			for _, probe := range []string{"livenessProbe", "readinessProbe", "startupProbe"} {
+				if probe, ok := container[probe].(map[string]interface{}); ok {
+					removeDefaults(probe, probeDefaults)
+				}
+			}
+			ports, _ := container["ports"].([]interface{})
+			for _, port := range ports {
+				if port, ok := port.(map[string]interface{}); ok {
+					removeDefaults(port, []fieldDefault{{[]string{"protocol"}, "TCP"}})
+				}
+			}
+		}
+	}
+}
+
+// removeDefaults deletes each field that holds its default value
+func removeDefaults(object map[string]interface{}, defaults []fieldDefault) {
+	for _, field := range defaults {
+		value, found, _ := unstructured.NestedFieldNoCopy(object, field.path...)
+		if found && reflect.DeepEqual(value, field.value) {
+			unstructured.RemoveNestedField(object, field.path...)
+		}
+	}
+}
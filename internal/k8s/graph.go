@@ -0,0 +1,354 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// GraphOptions contains options for the dependency graph export
+type GraphOptions struct {
+	Namespace string
+	Format    string // dot, mermaid or json
+}
+
+// GraphNode is an object in the dependency graph
+type GraphNode struct {
+	ID      string `json:"id"` // Kind/name
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Missing bool   `json:"missing,omitempty"` // Referenced but not found
+}
+
+// GraphEdge is a dependency between two objects
+type GraphEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Relation string `json:"relation"` // selects, runs, mounts, env, pulls with or scheduled on
+}
+
+// WorkloadGraph is the dependency graph of a namespace
+type WorkloadGraph struct {
+	Namespace string      `json:"namespace"`
+	Nodes     []GraphNode `json:"nodes"`
+	Edges     []GraphEdge `json:"edges"`
+}
+
+// ExportGraph prints the dependency graph of a namespace as Graphviz DOT,
+// a Mermaid flowchart or JSON.
+func ExportGraph(options GraphOptions) error {
+	if options.Format != "dot" && options.Format != "mermaid" && options.Format != "json" {
+		return fmt.Errorf("unsupported format '%s' (must be dot, mermaid or json)", options.Format)
+	}
+	if options.Namespace == "" {
+		options.Namespace = "default"
+	}
+	graph, err := BuildWorkloadGraph(options.Namespace)
+	if err != nil {
+		return err
+	}
+
+	switch options.Format {
+	case "json":
+		encoder := json.NewEncoder(sink.Output())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(graph)
+	case "mermaid":
+		writeMermaidGraph(sink.Output(), graph)
+	default:
+		writeDOTGraph(sink.Output(), graph)
+	}
+	return nil
+}
+
+// BuildWorkloadGraph links the Services, workloads and pods of a namespace to
+// the ConfigMaps, Secrets and PVCs the pods use and the nodes they run on.
+// Services are linked to the workloads whose pod template they select, and
+// to bare pods directly.
+func BuildWorkloadGraph(namespace string) (*WorkloadGraph, error) {
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+	listOptions := metav1.ListOptions{}
+
+	services, err := clientset.CoreV1().Services(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	statefulSets, err := clientset.AppsV1().StatefulSets(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	daemonSets, err := clientset.AppsV1().DaemonSets(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+	}
+	replicaSets, err := clientset.AppsV1().ReplicaSets(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replicasets: %w", err)
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	configMaps, err := clientset.CoreV1().ConfigMaps(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configmaps: %w", err)
+	}
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	pvcs, err := clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistentvolumeclaims: %w", err)
+	}
+
+	existing := make(map[string]bool)
+	for _, configMap := range configMaps.Items {
+		existing["ConfigMap/"+configMap.Name] = true
+	}
+	for _, secret := range secrets.Items {
+		existing["Secret/"+secret.Name] = true
+	}
+	for _, pvc := range pvcs.Items {
+		existing["PVC/"+pvc.Name] = true
+	}
+
+	builder := newGraphBuilder(namespace)
+
+	// Workloads are nodes even with no pods, so a Service can point at them
+	templates := make(map[string]map[string]string) // Workload ID to pod template labels
+	for _, deployment := range deployments.Items {
+		templates[builder.add("Deployment", deployment.Name, false)] = deployment.Spec.Template.Labels
+	}
+	for _, statefulSet := range statefulSets.Items {
+		templates[builder.add("StatefulSet", statefulSet.Name, false)] = statefulSet.Spec.Template.Labels
+	}
+	for _, daemonSet := range daemonSets.Items {
+		templates[builder.add("DaemonSet", daemonSet.Name, false)] = daemonSet.Spec.Template.Labels
+	}
+
+	rsOwnerCache := make(map[string]string)
+	for _, rs := range replicaSets.Items {
+		for _, owner := range rs.OwnerReferences {
+			if owner.Kind == "Deployment" {
+				rsOwnerCache[rs.Namespace+"/"+rs.Name] = owner.Name
+			}
+		}
+	}
+
+	for _, service := range services.Items {
+		serviceID := builder.add("Service", service.Name, false)
+		if len(service.Spec.Selector) == 0 {
+			continue
+		}
+		selector := labels.SelectorFromSet(service.Spec.Selector)
+		for workloadID, templateLabels := range templates {
+			if selector.Matches(labels.Set(templateLabels)) {
+				builder.link(serviceID, workloadID, "selects")
+			}
+		}
+		for _, pod := range pods.Items {
+			if len(pod.OwnerReferences) == 0 && selector.Matches(labels.Set(pod.Labels)) {
+				builder.link(serviceID, builder.add("Pod", pod.Name, false), "selects")
+			}
+		}
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		podID := builder.add("Pod", pod.Name, false)
+		if ownerName, ownerKind := getPodOwnerFast(pod, rsOwnerCache); ownerKind != "Pod" {
+			builder.link(builder.add(ownerKind, ownerName, false), podID, "runs")
+		}
+		for _, dependency := range podDependencies(pod.Spec) {
+			id := dependency.kind + "/" + dependency.name
+			builder.link(podID, builder.add(dependency.kind, dependency.name, !existing[id]), dependency.relation)
+		}
+		if pod.Spec.NodeName != "" {
+			builder.link(podID, builder.add("Node", pod.Spec.NodeName, false), "scheduled on")
+		}
+	}
+
+	return builder.graph(), nil
+}
+
+// podDependency is an object a pod spec references
+type podDependency struct {
+	kind     string
+	name     string
+	relation string
+}
+
+// podDependencies returns the ConfigMaps, Secrets and PVCs a pod spec
+// references through volumes, env, envFrom and imagePullSecrets.
+func podDependencies(spec corev1.PodSpec) []podDependency {
+	var dependencies []podDependency
+	for _, volume := range spec.Volumes {
+		switch {
+		case volume.ConfigMap != nil:
+			dependencies = append(dependencies, podDependency{"ConfigMap", volume.ConfigMap.Name, "mounts"})
+		case volume.Secret != nil:
+			dependencies = append(dependencies, podDependency{"Secret", volume.Secret.SecretName, "mounts"})
+		case volume.PersistentVolumeClaim != nil:
+			dependencies = append(dependencies, podDependency{"PVC", volume.PersistentVolumeClaim.ClaimName, "mounts"})
+		case volume.Projected != nil:
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					dependencies = append(dependencies, podDependency{"ConfigMap", source.ConfigMap.Name, "mounts"})
+				}
+				if source.Secret != nil {
+					dependencies = append(dependencies, podDependency{"Secret", source.Secret.Name, "mounts"})
+				}
+			}
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				dependencies = append(dependencies, podDependency{"ConfigMap", envFrom.ConfigMapRef.Name, "env"})
+			}
+			if envFrom.SecretRef != nil {
+				dependencies = append(dependencies, podDependency{"Secret", envFrom.SecretRef.Name, "env"})
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				dependencies = append(dependencies, podDependency{"ConfigMap", ref.Name, "env"})
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				dependencies = append(dependencies, podDependency{"Secret", ref.Name, "env"})
+			}
+		}
+	}
+
+	for _, pullSecret := range spec.ImagePullSecrets {
+		dependencies = append(dependencies, podDependency{"Secret", pullSecret.Name, "pulls with"})
+	}
+	return dependencies
+}
+
+// graphBuilder collects nodes and edges without duplicates
+type graphBuilder struct {
+	namespace string
+	nodes     map[string]*GraphNode
+	edges     map[GraphEdge]bool
+}
+
+func newGraphBuilder(namespace string) *graphBuilder {
+	return &graphBuilder{namespace: namespace, nodes: make(map[string]*GraphNode), edges: make(map[GraphEdge]bool)}
+}
+
+// add returns the ID of the node, adding it when it's new.
+func (b *graphBuilder) add(kind, name string, missing bool) string {
+	id := kind + "/" + name
+	if _, ok := b.nodes[id]; !ok {
+		b.nodes[id] = &GraphNode{ID: id, Kind: kind, Name: name, Missing: missing}
+	}
+	return id
+}
+
+func (b *graphBuilder) link(from, to, relation string) {
+	b.edges[GraphEdge{From: from, To: to, Relation: relation}] = true
+}
+
+// graph returns the nodes and edges sorted, so exports diff cleanly.
+func (b *graphBuilder) graph() *WorkloadGraph {
+	graph := &WorkloadGraph{Namespace: b.namespace, Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	for _, node := range b.nodes {
+		graph.Nodes = append(graph.Nodes, *node)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].ID < graph.Nodes[j].ID })
+	for edge := range b.edges {
+		graph.Edges = append(graph.Edges, edge)
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Relation < b.Relation
+	})
+	return graph
+}
+
+// graphShapes are the Graphviz shapes of each kind
+var graphShapes = map[string]string{
+	"Service":   "ellipse",
+	"Pod":       "box",
+	"ConfigMap": "note",
+	"Secret":    "note",
+	"PVC":       "cylinder",
+	"Node":      "box3d",
+}
+
+func writeDOTGraph(w io.Writer, graph *WorkloadGraph) {
+	fmt.Fprintf(w, "digraph %q {\n", graph.Namespace)
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, "  node [fontname=\"Helvetica\"];")
+	for _, node := range graph.Nodes {
+		shape := graphShapes[node.Kind]
+		if shape == "" {
+			shape = "component" // Workloads
+		}
+		attributes := fmt.Sprintf("label=%q, shape=%s", node.Kind+"\n"+node.Name, shape)
+		if node.Kind == "Pod" {
+			attributes += ", style=rounded"
+		}
+		if node.Missing {
+			attributes += ", style=dashed, color=red"
+		}
+		fmt.Fprintf(w, "  %q [%s];\n", node.ID, attributes)
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(w, "  %q -> %q [label=%q];\n", edge.From, edge.To, edge.Relation)
+	}
+	fmt.Fprintln(w, "}")
+}
+
+func writeMermaidGraph(w io.Writer, graph *WorkloadGraph) {
+	fmt.Fprintln(w, "flowchart LR")
+	// Mermaid IDs can't hold slashes or dots, so nodes are numbered
+	ids := make(map[string]string)
+	var missing []string
+	for i, node := range graph.Nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[node.ID] = id
+		label := strings.ReplaceAll(node.Kind+": "+node.Name, `"`, "#quot;")
+		fmt.Fprintf(w, "  %s[\"%s\"]\n", id, label)
+		if node.Missing {
+			missing = append(missing, id)
+		}
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(w, "  %s -->|%s| %s\n", ids[edge.From], edge.Relation, ids[edge.To])
+	}
+	if len(missing) > 0 {
+		fmt.Fprintln(w, "  classDef missing stroke:#d00,stroke-dasharray:5 5")
+		fmt.Fprintf(w, "  class %s missing\n", strings.Join(missing, ","))
+	}
+}
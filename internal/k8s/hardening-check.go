@@ -0,0 +1,280 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HardeningCheckOptions contains options for the node hardening check
+type HardeningCheckOptions struct {
+	Region      string // Defaults to the region of the cluster's nodes
+	Profile     string
+	Selector    string // Only nodes matching this label selector
+	MaxHopLimit int64  // Highest acceptable IMDS hop limit
+	Output      string // table or json
+	FailOn      string // Lowest severity that fails the run: error, warning, info or none
+}
+
+// hardeningCheck is the outcome of one check on one node
+type hardeningCheck struct {
+	id          string
+	passed      bool
+	severity    string // Severity when failed
+	cell        string // Table cell
+	message     string // Why it failed
+	remediation string
+}
+
+// hardeningCheckNames label the checks in the table and the remediation
+var hardeningCheckNames = map[string]string{
+	"imdsv2":         "IMDSv2 required",
+	"imds-hop-limit": "IMDS hop limit",
+	"ebs-encryption": "EBS volumes encrypted",
+	"public-ip":      "No public IP",
+	"ssm-agent":      "SSM agent healthy",
+}
+
+// hardeningCheckOrder is the order runHardeningChecks returns the checks in
+var hardeningCheckOrder = []string{"imdsv2", "imds-hop-limit", "ebs-encryption", "public-ip", "ssm-agent"}
+
+// CheckNodeHardening verifies, for every EC2 worker node, that IMDSv2 is
+// required, the IMDS hop limit keeps pods away from the node's credentials,
+// its EBS volumes are encrypted, it has no public IP, and its SSM agent is
+// online and current. Failures are reported with the command that fixes
+// them, or the launch template change when the instance can't be fixed in
+// place.
+func CheckNodeHardening(options HardeningCheckOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{LabelSelector: options.Selector})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	nodeByInstance := make(map[string]string)
+	var instanceIDs []string
+	for _, node := range nodes.Items {
+		if instanceID := awsutils.InstanceIDFromProviderID(node.Spec.ProviderID); instanceID != "" {
+			nodeByInstance[instanceID] = node.Name
+			instanceIDs = append(instanceIDs, instanceID)
+		}
+	}
+	if len(instanceIDs) == 0 {
+		return fmt.Errorf("no EC2 nodes found")
+	}
+	sort.Slice(instanceIDs, func(i, j int) bool { return nodeByInstance[instanceIDs[i]] < nodeByInstance[instanceIDs[j]] })
+
+	region := options.Region
+	if region == "" {
+		region = nodes.Items[0].Labels["topology.kubernetes.io/region"]
+	}
+	sess, err := awsutils.NewSession(options.Profile, region)
+	if err != nil {
+		return err
+	}
+	hardening, err := awsutils.GetInstanceHardening(sess, instanceIDs)
+	if err != nil {
+		return err
+	}
+	ssmStatuses, err := awsutils.GetSSMInstanceStatus(sess, instanceIDs)
+	if err != nil {
+		return err
+	}
+
+	checksByInstance := make(map[string][]hardeningCheck)
+	var findings []result.Finding
+	for _, instanceID := range instanceIDs {
+		instance, ok := hardening[instanceID]
+		if !ok {
+			continue
+		}
+		var ssmStatus *awsutils.SSMInstanceStatus
+		if status, ok := ssmStatuses[instanceID]; ok {
+			ssmStatus = &status
+		}
+		checks := runHardeningChecks(instance, ssmStatus, options.MaxHopLimit, region)
+		checksByInstance[instanceID] = checks
+		for _, check := range checks {
+			if check.passed {
+				continue
+			}
+			findings = append(findings, result.Finding{
+				Check:    check.id,
+				Severity: check.severity,
+				Resource: fmt.Sprintf("node/%s (%s)", nodeByInstance[instanceID], instanceID),
+				Message:  check.message,
+				Details:  map[string]string{"remediation": check.remediation},
+			})
+		}
+	}
+	if options.Output == "json" {
+		if err := result.New("node hardening-check", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, "NODE\tINSTANCE")
+	for _, id := range hardeningCheckOrder {
+		fmt.Fprintf(w, "\t%s", strings.ToUpper(hardeningCheckNames[id]))
+	}
+	fmt.Fprintln(w)
+	compliant := 0
+	for _, instanceID := range instanceIDs {
+		checks, ok := checksByInstance[instanceID]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s", nodeByInstance[instanceID], instanceID)
+		allPassed := true
+		for _, check := range checks {
+			fmt.Fprintf(w, "\t%s", check.cell)
+			allPassed = allPassed && check.passed
+		}
+		fmt.Fprintln(w)
+		if allPassed {
+			compliant++
+		}
+	}
+	w.Flush()
+
+	failedByCheck := make(map[string][]string)
+	for _, instanceID := range instanceIDs {
+		for _, check := range checksByInstance[instanceID] {
+			if !check.passed {
+				failedByCheck[check.id] = append(failedByCheck[check.id], fmt.Sprintf("%s: %s", nodeByInstance[instanceID], check.remediation))
+			}
+		}
+	}
+	if len(failedByCheck) > 0 {
+		ebsByDefault := true // Only suggested when known to be off
+		if len(failedByCheck["ebs-encryption"]) > 0 {
+			if enabled, err := awsutils.EBSEncryptionByDefault(sess); err == nil {
+				ebsByDefault = enabled
+			}
+		}
+		fmt.Println("\nRemediation:")
+		for _, id := range hardeningCheckOrder {
+			commands := failedByCheck[id]
+			if len(commands) == 0 {
+				continue
+			}
+			fmt.Printf("\n%s\n", hardeningCheckNames[id])
+			if hint := hardeningLaunchTemplateHint(id, options.MaxHopLimit, ebsByDefault); hint != "" {
+				fmt.Printf("  %s\n", hint)
+			}
+			for i, command := range commands {
+				if i == 10 {
+					fmt.Printf("  ... and %d more\n", len(commands)-10)
+					break
+				}
+				fmt.Printf("  - %s\n", command)
+			}
+		}
+	}
+
+	fmt.Println("\n--- Node Hardening Summary ---")
+	fmt.Printf("Nodes: %d, fully compliant: %d\n", len(checksByInstance), compliant)
+	for _, id := range hardeningCheckOrder {
+		if failed := len(failedByCheck[id]); failed > 0 {
+			fmt.Printf("❌ %s: %d nodes failing\n", hardeningCheckNames[id], failed)
+		} else {
+			fmt.Printf("✅ %s\n", hardeningCheckNames[id])
+		}
+	}
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// runHardeningChecks checks one instance, in hardeningCheckOrder.
+func runHardeningChecks(instance *awsutils.InstanceHardening, ssmStatus *awsutils.SSMInstanceStatus, maxHopLimit int64, region string) []hardeningCheck {
+	metadataOptions := fmt.Sprintf("aws ec2 modify-instance-metadata-options --region %s --instance-id %s", region, instance.InstanceID)
+
+	imds := hardeningCheck{id: "imdsv2", severity: result.SeverityError, passed: true, cell: "✅ required"}
+	switch {
+	case instance.HTTPEndpoint == "disabled":
+		imds.cell = "✅ disabled"
+	case instance.HTTPTokens != "required":
+		imds.passed, imds.cell = false, "❌ "+valueOrDash(instance.HTTPTokens)
+		imds.message = "IMDSv1 is allowed, session tokens are optional"
+		imds.remediation = metadataOptions + " --http-tokens required"
+	}
+
+	hop := hardeningCheck{id: "imds-hop-limit", severity: result.SeverityWarning, passed: true, cell: fmt.Sprintf("✅ %d", instance.HopLimit)}
+	if instance.HTTPEndpoint != "disabled" && instance.HopLimit > maxHopLimit {
+		hop.passed, hop.cell = false, fmt.Sprintf("⚠️  %d", instance.HopLimit)
+		hop.message = fmt.Sprintf("hop limit %d lets pods without host networking reach the node's IMDS credentials", instance.HopLimit)
+		hop.remediation = fmt.Sprintf("%s --http-put-response-hop-limit %d", metadataOptions, maxHopLimit)
+	}
+
+	ebs := hardeningCheck{id: "ebs-encryption", severity: result.SeverityError, passed: true, cell: fmt.Sprintf("✅ %d/%d", instance.Volumes, instance.Volumes)}
+	if unencrypted := len(instance.UnencryptedVolumes); unencrypted > 0 {
+		ebs.passed, ebs.cell = false, fmt.Sprintf("❌ %d/%d", instance.Volumes-unencrypted, instance.Volumes)
+		ebs.message = "unencrypted volumes " + strings.Join(instance.UnencryptedVolumes, ", ")
+		ebs.remediation = "replace the node; volumes can't be encrypted in place (" + strings.Join(instance.UnencryptedVolumes, ", ") + ")"
+	}
+
+	public := hardeningCheck{id: "public-ip", severity: result.SeverityError, passed: true, cell: "✅ none"}
+	if instance.PublicIP != "" {
+		public.passed, public.cell = false, "❌ "+instance.PublicIP
+		public.message = "public IP " + instance.PublicIP
+		public.remediation = fmt.Sprintf("aws ec2 modify-subnet-attribute --region %s --subnet-id %s --no-map-public-ip-on-launch, then replace the node", region, instance.SubnetID)
+	}
+
+	ssm := hardeningCheck{id: "ssm-agent", severity: result.SeverityWarning}
+	switch {
+	case ssmStatus == nil:
+		ssm.cell, ssm.message = "❌ not managed", "the instance is not managed by SSM"
+		profile := instance.InstanceProfile
+		if profile == "" {
+			profile = "<none>"
+		}
+		ssm.remediation = fmt.Sprintf("aws iam attach-role-policy --role-name <role of instance profile %s> --policy-arn arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore", profile)
+	case ssmStatus.PingStatus != "Online":
+		ssm.cell, ssm.message = "❌ "+ssmStatus.PingStatus, "the SSM agent is "+ssmStatus.PingStatus
+		ssm.remediation = "check the agent on the node (systemctl status amazon-ssm-agent) and its route to the SSM endpoints"
+	case !ssmStatus.AgentLatest:
+		ssm.severity = result.SeverityInfo
+		ssm.cell, ssm.message = "⚠️  "+ssmStatus.AgentVersion+" (outdated)", "SSM agent "+ssmStatus.AgentVersion+" is not the latest version"
+		ssm.remediation = fmt.Sprintf("aws ssm send-command --region %s --document-name AWS-UpdateSSMAgent --instance-ids %s", region, instance.InstanceID)
+	default:
+		ssm.passed, ssm.cell = true, "✅ "+ssmStatus.AgentVersion
+	}
+
+	return []hardeningCheck{imds, hop, ebs, public, ssm}
+}
+
+// hardeningLaunchTemplateHint is the permanent fix of a failed check, as
+// commands on single instances don't survive the node being replaced.
+func hardeningLaunchTemplateHint(id string, maxHopLimit int64, ebsByDefault bool) string {
+	switch id {
+	case "imdsv2", "imds-hop-limit":
+		return fmt.Sprintf("Make it permanent in the node group's launch template: MetadataOptions HttpTokens=required, HttpPutResponseHopLimit=%d", maxHopLimit)
+	case "ebs-encryption":
+		hint := "Set Encrypted=true on the launch template's block device mappings and roll the node group"
+		if !ebsByDefault {
+			hint += "; encryption by default is off in this region: aws ec2 enable-ebs-encryption-by-default"
+		}
+		return hint
+	case "public-ip":
+		return "Launch nodes in private subnets, or set AssociatePublicIpAddress=false on the launch template's network interface"
+	case "ssm-agent":
+		return "EKS optimized AMIs include the agent; the node role needs the AmazonSSMManagedInstanceCore policy"
+	}
+	return ""
+}
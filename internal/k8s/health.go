@@ -0,0 +1,315 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// healthCheck is one input to the cluster health score
+type healthCheck struct {
+	name   string
+	weight int
+	score  int // 0-100, or -1 when the check could not run
+	detail string
+	hint   string // What to run next when the score is not perfect
+}
+
+// ShowClusterHealth aggregates node readiness, pod state, crashloops, TLS
+// certificate expiry, subnet IP headroom and PDB coverage into a weighted
+// health score.
+func ShowClusterHealth() error {
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+
+	fmt.Println("Checking cluster health...")
+
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	checks := []healthCheck{
+		checkNodeReadiness(nodes.Items),
+		checkPodPhases(pods.Items),
+		checkCrashLoops(pods.Items),
+		checkCertificateExpiry(clientset),
+		checkSubnetHeadroom(nodes.Items),
+		checkPDBCoverage(clientset),
+	}
+
+	totalWeight, weighted := 0, 0
+	for _, check := range checks {
+		if check.score < 0 {
+			continue
+		}
+		totalWeight += check.weight
+		weighted += check.score * check.weight
+	}
+	overall := 0
+	if totalWeight > 0 {
+		overall = weighted / totalWeight
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nCHECK\tSCORE\tWEIGHT\tDETAIL")
+	for _, check := range checks {
+		score := "n/a"
+		if check.score >= 0 {
+			score = fmt.Sprintf("%s %d", healthIcon(check.score), check.score)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", check.name, score, check.weight, check.detail)
+	}
+	w.Flush()
+
+	fmt.Println("\n--- Cluster Health ---")
+	fmt.Printf("%s Score: %d/100 (%s)\n", healthIcon(overall), overall, healthGrade(overall))
+
+	var hints []string
+	for _, check := range checks {
+		if check.score >= 0 && check.score < 100 && check.hint != "" {
+			hints = append(hints, fmt.Sprintf("  %-18s %s", check.name+":", check.hint))
+		}
+	}
+	if len(hints) > 0 {
+		fmt.Println("\nDrill down:")
+		fmt.Println(strings.Join(hints, "\n"))
+	}
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+func checkNodeReadiness(nodes []corev1.Node) healthCheck {
+	check := healthCheck{name: "Node readiness", weight: 25, hint: "kubectl describe node <name>; swissarmycli node-usage"}
+	if len(nodes) == 0 {
+		check.score, check.detail = 0, "no nodes found"
+		return check
+	}
+
+	var notReady []string
+	for _, node := range nodes {
+		if getNodeReadyStatus(node) != "True" {
+			notReady = append(notReady, node.Name)
+		}
+	}
+	check.score = 100 * (len(nodes) - len(notReady)) / len(nodes)
+	check.detail = fmt.Sprintf("%d/%d nodes ready", len(nodes)-len(notReady), len(nodes))
+	if len(notReady) > 0 {
+		check.detail += " (not ready: " + truncateList(notReady, 3) + ")"
+	}
+	return check
+}
+
+func checkPodPhases(pods []corev1.Pod) healthCheck {
+	check := healthCheck{name: "Pods running", weight: 20, hint: "swissarmycli pending-watch; swissarmycli refs-check"}
+
+	total := 0
+	counts := make(map[corev1.PodPhase]int)
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodSucceeded {
+			continue // Completed jobs are healthy
+		}
+		total++
+		counts[pod.Status.Phase]++
+	}
+	if total == 0 {
+		check.score, check.detail = 100, "no active pods"
+		return check
+	}
+
+	running := counts[corev1.PodRunning]
+	check.score = 100 * running / total
+	check.detail = fmt.Sprintf("%d/%d running", running, total)
+	var others []string
+	for _, phase := range []corev1.PodPhase{corev1.PodPending, corev1.PodFailed, corev1.PodUnknown} {
+		if counts[phase] > 0 {
+			others = append(others, fmt.Sprintf("%d %s", counts[phase], strings.ToLower(string(phase))))
+		}
+	}
+	if len(others) > 0 {
+		check.detail += " (" + strings.Join(others, ", ") + ")"
+	}
+	return check
+}
+
+func checkCrashLoops(pods []corev1.Pod) healthCheck {
+	check := healthCheck{name: "Crashloops", weight: 20, hint: "kubectl logs <pod> --previous; kubectl describe pod <pod>"}
+
+	var crashing []string
+	for _, pod := range pods {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Waiting != nil && status.State.Waiting.Reason == "CrashLoopBackOff" {
+				crashing = append(crashing, pod.Namespace+"/"+pod.Name)
+				break
+			}
+		}
+	}
+
+	// Each crashlooping pod costs 10 points
+	check.score = 100 - 10*len(crashing)
+	if check.score < 0 {
+		check.score = 0
+	}
+	check.detail = fmt.Sprintf("%d pod(s) in CrashLoopBackOff", len(crashing))
+	if len(crashing) > 0 {
+		check.detail += " (" + truncateList(crashing, 3) + ")"
+	}
+	return check
+}
+
+func checkCertificateExpiry(clientset *kubernetes.Clientset) healthCheck {
+	check := healthCheck{name: "TLS certificates", weight: 10, hint: "swissarmycli secret-age -t kubernetes.io/tls"}
+
+	certs, err := getCertificateSummaries(clientset)
+	if err != nil {
+		check.score, check.detail = -1, "could not list TLS secrets"
+		return check
+	}
+
+	expired, expiring := 0, 0
+	for _, cert := range certs {
+		if cert.DaysUntilExpiry < 0 {
+			expired++
+		} else if cert.DaysUntilExpiry <= certExpiryWarningDays {
+			expiring++
+		}
+	}
+
+	// An expired certificate usually means an outage, expiring ones are a warning
+	check.score = 100 - 25*expired - 5*expiring
+	if check.score < 0 {
+		check.score = 0
+	}
+	check.detail = fmt.Sprintf("%d checked, %d expired, %d expiring within %d days", len(certs), expired, expiring, certExpiryWarningDays)
+	return check
+}
+
+func checkSubnetHeadroom(nodes []corev1.Node) healthCheck {
+	check := healthCheck{name: "Subnet IPs", weight: 15, hint: "swissarmycli getsnapshot --format txt"}
+
+	subnets := awsutils.GetNodeSubnetInfo(nodes)
+	if len(subnets) == 0 {
+		check.score, check.detail = -1, "no EC2 subnet information available"
+		return check
+	}
+
+	lowest := subnets[0]
+	for _, subnet := range subnets[1:] {
+		if subnet.AvailableIPs < lowest.AvailableIPs {
+			lowest = subnet
+		}
+	}
+
+	switch {
+	case lowest.AvailableIPs < 10:
+		check.score = 0
+	case lowest.AvailableIPs < 50:
+		check.score = 50
+	case lowest.AvailableIPs < 100:
+		check.score = 80
+	default:
+		check.score = 100
+	}
+	check.detail = fmt.Sprintf("%d node subnet(s), lowest %s with %d free IPs", len(subnets), lowest.SubnetID, lowest.AvailableIPs)
+	return check
+}
+
+func checkPDBCoverage(clientset *kubernetes.Clientset) healthCheck {
+	check := healthCheck{name: "PDB coverage", weight: 10, hint: "swissarmycli az-impact <zone>; swissarmycli topology-check"}
+	ctx := context.TODO()
+
+	pdbs, err := clientset.PolicyV1().PodDisruptionBudgets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		check.score, check.detail = -1, "could not list PodDisruptionBudgets"
+		return check
+	}
+	deployments, err := clientset.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		check.score, check.detail = -1, "could not list deployments"
+		return check
+	}
+	statefulSets, err := clientset.AppsV1().StatefulSets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		check.score, check.detail = -1, "could not list statefulsets"
+		return check
+	}
+
+	type replicated struct {
+		namespace string
+		labels    labels.Set
+	}
+	var workloads []replicated
+	for _, d := range deployments.Items {
+		if d.Spec.Replicas != nil && *d.Spec.Replicas > 1 {
+			workloads = append(workloads, replicated{d.Namespace, d.Spec.Template.Labels})
+		}
+	}
+	for _, s := range statefulSets.Items {
+		if s.Spec.Replicas != nil && *s.Spec.Replicas > 1 {
+			workloads = append(workloads, replicated{s.Namespace, s.Spec.Template.Labels})
+		}
+	}
+	if len(workloads) == 0 {
+		check.score, check.detail = 100, "no workloads with more than one replica"
+		return check
+	}
+
+	covered := 0
+	for _, workload := range workloads {
+		for _, pdb := range pdbs.Items {
+			if pdb.Namespace == workload.namespace && selectorMatches(pdb.Spec.Selector, workload.labels) {
+				covered++
+				break
+			}
+		}
+	}
+	check.score = 100 * covered / len(workloads)
+	check.detail = fmt.Sprintf("%d/%d replicated workloads covered by a PDB", covered, len(workloads))
+	return check
+}
+
+func healthIcon(score int) string {
+	switch {
+	case score >= 90:
+		return "✅"
+	case score >= 70:
+		return "⚠️ "
+	default:
+		return "❌"
+	}
+}
+
+func healthGrade(score int) string {
+	switch {
+	case score >= 90:
+		return "healthy"
+	case score >= 70:
+		return "degraded"
+	default:
+		return "unhealthy"
+	}
+}
+
+// truncateList joins the first n items and notes how many were left out.
+func truncateList(items []string, n int) string {
+	if len(items) <= n {
+		return strings.Join(items, ", ")
+	}
+	return strings.Join(items[:n], ", ") + fmt.Sprintf(" and %d more", len(items)-n)
+}
@@ -0,0 +1,216 @@
+package k8s
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// hibernatedHoursPerMonth assumes the usual dev cluster schedule: off 12
+// hours every weekday night and the whole weekend
+const hibernatedHoursPerMonth = (5*12 + 48) * 52 / 12
+
+// HibernateOptions contains options for the hibernate and resume commands
+type HibernateOptions struct {
+	Cluster    string   // EKS cluster whose managed node groups are included
+	NodeGroups []string // Only these node groups of Cluster
+	Schedule   string   // Cron expression; creates a recurring scheduled action instead of scaling now
+	TimeZone   string   // IANA time zone of Schedule, UTC when empty
+	Region     string
+	Profile    string
+	DryRun     bool
+}
+
+// Hibernate scales ASGs and managed node groups to zero, recording their
+// sizes in a tag so Resume can restore them, and prints the projected
+// savings priced from the cost estimate table. With a schedule it creates a
+// recurring scheduled action on each ASG instead.
+func Hibernate(asgNames []string, options HibernateOptions) error {
+	sess, groups, err := findHibernateGroups(asgNames, options)
+	if err != nil {
+		return err
+	}
+	pricing, err := loadPricingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load pricing config: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "GROUP\tSIZES\tINSTANCES\tHOURLY COST\tACTION")
+	var hourlySavings float64
+	unpriced := make(map[string]bool)
+	var failed int
+	for _, group := range groups {
+		var hourly float64
+		var instances []string
+		for _, instanceType := range sortedKeys(group.InstanceTypes) {
+			count := group.InstanceTypes[instanceType]
+			instances = append(instances, fmt.Sprintf("%d x %s", count, instanceType))
+			if price, ok := pricing.EC2Pricing[instanceType]; ok {
+				hourly += price * float64(count)
+			} else {
+				unpriced[instanceType] = true
+			}
+		}
+
+		action := "scale to 0"
+		switch {
+		case options.Schedule != "":
+			action = "schedule scale to 0"
+		case group.Hibernated():
+			action = "already hibernated"
+		}
+		if !options.DryRun && action != "already hibernated" {
+			if options.Schedule != "" {
+				err = awsutils.RecordGroupSizes(sess, group)
+				if err == nil {
+					err = awsutils.ScheduleGroupSizes(sess, group, awsutils.HibernateActionName,
+						awsutils.GroupSizes{Min: 0, Max: group.Sizes.Max, Desired: 0}, options.Schedule, options.TimeZone)
+				}
+			} else {
+				err = awsutils.HibernateGroup(sess, group)
+			}
+			if err != nil {
+				action = fmt.Sprintf("❌ %v", err)
+				failed++
+			} else {
+				action = "✅ " + action
+			}
+		}
+		if !strings.HasPrefix(action, "❌") {
+			hourlySavings += hourly
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t$%.2f\t%s\n", group.Name(), group.Sizes, valueOrDash(strings.Join(instances, ", ")), hourly, action)
+	}
+	w.Flush()
+
+	fmt.Println("\n--- Hibernate Summary ---")
+	if options.DryRun {
+		fmt.Println("Dry run: nothing was changed.")
+	}
+	if options.Schedule != "" {
+		fmt.Printf("Schedule: %s (%s), scheduled action %s\n", options.Schedule, timeZoneLabel(options.TimeZone), awsutils.HibernateActionName)
+	}
+	fmt.Printf("Projected savings: $%.2f/hour, about $%.2f/month when off nights and weekends (%d hours)\n",
+		hourlySavings, hourlySavings*hibernatedHoursPerMonth, hibernatedHoursPerMonth)
+	if len(unpriced) > 0 {
+		fmt.Printf("⚠️  No price for %s; not included in the savings\n", strings.Join(sortedKeys(unpriced), ", "))
+	}
+	fmt.Println("----------------------------------------------------")
+	if failed > 0 {
+		return fmt.Errorf("%d of %d groups could not be hibernated", failed, len(groups))
+	}
+	return nil
+}
+
+// Resume scales hibernated ASGs and node groups back to their recorded
+// sizes. With a schedule it creates a recurring scheduled action restoring
+// the recorded sizes, or the current ones when the group isn't hibernated.
+func Resume(asgNames []string, options HibernateOptions) error {
+	sess, groups, err := findHibernateGroups(asgNames, options)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "GROUP\tCURRENT\tRESUME TO\tACTION")
+	var failed int
+	for _, group := range groups {
+		target := group.Sizes
+		if group.Recorded != nil {
+			target = *group.Recorded
+		}
+
+		action := "restore"
+		switch {
+		case options.Schedule != "":
+			action = "schedule restore"
+		case group.Recorded == nil:
+			action = "not hibernated"
+		}
+		if !options.DryRun && action != "not hibernated" {
+			if options.Schedule != "" {
+				err = awsutils.RecordGroupSizes(sess, group)
+				if err == nil {
+					err = awsutils.ScheduleGroupSizes(sess, group, awsutils.ResumeActionName, target, options.Schedule, options.TimeZone)
+				}
+			} else {
+				err = awsutils.ResumeGroup(sess, group)
+			}
+			if err != nil {
+				action = fmt.Sprintf("❌ %v", err)
+				failed++
+			} else {
+				action = "✅ " + action
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", group.Name(), group.Sizes, target, action)
+	}
+	w.Flush()
+
+	fmt.Println("\n--- Resume Summary ---")
+	if options.DryRun {
+		fmt.Println("Dry run: nothing was changed.")
+	}
+	if options.Schedule != "" {
+		fmt.Printf("Schedule: %s (%s), scheduled action %s\n", options.Schedule, timeZoneLabel(options.TimeZone), awsutils.ResumeActionName)
+	}
+	fmt.Printf("Groups: %d, failed: %d\n", len(groups), failed)
+	fmt.Println("----------------------------------------------------")
+	if failed > 0 {
+		return fmt.Errorf("%d of %d groups could not be resumed", failed, len(groups))
+	}
+	return nil
+}
+
+func findHibernateGroups(asgNames []string, options HibernateOptions) (*session.Session, []*awsutils.ScalableGroup, error) {
+	if len(asgNames) == 0 && options.Cluster == "" {
+		return nil, nil, fmt.Errorf("name the ASGs or pass --cluster")
+	}
+	if len(options.NodeGroups) > 0 && options.Cluster == "" {
+		return nil, nil, fmt.Errorf("--nodegroup requires --cluster")
+	}
+	if options.Schedule != "" && len(strings.Fields(options.Schedule)) != 5 {
+		return nil, nil, fmt.Errorf("--schedule must be a cron expression with 5 fields, e.g. \"0 20 * * 1-5\"")
+	}
+	if options.TimeZone != "" {
+		if _, err := time.LoadLocation(options.TimeZone); err != nil {
+			return nil, nil, fmt.Errorf("invalid --timezone '%s': %w", options.TimeZone, err)
+		}
+	}
+
+	sess, err := awsutils.NewSession(options.Profile, options.Region)
+	if err != nil {
+		return nil, nil, err
+	}
+	groups, err := awsutils.FindScalableGroups(sess, asgNames, options.Cluster, options.NodeGroups)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(groups) == 0 {
+		return nil, nil, fmt.Errorf("cluster %s has no managed node groups", options.Cluster)
+	}
+	return sess, groups, nil
+}
+
+func timeZoneLabel(timeZone string) string {
+	if timeZone == "" {
+		return "UTC"
+	}
+	return timeZone
+}
+
+func sortedKeys[V any](set map[string]V) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
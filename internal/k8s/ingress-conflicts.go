@@ -0,0 +1,655 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	albAnnotationPrefix = "alb.ingress.kubernetes.io/"
+	albController       = "ingress.k8s.aws/alb"
+	albRuleLimit        = 100 // Default quota of rules per ALB listener
+)
+
+// albGroupSettings are the annotations the AWS Load Balancer Controller
+// merges across a group; differing values make it reject the whole group
+var albGroupSettings = []string{"scheme", "ip-address-type", "load-balancer-name", "subnets", "security-groups", "wafv2-acl-arn"}
+
+// albListenerSettings have to agree between Ingresses sharing a listener port
+var albListenerSettings = []string{"ssl-policy", "inbound-cidrs"}
+
+// IngressConflictsOptions contains options for the ALB ingress group check
+type IngressConflictsOptions struct {
+	Group   string // Only check this group
+	ACM     bool   // Look up the certificates of HTTPS listeners in ACM
+	Region  string
+	Profile string
+	Output  string // table or json
+	FailOn  string // Lowest severity that fails the run: error, warning, info or none
+}
+
+// albIngress is an Ingress as the controller sees it within its group
+type albIngress struct {
+	ingress  networkingv1.Ingress
+	name     string // namespace/name
+	order    int
+	explicit bool           // group.order is set
+	ports    map[int]string // Listener port to protocol
+	certArns []string
+	rules    []albRule
+}
+
+// albRule is one host and path of an Ingress, in evaluation order
+type albRule struct {
+	owner    *albIngress
+	host     string // Empty matches every host
+	path     string
+	prefix   bool // Path matches itself and everything below it
+	wildcard bool // ImplementationSpecific pattern with wildcards, not compared for shadowing
+	backend  string
+}
+
+// albGroup is the set of Ingresses served by one ALB
+type albGroup struct {
+	name      string
+	implicit  bool // Ingress without group.name, alone on its ALB
+	ingresses []*albIngress
+}
+
+// CheckIngressConflicts analyzes the Ingresses sharing an ALB through the
+// group.name annotation for problems the AWS Load Balancer Controller only
+// reports as reconcile errors, or not at all: conflicting group settings,
+// duplicate or invalid group.order, the same host and path routed to
+// different backends, rules shadowed by a broader rule evaluated first,
+// listeners over the rule quota, and hosts on HTTPS listeners no certificate
+// of the group covers.
+func CheckIngressConflicts(options IngressConflictsOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+
+	classes, err := clientset.NetworkingV1().IngressClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list ingress classes: %w", err)
+	}
+	albClasses := map[string]bool{"alb": true}
+	defaultALB := false
+	for _, class := range classes.Items {
+		if class.Spec.Controller == albController {
+			albClasses[class.Name] = true
+			defaultALB = defaultALB || class.Annotations[networkingv1.AnnotationIsDefaultIngressClass] == "true"
+		}
+	}
+	ingresses, err := clientset.NetworkingV1().Ingresses("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list ingresses: %w", err)
+	}
+
+	var findings []result.Finding
+	groups := make(map[string]*albGroup)
+	for _, ingress := range ingresses.Items {
+		class := ingress.Annotations["kubernetes.io/ingress.class"]
+		if ingress.Spec.IngressClassName != nil {
+			class = *ingress.Spec.IngressClassName
+		}
+		if !albClasses[class] && !(class == "" && defaultALB) {
+			continue
+		}
+		name := ingress.Namespace + "/" + ingress.Name
+		groupName := ingress.Annotations[albAnnotationPrefix+"group.name"]
+		key := groupName
+		if groupName == "" {
+			key = "ingress:" + name
+		}
+		if options.Group != "" && groupName != options.Group {
+			continue
+		}
+		group, ok := groups[key]
+		if !ok {
+			group = &albGroup{name: groupName, implicit: groupName == ""}
+			if group.implicit {
+				group.name = name
+			}
+			groups[key] = group
+		}
+		entry, problems := parseALBIngress(ingress)
+		for _, problem := range problems {
+			findings = append(findings, result.Finding{
+				Check:    "invalid-annotation",
+				Severity: result.SeverityError,
+				Resource: name,
+				Message:  problem,
+				Details:  map[string]string{"group": group.name},
+			})
+		}
+		group.ingresses = append(group.ingresses, entry)
+	}
+	if options.Group != "" && len(groups) == 0 {
+		return fmt.Errorf("no ALB Ingresses in group %s", options.Group)
+	}
+
+	var certificates map[string]awsutils.ACMCertificate
+	if options.ACM && groupsUseHTTPS(groups) {
+		certificates, err = acmCertificatesByArn(options.Region, options.Profile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v, certificates are not checked\n", err)
+		}
+	}
+
+	for _, key := range sortedKeys(groups) {
+		group := groups[key]
+		// The controller evaluates the Ingresses of a group by group.order,
+		// then by namespace and name
+		sort.SliceStable(group.ingresses, func(i, j int) bool {
+			if group.ingresses[i].order != group.ingresses[j].order {
+				return group.ingresses[i].order < group.ingresses[j].order
+			}
+			return group.ingresses[i].name < group.ingresses[j].name
+		})
+		findings = append(findings, checkGroupSettings(group)...)
+		findings = append(findings, checkGroupRules(group)...)
+		findings = append(findings, checkGroupCertificates(group, certificates)...)
+	}
+
+	if options.Output == "json" {
+		if err := result.New("ingress-conflicts", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	ingressCount, shared := 0, 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "GROUP\tINGRESSES\tLISTENERS\tRULES\tSTATUS")
+	for _, key := range sortedKeys(groups) {
+		group := groups[key]
+		ingressCount += len(group.ingresses)
+		if !group.implicit {
+			shared++
+		}
+		worst := ""
+		for _, finding := range findings {
+			if finding.Details["group"] == group.name && result.Rank(finding.Severity) > result.Rank(worst) {
+				worst = finding.Severity
+			}
+		}
+		status := "✅"
+		if worst != "" {
+			status = result.Label(worst)
+		}
+		name := group.name
+		if group.implicit {
+			name += " (own ALB)"
+		}
+		ports, rules := groupListeners(group)
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%s\n", name, len(group.ingresses), valueOrDash(ports), rules, status)
+	}
+	w.Flush()
+
+	if len(findings) > 0 {
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SEVERITY\tGROUP\tRESOURCE\tCHECK\tMESSAGE")
+		for _, finding := range findings {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", result.Label(finding.Severity), finding.Details["group"], finding.Resource, finding.Check, finding.Message)
+		}
+		w.Flush()
+	}
+
+	summary := result.New("ingress-conflicts", findings).Summary
+	fmt.Println("\n--- Ingress Conflicts Summary ---")
+	fmt.Printf("ALB Ingresses: %d in %d groups (%d shared)\n", ingressCount, len(groups), shared)
+	fmt.Printf("Errors: %d, warnings: %d\n", summary[result.SeverityError], summary[result.SeverityWarning])
+	if options.ACM && certificates == nil && groupsUseHTTPS(groups) {
+		fmt.Println("Certificates: not checked")
+	}
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// parseALBIngress reads the group order, listeners, certificates and rules
+// of an Ingress, with a message for every annotation the controller rejects.
+func parseALBIngress(ingress networkingv1.Ingress) (*albIngress, []string) {
+	entry := &albIngress{ingress: ingress, name: ingress.Namespace + "/" + ingress.Name, ports: make(map[int]string)}
+	var problems []string
+
+	if value := ingress.Annotations[albAnnotationPrefix+"group.order"]; value != "" {
+		order, err := strconv.Atoi(value)
+		if err != nil || order < -1000 || order > 1000 {
+			problems = append(problems, fmt.Sprintf("group.order '%s' must be an integer from -1000 to 1000", value))
+		} else {
+			entry.order, entry.explicit = order, true
+		}
+	}
+
+	for _, arn := range strings.Split(ingress.Annotations[albCertificateAnnotation], ",") {
+		if arn = strings.TrimSpace(arn); arn != "" {
+			entry.certArns = append(entry.certArns, arn)
+		}
+	}
+	if value := ingress.Annotations[albAnnotationPrefix+"listen-ports"]; value != "" {
+		var listeners []map[string]int
+		if err := json.Unmarshal([]byte(value), &listeners); err != nil {
+			problems = append(problems, fmt.Sprintf("listen-ports is not valid JSON: %v", err))
+		}
+		for _, listener := range listeners {
+			for protocol, port := range listener {
+				entry.ports[port] = strings.ToUpper(protocol)
+			}
+		}
+	}
+	if len(entry.ports) == 0 {
+		// The controller listens on HTTPS when it has certificates, explicit
+		// or discovered from the TLS hosts
+		if len(entry.certArns) > 0 || len(ingress.Spec.TLS) > 0 {
+			entry.ports[443] = "HTTPS"
+		} else {
+			entry.ports[80] = "HTTP"
+		}
+	}
+
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			r := albRule{owner: entry, host: strings.ToLower(rule.Host), path: path.Path, backend: ingressBackendName(path.Backend)}
+			if r.path == "" {
+				r.path = "/"
+			}
+			pathType := networkingv1.PathTypeImplementationSpecific
+			if path.PathType != nil {
+				pathType = *path.PathType
+			}
+			switch {
+			case pathType == networkingv1.PathTypePrefix:
+				r.prefix = true
+			case pathType == networkingv1.PathTypeImplementationSpecific && strings.HasSuffix(r.path, "/*") && !strings.ContainsAny(r.path[:len(r.path)-2], "*?"):
+				r.path, r.prefix = strings.TrimSuffix(r.path, "*"), true
+			case pathType == networkingv1.PathTypeImplementationSpecific && strings.ContainsAny(r.path, "*?"):
+				r.wildcard = true
+			}
+			if r.prefix && r.path != "/" {
+				r.path = strings.TrimSuffix(r.path, "/")
+			}
+			entry.rules = append(entry.rules, r)
+		}
+	}
+	return entry, problems
+}
+
+// ingressBackendName names the service port or resource a path routes to;
+// use-annotation backends name the action annotation.
+func ingressBackendName(backend networkingv1.IngressBackend) string {
+	if backend.Resource != nil {
+		return backend.Resource.Kind + "/" + backend.Resource.Name
+	}
+	if backend.Service == nil {
+		return "-"
+	}
+	if backend.Service.Port.Name == "use-annotation" {
+		return "actions." + backend.Service.Name
+	}
+	if backend.Service.Port.Name != "" {
+		return backend.Service.Name + ":" + backend.Service.Port.Name
+	}
+	return fmt.Sprintf("%s:%d", backend.Service.Name, backend.Service.Port.Number)
+}
+
+// checkGroupSettings finds group.order values used twice and group or
+// listener settings the Ingresses of a group disagree on.
+func checkGroupSettings(group *albGroup) []result.Finding {
+	var findings []result.Finding
+	add := func(check, resource, message string, details map[string]string) {
+		details["group"] = group.name
+		findings = append(findings, result.Finding{Check: check, Severity: result.SeverityError, Resource: resource, Message: message, Details: details})
+	}
+
+	orders := make(map[int][]string)
+	for _, entry := range group.ingresses {
+		if entry.explicit {
+			orders[entry.order] = append(orders[entry.order], entry.name)
+		}
+	}
+	for order, names := range orders {
+		if len(names) > 1 {
+			add("duplicate-group-order", "group/"+group.name,
+				fmt.Sprintf("group.order %d is set on %s; the controller rejects the group until orders are unique", order, strings.Join(names, ", ")),
+				map[string]string{"order": strconv.Itoa(order)})
+		}
+	}
+
+	for _, setting := range albGroupSettings {
+		if values := settingValues(group.ingresses, setting, nil); len(values) > 1 {
+			add("conflicting-setting", "group/"+group.name,
+				fmt.Sprintf("%s differs: %s", setting, describeSettingValues(values)), map[string]string{"annotation": albAnnotationPrefix + setting})
+		}
+	}
+	for _, setting := range albListenerSettings {
+		for _, port := range groupPorts(group) {
+			if values := settingValues(group.ingresses, setting, &port); len(values) > 1 {
+				add("conflicting-setting", "group/"+group.name,
+					fmt.Sprintf("%s differs on port %d: %s", setting, port, describeSettingValues(values)),
+					map[string]string{"annotation": albAnnotationPrefix + setting, "port": strconv.Itoa(port)})
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Message < findings[j].Message })
+	return findings
+}
+
+// settingValues maps each value of an annotation to the Ingresses setting
+// it, optionally only those listening on the port. Lists are compared
+// regardless of order.
+func settingValues(ingresses []*albIngress, setting string, port *int) map[string][]string {
+	values := make(map[string][]string)
+	for _, entry := range ingresses {
+		value := strings.TrimSpace(entry.ingress.Annotations[albAnnotationPrefix+setting])
+		if value == "" {
+			continue
+		}
+		if port != nil {
+			if protocol, ok := entry.ports[*port]; !ok || (setting == "ssl-policy" && protocol != "HTTPS") {
+				continue
+			}
+		}
+		items := strings.Split(value, ",")
+		for i := range items {
+			items[i] = strings.TrimSpace(items[i])
+		}
+		sort.Strings(items)
+		key := strings.Join(items, ",")
+		values[key] = append(values[key], entry.name)
+	}
+	return values
+}
+
+func describeSettingValues(values map[string][]string) string {
+	var parts []string
+	for _, value := range sortedKeys(values) {
+		parts = append(parts, fmt.Sprintf("'%s' (%s)", value, strings.Join(values[value], ", ")))
+	}
+	return strings.Join(parts, " vs ")
+}
+
+// checkGroupRules finds the same host and path routed twice, rules that a
+// broader rule evaluated before them always wins over, and listeners over
+// the rule quota.
+func checkGroupRules(group *albGroup) []result.Finding {
+	var findings []result.Finding
+	for _, port := range groupPorts(group) {
+		var rules []albRule
+		for _, entry := range group.ingresses {
+			if _, ok := entry.ports[port]; ok {
+				rules = append(rules, entry.rules...)
+			}
+		}
+		if len(rules) > albRuleLimit {
+			findings = append(findings, result.Finding{
+				Check:    "rule-limit",
+				Severity: result.SeverityWarning,
+				Resource: "group/" + group.name,
+				Message:  fmt.Sprintf("%d rules on port %d exceed the default quota of %d rules per listener", len(rules), port, albRuleLimit),
+				Details:  map[string]string{"group": group.name, "port": strconv.Itoa(port), "rules": strconv.Itoa(len(rules))},
+			})
+		}
+
+		reported := make(map[string]bool)
+		for j, later := range rules {
+			for _, earlier := range rules[:j] {
+				check, severity, message := "", "", ""
+				switch {
+				case earlier.host == later.host && earlier.path == later.path && earlier.prefix == later.prefix:
+					if earlier.backend == later.backend {
+						if earlier.owner == later.owner {
+							continue
+						}
+						check, severity = "duplicate-rule", result.SeverityWarning
+						message = "is also routed by " + earlier.owner.name + " to the same backend"
+					} else {
+						check, severity = "conflicting-rule", result.SeverityError
+						message = fmt.Sprintf("routes to %s, but %s is evaluated first and sends it to %s", later.backend, earlier.owner.name, earlier.backend)
+					}
+				case ruleShadows(earlier, later):
+					check, severity = "shadowed-rule", result.SeverityWarning
+					message = fmt.Sprintf("never matches: %s is evaluated first and its %s already matches it", earlier.owner.name, ruleLabel(earlier))
+				default:
+					continue
+				}
+				key := check + later.owner.name + ruleLabel(later)
+				if reported[key] {
+					continue
+				}
+				reported[key] = true
+				findings = append(findings, result.Finding{
+					Check:    check,
+					Severity: severity,
+					Resource: later.owner.name,
+					Message:  fmt.Sprintf("%s on port %d %s", ruleLabel(later), port, message),
+					Details: map[string]string{"group": group.name, "port": strconv.Itoa(port), "rule": ruleLabel(later),
+						"backend": later.backend, "winner": earlier.owner.name, "winner_backend": earlier.backend},
+				})
+			}
+		}
+	}
+
+	var defaults []string
+	for _, entry := range group.ingresses {
+		if entry.ingress.Spec.DefaultBackend != nil {
+			defaults = append(defaults, entry.name)
+		}
+	}
+	if len(defaults) > 1 {
+		findings = append(findings, result.Finding{
+			Check:    "conflicting-default-backend",
+			Severity: result.SeverityWarning,
+			Resource: "group/" + group.name,
+			Message:  fmt.Sprintf("%s set a default backend; only the one from %s is used", strings.Join(defaults, ", "), defaults[0]),
+			Details:  map[string]string{"group": group.name},
+		})
+	}
+	return findings
+}
+
+// ruleShadows reports whether earlier, a prefix rule, matches every request
+// later matches.
+func ruleShadows(earlier, later albRule) bool {
+	if !earlier.prefix || earlier.wildcard || later.wildcard {
+		return false
+	}
+	if earlier.host != "" && !hostMatches(earlier.host, later.host) {
+		return false
+	}
+	if later.host == "" && earlier.host != "" {
+		return false
+	}
+	return earlier.path == "/" || later.path == earlier.path || strings.HasPrefix(later.path, earlier.path+"/")
+}
+
+func ruleLabel(rule albRule) string {
+	host := rule.host
+	if host == "" {
+		host = "*"
+	}
+	path := rule.path
+	if rule.prefix {
+		path = strings.TrimSuffix(path, "/") + "/*"
+	}
+	return host + path
+}
+
+// checkGroupCertificates flags certificates on Ingresses without an HTTPS
+// listener and, with ACM data, hosts on HTTPS listeners that no certificate
+// of the group covers and certificates that are missing or not issued.
+func checkGroupCertificates(group *albGroup, certificates map[string]awsutils.ACMCertificate) []result.Finding {
+	var findings []result.Finding
+	add := func(check, severity, resource, message string, details map[string]string) {
+		details["group"] = group.name
+		findings = append(findings, result.Finding{Check: check, Severity: severity, Resource: resource, Message: message, Details: details})
+	}
+
+	// Certificates of all Ingresses end up on every HTTPS listener of the
+	// group; the first one is the default
+	var groupArns []string
+	for _, entry := range group.ingresses {
+		https := false
+		for _, protocol := range entry.ports {
+			https = https || protocol == "HTTPS"
+		}
+		if len(entry.certArns) > 0 && !https {
+			add("unused-certificate", result.SeverityWarning, entry.name, "has certificate-arn but no HTTPS listener in listen-ports", map[string]string{})
+		}
+		for _, arn := range entry.certArns {
+			if !containsString(groupArns, arn) {
+				groupArns = append(groupArns, arn)
+			}
+		}
+	}
+	if certificates == nil {
+		return findings
+	}
+
+	var names []string
+	for _, arn := range groupArns {
+		certificate, ok := certificates[arn]
+		switch {
+		case !ok:
+			add("certificate-not-found", result.SeverityError, "group/"+group.name,
+				fmt.Sprintf("certificate %s is not in ACM of this region", arn), map[string]string{"certificate": arn})
+		case certificate.Status != "ISSUED":
+			add("certificate-not-issued", result.SeverityError, "group/"+group.name,
+				fmt.Sprintf("certificate %s for %s is %s", arn, certificate.DomainName, certificate.Status), map[string]string{"certificate": arn})
+		default:
+			names = append(names, certificate.Names...)
+		}
+	}
+	if len(groupArns) == 0 {
+		// Without certificate-arn the controller discovers issued ACM
+		// certificates by host
+		for _, certificate := range certificates {
+			if certificate.Status == "ISSUED" {
+				names = append(names, certificate.Names...)
+			}
+		}
+	}
+
+	for _, entry := range group.ingresses {
+		var hosts []string
+		for _, tls := range entry.ingress.Spec.TLS {
+			hosts = append(hosts, tls.Hosts...)
+		}
+		for _, rule := range entry.rules {
+			if rule.host != "" {
+				hosts = append(hosts, rule.host)
+			}
+		}
+		for _, port := range groupPorts(group) {
+			if entry.ports[port] != "HTTPS" {
+				continue
+			}
+			var uncovered []string
+			for _, host := range hosts {
+				covered := false
+				for _, name := range names {
+					covered = covered || hostMatches(name, host)
+				}
+				if !covered && !containsString(uncovered, host) {
+					uncovered = append(uncovered, host)
+				}
+			}
+			if len(uncovered) == 0 {
+				continue
+			}
+			sort.Strings(uncovered)
+			message := fmt.Sprintf("no certificate of the group covers %s on port %d; clients get the default certificate", strings.Join(uncovered, ", "), port)
+			if len(groupArns) == 0 {
+				message = fmt.Sprintf("no issued ACM certificate covers %s on port %d; certificate discovery fails", strings.Join(uncovered, ", "), port)
+			}
+			add("certificate-mismatch", result.SeverityError, entry.name, message, map[string]string{"port": strconv.Itoa(port), "hosts": strings.Join(uncovered, ",")})
+		}
+	}
+	return findings
+}
+
+// acmCertificatesByArn lists the ACM certificates of the region by ARN.
+func acmCertificatesByArn(region, profile string) (map[string]awsutils.ACMCertificate, error) {
+	sess, err := awsutils.NewSession(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	certificates, err := awsutils.ListACMCertificates(sess)
+	if err != nil {
+		return nil, err
+	}
+	byArn := make(map[string]awsutils.ACMCertificate)
+	for _, certificate := range certificates {
+		byArn[certificate.Arn] = certificate
+	}
+	return byArn, nil
+}
+
+// groupPorts returns the listener ports of a group in ascending order.
+func groupPorts(group *albGroup) []int {
+	seen := make(map[int]bool)
+	var ports []int
+	for _, entry := range group.ingresses {
+		for port := range entry.ports {
+			if !seen[port] {
+				seen[port] = true
+				ports = append(ports, port)
+			}
+		}
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+// groupListeners describes the listeners of a group and counts its rules.
+func groupListeners(group *albGroup) (string, int) {
+	var listeners []string
+	for _, port := range groupPorts(group) {
+		for _, entry := range group.ingresses {
+			if protocol, ok := entry.ports[port]; ok {
+				listeners = append(listeners, fmt.Sprintf("%s:%d", protocol, port))
+				break
+			}
+		}
+	}
+	rules := 0
+	for _, entry := range group.ingresses {
+		rules += len(entry.rules)
+	}
+	return strings.Join(listeners, ","), rules
+}
+
+// groupsUseHTTPS reports whether any Ingress listens on HTTPS.
+func groupsUseHTTPS(groups map[string]*albGroup) bool {
+	for _, group := range groups {
+		for _, entry := range group.ingresses {
+			for _, protocol := range entry.ports {
+				if protocol == "HTTPS" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
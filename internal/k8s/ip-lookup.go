@@ -0,0 +1,146 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IPLookupOptions contains options for looking up an IP address
+type IPLookupOptions struct {
+	Region  string // Defaults to the region of the cluster's nodes
+	Profile string
+}
+
+// ipOwner is a Kubernetes object that has the IP
+type ipOwner struct {
+	kind   string
+	name   string
+	detail string
+}
+
+// LookupIP finds what an IP address belongs to: a pod, node or Service in the
+// cluster, and the ENI that carries it in EC2 along with the instance, load
+// balancer or other AWS resource that owns the ENI.
+func LookupIP(ip string, options IPLookupOptions) error {
+	if net.ParseIP(ip) == nil {
+		return fmt.Errorf("invalid IP address '%s'", ip)
+	}
+	fmt.Printf("Looking up %s...\n", ip)
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+
+	var owners []ipOwner
+	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		for _, podIP := range pod.Status.PodIPs {
+			if podIP.IP != ip {
+				continue
+			}
+			detail := fmt.Sprintf("node %s, %s", valueOrDash(pod.Spec.NodeName), pod.Status.Phase)
+			if pod.Spec.HostNetwork {
+				detail += ", host network"
+			}
+			owners = append(owners, ipOwner{"Pod", pod.Namespace + "/" + pod.Name, detail})
+		}
+	}
+
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	for _, node := range nodes.Items {
+		for _, address := range node.Status.Addresses {
+			if address.Address == ip {
+				owners = append(owners, ipOwner{"Node", node.Name, string(address.Type)})
+			}
+		}
+	}
+
+	services, err := clientset.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+	for _, service := range services.Items {
+		resource := service.Namespace + "/" + service.Name
+		if containsString(service.Spec.ClusterIPs, ip) || service.Spec.ClusterIP == ip {
+			owners = append(owners, ipOwner{"Service", resource, "cluster IP"})
+		}
+		if containsString(service.Spec.ExternalIPs, ip) {
+			owners = append(owners, ipOwner{"Service", resource, "external IP"})
+		}
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			if ingress.IP == ip {
+				owners = append(owners, ipOwner{"Service", resource, "load balancer IP"})
+			}
+		}
+	}
+
+	region := options.Region
+	if region == "" && len(nodes.Items) > 0 {
+		region = nodes.Items[0].Labels["topology.kubernetes.io/region"]
+	}
+	sess, err := awsutils.NewSession(options.Profile, region)
+	if err != nil {
+		return err
+	}
+	interfaces, awsErr := awsutils.FindNetworkInterfacesByIP(sess, ip)
+
+	if len(owners) > 0 {
+		fmt.Println("\nKubernetes:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\tNAME\tDETAIL")
+		for _, owner := range owners {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", owner.kind, owner.name, owner.detail)
+		}
+		w.Flush()
+	}
+
+	// Map instances back to nodes so ENIs of worker nodes name the node
+	nodeByInstance := make(map[string]string)
+	for _, node := range nodes.Items {
+		if instanceID := awsutils.InstanceIDFromProviderID(node.Spec.ProviderID); instanceID != "" {
+			nodeByInstance[instanceID] = node.Name
+		}
+	}
+	for _, eni := range interfaces {
+		fmt.Printf("\nENI %s:\n", eni.ID)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		owner := eni.Owner
+		if node, ok := nodeByInstance[eni.InstanceID]; ok {
+			owner += fmt.Sprintf(" (node %s)", node)
+		}
+		fmt.Fprintf(w, "  Owner:\t%s\n", owner)
+		fmt.Fprintf(w, "  Description:\t%s\n", valueOrDash(eni.Description))
+		fmt.Fprintf(w, "  Status:\t%s\n", eni.Status)
+		fmt.Fprintf(w, "  Primary private IP:\t%s\n", eni.PrivateIP)
+		fmt.Fprintf(w, "  Public IP:\t%s\n", valueOrDash(eni.PublicIP))
+		fmt.Fprintf(w, "  Subnet:\t%s (%s, %s)\n", eni.SubnetID, eni.AvailabilityZone, eni.VpcID)
+		fmt.Fprintf(w, "  Security groups:\t%s\n", valueOrDash(strings.Join(eni.SecurityGroups, ", ")))
+		w.Flush()
+	}
+
+	if awsErr != nil {
+		fmt.Printf("\n⚠️  %v\n", awsErr)
+	}
+	if len(owners) == 0 && len(interfaces) == 0 {
+		if awsErr != nil {
+			return fmt.Errorf("%s not found in the cluster", ip)
+		}
+		return fmt.Errorf("%s belongs to no pod, node or Service in the cluster and no ENI in %s", ip, valueOrDash(region))
+	}
+	return nil
+}
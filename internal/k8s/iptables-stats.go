@@ -0,0 +1,398 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// kubeProxyMetricsPort is where kube-proxy serves its metrics and /proxyMode
+const kubeProxyMetricsPort = "10249"
+
+// iptablesScript prints the iptables backend with rules, the number of
+// rules and of kube-proxy's KUBE-* rules, and the IPVS virtual services.
+// Nodes can have rules in either the nft or the legacy backend, so the first
+// one holding rules is counted.
+var iptablesScript = []string{
+	`for save in iptables-nft-save iptables-legacy-save iptables-save; do`,
+	`  command -v $save >/dev/null 2>&1 || continue`,
+	`  rules=$($save 2>/dev/null | grep -c '^-A')`,
+	`  if [ "$rules" -gt 0 ]; then echo "backend $save"; echo "rules $rules"; echo "kube_rules $($save 2>/dev/null | grep -c '^-A KUBE-')"; break; fi`,
+	`done`,
+	`if [ -r /proc/net/ip_vs ]; then echo "ipvs_services $(grep -cE '^(TCP|UDP|SCTP)' /proc/net/ip_vs)"; fi`,
+}
+
+// IPTablesStatsOptions contains options for the kube-proxy rule health check
+type IPTablesStatsOptions struct {
+	Selector  string // Only nodes matching this label selector
+	Method    string // auto, ssm or pod to count the rules on the node, or metrics for kube-proxy metrics only
+	Region    string // Defaults to the region in the nodes' provider IDs
+	Profile   string
+	Namespace string  // Where collector pods run; must admit hostNetwork and privileged pods
+	Image     string  // Needs iptables-save
+	MaxRules  int     // Rule count that is flagged
+	MaxSync   float64 // p99 rule sync duration in seconds that is flagged
+	Parallel  int     // Collector pods run at once
+	Timeout   time.Duration
+	Output    string // table or json
+	FailOn    string // Lowest severity that fails the run: error, warning, info or none
+}
+
+// iptablesNode is what was collected from one node
+type iptablesNode struct {
+	name      string
+	method    string // ssm, pod or metrics
+	err       error
+	backend   string
+	rules     int64 // -1 when unknown
+	kubeRules int64 // -1 when unknown
+	ipvs      int64 // IPVS virtual services, -1 when unknown
+	proxy     *kubeProxyStats
+}
+
+// kubeProxyStats are the rule sync metrics of the kube-proxy pod of a node
+type kubeProxyStats struct {
+	pod             string
+	mode            string
+	syncP99         float64 // Seconds, -1 when unknown
+	programmingP99  float64 // Seconds from a Service or EndpointSlice change to its rules, -1 when unknown
+	natRules        float64 // -1 when kube-proxy doesn't report it (before 1.28)
+	restoreFailures float64
+	err             error
+}
+
+// ShowIPTablesStats counts the iptables and IPVS rules of every node, on the
+// node through SSM or a pod, or from kube-proxy's metrics, together with
+// kube-proxy's rule sync latency, and flags the nodes where the Service rules
+// are numerous or slow enough to delay programming new endpoints.
+func ShowIPTablesStats(options IPTablesStatsOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	if options.Image == "" {
+		options.Image = DefaultDebugImage
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+	nodeList, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: options.Selector})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	var targets []corev1.Node
+	nodes := make(map[string]*iptablesNode)
+	for _, node := range nodeList.Items {
+		if isFargateNode(node) {
+			continue
+		}
+		targets = append(targets, node)
+		nodes[node.Name] = &iptablesNode{name: node.Name, method: "metrics", rules: -1, kubeRules: -1, ipvs: -1}
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no nodes to check (Fargate nodes can't be checked)")
+	}
+	if options.Output != "json" {
+		fmt.Printf("Collecting rule counts from %d nodes...\n", len(targets))
+	}
+
+	if options.Method != "metrics" {
+		outputs, err := runNodeScript(clientset, targets, iptablesScript, nodeScriptOptions{
+			Method:    options.Method,
+			Region:    options.Region,
+			Profile:   options.Profile,
+			Namespace: options.Namespace,
+			Image:     options.Image,
+			Parallel:  options.Parallel,
+			Timeout:   options.Timeout,
+			Name:      "iptables-stats",
+		})
+		if err != nil {
+			return err
+		}
+		for name, output := range outputs {
+			entry := nodes[name]
+			entry.method, entry.err = output.method, output.err
+			if entry.err == nil {
+				parseIPTablesOutput(output.output, entry)
+			}
+		}
+	}
+
+	proxyPods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{LabelSelector: "k8s-app=kube-proxy"})
+	if err != nil {
+		return fmt.Errorf("failed to list kube-proxy pods: %w", err)
+	}
+	for _, pod := range proxyPods.Items {
+		if entry := nodes[pod.Spec.NodeName]; entry != nil && pod.Status.Phase == corev1.PodRunning {
+			entry.proxy = collectKubeProxyStats(clientset, pod)
+		}
+	}
+	services, err := clientset.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+
+	var findings []result.Finding
+	if len(proxyPods.Items) == 0 {
+		findings = append(findings, result.Finding{
+			Check:    "kube-proxy-not-running",
+			Severity: result.SeverityInfo,
+			Resource: "cluster",
+			Message:  "no kube-proxy pods found; the CNI probably replaces kube-proxy, so sync latency is not available",
+		})
+	}
+	names := sortedKeys(nodes)
+	for _, name := range names {
+		entry := nodes[name]
+		resource := "node/" + name
+		if entry.err != nil {
+			findings = append(findings, result.Finding{
+				Check:    "collection-failed",
+				Severity: result.SeverityInfo,
+				Resource: resource,
+				Message:  entry.err.Error(),
+				Details:  map[string]string{"method": entry.method},
+			})
+		}
+		proxy := entry.proxy
+		if proxy != nil && proxy.err != nil {
+			findings = append(findings, result.Finding{
+				Check:    "collection-failed",
+				Severity: result.SeverityInfo,
+				Resource: "pod/" + proxy.pod,
+				Message:  "failed to read kube-proxy metrics: " + proxy.err.Error(),
+			})
+			proxy = nil
+		}
+		if entry.rules < 0 && proxy != nil && proxy.natRules >= 0 {
+			entry.rules = int64(proxy.natRules) // The nat table only, which holds the Service rules
+		}
+
+		if entry.rules >= int64(options.MaxRules) {
+			findings = append(findings, result.Finding{
+				Check:    "rule-count",
+				Severity: result.SeverityWarning,
+				Resource: resource,
+				Message: fmt.Sprintf("%d iptables rules, above %d; every sync rewrites them, consider IPVS or nftables mode, or fewer Services and endpoints",
+					entry.rules, options.MaxRules),
+				Details: map[string]string{"rules": strconv.FormatInt(entry.rules, 10), "kube_rules": strconv.FormatInt(entry.kubeRules, 10)},
+			})
+		}
+		if proxy == nil {
+			continue
+		}
+		if proxy.restoreFailures > 0 {
+			findings = append(findings, result.Finding{
+				Check:    "iptables-restore-failures",
+				Severity: result.SeverityError,
+				Resource: resource,
+				Message:  fmt.Sprintf("kube-proxy failed to apply its rules %.0f times; check its logs for lock contention with other iptables users", proxy.restoreFailures),
+			})
+		}
+		if proxy.syncP99 > options.MaxSync {
+			findings = append(findings, result.Finding{
+				Check:    "sync-latency",
+				Severity: result.SeverityWarning,
+				Resource: resource,
+				Message: fmt.Sprintf("kube-proxy rule sync p99 is %s, above %s; new endpoints take at least that long to receive traffic",
+					formatSeconds(proxy.syncP99), formatSeconds(options.MaxSync)),
+				Details: map[string]string{"programming_p99": formatSeconds(proxy.programmingP99)},
+			})
+		}
+	}
+
+	if options.Output == "json" {
+		if err := result.New("iptables-stats", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tMODE\tVIA\tRULES\tKUBE RULES\tIPVS SERVICES\tSYNC P99\tPROGRAMMING P99")
+	var flagged []string
+	var ruleCounts []int64
+	for _, name := range names {
+		entry := nodes[name]
+		mode, syncP99, programmingP99 := "-", "-", "-"
+		if proxy := entry.proxy; proxy != nil && proxy.err == nil {
+			mode = valueOrDash(proxy.mode)
+			syncP99, programmingP99 = formatSeconds(proxy.syncP99), formatSeconds(proxy.programmingP99)
+			if proxy.syncP99 > options.MaxSync {
+				syncP99 = "❌ " + syncP99
+			}
+		}
+		rules := formatCount(entry.rules)
+		if entry.rules >= int64(options.MaxRules) {
+			rules = "❌ " + rules
+			flagged = append(flagged, name)
+		}
+		if entry.rules >= 0 {
+			ruleCounts = append(ruleCounts, entry.rules)
+		}
+		via := entry.method
+		if entry.err != nil {
+			via = "⚠️  " + entry.method + " failed"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", name, mode, via, rules, formatCount(entry.kubeRules), formatCount(entry.ipvs),
+			syncP99, programmingP99)
+	}
+	w.Flush()
+
+	endpoints := 0
+	if slices, err := clientset.DiscoveryV1().EndpointSlices("").List(ctx, metav1.ListOptions{}); err == nil {
+		for _, slice := range slices.Items {
+			endpoints += len(slice.Endpoints)
+		}
+	}
+
+	fmt.Println("\n--- iptables Summary ---")
+	fmt.Printf("Nodes: %d, Services: %d, endpoints: %d\n", len(nodes), len(services.Items), endpoints)
+	if len(ruleCounts) > 0 {
+		sort.Slice(ruleCounts, func(i, j int) bool { return ruleCounts[i] < ruleCounts[j] })
+		fmt.Printf("Rules per node: min %d, median %d, max %d\n", ruleCounts[0], ruleCounts[len(ruleCounts)/2], ruleCounts[len(ruleCounts)-1])
+	}
+	if len(flagged) == 0 {
+		fmt.Printf("✅ No node has more than %d rules\n", options.MaxRules)
+	} else {
+		fmt.Printf("❌ %d nodes have more than %d rules: %s\n", len(flagged), options.MaxRules, strings.Join(flagged, ", "))
+	}
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// parseIPTablesOutput reads the output of iptablesScript into the node.
+func parseIPTablesOutput(output string, entry *iptablesNode) {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if fields[0] == "backend" {
+			entry.backend = fields[1]
+			continue
+		}
+		value, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "rules":
+			entry.rules = value
+		case "kube_rules":
+			entry.kubeRules = value
+		case "ipvs_services":
+			entry.ipvs = value
+		}
+	}
+}
+
+// collectKubeProxyStats reads the proxy mode and rule sync metrics of a
+// kube-proxy pod through the API server's pod proxy.
+func collectKubeProxyStats(clientset *kubernetes.Clientset, pod corev1.Pod) *kubeProxyStats {
+	stats := &kubeProxyStats{pod: pod.Namespace + "/" + pod.Name, syncP99: -1, programmingP99: -1, natRules: -1}
+	proxy := clientset.CoreV1().Pods(pod.Namespace)
+	metrics, err := proxy.ProxyGet("http", pod.Name, kubeProxyMetricsPort, "/metrics", nil).DoRaw(context.TODO())
+	if err != nil {
+		stats.err = err
+		return stats
+	}
+	if mode, err := proxy.ProxyGet("http", pod.Name, kubeProxyMetricsPort, "/proxyMode", nil).DoRaw(context.TODO()); err == nil {
+		stats.mode = strings.TrimSpace(string(mode))
+	}
+	text := string(metrics)
+	stats.syncP99 = histogramQuantile(text, "kubeproxy_sync_proxy_rules_duration_seconds", 0.99)
+	stats.programmingP99 = histogramQuantile(text, "kubeproxy_network_programming_duration_seconds", 0.99)
+	if strings.Contains(text, "kubeproxy_sync_proxy_rules_iptables_total{") {
+		stats.natRules = sumPrometheusMetric(text, "kubeproxy_sync_proxy_rules_iptables_total", `table="nat"`)
+	}
+	stats.restoreFailures = sumPrometheusMetric(text, "kubeproxy_sync_proxy_rules_iptables_restore_failures_total", "")
+	return stats
+}
+
+// histogramQuantile estimates a quantile of a Prometheus histogram from its
+// cumulative buckets, interpolating linearly inside the bucket like
+// PromQL's histogram_quantile. It returns -1 when the histogram is missing
+// or empty.
+func histogramQuantile(text, name string, quantile float64) float64 {
+	counts := make(map[float64]float64) // Upper bound to cumulative count, summed over series
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(line, name+"_bucket{") {
+			continue
+		}
+		_, rest, _ := strings.Cut(line, `le="`)
+		bound, rest, found := strings.Cut(rest, `"`)
+		if !found {
+			continue
+		}
+		upper := math.Inf(1)
+		if bound != "+Inf" {
+			var err error
+			if upper, err = strconv.ParseFloat(bound, 64); err != nil {
+				continue
+			}
+		}
+		fields := strings.Fields(rest[strings.Index(rest, "}")+1:])
+		if len(fields) == 0 {
+			continue
+		}
+		if count, err := strconv.ParseFloat(fields[0], 64); err == nil {
+			counts[upper] += count
+		}
+	}
+	total := counts[math.Inf(1)]
+	if total == 0 {
+		return -1
+	}
+	bounds := make([]float64, 0, len(counts))
+	for upper := range counts {
+		bounds = append(bounds, upper)
+	}
+	sort.Float64s(bounds)
+	rank := quantile * total
+	lower, below := 0.0, 0.0
+	for _, upper := range bounds {
+		count := counts[upper]
+		if count >= rank {
+			if math.IsInf(upper, 1) || count == below {
+				return lower
+			}
+			return lower + (upper-lower)*(rank-below)/(count-below)
+		}
+		lower, below = upper, count
+	}
+	return lower
+}
+
+func formatSeconds(seconds float64) string {
+	switch {
+	case seconds < 0:
+		return "-"
+	case seconds < 1:
+		return fmt.Sprintf("%.0fms", seconds*1000)
+	}
+	return fmt.Sprintf("%.1fs", seconds)
+}
+
+func formatCount(count int64) string {
+	if count < 0 {
+		return "-"
+	}
+	return strconv.FormatInt(count, 10)
+}
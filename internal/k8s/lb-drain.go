@@ -0,0 +1,204 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LBDrainOptions contains options for draining a target from its load balancers
+type LBDrainOptions struct {
+	Region  string // Defaults to the region of the cluster's nodes
+	Profile string
+	Timeout time.Duration // 0 waits for the longest deregistration delay plus a minute
+	DryRun  bool
+}
+
+// DrainLBTarget deregisters an instance, node or IP from every ALB and NLB
+// target group it is registered in, waits until each registration has
+// finished draining and reports when the target is safe to terminate.
+// Nodes are drained through their instance ID, so their instance-mode
+// registrations are removed.
+func DrainLBTarget(target string, options LBDrainOptions) error {
+	targetIDs, label, region, err := resolveLBTarget(target)
+	if err != nil {
+		return err
+	}
+	if options.Region != "" {
+		region = options.Region
+	}
+	sess, err := awsutils.NewSession(options.Profile, region)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Finding target groups of %s...\n", label)
+	registrations, err := awsutils.FindTargetRegistrations(sess, targetIDs)
+	if err != nil {
+		return err
+	}
+	if len(registrations) == 0 {
+		return fmt.Errorf("%s is not registered in any target group", label)
+	}
+
+	var maxDelay int64
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TARGET GROUP\tTARGET\tSTATE\tDRAIN DELAY")
+	for _, registration := range registrations {
+		fmt.Fprintf(w, "%s\t%s:%d\t%s\t%ds\n", registration.TargetGroupName, registration.TargetID, registration.Port, registration.State, registration.DrainDelay)
+		maxDelay = max(maxDelay, registration.DrainDelay)
+	}
+	w.Flush()
+	if options.DryRun {
+		fmt.Println("Dry run, nothing deregistered.")
+		return nil
+	}
+
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = time.Duration(maxDelay)*time.Second + time.Minute
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+	go func() {
+		if _, ok := <-stop; ok {
+			fmt.Println("\nInterrupted, no longer waiting (deregistration continues in AWS)...")
+			cancel()
+		}
+	}()
+
+	start := time.Now()
+	for _, registration := range registrations {
+		if err := awsutils.DeregisterTarget(sess, registration); err != nil {
+			return err
+		}
+		fmt.Printf("%s deregistered %s:%d from %s\n", time.Now().Format("15:04:05"), registration.TargetID, registration.Port, registration.TargetGroupName)
+	}
+	fmt.Printf("Waiting up to %s for connections to drain...\n", timeout)
+
+	pending := make(map[int]string)
+	for i, registration := range registrations {
+		pending[i] = registration.State
+	}
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for len(pending) > 0 && ctx.Err() == nil {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			continue
+		}
+		for i, previous := range pending {
+			registration := registrations[i]
+			state, err := awsutils.TargetState(sess, registration)
+			if err != nil {
+				fmt.Printf("%s ⚠️  %v\n", time.Now().Format("15:04:05"), err)
+				continue
+			}
+			if state != previous {
+				fmt.Printf("%s %s %s:%d %s → %s\n", time.Now().Format("15:04:05"), registration.TargetGroupName, registration.TargetID, registration.Port, previous, state)
+				pending[i] = state
+			}
+			if state == "unused" {
+				delete(pending, i)
+			}
+		}
+	}
+
+	fmt.Println("\n--- LB Drain Summary ---")
+	fmt.Printf("Target: %s\n", label)
+	fmt.Printf("Target groups: %d\n", len(registrations))
+	if len(pending) > 0 {
+		for i, state := range pending {
+			fmt.Printf("❌ %s %s:%d still %s\n", registrations[i].TargetGroupName, registrations[i].TargetID, registrations[i].Port, state)
+		}
+		fmt.Println("----------------------------------------------------")
+		return fmt.Errorf("%s has not finished draining after %s", label, time.Since(start).Round(time.Second))
+	}
+	fmt.Printf("✅ Drained in %s, safe to terminate %s\n", time.Since(start).Round(time.Second), label)
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// resolveLBTarget turns the argument into the target IDs load balancers know
+// it by, a label to print, and the region of the cluster's nodes. Instance
+// IDs and IPs are used as given, an IP of a node adds its instance ID, and
+// anything else is taken as a node name. The cluster is only required for
+// node names.
+func resolveLBTarget(target string) ([]string, string, string, error) {
+	var nodes []corev1.Node
+	var pods []corev1.Pod
+	if clientset, err := common.GetKubernetesClient(); err == nil {
+		if nodeList, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{}); err == nil {
+			nodes = nodeList.Items
+		}
+		if net.ParseIP(target) != nil {
+			if podList, err := clientset.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{FieldSelector: "status.podIP=" + target}); err == nil {
+				pods = podList.Items
+			}
+		}
+	}
+	region := ""
+	if len(nodes) > 0 {
+		region = nodes[0].Labels["topology.kubernetes.io/region"]
+	}
+
+	switch {
+	case strings.HasPrefix(target, "i-"):
+		for _, node := range nodes {
+			if awsutils.InstanceIDFromProviderID(node.Spec.ProviderID) == target {
+				return []string{target}, fmt.Sprintf("instance %s (node %s)", target, node.Name), region, nil
+			}
+		}
+		return []string{target}, "instance " + target, region, nil
+
+	case net.ParseIP(target) != nil:
+		ids := []string{target}
+		label := "IP " + target
+		for _, pod := range pods {
+			if !pod.Spec.HostNetwork {
+				label += fmt.Sprintf(" (pod %s/%s)", pod.Namespace, pod.Name)
+				break
+			}
+		}
+		for _, node := range nodes {
+			for _, address := range node.Status.Addresses {
+				if address.Type != corev1.NodeInternalIP || address.Address != target {
+					continue
+				}
+				if instanceID := awsutils.InstanceIDFromProviderID(node.Spec.ProviderID); instanceID != "" {
+					ids = append(ids, instanceID)
+					label += fmt.Sprintf(" (node %s, instance %s)", node.Name, instanceID)
+				}
+			}
+		}
+		return ids, label, region, nil
+
+	default:
+		for _, node := range nodes {
+			if node.Name != target {
+				continue
+			}
+			instanceID := awsutils.InstanceIDFromProviderID(node.Spec.ProviderID)
+			if instanceID == "" {
+				return nil, "", "", fmt.Errorf("node %s has no EC2 instance ID in its provider ID", target)
+			}
+			return []string{instanceID}, fmt.Sprintf("instance %s (node %s)", instanceID, target), region, nil
+		}
+		return nil, "", "", fmt.Errorf("'%s' is not an instance ID, an IP address or a node of the current cluster", target)
+	}
+}
@@ -0,0 +1,603 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// loadTestReportInterval is how often progress is printed
+const loadTestReportInterval = 5 * time.Second
+
+// loadTestClusterInterval is how often HPAs and node usage are polled
+const loadTestClusterInterval = 10 * time.Second
+
+// LoadTestOptions contains options for the load test
+type LoadTestOptions struct {
+	Namespace   string
+	RPS         int
+	Duration    time.Duration
+	Path        string
+	Method      string
+	Port        int32  // Service port, 0 for the first one
+	Scheme      string // http or https
+	Via         string // auto, port-forward or lb
+	MaxInFlight int    // Requests outstanding at once; ticks beyond it are dropped
+	Timeout     time.Duration
+}
+
+// loadTestStats collects the outcome of every request
+type loadTestStats struct {
+	mu        sync.Mutex
+	latencies []time.Duration // Successful requests
+	window    []time.Duration // Successful requests since the last progress line
+	statuses  map[int]int
+	errors    map[string]int // Transport errors by message
+	failed    int            // Transport errors and 4xx/5xx responses
+	dropped   int
+}
+
+// LoadTest sends HTTP requests at a fixed rate to a Service, through a
+// port-forward to one of its pods or through its load balancer, and reports
+// latency percentiles and errors. The HPAs scaling the Service's workloads
+// and the CPU usage of the nodes its pods run on are shown alongside.
+func LoadTest(serviceName string, options LoadTestOptions) error {
+	if options.RPS < 1 {
+		return fmt.Errorf("--rps must be at least 1")
+	}
+	if options.Duration <= 0 {
+		return fmt.Errorf("--duration must be positive")
+	}
+	if options.MaxInFlight < 1 {
+		return fmt.Errorf("--max-in-flight must be at least 1")
+	}
+	if options.Via != "auto" && options.Via != "port-forward" && options.Via != "lb" {
+		return fmt.Errorf("unsupported --via '%s' (must be auto, port-forward or lb)", options.Via)
+	}
+	if options.Scheme != "http" && options.Scheme != "https" {
+		return fmt.Errorf("unsupported --scheme '%s' (must be http or https)", options.Scheme)
+	}
+	if options.Namespace == "" {
+		options.Namespace = "default"
+	}
+	if !strings.HasPrefix(options.Path, "/") {
+		options.Path = "/" + options.Path
+	}
+
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+	go func() {
+		if _, ok := <-stop; ok {
+			fmt.Println("\nInterrupted, stopping the load test...")
+			cancel()
+		}
+	}()
+
+	service, err := clientset.CoreV1().Services(options.Namespace).Get(ctx, serviceName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get service %s/%s: %w", options.Namespace, serviceName, err)
+	}
+	servicePort, err := loadTestServicePort(service, options.Port)
+	if err != nil {
+		return err
+	}
+	baseURL, via, err := loadTestTarget(ctx, clientset, service, servicePort, options)
+	if err != nil {
+		return err
+	}
+	target := options.Scheme + "://" + baseURL + options.Path
+
+	cluster := newLoadTestCluster(clientset, service)
+	cluster.poll(ctx)
+	initialHPAs := cluster.hpaSummary()
+	go func() {
+		ticker := time.NewTicker(loadTestClusterInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				cluster.poll(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	fmt.Printf("Target: %s %s (via %s)\n", options.Method, target, via)
+	fmt.Printf("Load: %d requests/s for %s, at most %d in flight\n", options.RPS, options.Duration, options.MaxInFlight)
+	if initialHPAs != "" {
+		fmt.Printf("HPA: %s\n", initialHPAs)
+	}
+	fmt.Println()
+
+	client := &http.Client{
+		Timeout:   options.Timeout,
+		Transport: &http.Transport{MaxIdleConnsPerHost: options.MaxInFlight},
+	}
+	stats := &loadTestStats{statuses: make(map[int]int), errors: make(map[string]int)}
+	start := time.Now()
+	sent := runLoad(ctx, client, target, options, stats, func(elapsed time.Duration) {
+		fmt.Printf("%s  %s  %s\n", formatElapsed(elapsed), stats.progress(elapsed), cluster.status())
+	})
+	elapsed := time.Since(start)
+
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	sort.Slice(stats.latencies, func(i, j int) bool { return stats.latencies[i] < stats.latencies[j] })
+	completed := len(stats.latencies) + stats.failed
+
+	fmt.Println("\n--- Load Test Summary ---")
+	fmt.Printf("Target: %s (via %s)\n", target, via)
+	fmt.Printf("Requests: %d sent, %d completed, %d dropped at the in-flight limit\n", sent, completed, stats.dropped)
+	fmt.Printf("Throughput: %.1f requests/s over %s\n", float64(completed)/elapsed.Seconds(), elapsed.Round(time.Second))
+	if len(stats.latencies) > 0 {
+		fmt.Printf("Latency: p50 %s, p90 %s, p95 %s, p99 %s, max %s\n",
+			formatLatency(percentile(stats.latencies, 50)), formatLatency(percentile(stats.latencies, 90)),
+			formatLatency(percentile(stats.latencies, 95)), formatLatency(percentile(stats.latencies, 99)),
+			formatLatency(stats.latencies[len(stats.latencies)-1]))
+	}
+	errorRate := 0.0
+	if completed > 0 {
+		errorRate = float64(stats.failed) / float64(completed) * 100
+	}
+	icon := "✅"
+	if errorRate >= 5 {
+		icon = "❌"
+	} else if errorRate > 0 {
+		icon = "⚠️ "
+	}
+	fmt.Printf("%s Errors: %d (%.2f%%)\n", icon, stats.failed, errorRate)
+	for _, code := range sortedStatusCodes(stats.statuses) {
+		fmt.Printf("  - HTTP %d: %d\n", code, stats.statuses[code])
+	}
+	for _, message := range sortedKeys(stats.errors) {
+		fmt.Printf("  - %s: %d\n", message, stats.errors[message])
+	}
+	if finalHPAs := cluster.hpaSummary(); finalHPAs != "" {
+		fmt.Printf("HPA: before %s\n     after  %s\n", initialHPAs, finalHPAs)
+	}
+	if peak := cluster.peakNodeCPU(); peak > 0 {
+		fmt.Printf("Peak node CPU: %.0f%% on the nodes running the service's pods\n", peak)
+	}
+	if via == "port-forward" {
+		fmt.Println("⚠️  A port-forward sends all requests to one pod; use --via lb to load the whole service.")
+	}
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// runLoad sends one request per tick until the duration ends or ctx is
+// cancelled, calling progress every loadTestReportInterval, and returns the
+// number of requests sent.
+func runLoad(ctx context.Context, client *http.Client, target string, options LoadTestOptions, stats *loadTestStats, progress func(time.Duration)) int {
+	ticker := time.NewTicker(time.Second / time.Duration(options.RPS))
+	defer ticker.Stop()
+	report := time.NewTicker(loadTestReportInterval)
+	defer report.Stop()
+	deadline := time.After(options.Duration)
+	start := time.Now()
+	inFlight := make(chan struct{}, options.MaxInFlight)
+	var wg sync.WaitGroup
+	var sent int
+
+	for {
+		select {
+		case <-ticker.C:
+			select {
+			case inFlight <- struct{}{}:
+			default:
+				stats.mu.Lock()
+				stats.dropped++
+				stats.mu.Unlock()
+				continue
+			}
+			sent++
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-inFlight }()
+				stats.record(sendLoadRequest(client, options.Method, target))
+			}()
+		case <-report.C:
+			progress(time.Since(start))
+		case <-deadline:
+			wg.Wait()
+			return sent
+		case <-ctx.Done():
+			wg.Wait()
+			return sent
+		}
+	}
+}
+
+// loadResult is the outcome of one request
+type loadResult struct {
+	latency time.Duration
+	status  int
+	err     error
+}
+
+func sendLoadRequest(client *http.Client, method, target string) loadResult {
+	request, err := http.NewRequest(method, target, nil)
+	if err != nil {
+		return loadResult{err: err}
+	}
+	start := time.Now()
+	response, err := client.Do(request)
+	if err != nil {
+		return loadResult{err: err}
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+	return loadResult{latency: time.Since(start), status: response.StatusCode}
+}
+
+func (s *loadTestStats) record(result loadResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if result.err != nil {
+		s.failed++
+		s.errors[loadErrorLabel(result.err)]++
+		return
+	}
+	s.statuses[result.status]++
+	if result.status >= 400 {
+		s.failed++
+		return
+	}
+	s.latencies = append(s.latencies, result.latency)
+	s.window = append(s.window, result.latency)
+}
+
+// progress describes the requests so far and the latency since the last
+// call.
+func (s *loadTestStats) progress(elapsed time.Duration) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	window := s.window
+	s.window = nil
+	sort.Slice(window, func(i, j int) bool { return window[i] < window[j] })
+	completed := len(s.latencies) + s.failed
+	line := fmt.Sprintf("%6d done  %6.1f/s", completed, float64(completed)/elapsed.Seconds())
+	if len(window) > 0 {
+		line += fmt.Sprintf("  p50 %-7s p95 %-7s", formatLatency(percentile(window, 50)), formatLatency(percentile(window, 95)))
+	} else {
+		line += fmt.Sprintf("  %-23s", "no successful requests")
+	}
+	return line + fmt.Sprintf("  errors %d", s.failed)
+}
+
+// loadErrorLabel shortens transport errors so equal failures are counted
+// together.
+func loadErrorLabel(err error) string {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "Client.Timeout"), strings.Contains(message, "deadline exceeded"):
+		return "timeout"
+	case strings.Contains(message, "connection refused"):
+		return "connection refused"
+	case strings.Contains(message, "connection reset"):
+		return "connection reset"
+	case strings.Contains(message, "EOF"):
+		return "connection closed"
+	}
+	return message
+}
+
+// percentile returns the p-th percentile of sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	index := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[max(index, 0)]
+}
+
+func formatLatency(d time.Duration) string {
+	if d < 10*time.Millisecond {
+		return d.Round(10 * time.Microsecond).String()
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+func formatElapsed(d time.Duration) string {
+	seconds := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
+
+func sortedStatusCodes(statuses map[int]int) []int {
+	codes := make([]int, 0, len(statuses))
+	for code := range statuses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	return codes
+}
+
+// loadTestServicePort returns the service port numbered port, or the first
+// one when port is 0.
+func loadTestServicePort(service *corev1.Service, port int32) (corev1.ServicePort, error) {
+	if len(service.Spec.Ports) == 0 {
+		return corev1.ServicePort{}, fmt.Errorf("service %s has no ports", service.Name)
+	}
+	if port == 0 {
+		return service.Spec.Ports[0], nil
+	}
+	for _, servicePort := range service.Spec.Ports {
+		if servicePort.Port == port {
+			return servicePort, nil
+		}
+	}
+	return corev1.ServicePort{}, fmt.Errorf("service %s has no port %d", service.Name, port)
+}
+
+// loadTestTarget returns the host:port to send requests to and how it is
+// reached. auto uses the load balancer when the service has one and a
+// port-forward otherwise; the port-forward lives until ctx is cancelled.
+func loadTestTarget(ctx context.Context, clientset *kubernetes.Clientset, service *corev1.Service, servicePort corev1.ServicePort, options LoadTestOptions) (string, string, error) {
+	if options.Via != "port-forward" {
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			host := ingress.Hostname
+			if host == "" {
+				host = ingress.IP
+			}
+			if host != "" {
+				return fmt.Sprintf("%s:%d", host, servicePort.Port), "lb", nil
+			}
+		}
+		if options.Via == "lb" {
+			return "", "", fmt.Errorf("service %s has no load balancer address", service.Name)
+		}
+	}
+
+	address, err := forwardServicePort(ctx, clientset, service, servicePort)
+	if err != nil {
+		return "", "", err
+	}
+	return address, "port-forward", nil
+}
+
+// forwardServicePort port-forwards a free local port to a ready pod of the
+// service, on the container port the service port sends to, until ctx is
+// cancelled, and returns the local address.
+func forwardServicePort(ctx context.Context, clientset *kubernetes.Clientset, service *corev1.Service, servicePort corev1.ServicePort) (string, error) {
+	if len(service.Spec.Selector) == 0 {
+		return "", fmt.Errorf("service %s has no selector to find a pod to port-forward to", service.Name)
+	}
+	pods, err := clientset.CoreV1().Pods(service.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: metav1.FormatLabelSelector(metav1.SetAsLabelSelector(service.Spec.Selector)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods of service %s: %w", service.Name, err)
+	}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type != corev1.PodReady || condition.Status != corev1.ConditionTrue {
+				continue
+			}
+			podPort, err := resolveTargetPort(pod, servicePort)
+			if err != nil {
+				return "", err
+			}
+			localPort, err := portForward(ctx, clientset, pod, podPort)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("127.0.0.1:%d", localPort), nil
+		}
+	}
+	return "", fmt.Errorf("service %s has no ready pods", service.Name)
+}
+
+// resolveTargetPort returns the container port a service port sends to,
+// looking up named target ports in the pod's containers.
+func resolveTargetPort(pod corev1.Pod, servicePort corev1.ServicePort) (int32, error) {
+	targetPort := servicePort.TargetPort
+	if targetPort.StrVal == "" {
+		if targetPort.IntVal == 0 {
+			return servicePort.Port, nil
+		}
+		return targetPort.IntVal, nil
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Name == targetPort.StrVal {
+				return port.ContainerPort, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("pod %s has no container port named %s", pod.Name, targetPort.StrVal)
+}
+
+// portForward forwards a free local port to the pod's port until ctx is
+// cancelled and returns the local port.
+func portForward(ctx context.Context, clientset *kubernetes.Clientset, pod corev1.Pod, podPort int32) (uint16, error) {
+	config, err := common.GetRESTConfig()
+	if err != nil {
+		return 0, err
+	}
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return 0, fmt.Errorf("failed to set up port-forward: %w", err)
+	}
+	url := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("portforward").
+		URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", url)
+
+	ready := make(chan struct{})
+	forwarder, err := portforward.New(dialer, []string{fmt.Sprintf("0:%d", podPort)}, ctx.Done(), ready, io.Discard, io.Discard)
+	if err != nil {
+		return 0, fmt.Errorf("failed to port-forward to %s: %w", pod.Name, err)
+	}
+	failed := make(chan error, 1)
+	go func() { failed <- forwarder.ForwardPorts() }()
+	select {
+	case <-ready:
+	case err := <-failed:
+		return 0, fmt.Errorf("failed to port-forward to %s: %w", pod.Name, err)
+	}
+	ports, err := forwarder.GetPorts()
+	if err != nil || len(ports) == 0 {
+		return 0, fmt.Errorf("failed to get the forwarded port of %s: %v", pod.Name, err)
+	}
+	return ports[0].Local, nil
+}
+
+// loadTestCluster tracks the HPAs scaling the service's workloads and the
+// CPU usage of the nodes its pods run on
+type loadTestCluster struct {
+	clientset *kubernetes.Clientset
+	service   *corev1.Service
+
+	mu      sync.Mutex
+	hpas    []string
+	nodeCPU map[string]float64 // Node to CPU usage in percent of allocatable
+	peakCPU float64
+}
+
+func newLoadTestCluster(clientset *kubernetes.Clientset, service *corev1.Service) *loadTestCluster {
+	return &loadTestCluster{clientset: clientset, service: service}
+}
+
+// poll refreshes the HPA and node data. Failures leave the previous data in
+// place, since the load test matters more than the side information.
+func (c *loadTestCluster) poll(ctx context.Context) {
+	if len(c.service.Spec.Selector) == 0 {
+		return
+	}
+	namespace := c.service.Namespace
+	pods, err := c.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: metav1.FormatLabelSelector(metav1.SetAsLabelSelector(c.service.Spec.Selector)),
+	})
+	if err != nil {
+		return
+	}
+	replicaSets, err := c.clientset.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return
+	}
+	rsOwnerCache := make(map[string]string)
+	for _, rs := range replicaSets.Items {
+		for _, owner := range rs.OwnerReferences {
+			if owner.Kind == "Deployment" {
+				rsOwnerCache[rs.Namespace+"/"+rs.Name] = owner.Name
+			}
+		}
+	}
+	workloads := make(map[string]bool)
+	nodes := make(map[string]bool)
+	for i := range pods.Items {
+		name, kind := getPodOwnerFast(&pods.Items[i], rsOwnerCache)
+		workloads[kind+"/"+name] = true
+		if pods.Items[i].Spec.NodeName != "" {
+			nodes[pods.Items[i].Spec.NodeName] = true
+		}
+	}
+
+	var hpas []string
+	hpaList, hpaErr := c.clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, metav1.ListOptions{})
+	if hpaErr == nil {
+		for _, hpa := range hpaList.Items {
+			if !workloads[hpa.Spec.ScaleTargetRef.Kind+"/"+hpa.Spec.ScaleTargetRef.Name] {
+				continue
+			}
+			line := fmt.Sprintf("%s %d→%d replicas", hpa.Name, hpa.Status.CurrentReplicas, hpa.Status.DesiredReplicas)
+			for _, metric := range hpa.Status.CurrentMetrics {
+				if metric.Resource != nil && metric.Resource.Current.AverageUtilization != nil {
+					line += fmt.Sprintf(" %s %d%%", metric.Resource.Name, *metric.Resource.Current.AverageUtilization)
+				}
+			}
+			hpas = append(hpas, line)
+		}
+		sort.Strings(hpas)
+	}
+
+	nodeCPU := make(map[string]float64)
+	if metricsClient, err := common.GetMetricsClient(); err == nil {
+		if nodeMetrics, err := metricsClient.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{}); err == nil {
+			for _, metrics := range nodeMetrics.Items {
+				if !nodes[metrics.Name] {
+					continue
+				}
+				node, err := c.clientset.CoreV1().Nodes().Get(ctx, metrics.Name, metav1.GetOptions{})
+				if err != nil || node.Status.Allocatable.Cpu().MilliValue() == 0 {
+					continue
+				}
+				nodeCPU[metrics.Name] = float64(metrics.Usage.Cpu().MilliValue()) / float64(node.Status.Allocatable.Cpu().MilliValue()) * 100
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hpaErr == nil {
+		c.hpas = hpas
+	}
+	if len(nodeCPU) > 0 {
+		c.nodeCPU = nodeCPU
+		for _, cpu := range nodeCPU {
+			c.peakCPU = math.Max(c.peakCPU, cpu)
+		}
+	}
+}
+
+func (c *loadTestCluster) hpaSummary() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return strings.Join(c.hpas, ", ")
+}
+
+func (c *loadTestCluster) peakNodeCPU() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.peakCPU
+}
+
+// status is the cluster side of a progress line.
+func (c *loadTestCluster) status() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var parts []string
+	if len(c.hpas) > 0 {
+		parts = append(parts, "HPA "+strings.Join(c.hpas, ", "))
+	}
+	if len(c.nodeCPU) > 0 {
+		var total, highest float64
+		for _, cpu := range c.nodeCPU {
+			total += cpu
+			highest = math.Max(highest, cpu)
+		}
+		parts = append(parts, fmt.Sprintf("node CPU avg %.0f%% max %.0f%% (%d nodes)", total/float64(len(c.nodeCPU)), highest, len(c.nodeCPU)))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "| " + strings.Join(parts, " | ")
+}
@@ -0,0 +1,226 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// MakeKubeconfigOptions contains options for generating a CI kubeconfig
+type MakeKubeconfigOptions struct {
+	ServiceAccount string
+	Namespace      string        // Defaults to the namespace of the current context
+	Duration       time.Duration // Requested token lifetime; the API server may cap it
+	Audiences      []string      // Token audiences, the API server's when empty
+	Create         bool          // Create the ServiceAccount when it doesn't exist
+	Server         string        // API server URL for the kubeconfig, the current context's when empty
+	File           string        // Write the kubeconfig here instead of stdout
+}
+
+// clusterAccessChecks are the cluster-wide permissions the preflight asks
+// about; the ones marked risky are flagged when granted
+var clusterAccessChecks = []struct {
+	description string
+	attributes  authorizationv1.ResourceAttributes
+	risky       bool
+}{
+	{"cluster-admin (* on *)", authorizationv1.ResourceAttributes{Verb: "*", Group: "*", Resource: "*"}, true},
+	{"read secrets in all namespaces", authorizationv1.ResourceAttributes{Verb: "list", Resource: "secrets"}, true},
+	{"bind or escalate roles", authorizationv1.ResourceAttributes{Verb: "create", Group: "rbac.authorization.k8s.io", Resource: "clusterrolebindings"}, true},
+	{"create pods in all namespaces", authorizationv1.ResourceAttributes{Verb: "create", Resource: "pods"}, true},
+	{"list namespaces", authorizationv1.ResourceAttributes{Verb: "list", Resource: "namespaces"}, false},
+	{"list nodes", authorizationv1.ResourceAttributes{Verb: "list", Resource: "nodes"}, false},
+}
+
+// MakeKubeconfig requests a bound token for a ServiceAccount through the
+// TokenRequest API and writes a minimal kubeconfig using it, for CI
+// systems. Unlike a token Secret, the token expires and is invalidated
+// when the ServiceAccount is deleted. Before handing it out, the token
+// itself is used to ask the API server what it may do: the rules in its
+// namespace, and a set of cluster-wide permissions worth knowing about.
+func MakeKubeconfig(options MakeKubeconfigOptions) error {
+	if options.ServiceAccount == "" {
+		return fmt.Errorf("--sa is required")
+	}
+	if options.Namespace == "" {
+		options.Namespace = common.ContextNamespace()
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	config, err := common.GetRESTConfig()
+	if err != nil {
+		return err
+	}
+	caData := config.CAData
+	if len(caData) == 0 && config.CAFile != "" {
+		if caData, err = os.ReadFile(config.CAFile); err != nil {
+			return fmt.Errorf("failed to read cluster CA: %w", err)
+		}
+	}
+	server := options.Server
+	if server == "" {
+		server = config.Host
+	}
+	_, clusterName, err := common.CurrentContext()
+	if err != nil {
+		return err
+	}
+
+	// The kubeconfig goes to stdout unless a file is given, so the report
+	// can't go there too
+	report := io.Writer(os.Stdout)
+	if options.File == "" {
+		report = os.Stderr
+	}
+
+	ctx := context.TODO()
+	accounts := clientset.CoreV1().ServiceAccounts(options.Namespace)
+	if _, err := accounts.Get(ctx, options.ServiceAccount, metav1.GetOptions{}); err != nil {
+		if !apierrors.IsNotFound(err) || !options.Create {
+			return fmt.Errorf("failed to get ServiceAccount %s/%s (use --create to create it): %w", options.Namespace, options.ServiceAccount, err)
+		}
+		account := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: options.ServiceAccount, Namespace: options.Namespace}}
+		if _, err := accounts.Create(ctx, account, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create ServiceAccount %s/%s: %w", options.Namespace, options.ServiceAccount, err)
+		}
+		fmt.Fprintf(report, "Created ServiceAccount %s/%s; bind it to a Role before using the kubeconfig\n", options.Namespace, options.ServiceAccount)
+	}
+
+	seconds := int64(options.Duration.Seconds())
+	request := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{Audiences: options.Audiences, ExpirationSeconds: &seconds},
+	}
+	token, err := accounts.CreateToken(ctx, options.ServiceAccount, request, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to request a token for %s/%s: %w", options.Namespace, options.ServiceAccount, err)
+	}
+	expires := token.Status.ExpirationTimestamp.Time
+	fmt.Fprintf(report, "Token for %s/%s expires %s (in %s)\n", options.Namespace, options.ServiceAccount,
+		expires.Local().Format(time.RFC3339), time.Until(expires).Round(time.Minute))
+	if time.Until(expires) < options.Duration-time.Minute {
+		fmt.Fprintf(report, "⚠️  The API server shortened the lifetime from the %s requested (--service-account-max-token-expiration)\n", options.Duration)
+	}
+
+	tokenConfig := &rest.Config{
+		Host:            server,
+		BearerToken:     token.Status.Token,
+		TLSClientConfig: rest.TLSClientConfig{CAData: caData, Insecure: config.Insecure},
+	}
+	if err := printTokenAccess(ctx, tokenConfig, options.Namespace, report); err != nil {
+		fmt.Fprintf(report, "⚠️  Could not check the token's permissions: %v\n", err)
+	}
+
+	user := fmt.Sprintf("%s-%s", options.Namespace, options.ServiceAccount)
+	contextName := fmt.Sprintf("%s@%s", user, clusterName)
+	kubeconfig := clientcmdapi.NewConfig()
+	cluster := clientcmdapi.NewCluster()
+	cluster.Server = server
+	cluster.CertificateAuthorityData = caData
+	cluster.InsecureSkipTLSVerify = config.Insecure
+	kubeconfig.Clusters[clusterName] = cluster
+	authInfo := clientcmdapi.NewAuthInfo()
+	authInfo.Token = token.Status.Token
+	kubeconfig.AuthInfos[user] = authInfo
+	kubeContext := clientcmdapi.NewContext()
+	kubeContext.Cluster = clusterName
+	kubeContext.AuthInfo = user
+	kubeContext.Namespace = options.Namespace
+	kubeconfig.Contexts[contextName] = kubeContext
+	kubeconfig.CurrentContext = contextName
+
+	content, err := clientcmd.Write(*kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to serialize kubeconfig: %w", err)
+	}
+	if options.File == "" {
+		_, err = os.Stdout.Write(content)
+		return err
+	}
+	if err := os.WriteFile(options.File, content, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", options.File, err)
+	}
+	fmt.Fprintf(report, "Wrote kubeconfig to %s (context %s)\n", options.File, contextName)
+	return nil
+}
+
+// printTokenAccess asks the API server, as the token, which rules apply in
+// its namespace and whether it holds the cluster-wide permissions of
+// clusterAccessChecks
+func printTokenAccess(ctx context.Context, config *rest.Config, namespace string, report io.Writer) error {
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	review, err := client.AuthorizationV1().SelfSubjectRulesReviews().Create(ctx, &authorizationv1.SelfSubjectRulesReview{
+		Spec: authorizationv1.SelfSubjectRulesReviewSpec{Namespace: namespace},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(report, "\nPermissions in namespace %s:\n", namespace)
+	w := tabwriter.NewWriter(report, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERBS\tRESOURCES\tNAMES")
+	for _, rule := range review.Status.ResourceRules {
+		var resources []string
+		for _, resource := range rule.Resources {
+			for _, group := range rule.APIGroups {
+				if group != "" {
+					resource += "." + group
+				}
+				resources = append(resources, resource)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", strings.Join(rule.Verbs, ","), strings.Join(resources, ","), valueOrDash(strings.Join(rule.ResourceNames, ",")))
+	}
+	for _, rule := range review.Status.NonResourceRules {
+		fmt.Fprintf(w, "%s\t%s\t-\n", strings.Join(rule.Verbs, ","), strings.Join(rule.NonResourceURLs, ","))
+	}
+	w.Flush()
+	if review.Status.Incomplete {
+		fmt.Fprintf(report, "⚠️  The list is incomplete: %s\n", valueOrDash(review.Status.EvaluationError))
+	}
+
+	fmt.Fprintln(report, "\nCluster-wide:")
+	risky := 0
+	for _, check := range clusterAccessChecks {
+		attributes := check.attributes
+		access, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &attributes},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		status := "no"
+		switch {
+		case access.Status.Allowed && check.risky:
+			status = "⚠️  yes"
+			risky++
+		case access.Status.Allowed:
+			status = "yes"
+		}
+		fmt.Fprintf(report, "  %-32s %s\n", check.description, status)
+	}
+	if risky > 0 {
+		fmt.Fprintf(report, "⚠️  The token holds %d broad permissions; a leaked CI kubeconfig would expose them\n", risky)
+	}
+	fmt.Fprintln(report)
+	return nil
+}
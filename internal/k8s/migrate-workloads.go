@@ -0,0 +1,604 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/ui"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Migration actions of a pod
+const (
+	migrateEvict   = "evict"
+	migratePatch   = "patch"
+	migrateBlocked = "blocked"
+)
+
+// MigrateWorkloadsOptions contains options for moving workloads from one
+// node group to another
+type MigrateWorkloadsOptions struct {
+	From            string // EKS node group, Karpenter NodePool or eksctl node group the pods leave
+	To              string // Group the pods move to
+	UpdateWorkloads bool   // Patch the nodeSelectors and tolerations that keep pods off the target group
+	Execute         bool
+	Yes             bool // Execute every step without asking
+	NonInteractive  bool
+	Timeout         time.Duration // How long to wait for each node's pods to be evicted and replaced
+}
+
+// migrationPod is a pod on a source node and what the migration does with it
+type migrationPod struct {
+	pod    *corev1.Pod
+	owner  string // kind/name
+	action string // evict, patch or blocked
+	reason string
+}
+
+// workloadPatch moves a workload's pod template to the target group
+type workloadPatch struct {
+	namespace string
+	kind      string // Deployment or StatefulSet
+	name      string
+	changes   []string
+	patch     []byte // Merge patch of the new nodeSelector and tolerations
+	rollback  []byte // Merge patch restoring the original ones
+	err       error  // Why the workload can't be patched
+}
+
+// migration is the plan and the progress of a migration
+type migration struct {
+	clientset *kubernetes.Clientset
+	options   MigrateWorkloadsOptions
+	source    []corev1.Node
+	pods      map[string][]*migrationPod // Source node name to its pods
+	patches   []*workloadPatch
+	cordoned  []string // Nodes this run cordoned
+	applied   []*workloadPatch
+}
+
+// MigrateWorkloads moves the pods of one node group to another: it checks
+// that every pod on the source nodes fits the target group, optionally
+// patching Deployments and StatefulSets whose nodeSelector names the source
+// group or that don't tolerate the target group's taints, then cordons the
+// source nodes and evicts their pods one node at a time, respecting
+// PodDisruptionBudgets and waiting for the owners to be ready again. Without
+// Execute only the plan is printed. Rollback instructions are printed at the
+// end, also when a step fails.
+func MigrateWorkloads(options MigrateWorkloadsOptions) error {
+	if options.From == "" || options.To == "" {
+		return fmt.Errorf("--from-nodegroup and --to-nodegroup are required")
+	}
+	if options.From == options.To {
+		return fmt.Errorf("source and target node group are the same")
+	}
+	if options.Execute && !options.Yes && (options.NonInteractive || !ui.IsInteractive()) {
+		return fmt.Errorf("executing the migration asks before each step, pass --yes to run it without prompts")
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := &migration{clientset: clientset, options: options, pods: make(map[string][]*migrationPod)}
+	blocked, err := m.plan(ctx)
+	if err != nil {
+		return err
+	}
+	if !options.Execute {
+		fmt.Println("\nRun with --execute to carry out the migration.")
+		return nil
+	}
+	if blocked > 0 {
+		fmt.Printf("⚠️  %d blocked pods stay on the cordoned source nodes\n", blocked)
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+	go func() {
+		if _, ok := <-stop; ok {
+			fmt.Println("\nInterrupted, stopping after the current step...")
+			cancel()
+		}
+	}()
+
+	err = m.execute(ctx)
+	m.printRollback()
+	return err
+}
+
+// plan finds the source and target nodes, decides what happens to every pod
+// on the source nodes and prints the plan. It returns the number of blocked
+// pods.
+func (m *migration) plan(ctx context.Context) (int, error) {
+	nodeList, err := m.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	var target []corev1.Node
+	targetLabel := ""
+	for _, node := range nodeList.Items {
+		group, label := nodeGroupOf(node)
+		switch group {
+		case m.options.From:
+			m.source = append(m.source, node)
+		case m.options.To:
+			targetLabel = label
+			if !node.Spec.Unschedulable && getNodeReadyStatus(node) == "True" {
+				target = append(target, node)
+			}
+		}
+	}
+	if len(m.source) == 0 {
+		return 0, fmt.Errorf("no nodes in node group %s", m.options.From)
+	}
+	if len(target) == 0 {
+		return 0, fmt.Errorf("no Ready, schedulable nodes in node group %s; scale it up first", m.options.To)
+	}
+	sort.Slice(m.source, func(i, j int) bool { return m.source[i].Name < m.source[j].Name })
+	sourceNames := make(map[string]bool)
+	for _, node := range m.source {
+		sourceNames[node.Name] = true
+	}
+
+	podList, err := m.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pods: %w", err)
+	}
+	replicaSets, err := m.clientset.AppsV1().ReplicaSets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list replicasets: %w", err)
+	}
+	rsOwnerCache := make(map[string]string)
+	for _, rs := range replicaSets.Items {
+		for _, owner := range rs.OwnerReferences {
+			if owner.Kind == "Deployment" {
+				rsOwnerCache[rs.Namespace+"/"+rs.Name] = owner.Name
+			}
+		}
+	}
+
+	// Capacity the target group has left, and what the moving pods request
+	targetNames := make(map[string]bool)
+	var freeCPU, freeMemory, movingCPU, movingMemory float64
+	for _, node := range target {
+		targetNames[node.Name] = true
+		freeCPU += float64(node.Status.Allocatable.Cpu().MilliValue()) / 1000
+		freeMemory += float64(node.Status.Allocatable.Memory().Value()) / (1024 * 1024 * 1024)
+	}
+
+	patches := make(map[string]*workloadPatch)
+	blocked := 0
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if targetNames[pod.Spec.NodeName] && podNeedsEviction(*pod) {
+			cpu, memory := podRequests(pod)
+			freeCPU -= cpu
+			freeMemory -= memory
+		}
+		if !sourceNames[pod.Spec.NodeName] || !podNeedsEviction(*pod) {
+			continue
+		}
+		name, kind := getPodOwnerFast(pod, rsOwnerCache)
+		entry := &migrationPod{pod: pod, owner: kind + "/" + name, action: migrateEvict}
+		m.pods[pod.Spec.NodeName] = append(m.pods[pod.Spec.NodeName], entry)
+		switch {
+		case kind == "Pod":
+			entry.action, entry.reason = migrateBlocked, "no controller would recreate it"
+		case pod.Annotations[safeToEvictAnnotation] == "false":
+			entry.action, entry.reason = migrateBlocked, safeToEvictAnnotation+"=false"
+		case podFitsNodes(pod, pod.Spec, target):
+		default:
+			spec, changes, reason := retargetPodSpec(pod.Spec, m.options.From, m.options.To, targetLabel, target)
+			switch {
+			case reason != "":
+				entry.action, entry.reason = migrateBlocked, reason
+			case !podFitsNodes(pod, spec, target):
+				entry.action, entry.reason = migrateBlocked, "its required node affinity excludes "+m.options.To
+			case kind != "Deployment" && kind != "StatefulSet":
+				entry.action, entry.reason = migrateBlocked, fmt.Sprintf("needs %s, but %s can't be patched", strings.Join(changes, ", "), kind)
+			case !m.options.UpdateWorkloads:
+				entry.action, entry.reason = migrateBlocked, fmt.Sprintf("needs %s, pass --update-workloads", strings.Join(changes, ", "))
+			default:
+				key := pod.Namespace + "/" + kind + "/" + name
+				patch, ok := patches[key]
+				if !ok {
+					patch = m.buildWorkloadPatch(ctx, pod.Namespace, kind, name, targetLabel, target)
+					patches[key] = patch
+					if patch.err == nil {
+						m.patches = append(m.patches, patch)
+					}
+				}
+				if patch.err != nil {
+					entry.action, entry.reason = migrateBlocked, patch.err.Error()
+				} else {
+					entry.action, entry.reason = migratePatch, strings.Join(patch.changes, ", ")
+				}
+			}
+		}
+		if entry.action == migrateBlocked {
+			blocked++
+		} else {
+			cpu, memory := podRequests(pod)
+			movingCPU += cpu
+			movingMemory += memory
+		}
+	}
+
+	fmt.Printf("Migrating from %s (%d nodes) to %s (%d Ready nodes)\n\n", m.options.From, len(m.source), m.options.To, len(target))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tPOD\tOWNER\tACTION\tDETAIL")
+	total := 0
+	for _, node := range m.source {
+		for _, entry := range m.pods[node.Name] {
+			total++
+			action := entry.action
+			switch action {
+			case migratePatch:
+				action = "⚠️  patch owner, evict"
+			case migrateBlocked:
+				action = "❌ blocked"
+			}
+			fmt.Fprintf(w, "%s\t%s/%s\t%s\t%s\t%s\n", node.Name, entry.pod.Namespace, entry.pod.Name, entry.owner, action, valueOrDash(entry.reason))
+		}
+	}
+	w.Flush()
+
+	fmt.Println("\n--- Migration Plan ---")
+	fmt.Printf("Pods to move: %d of %d, blocked: %d\n", total-blocked, total, blocked)
+	for _, patch := range m.patches {
+		fmt.Printf("Patch %s %s/%s: %s\n", strings.ToLower(patch.kind), patch.namespace, patch.name, strings.Join(patch.changes, ", "))
+	}
+	fmt.Printf("Moving pods request %.2f cores and %.2fGi; %s has %.2f cores and %.2fGi unrequested\n",
+		movingCPU, movingMemory, m.options.To, max(freeCPU, 0), max(freeMemory, 0))
+	if movingCPU > freeCPU || movingMemory > freeMemory {
+		fmt.Printf("⚠️  %s needs more nodes; pods stay Pending until its autoscaler adds them or it is scaled up\n", m.options.To)
+	}
+	if blocked > 0 {
+		fmt.Printf("❌ %d pods can't move; they keep running on the cordoned source nodes\n", blocked)
+	} else {
+		fmt.Println("✅ Every pod can move")
+	}
+	fmt.Println("----------------------------------------------------")
+	return blocked, nil
+}
+
+// podFitsNodes reports whether a pod with the given spec matches the
+// selectors, tolerations and required node affinity of any of the nodes.
+func podFitsNodes(pod *corev1.Pod, spec corev1.PodSpec, nodes []corev1.Node) bool {
+	candidate := pod.DeepCopy()
+	candidate.Spec = spec
+	for _, node := range eligibleNodes(spec, nodes) {
+		if nodeAffinityMatches(candidate, node) {
+			return true
+		}
+	}
+	return false
+}
+
+// retargetPodSpec returns the spec with node group selectors pointed at the
+// target group and tolerations for the target nodes' taints, with the
+// changes made. The reason is set when the spec can't be fixed this way.
+func retargetPodSpec(spec corev1.PodSpec, from, to, targetLabel string, target []corev1.Node) (corev1.PodSpec, []string, string) {
+	spec = *spec.DeepCopy()
+	var changes []string
+	for key, value := range spec.NodeSelector {
+		isGroupLabel := key == eksNodeGroupLabel || key == karpenterNodePoolLabel || key == eksctlNodeGroupLabel
+		switch {
+		case isGroupLabel && value == from:
+			delete(spec.NodeSelector, key)
+			spec.NodeSelector[targetLabel] = to
+			changes = append(changes, fmt.Sprintf("nodeSelector %s=%s to %s=%s", key, value, targetLabel, to))
+		case !nodeLabelShared(target, key, value):
+			return spec, nil, fmt.Sprintf("nodeSelector %s=%s matches no node of %s", key, value, to)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, node := range target {
+		for _, taint := range node.Spec.Taints {
+			id := taint.ToString()
+			if seen[id] || taint.Effect == corev1.TaintEffectPreferNoSchedule || toleratesNodeTaints(spec.Tolerations, []corev1.Taint{taint}) {
+				continue
+			}
+			seen[id] = true
+			toleration := corev1.Toleration{Key: taint.Key, Operator: corev1.TolerationOpEqual, Value: taint.Value, Effect: taint.Effect}
+			if taint.Value == "" {
+				toleration.Operator = corev1.TolerationOpExists
+			}
+			spec.Tolerations = append(spec.Tolerations, toleration)
+			changes = append(changes, "toleration "+id)
+		}
+	}
+	return spec, changes, ""
+}
+
+// nodeLabelShared reports whether any of the nodes has the label.
+func nodeLabelShared(nodes []corev1.Node, key, value string) bool {
+	for _, node := range nodes {
+		if node.Labels[key] == value {
+			return true
+		}
+	}
+	return false
+}
+
+// buildWorkloadPatch computes the merge patches that move a workload's pod
+// template to the target group and back.
+func (m *migration) buildWorkloadPatch(ctx context.Context, namespace, kind, name, targetLabel string, target []corev1.Node) *workloadPatch {
+	patch := &workloadPatch{namespace: namespace, kind: kind, name: name}
+	var spec corev1.PodSpec
+	switch kind {
+	case "Deployment":
+		deployment, err := m.clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			patch.err = fmt.Errorf("failed to get deployment: %w", err)
+			return patch
+		}
+		spec = deployment.Spec.Template.Spec
+	case "StatefulSet":
+		statefulSet, err := m.clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			patch.err = fmt.Errorf("failed to get statefulset: %w", err)
+			return patch
+		}
+		spec = statefulSet.Spec.Template.Spec
+	}
+	updated, changes, reason := retargetPodSpec(spec, m.options.From, m.options.To, targetLabel, target)
+	if reason != "" {
+		patch.err = fmt.Errorf("%s", reason)
+		return patch
+	}
+	patch.changes = changes
+
+	// Keys the patch removes are set to null, and so are the keys the
+	// rollback has to remove again
+	forward := make(map[string]any)
+	backward := make(map[string]any)
+	for key, value := range spec.NodeSelector {
+		backward[key] = value
+		if _, ok := updated.NodeSelector[key]; !ok {
+			forward[key] = nil
+		}
+	}
+	for key, value := range updated.NodeSelector {
+		forward[key] = value
+		if _, ok := spec.NodeSelector[key]; !ok {
+			backward[key] = nil
+		}
+	}
+	templatePatch := func(nodeSelector map[string]any, tolerations []corev1.Toleration) []byte {
+		podSpec := map[string]any{"nodeSelector": nodeSelector, "tolerations": tolerations}
+		if len(tolerations) == 0 {
+			podSpec["tolerations"] = nil
+		}
+		data, _ := json.Marshal(map[string]any{"spec": map[string]any{"template": map[string]any{"spec": podSpec}}})
+		return data
+	}
+	patch.patch = templatePatch(forward, updated.Tolerations)
+	patch.rollback = templatePatch(backward, spec.Tolerations)
+	return patch
+}
+
+// execute patches the workloads, cordons the source nodes and evicts their
+// pods node by node, asking before each step unless Yes is set.
+func (m *migration) execute(ctx context.Context) error {
+	confirm := func(prompt string) (string, error) {
+		if m.options.Yes {
+			fmt.Println(prompt)
+			return ui.StepRun, nil
+		}
+		return ui.ConfirmStep(prompt)
+	}
+
+	for i, patch := range m.patches {
+		answer, err := confirm(fmt.Sprintf("\nPatch %d/%d: %s %s/%s (%s)", i+1, len(m.patches), strings.ToLower(patch.kind),
+			patch.namespace, patch.name, strings.Join(patch.changes, ", ")))
+		if err != nil || answer == ui.StepQuit {
+			return err
+		}
+		if answer == ui.StepSkip {
+			continue
+		}
+		if err := m.applyPatch(ctx, patch, patch.patch); err != nil {
+			return err
+		}
+		m.applied = append(m.applied, patch)
+		fmt.Printf("✅ Patched %s %s/%s, it rolls out onto %s\n", strings.ToLower(patch.kind), patch.namespace, patch.name, m.options.To)
+	}
+
+	answer, err := confirm(fmt.Sprintf("\nCordon the %d nodes of %s", len(m.source), m.options.From))
+	if err != nil || answer != ui.StepRun {
+		if err == nil {
+			fmt.Println("Stopped before cordoning; evicted pods could land on the source nodes again")
+		}
+		return err
+	}
+	for _, node := range m.source {
+		if node.Spec.Unschedulable {
+			continue
+		}
+		patch := []byte(`{"spec":{"unschedulable":true}}`)
+		if _, err := m.clientset.CoreV1().Nodes().Patch(ctx, node.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("failed to cordon node %s: %w", node.Name, err)
+		}
+		m.cordoned = append(m.cordoned, node.Name)
+	}
+	fmt.Printf("✅ Cordoned %d nodes\n", len(m.cordoned))
+
+	moved, skipped := 0, 0
+	for i, node := range m.source {
+		var movable []*migrationPod
+		for _, entry := range m.pods[node.Name] {
+			if entry.action != migrateBlocked {
+				movable = append(movable, entry)
+			}
+		}
+		if len(movable) == 0 {
+			continue
+		}
+		answer, err := confirm(fmt.Sprintf("\nNode %d/%d: evict %d pods from %s", i+1, len(m.source), len(movable), node.Name))
+		if err != nil || answer == ui.StepQuit {
+			return err
+		}
+		if answer == ui.StepSkip {
+			skipped += len(movable)
+			continue
+		}
+		if err := m.evictNode(ctx, movable); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return err
+		}
+		moved += len(movable)
+		fmt.Printf("✅ %s drained, %d pods moved (%d/%d nodes)\n", node.Name, len(movable), i+1, len(m.source))
+	}
+
+	fmt.Println("\n--- Migration Summary ---")
+	fmt.Printf("Pods moved: %d, skipped: %d\n", moved, skipped)
+	fmt.Printf("Workloads patched: %d, nodes cordoned: %d\n", len(m.applied), len(m.cordoned))
+	fmt.Printf("The %s nodes can be scaled down once nothing needed runs on them\n", m.options.From)
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// applyPatch applies a merge patch to the workload's pod template.
+func (m *migration) applyPatch(ctx context.Context, patch *workloadPatch, data []byte) error {
+	var err error
+	switch patch.kind {
+	case "Deployment":
+		_, err = m.clientset.AppsV1().Deployments(patch.namespace).Patch(ctx, patch.name, types.MergePatchType, data, metav1.PatchOptions{})
+	case "StatefulSet":
+		_, err = m.clientset.AppsV1().StatefulSets(patch.namespace).Patch(ctx, patch.name, types.MergePatchType, data, metav1.PatchOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to patch %s %s/%s: %w", strings.ToLower(patch.kind), patch.namespace, patch.name, err)
+	}
+	return nil
+}
+
+// evictNode evicts the pods, retrying while PodDisruptionBudgets block
+// them, and waits until they are gone and their Deployments and
+// StatefulSets are ready again.
+func (m *migration) evictNode(ctx context.Context, pods []*migrationPod) error {
+	deadline := time.Now().Add(m.options.Timeout)
+	pending := pods
+	for len(pending) > 0 {
+		var blocked []*migrationPod
+		for _, entry := range pending {
+			current, err := m.clientset.CoreV1().Pods(entry.pod.Namespace).Get(ctx, entry.pod.Name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) || (err == nil && current.UID != entry.pod.UID) {
+				continue
+			}
+			if err == nil && current.DeletionTimestamp != nil {
+				blocked = append(blocked, entry)
+				continue
+			}
+			eviction := &policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: entry.pod.Name, Namespace: entry.pod.Namespace}}
+			err = m.clientset.PolicyV1().Evictions(entry.pod.Namespace).Evict(ctx, eviction)
+			switch {
+			case err == nil, apierrors.IsNotFound(err):
+				blocked = append(blocked, entry) // Wait until it is gone
+			case apierrors.IsTooManyRequests(err):
+				fmt.Printf("Eviction of %s/%s blocked by a PodDisruptionBudget, retrying\n", entry.pod.Namespace, entry.pod.Name)
+				blocked = append(blocked, entry)
+			default:
+				return fmt.Errorf("failed to evict pod %s/%s: %w", entry.pod.Namespace, entry.pod.Name, err)
+			}
+		}
+		pending = blocked
+		if len(pending) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s, %d pods not evicted yet", m.options.Timeout, len(pending))
+		}
+		if err := sleepContext(ctx, 5*time.Second); err != nil {
+			return err
+		}
+	}
+
+	owners := make(map[string]*migrationPod)
+	for _, entry := range pods {
+		owners[entry.pod.Namespace+"/"+entry.owner] = entry
+	}
+	for _, key := range sortedKeys(owners) {
+		entry := owners[key]
+		kind, name, _ := strings.Cut(entry.owner, "/")
+		for {
+			ready, desired, err := workloadReadiness(ctx, m.clientset, entry.pod.Namespace, kind, name)
+			if err != nil {
+				return err
+			}
+			if ready >= desired {
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("%s is not ready again after %s (%d/%d ready); check for Pending pods on %s", key, m.options.Timeout, ready, desired, m.options.To)
+			}
+			if err := sleepContext(ctx, 5*time.Second); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// workloadReadiness returns the ready and desired replicas of a Deployment
+// or StatefulSet; other owners count as ready.
+func workloadReadiness(ctx context.Context, clientset *kubernetes.Clientset, namespace, kind, name string) (int32, int32, error) {
+	switch kind {
+	case "Deployment":
+		deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to get deployment %s/%s: %w", namespace, name, err)
+		}
+		desired := int32(1)
+		if deployment.Spec.Replicas != nil {
+			desired = *deployment.Spec.Replicas
+		}
+		return deployment.Status.ReadyReplicas, desired, nil
+	case "StatefulSet":
+		statefulSet, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to get statefulset %s/%s: %w", namespace, name, err)
+		}
+		desired := int32(1)
+		if statefulSet.Spec.Replicas != nil {
+			desired = *statefulSet.Spec.Replicas
+		}
+		return statefulSet.Status.ReadyReplicas, desired, nil
+	}
+	return 0, 0, nil
+}
+
+// printRollback prints the commands that undo what this run changed.
+func (m *migration) printRollback() {
+	if len(m.cordoned) == 0 && len(m.applied) == 0 {
+		return
+	}
+	fmt.Println("\nTo roll back:")
+	for _, patch := range m.applied {
+		fmt.Printf("  kubectl -n %s patch %s %s --type merge -p '%s'\n", patch.namespace, strings.ToLower(patch.kind), patch.name, patch.rollback)
+	}
+	if len(m.cordoned) > 0 {
+		fmt.Printf("  kubectl uncordon %s\n", strings.Join(m.cordoned, " "))
+	}
+	fmt.Printf("Evicted pods don't move back on their own; to move them, run migrate-workloads --from-nodegroup %s --to-nodegroup %s\n",
+		m.options.To, m.options.From)
+}
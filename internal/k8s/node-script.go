@@ -0,0 +1,196 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/providerid"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// nodeScriptOptions selects how a shell script is run on nodes
+type nodeScriptOptions struct {
+	Method    string // auto, ssm or pod
+	Region    string // Defaults to the region in the nodes' provider IDs
+	Profile   string
+	Namespace string // Where collector pods run; must admit hostNetwork and privileged pods
+	Image     string // Image of the collector pods
+	Parallel  int    // Collector pods run at once
+	Timeout   time.Duration
+	Name      string // Command name, used in the SSM comment and the pod names
+	HostRoot  bool   // Run the pod's script chrooted into the host's root filesystem, to query its packages
+}
+
+// nodeScriptResult is what a script printed on one node
+type nodeScriptResult struct {
+	method string // ssm or pod
+	output string
+	err    error
+}
+
+// runNodeScript runs a shell script on every node and returns the result per
+// node name. With the auto method EC2 nodes whose SSM agent is online run it
+// through SSM, and the others in a short-lived privileged pod on the node's
+// network namespace. A node that fails gets an error in its result instead of
+// failing the others.
+func runNodeScript(clientset *kubernetes.Clientset, nodes []corev1.Node, script []string, options nodeScriptOptions) (map[string]*nodeScriptResult, error) {
+	if options.Method != "auto" && options.Method != "ssm" && options.Method != "pod" {
+		return nil, fmt.Errorf("invalid method '%s' (must be auto, ssm or pod)", options.Method)
+	}
+	results := make(map[string]*nodeScriptResult)
+	ssmNodes := make(map[string][]string)  // Region to the names of the nodes run through SSM
+	instanceIDs := make(map[string]string) // Node name to instance ID
+	for _, node := range nodes {
+		entry := &nodeScriptResult{method: "pod"}
+		results[node.Name] = entry
+		machine := nodeMachine(node)
+		if options.Method == "pod" || machine.Provider != providerid.AWS || machine.InstanceID == "" {
+			if options.Method == "ssm" {
+				entry.err = fmt.Errorf("not an EC2 instance, use --method pod")
+			}
+			continue
+		}
+		region := options.Region
+		if region == "" {
+			region = machine.Region
+		}
+		entry.method = "ssm"
+		instanceIDs[node.Name] = machine.InstanceID
+		ssmNodes[region] = append(ssmNodes[region], node.Name)
+	}
+
+	for region, names := range ssmNodes {
+		sess, err := awsutils.NewSession(options.Profile, region)
+		if err != nil {
+			return nil, err
+		}
+		var ids []string
+		for _, name := range names {
+			ids = append(ids, instanceIDs[name])
+		}
+		statuses, err := awsutils.GetSSMInstanceStatus(sess, ids)
+		if err != nil {
+			return nil, err
+		}
+		var online []string
+		for _, name := range names {
+			instanceID := instanceIDs[name]
+			switch {
+			case statuses[instanceID].PingStatus == "Online":
+				online = append(online, instanceID)
+			case options.Method == "ssm":
+				results[name].err = fmt.Errorf("the SSM agent of %s is not online", instanceID)
+			default:
+				results[name].method = "pod"
+			}
+		}
+		if len(online) == 0 {
+			continue
+		}
+		outputs := awsutils.RunShellScript(sess, online, script, "swissarmycli "+options.Name, options.Timeout)
+		for _, name := range names {
+			if output, ok := outputs[instanceIDs[name]]; ok {
+				results[name].output, results[name].err = output.Output, output.Err
+			}
+		}
+	}
+
+	semaphore := make(chan struct{}, max(options.Parallel, 1))
+	var wg sync.WaitGroup
+	for name, entry := range results {
+		if entry.method != "pod" || entry.err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(name string, entry *nodeScriptResult) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			entry.output, entry.err = runScriptPod(clientset, name, script, options)
+		}(name, entry)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// runScriptPod runs the script in a pod on the node's network namespace and
+// returns its output. The pod is privileged so the kernel's tables and log
+// can be read, and is deleted afterwards. With HostRoot the host's root
+// filesystem is mounted read-only and the script runs chrooted into it.
+func runScriptPod(clientset *kubernetes.Clientset, nodeName string, script []string, options nodeScriptOptions) (string, error) {
+	ctx := context.TODO()
+	privileged := true
+	command := []string{"sh", "-c", strings.Join(script, "\n")}
+	var volumes []corev1.Volume
+	var mounts []corev1.VolumeMount
+	if options.HostRoot {
+		command = append([]string{"chroot", "/host"}, command...)
+		volumes = []corev1.Volume{{Name: "host-root", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}}}}
+		mounts = []corev1.VolumeMount{{Name: "host-root", MountPath: "/host", ReadOnly: true}}
+	}
+	pod, err := clientset.CoreV1().Pods(options.Namespace).Create(ctx, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "swissarmycli-" + options.Name + "-",
+			Labels:       map[string]string{"app.kubernetes.io/managed-by": "swissarmycli"},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:      nodeName,
+			HostNetwork:   true,
+			RestartPolicy: corev1.RestartPolicyNever,
+			Tolerations:   []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			Volumes:       volumes,
+			Containers: []corev1.Container{{
+				Name:            "collector",
+				Image:           options.Image,
+				Command:         command,
+				SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+				VolumeMounts:    mounts,
+			}},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to create collector pod: %w", err)
+	}
+	defer func() {
+		gracePeriod := int64(0)
+		_ = clientset.CoreV1().Pods(options.Namespace).Delete(context.TODO(), pod.Name, metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+	}()
+
+	deadline := time.Now().Add(options.Timeout)
+	for {
+		current, err := clientset.CoreV1().Pods(options.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to get collector pod: %w", err)
+		}
+		if current.Status.Phase == corev1.PodSucceeded || current.Status.Phase == corev1.PodFailed {
+			break
+		}
+		for _, status := range current.Status.ContainerStatuses {
+			if waiting := status.State.Waiting; waiting != nil && (waiting.Reason == "ErrImagePull" || waiting.Reason == "ImagePullBackOff") {
+				return "", fmt.Errorf("failed to pull %s: %s", options.Image, waiting.Message)
+			}
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("timed out waiting for collector pod %s/%s", options.Namespace, pod.Name)
+		}
+		time.Sleep(2 * time.Second)
+	}
+
+	logs, err := clientset.CoreV1().Pods(options.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{}).DoRaw(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to read collector pod logs: %w", err)
+	}
+	return string(logs), nil
+}
+
+// isFargateNode reports whether a node is a Fargate micro-VM, where nothing
+// can be run on the host.
+func isFargateNode(node corev1.Node) bool {
+	return strings.HasPrefix(node.Name, "fargate-") || node.Labels["eks.amazonaws.com/compute-type"] == "fargate"
+}
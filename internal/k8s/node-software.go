@@ -0,0 +1,433 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/providerid"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// packageNamePattern keeps the package name safe to put in a shell script
+var packageNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+-]*$`)
+
+// binaryVersionPattern finds the version in the first line a binary prints,
+// e.g. "containerd github.com/containerd/containerd v1.7.11 64b8a811" or
+// "OpenSSL 3.0.8 7 Feb 2023"
+var binaryVersionPattern = regexp.MustCompile(`\bv?(\d+(?:\.\d+)+[0-9A-Za-z.+~-]*)`)
+
+// softwareScript prints the rpm or dpkg packages whose name starts with
+// $name and, when a binary of that name is on the PATH, its version. It
+// runs after a line setting name.
+var softwareScript = []string{
+	`PATH=$PATH:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin`,
+	`if command -v rpm >/dev/null 2>&1; then`,
+	`  rpm -qa --qf 'package %{NAME} %{VERSION}-%{RELEASE} %{ARCH}\n' "$name*"`,
+	`elif command -v dpkg-query >/dev/null 2>&1; then`,
+	`  dpkg-query -W -f='${Status} package ${Package} ${Version} ${Architecture}\n' "$name*" 2>/dev/null | sed -n 's/^install ok installed //p'`,
+	`fi`,
+	`if path=$(command -v "$name" 2>/dev/null); then`,
+	`  echo "binary $path $({ "$name" --version 2>/dev/null || "$name" version 2>/dev/null; } | head -n 1)"`,
+	`fi`,
+}
+
+// NodeSoftwareOptions contains options for the per-node package inventory
+type NodeSoftwareOptions struct {
+	Selector  string // Only nodes matching this label selector
+	Method    string // auto, inventory, ssm or pod
+	FixedIn   string // Version that fixes the CVE; older versions are flagged
+	Region    string // Defaults to the region in the nodes' provider IDs
+	Profile   string
+	Namespace string // Where collector pods run; must admit privileged pods with a hostPath volume
+	Image     string // Needs chroot
+	Parallel  int    // Collector pods run at once
+	Timeout   time.Duration
+	Output    string // table or json
+	FailOn    string // Lowest severity that fails the run: error, warning, info or none
+}
+
+// installedSoftware is a package or binary found on a node
+type installedSoftware struct {
+	kind    string // package or binary
+	name    string // Package name or binary path
+	version string
+	arch    string
+}
+
+// softwareNode is what was found on one node
+type softwareNode struct {
+	name     string
+	group    string
+	method   string // inventory, ssm or pod
+	err      error
+	software []installedSoftware
+}
+
+// ShowNodeSoftware reports which version of a package or binary every node
+// has, to answer whether a CVE in containerd, runc, openssl and the like
+// affects the fleet. EC2 nodes are looked up in SSM Inventory first, which
+// needs no access to the node; the nodes inventory has nothing for are
+// queried with rpm or dpkg and the binary's --version through SSM Run
+// Command or a pod chrooted into the host. With FixedIn the nodes below that
+// version are flagged.
+func ShowNodeSoftware(name string, options NodeSoftwareOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	if !packageNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid package name %q", name)
+	}
+	if options.Method != "auto" && options.Method != "inventory" && options.Method != "ssm" && options.Method != "pod" {
+		return fmt.Errorf("invalid method '%s' (must be auto, inventory, ssm or pod)", options.Method)
+	}
+	if options.Image == "" {
+		options.Image = DefaultDebugImage
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	nodeList, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{LabelSelector: options.Selector})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	var targets []corev1.Node
+	nodes := make(map[string]*softwareNode)
+	for _, node := range nodeList.Items {
+		if isFargateNode(node) {
+			continue
+		}
+		group, _ := nodeGroupOf(node)
+		targets = append(targets, node)
+		nodes[node.Name] = &softwareNode{name: node.Name, group: group}
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no nodes to check (Fargate nodes can't be checked)")
+	}
+	if options.Output != "json" {
+		fmt.Printf("Looking up %s on %d nodes...\n", name, len(targets))
+	}
+
+	remaining := targets
+	if options.Method == "auto" || options.Method == "inventory" {
+		remaining, err = lookupSoftwareInventory(targets, nodes, name, options)
+		if err != nil {
+			return err
+		}
+	}
+	if options.Method == "inventory" {
+		for _, node := range remaining {
+			nodes[node.Name].method = "inventory"
+			nodes[node.Name].err = fmt.Errorf("no SSM inventory, is AWS-GatherSoftwareInventory associated with the instance?")
+		}
+		remaining = nil
+	}
+	if len(remaining) > 0 {
+		script := append([]string{"name='" + name + "'"}, softwareScript...)
+		outputs, err := runNodeScript(clientset, remaining, script, nodeScriptOptions{
+			Method:    options.Method,
+			Region:    options.Region,
+			Profile:   options.Profile,
+			Namespace: options.Namespace,
+			Image:     options.Image,
+			Parallel:  options.Parallel,
+			Timeout:   options.Timeout,
+			Name:      "node-software",
+			HostRoot:  true,
+		})
+		if err != nil {
+			return err
+		}
+		for nodeName, output := range outputs {
+			entry := nodes[nodeName]
+			entry.method, entry.err = output.method, output.err
+			if entry.err == nil {
+				entry.software = parseSoftwareOutput(output.output)
+			}
+		}
+	}
+
+	names := sortedKeys(nodes)
+	var findings []result.Finding
+	var vulnerable, failed, without []string
+	versionNodes := make(map[string]int) // "name version" to the number of nodes
+	for _, nodeName := range names {
+		entry := nodes[nodeName]
+		resource := "node/" + nodeName
+		if entry.err != nil {
+			failed = append(failed, nodeName)
+			findings = append(findings, result.Finding{
+				Check:    "collection-failed",
+				Severity: result.SeverityWarning,
+				Resource: resource,
+				Message:  fmt.Sprintf("could not check %s: %v", name, entry.err),
+				Details:  map[string]string{"method": entry.method},
+			})
+			continue
+		}
+		if len(entry.software) == 0 {
+			without = append(without, nodeName)
+		}
+		isVulnerable := false
+		for _, software := range entry.software {
+			versionNodes[softwareLabel(software)]++
+			details := map[string]string{"kind": software.kind, "name": software.name, "version": software.version, "method": entry.method}
+			if software.arch != "" {
+				details["arch"] = software.arch
+			}
+			if softwareBelowFix(software, name, options.FixedIn) {
+				isVulnerable = true
+				details["fixed_in"] = options.FixedIn
+				findings = append(findings, result.Finding{
+					Check:    "vulnerable-version",
+					Severity: result.SeverityError,
+					Resource: resource,
+					Message:  fmt.Sprintf("%s %s %s is older than %s", software.kind, software.name, software.version, options.FixedIn),
+					Details:  details,
+				})
+				continue
+			}
+			findings = append(findings, result.Finding{
+				Check:    "installed-version",
+				Severity: result.SeverityInfo,
+				Resource: resource,
+				Message:  fmt.Sprintf("%s %s %s", software.kind, software.name, software.version),
+				Details:  details,
+			})
+		}
+		if isVulnerable {
+			vulnerable = append(vulnerable, nodeName)
+		}
+	}
+
+	if options.Output == "json" {
+		if err := result.New("node software", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tGROUP\tVIA\tKIND\tNAME\tVERSION")
+	for _, nodeName := range names {
+		entry := nodes[nodeName]
+		switch {
+		case entry.err != nil:
+			fmt.Fprintf(w, "%s\t%s\t⚠️  %s failed\t-\t-\t-\n", nodeName, entry.group, entry.method)
+			continue
+		case len(entry.software) == 0:
+			fmt.Fprintf(w, "%s\t%s\t%s\t-\t-\tnot installed\n", nodeName, entry.group, entry.method)
+			continue
+		}
+		for _, software := range entry.software {
+			version := software.version
+			if softwareBelowFix(software, name, options.FixedIn) {
+				version = "❌ " + version
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", nodeName, entry.group, entry.method, software.kind, software.name, version)
+		}
+	}
+	w.Flush()
+
+	fmt.Println("\n--- Software Summary ---")
+	fmt.Printf("Nodes: %d, with %s: %d, without: %d, not checked: %d\n", len(names), name, len(names)-len(without)-len(failed), len(without), len(failed))
+	labels := sortedKeys(versionNodes)
+	sort.SliceStable(labels, func(i, j int) bool { return versionNodes[labels[i]] > versionNodes[labels[j]] })
+	for _, label := range labels {
+		fmt.Printf("  %s: %d nodes\n", label, versionNodes[label])
+	}
+	if options.FixedIn != "" {
+		if len(vulnerable) == 0 {
+			fmt.Printf("✅ No checked node runs %s older than %s\n", name, options.FixedIn)
+		} else {
+			fmt.Printf("❌ %d nodes run %s older than %s: %s\n", len(vulnerable), name, options.FixedIn, truncateList(vulnerable, 10))
+		}
+	}
+	if len(failed) > 0 {
+		fmt.Printf("⚠️  %d nodes could not be checked: %s\n", len(failed), truncateList(failed, 10))
+		for _, nodeName := range failed {
+			fmt.Printf("  %s: %v\n", nodeName, nodes[nodeName].err)
+		}
+	}
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// lookupSoftwareInventory fills in the nodes SSM Inventory has the package
+// for, and returns the nodes it has nothing for.
+func lookupSoftwareInventory(targets []corev1.Node, nodes map[string]*softwareNode, name string, options NodeSoftwareOptions) ([]corev1.Node, error) {
+	byRegion := make(map[string][]corev1.Node)
+	var remaining []corev1.Node
+	for _, node := range targets {
+		machine := nodeMachine(node)
+		if machine.Provider != providerid.AWS || machine.InstanceID == "" {
+			remaining = append(remaining, node)
+			continue
+		}
+		region := options.Region
+		if region == "" {
+			region = machine.Region
+		}
+		byRegion[region] = append(byRegion[region], node)
+	}
+	for region, regionNodes := range byRegion {
+		sess, err := awsutils.NewSession(options.Profile, region)
+		if err != nil {
+			return nil, err
+		}
+		var ids []string
+		for _, node := range regionNodes {
+			ids = append(ids, nodeMachine(node).InstanceID)
+		}
+		inventories, err := awsutils.GetInstalledApplications(sess, ids, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range regionNodes {
+			inventory := inventories[nodeMachine(node).InstanceID]
+			// An inventory without the package can't rule out a binary
+			// installed outside the package manager, so the node is queried
+			if len(inventory.Applications) == 0 {
+				remaining = append(remaining, node)
+				continue
+			}
+			entry := nodes[node.Name]
+			entry.method = "inventory"
+			for _, application := range inventory.Applications {
+				entry.software = append(entry.software, installedSoftware{
+					kind:    "package",
+					name:    application.Name,
+					version: application.Version,
+					arch:    application.Architecture,
+				})
+			}
+		}
+	}
+	return remaining, nil
+}
+
+// parseSoftwareOutput reads the output of softwareScript.
+func parseSoftwareOutput(output string) []installedSoftware {
+	var software []installedSoftware
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) >= 3 && fields[0] == "package":
+			entry := installedSoftware{kind: "package", name: fields[1], version: fields[2]}
+			if len(fields) >= 4 {
+				entry.arch = fields[3]
+			}
+			software = append(software, entry)
+		case len(fields) >= 2 && fields[0] == "binary":
+			version := "unknown"
+			if match := binaryVersionPattern.FindStringSubmatch(strings.Join(fields[2:], " ")); match != nil {
+				version = match[1]
+			}
+			software = append(software, installedSoftware{kind: "binary", name: fields[1], version: version})
+		}
+	}
+	return software
+}
+
+// softwareBelowFix reports whether the package named exactly like the
+// query, or the binary, is older than fixedIn. Other packages sharing the
+// prefix, such as openssl-libs for openssl, are listed but not compared.
+func softwareBelowFix(software installedSoftware, name, fixedIn string) bool {
+	if fixedIn == "" || software.version == "unknown" {
+		return false
+	}
+	if software.kind == "package" && software.name != name {
+		return false
+	}
+	return comparePackageVersions(software.version, fixedIn) < 0
+}
+
+// softwareLabel names a package or binary version for the summary
+func softwareLabel(software installedSoftware) string {
+	if software.kind == "binary" {
+		return fmt.Sprintf("%s (binary) %s", software.name, software.version)
+	}
+	return fmt.Sprintf("%s %s", software.name, software.version)
+}
+
+// comparePackageVersions orders rpm and dpkg versions such as
+// 2:1.7.11-1.amzn2023.0.1 the way rpmvercmp does: the epoch first, then
+// runs of digits compared as numbers and runs of letters compared as text,
+// a number being newer than letters and more runs newer than fewer. So
+// 1.7.11-1 is newer than 1.7.11, and 1.1.1k older than 1.1.1l.
+func comparePackageVersions(a, b string) int {
+	epochA, restA := splitPackageEpoch(a)
+	epochB, restB := splitPackageEpoch(b)
+	if epochA != epochB {
+		return epochA - epochB
+	}
+	runsA, runsB := versionRuns(restA), versionRuns(restB)
+	for i := 0; i < min(len(runsA), len(runsB)); i++ {
+		x, y := runsA[i], runsB[i]
+		numericX, numericY := unicode.IsDigit(rune(x[0])), unicode.IsDigit(rune(y[0]))
+		switch {
+		case numericX && !numericY:
+			return 1
+		case !numericX && numericY:
+			return -1
+		case numericX:
+			x, y = strings.TrimLeft(x, "0"), strings.TrimLeft(y, "0")
+			if len(x) != len(y) {
+				return len(x) - len(y)
+			}
+		}
+		if c := strings.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+	return len(runsA) - len(runsB)
+}
+
+// splitPackageEpoch splits the epoch off a version; it is 0 when missing.
+func splitPackageEpoch(version string) (int, string) {
+	if epoch, rest, ok := strings.Cut(version, ":"); ok {
+		if number, err := strconv.Atoi(epoch); err == nil {
+			return number, rest
+		}
+	}
+	return 0, version
+}
+
+// versionRuns splits a version into its runs of digits and of letters,
+// dropping the separators between them.
+func versionRuns(version string) []string {
+	var runs []string
+	current := ""
+	for _, r := range strings.TrimPrefix(version, "v") {
+		if !unicode.IsDigit(r) && !unicode.IsLetter(r) {
+			if current != "" {
+				runs = append(runs, current)
+			}
+			current = ""
+			continue
+		}
+		if current != "" && unicode.IsDigit(r) != unicode.IsDigit(rune(current[0])) {
+			runs = append(runs, current)
+			current = ""
+		}
+		current += string(r)
+	}
+	if current != "" {
+		runs = append(runs, current)
+	}
+	return runs
+}
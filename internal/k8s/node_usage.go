@@ -2,22 +2,103 @@ package k8s
 
 import (
 	"context"
+	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"sync"
 	"text/tabwriter"
+	"time"
 
+	"github.com/HighonAces/swissarmycli/internal/columns"
 	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/providerid"
+	"github.com/HighonAces/swissarmycli/internal/sink"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 )
 
+// NodeUsageOptions contains options for the node usage report
+type NodeUsageOptions struct {
+	Output   string // table or csv
+	AppendTo string // CSV file to append timestamped rows to
+	Custom   columns.Options
+}
+
 // ShowNodeUsage displays CPU and memory requests and limits for all nodes
-func ShowNodeUsage() error {
+func ShowNodeUsage(options NodeUsageOptions) error {
+	if options.AppendTo != "" {
+		options.Output = "csv"
+	}
+	if options.Output != "" && options.Output != "table" && options.Output != "csv" {
+		return fmt.Errorf("unsupported output format '%s' (must be table or csv)", options.Output)
+	}
+	if options.Custom.Enabled() {
+		if options.Output == "csv" {
+			return fmt.Errorf("--columns and --template can't be combined with CSV output")
+		}
+		printer, err := columns.New(options.Custom)
+		if err != nil {
+			return err
+		}
+		usage, err := CollectNodeUsage()
+		if err != nil {
+			return err
+		}
+		return printer.Print(os.Stdout, usage)
+	}
+
+	if options.Output != "csv" {
+		fmt.Println("Fetching node resource usage information...")
+	}
+
+	sortedStats, err := collectNodeUsage()
+	if err != nil {
+		return err
+	}
+
+	if options.Output == "csv" {
+		return outputNodeUsageCSV(sortedStats, options.AppendTo)
+	}
+
+	// Output results
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tCPU CAPACITY\tCPU REQUESTS\tCPU LIMITS\tCPU USAGE\tMEMORY CAPACITY\tMEMORY REQUESTS\tMEMORY LIMITS\tMEMORY USAGE")
+
+	for _, nodeInfo := range sortedStats {
+		cpuUsage := "N/A"
+		memoryUsage := "N/A"
+		if nodeInfo.cpuUsage > 0 {
+			cpuUsage = fmt.Sprintf("%.2f (%.0f%%)", nodeInfo.cpuUsage, nodeInfo.cpuUsage*100/nodeInfo.cpuCapacity)
+		}
+		if nodeInfo.memoryUsage > 0 {
+			memoryUsage = fmt.Sprintf("%.2fGi (%.0f%%)", nodeInfo.memoryUsage, nodeInfo.memoryUsage*100/nodeInfo.memoryCapacity)
+		}
+
+		fmt.Fprintf(w, "%s\t%.2f\t%.2f (%.0f%%)\t%.2f (%.0f%%)\t%s\t%.2fGi\t%.2fGi (%.0f%%)\t%.2fGi (%.0f%%)\t%s\n",
+			nodeInfo.name,
+			nodeInfo.cpuCapacity,
+			nodeInfo.cpuRequests, nodeInfo.cpuRequests*100/nodeInfo.cpuCapacity,
+			nodeInfo.cpuLimits, nodeInfo.cpuLimits*100/nodeInfo.cpuCapacity,
+			cpuUsage,
+			nodeInfo.memoryCapacity,
+			nodeInfo.memoryRequests, nodeInfo.memoryRequests*100/nodeInfo.memoryCapacity,
+			nodeInfo.memoryLimits, nodeInfo.memoryLimits*100/nodeInfo.memoryCapacity,
+			memoryUsage)
+	}
+
+	w.Flush()
+	return nil
+}
+
+// collectNodeUsage gathers capacity, requests, limits and usage for every
+// node, sorted by node name.
+func collectNodeUsage() ([]*nodeInfo, error) {
 	clientset, err := common.GetKubernetesClient()
 	if err != nil {
-		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
 
 	metricsClient, err := common.GetMetricsClient()
@@ -25,8 +106,6 @@ func ShowNodeUsage() error {
 		fmt.Fprintf(os.Stderr, "Warning: could not create metrics client: %v. Usage data will be unavailable.\n", err)
 	}
 
-	fmt.Println("Fetching node resource usage information...")
-
 	// Fetch all data concurrently
 	var wg sync.WaitGroup
 	var nodes *corev1.NodeList
@@ -57,10 +136,10 @@ func ShowNodeUsage() error {
 	wg.Wait()
 
 	if nodeErr != nil {
-		return fmt.Errorf("failed to get nodes: %w", nodeErr)
+		return nil, fmt.Errorf("failed to get nodes: %w", nodeErr)
 	}
 	if podErr != nil {
-		return fmt.Errorf("failed to get pods: %w", podErr)
+		return nil, fmt.Errorf("failed to get pods: %w", podErr)
 	}
 
 	// Build node stats
@@ -68,6 +147,7 @@ func ShowNodeUsage() error {
 	for _, node := range nodes.Items {
 		nodeStats[node.Name] = &nodeInfo{
 			name:           node.Name,
+			machine:        nodeMachine(node),
 			cpuCapacity:    float64(node.Status.Capacity.Cpu().MilliValue()) / 1000,
 			memoryCapacity: float64(node.Status.Capacity.Memory().Value()) / (1024 * 1024 * 1024),
 		}
@@ -110,38 +190,122 @@ func ShowNodeUsage() error {
 		}
 	}
 
-	// Output results
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "NODE\tCPU CAPACITY\tCPU REQUESTS\tCPU LIMITS\tCPU USAGE\tMEMORY CAPACITY\tMEMORY REQUESTS\tMEMORY LIMITS\tMEMORY USAGE")
-
+	sortedStats := make([]*nodeInfo, 0, len(nodeStats))
 	for _, nodeInfo := range nodeStats {
-		cpuUsage := "N/A"
-		memoryUsage := "N/A"
+		sortedStats = append(sortedStats, nodeInfo)
+	}
+	sort.Slice(sortedStats, func(i, j int) bool {
+		return sortedStats[i].name < sortedStats[j].name
+	})
+	return sortedStats, nil
+}
+
+// NodeUsage is the resource usage of one node. CPU is in cores and memory in
+// Gi; usage is zero when the Metrics Server is unavailable.
+type NodeUsage struct {
+	Name           string  `json:"name"`
+	Provider       string  `json:"provider"`    // aws, gce, azure or baremetal
+	InstanceID     string  `json:"instance_id"` // Empty on bare metal
+	Zone           string  `json:"zone"`
+	CPUCapacity    float64 `json:"cpu_capacity"`
+	CPURequests    float64 `json:"cpu_requests"`
+	CPULimits      float64 `json:"cpu_limits"`
+	CPUUsage       float64 `json:"cpu_usage"`
+	MemoryCapacity float64 `json:"memory_capacity_gi"`
+	MemoryRequests float64 `json:"memory_requests_gi"`
+	MemoryLimits   float64 `json:"memory_limits_gi"`
+	MemoryUsage    float64 `json:"memory_usage_gi"`
+}
+
+// CollectNodeUsage returns the data shown by ShowNodeUsage.
+func CollectNodeUsage() ([]NodeUsage, error) {
+	stats, err := collectNodeUsage()
+	if err != nil {
+		return nil, err
+	}
+	usage := make([]NodeUsage, len(stats))
+	for i, stat := range stats {
+		usage[i] = NodeUsage{
+			Name:           stat.name,
+			Provider:       stat.machine.Provider,
+			InstanceID:     stat.machine.InstanceID,
+			Zone:           stat.machine.Zone,
+			CPUCapacity:    stat.cpuCapacity,
+			CPURequests:    stat.cpuRequests,
+			CPULimits:      stat.cpuLimits,
+			CPUUsage:       stat.cpuUsage,
+			MemoryCapacity: stat.memoryCapacity,
+			MemoryRequests: stat.memoryRequests,
+			MemoryLimits:   stat.memoryLimits,
+			MemoryUsage:    stat.memoryUsage,
+		}
+	}
+	return usage, nil
+}
+
+// outputNodeUsageCSV writes one timestamped row per node to stdout, or appends
+// them to appendTo, writing the header only when the file is new or empty.
+func outputNodeUsageCSV(stats []*nodeInfo, appendTo string) error {
+	var out io.Writer = sink.Output()
+	writeHeader := true
+	if appendTo != "" {
+		file, err := os.OpenFile(appendTo, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open '%s': %w", appendTo, err)
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil {
+			return fmt.Errorf("failed to stat '%s': %w", appendTo, err)
+		}
+		writeHeader = info.Size() == 0
+		out = file
+	}
+
+	w := csv.NewWriter(out)
+	if writeHeader {
+		w.Write([]string{"timestamp", "node", "cpu_capacity", "cpu_requests", "cpu_limits", "cpu_usage",
+			"memory_capacity_gi", "memory_requests_gi", "memory_limits_gi", "memory_usage_gi"})
+	}
+
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	for _, nodeInfo := range stats {
+		// Usage is left empty when metrics are unavailable
+		cpuUsage, memoryUsage := "", ""
 		if nodeInfo.cpuUsage > 0 {
-			cpuUsage = fmt.Sprintf("%.2f (%.0f%%)", nodeInfo.cpuUsage, nodeInfo.cpuUsage*100/nodeInfo.cpuCapacity)
+			cpuUsage = fmt.Sprintf("%.3f", nodeInfo.cpuUsage)
 		}
 		if nodeInfo.memoryUsage > 0 {
-			memoryUsage = fmt.Sprintf("%.2fGi (%.0f%%)", nodeInfo.memoryUsage, nodeInfo.memoryUsage*100/nodeInfo.memoryCapacity)
+			memoryUsage = fmt.Sprintf("%.3f", nodeInfo.memoryUsage)
 		}
-
-		fmt.Fprintf(w, "%s\t%.2f\t%.2f (%.0f%%)\t%.2f (%.0f%%)\t%s\t%.2fGi\t%.2fGi (%.0f%%)\t%.2fGi (%.0f%%)\t%s\n",
+		w.Write([]string{
+			timestamp,
 			nodeInfo.name,
-			nodeInfo.cpuCapacity,
-			nodeInfo.cpuRequests, nodeInfo.cpuRequests*100/nodeInfo.cpuCapacity,
-			nodeInfo.cpuLimits, nodeInfo.cpuLimits*100/nodeInfo.cpuCapacity,
+			fmt.Sprintf("%.3f", nodeInfo.cpuCapacity),
+			fmt.Sprintf("%.3f", nodeInfo.cpuRequests),
+			fmt.Sprintf("%.3f", nodeInfo.cpuLimits),
 			cpuUsage,
-			nodeInfo.memoryCapacity,
-			nodeInfo.memoryRequests, nodeInfo.memoryRequests*100/nodeInfo.memoryCapacity,
-			nodeInfo.memoryLimits, nodeInfo.memoryLimits*100/nodeInfo.memoryCapacity,
-			memoryUsage)
+			fmt.Sprintf("%.3f", nodeInfo.memoryCapacity),
+			fmt.Sprintf("%.3f", nodeInfo.memoryRequests),
+			fmt.Sprintf("%.3f", nodeInfo.memoryLimits),
+			memoryUsage,
+		})
 	}
-
 	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+
+	if appendTo != "" {
+		fmt.Fprintf(os.Stderr, "Appended %d row(s) to %s\n", len(stats), appendTo)
+	}
 	return nil
 }
 
 type nodeInfo struct {
 	name           string
+	machine        providerid.Node
 	cpuCapacity    float64
 	cpuRequests    float64
 	cpuLimits      float64
@@ -151,3 +315,18 @@ type nodeInfo struct {
 	memoryLimits   float64
 	memoryUsage    float64
 }
+
+// podRequests returns the total CPU (cores) and memory (Gi) requested by the
+// regular containers of a pod.
+func podRequests(pod *corev1.Pod) (float64, float64) {
+	var cpuCores, memoryGi float64
+	for _, container := range pod.Spec.Containers {
+		if cpu, ok := container.Resources.Requests[corev1.ResourceCPU]; ok {
+			cpuCores += float64(cpu.MilliValue()) / 1000
+		}
+		if memory, ok := container.Resources.Requests[corev1.ResourceMemory]; ok {
+			memoryGi += float64(memory.Value()) / (1024 * 1024 * 1024)
+		}
+	}
+	return cpuCores, memoryGi
+}
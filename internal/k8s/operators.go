@@ -0,0 +1,722 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	appsv1 "k8s.io/api/apps/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Labels OLM sets on the Deployments of a ClusterServiceVersion
+const (
+	olmOwnerLabel          = "olm.owner"
+	olmOwnerNamespaceLabel = "olm.owner.namespace"
+)
+
+var csvGVR = schema.GroupVersionResource{
+	Group:    "operators.coreos.com",
+	Version:  "v1alpha1",
+	Resource: "clusterserviceversions",
+}
+
+// Conditions of a custom resource that should be True once its operator has
+// reconciled it, and ones that report a failure when True
+var (
+	operatorReadyConditions   = []string{"Ready", "Available", "Synced", "Healthy", "Reconciled"}
+	operatorFailureConditions = []string{"Stalled", "Degraded", "Failed", "ReconcileError"}
+)
+
+// klogErrorLine matches the E and F prefixes of klog's text format
+var klogErrorLine = regexp.MustCompile(`^[EF]\d{4} `)
+
+// OperatorsOptions contains options for the operator health report
+type OperatorsOptions struct {
+	Namespace  string
+	Operator   string        // Only operators whose name contains this
+	LogWindow  time.Duration // How far back to read the leader's logs, 0 to skip them
+	StuckAfter time.Duration // How long a custom resource may stay failing before it counts as stuck
+	Output     string        // table or json
+	FailOn     string        // Lowest severity that fails the run: error, warning, info or none
+}
+
+// operatorInfo is one operator Deployment and what was found about it
+type operatorInfo struct {
+	deployment appsv1.Deployment
+	foundBy    []string // OLM, Helm CRDs, lease, leader-elect flag or name
+	csv        string   // namespace/name of the OLM ClusterServiceVersion
+	csvPhase   string
+	ownedCRDs  []string // CRD names the CSV owns
+	pods       []corev1.Pod
+	restarts   int32
+	lastReason string // Last termination reason of a restarted container
+	lease      *coordinationv1.Lease
+	leader     string // Pod holding the lease
+
+	logErrors       int
+	reconcileErrors int
+	lastError       string
+	logsRead        bool
+	metricsErrors   float64
+	metricsTotal    float64
+	metricsRead     bool
+
+	crds     []crdInfo
+	checked  int // Custom resources checked
+	stuck    []stuckResource
+	findings []result.Finding
+}
+
+func (o *operatorInfo) key() string {
+	return o.deployment.Namespace + "/" + o.deployment.Name
+}
+
+// stuckResource is a custom resource whose operator hasn't managed to
+// reconcile it for a while
+type stuckResource struct {
+	resource  string // Kind namespace/name
+	condition string
+	since     string
+	message   string
+}
+
+// CheckOperators finds the operator Deployments in the cluster, from OLM
+// labels, Helm releases that also install CRDs, leader election leases and
+// their flags, and answers whether each one is actually running: its
+// replicas and restarts, who holds its leader lease and whether the lease
+// is being renewed, the errors in the leader's recent logs and its
+// controller-runtime reconcile error counters when the metrics port is
+// reachable, and the custom resources of its CRDs that have been failing
+// for longer than StuckAfter.
+func CheckOperators(options OperatorsOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	dynamicClient, err := common.GetDynamicClient()
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	ctx := context.TODO()
+
+	deployments, err := clientset.AppsV1().Deployments(options.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list deployments: %w", err)
+	}
+	pods, err := clientset.CoreV1().Pods(options.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	leases, err := clientset.CoordinationV1().Leases(options.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list leases: %w", err)
+	}
+	var crds []crdInfo
+	helmCRDs := make(map[string][]string) // release namespace/name -> CRD names
+	if list, err := dynamicClient.Resource(crdGVR).List(ctx, metav1.ListOptions{}); err == nil {
+		for _, item := range list.Items {
+			crds = append(crds, parseCRD(item))
+			if release := item.GetAnnotations()[helmReleaseAnnotation]; release != "" {
+				key := item.GetAnnotations()[helmNamespaceAnnotation] + "/" + release
+				helmCRDs[key] = append(helmCRDs[key], item.GetName())
+			}
+		}
+	}
+
+	operators := findOperators(deployments.Items, pods.Items, leases.Items, helmCRDs)
+	var selected []*operatorInfo
+	for _, operator := range operators {
+		if options.Operator == "" || strings.Contains(operator.deployment.Name, options.Operator) {
+			selected = append(selected, operator)
+		}
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("no operator deployments found")
+	}
+	for _, operator := range selected {
+		if name := operator.deployment.Labels[olmOwnerLabel]; name != "" {
+			readCSV(dynamicClient, operator, name)
+		}
+	}
+	assignCRDs(selected, crds, helmCRDs)
+
+	if options.Output != "json" {
+		fmt.Printf("Checking %d operators...\n", len(selected))
+	}
+	semaphore := make(chan struct{}, 8)
+	var wg sync.WaitGroup
+	for _, operator := range selected {
+		wg.Add(1)
+		go func(operator *operatorInfo) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			if options.LogWindow > 0 {
+				readOperatorLogs(clientset, operator, options.LogWindow)
+			}
+			readOperatorMetrics(clientset, operator)
+			findStuckResources(dynamicClient, operator, options.StuckAfter)
+		}(operator)
+	}
+	wg.Wait()
+
+	var findings []result.Finding
+	for _, operator := range selected {
+		evaluateOperator(operator, options)
+		findings = append(findings, operator.findings...)
+	}
+
+	if options.Output == "json" {
+		if err := result.New("operators", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tOPERATOR\tFOUND BY\tREADY\tRESTARTS\tLEADER\tLOG ERRORS\tRECONCILE ERRORS\tCRS\tSTUCK\tSTATUS")
+	down, stuckTotal := 0, 0
+	for _, operator := range selected {
+		desired := int32(1)
+		if operator.deployment.Spec.Replicas != nil {
+			desired = *operator.deployment.Spec.Replicas
+		}
+		leader := "-"
+		if operator.lease != nil {
+			leader = operator.leader
+			if renew := operator.lease.Spec.RenewTime; renew != nil {
+				leader += fmt.Sprintf(" (%s)", formatAge(renew.UTC().Format(time.RFC3339)))
+			}
+		}
+		logErrors := "-"
+		if operator.logsRead {
+			logErrors = fmt.Sprintf("%d in %s", operator.logErrors, options.LogWindow)
+		}
+		reconcileErrors := "-"
+		if operator.metricsRead {
+			reconcileErrors = fmt.Sprintf("%.0f of %.0f", operator.metricsErrors, operator.metricsTotal)
+		}
+		status, highest := "✅ running", ""
+		for _, finding := range operator.findings {
+			if result.Rank(finding.Severity) > result.Rank(highest) {
+				highest = finding.Severity
+			}
+		}
+		if highest != "" {
+			status = result.Label(highest)
+		}
+		if operator.deployment.Status.ReadyReplicas == 0 {
+			down++
+		}
+		stuckTotal += len(operator.stuck)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d/%d\t%d\t%s\t%s\t%s\t%d\t%d\t%s\n", operator.deployment.Namespace, operator.deployment.Name,
+			strings.Join(operator.foundBy, ", "), operator.deployment.Status.ReadyReplicas, desired, operator.restarts,
+			leader, logErrors, reconcileErrors, operator.checked, len(operator.stuck), status)
+	}
+	w.Flush()
+
+	if stuckTotal > 0 {
+		fmt.Println("\nStuck custom resources:")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "OPERATOR\tRESOURCE\tCONDITION\tSINCE\tMESSAGE")
+		for _, operator := range selected {
+			for _, stuck := range operator.stuck {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", operator.deployment.Name, stuck.resource, stuck.condition,
+					formatAge(stuck.since), valueOrDash(truncateMessage(stuck.message, 80)))
+			}
+		}
+		w.Flush()
+	}
+
+	counts := make(map[string]int)
+	if len(findings) > 0 {
+		fmt.Println("\nFindings:")
+		for _, finding := range findings {
+			counts[finding.Severity]++
+			if finding.Check == "stuck-resource" {
+				continue
+			}
+			fmt.Printf("  %s %s: %s\n", result.Label(finding.Severity), finding.Resource, finding.Message)
+		}
+	}
+
+	fmt.Println("\n--- Operators Summary ---")
+	fmt.Printf("Operators: %d, without ready replicas: %d\n", len(selected), down)
+	fmt.Printf("Stuck custom resources: %d (failing for longer than %s)\n", stuckTotal, options.StuckAfter)
+	fmt.Printf("Findings: %d errors, %d warnings, %d info\n", counts[result.SeverityError], counts[result.SeverityWarning], counts[result.SeverityInfo])
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// findOperators picks the operator Deployments out of all Deployments: the
+// ones OLM installed, the ones whose Helm release also installs CRDs, the
+// ones holding a leader election lease or started with a leader election
+// flag, and ones named like an operator. Each gets its pods and the lease it
+// holds.
+func findOperators(deployments []appsv1.Deployment, pods []corev1.Pod, leases []coordinationv1.Lease, helmCRDs map[string][]string) []*operatorInfo {
+	byKey := make(map[string]*operatorInfo)
+	podsByKey := make(map[string]corev1.Pod)
+	var all []*operatorInfo
+	for _, deployment := range deployments {
+		operator := &operatorInfo{deployment: deployment}
+		if selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector); err == nil && !selector.Empty() {
+			for _, pod := range pods {
+				if pod.Namespace == deployment.Namespace && selector.Matches(labels.Set(pod.Labels)) {
+					operator.pods = append(operator.pods, pod)
+				}
+			}
+		}
+		byKey[operator.key()] = operator
+		all = append(all, operator)
+	}
+	for _, pod := range pods {
+		podsByKey[pod.Namespace+"/"+pod.Name] = pod
+	}
+
+	// The holder identity of a lease is the pod's hostname, optionally
+	// followed by an underscore and a random id
+	for i := range leases {
+		lease := &leases[i]
+		if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+			continue
+		}
+		holder := strings.SplitN(*lease.Spec.HolderIdentity, "_", 2)[0]
+		var operator *operatorInfo
+		if pod, ok := podsByKey[lease.Namespace+"/"+holder]; ok {
+			for _, candidate := range all {
+				if candidate.deployment.Namespace == pod.Namespace && containsPod(candidate.pods, pod.Name) {
+					operator = candidate
+					break
+				}
+			}
+		} else if parts := strings.Split(holder, "-"); len(parts) > 2 {
+			// The pod is gone, guess the Deployment from the pod name's
+			// ReplicaSet and pod suffixes
+			operator = byKey[lease.Namespace+"/"+strings.Join(parts[:len(parts)-2], "-")]
+		}
+		if operator == nil {
+			continue
+		}
+		if operator.lease == nil || renewedAfter(lease, operator.lease) {
+			operator.lease, operator.leader = lease, holder
+		}
+	}
+
+	var operators []*operatorInfo
+	for _, operator := range all {
+		deployment := operator.deployment
+		if deployment.Labels[olmOwnerLabel] != "" {
+			operator.foundBy = append(operator.foundBy, "OLM")
+		}
+		if release := deployment.Annotations[helmReleaseAnnotation]; release != "" {
+			if len(helmCRDs[deployment.Annotations[helmNamespaceAnnotation]+"/"+release]) > 0 {
+				operator.foundBy = append(operator.foundBy, "Helm CRDs")
+			}
+		}
+		if operator.lease != nil {
+			operator.foundBy = append(operator.foundBy, "lease")
+		} else if hasLeaderElectFlag(deployment.Spec.Template.Spec) {
+			operator.foundBy = append(operator.foundBy, "leader-elect flag")
+		}
+		if len(operator.foundBy) == 0 && strings.Contains(deployment.Name, "operator") {
+			operator.foundBy = append(operator.foundBy, "name")
+		}
+		if len(operator.foundBy) == 0 {
+			continue
+		}
+		for _, pod := range operator.pods {
+			for _, status := range pod.Status.ContainerStatuses {
+				operator.restarts += status.RestartCount
+				if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.Reason != "" {
+					operator.lastReason = terminated.Reason
+				}
+			}
+		}
+		operators = append(operators, operator)
+	}
+	sort.Slice(operators, func(i, j int) bool { return operators[i].key() < operators[j].key() })
+	return operators
+}
+
+func containsPod(pods []corev1.Pod, name string) bool {
+	for _, pod := range pods {
+		if pod.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// renewedAfter reports whether lease a was renewed more recently than b.
+func renewedAfter(a, b *coordinationv1.Lease) bool {
+	if a.Spec.RenewTime == nil {
+		return false
+	}
+	return b.Spec.RenewTime == nil || a.Spec.RenewTime.After(b.Spec.RenewTime.Time)
+}
+
+// hasLeaderElectFlag reports whether a container is started with leader
+// election turned on, as controller-runtime and client-go based operators
+// are.
+func hasLeaderElectFlag(spec corev1.PodSpec) bool {
+	for _, container := range spec.Containers {
+		for _, arg := range append(append([]string{}, container.Command...), container.Args...) {
+			if strings.Contains(arg, "leader-elect") && !strings.HasSuffix(arg, "=false") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readCSV reads the phase and owned CRDs of the ClusterServiceVersion that
+// installed an OLM operator.
+func readCSV(dynamicClient dynamic.Interface, operator *operatorInfo, name string) {
+	namespace := operator.deployment.Labels[olmOwnerNamespaceLabel]
+	if namespace == "" {
+		namespace = operator.deployment.Namespace
+	}
+	operator.csv = namespace + "/" + name
+	csv, err := dynamicClient.Resource(csvGVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		operator.csvPhase = "Unknown"
+		return
+	}
+	operator.csvPhase, _, _ = unstructured.NestedString(csv.Object, "status", "phase")
+	owned, _, _ := unstructured.NestedSlice(csv.Object, "spec", "customresourcedefinitions", "owned")
+	for _, raw := range owned {
+		if crd, ok := raw.(map[string]interface{}); ok {
+			if crdName, _ := crd["name"].(string); crdName != "" {
+				operator.ownedCRDs = append(operator.ownedCRDs, crdName)
+			}
+		}
+	}
+}
+
+// assignCRDs gives every CRD to the operator most likely to reconcile it:
+// the CSV that owns it, else a Deployment of the Helm release that
+// installed it, else a Deployment or namespace named after the first label
+// of its API group. Ties go to the shortest Deployment name, so
+// cert-manager wins over cert-manager-cainjector.
+func assignCRDs(operators []*operatorInfo, crds []crdInfo, helmCRDs map[string][]string) {
+	for _, crd := range crds {
+		label := strings.SplitN(crd.group, ".", 2)[0]
+		var best *operatorInfo
+		bestScore := 0
+		for _, operator := range operators {
+			deployment := operator.deployment
+			score := 0
+			switch {
+			case containsString(operator.ownedCRDs, crd.name):
+				score = 4
+			case deployment.Annotations[helmReleaseAnnotation] != "" &&
+				containsString(helmCRDs[deployment.Annotations[helmNamespaceAnnotation]+"/"+deployment.Annotations[helmReleaseAnnotation]], crd.name):
+				score = 3
+			case len(label) >= 4 && (deployment.Name == label || deployment.Namespace == label):
+				score = 2
+			case len(label) >= 4 && strings.Contains(deployment.Name, label):
+				score = 1
+			}
+			if score > bestScore || (score == bestScore && score > 0 && len(deployment.Name) < len(best.deployment.Name)) {
+				best, bestScore = operator, score
+			}
+		}
+		if best != nil {
+			best.crds = append(best.crds, crd)
+		}
+	}
+}
+
+// readOperatorLogs counts the error lines in the last window of the
+// leader's logs, or of the first running pod's when there is no lease, and
+// keeps the last error message.
+func readOperatorLogs(clientset *kubernetes.Clientset, operator *operatorInfo, window time.Duration) {
+	pod := operatorPod(operator)
+	if pod == nil {
+		return
+	}
+	container := pod.Spec.Containers[0].Name
+	for _, c := range pod.Spec.Containers {
+		if c.Name == "manager" || (container == "kube-rbac-proxy" && c.Name != "kube-rbac-proxy") {
+			container = c.Name
+		}
+	}
+	since := int64(window.Seconds())
+	limit := int64(10 * 1024 * 1024)
+	logs, err := clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container:    container,
+		SinceSeconds: &since,
+		LimitBytes:   &limit,
+	}).DoRaw(context.TODO())
+	if err != nil {
+		return
+	}
+	operator.logsRead = true
+	for _, line := range strings.Split(string(logs), "\n") {
+		message, isError := parseErrorLogLine(line)
+		if !isError {
+			continue
+		}
+		operator.logErrors++
+		if strings.Contains(strings.ToLower(line), "reconcil") {
+			operator.reconcileErrors++
+		}
+		operator.lastError = message
+	}
+}
+
+// operatorPod returns the pod holding the operator's lease, or else its
+// first running pod.
+func operatorPod(operator *operatorInfo) *corev1.Pod {
+	for i := range operator.pods {
+		if operator.pods[i].Name == operator.leader {
+			return &operator.pods[i]
+		}
+	}
+	for i := range operator.pods {
+		if operator.pods[i].Status.Phase == corev1.PodRunning {
+			return &operator.pods[i]
+		}
+	}
+	return nil
+}
+
+// parseErrorLogLine reports whether a log line is an error in the JSON,
+// logfmt, zap console or klog format, and returns its message.
+func parseErrorLogLine(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "{") {
+		var entry map[string]interface{}
+		if json.Unmarshal([]byte(line), &entry) != nil {
+			return "", false
+		}
+		level, _ := entry["level"].(string)
+		if level == "" {
+			level, _ = entry["severity"].(string)
+		}
+		switch strings.ToLower(level) {
+		case "error", "fatal", "panic", "dpanic", "critical":
+		default:
+			return "", false
+		}
+		message, _ := entry["msg"].(string)
+		if message == "" {
+			message, _ = entry["message"].(string)
+		}
+		if cause, _ := entry["error"].(string); cause != "" {
+			message += ": " + cause
+		}
+		return message, true
+	}
+	if strings.Contains(line, "\tERROR\t") || strings.Contains(line, "level=error") || klogErrorLine.MatchString(line) {
+		return line, true
+	}
+	return "", false
+}
+
+// readOperatorMetrics reads the controller-runtime reconcile counters from
+// the metrics port of the operator's pod through the API server proxy. Most
+// operators serve them on a port named metrics or on 8080, those that bind
+// it to localhost behind kube-rbac-proxy can't be read this way.
+func readOperatorMetrics(clientset *kubernetes.Clientset, operator *operatorInfo) {
+	pod := operatorPod(operator)
+	if pod == nil {
+		return
+	}
+	port := "8080"
+	for _, container := range pod.Spec.Containers {
+		for _, p := range container.Ports {
+			if strings.Contains(p.Name, "metrics") && !strings.Contains(p.Name, "https") {
+				port = strconv.Itoa(int(p.ContainerPort))
+			}
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	metrics, err := clientset.CoreV1().Pods(pod.Namespace).ProxyGet("http", pod.Name, port, "/metrics", nil).DoRaw(ctx)
+	if err != nil || !strings.Contains(string(metrics), "controller_runtime_reconcile_total") {
+		return
+	}
+	operator.metricsRead = true
+	operator.metricsErrors = sumPrometheusMetric(string(metrics), "controller_runtime_reconcile_errors_total", "")
+	operator.metricsTotal = sumPrometheusMetric(string(metrics), "controller_runtime_reconcile_total", "")
+}
+
+// findStuckResources checks every custom resource of the operator's CRDs
+// for a readiness condition that isn't True, a failure condition that is,
+// or a deletion blocked on finalizers, lasting longer than stuckAfter.
+func findStuckResources(dynamicClient dynamic.Interface, operator *operatorInfo, stuckAfter time.Duration) {
+	for _, crd := range operator.crds {
+		version, err := crdBrowseVersion(crd, "")
+		if err != nil {
+			continue
+		}
+		list, err := crdResource(dynamicClient, crd, version, "").List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			continue
+		}
+		for _, item := range list.Items {
+			operator.checked++
+			name := item.GetName()
+			if item.GetNamespace() != "" {
+				name = item.GetNamespace() + "/" + name
+			}
+			if stuck, ok := stuckCondition(item, stuckAfter); ok {
+				stuck.resource = crd.kind + " " + name
+				operator.stuck = append(operator.stuck, stuck)
+			}
+		}
+	}
+}
+
+// stuckCondition returns the condition a custom resource has been stuck in
+// for longer than stuckAfter, if any.
+func stuckCondition(item unstructured.Unstructured, stuckAfter time.Duration) (stuckResource, bool) {
+	if deleted := item.GetDeletionTimestamp(); deleted != nil && len(item.GetFinalizers()) > 0 && time.Since(deleted.Time) >= stuckAfter {
+		return stuckResource{
+			condition: "Terminating",
+			since:     deleted.UTC().Format(time.RFC3339),
+			message:   "waiting on finalizers " + strings.Join(item.GetFinalizers(), ", "),
+		}, true
+	}
+	conditions, _, _ := unstructured.NestedSlice(item.Object, "status", "conditions")
+	for _, raw := range conditions {
+		condition, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		conditionType, _ := condition["type"].(string)
+		status, _ := condition["status"].(string)
+		failing := (containsString(operatorReadyConditions, conditionType) && status != "True") ||
+			(containsString(operatorFailureConditions, conditionType) && status == "True")
+		if !failing {
+			continue
+		}
+		since, _ := condition["lastTransitionTime"].(string)
+		if since == "" {
+			since = item.GetCreationTimestamp().UTC().Format(time.RFC3339)
+		}
+		if t, err := time.Parse(time.RFC3339, since); err != nil || time.Since(t) < stuckAfter {
+			continue
+		}
+		reason, _ := condition["reason"].(string)
+		message, _ := condition["message"].(string)
+		if reason != "" {
+			message = strings.TrimSpace(reason + ": " + message)
+		}
+		return stuckResource{condition: conditionType + "=" + status, since: since, message: message}, true
+	}
+	return stuckResource{}, false
+}
+
+// evaluateOperator turns what was found about an operator into findings.
+func evaluateOperator(operator *operatorInfo, options OperatorsOptions) {
+	deployment := operator.deployment
+	resource := "Deployment/" + operator.key()
+	add := func(check, severity, message string, details map[string]string) {
+		operator.findings = append(operator.findings, result.Finding{
+			Check: check, Severity: severity, Resource: resource, Message: message, Details: details,
+		})
+	}
+
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	switch {
+	case desired == 0:
+		add("operator-down", result.SeverityWarning, "scaled to 0 replicas, nothing reconciles its resources", nil)
+	case deployment.Status.ReadyReplicas == 0:
+		message := fmt.Sprintf("0 of %d replicas ready", desired)
+		if operator.lastReason != "" {
+			message += ", last container termination: " + operator.lastReason
+		}
+		add("operator-down", result.SeverityError, message, nil)
+	}
+	if operator.csv != "" && operator.csvPhase != "Succeeded" {
+		add("operator-csv", result.SeverityError, fmt.Sprintf("ClusterServiceVersion %s is in phase %s", operator.csv, valueOrDash(operator.csvPhase)),
+			map[string]string{"csv": operator.csv, "phase": operator.csvPhase})
+	}
+	if operator.restarts >= 5 {
+		message := fmt.Sprintf("containers restarted %d times", operator.restarts)
+		if operator.lastReason != "" {
+			message += ", last because of " + operator.lastReason
+		}
+		add("operator-restarts", result.SeverityWarning, message, nil)
+	}
+
+	if lease := operator.lease; lease != nil && lease.Spec.RenewTime != nil {
+		duration := 15 * time.Second
+		if lease.Spec.LeaseDurationSeconds != nil {
+			duration = time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+		}
+		expired := lease.Spec.RenewTime.Add(duration)
+		details := map[string]string{"lease": lease.Namespace + "/" + lease.Name, "holder": operator.leader}
+		switch {
+		case time.Now().After(expired):
+			add("leader-stale", result.SeverityError, fmt.Sprintf("leader lease %s expired %s, last held by %s",
+				lease.Name, formatAge(expired.UTC().Format(time.RFC3339)), operator.leader), details)
+		case !containsPod(operator.pods, operator.leader):
+			add("leader-stale", result.SeverityWarning, fmt.Sprintf("leader lease %s is held by %s, which no longer exists",
+				lease.Name, operator.leader), details)
+		}
+	}
+
+	if operator.logErrors > 0 {
+		message := fmt.Sprintf("%d error lines in the last %s", operator.logErrors, options.LogWindow)
+		if operator.reconcileErrors > 0 {
+			message += fmt.Sprintf(", %d of them reconcile errors", operator.reconcileErrors)
+		}
+		if operator.lastError != "" {
+			message += ", last: " + truncateMessage(operator.lastError, 120)
+		}
+		add("reconcile-errors", result.SeverityWarning, message,
+			map[string]string{"errors": strconv.Itoa(operator.logErrors), "window": options.LogWindow.String()})
+	}
+	if operator.metricsRead && operator.metricsTotal >= 10 && operator.metricsErrors/operator.metricsTotal > 0.1 {
+		add("reconcile-errors", result.SeverityWarning, fmt.Sprintf("%.0f of %.0f reconciles failed since the pod started (%.0f%%)",
+			operator.metricsErrors, operator.metricsTotal, 100*operator.metricsErrors/operator.metricsTotal), nil)
+	}
+
+	for _, stuck := range operator.stuck {
+		operator.findings = append(operator.findings, result.Finding{
+			Check:    "stuck-resource",
+			Severity: result.SeverityWarning,
+			Resource: stuck.resource,
+			Message:  fmt.Sprintf("%s since %s: %s", stuck.condition, formatAge(stuck.since), valueOrDash(stuck.message)),
+			Details:  map[string]string{"operator": operator.key(), "condition": stuck.condition, "since": stuck.since},
+		})
+	}
+}
+
+// truncateMessage shortens a message to n characters.
+func truncateMessage(message string, n int) string {
+	message = strings.Join(strings.Fields(message), " ")
+	if len(message) <= n {
+		return message
+	}
+	return message[:n-3] + "..."
+}
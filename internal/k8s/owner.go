@@ -0,0 +1,519 @@
+package k8s
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/HighonAces/swissarmycli/internal/config"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
+)
+
+// Ownership annotations and labels set by the GitOps tools and Helm
+const (
+	argoTrackingIDAnnotation = "argocd.argoproj.io/tracking-id"
+	argoInstanceLabel        = "argocd.argoproj.io/instance"
+	helmReleaseAnnotation    = "meta.helm.sh/release-name"
+	helmNamespaceAnnotation  = "meta.helm.sh/release-namespace"
+	fluxKustomizationLabel   = "kustomize.toolkit.fluxcd.io/name"
+	fluxKustomizationNSLabel = "kustomize.toolkit.fluxcd.io/namespace"
+	fluxHelmReleaseLabel     = "helm.toolkit.fluxcd.io/name"
+	fluxHelmReleaseNSLabel   = "helm.toolkit.fluxcd.io/namespace"
+)
+
+// maxOwnerDepth bounds the walk up the controller chain
+const maxOwnerDepth = 5
+
+// OwnerOptions contains options for looking up who owns a resource
+type OwnerOptions struct {
+	Namespace string
+}
+
+// ownershipSignal is one annotation or label that tells who manages or owns
+// a resource
+type ownershipSignal struct {
+	source string // Argo CD, Flux, Helm or team
+	object string // Kind/name the key was found on
+	key    string
+	value  string
+}
+
+// ownerManager is the Argo CD Application, Flux Kustomization or
+// HelmRelease, or Helm release, that deploys a resource, and where it
+// deploys it from
+type ownerManager struct {
+	tool     string // Argo CD, Flux or Helm
+	name     string // namespace/name of the Application, Kustomization, HelmRelease or Helm release
+	project  string // Argo CD project
+	repo     string
+	path     string // Path in the repo, or the chart
+	revision string
+	contacts []string // Helm chart maintainers
+	note     string   // Why the details could not be read
+	object   *unstructured.Unstructured
+}
+
+// ownerLookup holds the clients a lookup needs
+type ownerLookup struct {
+	clientset *kubernetes.Clientset
+	dynamic   dynamic.Interface
+	mapper    meta.RESTMapper
+}
+
+// FindOwner answers "who do I page about this": it walks a resource up its
+// controllers, reads the ownership annotations and labels of Argo CD, Flux
+// and Helm, follows them to the Application, Kustomization, HelmRelease or
+// Helm release and its repository, and finds the team from the team labels
+// of the config file on the resource, its controllers, its GitOps object or
+// its namespace.
+func FindOwner(args []string, options OwnerOptions) error {
+	kind, name, err := parseOwnerArgs(args)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	ownership, err := cfg.Ownership()
+	if err != nil {
+		return err
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	dynamicClient, err := common.GetDynamicClient()
+	if err != nil {
+		return err
+	}
+	discoveryClient := memory.NewMemCacheClient(clientset.Discovery())
+	lookup := &ownerLookup{
+		clientset: clientset,
+		dynamic:   dynamicClient,
+		mapper: restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(discoveryClient),
+			discoveryClient, func(string) {}),
+	}
+
+	gvr, err := lookup.mapper.ResourceFor(schema.ParseGroupResource(kind).WithVersion(""))
+	if err != nil {
+		return fmt.Errorf("unknown resource type '%s': %w", kind, err)
+	}
+	gvk, err := lookup.mapper.KindFor(gvr)
+	if err != nil {
+		return fmt.Errorf("unknown resource type '%s': %w", kind, err)
+	}
+	object, err := lookup.get(gvk, options.Namespace, name)
+	if err != nil {
+		return err
+	}
+
+	// The resource first, then its controllers up to the top one
+	chain := []*unstructured.Unstructured{object}
+	for len(chain) < maxOwnerDepth {
+		controller := metav1.GetControllerOf(chain[len(chain)-1])
+		if controller == nil {
+			break
+		}
+		parent, err := lookup.get(schema.FromAPIVersionAndKind(controller.APIVersion, controller.Kind), object.GetNamespace(), controller.Name)
+		if err != nil {
+			fmt.Printf("⚠️  Could not follow controller %s/%s: %v\n", controller.Kind, controller.Name, err)
+			break
+		}
+		chain = append(chain, parent)
+	}
+
+	var signals []ownershipSignal
+	var managers []ownerManager
+	seenManagers := make(map[string]bool)
+	addManager := func(manager ownerManager) {
+		if key := manager.tool + "/" + manager.name; !seenManagers[key] {
+			seenManagers[key] = true
+			managers = append(managers, manager)
+		}
+	}
+	for _, obj := range chain {
+		objectName := obj.GetKind() + "/" + obj.GetName()
+		annotations, objectLabels := obj.GetAnnotations(), obj.GetLabels()
+		if trackingID := annotations[argoTrackingIDAnnotation]; trackingID != "" {
+			signals = append(signals, ownershipSignal{"Argo CD", objectName, argoTrackingIDAnnotation, trackingID})
+			appName, _, _ := strings.Cut(trackingID, ":")
+			addManager(lookup.argoApplication(appName))
+		} else if instance := objectLabels[argoInstanceLabel]; instance != "" {
+			signals = append(signals, ownershipSignal{"Argo CD", objectName, argoInstanceLabel, instance})
+			addManager(lookup.argoApplication(instance))
+		}
+		if kustomization := objectLabels[fluxKustomizationLabel]; kustomization != "" {
+			signals = append(signals, ownershipSignal{"Flux", objectName, fluxKustomizationLabel, kustomization})
+			addManager(lookup.fluxKustomization(objectLabels[fluxKustomizationNSLabel], kustomization))
+		}
+		if release := objectLabels[fluxHelmReleaseLabel]; release != "" {
+			signals = append(signals, ownershipSignal{"Flux", objectName, fluxHelmReleaseLabel, release})
+			addManager(lookup.fluxHelmRelease(objectLabels[fluxHelmReleaseNSLabel], release))
+		}
+		if release := annotations[helmReleaseAnnotation]; release != "" {
+			signals = append(signals, ownershipSignal{"Helm", objectName, helmReleaseAnnotation, release})
+			releaseNamespace := annotations[helmNamespaceAnnotation]
+			if releaseNamespace == "" {
+				releaseNamespace = obj.GetNamespace()
+			}
+			addManager(lookup.helmRelease(releaseNamespace, release))
+		}
+	}
+
+	// Teams are looked up from the most specific object to the least
+	teamObjects := append([]*unstructured.Unstructured{}, chain...)
+	for _, manager := range managers {
+		if manager.object != nil {
+			teamObjects = append(teamObjects, manager.object)
+		}
+	}
+	if namespace := object.GetNamespace(); namespace != "" {
+		ns, err := clientset.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
+		if err == nil {
+			nsObject := &unstructured.Unstructured{}
+			nsObject.SetKind("Namespace")
+			nsObject.SetName(ns.Name)
+			nsObject.SetLabels(ns.Labels)
+			nsObject.SetAnnotations(ns.Annotations)
+			teamObjects = append(teamObjects, nsObject)
+		}
+	}
+	team, teamSource := "", ""
+	for _, obj := range teamObjects {
+		for _, key := range ownership.Labels() {
+			value := obj.GetLabels()[key]
+			if value == "" {
+				value = obj.GetAnnotations()[key]
+			}
+			if value == "" {
+				continue
+			}
+			objectName := obj.GetKind() + "/" + obj.GetName()
+			signals = append(signals, ownershipSignal{"team", objectName, key, value})
+			if team == "" {
+				team, teamSource = value, fmt.Sprintf("%s on %s", key, objectName)
+			}
+		}
+	}
+
+	resource := object.GetKind() + "/" + object.GetName()
+	if object.GetNamespace() != "" {
+		resource = object.GetNamespace() + "/" + resource
+	}
+	fmt.Printf("Resource: %s\n", resource)
+	if len(chain) > 1 {
+		var controllers []string
+		for _, obj := range chain[1:] {
+			controllers = append(controllers, obj.GetKind()+"/"+obj.GetName())
+		}
+		fmt.Printf("Controlled by: %s\n", strings.Join(controllers, " → "))
+	}
+
+	if len(signals) > 0 {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SOURCE\tFOUND ON\tKEY\tVALUE")
+		for _, signal := range signals {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", signal.source, signal.object, signal.key, signal.value)
+		}
+		w.Flush()
+	}
+
+	fmt.Println("\n--- Owner Summary ---")
+	if len(managers) == 0 {
+		fmt.Println("Managed by: ⚠️  no Argo CD, Flux or Helm markers; the resource was probably applied by hand")
+	}
+	for _, manager := range managers {
+		fmt.Printf("Managed by: %s %s\n", manager.tool, manager.name)
+		if manager.project != "" {
+			fmt.Printf("   Project:    %s\n", manager.project)
+		}
+		if manager.repo != "" {
+			fmt.Printf("   Repository: %s\n", manager.repo)
+		}
+		if manager.path != "" {
+			fmt.Printf("   Path:       %s\n", manager.path)
+		}
+		if manager.revision != "" {
+			fmt.Printf("   Revision:   %s\n", manager.revision)
+		}
+		if len(manager.contacts) > 0 {
+			fmt.Printf("   Maintainers: %s\n", strings.Join(manager.contacts, ", "))
+		}
+		if manager.note != "" {
+			fmt.Printf("   ⚠️  %s\n", manager.note)
+		}
+	}
+	if team == "" {
+		fmt.Printf("Team: ⚠️  none of the labels %s is set on the resource, its controllers, its GitOps object or its namespace\n",
+			strings.Join(ownership.Labels(), ", "))
+	} else {
+		fmt.Printf("Team: %s (%s)\n", team, teamSource)
+		if contact := ownership.Contacts[team]; contact != "" {
+			fmt.Printf("Contact: %s\n", contact)
+		} else if len(ownership.Contacts) > 0 {
+			fmt.Printf("Contact: ⚠️  team %s has no entry under ownership.contacts in %s\n", team, config.Path())
+		}
+	}
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// parseOwnerArgs accepts a resource as kind/name or as kind and name, like
+// kubectl.
+func parseOwnerArgs(args []string) (string, string, error) {
+	switch len(args) {
+	case 1:
+		kind, name, ok := strings.Cut(args[0], "/")
+		if ok && kind != "" && name != "" {
+			return kind, name, nil
+		}
+	case 2:
+		return args[0], args[1], nil
+	}
+	return "", "", fmt.Errorf("expected a resource as kind/name or kind name, e.g. deployment/api")
+}
+
+// get fetches an object by kind, ignoring the namespace for cluster-scoped
+// kinds.
+func (l *ownerLookup) get(gvk schema.GroupVersionKind, namespace, name string) (*unstructured.Unstructured, error) {
+	var versions []string
+	if gvk.Version != "" {
+		versions = append(versions, gvk.Version)
+	}
+	mapping, err := l.mapper.RESTMapping(gvk.GroupKind(), versions...)
+	if err != nil {
+		return nil, fmt.Errorf("unknown kind %s: %w", gvk.Kind, err)
+	}
+	var resources dynamic.ResourceInterface = l.dynamic.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		resources = l.dynamic.Resource(mapping.Resource).Namespace(namespace)
+	}
+	object, err := resources.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s %s: %w", gvk.Kind, name, err)
+	}
+	return object, nil
+}
+
+// argoApplication reads the Application of an Argo CD tracking ID's app
+// name, which is namespace_name for apps outside the control plane's
+// namespace and just the name otherwise.
+func (l *ownerLookup) argoApplication(appName string) ownerManager {
+	namespace, name, found := strings.Cut(appName, "_")
+	if !found {
+		namespace, name = "", appName
+	}
+	manager := ownerManager{tool: "Argo CD", name: "application " + name}
+	gvk := schema.GroupVersionKind{Group: "argoproj.io", Kind: "Application"}
+	if namespace == "" {
+		// The control plane's namespace is not recorded; find the app by name
+		mapping, err := l.mapper.RESTMapping(gvk.GroupKind())
+		if err != nil {
+			manager.note = "the Application CRD is not installed in this cluster"
+			return manager
+		}
+		apps, err := l.dynamic.Resource(mapping.Resource).Namespace("").List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			manager.note = fmt.Sprintf("could not list Applications: %v", err)
+			return manager
+		}
+		for _, app := range apps.Items {
+			if app.GetName() == name {
+				namespace = app.GetNamespace()
+				break
+			}
+		}
+		if namespace == "" {
+			manager.note = "the Application is not in this cluster; Argo CD probably runs in a management cluster"
+			return manager
+		}
+	}
+	manager.name = "application " + namespace + "/" + name
+	app, err := l.get(gvk, namespace, name)
+	if err != nil {
+		manager.note = err.Error()
+		return manager
+	}
+	manager.object = app
+	manager.project, _, _ = unstructured.NestedString(app.Object, "spec", "project")
+	source, found, _ := unstructured.NestedMap(app.Object, "spec", "source")
+	if !found {
+		// Multi-source apps: the first source holding manifests or a chart
+		if sources, ok, _ := unstructured.NestedSlice(app.Object, "spec", "sources"); ok && len(sources) > 0 {
+			source, _ = sources[0].(map[string]interface{})
+		}
+	}
+	manager.repo, _, _ = unstructured.NestedString(source, "repoURL")
+	manager.path, _, _ = unstructured.NestedString(source, "path")
+	if chart, _, _ := unstructured.NestedString(source, "chart"); chart != "" {
+		manager.path = "chart " + chart
+	}
+	manager.revision, _, _ = unstructured.NestedString(source, "targetRevision")
+	return manager
+}
+
+// fluxKustomization reads a Flux Kustomization and the source it applies.
+func (l *ownerLookup) fluxKustomization(namespace, name string) ownerManager {
+	manager := ownerManager{tool: "Flux", name: "kustomization " + namespace + "/" + name}
+	kustomization, err := l.get(schema.GroupVersionKind{Group: "kustomize.toolkit.fluxcd.io", Kind: "Kustomization"}, namespace, name)
+	if err != nil {
+		manager.note = err.Error()
+		return manager
+	}
+	manager.object = kustomization
+	manager.path, _, _ = unstructured.NestedString(kustomization.Object, "spec", "path")
+	sourceRef, _, _ := unstructured.NestedMap(kustomization.Object, "spec", "sourceRef")
+	l.readFluxSource(&manager, sourceRef, namespace)
+	return manager
+}
+
+// fluxHelmRelease reads a Flux HelmRelease, its chart and the repository
+// the chart comes from.
+func (l *ownerLookup) fluxHelmRelease(namespace, name string) ownerManager {
+	manager := ownerManager{tool: "Flux", name: "helmrelease " + namespace + "/" + name}
+	release, err := l.get(schema.GroupVersionKind{Group: "helm.toolkit.fluxcd.io", Kind: "HelmRelease"}, namespace, name)
+	if err != nil {
+		manager.note = err.Error()
+		return manager
+	}
+	manager.object = release
+	if chart, _, _ := unstructured.NestedString(release.Object, "spec", "chart", "spec", "chart"); chart != "" {
+		manager.path = "chart " + chart
+		if version, _, _ := unstructured.NestedString(release.Object, "spec", "chart", "spec", "version"); version != "" {
+			manager.path += " " + version
+		}
+	}
+	sourceRef, found, _ := unstructured.NestedMap(release.Object, "spec", "chart", "spec", "sourceRef")
+	if !found {
+		sourceRef, _, _ = unstructured.NestedMap(release.Object, "spec", "chartRef")
+	}
+	l.readFluxSource(&manager, sourceRef, namespace)
+	return manager
+}
+
+// readFluxSource fills in the URL and revision of a Flux source reference:
+// a GitRepository, OCIRepository, HelmRepository or Bucket.
+func (l *ownerLookup) readFluxSource(manager *ownerManager, sourceRef map[string]interface{}, defaultNamespace string) {
+	kind, _, _ := unstructured.NestedString(sourceRef, "kind")
+	name, _, _ := unstructured.NestedString(sourceRef, "name")
+	namespace, _, _ := unstructured.NestedString(sourceRef, "namespace")
+	if kind == "" || name == "" {
+		return
+	}
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	source, err := l.get(schema.GroupVersionKind{Group: "source.toolkit.fluxcd.io", Kind: kind}, namespace, name)
+	if err != nil {
+		manager.note = err.Error()
+		return
+	}
+	manager.repo, _, _ = unstructured.NestedString(source.Object, "spec", "url")
+	if manager.repo == "" {
+		manager.repo, _, _ = unstructured.NestedString(source.Object, "spec", "bucketName")
+	}
+	ref, _, _ := unstructured.NestedStringMap(source.Object, "spec", "ref")
+	for _, key := range []string{"branch", "tag", "semver", "commit", "name"} {
+		if ref[key] != "" {
+			manager.revision = key + " " + ref[key]
+			break
+		}
+	}
+}
+
+// helmRelease reads the chart of the latest revision of a Helm release from
+// its release Secret.
+func (l *ownerLookup) helmRelease(namespace, name string) ownerManager {
+	manager := ownerManager{tool: "Helm", name: "release " + namespace + "/" + name}
+	secrets, err := l.clientset.CoreV1().Secrets(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: "owner=helm,name=" + name,
+	})
+	if err != nil {
+		manager.note = fmt.Sprintf("could not read the release: %v", err)
+		return manager
+	}
+	if len(secrets.Items) == 0 {
+		manager.note = "no release Secret found; the release may be stored in ConfigMaps or was rendered with helm template"
+		return manager
+	}
+	sort.Slice(secrets.Items, func(i, j int) bool {
+		return secrets.Items[i].CreationTimestamp.After(secrets.Items[j].CreationTimestamp.Time)
+	})
+	chart, err := decodeHelmRelease(secrets.Items[0].Data["release"])
+	if err != nil {
+		manager.note = err.Error()
+		return manager
+	}
+	manager.path = fmt.Sprintf("chart %s %s", chart.Name, chart.Version)
+	if len(chart.Sources) > 0 {
+		manager.repo = chart.Sources[0]
+	} else {
+		manager.repo = chart.Home
+	}
+	for _, maintainer := range chart.Maintainers {
+		contact := maintainer.Name
+		if maintainer.Email != "" {
+			contact += " <" + maintainer.Email + ">"
+		}
+		manager.contacts = append(manager.contacts, contact)
+	}
+	return manager
+}
+
+// helmChartMetadata is the part of a chart's Chart.yaml owner reports
+type helmChartMetadata struct {
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	Home        string   `json:"home"`
+	Sources     []string `json:"sources"`
+	Maintainers []struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"maintainers"`
+}
+
+// decodeHelmRelease reads the chart metadata from a Helm release Secret's
+// data, which is base64 encoded gzipped JSON on top of the Secret's own
+// encoding.
+func decodeHelmRelease(data []byte) (*helmChartMetadata, error) {
+	compressed, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the Helm release: %w", err)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress the Helm release: %w", err)
+	}
+	defer reader.Close()
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress the Helm release: %w", err)
+	}
+	var release struct {
+		Chart struct {
+			Metadata helmChartMetadata `json:"metadata"`
+		} `json:"chart"`
+	}
+	if err := json.Unmarshal(decompressed, &release); err != nil {
+		return nil, fmt.Errorf("failed to parse the Helm release: %w", err)
+	}
+	return &release.Chart.Metadata, nil
+}
@@ -0,0 +1,285 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// patchStatusMaxAMIAge is the AMI age past which a node group is flagged for
+// a roll even when Patch Manager has nothing to report
+const patchStatusMaxAMIAge = 90 * 24 * time.Hour
+
+// Node labels naming the group a node was launched by
+const (
+	eksNodeGroupLabel      = "eks.amazonaws.com/nodegroup"
+	karpenterNodePoolLabel = "karpenter.sh/nodepool"
+	eksctlNodeGroupLabel   = "alpha.eksctl.io/nodegroup-name"
+)
+
+// PatchStatusOptions contains options for the patch compliance report
+type PatchStatusOptions struct {
+	Region   string // Defaults to the region of the cluster's nodes
+	Profile  string
+	Selector string // Only nodes matching this label selector
+}
+
+// nodePatchStatus is the patch and AMI state of one node
+type nodePatchStatus struct {
+	node       string
+	instanceID string
+	group      string
+	groupLabel string // Label the group was read from
+	image      awsutils.InstanceImage
+	os         string
+	kernel     string
+	ssm        *awsutils.SSMInstanceStatus  // Nil when SSM doesn't manage the instance
+	patches    *awsutils.InstancePatchState // Nil when Patch Manager never scanned it
+}
+
+// needsRoll reports whether the node's AMI is missing patches, or has
+// installed patches it hasn't rebooted into.
+func (s nodePatchStatus) needsRoll() bool {
+	return s.patches != nil && !s.patches.Compliant()
+}
+
+// ShowPatchStatus reports the OS patch compliance of every EC2 node from
+// Patch Manager, with its kernel version and the SSM agent's status, and
+// groups the nodes by AMI. EKS nodes are replaced rather than patched in
+// place, so the summary names the node groups whose AMI needs a roll.
+func ShowPatchStatus(options PatchStatusOptions) error {
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{LabelSelector: options.Selector})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	var statuses []nodePatchStatus
+	var instanceIDs []string
+	for _, node := range nodes.Items {
+		instanceID := awsutils.InstanceIDFromProviderID(node.Spec.ProviderID)
+		if instanceID == "" {
+			continue
+		}
+		instanceIDs = append(instanceIDs, instanceID)
+		group, groupLabel := nodeGroupOf(node)
+		statuses = append(statuses, nodePatchStatus{
+			node:       node.Name,
+			instanceID: instanceID,
+			group:      group,
+			groupLabel: groupLabel,
+			os:         node.Status.NodeInfo.OSImage,
+			kernel:     node.Status.NodeInfo.KernelVersion,
+		})
+	}
+	if len(statuses) == 0 {
+		fmt.Println("No EC2 nodes found.")
+		return nil
+	}
+
+	region := options.Region
+	if region == "" {
+		region = nodes.Items[0].Labels["topology.kubernetes.io/region"]
+	}
+	sess, err := awsutils.NewSession(options.Profile, region)
+	if err != nil {
+		return err
+	}
+	images, err := awsutils.GetInstanceImages(sess, instanceIDs)
+	if err != nil {
+		return err
+	}
+	patchStates, err := awsutils.GetInstancePatchStates(sess, instanceIDs)
+	if err != nil {
+		return err
+	}
+	ssmStatuses, err := awsutils.GetSSMInstanceStatus(sess, instanceIDs)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+	}
+	for i := range statuses {
+		status := &statuses[i]
+		status.image = images[status.instanceID]
+		if state, ok := patchStates[status.instanceID]; ok {
+			status.patches = &state
+		}
+		if ssmStatus, ok := ssmStatuses[status.instanceID]; ok {
+			status.ssm = &ssmStatus
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].group != statuses[j].group {
+			return statuses[i].group < statuses[j].group
+		}
+		return statuses[i].node < statuses[j].node
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tGROUP\tAMI\tKERNEL\tSSM\tPATCHES\tLAST SCAN")
+	for _, status := range statuses {
+		ssmText, lastScan := "not managed", "-"
+		if status.ssm != nil {
+			ssmText = status.ssm.PingStatus
+		}
+		if status.patches != nil {
+			lastScan = formatAge(status.patches.OperationEnd.Format(time.RFC3339))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", status.node, status.group, valueOrDash(status.image.ImageID),
+			valueOrDash(status.kernel), ssmText, describePatchState(status.patches), lastScan)
+	}
+	w.Flush()
+
+	byImage := make(map[string][]nodePatchStatus)
+	for _, status := range statuses {
+		byImage[status.image.ImageID] = append(byImage[status.image.ImageID], status)
+	}
+	fmt.Println("\nBy AMI:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "AMI\tNAME\tAGE\tOS\tGROUPS\tNODES\tNON-COMPLIANT\tKERNELS")
+	for _, imageID := range sortedKeys(byImage) {
+		imageStatuses := byImage[imageID]
+		image := imageStatuses[0].image
+		groups, kernels := make(map[string]bool), make(map[string]bool)
+		nonCompliant := 0
+		for _, status := range imageStatuses {
+			groups[status.group] = true
+			kernels[status.kernel] = true
+			if status.needsRoll() {
+				nonCompliant++
+			}
+		}
+		age := "-"
+		if !image.Created.IsZero() {
+			age = fmt.Sprintf("%dd", int(time.Since(image.Created).Hours()/24))
+			if time.Since(image.Created) > patchStatusMaxAMIAge {
+				age = "⚠️  " + age
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\n", valueOrDash(imageID), valueOrDash(image.Name), age, valueOrDash(imageStatuses[0].os),
+			strings.Join(sortedKeys(groups), ","), len(imageStatuses), nonCompliant, strings.Join(sortedKeys(kernels), ","))
+	}
+	w.Flush()
+
+	var compliant, nonCompliant, unscanned, unmanaged, managedUnscanned int
+	rollGroups := make(map[string]map[string]bool) // Node group to the reasons it needs a roll
+	for _, status := range statuses {
+		switch {
+		case status.patches == nil:
+			unscanned++
+		case status.needsRoll():
+			nonCompliant++
+			addRollReason(rollGroups, status.group, "missing patches")
+		default:
+			compliant++
+		}
+		if status.ssm == nil {
+			unmanaged++
+		} else if status.patches == nil {
+			managedUnscanned++
+		}
+		if !status.image.Created.IsZero() && time.Since(status.image.Created) > patchStatusMaxAMIAge {
+			addRollReason(rollGroups, status.group, fmt.Sprintf("AMI older than %d days", int(patchStatusMaxAMIAge.Hours()/24)))
+		}
+	}
+
+	fmt.Println("\n--- Patch Status Summary ---")
+	fmt.Printf("Nodes: %d (%d compliant, %d non-compliant, %d never scanned)\n", len(statuses), compliant, nonCompliant, unscanned)
+	if unmanaged > 0 {
+		fmt.Printf("⚠️  %d nodes are not managed by SSM, so Patch Manager can't scan them; check the SSM agent and the AmazonSSMManagedInstanceCore policy of the node role\n", unmanaged)
+	}
+	if managedUnscanned > 0 {
+		fmt.Printf("⚠️  %d SSM managed nodes have no patch state; associate AWS-RunPatchBaseline with Operation=Scan to scan them\n", managedUnscanned)
+	}
+	if len(rollGroups) == 0 {
+		fmt.Println("✅ No node group needs an AMI roll")
+	} else {
+		cluster, _ := getClusterName()
+		fmt.Println("❌ Node groups that need an AMI roll:")
+		for _, group := range sortedKeys(rollGroups) {
+			fmt.Printf("   %s: %s\n", group, strings.Join(sortedKeys(rollGroups[group]), ", "))
+			if hint := amiRollHint(statuses, group, cluster); hint != "" {
+				fmt.Printf("      %s\n", hint)
+			}
+		}
+	}
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// describePatchState summarizes a Patch Manager state for the table.
+func describePatchState(state *awsutils.InstancePatchState) string {
+	switch {
+	case state == nil:
+		return "not scanned"
+	case state.Compliant():
+		return fmt.Sprintf("✅ compliant (%d installed)", state.Installed)
+	}
+	var parts []string
+	if state.Missing > 0 {
+		parts = append(parts, fmt.Sprintf("%d missing", state.Missing))
+	}
+	if state.Failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", state.Failed))
+	}
+	if state.InstalledPendingReboot > 0 {
+		parts = append(parts, fmt.Sprintf("%d pending reboot", state.InstalledPendingReboot))
+	}
+	if state.CriticalNonCompliant > 0 || state.SecurityNonCompliant > 0 {
+		parts = append(parts, fmt.Sprintf("%d critical, %d security", state.CriticalNonCompliant, state.SecurityNonCompliant))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%d non-compliant", state.OtherNonCompliant))
+	}
+	return "❌ " + strings.Join(parts, ", ")
+}
+
+func addRollReason(rollGroups map[string]map[string]bool, group, reason string) {
+	if rollGroups[group] == nil {
+		rollGroups[group] = make(map[string]bool)
+	}
+	rollGroups[group][reason] = true
+}
+
+// nodeGroupOf names the group that launched a node, its EKS managed node
+// group, Karpenter NodePool or eksctl node group, and the label it was read
+// from. The name is "-" when there is none.
+func nodeGroupOf(node corev1.Node) (string, string) {
+	for _, label := range []string{eksNodeGroupLabel, karpenterNodePoolLabel, eksctlNodeGroupLabel} {
+		if value := node.Labels[label]; value != "" {
+			return value, label
+		}
+	}
+	return "-", ""
+}
+
+// amiRollHint suggests how to move a node group to a newer AMI.
+func amiRollHint(statuses []nodePatchStatus, group, cluster string) string {
+	if group == "-" {
+		return ""
+	}
+	for _, status := range statuses {
+		if status.group != group {
+			continue
+		}
+		switch {
+		case status.groupLabel == eksNodeGroupLabel && cluster != "":
+			return fmt.Sprintf("aws eks update-nodegroup-version --cluster-name %s --nodegroup-name %s", cluster, group)
+		case status.groupLabel == karpenterNodePoolLabel:
+			return "Karpenter replaces the nodes as drifted once their EC2NodeClass resolves a newer AMI"
+		}
+		return fmt.Sprintf("Update the launch template's AMI, then: swissarmycli rotate-nodes <ASG of %s>", group)
+	}
+	return ""
+}
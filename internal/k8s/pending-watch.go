@@ -0,0 +1,280 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PendingWatchOptions contains options for watching pending pods
+type PendingWatchOptions struct {
+	Namespace    string
+	SlackWebhook string // Incoming webhook URL notified for every explanation
+	Once         bool   // Report the pods pending now and exit instead of watching
+	Output       string // table or json, json needs Once
+	FailOn       string // Lowest severity that fails a Once run: error, warning, info or none
+}
+
+// schedulingHints maps fragments of scheduler messages to a plain explanation
+var schedulingHints = []struct {
+	fragment    string
+	explanation string
+}{
+	{"Insufficient cpu", "not enough free CPU on any node: lower requests or add capacity"},
+	{"Insufficient memory", "not enough free memory on any node: lower requests or add capacity"},
+	{"Insufficient ephemeral-storage", "not enough ephemeral storage on any node"},
+	{"Too many pods", "nodes are at their max pods limit: add nodes or raise max pods"},
+	{"untolerated taint", "nodes are tainted and the pod has no matching toleration"},
+	{"volume node affinity conflict", "the pod's volume is in a zone with no schedulable node"},
+	{"no available volume zone", "the pod's volume is in a zone with no schedulable node"},
+	{"unbound immediate PersistentVolumeClaims", "a PVC is not bound: check the storage class and provisioner"},
+	{"didn't match Pod's node affinity/selector", "no node matches the pod's nodeSelector or node affinity"},
+	{"didn't match pod anti-affinity rules", "pod anti-affinity rules exclude every candidate node"},
+	{"didn't match pod affinity rules", "no node runs the pods required by pod affinity"},
+	{"didn't match pod topology spread constraints", "topology spread constraints can't be satisfied with the current nodes"},
+	{"node(s) were unschedulable", "nodes are cordoned"},
+	{"didn't have free ports", "the requested hostPort is already used on every node"},
+}
+
+// WatchPendingPods watches for pods the scheduler can't place, or can't be
+// created because of a quota, and explains why as soon as the event arrives.
+func WatchPendingPods(options PendingWatchOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	if !options.Once && (options.Output == "json" || options.FailOn != "none") {
+		return fmt.Errorf("--output json and --fail-on need --once, a watch has no end to report at")
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	if options.Once {
+		return reportPendingPods(clientset, options)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	fmt.Println("Watching for pending pods (press Ctrl+C to stop)...")
+
+	listOptions := metav1.ListOptions{FieldSelector: "type=Warning"}
+	reported := make(map[string]string)
+	resourceVersion := ""
+	for ctx.Err() == nil {
+		// Start from the current state so old events are not replayed
+		if resourceVersion == "" {
+			events, err := clientset.CoreV1().Events(options.Namespace).List(ctx, listOptions)
+			if err != nil {
+				return fmt.Errorf("failed to list events: %w", err)
+			}
+			resourceVersion = events.ResourceVersion
+		}
+
+		watchOptions := listOptions
+		watchOptions.ResourceVersion = resourceVersion
+		watcher, err := clientset.CoreV1().Events(options.Namespace).Watch(ctx, watchOptions)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return fmt.Errorf("failed to watch events: %w", err)
+		}
+
+		for item := range watcher.ResultChan() {
+			if item.Type == watch.Error {
+				if status, ok := item.Object.(*metav1.Status); ok && status.Code == 410 {
+					resourceVersion = "" // Expired, list again
+				}
+				break
+			}
+			event, ok := item.Object.(*corev1.Event)
+			if !ok {
+				continue
+			}
+			resourceVersion = event.ResourceVersion
+			if item.Type == watch.Deleted {
+				continue
+			}
+			handlePendingEvent(ctx, clientset, event, reported, options.SlackWebhook)
+		}
+		watcher.Stop()
+	}
+
+	fmt.Println("Stopped watching.")
+	return nil
+}
+
+// reportPendingPods explains the pending pods of the Warning events the
+// cluster still holds and exits, so the check can run from CI or cron.
+func reportPendingPods(clientset *kubernetes.Clientset, options PendingWatchOptions) error {
+	ctx := context.TODO()
+	events, err := clientset.CoreV1().Events(options.Namespace).List(ctx, metav1.ListOptions{FieldSelector: "type=Warning"})
+	if err != nil {
+		return fmt.Errorf("failed to list events: %w", err)
+	}
+
+	// Keep the latest event per object and reason
+	latest := make(map[string]corev1.Event)
+	for _, event := range events.Items {
+		key := string(event.InvolvedObject.UID) + "/" + event.Reason
+		if previous, ok := latest[key]; !ok || pendingEventTime(event).After(pendingEventTime(previous)) {
+			latest[key] = event
+		}
+	}
+
+	var findings []result.Finding
+	for _, event := range latest {
+		check, subject, explanations := explainPendingEvent(ctx, clientset, &event)
+		if check == "" {
+			continue
+		}
+		findings = append(findings, result.Finding{
+			Check:    check,
+			Severity: result.SeverityWarning,
+			Resource: strings.Replace(subject, " ", "/", 1),
+			Message:  strings.Join(explanations, "; "),
+			Details:  map[string]string{"scheduler": event.Message},
+		})
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Resource < findings[j].Resource })
+
+	if options.Output == "json" {
+		if err := result.New("pending-watch", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+	if len(findings) == 0 {
+		fmt.Println("✅ No pending pods.")
+		return nil
+	}
+	for _, finding := range findings {
+		fmt.Printf("\n⚠️  %s is pending\n", finding.Resource)
+		fmt.Printf("  Scheduler: %s\n", finding.Details["scheduler"])
+		for _, explanation := range strings.Split(finding.Message, "; ") {
+			fmt.Printf("  → %s\n", explanation)
+		}
+	}
+	return result.Gate(findings, options.FailOn)
+}
+
+func pendingEventTime(event corev1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	return event.EventTime.Time
+}
+
+// explainPendingEvent returns the check, the object and the explanations of
+// an event about a pod that can't be scheduled or created, or an empty check
+// when the event is about something else or the pod is no longer pending.
+func explainPendingEvent(ctx context.Context, clientset *kubernetes.Clientset, event *corev1.Event) (string, string, []string) {
+	switch {
+	case event.Reason == "FailedScheduling" && event.InvolvedObject.Kind == "Pod":
+		pod, err := clientset.CoreV1().Pods(event.InvolvedObject.Namespace).Get(ctx, event.InvolvedObject.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) || (err == nil && pod.Status.Phase != corev1.PodPending) {
+			return "", "", nil
+		}
+		subject := "Pod " + event.InvolvedObject.Namespace + "/" + event.InvolvedObject.Name
+		return "pod-unschedulable", subject, explainScheduling(event.Message)
+	case event.Reason == "FailedCreate" && strings.Contains(event.Message, "exceeded quota"):
+		subject := event.InvolvedObject.Kind + " " + event.InvolvedObject.Namespace + "/" + event.InvolvedObject.Name
+		return "quota-exhausted", subject, []string{"the namespace ResourceQuota is exhausted, so the pod could not be created"}
+	}
+	return "", "", nil
+}
+
+func handlePendingEvent(ctx context.Context, clientset *kubernetes.Clientset, event *corev1.Event, reported map[string]string, slackWebhook string) {
+	check, subject, explanations := explainPendingEvent(ctx, clientset, event)
+	if check == "" {
+		return
+	}
+
+	// The scheduler retries often; only report when the reason changes
+	key := string(event.InvolvedObject.UID) + "/" + event.Reason
+	if reported[key] == event.Message {
+		return
+	}
+	reported[key] = event.Message
+
+	fmt.Printf("\n[%s] ⚠️  %s is pending\n", time.Now().Format("15:04:05"), subject)
+	fmt.Printf("  Scheduler: %s\n", event.Message)
+	for _, explanation := range explanations {
+		fmt.Printf("  → %s\n", explanation)
+	}
+
+	if slackWebhook != "" {
+		text := fmt.Sprintf(":warning: *%s is pending*\n>%s", subject, event.Message)
+		for _, explanation := range explanations {
+			text += "\n• " + explanation
+		}
+		if err := postSlackMessage(slackWebhook, text); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+}
+
+// explainScheduling turns a FailedScheduling message such as "0/3 nodes are
+// available: 2 Insufficient cpu, 1 node(s) had untolerated taint ..." into
+// plain explanations.
+func explainScheduling(message string) []string {
+	var explanations []string
+	seen := make(map[string]bool)
+	for _, hint := range schedulingHints {
+		if strings.Contains(message, hint.fragment) && !seen[hint.explanation] {
+			seen[hint.explanation] = true
+			explanations = append(explanations, hint.explanation)
+		}
+	}
+	if strings.Contains(message, "Preemption is not helpful") {
+		explanations = append(explanations, "preempting lower priority pods would not help")
+	}
+	if len(explanations) == 0 {
+		explanations = append(explanations, "no known cause recognised, see the scheduler message")
+	}
+	return explanations
+}
+
+// postSlackMessage sends text to a Slack incoming webhook.
+func postSlackMessage(webhookURL, text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post Slack notification: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+)
+
+// podDensitySnapshot is what pod-density --output json prints and --save
+// writes. Owners repeats the owners of every node as flat rows, the shape
+// Grafana's JSON and Infinity data sources read as a table.
+type podDensitySnapshot struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Context   string          `json:"context"`
+	Nodes     []NodeInfo      `json:"nodes"`
+	Owners    []podDensityRow `json:"owners"`
+}
+
+// podDensityNodeDelta is a node's change between two pod-density runs
+type podDensityNodeDelta struct {
+	Node           string  `json:"node"`
+	Status         string  `json:"status"` // added, removed or kept
+	PodsBefore     int     `json:"pods_before"`
+	PodsAfter      int     `json:"pods_after"`
+	CPURequests    float64 `json:"cpu_requests_delta"`
+	MemoryRequests float64 `json:"memory_requests_gi_delta"`
+	CPUBefore      float64 `json:"cpu_requested_pct_before"`
+	CPUAfter       float64 `json:"cpu_requested_pct_after"`
+}
+
+// podDensityOwnerDelta is a workload's change between two pod-density runs
+type podDensityOwnerDelta struct {
+	Owner       string  `json:"owner"` // namespace/type/name
+	PodsBefore  int     `json:"pods_before"`
+	PodsAfter   int     `json:"pods_after"`
+	Moved       int     `json:"pods_moved"` // Pods now on a different node, beyond the change in count
+	NodesBefore int     `json:"nodes_before"`
+	NodesAfter  int     `json:"nodes_after"`
+	CPURequest  float64 `json:"cpu_request_delta"`
+	MemRequest  float64 `json:"mem_request_gi_delta"`
+}
+
+// newPodDensitySnapshot wraps the nodes of a pod-density run with the time
+// and context it was taken in
+func newPodDensitySnapshot(nodeInfos []NodeInfo) podDensitySnapshot {
+	snapshot := podDensitySnapshot{Timestamp: time.Now().UTC(), Nodes: nodeInfos, Owners: []podDensityRow{}}
+	snapshot.Context, _, _ = common.CurrentContext()
+	for _, nodeInfo := range nodeInfos {
+		for _, owner := range nodeInfo.Owners {
+			snapshot.Owners = append(snapshot.Owners, podDensityRow{Node: nodeInfo.Name, OwnerInfo: owner})
+		}
+	}
+	return snapshot
+}
+
+func savePodDensitySnapshot(path string, snapshot podDensitySnapshot) error {
+	content, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal pod density: %w", err)
+	}
+	if err := os.WriteFile(path, append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func loadPodDensitySnapshot(path string) (podDensitySnapshot, error) {
+	var snapshot podDensitySnapshot
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(content, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if snapshot.Nodes == nil {
+		return snapshot, fmt.Errorf("%s is not a pod-density snapshot (no nodes); write one with pod-density --save", path)
+	}
+	return snapshot, nil
+}
+
+// comparePodDensity computes the per-node and per-owner deltas between
+// two pod-density runs
+func comparePodDensity(before, after podDensitySnapshot) ([]podDensityNodeDelta, []podDensityOwnerDelta) {
+	beforeNodes := make(map[string]NodeInfo)
+	for _, nodeInfo := range before.Nodes {
+		beforeNodes[nodeInfo.Name] = nodeInfo
+	}
+	afterNodes := make(map[string]NodeInfo)
+	for _, nodeInfo := range after.Nodes {
+		afterNodes[nodeInfo.Name] = nodeInfo
+	}
+	names := make(map[string]bool)
+	for name := range beforeNodes {
+		names[name] = true
+	}
+	for name := range afterNodes {
+		names[name] = true
+	}
+	var nodeDeltas []podDensityNodeDelta
+	for _, name := range sortedKeys(names) {
+		old, hadNode := beforeNodes[name]
+		current, hasNode := afterNodes[name]
+		delta := podDensityNodeDelta{
+			Node:           name,
+			Status:         "kept",
+			PodsBefore:     old.PodCount,
+			PodsAfter:      current.PodCount,
+			CPURequests:    current.CPURequests - old.CPURequests,
+			MemoryRequests: current.MemoryRequests - old.MemoryRequests,
+			CPUBefore:      safeRatio(old.CPURequests, old.CPUCapacity) * 100,
+			CPUAfter:       safeRatio(current.CPURequests, current.CPUCapacity) * 100,
+		}
+		switch {
+		case !hadNode:
+			delta.Status = "added"
+		case !hasNode:
+			delta.Status = "removed"
+		}
+		nodeDeltas = append(nodeDeltas, delta)
+	}
+
+	// Owners are compared across the cluster; pods on each node tell how
+	// many moved
+	type ownerTotals struct {
+		pods, nodes int
+		cpu, mem    float64
+		perNode     map[string]int
+	}
+	collect := func(snapshot podDensitySnapshot) map[string]*ownerTotals {
+		owners := make(map[string]*ownerTotals)
+		for _, nodeInfo := range snapshot.Nodes {
+			for _, owner := range nodeInfo.Owners {
+				key := fmt.Sprintf("%s/%s/%s", owner.Namespace, owner.Type, owner.Name)
+				if owners[key] == nil {
+					owners[key] = &ownerTotals{perNode: make(map[string]int)}
+				}
+				totals := owners[key]
+				totals.pods += owner.PodCount
+				totals.nodes++
+				totals.cpu += owner.CPURequest
+				totals.mem += owner.MemRequest
+				totals.perNode[nodeInfo.Name] += owner.PodCount
+			}
+		}
+		return owners
+	}
+	beforeOwners, afterOwners := collect(before), collect(after)
+	keys := make(map[string]bool)
+	for key := range beforeOwners {
+		keys[key] = true
+	}
+	for key := range afterOwners {
+		keys[key] = true
+	}
+	var ownerDeltas []podDensityOwnerDelta
+	for _, key := range sortedKeys(keys) {
+		old, current := beforeOwners[key], afterOwners[key]
+		if old == nil {
+			old = &ownerTotals{perNode: map[string]int{}}
+		}
+		if current == nil {
+			current = &ownerTotals{perNode: map[string]int{}}
+		}
+		arrived := 0
+		for node, pods := range current.perNode {
+			arrived += max(pods-old.perNode[node], 0)
+		}
+		delta := podDensityOwnerDelta{
+			Owner:       key,
+			PodsBefore:  old.pods,
+			PodsAfter:   current.pods,
+			Moved:       max(arrived-max(current.pods-old.pods, 0), 0),
+			NodesBefore: old.nodes,
+			NodesAfter:  current.nodes,
+			CPURequest:  current.cpu - old.cpu,
+			MemRequest:  current.mem - old.mem,
+		}
+		if delta.PodsBefore != delta.PodsAfter || delta.Moved > 0 || delta.NodesBefore != delta.NodesAfter ||
+			!nearlyZero(delta.CPURequest) || !nearlyZero(delta.MemRequest) {
+			ownerDeltas = append(ownerDeltas, delta)
+		}
+	}
+	sort.SliceStable(ownerDeltas, func(i, j int) bool {
+		return ownerDeltas[i].Moved+absInt(ownerDeltas[i].PodsAfter-ownerDeltas[i].PodsBefore) >
+			ownerDeltas[j].Moved+absInt(ownerDeltas[j].PodsAfter-ownerDeltas[j].PodsBefore)
+	})
+	return nodeDeltas, ownerDeltas
+}
+
+// showPodDensityComparison prints the deltas between a saved pod-density
+// run and the current one, to quantify a bin-packing or autoscaler change
+func showPodDensityComparison(path string, after podDensitySnapshot, output string) error {
+	before, err := loadPodDensitySnapshot(path)
+	if err != nil {
+		return err
+	}
+	nodeDeltas, ownerDeltas := comparePodDensity(before, after)
+	if output == "json" {
+		encoder := json.NewEncoder(sink.Output())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]any{
+			"before": before.Timestamp,
+			"after":  after.Timestamp,
+			"nodes":  nodeDeltas,
+			"owners": ownerDeltas,
+		})
+	}
+
+	if before.Context != "" && after.Context != "" && before.Context != after.Context {
+		fmt.Printf("⚠️  Comparing context %s with %s\n", before.Context, after.Context)
+	}
+	fmt.Printf("Comparing %s with now (%s later)\n\n", before.Timestamp.Local().Format(time.RFC3339),
+		after.Timestamp.Sub(before.Timestamp).Round(time.Minute))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tSTATUS\tPODS\tCPU REQ Δ\tMEM REQ Δ\tCPU REQUESTED")
+	for _, delta := range nodeDeltas {
+		fmt.Fprintf(w, "%s\t%s\t%d → %d\t%+.2f\t%+.2fGi\t%.0f%% → %.0f%%\n", delta.Node, delta.Status, delta.PodsBefore,
+			delta.PodsAfter, delta.CPURequests, delta.MemoryRequests, delta.CPUBefore, delta.CPUAfter)
+	}
+	w.Flush()
+
+	moved := 0
+	if len(ownerDeltas) > 0 {
+		fmt.Println("\nChanged owners:")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "OWNER\tPODS\tMOVED\tNODES\tCPU REQ Δ\tMEM REQ Δ")
+		for _, delta := range ownerDeltas {
+			moved += delta.Moved
+			fmt.Fprintf(w, "%s\t%d → %d\t%d\t%d → %d\t%+.2f\t%+.2fGi\n", delta.Owner, delta.PodsBefore, delta.PodsAfter,
+				delta.Moved, delta.NodesBefore, delta.NodesAfter, delta.CPURequest, delta.MemRequest)
+		}
+		w.Flush()
+	}
+
+	summarize := func(snapshot podDensitySnapshot) (int, float64, float64, float64, float64) {
+		pods := 0
+		var cpu, mem, cpuCapacity, memCapacity float64
+		for _, nodeInfo := range snapshot.Nodes {
+			pods += nodeInfo.PodCount
+			cpu += nodeInfo.CPURequests
+			mem += nodeInfo.MemoryRequests
+			cpuCapacity += nodeInfo.CPUCapacity
+			memCapacity += nodeInfo.MemoryCapacity
+		}
+		return pods, cpu, mem, safeRatio(cpu, cpuCapacity) * 100, safeRatio(mem, memCapacity) * 100
+	}
+	podsBefore, cpuBefore, memBefore, cpuPctBefore, memPctBefore := summarize(before)
+	podsAfter, cpuAfter, memAfter, cpuPctAfter, memPctAfter := summarize(after)
+	fmt.Println("\n--- Pod Density Comparison Summary ---")
+	fmt.Printf("Nodes: %d → %d\n", len(before.Nodes), len(after.Nodes))
+	fmt.Printf("Pods: %d → %d, %d moved to another node\n", podsBefore, podsAfter, moved)
+	fmt.Printf("CPU requests: %.2f → %.2f, %.0f%% → %.0f%% of capacity\n", cpuBefore, cpuAfter, cpuPctBefore, cpuPctAfter)
+	fmt.Printf("Memory requests: %.2fGi → %.2fGi, %.0f%% → %.0f%% of capacity\n", memBefore, memAfter, memPctBefore, memPctAfter)
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// nearlyZero ignores float noise in request deltas
+func nearlyZero(value float64) bool {
+	return value > -0.005 && value < 0.005
+}
+
+func absInt(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
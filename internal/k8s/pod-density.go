@@ -2,48 +2,60 @@ package k8s
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"sort"
 	"sync"
 	"text/tabwriter"
 
+	"github.com/HighonAces/swissarmycli/internal/columns"
 	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/sink"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 )
 
 type OwnerInfo struct {
-	Name       string
-	Type       string
-	Namespace  string
-	PodCount   int
-	CPURequest float64
-	CPULimit   float64
-	MemRequest float64
-	MemLimit   float64
+	Name       string  `json:"name"`
+	Type       string  `json:"type"`
+	Namespace  string  `json:"namespace"`
+	PodCount   int     `json:"pod_count"`
+	CPURequest float64 `json:"cpu_request"`
+	CPULimit   float64 `json:"cpu_limit"`
+	MemRequest float64 `json:"mem_request_gi"`
+	MemLimit   float64 `json:"mem_limit_gi"`
+	CPUUsage   float64 `json:"cpu_usage"`
+	MemUsage   float64 `json:"mem_usage_gi"`
+	HasUsage   bool    `json:"has_usage"`
 }
 
 type NodeInfo struct {
-	Name           string
-	PodCount       int
-	CPUCapacity    float64
-	CPURequests    float64
-	CPULimits      float64
-	CPUUsage       float64
-	MemoryCapacity float64
-	MemoryRequests float64
-	MemoryLimits   float64
-	MemoryUsage    float64
-	Owners         []*OwnerInfo
+	Name           string       `json:"name"`
+	Provider       string       `json:"provider"`    // aws, gce, azure or baremetal
+	InstanceID     string       `json:"instance_id"` // Empty on bare metal
+	Zone           string       `json:"zone"`
+	PodCount       int          `json:"pod_count"`
+	CPUCapacity    float64      `json:"cpu_capacity"`
+	CPURequests    float64      `json:"cpu_requests"`
+	CPULimits      float64      `json:"cpu_limits"`
+	CPUUsage       float64      `json:"cpu_usage"`
+	MemoryCapacity float64      `json:"memory_capacity_gi"`
+	MemoryRequests float64      `json:"memory_requests_gi"`
+	MemoryLimits   float64      `json:"memory_limits_gi"`
+	MemoryUsage    float64      `json:"memory_usage_gi"`
+	Owners         []*OwnerInfo `json:"owners"`
 }
 
-func ShowPodDensity() error {
+// CollectPodDensity returns every node with its running pods grouped by
+// owning workload, largest owners first.
+func CollectPodDensity() ([]NodeInfo, error) {
 	clientset, err := common.GetKubernetesClient()
 	if err != nil {
-		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
 
 	metricsClient, err := common.GetMetricsClient()
@@ -56,7 +68,8 @@ func ShowPodDensity() error {
 	var pods *corev1.PodList
 	var replicaSets *appsv1.ReplicaSetList
 	var nodeMetrics *metricsv1beta1.NodeMetricsList
-	var nodeErr, podErr, rsErr, metricsErr error
+	var podMetrics *metricsv1beta1.PodMetricsList
+	var nodeErr, podErr, rsErr, metricsErr, podMetricsErr error
 
 	// Fetch all data concurrently
 	wg.Add(3)
@@ -77,23 +90,34 @@ func ShowPodDensity() error {
 	}()
 
 	if metricsClient != nil {
-		wg.Add(1)
+		wg.Add(2)
 		go func() {
 			defer wg.Done()
 			nodeMetrics, metricsErr = metricsClient.MetricsV1beta1().NodeMetricses().List(context.TODO(), metav1.ListOptions{})
 		}()
+		go func() {
+			defer wg.Done()
+			podMetrics, podMetricsErr = metricsClient.MetricsV1beta1().PodMetricses("").List(context.TODO(), metav1.ListOptions{})
+		}()
 	}
 
 	wg.Wait()
 
 	if nodeErr != nil {
-		return fmt.Errorf("failed to get nodes: %w", nodeErr)
+		return nil, fmt.Errorf("failed to get nodes: %w", nodeErr)
 	}
 	if podErr != nil {
-		return fmt.Errorf("failed to get pods: %w", podErr)
+		return nil, fmt.Errorf("failed to get pods: %w", podErr)
 	}
 	if rsErr != nil {
-		return fmt.Errorf("failed to get replicasets: %w", rsErr)
+		return nil, fmt.Errorf("failed to get replicasets: %w", rsErr)
+	}
+
+	podUsage := make(map[string]corev1.ResourceList)
+	if podMetrics != nil && podMetricsErr == nil {
+		for _, metric := range podMetrics.Items {
+			podUsage[metric.Namespace+"/"+metric.Name] = sumContainerUsage(metric.Containers)
+		}
 	}
 
 	rsOwnerCache := make(map[string]string)
@@ -109,8 +133,12 @@ func ShowPodDensity() error {
 	nodeStats := make(map[string]*NodeInfo)
 
 	for _, node := range nodes.Items {
+		machine := nodeMachine(node)
 		nodeStats[node.Name] = &NodeInfo{
 			Name:           node.Name,
+			Provider:       machine.Provider,
+			InstanceID:     machine.InstanceID,
+			Zone:           machine.Zone,
 			CPUCapacity:    float64(node.Status.Capacity.Cpu().MilliValue()) / 1000,
 			MemoryCapacity: float64(node.Status.Capacity.Memory().Value()) / (1024 * 1024 * 1024),
 		}
@@ -137,6 +165,12 @@ func ShowPodDensity() error {
 		ownerInfo := nodeMap[nodeName][key]
 		ownerInfo.PodCount++
 
+		if usage, ok := podUsage[pod.Namespace+"/"+pod.Name]; ok {
+			ownerInfo.HasUsage = true
+			ownerInfo.CPUUsage += float64(usage.Cpu().MilliValue()) / 1000
+			ownerInfo.MemUsage += float64(usage.Memory().Value()) / (1024 * 1024 * 1024)
+		}
+
 		for _, container := range pod.Spec.Containers {
 			if cpu, ok := container.Resources.Requests[corev1.ResourceCPU]; ok {
 				cpuCores := float64(cpu.MilliValue()) / 1000
@@ -188,6 +222,70 @@ func ShowPodDensity() error {
 		nodeInfo.Owners = owners
 		nodeInfos = append(nodeInfos, *nodeInfo)
 	}
+	sort.Slice(nodeInfos, func(i, j int) bool {
+		return nodeInfos[i].Name < nodeInfos[j].Name
+	})
+	return nodeInfos, nil
+}
+
+// PodDensityOptions contains options for the pod density report
+type PodDensityOptions struct {
+	Custom  columns.Options // One row per owner on each node
+	Output  string          // table or json
+	Save    string          // File the run is written to as JSON, for a later --compare
+	Compare string          // File of an earlier run to show the deltas against
+}
+
+// podDensityRow is an owner on a node, the row of custom output
+type podDensityRow struct {
+	Node string `json:"node"`
+	*OwnerInfo
+}
+
+// ShowPodDensity prints the pods on every node grouped by owning workload
+func ShowPodDensity(options PodDensityOptions) error {
+	if options.Output != "table" && options.Output != "json" {
+		return fmt.Errorf("invalid output format '%s' (must be table or json)", options.Output)
+	}
+	if options.Custom.Enabled() && (options.Output == "json" || options.Compare != "") {
+		return fmt.Errorf("--columns and --template can't be combined with --output json or --compare")
+	}
+	var printer *columns.Printer
+	if options.Custom.Enabled() {
+		var err error
+		printer, err = columns.New(options.Custom)
+		if err != nil {
+			return err
+		}
+	}
+	nodeInfos, err := CollectPodDensity()
+	if err != nil {
+		return err
+	}
+	snapshot := newPodDensitySnapshot(nodeInfos)
+	if options.Save != "" {
+		if err := savePodDensitySnapshot(options.Save, snapshot); err != nil {
+			return err
+		}
+	}
+	if options.Compare != "" {
+		return showPodDensityComparison(options.Compare, snapshot, options.Output)
+	}
+	if options.Output == "json" {
+		encoder := json.NewEncoder(sink.Output())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(snapshot)
+	}
+
+	if printer != nil {
+		var rows []podDensityRow
+		for _, nodeInfo := range nodeInfos {
+			for _, owner := range nodeInfo.Owners {
+				rows = append(rows, podDensityRow{Node: nodeInfo.Name, OwnerInfo: owner})
+			}
+		}
+		return printer.Print(os.Stdout, rows)
+	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 
@@ -215,12 +313,18 @@ func ShowPodDensity() error {
 			nodeInfo.MemoryLimits, nodeInfo.MemoryLimits*100/nodeInfo.MemoryCapacity,
 			memUsageStr)
 
-		fmt.Fprintln(w, "  OWNER\tTYPE\tNAMESPACE\tPODS\tCPU REQ\tCPU LIM\tMEM REQ\tMEM LIM")
+		fmt.Fprintln(w, "  OWNER\tTYPE\tNAMESPACE\tPODS\tCPU REQ\tCPU LIM\tCPU USE\tCPU USE/REQ\tMEM REQ\tMEM LIM\tMEM USE\tMEM USE/REQ")
 
 		for _, owner := range nodeInfo.Owners {
-			fmt.Fprintf(w, "  %s\t%s\t%s\t%d\t%.2f\t%.2f\t%.2fGi\t%.2fGi\n",
+			cpuUse, memUse := "N/A", "N/A"
+			if owner.HasUsage {
+				cpuUse = fmt.Sprintf("%.2f", owner.CPUUsage)
+				memUse = fmt.Sprintf("%.2fGi", owner.MemUsage)
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%d\t%.2f\t%.2f\t%s\t%s\t%.2fGi\t%.2fGi\t%s\t%s\n",
 				owner.Name, owner.Type, owner.Namespace, owner.PodCount,
-				owner.CPURequest, owner.CPULimit, owner.MemRequest, owner.MemLimit)
+				owner.CPURequest, owner.CPULimit, cpuUse, usageRatio(owner.HasUsage, owner.CPUUsage, owner.CPURequest),
+				owner.MemRequest, owner.MemLimit, memUse, usageRatio(owner.HasUsage, owner.MemUsage, owner.MemRequest))
 		}
 	}
 
@@ -248,3 +352,29 @@ func getPodOwnerFast(pod *corev1.Pod, rsOwnerCache map[string]string) (string, s
 	}
 	return pod.Name, "Pod"
 }
+
+// sumContainerUsage adds up the CPU and memory usage of all containers in a pod.
+func sumContainerUsage(containers []metricsv1beta1.ContainerMetrics) corev1.ResourceList {
+	cpu := resource.NewMilliQuantity(0, resource.DecimalSI)
+	memory := resource.NewQuantity(0, resource.BinarySI)
+	for _, container := range containers {
+		cpu.Add(*container.Usage.Cpu())
+		memory.Add(*container.Usage.Memory())
+	}
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    *cpu,
+		corev1.ResourceMemory: *memory,
+	}
+}
+
+// usageRatio formats usage as a percentage of the request. Ratios well below
+// 100% point at over-provisioned workloads, ratios above it at under-provisioned ones.
+func usageRatio(hasUsage bool, usage, request float64) string {
+	if !hasUsage {
+		return "N/A"
+	}
+	if request == 0 {
+		return "no request"
+	}
+	return fmt.Sprintf("%.0f%%", usage*100/request)
+}
@@ -0,0 +1,437 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	kyvernoClusterPolicyGVR = schema.GroupVersionResource{Group: "kyverno.io", Version: "v1", Resource: "clusterpolicies"}
+	kyvernoPolicyGVR        = schema.GroupVersionResource{Group: "kyverno.io", Version: "v1", Resource: "policies"}
+	policyReportGVR         = schema.GroupVersionResource{Group: "wgpolicyk8s.io", Version: "v1alpha2", Resource: "policyreports"}
+	clusterPolicyReportGVR  = schema.GroupVersionResource{Group: "wgpolicyk8s.io", Version: "v1alpha2", Resource: "clusterpolicyreports"}
+)
+
+// gatekeeperConstraintsGroupVersion serves one resource per constraint
+// template
+const gatekeeperConstraintsGroupVersion = "constraints.gatekeeper.sh/v1beta1"
+
+// PolicyStatusOptions contains options for the policy engine summary
+type PolicyStatusOptions struct {
+	Namespace string        // Only violations and denials in this namespace
+	Since     time.Duration // How far back denied admission requests are shown
+	Limit     int           // Most denied requests listed
+}
+
+// policyRow is a Kyverno policy or Gatekeeper constraint with its audit
+// violations per namespace
+type policyRow struct {
+	name       string
+	action     string
+	ready      string
+	violations map[string]int // Namespace, empty for cluster-scoped resources, to violations
+}
+
+// policyDenial is an admission request a policy engine denied
+type policyDenial struct {
+	time     time.Time
+	policy   string
+	resource string
+	message  string
+}
+
+// ShowPolicyStatus lists the Kyverno ClusterPolicies and Policies and the
+// Gatekeeper Constraints installed, with whether they enforce or audit,
+// their audit violations by namespace from the policy reports and
+// constraint status, and the admission requests they denied recently, from
+// the events both engines emit. Engines that aren't installed are skipped.
+func ShowPolicyStatus(options PolicyStatusOptions) error {
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	dynamicClient, err := common.GetDynamicClient()
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	ctx := context.TODO()
+
+	kyverno, kyvernoInstalled, err := collectKyvernoPolicies(ctx, dynamicClient)
+	if err != nil {
+		return err
+	}
+	gatekeeper, gatekeeperInstalled, err := collectGatekeeperConstraints(ctx, clientset, dynamicClient)
+	if err != nil {
+		return err
+	}
+	if !kyvernoInstalled && !gatekeeperInstalled {
+		fmt.Println("Neither Kyverno (kyverno.io) nor Gatekeeper (constraints.gatekeeper.sh) is installed.")
+		return nil
+	}
+	denials, err := collectPolicyDenials(ctx, clientset, options)
+	if err != nil {
+		return err
+	}
+
+	byNamespace := make(map[string]map[string]int) // Namespace to policy to violations
+	total := 0
+	for _, section := range []struct {
+		title     string
+		installed bool
+		rows      []policyRow
+	}{
+		{"Kyverno policies", kyvernoInstalled, kyverno},
+		{"Gatekeeper constraints", gatekeeperInstalled, gatekeeper},
+	} {
+		if !section.installed {
+			continue
+		}
+		fmt.Printf("\n=== %s (%d) ===\n", section.title, len(section.rows))
+		if len(section.rows) == 0 {
+			continue
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tACTION\tREADY\tVIOLATIONS\tTOP NAMESPACES")
+		for _, row := range section.rows {
+			count := 0
+			for namespace, violations := range row.violations {
+				if options.Namespace != "" && namespace != options.Namespace {
+					continue
+				}
+				count += violations
+				if byNamespace[namespace] == nil {
+					byNamespace[namespace] = make(map[string]int)
+				}
+				byNamespace[namespace][row.name] += violations
+			}
+			total += count
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", row.name, row.action, row.ready, count, topViolationNamespaces(row.violations, options.Namespace))
+		}
+		w.Flush()
+	}
+
+	if len(byNamespace) > 0 {
+		fmt.Println("\nViolations by namespace:")
+		namespaces := sortedKeys(byNamespace)
+		counts := make(map[string]int)
+		for _, namespace := range namespaces {
+			for _, violations := range byNamespace[namespace] {
+				counts[namespace] += violations
+			}
+		}
+		sort.SliceStable(namespaces, func(i, j int) bool { return counts[namespaces[i]] > counts[namespaces[j]] })
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAMESPACE\tVIOLATIONS\tPOLICIES")
+		for _, namespace := range namespaces {
+			policies := sortedKeys(byNamespace[namespace])
+			sort.SliceStable(policies, func(i, j int) bool {
+				return byNamespace[namespace][policies[i]] > byNamespace[namespace][policies[j]]
+			})
+			var cells []string
+			for _, policy := range policies {
+				cells = append(cells, fmt.Sprintf("%s (%d)", policy, byNamespace[namespace][policy]))
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\n", valueOrDash(namespace), counts[namespace], strings.Join(cells, ", "))
+		}
+		w.Flush()
+	}
+
+	fmt.Printf("\nDenied admission requests in the last %s:\n", options.Since)
+	if len(denials) == 0 {
+		fmt.Println("  None")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tPOLICY\tRESOURCE\tMESSAGE")
+		for i, denial := range denials {
+			if i == options.Limit {
+				break
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", formatAge(denial.time.Format(time.RFC3339)), denial.policy,
+				valueOrDash(denial.resource), truncatePolicyMessage(denial.message))
+		}
+		w.Flush()
+	}
+
+	fmt.Println("\n--- Policy Status Summary ---")
+	if kyvernoInstalled {
+		fmt.Printf("Kyverno: %d policies, %d enforcing\n", len(kyverno), countEnforcing(kyverno))
+	}
+	if gatekeeperInstalled {
+		fmt.Printf("Gatekeeper: %d constraints, %d enforcing\n", len(gatekeeper), countEnforcing(gatekeeper))
+	}
+	fmt.Printf("Audit violations: %d in %d namespaces\n", total, len(byNamespace))
+	fmt.Printf("Denied requests: %d in the last %s\n", len(denials), options.Since)
+	if notReady := countNotReady(kyverno) + countNotReady(gatekeeper); notReady > 0 {
+		fmt.Printf("⚠️  %d policies are not ready and don't apply to admission requests\n", notReady)
+	}
+	if gatekeeperInstalled && len(denials) == 0 {
+		fmt.Println("Gatekeeper only reports denials as events when started with --emit-admission-events")
+	}
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// collectKyvernoPolicies returns the Kyverno policies with the failed
+// results of the policy reports counted per namespace
+func collectKyvernoPolicies(ctx context.Context, dynamicClient dynamic.Interface) ([]policyRow, bool, error) {
+	clusterPolicies, err := dynamicClient.Resource(kyvernoClusterPolicyGVR).List(ctx, metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list Kyverno ClusterPolicies: %w", err)
+	}
+	policies, err := dynamicClient.Resource(kyvernoPolicyGVR).List(ctx, metav1.ListOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, false, fmt.Errorf("failed to list Kyverno Policies: %w", err)
+	}
+
+	rows := make(map[string]*policyRow)
+	var names []string
+	items := clusterPolicies.Items
+	if policies != nil {
+		items = append(items, policies.Items...)
+	}
+	for _, item := range items {
+		name := item.GetName()
+		if item.GetNamespace() != "" {
+			name = item.GetNamespace() + "/" + name
+		}
+		row := &policyRow{name: name, action: kyvernoAction(item), ready: "✅", violations: make(map[string]int)}
+		status, _, _ := findCondition(item, "Ready")
+		legacyReady, hasLegacy, _ := unstructured.NestedBool(item.Object, "status", "ready")
+		if status == "False" || (status == "" && hasLegacy && !legacyReady) {
+			row.ready = "❌"
+		}
+		rows[name] = row
+		names = append(names, name)
+	}
+
+	// Reports are optional: Kyverno only writes them with background scans
+	// or reporting enabled
+	for _, gvr := range []schema.GroupVersionResource{policyReportGVR, clusterPolicyReportGVR} {
+		reports, err := dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+		}
+		for _, report := range reports.Items {
+			results, _, _ := unstructured.NestedSlice(report.Object, "results")
+			for _, raw := range results {
+				entry, _ := raw.(map[string]any)
+				if entry["result"] != "fail" {
+					continue
+				}
+				policy, _ := entry["policy"].(string)
+				row := rows[policy]
+				if row == nil && report.GetNamespace() != "" {
+					row = rows[report.GetNamespace()+"/"+policy]
+				}
+				if row != nil {
+					row.violations[report.GetNamespace()]++
+				}
+			}
+		}
+	}
+
+	var collected []policyRow
+	for _, name := range names {
+		collected = append(collected, *rows[name])
+	}
+	return collected, true, nil
+}
+
+// kyvernoAction is Enforce when the policy or any of its rules enforce
+func kyvernoAction(item unstructured.Unstructured) string {
+	action, _, _ := unstructured.NestedString(item.Object, "spec", "validationFailureAction")
+	rules, _, _ := unstructured.NestedSlice(item.Object, "spec", "rules")
+	for _, raw := range rules {
+		rule, _ := raw.(map[string]any)
+		if ruleAction, _, _ := unstructured.NestedString(rule, "validate", "failureAction"); ruleAction != "" {
+			if strings.EqualFold(ruleAction, "Enforce") || action == "" {
+				action = ruleAction
+			}
+		}
+	}
+	switch {
+	case strings.EqualFold(action, "Enforce"):
+		return "Enforce"
+	case action == "":
+		return "Audit"
+	}
+	return action
+}
+
+// collectGatekeeperConstraints returns every constraint of every
+// constraint template with its audit violations per namespace
+func collectGatekeeperConstraints(ctx context.Context, clientset *kubernetes.Clientset, dynamicClient dynamic.Interface) ([]policyRow, bool, error) {
+	resources, err := clientset.Discovery().ServerResourcesForGroupVersion(gatekeeperConstraintsGroupVersion)
+	if apierrors.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to discover Gatekeeper constraints: %w", err)
+	}
+	var rows []policyRow
+	for _, resource := range resources.APIResources {
+		if strings.Contains(resource.Name, "/") {
+			continue
+		}
+		gvr := schema.GroupVersionResource{Group: "constraints.gatekeeper.sh", Version: "v1beta1", Resource: resource.Name}
+		list, err := dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to list %s constraints: %w", resource.Kind, err)
+		}
+		for _, item := range list.Items {
+			row := policyRow{name: resource.Kind + "/" + item.GetName(), ready: "✅", violations: make(map[string]int)}
+			row.action, _, _ = unstructured.NestedString(item.Object, "spec", "enforcementAction")
+			if row.action == "" {
+				row.action = "deny"
+			}
+			byPod, _, _ := unstructured.NestedSlice(item.Object, "status", "byPod")
+			for _, raw := range byPod {
+				pod, _ := raw.(map[string]any)
+				if enforced, _ := pod["enforced"].(bool); !enforced {
+					row.ready = "❌"
+				}
+			}
+			if len(byPod) == 0 {
+				row.ready = "❔"
+			}
+			// The status lists at most --constraint-violations-limit
+			// violations; the rest are counted without namespace
+			violations, _, _ := unstructured.NestedSlice(item.Object, "status", "violations")
+			for _, raw := range violations {
+				violation, _ := raw.(map[string]any)
+				namespace, _ := violation["namespace"].(string)
+				row.violations[namespace]++
+			}
+			if totalViolations, _, _ := unstructured.NestedInt64(item.Object, "status", "totalViolations"); int(totalViolations) > len(violations) {
+				row.violations["(not listed)"] += int(totalViolations) - len(violations)
+			}
+			rows = append(rows, row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].name < rows[j].name })
+	return rows, true, nil
+}
+
+// collectPolicyDenials returns the admission requests Kyverno or Gatekeeper
+// denied within options.Since, newest first
+func collectPolicyDenials(ctx context.Context, clientset *kubernetes.Clientset, options PolicyStatusOptions) ([]policyDenial, error) {
+	events, err := clientset.CoreV1().Events("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	cutoff := time.Now().Add(-options.Since)
+	var denials []policyDenial
+	for _, event := range events.Items {
+		_, last, _ := eventTimes(event)
+		if last.Before(cutoff) {
+			continue
+		}
+		denial, ok := policyDenialFromEvent(event)
+		if !ok {
+			continue
+		}
+		if options.Namespace != "" && !strings.HasPrefix(denial.resource, options.Namespace+"/") {
+			continue
+		}
+		denial.time = last
+		denials = append(denials, denial)
+	}
+	sort.Slice(denials, func(i, j int) bool { return denials[i].time.After(denials[j].time) })
+	return denials, nil
+}
+
+// policyDenialFromEvent recognizes the event Kyverno records on a policy
+// when it blocks a request, and the FailedAdmission event Gatekeeper emits
+func policyDenialFromEvent(event corev1.Event) (policyDenial, bool) {
+	switch {
+	case event.Reason == "PolicyViolation" && (event.InvolvedObject.Kind == "ClusterPolicy" || event.InvolvedObject.Kind == "Policy") &&
+		strings.Contains(event.Message, "(blocked)"):
+		policy := event.InvolvedObject.Name
+		if event.InvolvedObject.Namespace != "" {
+			policy = event.InvolvedObject.Namespace + "/" + policy
+		}
+		// Kyverno messages start with "<Kind> <namespace>/<name>: "
+		resource := ""
+		if subject, _, ok := strings.Cut(event.Message, ": "); ok {
+			if _, name, ok := strings.Cut(subject, " "); ok {
+				resource = name
+			}
+		}
+		return policyDenial{policy: policy, resource: resource, message: event.Message}, true
+	case event.Reason == "FailedAdmission" && event.Annotations["process"] == "admission":
+		resource := event.Annotations["resource_name"]
+		if namespace := event.Annotations["resource_namespace"]; namespace != "" {
+			resource = namespace + "/" + resource
+		}
+		policy := event.Annotations["constraint_kind"] + "/" + event.Annotations["constraint_name"]
+		return policyDenial{policy: policy, resource: resource, message: event.Message}, true
+	}
+	return policyDenial{}, false
+}
+
+// topViolationNamespaces names the three namespaces with most violations
+func topViolationNamespaces(violations map[string]int, only string) string {
+	namespaces := sortedKeys(violations)
+	sort.SliceStable(namespaces, func(i, j int) bool { return violations[namespaces[i]] > violations[namespaces[j]] })
+	var cells []string
+	for _, namespace := range namespaces {
+		if only != "" && namespace != only {
+			continue
+		}
+		if len(cells) == 3 {
+			cells = append(cells, "...")
+			break
+		}
+		cells = append(cells, fmt.Sprintf("%s (%d)", valueOrDash(namespace), violations[namespace]))
+	}
+	return valueOrDash(strings.Join(cells, ", "))
+}
+
+// truncatePolicyMessage keeps denial messages to one table line
+func truncatePolicyMessage(message string) string {
+	message = strings.Join(strings.Fields(message), " ")
+	if len(message) > 120 {
+		return message[:117] + "..."
+	}
+	return message
+}
+
+func countEnforcing(rows []policyRow) int {
+	count := 0
+	for _, row := range rows {
+		if row.action == "Enforce" || row.action == "deny" {
+			count++
+		}
+	}
+	return count
+}
+
+func countNotReady(rows []policyRow) int {
+	count := 0
+	for _, row := range rows {
+		if row.ready == "❌" {
+			count++
+		}
+	}
+	return count
+}
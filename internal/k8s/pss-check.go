@@ -0,0 +1,434 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Pod Security Standards levels, least to most strict
+const (
+	pssPrivileged = "privileged"
+	pssBaseline   = "baseline"
+	pssRestricted = "restricted"
+)
+
+var pssLevelRank = map[string]int{pssPrivileged: 0, pssBaseline: 1, pssRestricted: 2}
+
+// pssEnforceLabel is the namespace label Pod Security Admission enforces
+const pssEnforceLabel = "pod-security.kubernetes.io/enforce"
+
+// pssBaselineCapabilities are the capabilities baseline allows containers to
+// add
+var pssBaselineCapabilities = map[string]bool{
+	"AUDIT_WRITE": true, "CHOWN": true, "DAC_OVERRIDE": true, "FOWNER": true, "FSETID": true, "KILL": true, "MKNOD": true,
+	"NET_BIND_SERVICE": true, "SETFCAP": true, "SETGID": true, "SETPCAP": true, "SETUID": true, "SYS_CHROOT": true,
+}
+
+// pssSafeSysctls are the sysctls baseline allows pods to set
+var pssSafeSysctls = map[string]bool{
+	"kernel.shm_rmid_forced": true, "net.ipv4.ip_local_port_range": true, "net.ipv4.ip_unprivileged_port_start": true,
+	"net.ipv4.tcp_syncookies": true, "net.ipv4.ping_group_range": true, "net.ipv4.ip_local_reserved_ports": true,
+	"net.ipv4.tcp_keepalive_time": true, "net.ipv4.tcp_fin_timeout": true, "net.ipv4.tcp_keepalive_intvl": true,
+	"net.ipv4.tcp_keepalive_probes": true,
+}
+
+// pssSELinuxTypes are the SELinux types baseline allows
+var pssSELinuxTypes = map[string]bool{"": true, "container_t": true, "container_init_t": true, "container_kvm_t": true, "container_engine_t": true}
+
+// PSSCheckOptions contains options for the Pod Security Standards report
+type PSSCheckOptions struct {
+	Namespace string // All namespaces when empty
+	Level     string // Strictest level evaluated: baseline or restricted
+	Output    string // table or json
+	FailOn    string // Lowest severity that fails the run: error, warning, info or none
+}
+
+// pssViolation is one field of a pod that a Pod Security Standards level
+// forbids
+type pssViolation struct {
+	level string // baseline or restricted
+	check string // Name of the PSS control
+	field string // Offending field and value
+}
+
+// pssWorkload is a workload's pods and what they violate
+type pssWorkload struct {
+	namespace  string
+	name       string // Kind/name
+	pods       int
+	violations []pssViolation
+}
+
+// pssNamespace is the Pod Security state of one namespace
+type pssNamespace struct {
+	name      string
+	enforce   string
+	workloads int
+	baseline  int // Workloads violating baseline
+	restrict  int // Workloads violating restricted only
+}
+
+// CheckPodSecurity evaluates the running pods of every namespace against the
+// baseline and restricted Pod Security Standards, reports each workload that
+// would be rejected by a stricter level than its namespace enforces and the
+// fields that trigger it, and lists the namespaces that can move to a
+// stricter level today.
+func CheckPodSecurity(options PSSCheckOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	if options.Level != pssBaseline && options.Level != pssRestricted {
+		return fmt.Errorf("invalid level '%s' (must be baseline or restricted)", options.Level)
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+
+	var namespaceList []corev1.Namespace
+	if options.Namespace != "" {
+		namespace, err := clientset.CoreV1().Namespaces().Get(ctx, options.Namespace, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get namespace %s: %w", options.Namespace, err)
+		}
+		namespaceList = append(namespaceList, *namespace)
+	} else {
+		namespaces, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list namespaces: %w", err)
+		}
+		namespaceList = namespaces.Items
+	}
+	pods, err := clientset.CoreV1().Pods(options.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	replicaSets, err := clientset.AppsV1().ReplicaSets(options.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list replicasets: %w", err)
+	}
+	rsOwnerCache := make(map[string]string)
+	for _, rs := range replicaSets.Items {
+		for _, owner := range rs.OwnerReferences {
+			if owner.Kind == "Deployment" {
+				rsOwnerCache[rs.Namespace+"/"+rs.Name] = owner.Name
+			}
+		}
+	}
+
+	// Pods of a workload share a spec, so they are evaluated per workload
+	workloads := make(map[string]*pssWorkload)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		owner, ownerType := getPodOwnerFast(pod, rsOwnerCache)
+		key := fmt.Sprintf("%s/%s/%s", pod.Namespace, ownerType, owner)
+		if workload, ok := workloads[key]; ok {
+			workload.pods++
+			continue
+		}
+		var violations []pssViolation
+		for _, violation := range evaluatePodSecurity(pod) {
+			if pssLevelRank[violation.level] <= pssLevelRank[options.Level] {
+				violations = append(violations, violation)
+			}
+		}
+		workloads[key] = &pssWorkload{namespace: pod.Namespace, name: ownerType + "/" + owner, pods: 1, violations: violations}
+	}
+
+	namespaces := make(map[string]*pssNamespace)
+	for _, namespace := range namespaceList {
+		enforce := namespace.Labels[pssEnforceLabel]
+		if _, ok := pssLevelRank[enforce]; !ok {
+			enforce = pssPrivileged
+		}
+		namespaces[namespace.Name] = &pssNamespace{name: namespace.Name, enforce: enforce}
+	}
+
+	var findings []result.Finding
+	for _, key := range sortedKeys(workloads) {
+		workload := workloads[key]
+		namespace := namespaces[workload.namespace]
+		if namespace == nil {
+			continue
+		}
+		namespace.workloads++
+		byLevel := make(map[string][]pssViolation)
+		for _, violation := range workload.violations {
+			byLevel[violation.level] = append(byLevel[violation.level], violation)
+		}
+		switch {
+		case len(byLevel[pssBaseline]) > 0:
+			namespace.baseline++
+		case len(byLevel[pssRestricted]) > 0:
+			namespace.restrict++
+		}
+		for _, level := range []string{pssBaseline, pssRestricted} {
+			violations := byLevel[level]
+			if len(violations) == 0 {
+				continue
+			}
+			// Running pods can violate the enforced level when they were
+			// admitted before the label was set
+			severity := result.SeverityInfo
+			switch {
+			case pssLevelRank[level] <= pssLevelRank[namespace.enforce]:
+				severity = result.SeverityError
+			case level == pssBaseline:
+				severity = result.SeverityWarning
+			}
+			var checks, fields []string
+			for _, violation := range violations {
+				if !containsString(checks, violation.check) {
+					checks = append(checks, violation.check)
+				}
+				fields = append(fields, violation.field)
+			}
+			findings = append(findings, result.Finding{
+				Check:    level + "-violation",
+				Severity: severity,
+				Resource: workload.namespace + "/" + workload.name,
+				Message:  fmt.Sprintf("violates %s: %s", level, strings.Join(fields, ", ")),
+				Details: map[string]string{
+					"controls": strings.Join(checks, ","),
+					"enforced": namespace.enforce,
+					"pods":     fmt.Sprintf("%d", workload.pods),
+				},
+			})
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return result.Rank(findings[i].Severity) > result.Rank(findings[j].Severity)
+	})
+
+	if options.Output == "json" {
+		if err := result.New("pss-check", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	var ready []*pssNamespace
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tENFORCED\tWORKLOADS\tBASELINE VIOLATIONS\tRESTRICTED VIOLATIONS\tSTRICTEST PASSING")
+	for _, name := range sortedKeys(namespaces) {
+		namespace := namespaces[name]
+		passing := options.Level
+		switch {
+		case namespace.baseline > 0:
+			passing = pssPrivileged
+		case namespace.restrict > 0:
+			passing = pssBaseline
+		}
+		cell := "✅ " + passing
+		switch {
+		case pssLevelRank[passing] < pssLevelRank[namespace.enforce]:
+			cell = "❌ " + passing
+		case pssLevelRank[passing] > pssLevelRank[namespace.enforce]:
+			cell = "⬆️  " + passing
+			ready = append(ready, namespace)
+		}
+		restricted := fmt.Sprintf("%d", namespace.restrict)
+		if options.Level == pssBaseline {
+			restricted = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\n", name, namespace.enforce, namespace.workloads, namespace.baseline, restricted, cell)
+	}
+	w.Flush()
+
+	if len(findings) > 0 {
+		fmt.Println("\nWorkloads blocking a stricter level:")
+		for _, finding := range findings {
+			fmt.Printf("  %s %s (%s pods): %s\n", result.Label(finding.Severity), finding.Resource, finding.Details["pods"], finding.Message)
+		}
+	}
+
+	fmt.Println("\n--- Pod Security Summary ---")
+	fmt.Printf("Namespaces: %d, workloads: %d\n", len(namespaces), len(workloads))
+	if len(ready) == 0 {
+		fmt.Println("No namespace can move to a stricter level without changing workloads")
+	} else {
+		fmt.Printf("⬆️  %d namespaces can enforce a stricter level today:\n", len(ready))
+		for _, namespace := range ready {
+			level := pssRestricted
+			if namespace.restrict > 0 || options.Level == pssBaseline {
+				level = pssBaseline
+			}
+			fmt.Printf("   kubectl label namespace %s %s=%s --overwrite\n", namespace.name, pssEnforceLabel, level)
+		}
+	}
+	fmt.Println("Set pod-security.kubernetes.io/warn or audit to the next level first to catch new workloads before enforcing it.")
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// pssContainer is a container of any kind with the fields PSS checks
+type pssContainer struct {
+	path            string // e.g. spec.containers[app]
+	securityContext *corev1.SecurityContext
+	ports           []corev1.ContainerPort
+}
+
+// evaluatePodSecurity returns every baseline and restricted control a pod
+// violates, following the Pod Security Standards.
+func evaluatePodSecurity(pod *corev1.Pod) []pssViolation {
+	var violations []pssViolation
+	add := func(level, check, field string) {
+		violations = append(violations, pssViolation{level, check, field})
+	}
+	spec := pod.Spec
+	podSC := spec.SecurityContext
+	if podSC == nil {
+		podSC = &corev1.PodSecurityContext{}
+	}
+	linux := spec.OS == nil || spec.OS.Name != corev1.Windows
+
+	var containers []pssContainer
+	for _, container := range spec.InitContainers {
+		containers = append(containers, pssContainer{fmt.Sprintf("spec.initContainers[%s]", container.Name), container.SecurityContext, container.Ports})
+	}
+	for _, container := range spec.Containers {
+		containers = append(containers, pssContainer{fmt.Sprintf("spec.containers[%s]", container.Name), container.SecurityContext, container.Ports})
+	}
+	for _, container := range spec.EphemeralContainers {
+		containers = append(containers, pssContainer{fmt.Sprintf("spec.ephemeralContainers[%s]", container.Name), container.SecurityContext, container.Ports})
+	}
+
+	// Baseline
+	if spec.HostNetwork {
+		add(pssBaseline, "hostNamespaces", "spec.hostNetwork=true")
+	}
+	if spec.HostPID {
+		add(pssBaseline, "hostNamespaces", "spec.hostPID=true")
+	}
+	if spec.HostIPC {
+		add(pssBaseline, "hostNamespaces", "spec.hostIPC=true")
+	}
+	if podSC.WindowsOptions != nil && podSC.WindowsOptions.HostProcess != nil && *podSC.WindowsOptions.HostProcess {
+		add(pssBaseline, "hostProcess", "spec.securityContext.windowsOptions.hostProcess=true")
+	}
+	for _, volume := range spec.Volumes {
+		if volume.HostPath != nil {
+			add(pssBaseline, "hostPathVolumes", fmt.Sprintf("spec.volumes[%s].hostPath=%s", volume.Name, volume.HostPath.Path))
+		}
+	}
+	for key, value := range pod.Annotations {
+		if strings.HasPrefix(key, "container.apparmor.security.beta.kubernetes.io/") && value != "runtime/default" && !strings.HasPrefix(value, "localhost/") {
+			add(pssBaseline, "appArmorProfile", fmt.Sprintf("metadata.annotations[%s]=%s", key, value))
+		}
+	}
+	if podSC.AppArmorProfile != nil && podSC.AppArmorProfile.Type == corev1.AppArmorProfileTypeUnconfined {
+		add(pssBaseline, "appArmorProfile", "spec.securityContext.appArmorProfile.type=Unconfined")
+	}
+	if options := podSC.SELinuxOptions; options != nil && (!pssSELinuxTypes[options.Type] || options.User != "" || options.Role != "") {
+		add(pssBaseline, "seLinuxOptions", "spec.securityContext.seLinuxOptions")
+	}
+	if podSC.SeccompProfile != nil && podSC.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+		add(pssBaseline, "seccompProfile", "spec.securityContext.seccompProfile.type=Unconfined")
+	}
+	for _, sysctl := range podSC.Sysctls {
+		if !pssSafeSysctls[sysctl.Name] {
+			add(pssBaseline, "sysctls", "spec.securityContext.sysctls["+sysctl.Name+"]")
+		}
+	}
+	for _, container := range containers {
+		for _, port := range container.ports {
+			if port.HostPort != 0 {
+				add(pssBaseline, "hostPorts", fmt.Sprintf("%s.ports.hostPort=%d", container.path, port.HostPort))
+			}
+		}
+		sc := container.securityContext
+		if sc == nil {
+			continue
+		}
+		if sc.Privileged != nil && *sc.Privileged {
+			add(pssBaseline, "privileged", container.path+".securityContext.privileged=true")
+		}
+		if sc.WindowsOptions != nil && sc.WindowsOptions.HostProcess != nil && *sc.WindowsOptions.HostProcess {
+			add(pssBaseline, "hostProcess", container.path+".securityContext.windowsOptions.hostProcess=true")
+		}
+		if sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Add {
+				if !pssBaselineCapabilities[string(capability)] {
+					add(pssBaseline, "capabilities", fmt.Sprintf("%s.securityContext.capabilities.add=%s", container.path, capability))
+				}
+			}
+		}
+		if sc.AppArmorProfile != nil && sc.AppArmorProfile.Type == corev1.AppArmorProfileTypeUnconfined {
+			add(pssBaseline, "appArmorProfile", container.path+".securityContext.appArmorProfile.type=Unconfined")
+		}
+		if options := sc.SELinuxOptions; options != nil && (!pssSELinuxTypes[options.Type] || options.User != "" || options.Role != "") {
+			add(pssBaseline, "seLinuxOptions", container.path+".securityContext.seLinuxOptions")
+		}
+		if sc.ProcMount != nil && *sc.ProcMount != corev1.DefaultProcMount {
+			add(pssBaseline, "procMount", fmt.Sprintf("%s.securityContext.procMount=%s", container.path, *sc.ProcMount))
+		}
+		if sc.SeccompProfile != nil && sc.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+			add(pssBaseline, "seccompProfile", container.path+".securityContext.seccompProfile.type=Unconfined")
+		}
+	}
+
+	// Restricted
+	for _, volume := range spec.Volumes {
+		source := volume.VolumeSource
+		if source.HostPath != nil || source.ConfigMap != nil || source.CSI != nil || source.DownwardAPI != nil || source.EmptyDir != nil ||
+			source.Ephemeral != nil || source.PersistentVolumeClaim != nil || source.Projected != nil || source.Secret != nil {
+			continue
+		}
+		add(pssRestricted, "volumeTypes", fmt.Sprintf("spec.volumes[%s]", volume.Name))
+	}
+	if podSC.RunAsUser != nil && *podSC.RunAsUser == 0 {
+		add(pssRestricted, "runAsUser", "spec.securityContext.runAsUser=0")
+	}
+	podNonRoot := podSC.RunAsNonRoot != nil && *podSC.RunAsNonRoot
+	podSeccomp := podSC.SeccompProfile != nil
+	for _, container := range containers {
+		sc := container.securityContext
+		if sc == nil {
+			sc = &corev1.SecurityContext{}
+		}
+		if sc.RunAsNonRoot == nil && !podNonRoot || sc.RunAsNonRoot != nil && !*sc.RunAsNonRoot {
+			add(pssRestricted, "runAsNonRoot", container.path+".securityContext.runAsNonRoot != true")
+		}
+		if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+			add(pssRestricted, "runAsUser", container.path+".securityContext.runAsUser=0")
+		}
+		if !linux {
+			continue // The remaining controls don't apply to Windows pods
+		}
+		if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+			add(pssRestricted, "allowPrivilegeEscalation", container.path+".securityContext.allowPrivilegeEscalation != false")
+		}
+		if sc.SeccompProfile == nil && !podSeccomp {
+			add(pssRestricted, "seccompProfile", container.path+".securityContext.seccompProfile unset")
+		}
+		var drop []string
+		if sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Drop {
+				drop = append(drop, string(capability))
+			}
+			for _, capability := range sc.Capabilities.Add {
+				if capability != "NET_BIND_SERVICE" && pssBaselineCapabilities[string(capability)] {
+					add(pssRestricted, "capabilities", fmt.Sprintf("%s.securityContext.capabilities.add=%s", container.path, capability))
+				}
+			}
+		}
+		if !containsString(drop, "ALL") {
+			add(pssRestricted, "capabilities", container.path+".securityContext.capabilities.drop missing ALL")
+		}
+	}
+	return violations
+}
@@ -0,0 +1,245 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/aws/aws-sdk-go/aws/session"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ebsCSIDriver is the provisioner of the EBS CSI driver
+const ebsCSIDriver = "ebs.csi.aws.com"
+
+// PVCResizeOptions contains options for resizing a PVC
+type PVCResizeOptions struct {
+	Namespace string
+	Size      string // New size, e.g. 200Gi
+	Region    string // Defaults to the region of the volume's zone
+	Profile   string
+	Timeout   time.Duration
+}
+
+// ResizePVC grows a PVC after checking that its StorageClass allows expansion
+// and, for EBS volumes, that EBS will accept another modification. It then
+// follows the resize through the EBS modification and the filesystem
+// expansion on the node.
+func ResizePVC(name string, options PVCResizeOptions) error {
+	if options.Namespace == "" {
+		options.Namespace = "default"
+	}
+	size, err := resource.ParseQuantity(options.Size)
+	if err != nil {
+		return fmt.Errorf("invalid size '%s': %w", options.Size, err)
+	}
+
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+
+	pvc, err := clientset.CoreV1().PersistentVolumeClaims(options.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get PVC %s/%s: %w", options.Namespace, name, err)
+	}
+	current := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if size.Cmp(current) <= 0 {
+		return fmt.Errorf("new size %s must be larger than the current %s, volumes can't shrink", size.String(), current.String())
+	}
+	if pvc.Status.Phase != corev1.ClaimBound {
+		return fmt.Errorf("PVC %s is %s, only bound PVCs can be resized", name, pvc.Status.Phase)
+	}
+
+	className := ""
+	if pvc.Spec.StorageClassName != nil {
+		className = *pvc.Spec.StorageClassName
+	}
+	if className == "" {
+		return fmt.Errorf("PVC %s has no StorageClass, so it can't be expanded", name)
+	}
+	storageClass, err := clientset.StorageV1().StorageClasses().Get(ctx, className, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get StorageClass %s: %w", className, err)
+	}
+	if storageClass.AllowVolumeExpansion == nil || !*storageClass.AllowVolumeExpansion {
+		return fmt.Errorf("StorageClass %s does not allow volume expansion; enable it with:\n  kubectl patch storageclass %s -p '{\"allowVolumeExpansion\": true}'",
+			className, className)
+	}
+	fmt.Printf("✅ StorageClass %s allows volume expansion\n", className)
+
+	pv, err := clientset.CoreV1().PersistentVolumes().Get(ctx, pvc.Spec.VolumeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get PersistentVolume %s: %w", pvc.Spec.VolumeName, err)
+	}
+	var sess *session.Session
+	volumeID, zone := ebsVolumeOf(pv)
+	if volumeID == "" {
+		fmt.Printf("⚠️  %s is not an EBS volume, skipping the AWS checks\n", pv.Name)
+	} else {
+		region := options.Region
+		if region == "" && zone != "" {
+			region = zone[:len(zone)-1]
+		}
+		sess, err = awsutils.NewSession(options.Profile, region)
+		if err != nil {
+			return err
+		}
+		modification, err := awsutils.GetVolumeModification(sess, volumeID)
+		if err != nil {
+			return err
+		}
+		if modification != nil && modification.State != "failed" {
+			since := time.Since(modification.StartTime)
+			if modification.State == "modifying" || since < awsutils.EBSModificationCooldown {
+				return fmt.Errorf("EBS allows one modification of %s every %s; the last one started %s ago and is %s",
+					volumeID, awsutils.EBSModificationCooldown, since.Round(time.Minute), modification.State)
+			}
+		}
+		fmt.Printf("✅ EBS volume %s can be modified\n", volumeID)
+	}
+
+	patch := []byte(fmt.Sprintf(`{"spec":{"resources":{"requests":{"storage":%q}}}}`, size.String()))
+	if _, err := clientset.CoreV1().PersistentVolumeClaims(options.Namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch PVC %s: %w", name, err)
+	}
+	fmt.Printf("Requested %s for PVC %s/%s (was %s), waiting for the resize...\n", size.String(), options.Namespace, name, current.String())
+
+	capacity, ebsState, err := waitForPVCResize(clientset, sess, pvc, volumeID, size, options.Timeout)
+
+	fmt.Println("\n--- PVC Resize Summary ---")
+	fmt.Printf("Requested: %s\n", size.String())
+	fmt.Printf("Capacity: %s\n", capacity)
+	if volumeID != "" {
+		fmt.Printf("EBS volume %s: %s\n", volumeID, valueOrDash(ebsState))
+	}
+	fmt.Println("----------------------------------------------------")
+	return err
+}
+
+// waitForPVCResize prints the resize conditions and EBS modification state as
+// they change until the PVC reports the new capacity. It returns the last
+// capacity and EBS state seen.
+func waitForPVCResize(clientset *kubernetes.Clientset, sess *session.Session, pvc *corev1.PersistentVolumeClaim, volumeID string, size resource.Quantity, timeout time.Duration) (string, string, error) {
+	ctx := context.TODO()
+	capacity := pvc.Status.Capacity[corev1.ResourceStorage]
+	ebsState := ""
+	seen := make(map[string]bool)
+	report := func(message string) {
+		if !seen[message] {
+			seen[message] = true
+			fmt.Println(message)
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		if sess != nil {
+			modification, err := awsutils.GetVolumeModification(sess, volumeID)
+			if err != nil {
+				report(fmt.Sprintf("⚠️  %v", err))
+			} else if modification != nil && modification.TargetSize*1024*1024*1024 >= size.Value() {
+				ebsState = fmt.Sprintf("%s (%d%%)", modification.State, modification.Progress)
+				report(fmt.Sprintf("  EBS modification %s", ebsState))
+				if modification.State == "failed" {
+					return capacity.String(), ebsState, fmt.Errorf("EBS modification of %s failed: %s", volumeID, modification.StatusMessage)
+				}
+			}
+		}
+
+		current, err := clientset.CoreV1().PersistentVolumeClaims(pvc.Namespace).Get(ctx, pvc.Name, metav1.GetOptions{})
+		if err != nil {
+			return capacity.String(), ebsState, fmt.Errorf("failed to get PVC %s: %w", pvc.Name, err)
+		}
+		capacity = current.Status.Capacity[corev1.ResourceStorage]
+		if capacity.Cmp(size) >= 0 {
+			fmt.Printf("✅ PVC %s now has %s\n", pvc.Name, capacity.String())
+			return capacity.String(), ebsState, nil
+		}
+
+		for _, status := range current.Status.AllocatedResourceStatuses {
+			if strings.HasSuffix(string(status), "Infeasible") {
+				return capacity.String(), ebsState, fmt.Errorf("resize is infeasible (%s), check the PVC's events", status)
+			}
+		}
+		for _, condition := range current.Status.Conditions {
+			switch condition.Type {
+			case corev1.PersistentVolumeClaimResizing:
+				report("  Resizing the volume...")
+			case corev1.PersistentVolumeClaimControllerResizeError, corev1.PersistentVolumeClaimNodeResizeError:
+				report(fmt.Sprintf("⚠️  %s: %s", condition.Type, condition.Message))
+			case corev1.PersistentVolumeClaimFileSystemResizePending:
+				report("  Volume resized, waiting for the kubelet to expand the filesystem...")
+				mounted, err := pvcMountedByRunningPod(clientset, current)
+				if err != nil {
+					return capacity.String(), ebsState, err
+				}
+				if !mounted {
+					fmt.Println("⚠️  No running pod mounts the PVC; the filesystem is expanded when one does.")
+					return capacity.String(), ebsState, nil
+				}
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return capacity.String(), ebsState, fmt.Errorf("timed out after %s waiting for the resize", timeout)
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+// pvcMountedByRunningPod reports whether a running pod uses the PVC.
+func pvcMountedByRunningPod(clientset *kubernetes.Clientset, pvc *corev1.PersistentVolumeClaim) (bool, error) {
+	pods, err := clientset.CoreV1().Pods(pvc.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == pvc.Name {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// ebsVolumeOf returns the EBS volume ID and availability zone behind a
+// PersistentVolume provisioned by the EBS CSI driver or the in-tree plugin,
+// or empty strings for other volume types.
+func ebsVolumeOf(pv *corev1.PersistentVolume) (string, string) {
+	zone := pv.Labels["topology.kubernetes.io/zone"]
+	if affinity := pv.Spec.NodeAffinity; affinity != nil && affinity.Required != nil {
+		for _, term := range affinity.Required.NodeSelectorTerms {
+			for _, expression := range term.MatchExpressions {
+				if strings.HasSuffix(expression.Key, "/zone") && len(expression.Values) > 0 {
+					zone = expression.Values[0]
+				}
+			}
+		}
+	}
+
+	switch {
+	case pv.Spec.CSI != nil && pv.Spec.CSI.Driver == ebsCSIDriver:
+		return pv.Spec.CSI.VolumeHandle, zone
+	case pv.Spec.AWSElasticBlockStore != nil:
+		// In-tree volume IDs look like aws://us-east-1a/vol-0123456789abcdef0
+		volumeID := pv.Spec.AWSElasticBlockStore.VolumeID
+		if parts := strings.Split(strings.TrimPrefix(volumeID, "aws://"), "/"); len(parts) == 2 {
+			return parts[1], parts[0]
+		}
+		return volumeID, zone
+	}
+	return "", ""
+}
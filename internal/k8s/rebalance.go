@@ -0,0 +1,561 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/ui"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/kubernetes"
+)
+
+// safeToEvictAnnotation is the cluster-autoscaler annotation that marks pods
+// as movable, or never to be moved when "false"
+const safeToEvictAnnotation = "cluster-autoscaler.kubernetes.io/safe-to-evict"
+
+// RebalanceOptions contains options for evening out the load of the nodes
+type RebalanceOptions struct {
+	Threshold      float64 // Percentage points above the mean load that make a node overloaded
+	MaxEvictions   int
+	Execute        bool
+	Yes            bool // Execute every step without asking
+	NonInteractive bool
+	Timeout        time.Duration // How long to wait for each evicted pod's Deployment to be ready again
+}
+
+// rebalanceNode is a node's requested load while the plan is simulated
+type rebalanceNode struct {
+	node        corev1.Node
+	info        NodeInfo
+	cpuRequests float64 // Cores
+	memRequests float64 // GiB
+	pods        []*corev1.Pod
+	eligible    bool // Ready and schedulable
+}
+
+// load is the larger of the node's CPU and memory requests in percent of
+// its capacity.
+func (n *rebalanceNode) load() float64 {
+	var cpu, memory float64
+	if n.info.CPUCapacity > 0 {
+		cpu = n.cpuRequests / n.info.CPUCapacity * 100
+	}
+	if n.info.MemoryCapacity > 0 {
+		memory = n.memRequests / n.info.MemoryCapacity * 100
+	}
+	return math.Max(cpu, memory)
+}
+
+// rebalanceMove is one eviction of the plan
+type rebalanceMove struct {
+	pod        *corev1.Pod
+	deployment string
+	from, to   string
+	cpu, mem   float64
+}
+
+// Rebalance finds nodes whose requested CPU or memory is far above the
+// cluster mean, as reported by pod-density, and plans evictions of
+// Deployment pods that can safely be rescheduled on the least loaded nodes.
+// A pod is only planned when its Deployment has another ready replica, its
+// PodDisruptionBudgets allow a disruption, it keeps no local data and a
+// less loaded node satisfies its selectors, taints and anti-affinity. With
+// Execute the plan is carried out one eviction at a time, waiting for the
+// Deployment to be ready again in between.
+func Rebalance(options RebalanceOptions) error {
+	if options.Execute && !options.Yes && (options.NonInteractive || !ui.IsInteractive()) {
+		return fmt.Errorf("executing the plan asks before each eviction, pass --yes to run it without prompts")
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	density, err := CollectPodDensity()
+	if err != nil {
+		return err
+	}
+	nodeList, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	podList, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	replicaSets, err := clientset.AppsV1().ReplicaSets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list replicasets: %w", err)
+	}
+	deployments, err := clientset.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list deployments: %w", err)
+	}
+	pdbs, err := clientset.PolicyV1().PodDisruptionBudgets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list PodDisruptionBudgets: %w", err)
+	}
+
+	infos := make(map[string]NodeInfo)
+	for _, info := range density {
+		infos[info.Name] = info
+	}
+	var nodes []*rebalanceNode
+	byName := make(map[string]*rebalanceNode)
+	for _, node := range nodeList.Items {
+		info := infos[node.Name]
+		n := &rebalanceNode{
+			node:        node,
+			info:        info,
+			cpuRequests: info.CPURequests,
+			memRequests: info.MemoryRequests,
+			eligible:    !node.Spec.Unschedulable && getNodeReadyStatus(node) == "True",
+		}
+		nodes = append(nodes, n)
+		byName[node.Name] = n
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].node.Name < nodes[j].node.Name })
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if n, ok := byName[pod.Spec.NodeName]; ok && pod.Status.Phase == corev1.PodRunning {
+			n.pods = append(n.pods, pod)
+		}
+	}
+
+	mean, eligibleCount := 0.0, 0
+	for _, n := range nodes {
+		if n.eligible {
+			mean += n.load()
+			eligibleCount++
+		}
+	}
+	if eligibleCount < 2 {
+		return fmt.Errorf("rebalancing needs at least 2 schedulable nodes, found %d", eligibleCount)
+	}
+	mean /= float64(eligibleCount)
+	spreadBefore := loadSpread(nodes)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tPODS\tCPU REQ\tMEM REQ\tLOAD\tSTATUS")
+	var overloaded []*rebalanceNode
+	for _, n := range nodes {
+		status := ""
+		switch {
+		case n.node.Spec.Unschedulable:
+			status = "cordoned"
+		case !n.eligible:
+			status = "not ready"
+		case n.load() >= mean+options.Threshold:
+			status = "⚠️  overloaded"
+			overloaded = append(overloaded, n)
+		case n.load() <= mean-options.Threshold:
+			status = "underloaded"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.1f/%.1f\t%.1f/%.1fGi\t%.0f%%\t%s\n", n.node.Name, n.info.PodCount,
+			n.cpuRequests, n.info.CPUCapacity, n.memRequests, n.info.MemoryCapacity, n.load(), status)
+	}
+	w.Flush()
+	fmt.Printf("\nMean load: %.0f%% (the larger of CPU and memory requests); overloaded from %.0f%%\n", mean, mean+options.Threshold)
+	if len(overloaded) == 0 {
+		fmt.Printf("✅ No node is more than %.0f points above the mean, nothing to rebalance\n", options.Threshold)
+		return nil
+	}
+	sort.SliceStable(overloaded, func(i, j int) bool { return overloaded[i].load() > overloaded[j].load() })
+
+	deploymentOfRS := make(map[string]string)
+	for _, rs := range replicaSets.Items {
+		for _, owner := range rs.OwnerReferences {
+			if owner.Kind == "Deployment" {
+				deploymentOfRS[rs.Namespace+"/"+rs.Name] = owner.Name
+			}
+		}
+	}
+	readyReplicas := make(map[string]int32)
+	for _, deployment := range deployments.Items {
+		readyReplicas[deployment.Namespace+"/"+deployment.Name] = deployment.Status.ReadyReplicas
+	}
+
+	var moves []rebalanceMove
+	left := make(map[string]int)
+	planned := make(map[*corev1.Pod]bool)
+	for _, source := range overloaded {
+		type candidate struct {
+			pod        *corev1.Pod
+			deployment string
+		}
+		var candidates []candidate
+		for _, pod := range source.pods {
+			deployment, reason := rebalanceBlocker(pod, deploymentOfRS, readyReplicas, pdbs.Items)
+			switch reason {
+			case "":
+				candidates = append(candidates, candidate{pod, deployment})
+			case "DaemonSet or static pod":
+			default:
+				left[reason]++
+			}
+		}
+
+		for len(moves) < options.MaxEvictions && source.load() > mean+options.Threshold/2 {
+			var best *rebalanceMove
+			bestWorst := source.load()
+			for _, c := range candidates {
+				if planned[c.pod] {
+					continue
+				}
+				cpu, mem := podRequests(c.pod)
+				for _, target := range nodes {
+					if target == source || !canHostPod(target, c.pod, cpu, mem, nodes) {
+						continue
+					}
+					worst := math.Max(loadAfter(source, -cpu, -mem), loadAfter(target, cpu, mem))
+					if worst < bestWorst {
+						bestWorst = worst
+						best = &rebalanceMove{pod: c.pod, deployment: c.deployment, from: source.node.Name, to: target.node.Name, cpu: cpu, mem: mem}
+					}
+				}
+			}
+			if best == nil {
+				break
+			}
+			target := byName[best.to]
+			source.cpuRequests -= best.cpu
+			source.memRequests -= best.mem
+			target.cpuRequests += best.cpu
+			target.memRequests += best.mem
+			source.pods = removePod(source.pods, best.pod)
+			target.pods = append(target.pods, best.pod)
+			planned[best.pod] = true
+			moves = append(moves, *best)
+		}
+	}
+
+	if len(moves) == 0 {
+		fmt.Println("\n❌ No pod on the overloaded nodes can be moved safely")
+	} else {
+		fmt.Println("\nPlan:")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "STEP\tPOD\tDEPLOYMENT\tFROM\tLIKELY TO\tCPU\tMEMORY")
+		for i, move := range moves {
+			fmt.Fprintf(w, "%d\t%s/%s\t%s\t%s\t%s\t%.2f\t%.2fGi\n", i+1, move.pod.Namespace, move.pod.Name, move.deployment, move.from, move.to, move.cpu, move.mem)
+		}
+		w.Flush()
+		fmt.Printf("Load spread (most minus least loaded node): %.0f → %.0f points\n", spreadBefore, loadSpread(nodes))
+		fmt.Println("The scheduler makes the final placement; LIKELY TO is the least loaded node the pod fits on.")
+	}
+	if len(left) > 0 {
+		fmt.Println("\nPods left on the overloaded nodes:")
+		for _, reason := range sortedKeys(left) {
+			fmt.Printf("  %d %s\n", left[reason], reason)
+		}
+	}
+	if len(moves) == 0 || !options.Execute {
+		if len(moves) > 0 {
+			fmt.Println("\nRun again with --execute to evict the pods step by step.")
+		}
+		return nil
+	}
+	return executeRebalance(ctx, cancel, clientset, moves, options)
+}
+
+// rebalanceBlocker returns the Deployment the pod belongs to, and why it
+// can't be moved, if it can't.
+func rebalanceBlocker(pod *corev1.Pod, deploymentOfRS map[string]string, readyReplicas map[string]int32, pdbs []policyv1.PodDisruptionBudget) (string, string) {
+	if _, isMirror := pod.Annotations[corev1.MirrorPodAnnotationKey]; isMirror || daemonSetOwner(*pod) != "" {
+		return "", "DaemonSet or static pod"
+	}
+	if pod.Annotations[safeToEvictAnnotation] == "false" {
+		return "", "marked " + safeToEvictAnnotation + "=false"
+	}
+	deployment := ""
+	for _, owner := range pod.OwnerReferences {
+		if owner.Kind == "ReplicaSet" {
+			deployment = deploymentOfRS[pod.Namespace+"/"+owner.Name]
+		}
+	}
+	if deployment == "" {
+		return "", "not managed by a Deployment"
+	}
+	if readyReplicas[pod.Namespace+"/"+deployment] < 2 {
+		return deployment, "only ready replica of their Deployment"
+	}
+	ready := false
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+			ready = true
+		}
+	}
+	if !ready {
+		return deployment, "not ready"
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim != nil {
+			return deployment, "using a PersistentVolumeClaim"
+		}
+		if volume.EmptyDir != nil && pod.Annotations[safeToEvictAnnotation] != "true" {
+			return deployment, "using local storage (emptyDir)"
+		}
+	}
+	if affinity := pod.Spec.Affinity; affinity != nil && affinity.PodAffinity != nil && len(affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution) > 0 {
+		return deployment, "bound to other pods by required pod affinity"
+	}
+	if cpu, mem := podRequests(pod); cpu == 0 && mem == 0 {
+		return deployment, "without resource requests"
+	}
+	for _, pdb := range pdbs {
+		if pdb.Namespace == pod.Namespace && selectorMatches(pdb.Spec.Selector, labels.Set(pod.Labels)) && pdb.Status.DisruptionsAllowed < 1 {
+			return deployment, "covered by a PodDisruptionBudget allowing no disruption"
+		}
+	}
+	return deployment, ""
+}
+
+// canHostPod reports whether the pod could be scheduled on the node: it is
+// Ready and schedulable, matches the pod's node selector and required node
+// affinity, has its taints tolerated, has room for the pod's requests and
+// no required anti-affinity of the pod, or of the pods around it, objects.
+func canHostPod(target *rebalanceNode, pod *corev1.Pod, cpu, mem float64, nodes []*rebalanceNode) bool {
+	if !target.eligible || len(eligibleNodes(pod.Spec, []corev1.Node{target.node})) == 0 {
+		return false
+	}
+	if !nodeAffinityMatches(pod, target.node) {
+		return false
+	}
+	if target.cpuRequests+cpu > target.info.CPUCapacity || target.memRequests+mem > target.info.MemoryCapacity {
+		return false
+	}
+	for _, n := range nodes {
+		for _, other := range n.pods {
+			if other == pod {
+				continue
+			}
+			if antiAffinityConflict(pod, other, target.node, n.node) || antiAffinityConflict(other, pod, n.node, target.node) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// antiAffinityConflict reports whether a required anti-affinity term of pod,
+// placed on node, rejects other running on otherNode.
+func antiAffinityConflict(pod, other *corev1.Pod, node, otherNode corev1.Node) bool {
+	if pod.Spec.Affinity == nil || pod.Spec.Affinity.PodAntiAffinity == nil {
+		return false
+	}
+	for _, term := range pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+		domain, ok := node.Labels[term.TopologyKey]
+		if !ok || otherNode.Labels[term.TopologyKey] != domain {
+			continue
+		}
+		namespaces := term.Namespaces
+		if len(namespaces) == 0 && term.NamespaceSelector == nil {
+			namespaces = []string{pod.Namespace}
+		}
+		if len(namespaces) > 0 && !containsString(namespaces, other.Namespace) {
+			continue
+		}
+		if selectorMatches(term.LabelSelector, labels.Set(other.Labels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeAffinityMatches reports whether the node satisfies one of the terms of
+// the pod's required node affinity.
+func nodeAffinityMatches(pod *corev1.Pod, node corev1.Node) bool {
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return true
+	}
+	operators := map[corev1.NodeSelectorOperator]selection.Operator{
+		corev1.NodeSelectorOpIn:           selection.In,
+		corev1.NodeSelectorOpNotIn:        selection.NotIn,
+		corev1.NodeSelectorOpExists:       selection.Exists,
+		corev1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+		corev1.NodeSelectorOpGt:           selection.GreaterThan,
+		corev1.NodeSelectorOpLt:           selection.LessThan,
+	}
+	for _, term := range affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		matches := true
+		for _, expression := range term.MatchExpressions {
+			requirement, err := labels.NewRequirement(expression.Key, operators[expression.Operator], expression.Values)
+			if err != nil || !requirement.Matches(labels.Set(node.Labels)) {
+				matches = false
+				break
+			}
+		}
+		for _, field := range term.MatchFields {
+			if field.Key == "metadata.name" && !containsString(field.Values, node.Name) {
+				matches = false
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// loadAfter is the node's load with cpu and mem added to its requests.
+func loadAfter(n *rebalanceNode, cpu, mem float64) float64 {
+	moved := *n
+	moved.cpuRequests += cpu
+	moved.memRequests += mem
+	return moved.load()
+}
+
+// loadSpread is the difference between the most and least loaded
+// schedulable nodes.
+func loadSpread(nodes []*rebalanceNode) float64 {
+	highest, lowest := 0.0, math.MaxFloat64
+	for _, n := range nodes {
+		if n.eligible {
+			highest = math.Max(highest, n.load())
+			lowest = math.Min(lowest, n.load())
+		}
+	}
+	return highest - lowest
+}
+
+func removePod(pods []*corev1.Pod, pod *corev1.Pod) []*corev1.Pod {
+	var kept []*corev1.Pod
+	for _, p := range pods {
+		if p != pod {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// executeRebalance evicts the planned pods one at a time, asking before each
+// unless --yes is set, and waits for the pod's Deployment to be fully ready
+// before the next eviction. A Deployment that doesn't recover stops the plan.
+func executeRebalance(ctx context.Context, cancel context.CancelFunc, clientset *kubernetes.Clientset, moves []rebalanceMove, options RebalanceOptions) error {
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+	go func() {
+		if _, ok := <-stop; ok {
+			fmt.Println("\nInterrupted, not evicting further pods...")
+			cancel()
+		}
+	}()
+
+	var evicted, skipped int
+	var failure error
+	for i, move := range moves {
+		if ctx.Err() != nil {
+			break
+		}
+		prompt := fmt.Sprintf("\nStep %d/%d: evict %s/%s from %s (likely to %s)", i+1, len(moves), move.pod.Namespace, move.pod.Name, move.from, move.to)
+		if options.Yes {
+			fmt.Println(prompt)
+		} else {
+			answer, err := ui.ConfirmStep(prompt)
+			if err != nil {
+				return err
+			}
+			if answer == ui.StepQuit {
+				break
+			}
+			if answer == ui.StepSkip {
+				skipped++
+				continue
+			}
+		}
+
+		evictedAt := time.Now()
+		eviction := &policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: move.pod.Name, Namespace: move.pod.Namespace}}
+		err := clientset.PolicyV1().Evictions(move.pod.Namespace).Evict(ctx, eviction)
+		switch {
+		case apierrors.IsNotFound(err):
+			fmt.Println("⚠️  Pod is already gone, skipped")
+			skipped++
+			continue
+		case apierrors.IsTooManyRequests(err):
+			fmt.Println("⚠️  Eviction blocked by a PodDisruptionBudget, skipped")
+			skipped++
+			continue
+		case err != nil:
+			failure = fmt.Errorf("failed to evict pod %s/%s: %w", move.pod.Namespace, move.pod.Name, err)
+		}
+		if failure != nil {
+			break
+		}
+		evicted++
+
+		node, err := waitForRebalancedDeployment(ctx, clientset, move, evictedAt, options.Timeout)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			failure = err
+			break
+		}
+		fmt.Printf("✅ Deployment %s is ready again, the replacement runs on %s\n", move.deployment, valueOrDash(node))
+	}
+
+	fmt.Println("\n--- Rebalance Summary ---")
+	fmt.Printf("Evicted: %d\n", evicted)
+	fmt.Printf("Skipped: %d\n", skipped)
+	if remaining := len(moves) - evicted - skipped; remaining > 0 {
+		fmt.Printf("⚠️  Not run: %d\n", remaining)
+	}
+	fmt.Println("Run rebalance again to see the new balance.")
+	fmt.Println("----------------------------------------------------")
+	return failure
+}
+
+// waitForRebalancedDeployment waits until the evicted pod is gone and its
+// Deployment has all replicas ready, and returns the node of the newest
+// replacement pod.
+func waitForRebalancedDeployment(ctx context.Context, clientset *kubernetes.Clientset, move rebalanceMove, evictedAt time.Time, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		deployment, err := clientset.AppsV1().Deployments(move.pod.Namespace).Get(ctx, move.deployment, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to get deployment %s: %w", move.deployment, err)
+		}
+		_, podErr := clientset.CoreV1().Pods(move.pod.Namespace).Get(ctx, move.pod.Name, metav1.GetOptions{})
+		desired := int32(1)
+		if deployment.Spec.Replicas != nil {
+			desired = *deployment.Spec.Replicas
+		}
+		if apierrors.IsNotFound(podErr) && deployment.Status.ReadyReplicas >= desired {
+			node := ""
+			selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+			if err == nil {
+				pods, err := clientset.CoreV1().Pods(move.pod.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+				if err == nil {
+					newest := evictedAt
+					for _, pod := range pods.Items {
+						if pod.CreationTimestamp.After(newest) {
+							newest, node = pod.CreationTimestamp.Time, pod.Spec.NodeName
+						}
+					}
+				}
+			}
+			return node, nil
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("deployment %s is not ready again after %s (%d/%d ready)", move.deployment, timeout, deployment.Status.ReadyReplicas, desired)
+		}
+		if err := sleepContext(ctx, 3*time.Second); err != nil {
+			return "", err
+		}
+	}
+}
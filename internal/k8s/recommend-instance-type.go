@@ -0,0 +1,302 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultRecommendFamilies are the instance families recommend-instance-type
+// considers: current general purpose, compute and memory optimized
+// generations, Intel, AMD and Graviton
+var DefaultRecommendFamilies = []string{
+	"m5", "m6i", "m6a", "m6g", "m7i", "m7a", "m7g", "m8g",
+	"c5", "c6i", "c6a", "c6g", "c7i", "c7a", "c7g", "c8g",
+	"r5", "r6i", "r6a", "r6g", "r7i", "r7a", "r7g", "r8g",
+}
+
+// RecommendInstanceTypeOptions contains options for the instance type recommendation
+type RecommendInstanceTypeOptions struct {
+	Families          []string // Instance families to consider, default DefaultRecommendFamilies
+	TargetUtilization float64  // Share of allocatable resources the requests may fill
+	MinNodes          int      // Never recommend fewer nodes, for availability
+	Top               int      // Candidates shown
+	Region            string   // Defaults to the region of the nodes
+	Profile           string
+}
+
+// instanceCandidate is an instance type sized for the node group's pods
+type instanceCandidate struct {
+	spec    awsutils.InstanceTypeSpec
+	nodes   int
+	cpuFit  float64 // Requested share of allocatable CPU
+	memFit  float64
+	monthly float64
+	current bool
+}
+
+// RecommendInstanceType analyses the CPU and memory requests of the pods
+// running on a node group, found by its EKS, Karpenter or eksctl node label,
+// and sizes every instance type of the candidate families to fit them: the
+// largest pod and the group's DaemonSets must fit on one node, and the
+// requests may fill TargetUtilization of the allocatable resources. The
+// cheapest fits are listed with their monthly cost and savings against the
+// group's current nodes, priced from the cost estimate table.
+func RecommendInstanceType(group string, options RecommendInstanceTypeOptions) error {
+	if options.TargetUtilization <= 0 || options.TargetUtilization > 1 {
+		return fmt.Errorf("--target-utilization must be between 0 and 1")
+	}
+	if len(options.Families) == 0 {
+		options.Families = DefaultRecommendFamilies
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	nodeList, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	groupNodes := make(map[string]corev1.Node)
+	groups := make(map[string]bool)
+	for _, node := range nodeList.Items {
+		name, _ := nodeGroupOf(node)
+		groups[name] = true
+		if name == group {
+			groupNodes[node.Name] = node
+		}
+	}
+	if len(groupNodes) == 0 {
+		return fmt.Errorf("no nodes in node group %s (node groups: %s)", group, strings.Join(sortedKeys(groups), ", "))
+	}
+	pricing, err := loadPricingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load pricing config: %w", err)
+	}
+	density, err := CollectPodDensity()
+	if err != nil {
+		return err
+	}
+
+	// Requests of the group's pods, one entry per pod, and the DaemonSet
+	// overhead every node carries
+	var podCPU, podMem []float64
+	var dsCPU, dsMem float64
+	var dsPods, unrequested int
+	for _, info := range density {
+		if _, ok := groupNodes[info.Name]; !ok {
+			continue
+		}
+		for _, owner := range info.Owners {
+			if owner.Type == "DaemonSet" {
+				dsCPU += owner.CPURequest
+				dsMem += owner.MemRequest
+				dsPods += owner.PodCount
+				continue
+			}
+			for i := 0; i < owner.PodCount; i++ {
+				podCPU = append(podCPU, owner.CPURequest/float64(owner.PodCount))
+				podMem = append(podMem, owner.MemRequest/float64(owner.PodCount))
+			}
+			if owner.CPURequest == 0 && owner.MemRequest == 0 {
+				unrequested += owner.PodCount
+			}
+		}
+	}
+	nodeCount := float64(len(groupNodes))
+	dsCPU, dsMem = dsCPU/nodeCount, dsMem/nodeCount
+	dsPodsPerNode := int(math.Ceil(float64(dsPods) / nodeCount))
+	sort.Float64s(podCPU)
+	sort.Float64s(podMem)
+	var workCPU, workMem float64
+	for i := range podCPU {
+		workCPU += podCPU[i]
+		workMem += podMem[i]
+	}
+
+	var currentMonthly, allocCPU, allocMem float64
+	currentTypes := make(map[string]int)
+	region := options.Region
+	for _, node := range groupNodes {
+		instanceType := getNodeInstanceType(node)
+		currentTypes[instanceType]++
+		currentMonthly += pricing.EC2Pricing[instanceType] * 730
+		allocCPU += float64(node.Status.Allocatable.Cpu().MilliValue()) / 1000
+		allocMem += float64(node.Status.Allocatable.Memory().Value()) / (1024 * 1024 * 1024)
+		if region == "" {
+			region = node.Labels["topology.kubernetes.io/region"]
+		}
+	}
+
+	var candidateTypes []string
+	for instanceType := range pricing.EC2Pricing {
+		family, size, _ := strings.Cut(instanceType, ".")
+		if (containsString(options.Families, family) && !strings.HasPrefix(size, "metal")) || currentTypes[instanceType] > 0 {
+			candidateTypes = append(candidateTypes, instanceType)
+		}
+	}
+	sort.Strings(candidateTypes)
+	specs, err := awsutils.DescribeInstanceTypeSpecs(options.Profile, region, candidateTypes)
+	if err != nil {
+		return err
+	}
+
+	largestCPU, largestMem := 0.0, 0.0
+	if len(podCPU) > 0 {
+		largestCPU, largestMem = podCPU[len(podCPU)-1], podMem[len(podMem)-1]
+	}
+	var candidates []instanceCandidate
+	for _, instanceType := range candidateTypes {
+		spec, ok := specs[instanceType]
+		if !ok {
+			continue
+		}
+		candidate, fits := sizeInstanceCandidate(spec, pricing.EC2Pricing[instanceType], workCPU, workMem, len(podCPU),
+			largestCPU, largestMem, dsCPU, dsMem, dsPodsPerNode, options)
+		if !fits {
+			continue
+		}
+		candidate.current = currentTypes[instanceType] > 0
+		candidates = append(candidates, candidate)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].monthly != candidates[j].monthly {
+			return candidates[i].monthly < candidates[j].monthly
+		}
+		return candidates[i].spec.Name < candidates[j].spec.Name
+	})
+
+	var current []string
+	for _, instanceType := range sortedKeys(currentTypes) {
+		current = append(current, fmt.Sprintf("%d × %s", currentTypes[instanceType], instanceType))
+	}
+	fmt.Printf("Node group %s: %s, $%.2f/month\n", group, strings.Join(current, ", "), currentMonthly)
+	fmt.Printf("Requests fill %.0f%% of allocatable CPU and %.0f%% of memory\n\n",
+		safeRatio(workCPU+dsCPU*nodeCount, allocCPU)*100, safeRatio(workMem+dsMem*nodeCount, allocMem)*100)
+
+	fmt.Printf("Pods: %d, plus %d DaemonSet pods per node requesting %.2f CPU and %.2f GiB\n", len(podCPU), dsPodsPerNode, dsCPU, dsMem)
+	if len(podCPU) > 0 {
+		fmt.Printf("CPU requests:    p50 %.2f, p90 %.2f, max %.2f, total %.2f\n",
+			podCPU[len(podCPU)/2], podCPU[len(podCPU)*9/10], largestCPU, workCPU)
+		fmt.Printf("Memory requests: p50 %.2f GiB, p90 %.2f GiB, max %.2f GiB, total %.2f GiB\n",
+			podMem[len(podMem)/2], podMem[len(podMem)*9/10], largestMem, workMem)
+		if workCPU > 0 {
+			ratio := workMem / workCPU
+			shape := "general purpose (m, 4 GiB per vCPU)"
+			switch {
+			case ratio < 3:
+				shape = "compute optimized (c, 2 GiB per vCPU)"
+			case ratio > 6:
+				shape = "memory optimized (r, 8 GiB per vCPU)"
+			}
+			fmt.Printf("Requests ask for %.1f GiB per vCPU, the shape of %s instances\n", ratio, shape)
+		}
+	}
+	if unrequested > 0 {
+		fmt.Printf("⚠️  %d pods request no CPU or memory; they are counted against the pod limit only\n", unrequested)
+	}
+	fmt.Println()
+
+	if len(candidates) == 0 {
+		return fmt.Errorf("no instance type of %s fits the largest pod and the DaemonSets", strings.Join(options.Families, ", "))
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "INSTANCE TYPE\tARCH\tVCPU\tMEMORY\tMAX PODS\tNODES\tCPU FIT\tMEM FIT\tMONTHLY\tSAVINGS")
+	shown := 0
+	for _, candidate := range candidates {
+		if shown >= options.Top && !candidate.current {
+			continue
+		}
+		shown++
+		name := candidate.spec.Name
+		if candidate.current {
+			name += " (current)"
+		}
+		arch := candidate.spec.Architecture
+		if arch == "arm64" {
+			arch = "arm64 (Graviton)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%.0f GiB\t%d\t%d\t%.0f%%\t%.0f%%\t$%.2f\t%s\n", name, arch, candidate.spec.VCPUs,
+			candidate.spec.MemoryGiB, candidate.spec.MaxPods, candidate.nodes, candidate.cpuFit*100, candidate.memFit*100,
+			candidate.monthly, formatSavings(currentMonthly, candidate.monthly))
+	}
+	w.Flush()
+
+	fmt.Println("\n--- Instance Type Recommendation Summary ---")
+	for _, arch := range []string{"x86_64", "arm64"} {
+		for _, candidate := range candidates {
+			if candidate.spec.Architecture != arch {
+				continue
+			}
+			label := "Cheapest x86:     "
+			if arch == "arm64" {
+				label = "Cheapest Graviton:"
+			}
+			fmt.Printf("%s %d × %s, $%.2f/month (%s)\n", label, candidate.nodes, candidate.spec.Name, candidate.monthly,
+				formatSavings(currentMonthly, candidate.monthly))
+			break
+		}
+	}
+	fmt.Printf("Sized for %.0f%% target utilization and at least %d nodes, on-demand prices from the cost estimate table\n",
+		options.TargetUtilization*100, options.MinNodes)
+	fmt.Println("Graviton types need arm64 builds of every image on the node group")
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// sizeInstanceCandidate works out how many nodes of an instance type the
+// pods need, and reports false when the largest pod doesn't fit on one
+func sizeInstanceCandidate(spec awsutils.InstanceTypeSpec, hourly, workCPU, workMem float64, pods int,
+	largestCPU, largestMem, dsCPU, dsMem float64, dsPods int, options RecommendInstanceTypeOptions) (instanceCandidate, bool) {
+	allocCPU, allocMem := eksAllocatable(spec)
+	usableCPU, usableMem, podSlots := allocCPU-dsCPU, allocMem-dsMem, spec.MaxPods-dsPods
+	if hourly == 0 || usableCPU < largestCPU || usableMem < largestMem || podSlots < 1 {
+		return instanceCandidate{}, false
+	}
+	nodes := max(options.MinNodes,
+		int(math.Ceil(workCPU/(usableCPU*options.TargetUtilization))),
+		int(math.Ceil(workMem/(usableMem*options.TargetUtilization))),
+		int(math.Ceil(float64(pods)/float64(podSlots))), 1)
+	total := float64(nodes)
+	return instanceCandidate{
+		spec:    spec,
+		nodes:   nodes,
+		cpuFit:  (workCPU + dsCPU*total) / (allocCPU * total),
+		memFit:  (workMem + dsMem*total) / (allocMem * total),
+		monthly: hourly * 730 * total,
+	}, true
+}
+
+// eksAllocatable estimates the allocatable CPU and memory GiB of an
+// instance type from the kube-reserved and eviction threshold of the EKS
+// AMI: 6% of the first core, 1% of the second, 0.5% of the next two and
+// 0.25% of the rest, and 255 MiB plus 11 MiB per pod, plus 100 MiB
+func eksAllocatable(spec awsutils.InstanceTypeSpec) (float64, float64) {
+	cpus := float64(spec.VCPUs)
+	reserved := 0.06 * math.Min(cpus, 1)
+	reserved += 0.01 * math.Max(math.Min(cpus-1, 1), 0)
+	reserved += 0.005 * math.Max(math.Min(cpus-2, 2), 0)
+	reserved += 0.0025 * math.Max(cpus-4, 0)
+	reservedMiB := 255 + 11*float64(spec.MaxPods) + 100
+	return cpus - reserved, spec.MemoryGiB - reservedMiB/1024
+}
+
+// formatSavings shows the monthly difference to the current cost
+func formatSavings(current, candidate float64) string {
+	if current == 0 {
+		return "-"
+	}
+	if candidate > current {
+		return fmt.Sprintf("+$%.2f", candidate-current)
+	}
+	return fmt.Sprintf("$%.2f (%.0f%%)", current-candidate, (current-candidate)/current*100)
+}
@@ -0,0 +1,336 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RefsCheckOptions contains options for the reference integrity check
+type RefsCheckOptions struct {
+	Namespace string
+	Output    string // table or json
+	FailOn    string // Lowest severity that fails the run: error, warning, info or none
+}
+
+// brokenRef is a reference from a workload to a missing object or key
+type brokenRef struct {
+	namespace string
+	workload  string // Kind/name
+	target    string // Kind/name of the referenced object
+	key       string
+	usedBy    string
+	problem   string
+}
+
+// refIndex holds the keys of every ConfigMap and Secret and the names of
+// every PVC, by namespace/name
+type refIndex struct {
+	configMaps map[string]map[string]bool
+	secrets    map[string]map[string]bool
+	pvcs       map[string]bool
+}
+
+// refWorkload is a pod spec together with the workload it belongs to
+type refWorkload struct {
+	namespace string
+	name      string // Kind/name
+	spec      corev1.PodSpec
+}
+
+// CheckReferences finds workloads referencing ConfigMaps, Secrets or PVCs that
+// don't exist, or ConfigMap and Secret keys that are missing.
+func CheckReferences(options RefsCheckOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	namespace := options.Namespace
+
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+
+	index, err := buildRefIndex(ctx, clientset, namespace)
+	if err != nil {
+		return err
+	}
+
+	var workloads []refWorkload
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list deployments: %w", err)
+	}
+	for _, d := range deployments.Items {
+		workloads = append(workloads, refWorkload{d.Namespace, "Deployment/" + d.Name, d.Spec.Template.Spec})
+	}
+	statefulSets, err := clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	for _, s := range statefulSets.Items {
+		workloads = append(workloads, refWorkload{s.Namespace, "StatefulSet/" + s.Name, s.Spec.Template.Spec})
+	}
+	daemonSets, err := clientset.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list daemonsets: %w", err)
+	}
+	for _, ds := range daemonSets.Items {
+		workloads = append(workloads, refWorkload{ds.Namespace, "DaemonSet/" + ds.Name, ds.Spec.Template.Spec})
+	}
+	cronJobs, err := clientset.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list cronjobs: %w", err)
+	}
+	for _, cj := range cronJobs.Items {
+		workloads = append(workloads, refWorkload{cj.Namespace, "CronJob/" + cj.Name, cj.Spec.JobTemplate.Spec.Template.Spec})
+	}
+	// Controller-owned pods are covered by their workload's template
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		if metav1.GetControllerOf(&pod) == nil {
+			workloads = append(workloads, refWorkload{pod.Namespace, "Pod/" + pod.Name, pod.Spec})
+		}
+	}
+
+	var broken []brokenRef
+	for _, workload := range workloads {
+		broken = append(broken, index.check(workload)...)
+	}
+
+	sort.SliceStable(broken, func(i, j int) bool {
+		if broken[i].namespace != broken[j].namespace {
+			return broken[i].namespace < broken[j].namespace
+		}
+		return broken[i].workload < broken[j].workload
+	})
+
+	findings := brokenRefFindings(broken)
+	if options.Output == "json" {
+		if err := result.New("refs-check", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	fmt.Printf("Checked %d workload(s) against %d ConfigMap(s), %d Secret(s) and %d PVC(s).\n",
+		len(workloads), len(index.configMaps), len(index.secrets), len(index.pvcs))
+	if len(broken) == 0 {
+		fmt.Println("✅ All references resolve.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tWORKLOAD\tREFERENCE\tKEY\tUSED BY\tPROBLEM")
+	affected := make(map[string]bool)
+	for _, ref := range broken {
+		affected[ref.namespace+"/"+ref.workload] = true
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t❌ %s\n",
+			ref.namespace, ref.workload, ref.target, valueOrDash(ref.key), ref.usedBy, ref.problem)
+	}
+	w.Flush()
+
+	fmt.Println("\n--- Reference Check Summary ---")
+	fmt.Printf("Broken references: %d\n", len(broken))
+	fmt.Printf("Affected workloads: %d\n", len(affected))
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// brokenRefFindings turns broken references into findings of the shared
+// result contract.
+func brokenRefFindings(broken []brokenRef) []result.Finding {
+	var findings []result.Finding
+	for _, ref := range broken {
+		details := map[string]string{"reference": ref.target, "used_by": ref.usedBy}
+		if ref.key != "" {
+			details["key"] = ref.key
+		}
+		findings = append(findings, result.Finding{
+			Check:    "broken-reference",
+			Severity: result.SeverityError,
+			Resource: ref.namespace + "/" + ref.workload,
+			Message:  ref.problem,
+			Details:  details,
+		})
+	}
+	return findings
+}
+
+// buildRefIndex indexes the ConfigMaps, Secrets and PVCs of a namespace, or
+// of all namespaces when it's empty.
+func buildRefIndex(ctx context.Context, clientset *kubernetes.Clientset, namespace string) (refIndex, error) {
+	index := refIndex{
+		configMaps: make(map[string]map[string]bool),
+		secrets:    make(map[string]map[string]bool),
+		pvcs:       make(map[string]bool),
+	}
+
+	configMaps, err := clientset.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return index, fmt.Errorf("failed to list configmaps: %w", err)
+	}
+	for _, cm := range configMaps.Items {
+		keys := make(map[string]bool)
+		for key := range cm.Data {
+			keys[key] = true
+		}
+		for key := range cm.BinaryData {
+			keys[key] = true
+		}
+		index.configMaps[cm.Namespace+"/"+cm.Name] = keys
+	}
+
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return index, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	for _, secret := range secrets.Items {
+		keys := make(map[string]bool)
+		for key := range secret.Data {
+			keys[key] = true
+		}
+		index.secrets[secret.Namespace+"/"+secret.Name] = keys
+	}
+
+	pvcs, err := clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return index, fmt.Errorf("failed to list persistent volume claims: %w", err)
+	}
+	for _, pvc := range pvcs.Items {
+		index.pvcs[pvc.Namespace+"/"+pvc.Name] = true
+	}
+	return index, nil
+}
+
+// check returns the broken references of a workload. References marked
+// optional are skipped since Kubernetes tolerates them missing.
+func (idx refIndex) check(workload refWorkload) []brokenRef {
+	var broken []brokenRef
+	report := func(target, key, usedBy, problem string) {
+		broken = append(broken, brokenRef{workload.namespace, workload.name, target, key, usedBy, problem})
+	}
+	checkConfigMap := func(name, key, usedBy string, optional *bool) {
+		if optional != nil && *optional {
+			return
+		}
+		keys, exists := idx.configMaps[workload.namespace+"/"+name]
+		if !exists {
+			report("ConfigMap/"+name, key, usedBy, "ConfigMap not found")
+		} else if key != "" && !keys[key] {
+			report("ConfigMap/"+name, key, usedBy, "key not found")
+		}
+	}
+	checkSecret := func(name, key, usedBy string, optional *bool) {
+		if optional != nil && *optional {
+			return
+		}
+		keys, exists := idx.secrets[workload.namespace+"/"+name]
+		if !exists {
+			report("Secret/"+name, key, usedBy, "Secret not found")
+		} else if key != "" && !keys[key] {
+			report("Secret/"+name, key, usedBy, "key not found")
+		}
+	}
+
+	for _, volume := range workload.spec.Volumes {
+		usedBy := "volume " + volume.Name
+		switch {
+		case volume.ConfigMap != nil:
+			checkConfigMap(volume.ConfigMap.Name, "", usedBy, volume.ConfigMap.Optional)
+			for _, item := range volume.ConfigMap.Items {
+				checkConfigMap(volume.ConfigMap.Name, item.Key, usedBy, volume.ConfigMap.Optional)
+			}
+		case volume.Secret != nil:
+			checkSecret(volume.Secret.SecretName, "", usedBy, volume.Secret.Optional)
+			for _, item := range volume.Secret.Items {
+				checkSecret(volume.Secret.SecretName, item.Key, usedBy, volume.Secret.Optional)
+			}
+		case volume.PersistentVolumeClaim != nil:
+			if !idx.pvcs[workload.namespace+"/"+volume.PersistentVolumeClaim.ClaimName] {
+				report("PVC/"+volume.PersistentVolumeClaim.ClaimName, "", usedBy, "PVC not found")
+			}
+		case volume.Projected != nil:
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					checkConfigMap(source.ConfigMap.Name, "", usedBy, source.ConfigMap.Optional)
+					for _, item := range source.ConfigMap.Items {
+						checkConfigMap(source.ConfigMap.Name, item.Key, usedBy, source.ConfigMap.Optional)
+					}
+				}
+				if source.Secret != nil {
+					checkSecret(source.Secret.Name, "", usedBy, source.Secret.Optional)
+					for _, item := range source.Secret.Items {
+						checkSecret(source.Secret.Name, item.Key, usedBy, source.Secret.Optional)
+					}
+				}
+			}
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, workload.spec.InitContainers...), workload.spec.Containers...)
+	for _, container := range containers {
+		for _, envFrom := range container.EnvFrom {
+			usedBy := "container " + container.Name + " envFrom"
+			if envFrom.ConfigMapRef != nil {
+				checkConfigMap(envFrom.ConfigMapRef.Name, "", usedBy, envFrom.ConfigMapRef.Optional)
+			}
+			if envFrom.SecretRef != nil {
+				checkSecret(envFrom.SecretRef.Name, "", usedBy, envFrom.SecretRef.Optional)
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			usedBy := "container " + container.Name + " env " + env.Name
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				checkConfigMap(ref.Name, ref.Key, usedBy, ref.Optional)
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				checkSecret(ref.Name, ref.Key, usedBy, ref.Optional)
+			}
+		}
+	}
+
+	for _, pullSecret := range workload.spec.ImagePullSecrets {
+		checkSecret(pullSecret.Name, "", "imagePullSecrets", nil)
+	}
+
+	return dedupeBrokenRefs(broken)
+}
+
+// dedupeBrokenRefs drops repeats, e.g. a missing ConfigMap reported once for
+// the volume and again for each of its items.
+func dedupeBrokenRefs(refs []brokenRef) []brokenRef {
+	seen := make(map[string]bool)
+	var result []brokenRef
+	for _, ref := range refs {
+		key := ref.target + "|" + ref.usedBy + "|" + ref.problem
+		if ref.problem == "key not found" {
+			key += "|" + ref.key
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if ref.problem != "key not found" {
+			ref.key = ""
+		}
+		result = append(result, ref)
+	}
+	return result
+}
@@ -0,0 +1,253 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+)
+
+// Manifest media types asked for, indexes first so multi-arch images
+// return their platform list
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.v1+prettyjws",
+}
+
+// ecrHostPattern matches ECR registries, capturing the account and region
+var ecrHostPattern = regexp.MustCompile(`^(\d{12})\.dkr\.ecr\.([a-z0-9-]+)\.amazonaws\.com$`)
+
+// imageReference is an image split into the registry host, repository and
+// tag or digest
+type imageReference struct {
+	registry   string
+	repository string
+	reference  string
+}
+
+// parseImageReference splits an image the way the container runtime
+// resolves it: Docker Hub when the first component isn't a host, library/
+// for official images, and latest without a tag
+func parseImageReference(image string) imageReference {
+	ref := imageReference{registry: "registry-1.docker.io", reference: "latest"}
+	name := image
+	if repository, digest, ok := strings.Cut(image, "@"); ok {
+		name, ref.reference = repository, digest
+	} else if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		name, ref.reference = image[:i], image[i+1:]
+	}
+	if host, rest, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		if host != "docker.io" && host != "index.docker.io" {
+			ref.registry = host
+		}
+		name = rest
+	}
+	if ref.registry == "registry-1.docker.io" && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	ref.repository = name
+	return ref
+}
+
+// registryClient reads manifests from OCI registries: anonymously with
+// bearer tokens where the registry hands them out, and with the AWS
+// credentials for ECR
+type registryClient struct {
+	client  *http.Client
+	profile string
+	mu      sync.Mutex
+	auth    map[string]string // Authorization header per registry/repository
+}
+
+func newRegistryClient(profile string) *registryClient {
+	return &registryClient{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		profile: profile,
+		auth:    make(map[string]string),
+	}
+}
+
+// imagePlatform is an os/architecture an image is built for
+type imagePlatform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+func (p imagePlatform) String() string {
+	if p.Variant != "" {
+		return p.OS + "/" + p.Architecture + "/" + p.Variant
+	}
+	return p.OS + "/" + p.Architecture
+}
+
+// imagePlatforms returns the platforms an image is available for. A HEAD
+// request tells an index from a single manifest; an index lists its
+// platforms, a single manifest's platform is read from its config.
+func (r *registryClient) imagePlatforms(image string) ([]imagePlatform, error) {
+	ref := parseImageReference(image)
+	resp, err := r.do(http.MethodHead, ref, "manifests/"+ref.reference, strings.Join(manifestMediaTypes, ", "))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	mediaType, _, _ := strings.Cut(resp.Header.Get("Content-Type"), ";")
+
+	var manifest struct {
+		Architecture string `json:"architecture"` // Schema 1 manifests only
+		Manifests    []struct {
+			Platform *imagePlatform `json:"platform"`
+		} `json:"manifests"`
+		Config struct {
+			Digest string `json:"digest"`
+		} `json:"config"`
+	}
+	if err := r.getJSON(ref, "manifests/"+ref.reference, mediaType, &manifest); err != nil {
+		return nil, err
+	}
+	switch {
+	case len(manifest.Manifests) > 0:
+		var platforms []imagePlatform
+		for _, entry := range manifest.Manifests {
+			// Attestations are listed as unknown/unknown
+			if entry.Platform != nil && entry.Platform.OS != "unknown" {
+				platforms = append(platforms, *entry.Platform)
+			}
+		}
+		return platforms, nil
+	case manifest.Config.Digest != "":
+		var config imagePlatform
+		if err := r.getJSON(ref, "blobs/"+manifest.Config.Digest, "*/*", &config); err != nil {
+			return nil, err
+		}
+		return []imagePlatform{config}, nil
+	case manifest.Architecture != "":
+		return []imagePlatform{{OS: "linux", Architecture: manifest.Architecture}}, nil
+	}
+	return nil, fmt.Errorf("unsupported manifest type %s", mediaType)
+}
+
+func (r *registryClient) getJSON(ref imageReference, path, accept string, into any) error {
+	resp, err := r.do(http.MethodGet, ref, path, accept)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(into); err != nil {
+		return fmt.Errorf("failed to decode %s of %s: %w", path, ref.repository, err)
+	}
+	return nil
+}
+
+// do sends a registry API request, authenticating when the registry
+// answers 401 and retrying once
+func (r *registryClient) do(method string, ref imageReference, path, accept string) (*http.Response, error) {
+	key := ref.registry + "/" + ref.repository
+	ecr := ecrHostPattern.FindStringSubmatch(ref.registry)
+	if ecr != nil {
+		// One ECR token covers every repository of the registry
+		key = ref.registry
+	}
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(method, fmt.Sprintf("https://%s/v2/%s/%s", ref.registry, ref.repository, path), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", accept)
+		r.mu.Lock()
+		auth := r.auth[key]
+		r.mu.Unlock()
+		if auth == "" && ecr != nil {
+			token, err := awsutils.ECRAuthorization(r.profile, ecr[2], ecr[1])
+			if err != nil {
+				return nil, err
+			}
+			auth = "Basic " + token
+			r.setAuth(key, auth)
+		}
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := r.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reach %s: %w", ref.registry, err)
+		}
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized && attempt == 0:
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+			token, err := r.bearerToken(challenge, ref)
+			if err != nil {
+				return nil, err
+			}
+			r.setAuth(key, "Bearer "+token)
+			continue
+		case resp.StatusCode != http.StatusOK:
+			resp.Body.Close()
+			return nil, fmt.Errorf("%s %s/%s: %s", method, ref.registry, ref.repository, resp.Status)
+		}
+		return resp, nil
+	}
+}
+
+func (r *registryClient) setAuth(key, auth string) {
+	r.mu.Lock()
+	r.auth[key] = auth
+	r.mu.Unlock()
+}
+
+// bearerToken asks the token service named in a Bearer challenge for an
+// anonymous pull token of the repository
+func (r *registryClient) bearerToken(challenge string, ref imageReference) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("%s requires credentials", ref.registry)
+	}
+	fields := make(map[string]string)
+	for _, param := range strings.Split(params, ",") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok {
+			fields[key] = strings.Trim(value, `"`)
+		}
+	}
+	if fields["realm"] == "" {
+		return "", fmt.Errorf("%s sent no token realm", ref.registry)
+	}
+	req, err := http.NewRequest(http.MethodGet, fields["realm"], nil)
+	if err != nil {
+		return "", err
+	}
+	query := req.URL.Query()
+	if fields["service"] != "" {
+		query.Set("service", fields["service"])
+	}
+	query.Set("scope", "repository:"+ref.repository+":pull")
+	req.URL.RawQuery = query.Encode()
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get token from %s: %w", fields["realm"], err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s requires credentials (token service: %s)", ref.registry, resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode token from %s: %w", fields["realm"], err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	return token.Token, nil
+}
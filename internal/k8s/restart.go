@@ -0,0 +1,314 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// restartedAtAnnotation is the pod template annotation kubectl rollout
+// restart sets; changing it rolls the pods
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// Workload kinds the restart command supports
+var restartKinds = []string{"deployment", "statefulset", "daemonset"}
+
+// RestartOptions contains options for restarting workloads
+type RestartOptions struct {
+	Namespace   string // Empty means all namespaces when a selector is given
+	Selector    string
+	Kind        string // deployment, statefulset, daemonset or all
+	Concurrency int
+	Wait        bool
+	Timeout     time.Duration // Per workload rollout timeout
+	DryRun      bool
+}
+
+// restartTarget is one workload to restart
+type restartTarget struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+func (t restartTarget) String() string {
+	return fmt.Sprintf("%s %s/%s", t.kind, t.namespace, t.name)
+}
+
+// RestartWorkloads performs the equivalent of kubectl rollout restart on the
+// named workloads, or on every workload matching the selector, restarting at
+// most Concurrency at a time and optionally waiting for each rollout to finish
+// before starting another.
+func RestartWorkloads(names []string, options RestartOptions) error {
+	if len(names) == 0 && options.Selector == "" {
+		return fmt.Errorf("give workload names or a --selector")
+	}
+	if len(names) > 0 && options.Selector != "" {
+		return fmt.Errorf("give either workload names or a --selector, not both")
+	}
+	if options.Concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1")
+	}
+	kinds := restartKinds
+	if options.Kind != "all" {
+		if !containsString(restartKinds, options.Kind) {
+			return fmt.Errorf("unsupported kind '%s' (must be %s or all)", options.Kind, strings.Join(restartKinds, ", "))
+		}
+		kinds = []string{options.Kind}
+	}
+	if len(names) > 0 && options.Namespace == "" {
+		options.Namespace = "default"
+	}
+
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	targets, err := findRestartTargets(ctx, clientset, names, kinds, options)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		fmt.Println("No matching workloads found.")
+		return nil
+	}
+
+	fmt.Printf("Restarting %d workload(s), %d at a time:\n", len(targets), options.Concurrency)
+	for _, target := range targets {
+		fmt.Printf("  - %s\n", target)
+	}
+	if options.DryRun {
+		fmt.Println("Dry run, nothing restarted.")
+		return nil
+	}
+
+	// Stop starting new restarts on Ctrl+C; rollouts already started continue
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+	go func() {
+		if _, ok := <-stop; ok {
+			fmt.Println("\nInterrupted, not starting further restarts...")
+			cancel()
+		}
+	}()
+
+	var mu sync.Mutex
+	var succeeded, failed, skipped []string
+	slots := make(chan struct{}, options.Concurrency)
+	var wg sync.WaitGroup
+	for _, target := range targets {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			skipped = append(skipped, target.String())
+			continue
+		}
+
+		wg.Add(1)
+		go func(target restartTarget) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			start := time.Now()
+			err := restartWorkload(clientset, target, options)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				fmt.Printf("❌ %s: %v\n", target, err)
+				failed = append(failed, target.String())
+				return
+			}
+			fmt.Printf("✅ %s (%s)\n", target, time.Since(start).Round(time.Second))
+			succeeded = append(succeeded, target.String())
+		}(target)
+	}
+	wg.Wait()
+
+	fmt.Println("\n--- Restart Summary ---")
+	fmt.Printf("✅ Restarted: %d\n", len(succeeded))
+	fmt.Printf("❌ Failed: %d\n", len(failed))
+	for _, name := range failed {
+		fmt.Printf("  - %s\n", name)
+	}
+	if len(skipped) > 0 {
+		fmt.Printf("⚠️  Not started: %d\n", len(skipped))
+		for _, name := range skipped {
+			fmt.Printf("  - %s\n", name)
+		}
+	}
+	fmt.Println("----------------------------------------------------")
+
+	if len(failed) > 0 || len(skipped) > 0 {
+		return fmt.Errorf("%d of %d workloads were not restarted", len(failed)+len(skipped), len(targets))
+	}
+	return nil
+}
+
+// findRestartTargets resolves names or the selector to workloads, sorted by
+// namespace, kind and name.
+func findRestartTargets(ctx context.Context, clientset *kubernetes.Clientset, names, kinds []string, options RestartOptions) ([]restartTarget, error) {
+	listOptions := metav1.ListOptions{LabelSelector: options.Selector}
+	var targets []restartTarget
+	for _, kind := range kinds {
+		var found []restartTarget
+		switch kind {
+		case "deployment":
+			list, err := clientset.AppsV1().Deployments(options.Namespace).List(ctx, listOptions)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list deployments: %w", err)
+			}
+			for _, item := range list.Items {
+				found = append(found, restartTarget{kind, item.Namespace, item.Name})
+			}
+		case "statefulset":
+			list, err := clientset.AppsV1().StatefulSets(options.Namespace).List(ctx, listOptions)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+			}
+			for _, item := range list.Items {
+				found = append(found, restartTarget{kind, item.Namespace, item.Name})
+			}
+		case "daemonset":
+			list, err := clientset.AppsV1().DaemonSets(options.Namespace).List(ctx, listOptions)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+			}
+			for _, item := range list.Items {
+				found = append(found, restartTarget{kind, item.Namespace, item.Name})
+			}
+		}
+		for _, target := range found {
+			if len(names) == 0 || containsString(names, target.name) {
+				targets = append(targets, target)
+			}
+		}
+	}
+
+	if len(names) > 0 {
+		for _, name := range names {
+			matched := false
+			for _, target := range targets {
+				matched = matched || target.name == name
+			}
+			if !matched {
+				return nil, fmt.Errorf("no %s named '%s' in namespace %s", strings.Join(kinds, " or "), name, options.Namespace)
+			}
+		}
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].namespace != targets[j].namespace {
+			return targets[i].namespace < targets[j].namespace
+		}
+		if targets[i].kind != targets[j].kind {
+			return targets[i].kind < targets[j].kind
+		}
+		return targets[i].name < targets[j].name
+	})
+	return targets, nil
+}
+
+// restartWorkload patches the pod template annotation and, with Wait, waits
+// for the rollout to complete.
+func restartWorkload(clientset *kubernetes.Clientset, target restartTarget, options RestartOptions) error {
+	ctx := context.TODO()
+	patch := []byte(fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`,
+		restartedAtAnnotation, time.Now().Format(time.RFC3339)))
+
+	var err error
+	switch target.kind {
+	case "deployment":
+		_, err = clientset.AppsV1().Deployments(target.namespace).Patch(ctx, target.name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	case "statefulset":
+		_, err = clientset.AppsV1().StatefulSets(target.namespace).Patch(ctx, target.name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	case "daemonset":
+		_, err = clientset.AppsV1().DaemonSets(target.namespace).Patch(ctx, target.name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to patch: %w", err)
+	}
+	if !options.Wait {
+		return nil
+	}
+
+	deadline := time.Now().Add(options.Timeout)
+	for {
+		done, progress, err := rolloutComplete(clientset, target)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for rollout (%s)", progress)
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+// rolloutComplete applies the same checks as kubectl rollout status and
+// returns a short description of the progress so far.
+func rolloutComplete(clientset *kubernetes.Clientset, target restartTarget) (bool, string, error) {
+	ctx := context.TODO()
+	switch target.kind {
+	case "deployment":
+		d, err := clientset.AppsV1().Deployments(target.namespace).Get(ctx, target.name, metav1.GetOptions{})
+		if err != nil {
+			return false, "", fmt.Errorf("failed to get deployment: %w", err)
+		}
+		replicas := int32(1)
+		if d.Spec.Replicas != nil {
+			replicas = *d.Spec.Replicas
+		}
+		progress := fmt.Sprintf("%d/%d updated, %d available", d.Status.UpdatedReplicas, replicas, d.Status.AvailableReplicas)
+		done := d.Status.ObservedGeneration >= d.Generation &&
+			d.Status.UpdatedReplicas == replicas &&
+			d.Status.Replicas == replicas &&
+			d.Status.AvailableReplicas == replicas
+		return done, progress, nil
+	case "statefulset":
+		s, err := clientset.AppsV1().StatefulSets(target.namespace).Get(ctx, target.name, metav1.GetOptions{})
+		if err != nil {
+			return false, "", fmt.Errorf("failed to get statefulset: %w", err)
+		}
+		replicas := int32(1)
+		if s.Spec.Replicas != nil {
+			replicas = *s.Spec.Replicas
+		}
+		progress := fmt.Sprintf("%d/%d updated, %d ready", s.Status.UpdatedReplicas, replicas, s.Status.ReadyReplicas)
+		done := s.Status.ObservedGeneration >= s.Generation &&
+			s.Status.UpdatedReplicas == replicas &&
+			s.Status.ReadyReplicas == replicas &&
+			s.Status.UpdateRevision == s.Status.CurrentRevision
+		return done, progress, nil
+	default:
+		ds, err := clientset.AppsV1().DaemonSets(target.namespace).Get(ctx, target.name, metav1.GetOptions{})
+		if err != nil {
+			return false, "", fmt.Errorf("failed to get daemonset: %w", err)
+		}
+		desired := ds.Status.DesiredNumberScheduled
+		progress := fmt.Sprintf("%d/%d updated, %d available", ds.Status.UpdatedNumberScheduled, desired, ds.Status.NumberAvailable)
+		done := ds.Status.ObservedGeneration >= ds.Generation &&
+			ds.Status.UpdatedNumberScheduled == desired &&
+			ds.Status.NumberAvailable == desired
+		return done, progress, nil
+	}
+}
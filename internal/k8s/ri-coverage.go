@@ -0,0 +1,140 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RICoverageOptions contains options for the RI and Savings Plans coverage report
+type RICoverageOptions struct {
+	Region  string // Defaults to the region of the cluster's nodes
+	Profile string
+	Days    int // Cost Explorer lookback window
+}
+
+type riCoverageRow struct {
+	instanceType   string
+	nodes          int
+	hourlyPrice    float64
+	reserved       float64
+	savingsPlans   float64
+	monthlyCost    float64
+	uncoveredSpend float64
+}
+
+// ShowRICoverage compares the cluster's running instance hours with the
+// Reserved Instance and Savings Plans coverage Cost Explorer reports for the
+// same instance types, and estimates the on-demand spend left uncovered.
+func ShowRICoverage(options RICoverageOptions) error {
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	region := options.Region
+	if region == "" && len(nodes.Items) > 0 {
+		region = nodes.Items[0].Labels["topology.kubernetes.io/region"]
+	}
+	if region == "" {
+		return fmt.Errorf("could not determine the cluster's region, use --region")
+	}
+
+	pricing, err := loadPricingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load pricing config: %w", err)
+	}
+
+	counts := make(map[string]int)
+	for _, node := range nodes.Items {
+		if instanceType := getNodeInstanceType(node); instanceType != "" {
+			counts[instanceType]++
+		}
+	}
+	if len(counts) == 0 {
+		fmt.Println("No nodes with an instance type label found.")
+		return nil
+	}
+
+	sess, err := awsutils.NewSession(options.Profile, region)
+	if err != nil {
+		return err
+	}
+	end := time.Now()
+	start := end.AddDate(0, 0, -options.Days)
+	fmt.Printf("Fetching coverage for %s from Cost Explorer (%s to %s)...\n", region, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	reserved, savingsPlans, err := awsutils.GetCommitmentCoverage(sess, region, start, end)
+	if err != nil {
+		return err
+	}
+
+	var rows []riCoverageRow
+	var totalCost, totalUncovered float64
+	missingPrices := 0
+	for instanceType, count := range counts {
+		family, _, _ := strings.Cut(instanceType, ".")
+		row := riCoverageRow{
+			instanceType: instanceType,
+			nodes:        count,
+			hourlyPrice:  pricing.EC2Pricing[instanceType],
+			reserved:     reserved[instanceType],
+			savingsPlans: savingsPlans[family],
+		}
+		if row.hourlyPrice == 0 {
+			missingPrices++
+		}
+		// Savings Plans coverage is measured on usage RIs leave uncovered
+		row.monthlyCost = row.hourlyPrice * 730 * float64(count)
+		row.uncoveredSpend = row.monthlyCost * (1 - row.reserved) * (1 - row.savingsPlans)
+		totalCost += row.monthlyCost
+		totalUncovered += row.uncoveredSpend
+		rows = append(rows, row)
+	}
+
+	// Biggest uncovered spend first, those are the best RI or Savings Plan candidates
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].uncoveredSpend != rows[j].uncoveredSpend {
+			return rows[i].uncoveredSpend > rows[j].uncoveredSpend
+		}
+		return rows[i].instanceType < rows[j].instanceType
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nINSTANCE TYPE\tNODES\tON-DEMAND $/HR\tRI COVERAGE\tSP COVERAGE\tMONTHLY COST\tUNCOVERED $/MONTH")
+	for _, row := range rows {
+		fmt.Fprintf(w, "%s\t%d\t$%.4f\t%.1f%%\t%.1f%%\t$%.2f\t$%.2f\n",
+			row.instanceType, row.nodes, row.hourlyPrice,
+			row.reserved*100, row.savingsPlans*100,
+			row.monthlyCost, row.uncoveredSpend)
+	}
+	w.Flush()
+
+	coverage := 0.0
+	if totalCost > 0 {
+		coverage = (totalCost - totalUncovered) / totalCost * 100
+	}
+	fmt.Println("\n--- RI / Savings Plans Coverage Summary ---")
+	fmt.Printf("Nodes: %d across %d instance types\n", len(nodes.Items), len(counts))
+	fmt.Printf("Estimated monthly on-demand cost: $%.2f\n", totalCost)
+	fmt.Printf("Estimated coverage: %.1f%%\n", coverage)
+	fmt.Printf("Uncovered spend: $%.2f/month\n", totalUncovered)
+	if missingPrices > 0 {
+		fmt.Printf("⚠️  %d instance type(s) have no price in the pricing table and count as $0\n", missingPrices)
+	}
+	fmt.Println("Note: Cost Explorer coverage is account wide for the region, not specific to this cluster.")
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
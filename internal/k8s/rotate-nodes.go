@@ -0,0 +1,428 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/aws/aws-sdk-go/aws/session"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RotateNodesOptions contains options for rotating the nodes of an ASG
+type RotateNodesOptions struct {
+	ASGName        string
+	Region         string
+	Profile        string
+	MaxUnavailable int           // Nodes rotated at the same time
+	Decrement      bool          // Shrink the ASG instead of waiting for replacements
+	StateFile      string        // Progress file used to resume an interrupted rotation
+	Timeout        time.Duration // Per step timeout for drains and replacements
+	UI             bool          // Show progress in the ASG monitor dashboard
+}
+
+// rotationState is persisted after every rotated batch so an interrupted
+// rotation can pick up where it left off.
+type rotationState struct {
+	ASGName   string    `json:"asg_name"`
+	StartedAt time.Time `json:"started_at"`
+	Pending   []string  `json:"pending"`
+	Completed []string  `json:"completed"`
+}
+
+type nodeRotator struct {
+	clientset *kubernetes.Clientset
+	sess      *session.Session
+	options   RotateNodesOptions
+	ctx       context.Context
+	logf      func(format string, args ...interface{})
+}
+
+// RotateNodes cycles the nodes of an ASG in batches of MaxUnavailable: cordon,
+// drain while respecting PDBs, terminate through the ASG, then wait for the
+// replacements to become Ready before moving on.
+func RotateNodes(options RotateNodesOptions) error {
+	if options.MaxUnavailable < 1 {
+		return fmt.Errorf("max-unavailable must be at least 1")
+	}
+	if options.StateFile == "" {
+		options.StateFile = fmt.Sprintf(".rotate-nodes-%s.json", options.ASGName)
+	}
+	if options.Timeout == 0 {
+		options.Timeout = 15 * time.Minute
+	}
+
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	sess, err := awsutils.NewSession(options.Profile, options.Region)
+	if err != nil {
+		return err
+	}
+
+	state, err := loadRotationState(options)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		instanceIDs, err := awsutils.GetASGInstanceIDs(sess, options.ASGName)
+		if err != nil {
+			return fmt.Errorf("failed to list ASG instances: %w", err)
+		}
+		sort.Strings(instanceIDs)
+		if options.Decrement {
+			if err := checkDecrementCapacity(sess, options.ASGName, instanceIDs); err != nil {
+				return err
+			}
+		}
+		state = &rotationState{ASGName: options.ASGName, StartedAt: time.Now(), Pending: instanceIDs}
+		if err := saveRotationState(options.StateFile, state); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Resuming rotation of '%s' from %s (%d done, %d pending)\n",
+			options.ASGName, options.StateFile, len(state.Completed), len(state.Pending))
+		if options.Decrement {
+			if err := checkDecrementCapacity(sess, options.ASGName, state.Pending); err != nil {
+				return err
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rotator := &nodeRotator{clientset: clientset, sess: sess, options: options, ctx: ctx}
+
+	if !options.UI {
+		rotator.logf = func(format string, args ...interface{}) {
+			fmt.Printf(format+"\n", args...)
+		}
+		return rotator.run(state)
+	}
+
+	logs := make(chan string, 100)
+	rotator.logf = func(format string, args ...interface{}) {
+		select {
+		case logs <- fmt.Sprintf(format, args...):
+		default: // Never block the rotation on a slow UI
+		}
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		err := rotator.run(state)
+		if err != nil {
+			rotator.logf("Rotation stopped: %v", err)
+		}
+		rotator.logf("Press q to exit the monitor.")
+		done <- err
+	}()
+
+	monitorErr := awsutils.MonitorWithLog(options.ASGName, awsutils.MonitorOptions{
+		RefreshInterval: 5,
+		Region:          options.Region,
+		Profile:         options.Profile,
+	}, logs)
+
+	// Quitting the monitor stops the rotation after the current step; the
+	// state file allows it to be resumed later.
+	cancel()
+	if err := <-done; err != nil {
+		return err
+	}
+	return monitorErr
+}
+
+// checkDecrementCapacity refuses a --decrement rotation that would take the
+// ASG below its minimum size, which the ASG would otherwise reject halfway
+// through. Pending instances already gone from the ASG don't count.
+func checkDecrementCapacity(sess *session.Session, asgName string, pending []string) error {
+	desired, minSize, err := awsutils.GetASGCapacity(sess, asgName)
+	if err != nil {
+		return fmt.Errorf("failed to get ASG capacity: %w", err)
+	}
+	current, err := awsutils.GetASGInstanceIDs(sess, asgName)
+	if err != nil {
+		return fmt.Errorf("failed to list ASG instances: %w", err)
+	}
+	attached := make(map[string]bool, len(current))
+	for _, instanceID := range current {
+		attached[instanceID] = true
+	}
+	remaining := 0
+	for _, instanceID := range pending {
+		if attached[instanceID] {
+			remaining++
+		}
+	}
+	if desired-int64(remaining) < minSize {
+		return fmt.Errorf("--decrement would take ASG '%s' from a desired capacity of %d to %d, below its minimum size of %d; lower the minimum size or rotate without --decrement",
+			asgName, desired, desired-int64(remaining), minSize)
+	}
+	return nil
+}
+
+func (r *nodeRotator) run(state *rotationState) error {
+	total := len(state.Pending) + len(state.Completed)
+	for len(state.Pending) > 0 {
+		if err := r.ctx.Err(); err != nil {
+			return fmt.Errorf("rotation interrupted, resume with the same state file: %w", err)
+		}
+
+		batchSize := r.options.MaxUnavailable
+		if batchSize > len(state.Pending) {
+			batchSize = len(state.Pending)
+		}
+		batch := state.Pending[:batchSize]
+		r.logf("Rotating batch %v (%d/%d done)", batch, len(state.Completed), total)
+
+		if err := r.rotateBatch(batch); err != nil {
+			return err
+		}
+
+		state.Completed = append(state.Completed, batch...)
+		state.Pending = state.Pending[batchSize:]
+		if err := saveRotationState(r.options.StateFile, state); err != nil {
+			return err
+		}
+	}
+
+	r.logf("✅ Rotated %d nodes of ASG '%s'", len(state.Completed), r.options.ASGName)
+	if err := os.Remove(r.options.StateFile); err != nil && !os.IsNotExist(err) {
+		r.logf("Warning: could not remove state file %s: %v", r.options.StateFile, err)
+	}
+	return nil
+}
+
+func (r *nodeRotator) rotateBatch(instanceIDs []string) error {
+	nodesByInstance, err := r.nodesByInstanceID()
+	if err != nil {
+		return err
+	}
+	before, err := awsutils.GetASGInstanceIDs(r.sess, r.options.ASGName)
+	if err != nil {
+		return fmt.Errorf("failed to list ASG instances: %w", err)
+	}
+
+	for _, instanceID := range instanceIDs {
+		nodeName, ok := nodesByInstance[instanceID]
+		if !ok {
+			r.logf("Instance %s has no Kubernetes node, terminating directly", instanceID)
+		} else {
+			r.logf("Cordoning node %s (%s)", nodeName, instanceID)
+			if err := r.cordonNode(nodeName); err != nil {
+				return err
+			}
+			r.logf("Draining node %s", nodeName)
+			if err := r.drainNode(nodeName); err != nil {
+				return err
+			}
+		}
+
+		r.logf("Terminating instance %s", instanceID)
+		if err := awsutils.TerminateASGInstance(r.sess, instanceID, r.options.Decrement); err != nil {
+			// The instance may already be gone when resuming a rotation;
+			// anything else stops the batch before the state file moves on
+			if !awsutils.IsInstanceNotInASG(err) {
+				return err
+			}
+			r.logf("Instance %s is no longer in the ASG, skipping", instanceID)
+		}
+	}
+
+	if r.options.Decrement {
+		return nil
+	}
+	return r.waitForReplacements(before, len(instanceIDs))
+}
+
+func (r *nodeRotator) nodesByInstanceID() (map[string]string, error) {
+	nodes, err := r.clientset.CoreV1().Nodes().List(r.ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nodes: %w", err)
+	}
+	result := make(map[string]string)
+	for _, node := range nodes.Items {
+		if instanceID := awsutils.InstanceIDFromProviderID(node.Spec.ProviderID); instanceID != "" {
+			result[instanceID] = node.Name
+		}
+	}
+	return result, nil
+}
+
+func (r *nodeRotator) cordonNode(nodeName string) error {
+	patch := []byte(`{"spec":{"unschedulable":true}}`)
+	if _, err := r.clientset.CoreV1().Nodes().Patch(r.ctx, nodeName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to cordon node %s: %w", nodeName, err)
+	}
+	return nil
+}
+
+// drainNode evicts every pod except DaemonSet and mirror pods. Evictions are
+// retried while a PodDisruptionBudget blocks them.
+func (r *nodeRotator) drainNode(nodeName string) error {
+	deadline := time.Now().Add(r.options.Timeout)
+	for {
+		pods, err := r.clientset.CoreV1().Pods("").List(r.ctx, metav1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list pods on node %s: %w", nodeName, err)
+		}
+
+		remaining := 0
+		for _, pod := range pods.Items {
+			if !podNeedsEviction(pod) {
+				continue
+			}
+			remaining++
+			if pod.DeletionTimestamp != nil {
+				continue
+			}
+
+			eviction := &policyv1.Eviction{
+				ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+			}
+			err := r.clientset.PolicyV1().Evictions(pod.Namespace).Evict(r.ctx, eviction)
+			switch {
+			case err == nil, apierrors.IsNotFound(err):
+			case apierrors.IsTooManyRequests(err):
+				r.logf("Eviction of %s/%s blocked by PDB, retrying", pod.Namespace, pod.Name)
+			default:
+				return fmt.Errorf("failed to evict pod %s/%s: %w", pod.Namespace, pod.Name, err)
+			}
+		}
+
+		if remaining == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out draining node %s, %d pods remaining", nodeName, remaining)
+		}
+		if err := sleepContext(r.ctx, 5*time.Second); err != nil {
+			return err
+		}
+	}
+}
+
+func podNeedsEviction(pod corev1.Pod) bool {
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+	if _, isMirror := pod.Annotations[corev1.MirrorPodAnnotationKey]; isMirror {
+		return false
+	}
+	return daemonSetOwner(pod) == ""
+}
+
+// waitForReplacements waits until count instances that were not part of the
+// ASG before the batch have joined the cluster as Ready nodes.
+func (r *nodeRotator) waitForReplacements(before []string, count int) error {
+	known := make(map[string]bool)
+	for _, id := range before {
+		known[id] = true
+	}
+
+	r.logf("Waiting for %d replacement node(s) to become Ready", count)
+	deadline := time.Now().Add(r.options.Timeout)
+	for {
+		current, err := awsutils.GetASGInstanceIDs(r.sess, r.options.ASGName)
+		if err != nil {
+			return fmt.Errorf("failed to list ASG instances: %w", err)
+		}
+		nodes, err := r.clientset.CoreV1().Nodes().List(r.ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get nodes: %w", err)
+		}
+
+		readyInstances := make(map[string]bool)
+		for _, node := range nodes.Items {
+			if getNodeReadyStatus(node) == "True" {
+				readyInstances[awsutils.InstanceIDFromProviderID(node.Spec.ProviderID)] = true
+			}
+		}
+
+		ready := 0
+		for _, id := range current {
+			if !known[id] && readyInstances[id] {
+				ready++
+			}
+		}
+		if ready >= count {
+			r.logf("%d replacement node(s) Ready", ready)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for replacement nodes (%d/%d Ready)", ready, count)
+		}
+		if err := sleepContext(r.ctx, 15*time.Second); err != nil {
+			return err
+		}
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func loadRotationState(options RotateNodesOptions) (*rotationState, error) {
+	data, err := os.ReadFile(options.StateFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file '%s': %w", options.StateFile, err)
+	}
+
+	var state rotationState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid state file '%s': %w", options.StateFile, err)
+	}
+	if state.ASGName != options.ASGName {
+		return nil, fmt.Errorf("state file '%s' belongs to ASG '%s'", options.StateFile, state.ASGName)
+	}
+	return &state, nil
+}
+
+func saveRotationState(path string, state *rotationState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Written to a temporary file and renamed, so an interruption never
+	// leaves a truncated state file behind
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write state file '%s': %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file '%s': %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file '%s': %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write state file '%s': %w", path, err)
+	}
+	return nil
+}
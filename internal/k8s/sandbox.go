@@ -0,0 +1,317 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/user"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// sandboxLabel marks namespaces created by sandbox create
+	sandboxLabel = "swissarmycli.io/sandbox"
+	// sandboxExpiresAnnotation holds the RFC 3339 time a sandbox may be reaped
+	sandboxExpiresAnnotation = "swissarmycli.io/expires-at"
+	// sandboxOwnerAnnotation holds who created the sandbox
+	sandboxOwnerAnnotation = "swissarmycli.io/owner"
+)
+
+// invalidNameChars are the characters not allowed in a namespace name
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// SandboxOptions contains options for creating a sandbox namespace
+type SandboxOptions struct {
+	TTL           time.Duration // How long until the sandbox may be reaped
+	Owner         string        // Defaults to the local user
+	CPU           string        // Quota of CPU requests and limits
+	Memory        string        // Quota of memory requests and limits
+	Pods          int           // Quota of pods
+	NetworkPolicy string        // isolated or none
+}
+
+// SandboxReapOptions contains options for deleting expired sandboxes
+type SandboxReapOptions struct {
+	Grace  time.Duration // Extra time after expiry before a sandbox is reaped
+	DryRun bool
+}
+
+// CreateSandbox creates a namespace for ad-hoc testing with a resource
+// quota, a limit range giving containers default requests and limits, a
+// network policy only admitting traffic from inside the namespace, and an
+// expiry time after which sandbox reap deletes it. Load balancer and node
+// port services are not allowed, so a forgotten sandbox costs nothing
+// outside the cluster.
+func CreateSandbox(name string, options SandboxOptions) error {
+	if options.TTL <= 0 {
+		return fmt.Errorf("--ttl must be positive")
+	}
+	if options.NetworkPolicy != "isolated" && options.NetworkPolicy != "none" {
+		return fmt.Errorf("invalid network policy '%s' (must be isolated or none)", options.NetworkPolicy)
+	}
+	cpu, err := resource.ParseQuantity(options.CPU)
+	if err != nil {
+		return fmt.Errorf("invalid --cpu: %w", err)
+	}
+	memory, err := resource.ParseQuantity(options.Memory)
+	if err != nil {
+		return fmt.Errorf("invalid --memory: %w", err)
+	}
+	if options.Owner == "" {
+		options.Owner = "unknown"
+		if current, err := user.Current(); err == nil {
+			options.Owner = current.Username
+		}
+	}
+	if name == "" {
+		owner := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(options.Owner), "-"), "-")
+		name = fmt.Sprintf("sandbox-%s-%04x", owner, rand.Intn(0x10000))
+	}
+
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+	expires := time.Now().Add(options.TTL).UTC()
+	_, err = clientset.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{sandboxLabel: "true", "app.kubernetes.io/managed-by": "swissarmycli"},
+			Annotations: map[string]string{
+				sandboxExpiresAnnotation: expires.Format(time.RFC3339),
+				sandboxOwnerAnnotation:   options.Owner,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create namespace %s: %w", name, err)
+	}
+	fmt.Printf("✅ Namespace %s created\n", name)
+
+	if err := createSandboxPolicies(clientset, name, cpu, memory, options); err != nil {
+		// A sandbox without its limits must not linger
+		_ = clientset.CoreV1().Namespaces().Delete(ctx, name, metav1.DeleteOptions{})
+		return fmt.Errorf("%w, namespace %s deleted again", err, name)
+	}
+
+	fmt.Println("\n--- Sandbox Summary ---")
+	fmt.Printf("Namespace: %s\n", name)
+	fmt.Printf("Owner:     %s\n", options.Owner)
+	fmt.Printf("Expires:   %s (in %s)\n", expires.Local().Format("2006-01-02 15:04"), options.TTL)
+	fmt.Printf("Quota:     %s CPU, %s memory, %d pods, no load balancers or node ports\n", options.CPU, options.Memory, options.Pods)
+	fmt.Printf("Use it:    kubectl config set-context --current --namespace %s\n", name)
+	fmt.Printf("Remove it: swissarmycli sandbox destroy %s\n", name)
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// createSandboxPolicies creates the quota, limit range and network policy
+// of a new sandbox
+func createSandboxPolicies(clientset *kubernetes.Clientset, namespace string, cpu, memory resource.Quantity, options SandboxOptions) error {
+	ctx := context.TODO()
+	meta := metav1.ObjectMeta{Name: "sandbox", Labels: map[string]string{sandboxLabel: "true"}}
+	_, err := clientset.CoreV1().ResourceQuotas(namespace).Create(ctx, &corev1.ResourceQuota{
+		ObjectMeta: meta,
+		Spec: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+			corev1.ResourceRequestsCPU:            cpu,
+			corev1.ResourceLimitsCPU:              cpu,
+			corev1.ResourceRequestsMemory:         memory,
+			corev1.ResourceLimitsMemory:           memory,
+			corev1.ResourcePods:                   *resource.NewQuantity(int64(options.Pods), resource.DecimalSI),
+			corev1.ResourceServicesLoadBalancers:  resource.MustParse("0"),
+			corev1.ResourceServicesNodePorts:      resource.MustParse("0"),
+			corev1.ResourcePersistentVolumeClaims: resource.MustParse("5"),
+			corev1.ResourceRequestsStorage:        resource.MustParse("20Gi"),
+		}},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create resource quota: %w", err)
+	}
+	fmt.Println("✅ ResourceQuota sandbox created")
+
+	// The quota makes requests and limits mandatory, so containers that set
+	// none get these
+	_, err = clientset.CoreV1().LimitRanges(namespace).Create(ctx, &corev1.LimitRange{
+		ObjectMeta: meta,
+		Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+			Type: corev1.LimitTypeContainer,
+			DefaultRequest: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("100m"),
+				corev1.ResourceMemory: resource.MustParse("128Mi"),
+			},
+			Default: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("500m"),
+				corev1.ResourceMemory: resource.MustParse("512Mi"),
+			},
+		}}},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create limit range: %w", err)
+	}
+	fmt.Println("✅ LimitRange sandbox created")
+
+	if options.NetworkPolicy == "none" {
+		return nil
+	}
+	_, err = clientset.NetworkingV1().NetworkPolicies(namespace).Create(ctx, &networkingv1.NetworkPolicy{
+		ObjectMeta: meta,
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{{
+				From: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}},
+			}},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create network policy: %w", err)
+	}
+	fmt.Println("✅ NetworkPolicy sandbox created (ingress only from inside the namespace)")
+	return nil
+}
+
+// DestroySandbox deletes a sandbox namespace. Namespaces that sandbox
+// create didn't make are refused.
+func DestroySandbox(name string) error {
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+	namespace, err := clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get namespace %s: %w", name, err)
+	}
+	if namespace.Labels[sandboxLabel] != "true" {
+		return fmt.Errorf("namespace %s is not a sandbox (no %s=true label)", name, sandboxLabel)
+	}
+	if err := clientset.CoreV1().Namespaces().Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete namespace %s: %w", name, err)
+	}
+	fmt.Printf("✅ Sandbox %s deleted\n", name)
+	return nil
+}
+
+// ListSandboxes prints every sandbox with its owner and expiry
+func ListSandboxes() error {
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	sandboxes, err := listSandboxes(clientset)
+	if err != nil {
+		return err
+	}
+	if len(sandboxes) == 0 {
+		fmt.Println("No sandboxes found.")
+		return nil
+	}
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tOWNER\tAGE\tEXPIRES\tSTATUS")
+	for _, namespace := range sandboxes {
+		expires, err := sandboxExpiry(namespace)
+		status, expiresCell := "✅ active", "-"
+		switch {
+		case namespace.Status.Phase == corev1.NamespaceTerminating:
+			status = "terminating"
+		case err != nil:
+			status = "⚠️ " + err.Error()
+		case now.After(expires):
+			status = "❌ expired"
+		}
+		if err == nil {
+			expiresCell = expires.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", namespace.Name, valueOrDash(namespace.Annotations[sandboxOwnerAnnotation]),
+			now.Sub(namespace.CreationTimestamp.Time).Round(time.Minute), expiresCell, status)
+	}
+	w.Flush()
+	return nil
+}
+
+// ReapSandboxes deletes every sandbox whose expiry has passed by more than
+// the grace period. Sandboxes with a missing or unreadable expiry are left
+// alone and reported.
+func ReapSandboxes(options SandboxReapOptions) error {
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	sandboxes, err := listSandboxes(clientset)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var reaped, failed int
+	for _, namespace := range sandboxes {
+		if namespace.Status.Phase == corev1.NamespaceTerminating {
+			continue
+		}
+		expires, err := sandboxExpiry(namespace)
+		if err != nil {
+			fmt.Printf("⚠️  %s: %v, skipped\n", namespace.Name, err)
+			continue
+		}
+		if now.Before(expires.Add(options.Grace)) {
+			continue
+		}
+		overdue := now.Sub(expires).Round(time.Minute)
+		if options.DryRun {
+			fmt.Printf("Would delete %s (owner %s, expired %s ago)\n", namespace.Name, valueOrDash(namespace.Annotations[sandboxOwnerAnnotation]), overdue)
+			reaped++
+			continue
+		}
+		if err := clientset.CoreV1().Namespaces().Delete(context.TODO(), namespace.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			fmt.Printf("❌ %s: failed to delete: %v\n", namespace.Name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ Deleted %s (owner %s, expired %s ago)\n", namespace.Name, valueOrDash(namespace.Annotations[sandboxOwnerAnnotation]), overdue)
+		reaped++
+	}
+
+	fmt.Println("\n--- Sandbox Reap Summary ---")
+	fmt.Printf("Sandboxes: %d, expired and %s: %d\n", len(sandboxes), map[bool]string{true: "to delete", false: "deleted"}[options.DryRun], reaped)
+	fmt.Println("----------------------------------------------------")
+	if failed > 0 {
+		return fmt.Errorf("%d sandboxes failed to be deleted", failed)
+	}
+	return nil
+}
+
+// listSandboxes returns the namespaces labeled as sandboxes
+func listSandboxes(clientset *kubernetes.Clientset) ([]corev1.Namespace, error) {
+	namespaces, err := clientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{LabelSelector: sandboxLabel + "=true"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	return namespaces.Items, nil
+}
+
+// sandboxExpiry reads the expiry annotation of a sandbox
+func sandboxExpiry(namespace corev1.Namespace) (time.Time, error) {
+	value, ok := namespace.Annotations[sandboxExpiresAnnotation]
+	if !ok {
+		return time.Time{}, fmt.Errorf("no %s annotation", sandboxExpiresAnnotation)
+	}
+	expires, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s annotation %q", sandboxExpiresAnnotation, value)
+	}
+	return expires, nil
+}
@@ -0,0 +1,369 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/HighonAces/swissarmycli/internal/config"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// scanSeverities are the vulnerability severities counted, most severe first
+var scanSeverities = []string{"CRITICAL", "HIGH", "MEDIUM", "LOW"}
+
+// ScanImagesOptions contains options for the image vulnerability scan
+type ScanImagesOptions struct {
+	Namespace   string // All namespaces when empty
+	Scanner     string // trivy or grype, overriding the config file
+	Parallel    int    // Images scanned at once
+	MaxCritical int    // Critical vulnerabilities tolerated before failing, negative for no limit
+	Output      string // table or json
+	FailOn      string // Lowest severity that fails the run: error, warning, info or none
+}
+
+// scannedImage is one image running in the cluster and what the scanner
+// found in it
+type scannedImage struct {
+	ref       string // What was scanned, the digest the pods run when known
+	image     string // As written in the pod spec
+	workloads map[string]bool
+	counts    map[string]int // Severity to distinct vulnerabilities
+	err       error
+}
+
+// ScanImages builds an inventory of the images the cluster's pods run,
+// scans each distinct image once with trivy or grype, and reports the
+// vulnerabilities per image and per workload. With MaxCritical set the run
+// fails when the cluster's images have more critical vulnerabilities.
+func ScanImages(options ScanImagesOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	imageScan, err := cfg.ImageScan()
+	if err != nil {
+		return err
+	}
+	scanner := options.Scanner
+	if scanner == "" {
+		scanner = imageScan.Scanner
+	}
+	if scanner == "" {
+		scanner = "trivy"
+	}
+	if scanner != "trivy" && scanner != "grype" {
+		return fmt.Errorf("unsupported scanner '%s' (must be trivy or grype)", scanner)
+	}
+	scannerPath := imageScan.Path
+	if scannerPath == "" {
+		if scannerPath, err = exec.LookPath(scanner); err != nil {
+			return fmt.Errorf("%s not found in PATH; install it or set image_scan.path in %s", scanner, config.Path())
+		}
+	}
+
+	images, err := collectImageInventory(options.Namespace)
+	if err != nil {
+		return err
+	}
+	if len(images) == 0 {
+		fmt.Println("No running pods found.")
+		return nil
+	}
+	refs := sortedKeys(images)
+	if options.Output != "json" {
+		fmt.Printf("Scanning %d images with %s...\n", len(refs), scanner)
+	}
+
+	parallel := max(options.Parallel, 1)
+	semaphore := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for _, ref := range refs {
+		wg.Add(1)
+		go func(image *scannedImage) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			image.counts, image.err = runImageScanner(scanner, scannerPath, imageScan.ExtraArgs, image.ref)
+		}(images[ref])
+	}
+	wg.Wait()
+
+	var findings []result.Finding
+	workloadCounts := make(map[string]map[string]int)
+	workloadImages := make(map[string]int)
+	totals := make(map[string]int)
+	failed := 0
+	for _, ref := range refs {
+		image := images[ref]
+		workloads := strings.Join(sortedKeys(image.workloads), ", ")
+		if image.err != nil {
+			failed++
+			findings = append(findings, result.Finding{
+				Check:    "scan-failed",
+				Severity: result.SeverityInfo,
+				Resource: image.image,
+				Message:  image.err.Error(),
+				Details:  map[string]string{"workloads": workloads},
+			})
+			continue
+		}
+		for _, severity := range scanSeverities {
+			totals[severity] += image.counts[severity]
+		}
+		for workload := range image.workloads {
+			if workloadCounts[workload] == nil {
+				workloadCounts[workload] = make(map[string]int)
+			}
+			workloadImages[workload]++
+			for severity, count := range image.counts {
+				workloadCounts[workload][severity] += count
+			}
+		}
+
+		severity, check := "", ""
+		switch {
+		case image.counts["CRITICAL"] > 0:
+			severity, check = result.SeverityError, "critical-vulnerabilities"
+		case image.counts["HIGH"] > 0:
+			severity, check = result.SeverityWarning, "high-vulnerabilities"
+		default:
+			continue
+		}
+		findings = append(findings, result.Finding{
+			Check:    check,
+			Severity: severity,
+			Resource: image.image,
+			Message:  fmt.Sprintf("%d critical, %d high vulnerabilities", image.counts["CRITICAL"], image.counts["HIGH"]),
+			Details: map[string]string{
+				"scanned":   image.ref,
+				"workloads": workloads,
+				"medium":    fmt.Sprintf("%d", image.counts["MEDIUM"]),
+				"low":       fmt.Sprintf("%d", image.counts["LOW"]),
+			},
+		})
+	}
+
+	if options.Output == "json" {
+		if err := result.New("scan-images", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return gateImageScan(findings, totals["CRITICAL"], options)
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "IMAGE\tWORKLOADS\tCRITICAL\tHIGH\tMEDIUM\tLOW")
+	for _, ref := range refs {
+		image := images[ref]
+		if image.err != nil {
+			fmt.Fprintf(w, "%s\t%d\t⚠️  scan failed\t\t\t\n", image.image, len(image.workloads))
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%d\t%d\n", image.image, len(image.workloads), criticalCell(image.counts["CRITICAL"]),
+			image.counts["HIGH"], image.counts["MEDIUM"], image.counts["LOW"])
+	}
+	w.Flush()
+
+	workloads := sortedKeys(workloadCounts)
+	sort.SliceStable(workloads, func(i, j int) bool {
+		a, b := workloadCounts[workloads[i]], workloadCounts[workloads[j]]
+		if a["CRITICAL"] != b["CRITICAL"] {
+			return a["CRITICAL"] > b["CRITICAL"]
+		}
+		return a["HIGH"] > b["HIGH"]
+	})
+	fmt.Println("\nBy workload:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WORKLOAD\tIMAGES\tCRITICAL\tHIGH\tMEDIUM\tLOW")
+	for _, workload := range workloads {
+		counts := workloadCounts[workload]
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%d\t%d\n", workload, workloadImages[workload], criticalCell(counts["CRITICAL"]),
+			counts["HIGH"], counts["MEDIUM"], counts["LOW"])
+	}
+	w.Flush()
+
+	fmt.Println("\n--- Image Scan Summary ---")
+	fmt.Printf("Images: %d scanned, %d failed, in %d workloads\n", len(refs)-failed, failed, len(workloadCounts))
+	fmt.Printf("Vulnerabilities: %d critical, %d high, %d medium, %d low\n", totals["CRITICAL"], totals["HIGH"], totals["MEDIUM"], totals["LOW"])
+	switch {
+	case options.MaxCritical < 0:
+	case totals["CRITICAL"] > options.MaxCritical:
+		fmt.Printf("❌ %d critical vulnerabilities, more than the %d allowed\n", totals["CRITICAL"], options.MaxCritical)
+	default:
+		fmt.Printf("✅ Critical vulnerabilities within the limit of %d\n", options.MaxCritical)
+	}
+	if failed > 0 {
+		fmt.Printf("⚠️  %d images could not be scanned; private registries need the scanner to be logged in (e.g. aws ecr get-login-password | docker login)\n", failed)
+	}
+	fmt.Println("----------------------------------------------------")
+	return gateImageScan(findings, totals["CRITICAL"], options)
+}
+
+// gateImageScan fails the run on findings at or above --fail-on, or on more
+// critical vulnerabilities than --max-critical.
+func gateImageScan(findings []result.Finding, criticals int, options ScanImagesOptions) error {
+	if err := result.Gate(findings, options.FailOn); err != nil {
+		return err
+	}
+	if options.MaxCritical >= 0 && criticals > options.MaxCritical {
+		return &result.FindingsError{Count: criticals, Threshold: "critical"}
+	}
+	return nil
+}
+
+func criticalCell(count int) string {
+	if count > 0 {
+		return fmt.Sprintf("❌ %d", count)
+	}
+	return "0"
+}
+
+// collectImageInventory returns the images of the running pods' containers
+// keyed by the reference to scan, with the workloads running each.
+func collectImageInventory(namespace string) (map[string]*scannedImage, error) {
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	replicaSets, err := clientset.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replicasets: %w", err)
+	}
+	rsOwnerCache := make(map[string]string)
+	for _, rs := range replicaSets.Items {
+		for _, owner := range rs.OwnerReferences {
+			if owner.Kind == "Deployment" {
+				rsOwnerCache[rs.Namespace+"/"+rs.Name] = owner.Name
+			}
+		}
+	}
+
+	images := make(map[string]*scannedImage)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		owner, ownerType := getPodOwnerFast(pod, rsOwnerCache)
+		workload := fmt.Sprintf("%s/%s/%s", pod.Namespace, ownerType, owner)
+
+		specImages := make(map[string]string)
+		for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+			specImages[container.Name] = container.Image
+		}
+		for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			image := specImages[status.Name]
+			if image == "" {
+				continue
+			}
+			ref := runningImageRef(image, status.ImageID)
+			if images[ref] == nil {
+				images[ref] = &scannedImage{ref: ref, image: image, workloads: make(map[string]bool)}
+			}
+			images[ref].workloads[workload] = true
+		}
+	}
+	return images, nil
+}
+
+// runningImageRef returns the repository@digest a container actually runs,
+// from its status imageID, so a moved tag doesn't change what is scanned.
+// It falls back to the image of the pod spec when the runtime only reports
+// the image's config digest.
+func runningImageRef(image, imageID string) string {
+	imageID = strings.TrimPrefix(imageID, "docker-pullable://")
+	if repository, _, ok := strings.Cut(imageID, "@sha256:"); ok && repository != "" {
+		return imageID
+	}
+	return image
+}
+
+// runImageScanner scans one image and counts its distinct vulnerabilities
+// per severity.
+func runImageScanner(scanner, path string, extraArgs []string, ref string) (map[string]int, error) {
+	var args []string
+	switch scanner {
+	case "trivy":
+		args = []string{"image", "--quiet", "--format", "json", "--scanners", "vuln"}
+	case "grype":
+		args = []string{"--quiet", "--output", "json"}
+	}
+	args = append(append(args, extraArgs...), ref)
+
+	cmd := exec.Command(path, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if lines := strings.Split(message, "\n"); len(lines) > 3 {
+			message = strings.Join(lines[len(lines)-3:], " ")
+		}
+		return nil, fmt.Errorf("%s failed: %w: %s", scanner, err, message)
+	}
+
+	seen := make(map[string]bool) // Severity and ID, as packages share vulnerabilities
+	counts := make(map[string]int)
+	add := func(id, severity string) {
+		severity = strings.ToUpper(severity)
+		if key := severity + "/" + id; !seen[key] {
+			seen[key] = true
+			counts[severity]++
+		}
+	}
+	switch scanner {
+	case "trivy":
+		var report struct {
+			Results []struct {
+				Vulnerabilities []struct {
+					VulnerabilityID string
+					Severity        string
+				}
+			}
+		}
+		if err := json.Unmarshal(output, &report); err != nil {
+			return nil, fmt.Errorf("failed to parse trivy output: %w", err)
+		}
+		for _, target := range report.Results {
+			for _, vulnerability := range target.Vulnerabilities {
+				add(vulnerability.VulnerabilityID, vulnerability.Severity)
+			}
+		}
+	case "grype":
+		var report struct {
+			Matches []struct {
+				Vulnerability struct {
+					ID       string `json:"id"`
+					Severity string `json:"severity"`
+				} `json:"vulnerability"`
+			} `json:"matches"`
+		}
+		if err := json.Unmarshal(output, &report); err != nil {
+			return nil, fmt.Errorf("failed to parse grype output: %w", err)
+		}
+		for _, match := range report.Matches {
+			add(match.Vulnerability.ID, match.Vulnerability.Severity)
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,192 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/config"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SecretAgeOptions contains options for the secret age report
+type SecretAgeOptions struct {
+	Namespace  string
+	Type       string // Only secrets of this type, e.g. kubernetes.io/tls
+	Annotation string // Only secrets with this annotation, as "key" or "key=value"
+	Output     string // table or json
+	FailOn     string // Lowest severity that fails the run: error, warning, info or none
+}
+
+type secretAgeRow struct {
+	namespace   string
+	name        string
+	secretType  string
+	created     time.Time
+	lastUpdated time.Time
+	ageDays     int
+	maxAgeDays  int
+	certExpiry  string
+	certDays    int
+	hasCert     bool
+}
+
+// ShowSecretAge lists secrets with their creation and last update time, flags
+// the ones older than the rotation policy and shows certificate expiry for
+// secrets that hold one.
+func ShowSecretAge(options SecretAgeOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	rotation, err := cfg.SecretRotation()
+	if err != nil {
+		return err
+	}
+
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	listOptions := metav1.ListOptions{}
+	if options.Type != "" {
+		listOptions.FieldSelector = "type=" + options.Type
+	}
+	secrets, err := clientset.CoreV1().Secrets(options.Namespace).List(context.TODO(), listOptions)
+	if err != nil {
+		return fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	annotationKey, annotationValue, matchValue := strings.Cut(options.Annotation, "=")
+
+	now := time.Now()
+	var rows []secretAgeRow
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if options.Annotation != "" {
+			value, exists := secret.Annotations[annotationKey]
+			if !exists || (matchValue && value != annotationValue) {
+				continue
+			}
+		}
+
+		lastUpdated := objectLastUpdated(secret.ObjectMeta)
+		row := secretAgeRow{
+			namespace:   secret.Namespace,
+			name:        secret.Name,
+			secretType:  string(secret.Type),
+			created:     secret.CreationTimestamp.Time,
+			lastUpdated: lastUpdated,
+			ageDays:     int(now.Sub(lastUpdated).Hours() / 24),
+			maxAgeDays:  rotation.MaxAgeFor(string(secret.Type)),
+			certExpiry:  "-",
+		}
+
+		if certData, _ := findCertData(secret); certData != nil {
+			if cert, err := parsePEMCertificate(certData); err == nil {
+				row.hasCert = true
+				row.certExpiry = cert.NotAfter.Format("2006-01-02")
+				row.certDays = int(cert.NotAfter.Sub(now).Hours() / 24)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 && options.Output != "json" {
+		fmt.Println("No secrets found matching the given filters.")
+		return nil
+	}
+
+	// Oldest first so the secrets most in need of rotation are on top
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].lastUpdated.Before(rows[j].lastUpdated)
+	})
+
+	overdue, expiring, expired := 0, 0, 0
+	var findings []result.Finding
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if options.Output != "json" {
+		fmt.Fprintln(w, "NAMESPACE\tNAME\tTYPE\tCREATED\tLAST UPDATED\tAGE (DAYS)\tCERT EXPIRY\tSTATUS")
+	}
+	for _, row := range rows {
+		var notes []string
+		addFinding := func(check, severity, message string) {
+			notes = append(notes, message)
+			findings = append(findings, result.Finding{
+				Check:    check,
+				Severity: severity,
+				Resource: "Secret/" + row.namespace + "/" + row.name,
+				Message:  message,
+				Details: map[string]string{
+					"type":         row.secretType,
+					"last_updated": row.lastUpdated.Format(time.RFC3339),
+					"cert_expiry":  row.certExpiry,
+				},
+			})
+		}
+		if row.ageDays > row.maxAgeDays {
+			overdue++
+			addFinding("rotation-overdue", result.SeverityWarning, fmt.Sprintf("rotation overdue (>%dd)", row.maxAgeDays))
+		}
+		if row.hasCert {
+			if row.certDays < 0 {
+				expired++
+				addFinding("cert-expired", result.SeverityError, fmt.Sprintf("cert expired %d days ago", -row.certDays))
+			} else if row.certDays <= certExpiryWarningDays {
+				expiring++
+				addFinding("cert-expiring", result.SeverityWarning, fmt.Sprintf("cert expires in %d days", row.certDays))
+			}
+		}
+		if options.Output == "json" {
+			continue
+		}
+
+		status := "✅ OK"
+		if len(notes) > 0 {
+			status = "⚠️  " + strings.Join(notes, ", ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			row.namespace, row.name, row.secretType,
+			row.created.Format("2006-01-02"), row.lastUpdated.Format("2006-01-02"),
+			row.ageDays, row.certExpiry, status)
+	}
+	if options.Output == "json" {
+		if err := result.New("secret-age", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+	w.Flush()
+
+	fmt.Println("\n--- Secret Age Summary ---")
+	fmt.Printf("Secrets checked: %d\n", len(rows))
+	fmt.Printf("Rotation overdue: %d\n", overdue)
+	fmt.Printf("Certificates expiring within %d days: %d\n", certExpiryWarningDays, expiring)
+	fmt.Printf("Certificates expired: %d\n", expired)
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// objectLastUpdated returns the most recent managed field write, which is the
+// closest thing to a modification time the API keeps for a secret or config
+// map.
+func objectLastUpdated(meta metav1.ObjectMeta) time.Time {
+	lastUpdated := meta.CreationTimestamp.Time
+	for _, entry := range meta.ManagedFields {
+		if entry.Time != nil && entry.Time.After(lastUpdated) {
+			lastUpdated = entry.Time.Time
+		}
+	}
+	return lastUpdated
+}
@@ -0,0 +1,210 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/ui"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// secretUser is an object referencing a secret and how it does so
+type secretUser struct {
+	kind   string
+	name   string
+	usedBy []string
+}
+
+// ShowSecretUsage lists the pods, workloads and ServiceAccounts referencing a
+// secret through env, envFrom, volumes or imagePullSecrets, to show the blast
+// radius of rotating it. Without a namespace all namespaces are searched and
+// selection decides between several matches.
+func ShowSecretUsage(secretName, namespace string, selection ui.Selection) error {
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+
+	if namespace == "" {
+		allSecrets, err := clientset.CoreV1().Secrets("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list secrets in all namespaces: %w", err)
+		}
+		var foundSecrets []corev1.Secret
+		for _, secret := range allSecrets.Items {
+			if secret.Name == secretName {
+				foundSecrets = append(foundSecrets, secret)
+			}
+		}
+		switch len(foundSecrets) {
+		case 0:
+			return fmt.Errorf("secret '%s' not found in any namespace", secretName)
+		case 1:
+			namespace = foundSecrets[0].Namespace
+		default:
+			selected, err := chooseSecret(secretName, foundSecrets, secretPreview, selection)
+			if err != nil {
+				return err
+			}
+			namespace = selected.Namespace
+		}
+	} else if _, err := clientset.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{}); err != nil {
+		return fmt.Errorf("failed to get secret '%s' in namespace '%s': %w", secretName, namespace, err)
+	}
+	fmt.Printf("Finding references to secret '%s' in namespace '%s'...\n", secretName, namespace)
+
+	var workloads []refWorkload
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list deployments: %w", err)
+	}
+	for _, d := range deployments.Items {
+		workloads = append(workloads, refWorkload{d.Namespace, "Deployment/" + d.Name, d.Spec.Template.Spec})
+	}
+	statefulSets, err := clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	for _, s := range statefulSets.Items {
+		workloads = append(workloads, refWorkload{s.Namespace, "StatefulSet/" + s.Name, s.Spec.Template.Spec})
+	}
+	daemonSets, err := clientset.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list daemonsets: %w", err)
+	}
+	for _, ds := range daemonSets.Items {
+		workloads = append(workloads, refWorkload{ds.Namespace, "DaemonSet/" + ds.Name, ds.Spec.Template.Spec})
+	}
+	cronJobs, err := clientset.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list cronjobs: %w", err)
+	}
+	for _, cj := range cronJobs.Items {
+		workloads = append(workloads, refWorkload{cj.Namespace, "CronJob/" + cj.Name, cj.Spec.JobTemplate.Spec.Template.Spec})
+	}
+	// Every pod is listed, not only unowned ones: running pods hold the
+	// current value and are the ones to restart after rotating
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		workloads = append(workloads, refWorkload{pod.Namespace, "Pod/" + pod.Name, pod.Spec})
+	}
+
+	var users []secretUser
+	podCount, workloadCount := 0, 0
+	for _, workload := range workloads {
+		usedBy := secretReferences(workload.spec, secretName)
+		if len(usedBy) == 0 {
+			continue
+		}
+		kind, name, _ := strings.Cut(workload.name, "/")
+		users = append(users, secretUser{kind, name, usedBy})
+		if kind == "Pod" {
+			podCount++
+		} else {
+			workloadCount++
+		}
+	}
+
+	serviceAccounts, err := clientset.CoreV1().ServiceAccounts(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list service accounts: %w", err)
+	}
+	serviceAccountCount := 0
+	for _, sa := range serviceAccounts.Items {
+		var usedBy []string
+		for _, ref := range sa.Secrets {
+			if ref.Name == secretName {
+				usedBy = append(usedBy, "secrets")
+			}
+		}
+		for _, ref := range sa.ImagePullSecrets {
+			if ref.Name == secretName {
+				usedBy = append(usedBy, "imagePullSecrets")
+			}
+		}
+		if len(usedBy) > 0 {
+			users = append(users, secretUser{"ServiceAccount", sa.Name, usedBy})
+			serviceAccountCount++
+		}
+	}
+
+	if len(users) == 0 {
+		fmt.Println("✅ Nothing references this secret.")
+		return nil
+	}
+
+	sort.SliceStable(users, func(i, j int) bool {
+		if users[i].kind != users[j].kind {
+			return users[i].kind < users[j].kind
+		}
+		return users[i].name < users[j].name
+	})
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tUSED BY")
+	for _, user := range users {
+		for i, usedBy := range user.usedBy {
+			if i == 0 {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", user.kind, user.name, usedBy)
+			} else {
+				fmt.Fprintf(w, "\t\t%s\n", usedBy)
+			}
+		}
+	}
+	w.Flush()
+
+	fmt.Println("\n--- Secret Usage Summary ---")
+	fmt.Printf("Pods: %d\n", podCount)
+	fmt.Printf("Workloads: %d\n", workloadCount)
+	fmt.Printf("ServiceAccounts: %d\n", serviceAccountCount)
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// secretReferences returns where a pod spec references the secret.
+func secretReferences(spec corev1.PodSpec, secretName string) []string {
+	var usedBy []string
+	for _, volume := range spec.Volumes {
+		if volume.Secret != nil && volume.Secret.SecretName == secretName {
+			usedBy = append(usedBy, "volume "+volume.Name)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil && source.Secret.Name == secretName {
+					usedBy = append(usedBy, "volume "+volume.Name)
+					break
+				}
+			}
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.SecretRef != nil && envFrom.SecretRef.Name == secretName {
+				usedBy = append(usedBy, "container "+container.Name+" envFrom")
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name == secretName {
+				usedBy = append(usedBy, "container "+container.Name+" env "+env.Name)
+			}
+		}
+	}
+
+	for _, pullSecret := range spec.ImagePullSecrets {
+		if pullSecret.Name == secretName {
+			usedBy = append(usedBy, "imagePullSecrets")
+		}
+	}
+	return usedBy
+}
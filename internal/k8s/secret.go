@@ -1,39 +1,129 @@
 package k8s
 
 import (
-	"bufio"
 	"context"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
-	"os"
-	"strconv"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/HighonAces/swissarmycli/internal/audit"
 	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/ui"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// printDecodedSecret is a helper function to neatly print the contents of a secret.
-func printDecodedSecret(secret *v1.Secret) {
-	if len(secret.Data) == 0 {
-		fmt.Printf("Secret '%s' in namespace '%s' contains no data.\n", secret.Name, secret.Namespace)
-		return
-	}
-
+// printDecodedSecret is a helper function to neatly print the contents of a
+// secret, with only the ends of each value shown when mask is set.
+func printDecodedSecret(secret *v1.Secret, mask bool) {
 	fmt.Printf("\n--- Decoded Secret Data: '%s' (Namespace: %s) ---\n", secret.Name, secret.Namespace)
-	for key, value := range secret.Data {
+	for _, key := range sortedKeys(secret.Data) {
 		// The `client-go` library automatically decodes the secret data for us.
-		// The `value` here is a raw byte slice (`[]byte`) of the already-decoded data.
-		// We just need to cast it to a string to print it.
+		// The value here is a raw byte slice (`[]byte`) of the already-decoded data.
+		value := secret.Data[key]
+		if mask {
+			fmt.Printf("%s: %s\n", key, maskSecretValue(value))
+			continue
+		}
 		fmt.Printf("%s: %s\n", key, string(value))
 	}
 	fmt.Println("----------------------------------------------------")
 }
 
-func RevealSecret(secretName, namespace string) error {
+// maskSecretValue keeps the first and last 4 characters of a value, enough
+// to tell which one it is without leaking it. Values of up to 8 characters
+// are hidden entirely.
+func maskSecretValue(value []byte) string {
+	runes := []rune(string(value))
+	if len(runes) <= 8 {
+		return fmt.Sprintf("******** (%d bytes)", len(value))
+	}
+	return fmt.Sprintf("%s****%s (%d bytes)", string(runes[:4]), string(runes[len(runes)-4:]), len(value))
+}
+
+// CopyChooseKey is the --copy value asking to pick the key from a list
+const CopyChooseKey = "?"
+
+// RevealSecretOptions contains options for revealing a secret
+type RevealSecretOptions struct {
+	Namespace string
+	Selection ui.Selection
+	Mask      bool   // Show only the first and last 4 characters of each value
+	Copy      string // Key whose value is copied to the clipboard instead of printed, or CopyChooseKey
+}
+
+// revealSecretData prints the secret's values, or copies one of them to the
+// clipboard, after recording the access in the audit log. Nothing is
+// revealed when the audit entry can't be written.
+func revealSecretData(secret *v1.Secret, options RevealSecretOptions) error {
+	if len(secret.Data) == 0 {
+		fmt.Printf("Secret '%s' in namespace '%s' contains no data.\n", secret.Name, secret.Namespace)
+		return nil
+	}
+	entry := audit.Entry{Command: "reveal-secret", Namespace: secret.Namespace, Name: secret.Name, Keys: sortedKeys(secret.Data), Mode: "plain"}
+	entry.Context, entry.Cluster, _ = common.CurrentContext()
+
+	if options.Copy != "" {
+		key, err := chooseSecretKey(secret, options)
+		if err != nil {
+			return err
+		}
+		entry.Keys, entry.Mode = []string{key}, "clipboard"
+		if err := audit.Record(entry); err != nil {
+			return err
+		}
+		if err := ui.CopyToClipboard(secret.Data[key]); err != nil {
+			return fmt.Errorf("failed to copy to the clipboard: %w", err)
+		}
+		fmt.Printf("✅ Copied '%s' of secret '%s' (Namespace: %s) to the clipboard (%d bytes)\n", key, secret.Name, secret.Namespace, len(secret.Data[key]))
+		return nil
+	}
+
+	if options.Mask {
+		entry.Mode = "masked"
+	}
+	if err := audit.Record(entry); err != nil {
+		return err
+	}
+	printDecodedSecret(secret, options.Mask)
+	return nil
+}
+
+// chooseSecretKey returns the key named by --copy. With CopyChooseKey, a
+// secret with a single key needs no choice and otherwise the picker opens.
+func chooseSecretKey(secret *v1.Secret, options RevealSecretOptions) (string, error) {
+	keys := sortedKeys(secret.Data)
+	if options.Copy != CopyChooseKey {
+		if _, ok := secret.Data[options.Copy]; !ok {
+			return "", fmt.Errorf("secret '%s' has no key '%s' (keys: %s)", secret.Name, options.Copy, strings.Join(keys, ", "))
+		}
+		return options.Copy, nil
+	}
+	if len(keys) == 1 {
+		return keys[0], nil
+	}
+	if options.Selection.NonInteractive || !ui.IsInteractive() {
+		return "", fmt.Errorf("secret '%s' has several keys (%s), name one with --copy=<key>", secret.Name, strings.Join(keys, ", "))
+	}
+	items := make([]ui.Item, len(keys))
+	for i, key := range keys {
+		items[i] = ui.Item{Label: key, Preview: fmt.Sprintf("%d bytes", len(secret.Data[key]))}
+	}
+	choice, err := ui.Pick(fmt.Sprintf("Keys of secret '%s'", secret.Name), items)
+	if err != nil {
+		return "", err
+	}
+	return keys[choice], nil
+}
+
+// RevealSecret prints the decoded data of a secret, masked or copied to the
+// clipboard as options ask. Without a namespace all namespaces are searched
+// and the selection decides between several matches.
+func RevealSecret(secretName string, options RevealSecretOptions) error {
+	namespace := options.Namespace
 	clientset, err := common.GetKubernetesClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
@@ -46,8 +136,7 @@ func RevealSecret(secretName, namespace string) error {
 		if err != nil {
 			return fmt.Errorf("failed to get secret '%s' in namespace '%s': %w", secretName, namespace, err)
 		}
-		printDecodedSecret(secret)
-		return nil
+		return revealSecretData(secret, options)
 	}
 
 	// --- Case 2: No namespace provided; search all namespaces ---
@@ -75,68 +164,106 @@ func RevealSecret(secretName, namespace string) error {
 		// Exactly one match was found, so we can print it directly.
 		secret := foundSecrets[0]
 		fmt.Printf("Found one match in namespace '%s'.\n", secret.Namespace)
-		printDecodedSecret(&secret)
+		return revealSecretData(&secret, options)
 
 	default:
 		// Multiple matches found, so we need to ask the user which one they want.
-		fmt.Printf("Found multiple secrets named '%s'. Please choose one:\n", secretName)
-		for i, secret := range foundSecrets {
-			fmt.Printf("[%d] %s\n", i+1, secret.Namespace)
+		selectedSecret, err := chooseSecret(secretName, foundSecrets, secretPreview, options.Selection)
+		if err != nil {
+			return err
 		}
+		return revealSecretData(selectedSecret, options)
+	}
+}
 
-		// Create a reader to get user input from the console.
-		reader := bufio.NewReader(os.Stdin)
-		for {
-			fmt.Print("Enter number: ")
-			input, _ := reader.ReadString('\n')
-			input = strings.TrimSpace(input)
-
-			choice, err := strconv.Atoi(input)
-			if err != nil || choice < 1 || choice > len(foundSecrets) {
-				fmt.Printf("Invalid input. Please enter a number between 1 and %d.\n", len(foundSecrets))
-				continue // Ask again if the input is not a valid number in the range.
-			}
-
-			// Use the user's choice to select the correct secret.
-			selectedSecret := foundSecrets[choice-1]
-			printDecodedSecret(&selectedSecret)
-			break // Exit the loop after a valid choice.
-		}
+// chooseSecret lets the user pick one of several secrets with the same name
+// by namespace, with preview showing details of the highlighted one.
+// Secrets are ordered by namespace so --index is stable between runs.
+func chooseSecret(secretName string, secrets []v1.Secret, preview func(*v1.Secret) string, selection ui.Selection) (*v1.Secret, error) {
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Namespace < secrets[j].Namespace })
+	items := make([]ui.Item, len(secrets))
+	for i := range secrets {
+		items[i] = ui.Item{Label: secrets[i].Namespace, Preview: preview(&secrets[i])}
+	}
+	choice, err := ui.Choose(fmt.Sprintf("Secrets named '%s'", secretName), items, selection)
+	if err != nil {
+		return nil, err
+	}
+	return &secrets[choice], nil
+}
+
+// secretPreview describes a secret without showing its values.
+func secretPreview(secret *v1.Secret) string {
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
 
-	return nil
+	preview := fmt.Sprintf("Namespace: %s\nType: %s\nCreated: %s\n\nKeys:\n",
+		secret.Namespace, secret.Type, secret.CreationTimestamp.Format(time.RFC3339))
+	for _, key := range keys {
+		preview += fmt.Sprintf("  %s (%d bytes)\n", key, len(secret.Data[key]))
+	}
+	return preview
+}
+
+// certPreview describes the certificate held by a secret.
+func certPreview(secret *v1.Secret) string {
+	preview := fmt.Sprintf("Namespace: %s\nType: %s\n\n", secret.Namespace, secret.Type)
+	certData, _ := findCertData(secret)
+	if certData == nil {
+		return preview + "No certificate data found."
+	}
+	cert, err := parsePEMCertificate(certData)
+	if err != nil {
+		return preview + err.Error()
+	}
+	return preview + fmt.Sprintf("Subject: %s\nIssuer: %s\nNot After: %s (%d days)\nDNS Names: %s\n",
+		cert.Subject, cert.Issuer, cert.NotAfter.Format("2006-01-02"),
+		int(time.Until(cert.NotAfter).Hours()/24), strings.Join(cert.DNSNames, ", "))
 }
 
 
-func printCertDetails(secret *v1.Secret) error {
-	fmt.Printf("\n--- TLS Certificate Details: '%s' (Namespace: %s) ---\n", secret.Name, secret.Namespace)
-	
+// findCertData returns the PEM certificate data stored in the secret and the
+// key it was found under, or nil if none of the well-known keys are present.
+func findCertData(secret *v1.Secret) ([]byte, string) {
 	certKeys := []string{"tls.crt", "cert.pem", "certificate", "cert"}
-	var certData []byte
-	var foundKey string
-	
 	for _, key := range certKeys {
 		if data, exists := secret.Data[key]; exists {
-			certData = data
-			foundKey = key
-			break
+			return data, key
 		}
 	}
+	return nil, ""
+}
+
+// parsePEMCertificate decodes the first PEM block in certData as an x509 certificate.
+func parsePEMCertificate(certData []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certData)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return cert, nil
+}
+
+func printCertDetails(secret *v1.Secret) error {
+	fmt.Printf("\n--- TLS Certificate Details: '%s' (Namespace: %s) ---\n", secret.Name, secret.Namespace)
 	
+	certData, foundKey := findCertData(secret)
 	if certData == nil {
 		return fmt.Errorf("no certificate data found in secret. Please check if the secret have one of the following keys tls.crt, cert.pem, certificate, cert")
 	}
 	
 	fmt.Printf("Certificate Key: %s\n", foundKey)
 	
-	block, _ := pem.Decode(certData)
-	if block == nil {
-		return fmt.Errorf("failed to decode PEM block")
-	}
-	
-	cert, err := x509.ParseCertificate(block.Bytes)
+	cert, err := parsePEMCertificate(certData)
 	if err != nil {
-		return fmt.Errorf("failed to parse certificate: %w", err)
+		return err
 	}
 	
 	fmt.Printf("Subject: %s\n", cert.Subject)
@@ -163,7 +290,10 @@ func printCertDetails(secret *v1.Secret) error {
 	return nil
 }
 
-func CheckTLSSecret(secretName, namespace string) error {
+// CheckTLSSecret prints the certificate held by a secret. Without a
+// namespace all namespaces are searched and selection decides between
+// several matches.
+func CheckTLSSecret(secretName, namespace string, selection ui.Selection) error {
 	clientset, err := common.GetKubernetesClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
@@ -195,24 +325,10 @@ func CheckTLSSecret(secretName, namespace string) error {
 	case 1:
 		return printCertDetails(&foundSecrets[0])
 	default:
-		fmt.Printf("Found multiple secrets named '%s'. Please choose one:\n", secretName)
-		for i, secret := range foundSecrets {
-			fmt.Printf("[%d] %s\n", i+1, secret.Namespace)
-		}
-		
-		reader := bufio.NewReader(os.Stdin)
-		for {
-			fmt.Print("Enter number: ")
-			input, _ := reader.ReadString('\n')
-			input = strings.TrimSpace(input)
-			
-			choice, err := strconv.Atoi(input)
-			if err != nil || choice < 1 || choice > len(foundSecrets) {
-				fmt.Printf("Invalid input. Please enter a number between 1 and %d.\n", len(foundSecrets))
-				continue
-			}
-			
-			return printCertDetails(&foundSecrets[choice-1])
+		selectedSecret, err := chooseSecret(secretName, foundSecrets, certPreview, selection)
+		if err != nil {
+			return err
 		}
+		return printCertDetails(selectedSecret)
 	}
 }
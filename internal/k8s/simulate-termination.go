@@ -0,0 +1,450 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/aws/aws-sdk-go/aws/session"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// spotInterruptionNotice is how long EC2 warns before reclaiming a spot instance
+const spotInterruptionNotice = 2 * time.Minute
+
+// capacityTypeLabels name on-demand or spot capacity, for managed node
+// groups and Karpenter
+var capacityTypeLabels = []string{"eks.amazonaws.com/capacityType", "karpenter.sh/capacity-type"}
+
+// SimulateTerminationOptions contains options for the termination dry run
+type SimulateTerminationOptions struct {
+	Event   string // spot or scale-in
+	Region  string // Defaults to the region of the node
+	Profile string
+}
+
+// evictedPod is a pod the termination would evict, and where it would go
+type evictedPod struct {
+	pod      *corev1.Pod
+	owner    string // namespace/kind/name
+	cpu, mem float64
+	grace    time.Duration
+	risks    []string
+	target   string // Node the pod would be rescheduled on, empty when none fits
+}
+
+// SimulateTermination walks through what would happen if the node received
+// a spot interruption or was scaled in by its ASG, without changing
+// anything: the pods a termination handler would evict and the risks each
+// carries, the PodDisruptionBudgets that would hold the drain up, the nodes
+// the scheduler could place the pods on, and the load balancer targets to
+// drain. Everything is measured against the time the event leaves: the two
+// minute spot notice, or the ASG's termination lifecycle hook.
+func SimulateTermination(nodeName string, options SimulateTerminationOptions) error {
+	if options.Event != "spot" && options.Event != "scale-in" {
+		return fmt.Errorf("invalid --event %q, use spot or scale-in", options.Event)
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+	node, err := clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+	nodeList, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	podList, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	replicaSets, err := clientset.AppsV1().ReplicaSets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list replicasets: %w", err)
+	}
+	pdbs, err := clientset.PolicyV1().PodDisruptionBudgets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list PodDisruptionBudgets: %w", err)
+	}
+	pvcs, err := clientset.CoreV1().PersistentVolumeClaims("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list PVCs: %w", err)
+	}
+	pvs, err := clientset.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list PVs: %w", err)
+	}
+
+	machine := nodeMachine(*node)
+	group, _ := nodeGroupOf(*node)
+	capacityType := "-"
+	for _, label := range capacityTypeLabels {
+		if value := node.Labels[label]; value != "" {
+			capacityType = strings.ToLower(value)
+		}
+	}
+	fmt.Printf("Node: %s (%s, %s, group %s, %s)\n", node.Name, valueOrDash(machine.InstanceID),
+		getNodeInstanceType(*node), group, valueOrDash(machine.Zone))
+	if options.Event == "spot" && capacityType == "on-demand" {
+		fmt.Println("⚠️  The node runs on on-demand capacity and can't receive a spot interruption; simulating one anyway")
+	}
+
+	// The window is how long pods have between the handler cordoning the
+	// node and the instance going away
+	window := spotInterruptionNotice
+	windowSource := "spot interruption notice"
+	var sess *session.Session
+	var sessErr error
+	if options.Event == "scale-in" {
+		window, windowSource = 0, "no termination lifecycle hook"
+	}
+	if machine.InstanceID != "" {
+		region := options.Region
+		if region == "" {
+			region = node.Labels["topology.kubernetes.io/region"]
+		}
+		sess, sessErr = awsutils.NewSession(options.Profile, region)
+		if options.Event == "scale-in" && sessErr == nil {
+			asgName, hooks, err := awsutils.TerminationLifecycleHooks(sess, machine.InstanceID)
+			switch {
+			case err != nil:
+				sessErr = err
+				windowSource = "lifecycle hooks unknown"
+			case asgName == "":
+				windowSource = "instance is not in an ASG"
+			default:
+				for _, hook := range hooks {
+					if hookWindow := time.Duration(hook.HeartbeatTimeout) * time.Second; hookWindow > window {
+						window, windowSource = hookWindow, fmt.Sprintf("lifecycle hook %s of %s, default %s", hook.Name, asgName, hook.DefaultResult)
+					}
+				}
+				if len(hooks) == 0 {
+					windowSource = "no termination lifecycle hook on " + asgName
+				}
+			}
+		}
+	}
+	fmt.Printf("Event: %s, %s to drain (%s)\n", options.Event, window, windowSource)
+
+	rsOwnerCache := make(map[string]string)
+	for _, rs := range replicaSets.Items {
+		for _, owner := range rs.OwnerReferences {
+			if owner.Kind == "Deployment" {
+				rsOwnerCache[rs.Namespace+"/"+rs.Name] = owner.Name
+			}
+		}
+	}
+	claimZones := make(map[string]string) // namespace/claim to the zone its volume is pinned to
+	volumes := make(map[string]corev1.PersistentVolume)
+	for _, pv := range pvs.Items {
+		volumes[pv.Name] = pv
+	}
+	for _, pvc := range pvcs.Items {
+		if pv, ok := volumes[pvc.Spec.VolumeName]; ok && machine.Zone != "" && persistentVolumeInZone(pv, machine.Zone) {
+			claimZones[pvc.Namespace+"/"+pvc.Name] = machine.Zone
+		}
+	}
+
+	// Owners' running pods across the cluster, to tell which lose their
+	// last replica
+	running := make(map[string]int)
+	var evicted []*evictedPod
+	staying := 0
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Status.Phase != corev1.PodRunning && pod.Status.Phase != corev1.PodPending {
+			continue
+		}
+		owner, ownerType := getPodOwnerFast(pod, rsOwnerCache)
+		key := fmt.Sprintf("%s/%s/%s", pod.Namespace, ownerType, owner)
+		if pod.Status.Phase == corev1.PodRunning {
+			running[key]++
+		}
+		if pod.Spec.NodeName != node.Name {
+			continue
+		}
+		if !podNeedsEviction(*pod) {
+			staying++
+			continue
+		}
+		cpu, mem := podRequests(pod)
+		grace := 30 * time.Second
+		if pod.Spec.TerminationGracePeriodSeconds != nil {
+			grace = time.Duration(*pod.Spec.TerminationGracePeriodSeconds) * time.Second
+		}
+		evicted = append(evicted, &evictedPod{pod: pod, owner: key, cpu: cpu, mem: mem, grace: grace})
+	}
+
+	onNode := make(map[string]int)
+	for _, e := range evicted {
+		onNode[e.owner]++
+	}
+	lastReplica := make(map[string]bool)
+	for _, e := range evicted {
+		pod := e.pod
+		switch {
+		case strings.Contains(e.owner, "/Pod/"):
+			e.risks = append(e.risks, "bare pod, not recreated")
+		case running[e.owner] > 0 && onNode[e.owner] >= running[e.owner]:
+			e.risks = append(e.risks, "all running replicas are on this node")
+			lastReplica[e.owner] = true
+		}
+		if window > 0 && e.grace > window {
+			e.risks = append(e.risks, fmt.Sprintf("grace period %s is cut off", e.grace))
+		}
+		if pod.Annotations[safeToEvictAnnotation] == "false" {
+			e.risks = append(e.risks, "marked "+safeToEvictAnnotation+"=false")
+		}
+		for _, volume := range pod.Spec.Volumes {
+			switch {
+			case volume.EmptyDir != nil:
+				e.risks = append(e.risks, "emptyDir "+volume.Name+" is lost")
+			case volume.PersistentVolumeClaim != nil && claimZones[pod.Namespace+"/"+volume.PersistentVolumeClaim.ClaimName] != "":
+				e.risks = append(e.risks, fmt.Sprintf("PVC %s pinned to %s", volume.PersistentVolumeClaim.ClaimName, machine.Zone))
+			}
+		}
+	}
+	sort.Slice(evicted, func(i, j int) bool {
+		if evicted[i].pod.Namespace != evicted[j].pod.Namespace {
+			return evicted[i].pod.Namespace < evicted[j].pod.Namespace
+		}
+		return evicted[i].pod.Name < evicted[j].pod.Name
+	})
+
+	fmt.Printf("\n=== PODS TO BE EVICTED (%d) ===\n", len(evicted))
+	if len(evicted) == 0 {
+		fmt.Println("No pods besides DaemonSet and static pods run on this node.")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAMESPACE\tPOD\tOWNER\tGRACE\tRISKS")
+		for _, e := range evicted {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.pod.Namespace, e.pod.Name, strings.TrimPrefix(e.owner, e.pod.Namespace+"/"),
+				e.grace, valueOrDash(strings.Join(e.risks, "; ")))
+		}
+		w.Flush()
+	}
+	if staying > 0 {
+		fmt.Printf("%d DaemonSet and static pods stop with the node.\n", staying)
+	}
+
+	blockingPDBs := printTerminationPDBs(pdbs.Items, evicted, options.Event)
+	unplaced := placeEvictedPods(node.Name, nodeList.Items, podList.Items, evicted, claimZones)
+	drainTooLong := 0
+	if machine.InstanceID != "" {
+		drainTooLong = printTerminationTargets(sess, sessErr, machine.InstanceID, evicted, window)
+	}
+
+	fmt.Println("\n--- Termination Simulation Summary ---")
+	fmt.Printf("Node: %s, %s with %s to drain\n", node.Name, options.Event, window)
+	fmt.Printf("Pods evicted: %d, %d stop with the node\n", len(evicted), staying)
+	issues := 0
+	if len(lastReplica) > 0 {
+		fmt.Printf("❌ %d workloads have all their running replicas on this node and go down until rescheduled\n", len(lastReplica))
+		issues++
+	}
+	if blockingPDBs > 0 {
+		fmt.Printf("⚠️  %d PodDisruptionBudgets allow fewer disruptions than the pods they cover here\n", blockingPDBs)
+		issues++
+	}
+	if unplaced > 0 {
+		fmt.Printf("⚠️  %d pods don't fit on the other nodes and stay Pending until a node is added\n", unplaced)
+		issues++
+	}
+	if drainTooLong > 0 {
+		fmt.Printf("⚠️  %d target group registrations drain for longer than the %s window\n", drainTooLong, window)
+		issues++
+	}
+	if options.Event == "scale-in" && window == 0 {
+		fmt.Println("⚠️  Without a termination lifecycle hook the instance terminates while pods are still running")
+		issues++
+	}
+	if issues == 0 {
+		fmt.Println("✅ The node can go away without losing a workload")
+	}
+	fmt.Println("Nothing was changed.")
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// printTerminationPDBs lists the PodDisruptionBudgets covering the evicted
+// pods and returns how many allow fewer disruptions than the node holds.
+// A spot interruption doesn't wait for them: the pods go away regardless.
+func printTerminationPDBs(pdbs []policyv1.PodDisruptionBudget, evicted []*evictedPod, event string) int {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	covering, blocking := 0, 0
+	for _, pdb := range pdbs {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		pods := 0
+		for _, e := range evicted {
+			if e.pod.Namespace == pdb.Namespace && selector.Matches(labels.Set(e.pod.Labels)) {
+				pods++
+			}
+		}
+		if pods == 0 {
+			continue
+		}
+		if covering == 0 {
+			fmt.Fprintln(w, "NAMESPACE\tPDB\tPODS ON NODE\tDISRUPTIONS ALLOWED\tRESULT")
+		}
+		covering++
+		status := "✅ drains"
+		if int32(pods) > pdb.Status.DisruptionsAllowed {
+			blocking++
+			status = "⚠️  drain waits for replacements"
+			if event == "spot" {
+				status = "❌ budget broken when the instance is reclaimed"
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", pdb.Namespace, pdb.Name, pods, pdb.Status.DisruptionsAllowed, status)
+	}
+	fmt.Printf("\n=== PDB CONSTRAINTS (%d) ===\n", covering)
+	if covering == 0 {
+		fmt.Println("No PodDisruptionBudget covers the evicted pods.")
+		return 0
+	}
+	w.Flush()
+	return blocking
+}
+
+// placeEvictedPods finds a node for each evicted pod, largest CPU request
+// first, on the node that would be least loaded after taking it, honoring
+// selectors, taints, anti-affinity and zonal volumes. It prints the
+// placements and returns how many pods fit nowhere.
+func placeEvictedPods(terminating string, nodeList []corev1.Node, podList []corev1.Pod, evicted []*evictedPod, claimZones map[string]string) int {
+	var nodes []*rebalanceNode
+	byName := make(map[string]*rebalanceNode)
+	for _, node := range nodeList {
+		if node.Name == terminating {
+			continue
+		}
+		// Allocatable rather than capacity: that is what the scheduler fits against
+		n := &rebalanceNode{
+			node: node,
+			info: NodeInfo{
+				Name:           node.Name,
+				CPUCapacity:    float64(node.Status.Allocatable.Cpu().MilliValue()) / 1000,
+				MemoryCapacity: float64(node.Status.Allocatable.Memory().Value()) / (1024 * 1024 * 1024),
+			},
+			eligible: !node.Spec.Unschedulable && getNodeReadyStatus(node) == "True",
+		}
+		nodes = append(nodes, n)
+		byName[node.Name] = n
+	}
+	for i := range podList {
+		pod := &podList[i]
+		if n, ok := byName[pod.Spec.NodeName]; ok && pod.Status.Phase == corev1.PodRunning {
+			cpu, mem := podRequests(pod)
+			n.cpuRequests += cpu
+			n.memRequests += mem
+			n.pods = append(n.pods, pod)
+		}
+	}
+
+	order := append([]*evictedPod(nil), evicted...)
+	sort.SliceStable(order, func(i, j int) bool { return order[i].cpu > order[j].cpu })
+	unplaced := 0
+	for _, e := range order {
+		zone := ""
+		for _, volume := range e.pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil && claimZones[e.pod.Namespace+"/"+volume.PersistentVolumeClaim.ClaimName] != "" {
+				zone = claimZones[e.pod.Namespace+"/"+volume.PersistentVolumeClaim.ClaimName]
+			}
+		}
+		var best *rebalanceNode
+		for _, n := range nodes {
+			if zone != "" && getNodeZone(n.node) != zone {
+				continue
+			}
+			if !canHostPod(n, e.pod, e.cpu, e.mem, nodes) {
+				continue
+			}
+			if best == nil || loadAfter(n, e.cpu, e.mem) < loadAfter(best, e.cpu, e.mem) {
+				best = n
+			}
+		}
+		if best == nil {
+			unplaced++
+			continue
+		}
+		best.cpuRequests += e.cpu
+		best.memRequests += e.mem
+		best.pods = append(best.pods, e.pod)
+		e.target = best.node.Name
+	}
+
+	fmt.Printf("\n=== RESCHEDULE TARGETS ===\n")
+	if len(evicted) == 0 {
+		fmt.Println("Nothing to reschedule.")
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "POD\tCPU REQ\tMEM REQ\tTARGET\tLOAD AFTER")
+	for _, e := range evicted {
+		target, load := "❌ no node fits", "-"
+		if n := byName[e.target]; n != nil {
+			target, load = n.node.Name, fmt.Sprintf("%.0f%%", n.load())
+		}
+		fmt.Fprintf(w, "%s/%s\t%.2f\t%.2fGi\t%s\t%s\n", e.pod.Namespace, e.pod.Name, e.cpu, e.mem, target, load)
+	}
+	w.Flush()
+	fmt.Println("LOAD AFTER is the larger of the target's CPU and memory requests once every evicted pod is placed.")
+	return unplaced
+}
+
+// printTerminationTargets lists the target group registrations of the
+// instance and of the evicted pods' IPs, and returns how many drain for
+// longer than the window.
+func printTerminationTargets(sess *session.Session, sessErr error, instanceID string, evicted []*evictedPod, window time.Duration) int {
+	fmt.Printf("\n=== LOAD BALANCER TARGETS ===\n")
+	if sessErr != nil {
+		fmt.Printf("⚠️  Could not check target groups: %v\n", sessErr)
+		return 0
+	}
+	targetIDs := []string{instanceID}
+	owners := map[string]string{instanceID: "node"}
+	for _, e := range evicted {
+		if e.pod.Status.PodIP != "" && !e.pod.Spec.HostNetwork && net.ParseIP(e.pod.Status.PodIP) != nil {
+			targetIDs = append(targetIDs, e.pod.Status.PodIP)
+			owners[e.pod.Status.PodIP] = e.pod.Namespace + "/" + e.pod.Name
+		}
+	}
+	registrations, err := awsutils.FindTargetRegistrations(sess, targetIDs)
+	if err != nil {
+		fmt.Printf("⚠️  Could not check target groups: %v\n", err)
+		return 0
+	}
+	if len(registrations) == 0 {
+		fmt.Println("Neither the instance nor its pods are registered in a target group.")
+		return 0
+	}
+	tooLong := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TARGET GROUP\tTARGET\tFOR\tSTATE\tDRAIN DELAY")
+	for _, registration := range registrations {
+		delay := fmt.Sprintf("%ds", registration.DrainDelay)
+		if time.Duration(registration.DrainDelay)*time.Second > window {
+			delay += " ⚠️  longer than the window"
+			tooLong++
+		}
+		fmt.Fprintf(w, "%s\t%s:%d\t%s\t%s\t%s\n", registration.TargetGroupName, registration.TargetID, registration.Port,
+			owners[registration.TargetID], registration.State, delay)
+	}
+	w.Flush()
+	return tooLong
+}
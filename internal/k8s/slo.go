@@ -0,0 +1,303 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/config"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Burn rates of the multiwindow alerts of the Google SRE workbook, for a
+// 30 day window: 2% of the budget in an hour, 5% in six hours
+const (
+	fastBurnRate = 14.4
+	slowBurnRate = 6
+)
+
+// sloBurnWindows are the short windows the current burn rate is measured over
+var sloBurnWindows = []string{"5m", "1h", "6h", "1d"}
+
+var promDurationPattern = regexp.MustCompile(`^(\d+)([smhdw])$`)
+
+// SLOOptions contains options for the error budget calculation
+type SLOOptions struct {
+	Target        float64 // Availability objective in percent, e.g. 99.9
+	Window        string  // SLO window as a Prometheus duration, e.g. 30d
+	URL           string  // Overrides prometheus.url of the config file
+	RequestsQuery string  // Overrides prometheus.requests_query
+	ErrorsQuery   string  // Overrides prometheus.errors_query
+}
+
+// sloWindow is the error ratio of a service over one window
+type sloWindow struct {
+	window   string
+	requests float64 // Per second
+	errors   float64 // Per second
+}
+
+func (w sloWindow) ratio() float64 {
+	return safeRatio(w.errors, w.requests)
+}
+
+// ShowSLO queries Prometheus for the request and error rates of a service
+// and reports how much of the error budget of the SLO window is left, the
+// burn rate over the last 5 minutes to a day, and when the budget runs out
+// at the current rate, to help decide how urgent a rollback is during an
+// incident. A burn rate of 1 spends exactly the budget over the window.
+func ShowSLO(service string, options SLOOptions) error {
+	if options.Target <= 0 || options.Target >= 100 {
+		return fmt.Errorf("--target must be between 0 and 100, got %g", options.Target)
+	}
+	windowDuration, err := parsePromDuration(options.Window)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	prometheus, err := cfg.Prometheus()
+	if err != nil {
+		return err
+	}
+	requestsQuery, errorsQuery := prometheus.Queries()
+	if options.RequestsQuery != "" {
+		requestsQuery = options.RequestsQuery
+	}
+	if options.ErrorsQuery != "" {
+		errorsQuery = options.ErrorsQuery
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	endpoint, err := prometheusEndpoint(ctx, prometheus, options.URL)
+	if err != nil {
+		return err
+	}
+	client := &prometheusClient{endpoint: endpoint, http: &http.Client{Timeout: 30 * time.Second}}
+	if prometheus.BearerTokenEnv != "" {
+		client.token = os.Getenv(prometheus.BearerTokenEnv)
+	}
+
+	windows := append([]string{}, sloBurnWindows...)
+	if !containsString(windows, options.Window) {
+		windows = append(windows, options.Window)
+	}
+	measured := make(map[string]sloWindow)
+	for _, window := range windows {
+		expand := strings.NewReplacer("$service", service, "$window", window)
+		requests, err := client.query(ctx, expand.Replace(requestsQuery))
+		if err != nil {
+			return fmt.Errorf("failed to query requests over %s: %w", window, err)
+		}
+		errors, err := client.query(ctx, expand.Replace(errorsQuery))
+		if err != nil {
+			return fmt.Errorf("failed to query errors over %s: %w", window, err)
+		}
+		measured[window] = sloWindow{window: window, requests: requests, errors: errors}
+	}
+	slo := measured[options.Window]
+	if slo.requests == 0 {
+		return fmt.Errorf("no requests found for %s over %s; check the queries with --requests-query and --errors-query", service, options.Window)
+	}
+
+	budget := 1 - options.Target/100
+	consumed := slo.ratio() / budget
+	remaining := 1 - consumed
+	total := slo.requests * windowDuration.Seconds()
+
+	fmt.Printf("Service: %s, SLO %g%% over %s (error budget %.4g%% of requests)\n\n", service, options.Target, options.Window, budget*100)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WINDOW\tREQ/S\tERR/S\tERROR RATIO\tBURN RATE\tSTATUS")
+	for _, window := range windows {
+		m := measured[window]
+		burn := m.ratio() / budget
+		status := "✅"
+		switch {
+		case m.requests == 0:
+			status = "no traffic"
+		case burn >= fastBurnRate:
+			status = "❌ fast burn"
+		case burn >= slowBurnRate:
+			status = "⚠️  slow burn"
+		case burn > 1:
+			status = "⚠️  over budget"
+		}
+		fmt.Fprintf(w, "%s\t%.2f\t%.3f\t%.4f%%\t%.2f\t%s\n", window, m.requests, m.errors, m.ratio()*100, burn, status)
+	}
+	w.Flush()
+
+	burn5m := measured["5m"].ratio() / budget
+	burn1h := measured["1h"].ratio() / budget
+	burn6h := measured["6h"].ratio() / budget
+	fmt.Println("\n--- Error Budget Summary ---")
+	fmt.Printf("Requests over %s: %.0f, failed: %.0f, allowed: %.0f\n", options.Window, total, slo.errors*windowDuration.Seconds(), budget*total)
+	fmt.Printf("Budget remaining: %.1f%%\n", remaining*100)
+	if burn1h > 1 && remaining > 0 {
+		exhaustion := time.Duration(remaining * float64(windowDuration) / burn1h)
+		fmt.Printf("At the 1h burn rate of %.2f the budget runs out in %s\n", burn1h, exhaustion.Round(time.Minute))
+	}
+	switch {
+	case burn1h >= fastBurnRate && burn5m >= fastBurnRate:
+		fmt.Println("❌ Fast burn still ongoing: roll back or mitigate now")
+	case remaining <= 0:
+		fmt.Println("❌ Error budget exhausted: the SLO is missed for this window, freeze risky changes")
+	case burn6h >= slowBurnRate && burn1h >= slowBurnRate:
+		fmt.Println("⚠️  Sustained burn: roll back or mitigate within hours")
+	case burn1h >= fastBurnRate:
+		fmt.Println("⚠️  The last hour burned fast but the last 5 minutes recovered; watch before rolling back")
+	case burn1h > 1:
+		fmt.Println("⚠️  Burning faster than the SLO allows, but no page-worthy rate")
+	default:
+		fmt.Println("✅ Within budget, no rollback pressure from the SLO")
+	}
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// prometheusEndpoint returns the base URL to query: the --url flag,
+// prometheus.url, or a port-forward to prometheus.service that lives until
+// ctx is cancelled.
+func prometheusEndpoint(ctx context.Context, prometheus config.PrometheusConfig, override string) (string, error) {
+	if override != "" {
+		return strings.TrimSuffix(override, "/"), nil
+	}
+	if prometheus.URL != "" {
+		return strings.TrimSuffix(prometheus.URL, "/"), nil
+	}
+	if prometheus.Service == "" {
+		return "", fmt.Errorf("no Prometheus endpoint: set prometheus.url or prometheus.service in %s, or pass --url", config.Path())
+	}
+	ref, portText, _ := strings.Cut(prometheus.Service, ":")
+	namespace, name, ok := strings.Cut(ref, "/")
+	if !ok {
+		return "", fmt.Errorf("prometheus.service must be namespace/name:port, got %q", prometheus.Service)
+	}
+	var port int32
+	if portText != "" {
+		parsed, err := strconv.ParseInt(portText, 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("invalid port in prometheus.service %q", prometheus.Service)
+		}
+		port = int32(parsed)
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return "", fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	service, err := clientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get service %s/%s: %w", namespace, name, err)
+	}
+	servicePort, err := loadTestServicePort(service, port)
+	if err != nil {
+		return "", err
+	}
+	address, err := forwardServicePort(ctx, clientset, service, servicePort)
+	if err != nil {
+		return "", err
+	}
+	return "http://" + address, nil
+}
+
+// prometheusClient runs instant queries against the Prometheus HTTP API
+type prometheusClient struct {
+	endpoint string
+	token    string
+	http     *http.Client
+}
+
+// query runs an instant query and returns the sum of the resulting series,
+// 0 when there are none
+func (c *prometheusClient) query(ctx context.Context, promql string) (float64, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+"/api/v1/query?query="+url.QueryEscape(promql), nil)
+	if err != nil {
+		return 0, err
+	}
+	if c.token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	response, err := c.http.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	var body struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   struct {
+			ResultType string          `json:"resultType"`
+			Result     json.RawMessage `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("unexpected response from %s (HTTP %d): %w", c.endpoint, response.StatusCode, err)
+	}
+	if body.Status != "success" {
+		return 0, fmt.Errorf("%s: %s", promql, body.Error)
+	}
+
+	var samples [][2]any
+	switch body.Data.ResultType {
+	case "vector":
+		var vector []struct {
+			Value [2]any `json:"value"`
+		}
+		if err := json.Unmarshal(body.Data.Result, &vector); err != nil {
+			return 0, err
+		}
+		for _, series := range vector {
+			samples = append(samples, series.Value)
+		}
+	case "scalar":
+		var scalar [2]any
+		if err := json.Unmarshal(body.Data.Result, &scalar); err != nil {
+			return 0, err
+		}
+		samples = append(samples, scalar)
+	default:
+		return 0, fmt.Errorf("%s returned a %s, expected an instant vector", promql, body.Data.ResultType)
+	}
+
+	sum := 0.0
+	for _, sample := range samples {
+		text, _ := sample[1].(string)
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid sample value %v", sample[1])
+		}
+		// NaN comes from dividing by zero traffic and counts as none
+		if !math.IsNaN(value) {
+			sum += value
+		}
+	}
+	return sum, nil
+}
+
+// parsePromDuration parses a single-unit Prometheus duration such as 30d,
+// which time.ParseDuration doesn't accept
+func parsePromDuration(text string) (time.Duration, error) {
+	match := promDurationPattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0, fmt.Errorf("invalid window %q, use a number and one of s, m, h, d or w, e.g. 30d", text)
+	}
+	count, _ := strconv.Atoi(match[1])
+	if count == 0 {
+		return 0, fmt.Errorf("window %q is empty", text)
+	}
+	units := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	return time.Duration(count) * units[match[2]], nil
+}
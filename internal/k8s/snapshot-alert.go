@@ -0,0 +1,245 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// SnapshotAlertOptions contains options for capturing snapshots when the
+// cluster starts degrading. A threshold of 0 turns its trigger off.
+type SnapshotAlertOptions struct {
+	Format        string
+	OutputDir     string
+	Retain        int    // Number of local snapshots to keep, 0 keeps all
+	S3Bucket      string // Optional bucket to upload every snapshot to
+	S3Prefix      string
+	Profile       string        // AWS profile for the S3 upload
+	Region        string        // Region hint for finding the bucket
+	Metrics       bool          // Include node and pod usage from the metrics API
+	Certificates  bool          // Include the expiry of every TLS secret
+	CheckEvery    time.Duration // How often the triggers are evaluated
+	Window        time.Duration // Period the crash loop and event triggers count over
+	NotReady      int           // NotReady nodes that trigger a snapshot
+	CrashLoops    int           // Pods entering CrashLoopBackOff within the window that trigger a snapshot
+	WarningEvents int           // Warning events within the window that trigger a snapshot
+	Cooldown      time.Duration // Minimum time between two snapshots
+	Once          bool          // Exit after the first snapshot
+}
+
+// alertState is what the watcher remembers between checks
+type alertState struct {
+	crashLooping map[string]time.Time // Pod to when it was first seen crash looping, zero when at start
+	firing       map[string]bool      // Triggers above their threshold at the previous check
+	lastSnapshot time.Time
+}
+
+// alertTrigger is one evaluated trigger condition
+type alertTrigger struct {
+	name      string
+	value     int
+	threshold int
+	detail    string
+}
+
+func (t alertTrigger) crossed() bool {
+	return t.threshold > 0 && t.value >= t.threshold
+}
+
+// RunSnapshotOnAlert watches the cluster and captures a snapshot when a
+// trigger crosses its threshold: NotReady nodes, pods entering
+// CrashLoopBackOff within the window, or Warning events within the window,
+// which surge when the API server, webhooks or controllers start failing.
+// A trigger fires when it crosses its threshold, not on every check while it
+// stays above it, so a lasting outage doesn't fill the disk; snapshots are
+// also at least Cooldown apart.
+func RunSnapshotOnAlert(options SnapshotAlertOptions) error {
+	if options.CheckEvery <= 0 || options.Window <= 0 {
+		return fmt.Errorf("check interval and window must be greater than zero")
+	}
+	if options.NotReady <= 0 && options.CrashLoops <= 0 && options.WarningEvents <= 0 {
+		return fmt.Errorf("every trigger is off; set --not-ready, --crashloops or --warning-events")
+	}
+	if options.Retain < 0 {
+		return fmt.Errorf("retain count cannot be negative")
+	}
+	if options.OutputDir == "" {
+		options.OutputDir = "."
+	}
+	if err := os.MkdirAll(options.OutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", options.OutputDir, err)
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	var watching []string
+	if options.NotReady > 0 {
+		watching = append(watching, fmt.Sprintf("%d NotReady nodes", options.NotReady))
+	}
+	if options.CrashLoops > 0 {
+		watching = append(watching, fmt.Sprintf("%d new crash loops in %s", options.CrashLoops, options.Window))
+	}
+	if options.WarningEvents > 0 {
+		watching = append(watching, fmt.Sprintf("%d warning events in %s", options.WarningEvents, options.Window))
+	}
+	fmt.Printf("Watching for %s (every %s, output: %s)...\n", strings.Join(watching, ", "), options.CheckEvery, options.OutputDir)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	ticker := time.NewTicker(options.CheckEvery)
+	defer ticker.Stop()
+
+	state := &alertState{firing: make(map[string]bool)}
+	snapshotOptions := SnapshotDaemonOptions{
+		Format:       options.Format,
+		OutputDir:    options.OutputDir,
+		Retain:       options.Retain,
+		S3Bucket:     options.S3Bucket,
+		S3Prefix:     options.S3Prefix,
+		Profile:      options.Profile,
+		Region:       options.Region,
+		Metrics:      options.Metrics,
+		Certificates: options.Certificates,
+	}
+	for {
+		triggers, err := evaluateAlertTriggers(clientset, state, options)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to evaluate triggers: %v\n", err)
+		}
+		var fired []string
+		for _, trigger := range triggers {
+			if trigger.crossed() && !state.firing[trigger.name] {
+				fired = append(fired, fmt.Sprintf("%s %d >= %d%s", trigger.name, trigger.value, trigger.threshold, trigger.detail))
+			}
+			if err == nil {
+				state.firing[trigger.name] = trigger.crossed()
+			}
+		}
+		if len(fired) > 0 {
+			now := time.Now()
+			if now.Sub(state.lastSnapshot) < options.Cooldown {
+				fmt.Printf("[%s] ⚠️  Triggered but in cooldown until %s: %s\n", now.Format("15:04:05"),
+					state.lastSnapshot.Add(options.Cooldown).Format("15:04:05"), strings.Join(fired, "; "))
+			} else {
+				fmt.Printf("[%s] ❌ Triggered: %s\n", now.Format("15:04:05"), strings.Join(fired, "; "))
+				runSnapshotCycle(snapshotOptions)
+				state.lastSnapshot = now
+				if options.Once {
+					return nil
+				}
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			fmt.Println("\nSnapshot watcher stopped.")
+			return nil
+		}
+	}
+}
+
+// evaluateAlertTriggers reads the current value of every enabled trigger.
+func evaluateAlertTriggers(clientset *kubernetes.Clientset, state *alertState, options SnapshotAlertOptions) ([]alertTrigger, error) {
+	ctx := context.TODO()
+	now := time.Now()
+	var triggers []alertTrigger
+
+	if options.NotReady > 0 {
+		nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return triggers, fmt.Errorf("failed to list nodes: %w", err)
+		}
+		var notReady []string
+		for _, node := range nodes.Items {
+			// Nodes still joining are NotReady for a while, which is no alert
+			if getNodeReadyStatus(node) != "True" && now.Sub(node.CreationTimestamp.Time) > options.Window {
+				notReady = append(notReady, node.Name)
+			}
+		}
+		triggers = append(triggers, alertTrigger{"NotReady nodes", len(notReady), options.NotReady, " (" + truncateList(notReady, 5) + ")"})
+	}
+
+	if options.CrashLoops > 0 {
+		pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return triggers, fmt.Errorf("failed to list pods: %w", err)
+		}
+		// A crash looping container is briefly Running between back-offs, so
+		// pods are remembered until they are deleted
+		first := state.crashLooping == nil
+		current := make(map[string]time.Time)
+		var recent []string
+		for _, pod := range pods.Items {
+			key := pod.Namespace + "/" + pod.Name
+			seen, known := state.crashLooping[key]
+			if !known && !podCrashLooping(pod) {
+				continue
+			}
+			switch {
+			case known:
+			case first:
+				seen = time.Time{} // Already crash looping when the watch started
+			default:
+				seen = now
+			}
+			current[key] = seen
+			if !seen.IsZero() && now.Sub(seen) <= options.Window {
+				recent = append(recent, key)
+			}
+		}
+		state.crashLooping = current
+		triggers = append(triggers, alertTrigger{"new crash loops", len(recent), options.CrashLoops, " (" + truncateList(recent, 5) + ")"})
+	}
+
+	if options.WarningEvents > 0 {
+		events, err := clientset.CoreV1().Events("").List(ctx, metav1.ListOptions{FieldSelector: "type=Warning"})
+		if err != nil {
+			return triggers, fmt.Errorf("failed to list events: %w", err)
+		}
+		count := 0
+		reasons := make(map[string]int)
+		for _, event := range events.Items {
+			if _, last, _ := eventTimes(event); now.Sub(last) <= options.Window {
+				count++
+				reasons[event.Reason]++
+			}
+		}
+		top := ""
+		for _, reason := range sortedKeys(reasons) {
+			if top == "" || reasons[reason] > reasons[top] {
+				top = reason
+			}
+		}
+		detail := ""
+		if top != "" {
+			detail = fmt.Sprintf(" (most: %s x%d)", top, reasons[top])
+		}
+		triggers = append(triggers, alertTrigger{"warning events", count, options.WarningEvents, detail})
+	}
+	return triggers, nil
+}
+
+// podCrashLooping reports whether any container of the pod is waiting in
+// CrashLoopBackOff.
+func podCrashLooping(pod corev1.Pod) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting != nil && status.State.Waiting.Reason == "CrashLoopBackOff" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package k8s
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+)
+
+// SnapshotDaemonOptions contains options for the periodic snapshot daemon
+type SnapshotDaemonOptions struct {
+	Format       string
+	OutputDir    string
+	Interval     time.Duration
+	Retain       int    // Number of local snapshots to keep, 0 keeps all
+	S3Bucket     string // Optional bucket to upload every snapshot to
+	S3Prefix     string
+	Profile      string // AWS profile for the S3 upload
+	Region       string // Region hint for finding the bucket
+	Metrics      bool   // Include node and pod usage from the metrics API
+	Certificates bool   // Include the expiry of every TLS secret
+}
+
+// RunSnapshotDaemon captures a cluster snapshot every options.Interval until
+// interrupted, pruning old local snapshots and optionally uploading each one to S3.
+func RunSnapshotDaemon(options SnapshotDaemonOptions) error {
+	if options.Interval <= 0 {
+		return fmt.Errorf("snapshot interval must be greater than zero")
+	}
+	if options.Retain < 0 {
+		return fmt.Errorf("retain count cannot be negative")
+	}
+	if options.OutputDir == "" {
+		options.OutputDir = "."
+	}
+	if err := os.MkdirAll(options.OutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", options.OutputDir, err)
+	}
+
+	fmt.Printf("Starting snapshot daemon (every %s, retain %d, output: %s)...\n",
+		options.Interval, options.Retain, options.OutputDir)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	ticker := time.NewTicker(options.Interval)
+	defer ticker.Stop()
+
+	for {
+		runSnapshotCycle(options)
+		fmt.Printf("Next snapshot at %s\n", time.Now().Add(options.Interval).Format("2006-01-02 15:04:05 MST"))
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			fmt.Println("\nSnapshot daemon stopped.")
+			return nil
+		}
+	}
+}
+
+// runSnapshotCycle takes a single snapshot, uploads it and prunes old files.
+// Failures are reported but never stop the daemon.
+func runSnapshotCycle(options SnapshotDaemonOptions) {
+	path, err := CaptureClusterSnapshot(options.Format, options.OutputDir, options.Metrics, options.Certificates)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error capturing cluster snapshot: %v\n", err)
+		return
+	}
+
+	if options.S3Bucket != "" {
+		location, err := awsutils.UploadFileToS3(options.Profile, options.Region, options.S3Bucket, options.S3Prefix, path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else {
+			fmt.Printf("Uploaded snapshot to %s\n", location)
+		}
+	}
+
+	if options.Retain > 0 {
+		if err := pruneSnapshots(path, options.Retain); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to prune old snapshots: %v\n", err)
+		}
+	}
+}
+
+// pruneSnapshots removes the oldest snapshots sharing the cluster prefix and
+// extension of latestPath so that at most retain files remain.
+func pruneSnapshots(latestPath string, retain int) error {
+	dir := filepath.Dir(latestPath)
+	base := filepath.Base(latestPath)
+	ext := filepath.Ext(base)
+
+	// Filenames look like <cluster>-snapshot-<timestamp><ext>, so the timestamp
+	// sorts lexically and everything up to it identifies the cluster.
+	idx := len(base) - len(ext) - len("20060102-150405")
+	if idx <= 0 {
+		return fmt.Errorf("unexpected snapshot filename: %s", base)
+	}
+	pattern := filepath.Join(dir, base[:idx]+"*"+ext)
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	if len(matches) <= retain {
+		return nil
+	}
+
+	sort.Strings(matches)
+	for _, old := range matches[:len(matches)-retain] {
+		if err := os.Remove(old); err != nil {
+			return err
+		}
+		fmt.Printf("Pruned old snapshot: %s\n", old)
+	}
+	return nil
+}
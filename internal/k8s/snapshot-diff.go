@@ -0,0 +1,471 @@
+package k8s
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"sigs.k8s.io/yaml"
+)
+
+// SnapshotDiffOptions contains options for comparing two snapshots
+type SnapshotDiffOptions struct {
+	CrossCluster bool     // Normalize cluster specific values even when both snapshots are of the same cluster
+	Mappings     []string // FROM=TO replacements applied to the first snapshot, e.g. staging=prod
+	Ignore       []string // Field paths left out of the comparison, e.g. spec.replicas
+}
+
+// snapshotDiffSections are the dump sections compared: configuration, not
+// runtime state such as pods and nodes
+var snapshotDiffSections = []struct{ section, kind string }{
+	{"deployments", "Deployment"},
+	{"statefulsets", "StatefulSet"},
+	{"daemonsets", "DaemonSet"},
+	{"services", "Service"},
+	{"pvcs", "PersistentVolumeClaim"},
+	{"storageclasses", "StorageClass"},
+	{"eni_configs", "ENIConfig"},
+}
+
+// snapshotVolatileAnnotations change without anyone changing the
+// configuration
+var snapshotVolatileAnnotations = []string{
+	"deployment.kubernetes.io/revision",
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"kubectl.kubernetes.io/restartedAt",
+	"pv.kubernetes.io/bind-completed",
+	"pv.kubernetes.io/bound-by-controller",
+	"volume.kubernetes.io/selected-node",
+	"volume.kubernetes.io/storage-provisioner",
+	"volume.beta.kubernetes.io/storage-provisioner",
+}
+
+// Cluster specific values replaced by placeholders when comparing
+// snapshots of different clusters
+var snapshotClusterPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uid>"},
+	{regexp.MustCompile(`\bip-\d+-\d+-\d+-\d+(\.[a-z0-9-]+)*\.(compute|ec2)\.internal\b`), "<node>"},
+	{regexp.MustCompile(`\b(i|subnet|sg|vpc|vol|eni)-[0-9a-f]{8}([0-9a-f]{9})?\b`), "<$1>"},
+	{regexp.MustCompile(`(arn:aws[\w-]*:[\w-]*:[\w-]*:)\d{12}:`), "${1}<account>:"},
+	{regexp.MustCompile(`\b\d{12}(\.dkr\.ecr\.)`), "<account>$1"},
+}
+
+// loadedSnapshot is a snapshot file with its objects normalized for
+// comparison, keyed by "Kind namespace/name"
+type loadedSnapshot struct {
+	path    string
+	cluster string
+	taken   string
+	objects map[string]interface{}
+}
+
+// DiffSnapshots compares the configuration in two YAML snapshots taken by
+// getsnapshot: workloads, services, PVCs, storage classes, ENIConfigs and
+// Helm releases. Fields the API server or controllers set are left out, and
+// when the snapshots come from different clusters, or with CrossCluster,
+// UIDs, IPs, node and instance names, AWS resource and account IDs and the
+// cluster name are replaced with placeholders, so staging and production
+// can be compared for unintended divergence.
+func DiffSnapshots(pathA, pathB string, options SnapshotDiffOptions) error {
+	rawA, err := readSnapshotFile(pathA)
+	if err != nil {
+		return err
+	}
+	rawB, err := readSnapshotFile(pathB)
+	if err != nil {
+		return err
+	}
+	clusterA, clusterB := snapshotCluster(pathA, rawA), snapshotCluster(pathB, rawB)
+	crossCluster := options.CrossCluster || (clusterA != "" && clusterB != "" && clusterA != clusterB)
+
+	var replacements []string
+	for _, mapping := range options.Mappings {
+		from, to, ok := strings.Cut(mapping, "=")
+		if !ok || from == "" {
+			return fmt.Errorf("invalid mapping %q, expected FROM=TO", mapping)
+		}
+		replacements = append(replacements, from, to)
+	}
+	if crossCluster && clusterA != "" && clusterB != "" && clusterA != clusterB {
+		replacements = append(replacements, clusterA, clusterB)
+	}
+	snapshotA := loadSnapshotObjects(pathA, rawA, crossCluster, strings.NewReplacer(replacements...))
+	snapshotB := loadSnapshotObjects(pathB, rawB, crossCluster, strings.NewReplacer())
+	snapshotA.cluster, snapshotB.cluster = clusterA, clusterB
+
+	fmt.Println("Comparing:")
+	for _, label := range []struct {
+		name     string
+		snapshot loadedSnapshot
+	}{{"A", snapshotA}, {"B", snapshotB}} {
+		fmt.Printf("  %s: %s (cluster %s, taken %s)\n", label.name, filepath.Base(label.snapshot.path),
+			valueOrDash(label.snapshot.cluster), valueOrDash(label.snapshot.taken))
+	}
+	if crossCluster {
+		fmt.Println("Cross-cluster: UIDs, IPs, node and instance names, AWS IDs, account IDs and cluster assigned fields are normalized")
+	}
+	if len(replacements) > 0 {
+		var pairs []string
+		for i := 0; i < len(replacements); i += 2 {
+			pairs = append(pairs, replacements[i]+" → "+replacements[i+1])
+		}
+		fmt.Printf("Mapped in A: %s\n", strings.Join(pairs, ", "))
+	}
+
+	keys := make(map[string]bool)
+	for key := range snapshotA.objects {
+		keys[key] = true
+	}
+	for key := range snapshotB.objects {
+		keys[key] = true
+	}
+	type kindCounts struct{ same, changed, onlyA, onlyB int }
+	counts := make(map[string]*kindCounts)
+	var onlyA, onlyB []string
+	changes := make(map[string][]string)
+	for _, key := range sortedKeys(keys) {
+		kind := strings.SplitN(key, " ", 2)[0]
+		if counts[kind] == nil {
+			counts[kind] = &kindCounts{}
+		}
+		objectA, inA := snapshotA.objects[key]
+		objectB, inB := snapshotB.objects[key]
+		switch {
+		case !inB:
+			counts[kind].onlyA++
+			onlyA = append(onlyA, key)
+		case !inA:
+			counts[kind].onlyB++
+			onlyB = append(onlyB, key)
+		default:
+			var objectChanges []string
+			diffSnapshotValues("", objectA, objectB, options.Ignore, &objectChanges)
+			if len(objectChanges) == 0 {
+				counts[kind].same++
+			} else {
+				counts[kind].changed++
+				changes[key] = objectChanges
+			}
+		}
+	}
+
+	printKeys := func(title string, keys []string) {
+		if len(keys) == 0 {
+			return
+		}
+		fmt.Printf("\n%s (%d):\n", title, len(keys))
+		for _, key := range keys {
+			fmt.Printf("  %s\n", key)
+		}
+	}
+	printKeys("Only in A", onlyA)
+	printKeys("Only in B", onlyB)
+	if len(changes) > 0 {
+		fmt.Printf("\nChanged (%d):\n", len(changes))
+		for _, key := range sortedKeys(changes) {
+			fmt.Printf("  %s\n", key)
+			for _, change := range changes[key] {
+				fmt.Printf("    %s\n", change)
+			}
+		}
+	}
+
+	fmt.Println("\n--- Snapshot Diff Summary ---")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tSAME\tCHANGED\tONLY IN A\tONLY IN B")
+	for _, kind := range sortedKeys(counts) {
+		c := counts[kind]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", kind, c.same, c.changed, c.onlyA, c.onlyB)
+	}
+	w.Flush()
+	if len(onlyA)+len(onlyB)+len(changes) == 0 {
+		fmt.Println("✅ No differences")
+	} else {
+		fmt.Printf("⚠️  %d changed, %d only in A, %d only in B\n", len(changes), len(onlyA), len(onlyB))
+	}
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+func readSnapshotFile(path string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse %s, only YAML snapshots can be compared: %w", path, err)
+	}
+	if _, ok := raw["dump"].(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("%s has no dump section, only YAML snapshots can be compared", path)
+	}
+	return raw, nil
+}
+
+// snapshotCluster returns the cluster a snapshot was taken of, from the
+// snapshot itself or, for snapshots taken before it was recorded, from the
+// <cluster>-snapshot-<timestamp> file name.
+func snapshotCluster(path string, raw map[string]interface{}) string {
+	if cluster, ok := raw["cluster"].(string); ok && cluster != "" && cluster != "unknown" {
+		return cluster
+	}
+	if cluster, _, ok := strings.Cut(filepath.Base(path), "-snapshot-"); ok && cluster != "unknown" {
+		return cluster
+	}
+	return ""
+}
+
+// loadSnapshotObjects collects the compared objects of a snapshot and
+// normalizes them.
+func loadSnapshotObjects(path string, raw map[string]interface{}, crossCluster bool, replacer *strings.Replacer) loadedSnapshot {
+	snapshot := loadedSnapshot{path: path, objects: make(map[string]interface{})}
+	if taken, ok := raw["timestamp"].(string); ok {
+		snapshot.taken = taken
+	}
+	normalize := func(value interface{}) interface{} {
+		return normalizeSnapshotValue(value, crossCluster, replacer)
+	}
+
+	dump := raw["dump"].(map[string]interface{})
+	for _, section := range snapshotDiffSections {
+		items, _ := dump[section.section].([]interface{})
+		for _, item := range items {
+			object, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			stripSnapshotFields(section.kind, object, crossCluster)
+			metadata, _ := object["metadata"].(map[string]interface{})
+			name, _ := metadata["name"].(string)
+			namespace, _ := metadata["namespace"].(string)
+			key := section.kind + " " + replacer.Replace(name)
+			if namespace != "" {
+				key = section.kind + " " + replacer.Replace(namespace) + "/" + replacer.Replace(name)
+			}
+			snapshot.objects[key] = normalize(object)
+		}
+	}
+
+	summary, _ := raw["summary"].(map[string]interface{})
+	releases, _ := summary["helm_releases"].([]interface{})
+	for _, item := range releases {
+		release, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := release["name"].(string)
+		namespace, _ := release["namespace"].(string)
+		key := "HelmRelease " + replacer.Replace(namespace) + "/" + replacer.Replace(name)
+		snapshot.objects[key] = normalize(map[string]interface{}{"chart": release["chart"], "version": release["version"]})
+	}
+	return snapshot
+}
+
+// stripSnapshotFields removes what the API server and controllers set, and
+// across clusters what each cluster assigns on its own.
+func stripSnapshotFields(kind string, object map[string]interface{}, crossCluster bool) {
+	delete(object, "status")
+	if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+		for _, field := range []string{"uid", "resourceVersion", "creationTimestamp", "generation", "managedFields", "selfLink", "ownerReferences"} {
+			delete(metadata, field)
+		}
+		stripAnnotations(metadata)
+	}
+	if template, ok := nestedMap(object, "spec", "template", "metadata"); ok {
+		delete(template, "creationTimestamp")
+		stripAnnotations(template)
+	}
+	if !crossCluster {
+		return
+	}
+	spec, _ := object["spec"].(map[string]interface{})
+	switch kind {
+	case "Service":
+		for _, field := range []string{"clusterIP", "clusterIPs", "healthCheckNodePort"} {
+			delete(spec, field)
+		}
+		ports, _ := spec["ports"].([]interface{})
+		for _, port := range ports {
+			if port, ok := port.(map[string]interface{}); ok {
+				delete(port, "nodePort")
+			}
+		}
+	case "PersistentVolumeClaim":
+		delete(spec, "volumeName")
+	}
+}
+
+func stripAnnotations(metadata map[string]interface{}) {
+	annotations, ok := metadata["annotations"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, annotation := range snapshotVolatileAnnotations {
+		delete(annotations, annotation)
+	}
+	if len(annotations) == 0 {
+		delete(metadata, "annotations")
+	}
+}
+
+func nestedMap(object map[string]interface{}, fields ...string) (map[string]interface{}, bool) {
+	current := object
+	for _, field := range fields {
+		next, ok := current[field].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+	return current, true
+}
+
+// normalizeSnapshotValue applies the mappings to every string and map key,
+// and across clusters replaces cluster specific values with placeholders.
+func normalizeSnapshotValue(value interface{}, crossCluster bool, replacer *strings.Replacer) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			normalized[replacer.Replace(key)] = normalizeSnapshotValue(item, crossCluster, replacer)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, item := range v {
+			normalized[i] = normalizeSnapshotValue(item, crossCluster, replacer)
+		}
+		return normalized
+	case string:
+		s := replacer.Replace(v)
+		if !crossCluster {
+			return s
+		}
+		if net.ParseIP(s) != nil {
+			return "<ip>"
+		}
+		if _, _, err := net.ParseCIDR(s); err == nil {
+			return "<cidr>"
+		}
+		for _, p := range snapshotClusterPatterns {
+			s = p.pattern.ReplaceAllString(s, p.replacement)
+		}
+		return s
+	}
+	return value
+}
+
+// diffSnapshotValues appends a "path: a → b" line for every difference.
+// List items with a name, such as containers, ports and env vars, are
+// matched by name rather than position.
+func diffSnapshotValues(path string, a, b interface{}, ignore []string, changes *[]string) {
+	if snapshotPathIgnored(path, ignore) || reflect.DeepEqual(a, b) {
+		return
+	}
+	mapA, okA := a.(map[string]interface{})
+	mapB, okB := b.(map[string]interface{})
+	if okA && okB {
+		keys := make(map[string]bool)
+		for key := range mapA {
+			keys[key] = true
+		}
+		for key := range mapB {
+			keys[key] = true
+		}
+		for _, key := range sortedKeys(keys) {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			diffSnapshotValues(child, mapA[key], mapB[key], ignore, changes)
+		}
+		return
+	}
+	listA, okA := a.([]interface{})
+	listB, okB := b.([]interface{})
+	if okA && okB {
+		namedA, namedB := namedItems(listA), namedItems(listB)
+		if namedA != nil && namedB != nil {
+			names := make(map[string]bool)
+			for name := range namedA {
+				names[name] = true
+			}
+			for name := range namedB {
+				names[name] = true
+			}
+			for _, name := range sortedKeys(names) {
+				diffSnapshotValues(fmt.Sprintf("%s[%s]", path, name), namedA[name], namedB[name], ignore, changes)
+			}
+			return
+		}
+		if len(listA) == len(listB) {
+			for i := range listA {
+				diffSnapshotValues(fmt.Sprintf("%s[%d]", path, i), listA[i], listB[i], ignore, changes)
+			}
+			return
+		}
+	}
+	*changes = append(*changes, fmt.Sprintf("%s: %s → %s", valueOrDash(path), formatSnapshotValue(a), formatSnapshotValue(b)))
+}
+
+// namedItems indexes a list by the name field of its items, or returns nil
+// when some item has none.
+func namedItems(list []interface{}) map[string]interface{} {
+	items := make(map[string]interface{})
+	for _, item := range list {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		name, ok := object["name"].(string)
+		if !ok || name == "" {
+			return nil
+		}
+		items[name] = item
+	}
+	return items
+}
+
+var snapshotListSelector = regexp.MustCompile(`\[[^\]]*\]`)
+
+// snapshotPathIgnored matches a path against the --ignore paths, with and
+// without the list item selectors, so spec.template.spec.containers.image
+// ignores the image of every container.
+func snapshotPathIgnored(path string, ignore []string) bool {
+	plain := snapshotListSelector.ReplaceAllString(path, "")
+	for _, pattern := range ignore {
+		for _, candidate := range []string{path, plain} {
+			if candidate == pattern || strings.HasPrefix(candidate, pattern+".") || strings.HasPrefix(candidate, pattern+"[") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func formatSnapshotValue(value interface{}) string {
+	if value == nil {
+		return "<none>"
+	}
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false) // Keep the <ip> style placeholders readable
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	content := strings.TrimSpace(buffer.String())
+	if len(content) > 80 {
+		return content[:77] + "..."
+	}
+	return content
+}
@@ -0,0 +1,179 @@
+package k8s
+
+import (
+	"bytes"
+	"html/template"
+	"net"
+)
+
+// certExpiryWarningDays matches the warning threshold used by check-cert.
+const certExpiryWarningDays = 30
+
+var snapshotHTMLTemplate = template.Must(template.New("snapshot").Funcs(template.FuncMap{
+	"subnetUsage": subnetUsagePercent,
+	"certClass": func(days int) string {
+		if days < 0 {
+			return "bad"
+		} else if days <= certExpiryWarningDays {
+			return "warn"
+		}
+		return "ok"
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Cluster Snapshot - {{.ClusterName}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0; }
+h2 { border-bottom: 2px solid #ddd; padding-bottom: 4px; margin-top: 2em; }
+.meta { color: #666; margin-top: 4px; }
+table { border-collapse: collapse; width: 100%; margin-top: 8px; }
+th, td { border: 1px solid #ddd; padding: 6px 10px; text-align: left; font-size: 14px; }
+th { background: #f4f4f4; }
+.ok { color: #1a7f37; }
+.warn { color: #9a6700; font-weight: bold; }
+.bad { color: #cf222e; font-weight: bold; }
+.alert { border-left: 4px solid #cf222e; background: #fff5f5; padding: 8px 12px; margin: 6px 0; }
+.alert.warn { border-color: #d4a72c; background: #fffbea; color: #222; font-weight: normal; }
+.bar { background: #eee; width: 200px; height: 14px; display: inline-block; vertical-align: middle; }
+.bar span { display: block; height: 100%; background: #2da44e; }
+.bar span.high { background: #cf222e; }
+</style>
+</head>
+<body>
+<h1>Cluster Snapshot: {{.ClusterName}}</h1>
+<div class="meta">Captured {{.Snapshot.Timestamp.Format "2006-01-02 15:04:05 MST"}}</div>
+{{with .Snapshot.Summary}}
+{{range .Certificates}}{{$class := certClass .DaysUntilExpiry}}{{if eq $class "bad"}}<div class="alert">Certificate {{.Namespace}}/{{.SecretName}} EXPIRED {{.NotAfter.Format "2006-01-02"}}</div>
+{{else if eq $class "warn"}}<div class="alert warn">Certificate {{.Namespace}}/{{.SecretName}} expires in {{.DaysUntilExpiry}} days</div>
+{{end}}{{end}}
+<h2>Nodes ({{len .Nodes}})</h2>
+<table>
+<tr><th>Name</th><th>Ready</th></tr>
+{{range .Nodes}}<tr><td>{{.Name}}</td><td class="{{if .Ready}}ok{{else}}bad{{end}}">{{.Status}}</td></tr>
+{{end}}</table>
+
+<h2>Deployments ({{len .Deployments}})</h2>
+<table>
+<tr><th>Namespace</th><th>Name</th><th>Replicas (ready/desired)</th></tr>
+{{range .Deployments}}<tr><td>{{.Namespace}}</td><td>{{.Name}}</td><td>{{.Replicas}}</td></tr>
+{{end}}</table>
+{{if .NonRunningPods}}
+<h2>Non-Running Pods ({{len .NonRunningPods}})</h2>
+<table>
+<tr><th>Namespace</th><th>Name</th><th>Phase</th><th>Node</th></tr>
+{{range .NonRunningPods}}<tr><td>{{.Namespace}}</td><td>{{.Name}}</td><td class="warn">{{.Phase}}</td><td>{{.Node}}</td></tr>
+{{end}}</table>
+{{end}}{{if .HelmReleases}}
+<h2>Helm Releases ({{len .HelmReleases}})</h2>
+<table>
+<tr><th>Namespace</th><th>Name</th><th>Version</th><th>Status</th></tr>
+{{range .HelmReleases}}<tr><td>{{.Namespace}}</td><td>{{.Name}}</td><td>{{.Version}}</td><td>{{.Status}}</td></tr>
+{{end}}</table>
+{{end}}{{if .Certificates}}
+<h2>TLS Certificates ({{len .Certificates}})</h2>
+<table>
+<tr><th>Namespace</th><th>Secret</th><th>Common Name</th><th>Expires</th><th>Days Left</th></tr>
+{{range .Certificates}}<tr><td>{{.Namespace}}</td><td>{{.SecretName}}</td><td>{{.Subject}}</td><td>{{.NotAfter.Format "2006-01-02"}}</td><td class="{{certClass .DaysUntilExpiry}}">{{.DaysUntilExpiry}}</td></tr>
+{{end}}</table>
+{{end}}
+<h2>Persistent Volumes ({{len .PVs}})</h2>
+<table>
+<tr><th>Name</th><th>Size</th><th>Status</th></tr>
+{{range .PVs}}<tr><td>{{.Name}}</td><td>{{.Size}}</td><td>{{.Status}}</td></tr>
+{{end}}</table>
+
+<h2>Persistent Volume Claims ({{len .PVCs}})</h2>
+<table>
+<tr><th>Namespace</th><th>Name</th><th>Size</th><th>Status</th></tr>
+{{range .PVCs}}<tr><td>{{.Namespace}}</td><td>{{.Name}}</td><td>{{.Size}}</td><td>{{.Status}}</td></tr>
+{{end}}</table>
+
+<h2>Storage Classes ({{len .StorageClasses}})</h2>
+<table>
+<tr><th>Name</th><th>Provisioner</th></tr>
+{{range .StorageClasses}}<tr><td>{{.Name}}</td><td>{{.Provisioner}}</td></tr>
+{{end}}</table>
+{{if .ENIConfigs}}
+<h2>ENI Configs ({{len .ENIConfigs}})</h2>
+<table>
+<tr><th>Name</th><th>Subnet</th><th>AZ</th><th>Available IPs</th></tr>
+{{range .ENIConfigs}}<tr><td>{{.Name}}</td><td>{{.SubnetID}}</td><td>{{.AvailabilityZone}}</td><td>{{.AvailableIPs}}</td></tr>
+{{end}}</table>
+{{end}}{{if .SubnetInfo}}
+<h2>Subnet Utilization ({{len .SubnetInfo}})</h2>
+<table>
+<tr><th>Subnet</th><th>Type</th><th>CIDR</th><th>Available IPs</th><th>Utilization</th></tr>
+{{range .SubnetInfo}}{{$pct := subnetUsage .CIDR .AvailableIPs}}<tr><td>{{.SubnetID}}</td><td>{{.Type}}</td><td>{{.CIDR}}</td><td>{{.AvailableIPs}}</td><td><div class="bar"><span{{if ge $pct 80}} class="high"{{end}} style="width: {{$pct}}%"></span></div> {{$pct}}%</td></tr>
+{{end}}</table>
+{{end}}{{with .Metrics}}
+<h2>Node Usage (at {{.CollectedAt.Format "15:04:05 MST"}})</h2>
+<table>
+<tr><th>Node</th><th>CPU</th><th>CPU % of Allocatable</th><th>Memory</th><th>Memory % of Allocatable</th></tr>
+{{range .Nodes}}<tr><td>{{.Name}}</td><td>{{.CPUMillicores}}m</td><td><div class="bar"><span{{if ge .CPUPercent 80}} class="high"{{end}} style="width: {{.CPUPercent}}%"></span></div> {{.CPUPercent}}%</td><td>{{.MemoryMiB}}Mi</td><td><div class="bar"><span{{if ge .MemoryPercent 80}} class="high"{{end}} style="width: {{.MemoryPercent}}%"></span></div> {{.MemoryPercent}}%</td></tr>
+{{end}}</table>
+
+<h2>Namespace Usage ({{len .Namespaces}})</h2>
+<table>
+<tr><th>Namespace</th><th>Pods</th><th>CPU</th><th>Memory</th></tr>
+{{range .Namespaces}}<tr><td>{{.Namespace}}</td><td>{{.Pods}}</td><td>{{.CPUMillicores}}m</td><td>{{.MemoryMiB}}Mi</td></tr>
+{{end}}</table>
+
+<h2>Top Pods by CPU</h2>
+<table>
+<tr><th>Namespace</th><th>Pod</th><th>Node</th><th>CPU</th><th>Memory</th></tr>
+{{range .TopPodsByCPU}}<tr><td>{{.Namespace}}</td><td>{{.Name}}</td><td>{{.Node}}</td><td>{{.CPUMillicores}}m</td><td>{{.MemoryMiB}}Mi</td></tr>
+{{end}}</table>
+
+<h2>Top Pods by Memory</h2>
+<table>
+<tr><th>Namespace</th><th>Pod</th><th>Node</th><th>CPU</th><th>Memory</th></tr>
+{{range .TopPodsByMemory}}<tr><td>{{.Namespace}}</td><td>{{.Name}}</td><td>{{.Node}}</td><td>{{.CPUMillicores}}m</td><td>{{.MemoryMiB}}Mi</td></tr>
+{{end}}</table>
+{{end}}{{if .NodeSubnets}}
+<h2>Node Subnets ({{len .NodeSubnets}})</h2>
+<table>
+<tr><th>Subnet</th><th>Available IPs</th><th>Nodes</th></tr>
+{{range .NodeSubnets}}<tr><td>{{.SubnetID}}</td><td>{{.AvailableIPs}}</td><td>{{.NodeCount}}</td></tr>
+{{end}}</table>
+{{end}}{{end}}
+</body>
+</html>
+`))
+
+// formatSnapshotAsHTML renders the snapshot summary as a single self-contained HTML page.
+func formatSnapshotAsHTML(snapshot ClusterSnapshot, clusterName string) ([]byte, error) {
+	var buf bytes.Buffer
+	err := snapshotHTMLTemplate.Execute(&buf, struct {
+		ClusterName string
+		Snapshot    ClusterSnapshot
+	}{clusterName, snapshot})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// subnetUsagePercent returns how much of the subnet's usable address space is
+// in use. AWS reserves 5 addresses in every subnet.
+func subnetUsagePercent(cidr string, availableIPs int) int {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return 0
+	}
+	ones, bits := network.Mask.Size()
+	if bits-ones > 30 {
+		return 0
+	}
+	usable := (1 << (bits - ones)) - 5
+	if usable <= 0 {
+		return 0
+	}
+	used := usable - availableIPs
+	if used < 0 {
+		used = 0
+	}
+	return used * 100 / usable
+}
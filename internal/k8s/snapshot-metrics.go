@@ -0,0 +1,149 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// snapshotTopPods is how many of the busiest pods the metrics summary keeps
+// per resource
+const snapshotTopPods = 10
+
+// MetricsSummary is the resource usage reported by the metrics API when the
+// snapshot was taken
+type MetricsSummary struct {
+	CollectedAt     time.Time                 `json:"collected_at" yaml:"collected_at"`
+	Nodes           []NodeMetricsSummary      `json:"nodes" yaml:"nodes"`
+	Namespaces      []NamespaceMetricsSummary `json:"namespaces" yaml:"namespaces"`
+	TopPodsByCPU    []PodMetricsSummary       `json:"top_pods_by_cpu" yaml:"top_pods_by_cpu"`
+	TopPodsByMemory []PodMetricsSummary       `json:"top_pods_by_memory" yaml:"top_pods_by_memory"`
+}
+
+type NodeMetricsSummary struct {
+	Name          string `json:"name" yaml:"name"`
+	CPUMillicores int64  `json:"cpu_millicores" yaml:"cpu_millicores"`
+	CPUPercent    int    `json:"cpu_percent" yaml:"cpu_percent"` // Of allocatable
+	MemoryMiB     int64  `json:"memory_mib" yaml:"memory_mib"`
+	MemoryPercent int    `json:"memory_percent" yaml:"memory_percent"`
+}
+
+type NamespaceMetricsSummary struct {
+	Namespace     string `json:"namespace" yaml:"namespace"`
+	Pods          int    `json:"pods" yaml:"pods"`
+	CPUMillicores int64  `json:"cpu_millicores" yaml:"cpu_millicores"`
+	MemoryMiB     int64  `json:"memory_mib" yaml:"memory_mib"`
+}
+
+type PodMetricsSummary struct {
+	Name          string `json:"name" yaml:"name"`
+	Namespace     string `json:"namespace" yaml:"namespace"`
+	Node          string `json:"node" yaml:"node"`
+	CPUMillicores int64  `json:"cpu_millicores" yaml:"cpu_millicores"`
+	MemoryMiB     int64  `json:"memory_mib" yaml:"memory_mib"`
+}
+
+// collectMetricsSummary reads node and pod usage from the metrics API. Usage
+// is summed per namespace, and the pods using the most CPU and memory are
+// kept individually.
+func collectMetricsSummary(nodes []corev1.Node, pods []corev1.Pod) (*MetricsSummary, error) {
+	metricsClient, err := common.GetMetricsClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metrics client: %w", err)
+	}
+	ctx := context.TODO()
+	nodeMetrics, err := metricsClient.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node metrics (is metrics-server installed?): %w", err)
+	}
+	podMetrics, err := metricsClient.MetricsV1beta1().PodMetricses("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod metrics: %w", err)
+	}
+
+	summary := &MetricsSummary{CollectedAt: time.Now()}
+	allocatable := make(map[string]corev1.ResourceList)
+	for _, node := range nodes {
+		allocatable[node.Name] = node.Status.Allocatable
+	}
+	for _, metrics := range nodeMetrics.Items {
+		node := NodeMetricsSummary{
+			Name:          metrics.Name,
+			CPUMillicores: metrics.Usage.Cpu().MilliValue(),
+			MemoryMiB:     metrics.Usage.Memory().Value() / (1024 * 1024),
+		}
+		if resources, ok := allocatable[metrics.Name]; ok {
+			if cpu := resources.Cpu().MilliValue(); cpu > 0 {
+				node.CPUPercent = int(node.CPUMillicores * 100 / cpu)
+			}
+			if memory := resources.Memory().Value(); memory > 0 {
+				node.MemoryPercent = int(metrics.Usage.Memory().Value() * 100 / memory)
+			}
+		}
+		summary.Nodes = append(summary.Nodes, node)
+	}
+	sort.Slice(summary.Nodes, func(i, j int) bool { return summary.Nodes[i].Name < summary.Nodes[j].Name })
+
+	podNodes := make(map[string]string)
+	for _, pod := range pods {
+		podNodes[pod.Namespace+"/"+pod.Name] = pod.Spec.NodeName
+	}
+	namespaces := make(map[string]*NamespaceMetricsSummary)
+	var podSummaries []PodMetricsSummary
+	for _, metrics := range podMetrics.Items {
+		usage := sumContainerUsage(metrics.Containers)
+		pod := PodMetricsSummary{
+			Name:          metrics.Name,
+			Namespace:     metrics.Namespace,
+			Node:          podNodes[metrics.Namespace+"/"+metrics.Name],
+			CPUMillicores: usage.Cpu().MilliValue(),
+			MemoryMiB:     usage.Memory().Value() / (1024 * 1024),
+		}
+		podSummaries = append(podSummaries, pod)
+
+		namespace, ok := namespaces[pod.Namespace]
+		if !ok {
+			namespace = &NamespaceMetricsSummary{Namespace: pod.Namespace}
+			namespaces[pod.Namespace] = namespace
+		}
+		namespace.Pods++
+		namespace.CPUMillicores += pod.CPUMillicores
+		namespace.MemoryMiB += pod.MemoryMiB
+	}
+	for _, name := range sortedKeys(namespaces) {
+		summary.Namespaces = append(summary.Namespaces, *namespaces[name])
+	}
+
+	sort.SliceStable(podSummaries, func(i, j int) bool { return podSummaries[i].CPUMillicores > podSummaries[j].CPUMillicores })
+	summary.TopPodsByCPU = append([]PodMetricsSummary{}, podSummaries[:min(snapshotTopPods, len(podSummaries))]...)
+	sort.SliceStable(podSummaries, func(i, j int) bool { return podSummaries[i].MemoryMiB > podSummaries[j].MemoryMiB })
+	summary.TopPodsByMemory = append([]PodMetricsSummary{}, podSummaries[:min(snapshotTopPods, len(podSummaries))]...)
+	return summary, nil
+}
+
+// formatMetricsAsText renders the metrics summary for the txt snapshot.
+func formatMetricsAsText(metrics *MetricsSummary) string {
+	content := fmt.Sprintf("=== RESOURCE USAGE (at %s) ===\n", metrics.CollectedAt.Format("2006-01-02 15:04:05 MST"))
+	content += "Nodes:\n"
+	for _, node := range metrics.Nodes {
+		content += fmt.Sprintf("- %s (CPU: %dm, %d%%; Memory: %dMi, %d%%)\n", node.Name, node.CPUMillicores, node.CPUPercent, node.MemoryMiB, node.MemoryPercent)
+	}
+	content += "Namespaces:\n"
+	for _, namespace := range metrics.Namespaces {
+		content += fmt.Sprintf("- %s (Pods: %d, CPU: %dm, Memory: %dMi)\n", namespace.Namespace, namespace.Pods, namespace.CPUMillicores, namespace.MemoryMiB)
+	}
+	content += "Top pods by CPU:\n"
+	for _, pod := range metrics.TopPodsByCPU {
+		content += fmt.Sprintf("- %s/%s (CPU: %dm, Memory: %dMi, Node: %s)\n", pod.Namespace, pod.Name, pod.CPUMillicores, pod.MemoryMiB, valueOrDash(pod.Node))
+	}
+	content += "Top pods by memory:\n"
+	for _, pod := range metrics.TopPodsByMemory {
+		content += fmt.Sprintf("- %s/%s (CPU: %dm, Memory: %dMi, Node: %s)\n", pod.Namespace, pod.Name, pod.CPUMillicores, pod.MemoryMiB, valueOrDash(pod.Node))
+	}
+	return content + "\n"
+}
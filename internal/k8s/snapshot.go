@@ -3,15 +3,17 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/aws"
 	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
 	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/providerid"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
@@ -19,13 +21,13 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
 )
 
 type ClusterSnapshot struct {
 	Timestamp      time.Time                `json:"timestamp" yaml:"timestamp"`
+	Cluster        string                   `json:"cluster,omitempty" yaml:"cluster,omitempty"`
 	Summary        ClusterSummary           `json:"summary" yaml:"summary"`
 	Dump           ClusterDump              `json:"dump" yaml:"dump"`
 }
@@ -41,6 +43,8 @@ type ClusterSummary struct {
 	ENIConfigs     []ENIConfigSummary       `json:"eni_configs" yaml:"eni_configs"`
 	SubnetInfo     []SubnetInfo             `json:"subnet_info" yaml:"subnet_info"`
 	NodeSubnets    []awsutils.NodeSubnetInfo `json:"node_subnets" yaml:"node_subnets"`
+	Certificates   []CertificateSummary     `json:"certificates" yaml:"certificates"`
+	Metrics        *MetricsSummary          `json:"metrics,omitempty" yaml:"metrics,omitempty"`
 }
 
 type ClusterDump struct {
@@ -57,9 +61,11 @@ type ClusterDump struct {
 }
 
 type NodeSummary struct {
-	Name   string `json:"name" yaml:"name"`
-	Ready  bool   `json:"ready" yaml:"ready"`
-	Status string `json:"status" yaml:"status"`
+	Name       string `json:"name" yaml:"name"`
+	Ready      bool   `json:"ready" yaml:"ready"`
+	Status     string `json:"status" yaml:"status"`
+	Provider   string `json:"provider,omitempty" yaml:"provider,omitempty"`
+	InstanceID string `json:"instance_id,omitempty" yaml:"instance_id,omitempty"`
 }
 
 type DeploymentSummary struct {
@@ -107,6 +113,14 @@ type SubnetInfo struct {
 	Type         string `json:"type" yaml:"type"` // "primary" or "secondary"
 }
 
+type CertificateSummary struct {
+	SecretName      string    `json:"secret_name" yaml:"secret_name"`
+	Namespace       string    `json:"namespace" yaml:"namespace"`
+	Subject         string    `json:"subject" yaml:"subject"`
+	NotAfter        time.Time `json:"not_after" yaml:"not_after"`
+	DaysUntilExpiry int       `json:"days_until_expiry" yaml:"days_until_expiry"`
+}
+
 type HelmRelease struct {
 	Name      string `json:"name" yaml:"name"`
 	Namespace string `json:"namespace" yaml:"namespace"`
@@ -115,10 +129,20 @@ type HelmRelease struct {
 	Status    string `json:"status" yaml:"status"`
 }
 
-func GetClusterSnapshot(format string) error {
+func GetClusterSnapshot(format, outputDir string, includeMetrics, includeCertificates bool) error {
+	_, err := CaptureClusterSnapshot(format, outputDir, includeMetrics, includeCertificates)
+	return err
+}
+
+// CaptureClusterSnapshot collects the cluster state, writes it to outputDir
+// and returns the path of the written file. With includeMetrics the summary
+// also gets node and pod usage from the metrics API, and with
+// includeCertificates the expiry of every TLS secret, which needs read
+// access to secrets.
+func CaptureClusterSnapshot(format, outputDir string, includeMetrics, includeCertificates bool) (string, error) {
 	clientset, err := common.GetKubernetesClient()
 	if err != nil {
-		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		return "", fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
 
 	fmt.Println("Collecting cluster snapshot...")
@@ -135,7 +159,7 @@ func GetClusterSnapshot(format string) error {
 	fmt.Print("Collecting nodes... ")
 	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to get nodes: %w", err)
+		return "", fmt.Errorf("failed to get nodes: %w", err)
 	}
 	snapshot.Dump.Nodes = nodes.Items
 	fmt.Printf("✓ (%d)\n", len(nodes.Items))
@@ -144,7 +168,7 @@ func GetClusterSnapshot(format string) error {
 	fmt.Print("Collecting services... ")
 	services, err := clientset.CoreV1().Services("").List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to get services: %w", err)
+		return "", fmt.Errorf("failed to get services: %w", err)
 	}
 	snapshot.Dump.Services = services.Items
 	fmt.Printf("✓ (%d)\n", len(services.Items))
@@ -153,7 +177,7 @@ func GetClusterSnapshot(format string) error {
 	fmt.Print("Collecting deployments... ")
 	deployments, err := clientset.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to get deployments: %w", err)
+		return "", fmt.Errorf("failed to get deployments: %w", err)
 	}
 	snapshot.Dump.Deployments = deployments.Items
 	fmt.Printf("✓ (%d)\n", len(deployments.Items))
@@ -162,7 +186,7 @@ func GetClusterSnapshot(format string) error {
 	fmt.Print("Collecting daemonsets... ")
 	daemonsets, err := clientset.AppsV1().DaemonSets("").List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to get daemonsets: %w", err)
+		return "", fmt.Errorf("failed to get daemonsets: %w", err)
 	}
 	snapshot.Dump.DaemonSets = daemonsets.Items
 	fmt.Printf("✓ (%d)\n", len(daemonsets.Items))
@@ -171,7 +195,7 @@ func GetClusterSnapshot(format string) error {
 	fmt.Print("Collecting statefulsets... ")
 	statefulsets, err := clientset.AppsV1().StatefulSets("").List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to get statefulsets: %w", err)
+		return "", fmt.Errorf("failed to get statefulsets: %w", err)
 	}
 	snapshot.Dump.StatefulSets = statefulsets.Items
 	fmt.Printf("✓ (%d)\n", len(statefulsets.Items))
@@ -180,7 +204,7 @@ func GetClusterSnapshot(format string) error {
 	fmt.Print("Collecting pods... ")
 	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to get pods: %w", err)
+		return "", fmt.Errorf("failed to get pods: %w", err)
 	}
 	snapshot.Dump.Pods = pods.Items
 	fmt.Printf("✓ (%d)\n", len(pods.Items))
@@ -189,7 +213,7 @@ func GetClusterSnapshot(format string) error {
 	fmt.Print("Collecting PVCs... ")
 	pvcs, err := clientset.CoreV1().PersistentVolumeClaims("").List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to get PVCs: %w", err)
+		return "", fmt.Errorf("failed to get PVCs: %w", err)
 	}
 	snapshot.Dump.PVCs = pvcs.Items
 	fmt.Printf("✓ (%d)\n", len(pvcs.Items))
@@ -198,7 +222,7 @@ func GetClusterSnapshot(format string) error {
 	fmt.Print("Collecting PVs... ")
 	pvs, err := clientset.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to get PVs: %w", err)
+		return "", fmt.Errorf("failed to get PVs: %w", err)
 	}
 	snapshot.Dump.PVs = pvs.Items
 	fmt.Printf("✓ (%d)\n", len(pvs.Items))
@@ -207,7 +231,7 @@ func GetClusterSnapshot(format string) error {
 	fmt.Print("Collecting storage classes... ")
 	storageClasses, err := clientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to get storage classes: %w", err)
+		return "", fmt.Errorf("failed to get storage classes: %w", err)
 	}
 	snapshot.Dump.StorageClasses = storageClasses.Items
 	fmt.Printf("✓ (%d)\n", len(storageClasses.Items))
@@ -232,16 +256,56 @@ func GetClusterSnapshot(format string) error {
 		fmt.Printf("✓ (%d)\n", len(helmReleases))
 	}
 
+	// Collect TLS certificate expiry (optional)
+	if includeCertificates {
+		fmt.Print("Collecting TLS certificates... ")
+		certificates, err := getCertificateSummaries(clientset)
+		if err != nil {
+			fmt.Printf("⚠ (skipped: %v)\n", err)
+		} else {
+			snapshot.Summary.Certificates = certificates
+			fmt.Printf("✓ (%d)\n", len(certificates))
+		}
+	}
+
+	// Collect resource usage (optional)
+	if includeMetrics {
+		fmt.Print("Collecting resource usage... ")
+		metrics, err := collectMetricsSummary(snapshot.Dump.Nodes, snapshot.Dump.Pods)
+		if err != nil {
+			fmt.Printf("⚠ (skipped: %v)\n", err)
+		} else {
+			snapshot.Summary.Metrics = metrics
+			fmt.Printf("✓ (%d nodes, %d namespaces)\n", len(metrics.Nodes), len(metrics.Namespaces))
+		}
+	}
+
 	// Build summary
 	fmt.Print("Building summary... ")
-	buildSummary(&snapshot)
+	// One scanner serves every subnet lookup, so subnets are described once.
+	// Clusters without EC2 nodes have no subnets to scan.
+	var scanner *awsutils.SubnetScanner
+	for _, node := range snapshot.Dump.Nodes {
+		if providerid.Parse(node.Spec.ProviderID).Provider == providerid.AWS {
+			scanner = awsutils.NewSubnetScanner()
+			break
+		}
+	}
+	buildSummary(&snapshot, scanner)
 	fmt.Println("✓")
 
 	// Get node subnet information
 	fmt.Print("Collecting node subnet info... ")
-	nodeSubnetInfo := awsutils.GetNodeSubnetInfo(snapshot.Dump.Nodes)
-	snapshot.Summary.NodeSubnets = nodeSubnetInfo
-	fmt.Printf("✓ (%d)\n", len(nodeSubnetInfo))
+	if scanner == nil {
+		fmt.Println("⚠ (skipped: no nodes run on AWS)")
+	} else {
+		nodeSubnetInfo, err := scanner.NodeSubnetInfo(snapshot.Dump.Nodes)
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+		snapshot.Summary.NodeSubnets = nodeSubnetInfo
+		fmt.Printf("✓ (%d)\n", len(nodeSubnetInfo))
+	}
 
 	// Get cluster name from kubeconfig context
 	clusterName, err := getClusterName()
@@ -249,6 +313,7 @@ func GetClusterSnapshot(format string) error {
 		fmt.Printf("Warning: could not get cluster name: %v, using 'unknown'\n", err)
 		clusterName = "unknown"
 	}
+	snapshot.Cluster = clusterName
 
 	// Generate filename with cluster name and timestamp
 	timestamp := time.Now().Format("20060102-150405")
@@ -260,24 +325,34 @@ func GetClusterSnapshot(format string) error {
 		filename = fmt.Sprintf("%s-snapshot-%s.yaml", clusterName, timestamp)
 		content, err = marshalSnapshotYAML(snapshot)
 		if err != nil {
-			return fmt.Errorf("failed to marshal to YAML: %w", err)
+			return "", fmt.Errorf("failed to marshal to YAML: %w", err)
 		}
 	case "txt":
 		filename = fmt.Sprintf("%s-snapshot-%s.txt", clusterName, timestamp)
 		content = []byte(formatSnapshotAsText(snapshot))
+	case "html":
+		filename = fmt.Sprintf("%s-snapshot-%s.html", clusterName, timestamp)
+		content, err = formatSnapshotAsHTML(snapshot, clusterName)
+		if err != nil {
+			return "", fmt.Errorf("failed to render HTML report: %w", err)
+		}
 	default:
-		return fmt.Errorf("unsupported format: %s (supported: yaml, txt)", format)
+		return "", fmt.Errorf("unsupported format: %s (supported: yaml, txt, html)", format)
+	}
+
+	if outputDir != "" {
+		filename = filepath.Join(outputDir, filename)
 	}
 
 	// Write to file
 	err = os.WriteFile(filename, content, 0644)
 	if err != nil {
-		return fmt.Errorf("failed to write snapshot to file: %w", err)
+		return "", fmt.Errorf("failed to write snapshot to file: %w", err)
 	}
 
 	absPath, _ := filepath.Abs(filename)
 	fmt.Printf("\n✅ Cluster snapshot saved to: %s\n", absPath)
-	return nil
+	return absPath, nil
 }
 
 func getHelmReleases(clientset *kubernetes.Clientset) ([]HelmRelease, error) {
@@ -305,13 +380,64 @@ func getHelmReleases(clientset *kubernetes.Clientset) ([]HelmRelease, error) {
 	return releases, nil
 }
 
-func buildSummary(snapshot *ClusterSnapshot) {
+// ListCertificates returns the certificates of every TLS secret in the
+// cluster, soonest expiry first.
+func ListCertificates() ([]CertificateSummary, error) {
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	certificates, err := getCertificateSummaries(clientset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list TLS secrets: %w", err)
+	}
+	return certificates, nil
+}
+
+func getCertificateSummaries(clientset *kubernetes.Clientset) ([]CertificateSummary, error) {
+	secrets, err := clientset.CoreV1().Secrets("").List(context.TODO(), metav1.ListOptions{
+		FieldSelector: "type=" + string(corev1.SecretTypeTLS),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var certificates []CertificateSummary
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		certData, _ := findCertData(secret)
+		if certData == nil {
+			continue
+		}
+		cert, err := parsePEMCertificate(certData)
+		if err != nil {
+			continue
+		}
+		certificates = append(certificates, CertificateSummary{
+			SecretName:      secret.Name,
+			Namespace:       secret.Namespace,
+			Subject:         cert.Subject.CommonName,
+			NotAfter:        cert.NotAfter,
+			DaysUntilExpiry: int(math.Floor(time.Until(cert.NotAfter).Hours() / 24)), // Negative as soon as it expired
+		})
+	}
+
+	sort.Slice(certificates, func(i, j int) bool {
+		return certificates[i].NotAfter.Before(certificates[j].NotAfter)
+	})
+	return certificates, nil
+}
+
+func buildSummary(snapshot *ClusterSnapshot, scanner *awsutils.SubnetScanner) {
 	// Build node summary
 	for _, node := range snapshot.Dump.Nodes {
+		machine := providerid.Parse(node.Spec.ProviderID)
 		summary := NodeSummary{
-			Name:   node.Name,
-			Ready:  getNodeReadyStatus(node) == "True",
-			Status: getNodeReadyStatus(node),
+			Name:       node.Name,
+			Ready:      getNodeReadyStatus(node) == "True",
+			Status:     getNodeReadyStatus(node),
+			Provider:   machine.Provider,
+			InstanceID: machine.InstanceID,
 		}
 		snapshot.Summary.Nodes = append(snapshot.Summary.Nodes, summary)
 	}
@@ -382,7 +508,10 @@ func buildSummary(snapshot *ClusterSnapshot) {
 	}
 
 	// Build ENIConfig and subnet summary
-	eniConfigSummary, subnetInfo := buildENIConfigAndSubnetSummary(snapshot.Dump.ENIConfigs, snapshot.Dump.Pods)
+	if scanner == nil {
+		return
+	}
+	eniConfigSummary, subnetInfo := buildENIConfigAndSubnetSummary(scanner, snapshot.Dump.ENIConfigs, snapshot.Dump.Pods)
 	snapshot.Summary.ENIConfigs = eniConfigSummary
 	snapshot.Summary.SubnetInfo = subnetInfo
 }
@@ -465,6 +594,22 @@ func formatSnapshotAsText(snapshot ClusterSnapshot) string {
 		content += "\n"
 	}
 
+	if len(snapshot.Summary.Certificates) > 0 {
+		content += fmt.Sprintf("=== TLS CERTIFICATES (%d) ===\n", len(snapshot.Summary.Certificates))
+		for _, cert := range snapshot.Summary.Certificates {
+			if cert.DaysUntilExpiry < 0 {
+				content += fmt.Sprintf("- %s/%s (CN: %s, EXPIRED: %s)\n", cert.Namespace, cert.SecretName, cert.Subject, cert.NotAfter.Format("2006-01-02"))
+				continue
+			}
+			content += fmt.Sprintf("- %s/%s (CN: %s, Expires: %s, Days left: %d)\n", cert.Namespace, cert.SecretName, cert.Subject, cert.NotAfter.Format("2006-01-02"), cert.DaysUntilExpiry)
+		}
+		content += "\n"
+	}
+
+	if snapshot.Summary.Metrics != nil {
+		content += formatMetricsAsText(snapshot.Summary.Metrics)
+	}
+
 	content += fmt.Sprintf("=== DUMP ===\n\n")
 	content += fmt.Sprintf("Full cluster resource dump including ENIConfigs available in YAML format.\n")
 	content += fmt.Sprintf("Use --format yaml to get complete resource definitions.\n")
@@ -474,10 +619,7 @@ func formatSnapshotAsText(snapshot ClusterSnapshot) string {
 
 func getClusterName() (string, error) {
 	// Get from kubeconfig context
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	configOverrides := &clientcmd.ConfigOverrides{}
-	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
-	rawConfig, err := kubeConfig.RawConfig()
+	rawConfig, err := common.RawConfig()
 	if err != nil {
 		return "", err
 	}
@@ -508,6 +650,10 @@ func marshalSnapshotYAML(snapshot ClusterSnapshot) ([]byte, error) {
 	// Timestamp first
 	timestampYAML, _ := yaml.Marshal(map[string]interface{}{"timestamp": snapshot.Timestamp})
 	result.Write(timestampYAML)
+	if snapshot.Cluster != "" {
+		clusterYAML, _ := yaml.Marshal(map[string]interface{}{"cluster": snapshot.Cluster})
+		result.Write(clusterYAML)
+	}
 	
 	// Summary section
 	summaryYAML, _ := yaml.Marshal(map[string]interface{}{"summary": snapshot.Summary})
@@ -522,10 +668,7 @@ func marshalSnapshotYAML(snapshot ClusterSnapshot) ([]byte, error) {
 
 func getENIConfigs() ([]unstructured.Unstructured, error) {
 	// Get kubeconfig
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	configOverrides := &clientcmd.ConfigOverrides{}
-	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
-	restConfig, err := kubeConfig.ClientConfig()
+	restConfig, err := common.GetRESTConfig()
 	if err != nil {
 		return nil, err
 	}
@@ -551,21 +694,13 @@ func getENIConfigs() ([]unstructured.Unstructured, error) {
 	return eniConfigList.Items, nil
 }
 
-func buildENIConfigAndSubnetSummary(eniConfigs []unstructured.Unstructured, pods []corev1.Pod) ([]ENIConfigSummary, []SubnetInfo) {
+func buildENIConfigAndSubnetSummary(scanner *awsutils.SubnetScanner, eniConfigs []unstructured.Unstructured, pods []corev1.Pod) ([]ENIConfigSummary, []SubnetInfo) {
 	var eniConfigSummary []ENIConfigSummary
 	var subnetInfo []SubnetInfo
 	subnetMap := make(map[string]bool)
 
-	// Create AWS session
-	sess, err := session.NewSession()
-	if err != nil {
-		fmt.Printf("Warning: could not create AWS session: %v\n", err)
-		return eniConfigSummary, subnetInfo
-	}
-
-	ec2Svc := ec2.New(sess)
-
 	// Process ENIConfigs
+	subnetByENIConfig := make(map[string]string)
 	for _, eniConfig := range eniConfigs {
 		name := eniConfig.GetName()
 		spec, found, _ := unstructured.NestedMap(eniConfig.Object, "spec")
@@ -578,42 +713,57 @@ func buildENIConfigAndSubnetSummary(eniConfigs []unstructured.Unstructured, pods
 
 		if subnetID != "" {
 			subnetMap[subnetID] = true
-			availableIPs := awsutils.GetSubnetAvailableIPsWithRegion(name, subnetID)
+			subnetByENIConfig[name] = subnetID
 
 			eniConfigSummary = append(eniConfigSummary, ENIConfigSummary{
 				Name:             name,
 				SubnetID:         subnetID,
 				AvailabilityZone: az,
-				AvailableIPs:     availableIPs,
 			})
 		}
 	}
 
-	// Find secondary subnets from pod IPs
-	secondarySubnets := awsutils.FindSecondarySubnets(pods, ec2Svc)
-	for subnetID := range secondarySubnets {
-		if !subnetMap[subnetID] {
-			subnetMap[subnetID] = true
+	// Describe the subnets of all ENIConfigs at once, region by region
+	subnets, err := scanner.DescribeENIConfigSubnets(subnetByENIConfig)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	for i, summary := range eniConfigSummary {
+		if subnet, ok := subnets[summary.SubnetID]; ok {
+			eniConfigSummary[i].AvailableIPs = int(aws.Int64Value(subnet.AvailableIpAddressCount))
 		}
 	}
 
+	// Find secondary subnets from pod IPs
+	secondarySubnets, err := scanner.FindSecondarySubnets("", pods)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	for subnetID, subnet := range secondarySubnets {
+		subnetMap[subnetID] = true
+		subnets[subnetID] = subnet
+	}
+
 	// Get subnet information
 	for subnetID := range subnetMap {
-		subnetDetails := awsutils.GetSubnetDetails(ec2Svc, subnetID)
+		subnetDetails := subnets[subnetID]
 		if subnetDetails != nil {
 			subnetType := "primary"
-			if secondarySubnets[subnetID] {
+			if secondarySubnets[subnetID] != nil {
 				subnetType = "secondary"
 			}
 
 			subnetInfo = append(subnetInfo, SubnetInfo{
 				SubnetID:     subnetID,
-				CIDR:         *subnetDetails.CidrBlock,
-				AvailableIPs: int(*subnetDetails.AvailableIpAddressCount),
+				CIDR:         aws.StringValue(subnetDetails.CidrBlock),
+				AvailableIPs: int(aws.Int64Value(subnetDetails.AvailableIpAddressCount)),
 				Type:         subnetType,
 			})
 		}
 	}
+	sort.Slice(subnetInfo, func(i, j int) bool {
+		return subnetInfo[i].SubnetID < subnetInfo[j].SubnetID
+	})
 
 	return eniConfigSummary, subnetInfo
 }
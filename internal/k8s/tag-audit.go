@@ -0,0 +1,307 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/config"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TagAuditOptions contains options for the cost-allocation tag audit
+type TagAuditOptions struct {
+	Region  string // Defaults to the region of the cluster's nodes
+	Profile string
+	Tags    []string // Required tag keys, overriding the tagging policy of the config file
+	Output  string   // table or json
+	FailOn  string   // Lowest severity that fails the run: error, warning, info or none
+}
+
+// taggedResourceAudit is a cluster resource that breaks the tagging policy
+type taggedResourceAudit struct {
+	resource    awsutils.TaggedResource
+	usedBy      string // Node, PVC or Service the resource backs
+	missing     []string
+	invalid     []string // key=value pairs outside the allowed values
+	monthlyCost float64  // -1 when the resource type has no price
+}
+
+// AuditCostTags checks that the EC2 instances of the cluster's nodes, the
+// EBS volumes of the nodes and PersistentVolumes, and the load balancers of
+// LoadBalancer Services carry the cost-allocation tags of the tagging policy,
+// and lists the ones that don't with their monthly cost, priced like
+// cost-estimate.
+func AuditCostTags(options TagAuditOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	tagging, err := cfg.Tagging()
+	if err != nil {
+		return err
+	}
+	required := options.Tags
+	if len(required) == 0 {
+		required = tagging.Required()
+	}
+	pricing, err := loadPricingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load pricing config: %w", err)
+	}
+
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	usedBy := make(map[string]string) // Instance ID, volume ID or LB DNS name to what uses it
+	var instanceIDs []string
+	for _, node := range nodes.Items {
+		if instanceID := awsutils.InstanceIDFromProviderID(node.Spec.ProviderID); instanceID != "" {
+			usedBy[instanceID] = "node/" + node.Name
+			instanceIDs = append(instanceIDs, instanceID)
+		}
+	}
+	if len(instanceIDs) == 0 {
+		return fmt.Errorf("no EC2 nodes found")
+	}
+
+	pvs, err := clientset.CoreV1().PersistentVolumes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list persistent volumes: %w", err)
+	}
+	var volumeIDs []string
+	for i := range pvs.Items {
+		pv := &pvs.Items[i]
+		volumeID, _ := ebsVolumeOf(pv)
+		if volumeID == "" {
+			continue
+		}
+		volumeIDs = append(volumeIDs, volumeID)
+		usedBy[volumeID] = "pv/" + pv.Name
+		if claim := pv.Spec.ClaimRef; claim != nil {
+			usedBy[volumeID] = fmt.Sprintf("pvc/%s/%s", claim.Namespace, claim.Name)
+		}
+	}
+
+	services, err := clientset.CoreV1().Services("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+	var lbDNSNames []string
+	for _, service := range services.Items {
+		if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			continue
+		}
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			if ingress.Hostname != "" {
+				dnsName := strings.ToLower(ingress.Hostname)
+				lbDNSNames = append(lbDNSNames, dnsName)
+				usedBy[dnsName] = fmt.Sprintf("service/%s/%s", service.Namespace, service.Name)
+			}
+		}
+	}
+
+	region := options.Region
+	if region == "" {
+		region = nodes.Items[0].Labels["topology.kubernetes.io/region"]
+	}
+	sess, err := awsutils.NewSession(options.Profile, region)
+	if err != nil {
+		return err
+	}
+	instances, err := awsutils.GetInstanceTags(sess, instanceIDs)
+	if err != nil {
+		return err
+	}
+	volumes, err := awsutils.GetVolumeTags(sess, instanceIDs, volumeIDs)
+	if err != nil {
+		return err
+	}
+	loadBalancers, err := awsutils.GetLoadBalancerTags(sess, lbDNSNames)
+	if err != nil {
+		return err
+	}
+
+	type auditedResource struct {
+		resource awsutils.TaggedResource
+		usedBy   string
+	}
+	var resources []auditedResource
+	for _, instance := range instances {
+		resources = append(resources, auditedResource{instance, usedBy[instance.ID]})
+	}
+	for _, volume := range volumes {
+		owner := usedBy[volume.ID]
+		if owner == "" {
+			owner = "node volume"
+		}
+		resources = append(resources, auditedResource{volume, owner})
+	}
+	for _, dnsName := range sortedKeys(loadBalancers) {
+		resources = append(resources, auditedResource{loadBalancers[dnsName], usedBy[dnsName]})
+	}
+
+	var audits []taggedResourceAudit
+	var findings []result.Finding
+	var totalCost, untaggedCost float64
+	missingByTag := make(map[string]int)
+	checkedByKind := make(map[string]int)
+	for _, entry := range resources {
+		resource := entry.resource
+		checkedByKind[resource.Kind]++
+		cost := taggedResourceMonthlyCost(resource, pricing)
+		if cost > 0 {
+			totalCost += cost
+		}
+
+		audit := taggedResourceAudit{resource: resource, usedBy: entry.usedBy, monthlyCost: cost}
+		for _, key := range required {
+			value := strings.TrimSpace(resource.Tags[key])
+			allowed := tagging.AllowedValues[key]
+			switch {
+			case value == "":
+				audit.missing = append(audit.missing, key)
+				missingByTag[key]++
+			case len(allowed) > 0 && !containsString(allowed, value):
+				audit.invalid = append(audit.invalid, key+"="+value)
+			}
+		}
+		if len(audit.missing) == 0 && len(audit.invalid) == 0 {
+			continue
+		}
+		audits = append(audits, audit)
+		if cost > 0 {
+			untaggedCost += cost
+		}
+
+		name := fmt.Sprintf("%s/%s", resource.Kind, resource.ID)
+		details := map[string]string{
+			"type":         resource.Type,
+			"used_by":      entry.usedBy,
+			"monthly_cost": formatTagAuditCost(cost),
+			"remediation":  tagAuditRemediation(resource, audit.missing, region),
+		}
+		if len(audit.missing) > 0 {
+			findings = append(findings, result.Finding{
+				Check:    "missing-cost-tags",
+				Severity: result.SeverityError,
+				Resource: name,
+				Message:  "missing cost-allocation tags " + strings.Join(audit.missing, ", "),
+				Details:  details,
+			})
+		}
+		if len(audit.invalid) > 0 {
+			findings = append(findings, result.Finding{
+				Check:    "invalid-tag-value",
+				Severity: result.SeverityWarning,
+				Resource: name,
+				Message:  "tag values outside the tagging policy: " + strings.Join(audit.invalid, ", "),
+				Details:  details,
+			})
+		}
+	}
+	sort.SliceStable(audits, func(i, j int) bool { return audits[i].monthlyCost > audits[j].monthlyCost })
+
+	if options.Output == "json" {
+		if err := result.New("tag-audit", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	fmt.Printf("Required tags: %s\n\n", strings.Join(required, ", "))
+	if len(audits) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\tRESOURCE\tTYPE\tUSED BY\tMISSING\tINVALID\tMONTHLY COST")
+		for _, audit := range audits {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", audit.resource.Kind, audit.resource.ID, valueOrDash(audit.resource.Type),
+				valueOrDash(audit.usedBy), valueOrDash(strings.Join(audit.missing, ",")), valueOrDash(strings.Join(audit.invalid, ",")),
+				formatTagAuditCost(audit.monthlyCost))
+		}
+		w.Flush()
+	}
+
+	fmt.Println("\n--- Tag Audit Summary ---")
+	fmt.Printf("Resources checked: %d instances, %d volumes, %d load balancers\n",
+		checkedByKind["instance"], checkedByKind["volume"], checkedByKind["load-balancer"])
+	if len(audits) == 0 {
+		fmt.Println("✅ Every resource carries the required tags")
+	} else {
+		share := 0.0
+		if totalCost > 0 {
+			share = untaggedCost / totalCost * 100
+		}
+		fmt.Printf("❌ %d resources break the tagging policy\n", len(audits))
+		fmt.Printf("Unallocated spend: $%.2f/month of $%.2f (%.0f%%)\n", untaggedCost, totalCost, share)
+		for _, key := range required {
+			if missingByTag[key] > 0 {
+				fmt.Printf("   %s missing on %d resources\n", key, missingByTag[key])
+			}
+		}
+		fmt.Println("Tag the node group's launch template, the EBS CSI driver's extra-tags and the load balancer annotations so new resources are tagged too.")
+	}
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// taggedResourceMonthlyCost prices a resource from the cost-estimate table,
+// or returns -1 when its type has no price.
+func taggedResourceMonthlyCost(resource awsutils.TaggedResource, pricing *PricingConfig) float64 {
+	switch resource.Kind {
+	case "instance":
+		if price, ok := pricing.EC2Pricing[resource.Type]; ok {
+			return price * 730
+		}
+	case "volume":
+		if price, ok := pricing.EBSPricing[resource.Type]; ok {
+			return price * float64(resource.SizeGB)
+		}
+	case "load-balancer":
+		if price, ok := pricing.LBPricing[resource.Type]; ok {
+			return price * 730
+		}
+	}
+	return -1
+}
+
+func formatTagAuditCost(cost float64) string {
+	if cost < 0 {
+		return "-"
+	}
+	return fmt.Sprintf("$%.2f", cost)
+}
+
+// tagAuditRemediation is the AWS CLI command that adds the missing tags to a
+// resource, with placeholders for their values.
+func tagAuditRemediation(resource awsutils.TaggedResource, missing []string, region string) string {
+	if len(missing) == 0 {
+		return "set the tags to one of the allowed values of the tagging policy"
+	}
+	var tags []string
+	for _, key := range missing {
+		tags = append(tags, fmt.Sprintf("Key=%s,Value=<%s>", key, strings.ToLower(key)))
+	}
+	switch {
+	case resource.Kind != "load-balancer":
+		return fmt.Sprintf("aws ec2 create-tags --region %s --resources %s --tags %s", region, resource.ID, strings.Join(tags, " "))
+	case resource.ARN != "":
+		return fmt.Sprintf("aws elbv2 add-tags --region %s --resource-arns %s --tags %s", region, resource.ARN, strings.Join(tags, " "))
+	}
+	return fmt.Sprintf("aws elb add-tags --region %s --load-balancer-names %s --tags %s", region, resource.ID, strings.Join(tags, " "))
+}
@@ -0,0 +1,271 @@
+package k8s
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// unlabeledTeam groups the namespaces without the team label
+const unlabeledTeam = "(none)"
+
+// TeamReportOptions contains options for the per-team resource and cost report
+type TeamReportOptions struct {
+	Label  string // Namespace label holding the team
+	Output string // table, csv or json
+}
+
+// TeamUsage is one team's share of the cluster
+type TeamUsage struct {
+	Team           string   `json:"team"`
+	Namespaces     []string `json:"namespaces"`
+	Pods           int      `json:"pods"`
+	CPURequests    float64  `json:"cpu_requests"`
+	CPUUsage       float64  `json:"cpu_usage"`
+	MemoryRequests float64  `json:"memory_requests_gi"`
+	MemoryUsage    float64  `json:"memory_usage_gi"`
+	StorageGi      float64  `json:"pvc_storage_gi"`
+	LoadBalancers  int      `json:"load_balancers"`
+	ComputeCost    float64  `json:"compute_monthly_cost"`
+	StorageCost    float64  `json:"storage_monthly_cost"`
+	LBCost         float64  `json:"lb_monthly_cost"`
+	MonthlyCost    float64  `json:"monthly_cost"`
+}
+
+// ShowTeamReport groups namespaces by a team label and totals per team the
+// running pods, their requests and usage, PVC storage, LoadBalancer
+// services and the estimated monthly cost. A pod's compute cost is its
+// node's price split by the average of the pod's CPU and memory share of
+// the node's allocatable; Fargate pods are priced by their billed size.
+// Node capacity no pod requests is reported as unallocated rather than
+// charged to a team.
+func ShowTeamReport(options TeamReportOptions) error {
+	if options.Output != "table" && options.Output != "csv" && options.Output != "json" {
+		return fmt.Errorf("invalid output format '%s' (must be table, csv or json)", options.Output)
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	pricing, err := loadPricingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load pricing config: %w", err)
+	}
+	ctx := context.TODO()
+	namespaces, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	pvcs, err := clientset.CoreV1().PersistentVolumeClaims("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list PVCs: %w", err)
+	}
+	services, err := clientset.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+	storageClasses, err := clientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list storage classes: %w", err)
+	}
+	fargatePods, err := collectFargatePods(ctx, clientset)
+	if err != nil {
+		return err
+	}
+
+	// Usage is optional, metrics-server may not be installed
+	usage := make(map[string]corev1.ResourceList)
+	metricsAvailable := false
+	if metricsClient, err := common.GetMetricsClient(); err == nil {
+		if podMetrics, err := metricsClient.MetricsV1beta1().PodMetricses("").List(ctx, metav1.ListOptions{}); err == nil {
+			metricsAvailable = true
+			for _, metrics := range podMetrics.Items {
+				usage[metrics.Namespace+"/"+metrics.Name] = sumContainerUsage(metrics.Containers)
+			}
+		}
+	}
+	if !metricsAvailable && options.Output == "table" {
+		fmt.Fprintln(os.Stderr, "Warning: pod metrics are unavailable, usage columns are empty. Is metrics-server installed?")
+	}
+
+	teams := make(map[string]*TeamUsage)
+	teamOf := make(map[string]*TeamUsage)
+	for _, namespace := range namespaces.Items {
+		name := namespace.Labels[options.Label]
+		if name == "" {
+			name = unlabeledTeam
+		}
+		if teams[name] == nil {
+			teams[name] = &TeamUsage{Team: name, Namespaces: []string{}}
+		}
+		teams[name].Namespaces = append(teams[name].Namespaces, namespace.Name)
+		teamOf[namespace.Name] = teams[name]
+	}
+
+	// Monthly price and allocatable of each node, split among its pods
+	type nodePrice struct {
+		monthly, cpu, memory float64
+	}
+	nodePrices := make(map[string]nodePrice)
+	var nodeCost float64
+	unpricedNodes := 0
+	for _, node := range nodes.Items {
+		price, ok := pricing.EC2Pricing[getNodeInstanceType(node)]
+		if !ok {
+			if !strings.HasPrefix(node.Name, "fargate-") {
+				unpricedNodes++
+			}
+			continue
+		}
+		nodePrices[node.Name] = nodePrice{
+			monthly: price * 730,
+			cpu:     float64(node.Status.Allocatable.Cpu().MilliValue()) / 1000,
+			memory:  float64(node.Status.Allocatable.Memory().Value()) / (1024 * 1024 * 1024),
+		}
+		nodeCost += price * 730
+	}
+	fargateCost := make(map[string]float64)
+	for _, pod := range fargatePods {
+		fargateCost[pod.pod.Namespace+"/"+pod.pod.Name] = fargateHourlyCost(pricing, pod.billedVCPU, pod.billedGB) * 730
+	}
+
+	allocated := 0.0
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		team := teamOf[pod.Namespace]
+		if team == nil || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		cpu, memory := podRequests(pod)
+		team.Pods++
+		team.CPURequests += cpu
+		team.MemoryRequests += memory
+		if used, ok := usage[pod.Namespace+"/"+pod.Name]; ok {
+			team.CPUUsage += float64(used.Cpu().MilliValue()) / 1000
+			team.MemoryUsage += float64(used.Memory().Value()) / (1024 * 1024 * 1024)
+		}
+		if cost, ok := fargateCost[pod.Namespace+"/"+pod.Name]; ok {
+			team.ComputeCost += cost
+			continue
+		}
+		if node, ok := nodePrices[pod.Spec.NodeName]; ok {
+			share := math.Min((safeRatio(cpu, node.cpu)+safeRatio(memory, node.memory))/2, 1)
+			team.ComputeCost += node.monthly * share
+			allocated += node.monthly * share
+		}
+	}
+
+	volumeTypes := ebsVolumeTypes(storageClasses.Items)
+	for _, pvc := range pvcs.Items {
+		team := teamOf[pvc.Namespace]
+		if team == nil || pvc.Status.Phase != corev1.ClaimBound {
+			continue
+		}
+		sizeGi := float64(pvc.Status.Capacity.Storage().Value()) / (1024 * 1024 * 1024)
+		team.StorageGi += sizeGi
+		if pvc.Spec.StorageClassName != nil {
+			team.StorageCost += pricing.EBSPricing[volumeTypes[*pvc.Spec.StorageClassName]] * sizeGi
+		}
+	}
+	for _, svc := range services.Items {
+		team := teamOf[svc.Namespace]
+		if team == nil || svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			continue
+		}
+		team.LoadBalancers++
+		team.LBCost += pricing.LBPricing[serviceLoadBalancerType(svc)] * 730
+	}
+
+	var report []TeamUsage
+	for _, team := range teams {
+		team.MonthlyCost = team.ComputeCost + team.StorageCost + team.LBCost
+		sort.Strings(team.Namespaces)
+		report = append(report, *team)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].MonthlyCost != report[j].MonthlyCost {
+			return report[i].MonthlyCost > report[j].MonthlyCost
+		}
+		return report[i].Team < report[j].Team
+	})
+
+	switch options.Output {
+	case "json":
+		encoder := json.NewEncoder(sink.Output())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]any{
+			"label":                    options.Label,
+			"teams":                    report,
+			"unallocated_monthly_cost": nodeCost - allocated,
+			"node_monthly_cost":        nodeCost,
+			"metrics_available":        metricsAvailable,
+		})
+	case "csv":
+		return writeTeamReportCSV(report)
+	}
+
+	usageText := func(value float64, unit string) string {
+		if !metricsAvailable {
+			return "-"
+		}
+		return fmt.Sprintf("%.2f%s", value, unit)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TEAM\tNAMESPACES\tPODS\tCPU REQ\tCPU USED\tMEM REQ\tMEM USED\tPVC\tLBS\tCOST/MONTH")
+	var total float64
+	for _, team := range report {
+		total += team.MonthlyCost
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.2f\t%s\t%.2fGi\t%s\t%.0fGi\t%d\t$%.2f\n", team.Team, len(team.Namespaces), team.Pods,
+			team.CPURequests, usageText(team.CPUUsage, ""), team.MemoryRequests, usageText(team.MemoryUsage, "Gi"),
+			team.StorageGi, team.LoadBalancers, team.MonthlyCost)
+	}
+	w.Flush()
+
+	fmt.Println("\n--- Team Report Summary ---")
+	fmt.Printf("Teams: %d by namespace label %s\n", len(report), options.Label)
+	fmt.Printf("Charged to teams: $%.2f/month\n", total)
+	fmt.Printf("Unallocated node capacity: $%.2f/month of $%.2f/month in nodes\n", nodeCost-allocated, nodeCost)
+	if team, ok := teams[unlabeledTeam]; ok && team.Pods > 0 {
+		fmt.Printf("⚠️  %d namespaces have no %s label: %s\n", len(team.Namespaces), options.Label, truncateList(team.Namespaces, 10))
+	}
+	if unpricedNodes > 0 {
+		fmt.Printf("⚠️  %d nodes have no price in the pricing table and are not charged\n", unpricedNodes)
+	}
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+func writeTeamReportCSV(report []TeamUsage) error {
+	writer := csv.NewWriter(sink.Output())
+	writer.Write([]string{"team", "namespaces", "pods", "cpu_requests", "cpu_usage", "memory_requests_gi", "memory_usage_gi",
+		"pvc_storage_gi", "load_balancers", "compute_monthly_cost", "storage_monthly_cost", "lb_monthly_cost", "monthly_cost"})
+	format := func(value float64) string { return strconv.FormatFloat(value, 'f', 2, 64) }
+	for _, team := range report {
+		writer.Write([]string{team.Team, strings.Join(team.Namespaces, ";"), strconv.Itoa(team.Pods),
+			format(team.CPURequests), format(team.CPUUsage), format(team.MemoryRequests), format(team.MemoryUsage),
+			format(team.StorageGi), strconv.Itoa(team.LoadBalancers), format(team.ComputeCost), format(team.StorageCost),
+			format(team.LBCost), format(team.MonthlyCost)})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,417 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// TimelineOptions contains options for reconstructing pod timelines
+type TimelineOptions struct {
+	Namespace  string
+	Deployment string // Reconstruct the rollout of this deployment instead of one pod
+}
+
+// timelineEntry is one moment in the life of a pod or rollout
+type timelineEntry struct {
+	time   time.Time
+	object string // Pod, ReplicaSet or Deployment name
+	event  string
+	detail string
+}
+
+// podMilestones are the phases a pod goes through on its way to ready
+type podMilestones struct {
+	created   time.Time
+	scheduled time.Time
+	pulled    time.Time // Last image pulled before the containers started
+	started   time.Time
+	ready     time.Time
+	restarts  int32
+	status    string
+}
+
+// ShowTimeline reconstructs the life of a pod, or of every pod of a
+// deployment rollout, from its events and status: scheduling, image pulls,
+// container starts, probe failures, restarts and evictions, with the time
+// between them. Pods that are already gone are rebuilt from their events,
+// which the API server keeps for an hour by default.
+func ShowTimeline(podName string, options TimelineOptions) error {
+	if podName == "" && options.Deployment == "" {
+		return fmt.Errorf("give a pod name or --deployment")
+	}
+	if podName != "" && options.Deployment != "" {
+		return fmt.Errorf("give either a pod name or --deployment, not both")
+	}
+	if options.Namespace == "" {
+		options.Namespace = "default"
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+
+	events, err := clientset.CoreV1().Events(options.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list events: %w", err)
+	}
+	if options.Deployment != "" {
+		return showRolloutTimeline(ctx, clientset, options, events.Items)
+	}
+
+	var pods []corev1.Pod
+	pod, err := clientset.CoreV1().Pods(options.Namespace).Get(ctx, podName, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		fmt.Printf("⚠️  Pod %s/%s no longer exists, rebuilding it from events only\n", options.Namespace, podName)
+	case err != nil:
+		return fmt.Errorf("failed to get pod %s/%s: %w", options.Namespace, podName, err)
+	default:
+		pods = append(pods, *pod)
+		fmt.Printf("Pod: %s/%s (node %s)\n", options.Namespace, podName, valueOrDash(pod.Spec.NodeName))
+	}
+
+	isPod := func(event corev1.Event) bool {
+		return event.InvolvedObject.Kind == "Pod" && event.InvolvedObject.Name == podName
+	}
+	entries := buildTimeline(pods, events.Items, isPod)
+	if len(entries) == 0 {
+		return fmt.Errorf("nothing known about pod %s/%s; its events may have expired", options.Namespace, podName)
+	}
+	printTimeline(entries, false)
+
+	milestones := podTimelineMilestones(pods, events.Items, podName)
+	fmt.Println("\n--- Timeline Summary ---")
+	printPhaseDurations(milestones)
+	fmt.Printf("Restarts: %d\n", milestones.restarts)
+	fmt.Printf("Status: %s\n", valueOrDash(milestones.status))
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// showRolloutTimeline merges the timelines of a deployment, its ReplicaSets
+// and their pods, including pods that were replaced but still have events.
+func showRolloutTimeline(ctx context.Context, clientset *kubernetes.Clientset, options TimelineOptions, events []corev1.Event) error {
+	deployment, err := clientset.AppsV1().Deployments(options.Namespace).Get(ctx, options.Deployment, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get deployment %s/%s: %w", options.Namespace, options.Deployment, err)
+	}
+	replicaSets, err := clientset.AppsV1().ReplicaSets(options.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list replicasets: %w", err)
+	}
+	rsNames := make(map[string]bool)
+	for _, rs := range replicaSets.Items {
+		for _, owner := range rs.OwnerReferences {
+			if owner.UID == deployment.UID {
+				rsNames[rs.Name] = true
+			}
+		}
+	}
+	allPods, err := clientset.CoreV1().Pods(options.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	var pods []corev1.Pod
+	for _, pod := range allPods.Items {
+		for _, owner := range pod.OwnerReferences {
+			if owner.Kind == "ReplicaSet" && rsNames[owner.Name] {
+				pods = append(pods, pod)
+			}
+		}
+	}
+
+	// Pods of a ReplicaSet are named after it, which finds the events of
+	// pods that no longer exist
+	podOfRollout := func(name string) bool {
+		for rsName := range rsNames {
+			if strings.HasPrefix(name, rsName+"-") {
+				return true
+			}
+		}
+		return false
+	}
+	belongs := func(event corev1.Event) bool {
+		object := event.InvolvedObject
+		switch object.Kind {
+		case "Deployment":
+			return object.Name == deployment.Name
+		case "ReplicaSet":
+			return rsNames[object.Name]
+		case "Pod":
+			return podOfRollout(object.Name)
+		}
+		return false
+	}
+
+	fmt.Printf("Deployment: %s/%s (revision %s, %d ReplicaSets)\n", options.Namespace, deployment.Name,
+		valueOrDash(deployment.Annotations["deployment.kubernetes.io/revision"]), len(rsNames))
+	entries := buildTimeline(pods, events, belongs)
+	if len(entries) == 0 {
+		return fmt.Errorf("nothing known about the rollout of %s; its events may have expired", deployment.Name)
+	}
+	printTimeline(entries, true)
+
+	podNames := make(map[string]bool)
+	for _, pod := range pods {
+		podNames[pod.Name] = true
+	}
+	for _, event := range events {
+		if event.InvolvedObject.Kind == "Pod" && podOfRollout(event.InvolvedObject.Name) {
+			podNames[event.InvolvedObject.Name] = true
+		}
+	}
+
+	fmt.Println("\n--- Rollout Summary ---")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "POD\tTO SCHEDULE\tTO PULL\tTO START\tTO READY\tRESTARTS\tSTATUS")
+	var rolloutStart, rolloutReady time.Time
+	for _, name := range sortedKeys(podNames) {
+		milestones := podTimelineMilestones(pods, events, name)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", name,
+			phaseDuration(milestones.created, milestones.scheduled),
+			phaseDuration(milestones.scheduled, milestones.pulled),
+			phaseDuration(firstSet(milestones.pulled, milestones.scheduled), milestones.started),
+			phaseDuration(milestones.started, milestones.ready),
+			milestones.restarts, valueOrDash(milestones.status))
+		if !milestones.created.IsZero() && (rolloutStart.IsZero() || milestones.created.Before(rolloutStart)) {
+			rolloutStart = milestones.created
+		}
+		if milestones.ready.After(rolloutReady) {
+			rolloutReady = milestones.ready
+		}
+	}
+	w.Flush()
+	ready := deployment.Status.UpdatedReplicas == deployment.Status.Replicas &&
+		deployment.Status.AvailableReplicas == deployment.Status.Replicas
+	if ready {
+		fmt.Printf("✅ Rollout complete: %d/%d replicas updated and available\n", deployment.Status.AvailableReplicas, deployment.Status.Replicas)
+	} else {
+		fmt.Printf("⚠️  Rollout in progress: %d updated, %d available of %d\n",
+			deployment.Status.UpdatedReplicas, deployment.Status.AvailableReplicas, deployment.Status.Replicas)
+	}
+	if !rolloutStart.IsZero() && rolloutReady.After(rolloutStart) {
+		fmt.Printf("First pod created to last pod ready: %s\n", rolloutReady.Sub(rolloutStart).Round(time.Second))
+	}
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// buildTimeline collects the moments in the status of pods and in the
+// events selected by include, sorted by time.
+func buildTimeline(pods []corev1.Pod, events []corev1.Event, include func(corev1.Event) bool) []timelineEntry {
+	var entries []timelineEntry
+	for _, pod := range pods {
+		entries = append(entries, timelineEntry{pod.CreationTimestamp.Time, pod.Name, "Created", ""})
+		for _, condition := range pod.Status.Conditions {
+			if condition.Status != corev1.ConditionTrue || condition.LastTransitionTime.IsZero() {
+				continue
+			}
+			switch condition.Type {
+			case corev1.PodInitialized, corev1.ContainersReady, corev1.PodReady:
+				entries = append(entries, timelineEntry{condition.LastTransitionTime.Time, pod.Name, string(condition.Type), ""})
+			}
+		}
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			// Events of earlier restarts may be aggregated away, the last
+			// termination is always in the status
+			if terminated := status.LastTerminationState.Terminated; terminated != nil {
+				entries = append(entries, timelineEntry{terminated.FinishedAt.Time, pod.Name, "Terminated",
+					fmt.Sprintf("%s: %s, exit code %d", status.Name, terminated.Reason, terminated.ExitCode)})
+			}
+			if terminated := status.State.Terminated; terminated != nil {
+				entries = append(entries, timelineEntry{terminated.FinishedAt.Time, pod.Name, "Terminated",
+					fmt.Sprintf("%s: %s, exit code %d", status.Name, terminated.Reason, terminated.ExitCode)})
+			}
+		}
+		if pod.Status.Reason != "" {
+			entries = append(entries, timelineEntry{podLastTransition(pod), pod.Name, pod.Status.Reason, pod.Status.Message})
+		}
+		if pod.DeletionTimestamp != nil {
+			entries = append(entries, timelineEntry{pod.DeletionTimestamp.Time, pod.Name, "Deleting", "grace period ends"})
+		}
+	}
+
+	for _, event := range events {
+		if !include(event) {
+			continue
+		}
+		first, last, count := eventTimes(event)
+		detail := event.Message
+		if count > 1 {
+			detail = fmt.Sprintf("%s (x%d until %s)", detail, count, last.Local().Format("15:04:05"))
+		}
+		entries = append(entries, timelineEntry{first, event.InvolvedObject.Name, event.Reason, detail})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].time.Before(entries[j].time) })
+	return entries
+}
+
+func printTimeline(entries []timelineEntry, showObject bool) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if showObject {
+		fmt.Fprintln(w, "TIME\tDELTA\tOBJECT\tEVENT\tDETAIL")
+	} else {
+		fmt.Fprintln(w, "TIME\tDELTA\tEVENT\tDETAIL")
+	}
+	var previous time.Time
+	for _, entry := range entries {
+		delta := "-"
+		if !previous.IsZero() && !entry.time.IsZero() {
+			delta = "+" + entry.time.Sub(previous).Round(time.Second).String()
+		}
+		if !entry.time.IsZero() {
+			previous = entry.time
+		}
+		timestamp := "-"
+		if !entry.time.IsZero() {
+			timestamp = entry.time.Local().Format("2006-01-02 15:04:05")
+		}
+		detail := strings.ReplaceAll(entry.detail, "\n", " ")
+		if showObject {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", timestamp, delta, entry.object, entry.event, detail)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", timestamp, delta, entry.event, detail)
+		}
+	}
+	w.Flush()
+}
+
+// podTimelineMilestones finds when a pod reached each phase, from its status
+// when it still exists and from its events otherwise.
+func podTimelineMilestones(pods []corev1.Pod, events []corev1.Event, podName string) podMilestones {
+	var milestones podMilestones
+	for _, pod := range pods {
+		if pod.Name != podName {
+			continue
+		}
+		milestones.created = pod.CreationTimestamp.Time
+		for _, condition := range pod.Status.Conditions {
+			if condition.Status != corev1.ConditionTrue {
+				continue
+			}
+			switch condition.Type {
+			case corev1.PodScheduled:
+				milestones.scheduled = condition.LastTransitionTime.Time
+			case corev1.PodReady:
+				milestones.ready = condition.LastTransitionTime.Time
+			}
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			milestones.restarts += status.RestartCount
+		}
+		milestones.status = string(pod.Status.Phase)
+		if pod.Status.Reason != "" {
+			milestones.status += " (" + pod.Status.Reason + ")"
+		}
+	}
+
+	podExists := !milestones.created.IsZero()
+	var starts int32
+	for _, event := range events {
+		if event.InvolvedObject.Kind != "Pod" || event.InvolvedObject.Name != podName {
+			continue
+		}
+		first, last, count := eventTimes(event)
+		if !podExists && (milestones.created.IsZero() || first.Before(milestones.created)) {
+			milestones.created = first
+		}
+		switch event.Reason {
+		case "Scheduled":
+			milestones.scheduled = firstSet(milestones.scheduled, first)
+		case "Pulled":
+			if (milestones.started.IsZero() || last.Before(milestones.started)) && last.After(milestones.pulled) {
+				milestones.pulled = last
+			}
+		case "Started":
+			starts += count
+			if milestones.started.IsZero() || first.Before(milestones.started) {
+				milestones.started = first
+			}
+		case "Evicted", "Preempted", "Killing":
+			if !podExists {
+				milestones.status = event.Reason
+			}
+		}
+	}
+	if !podExists {
+		// Every start after the first is a restart
+		milestones.restarts = max(starts-1, 0)
+		if milestones.status == "" {
+			milestones.status = "Gone"
+		}
+	}
+	return milestones
+}
+
+// printPhaseDurations prints the time spent between the milestones that
+// are known.
+func printPhaseDurations(milestones podMilestones) {
+	fmt.Printf("Created → scheduled: %s\n", phaseDuration(milestones.created, milestones.scheduled))
+	fmt.Printf("Scheduled → images pulled: %s\n", phaseDuration(milestones.scheduled, milestones.pulled))
+	fmt.Printf("Images pulled → started: %s\n", phaseDuration(firstSet(milestones.pulled, milestones.scheduled), milestones.started))
+	fmt.Printf("Started → ready: %s\n", phaseDuration(milestones.started, milestones.ready))
+	fmt.Printf("Created → ready: %s\n", phaseDuration(milestones.created, milestones.ready))
+}
+
+func phaseDuration(from, to time.Time) string {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return "-"
+	}
+	return to.Sub(from).Round(time.Second).String()
+}
+
+// firstSet returns a unless it is zero, b otherwise.
+func firstSet(a, b time.Time) time.Time {
+	if a.IsZero() {
+		return b
+	}
+	return a
+}
+
+// eventTimes returns when an event first and last happened and how often,
+// from the legacy count fields or from the event series.
+func eventTimes(event corev1.Event) (time.Time, time.Time, int32) {
+	first := event.FirstTimestamp.Time
+	if first.IsZero() {
+		first = event.EventTime.Time
+	}
+	if first.IsZero() {
+		first = event.CreationTimestamp.Time
+	}
+	last := event.LastTimestamp.Time
+	count := event.Count
+	if event.Series != nil {
+		last = event.Series.LastObservedTime.Time
+		count = event.Series.Count
+	}
+	if last.IsZero() {
+		last = first
+	}
+	return first, last, max(count, 1)
+}
+
+// podLastTransition is the latest condition change of a pod, the best guess
+// for when it got its status reason.
+func podLastTransition(pod corev1.Pod) time.Time {
+	var latest time.Time
+	for _, condition := range pod.Status.Conditions {
+		if condition.LastTransitionTime.After(latest) {
+			latest = condition.LastTransitionTime.Time
+		}
+	}
+	return firstSet(latest, pod.CreationTimestamp.Time)
+}
@@ -0,0 +1,222 @@
+package k8s
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	awsutils "github.com/HighonAces/swissarmycli/internal/aws"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	renewedAtAnnotation = "swissarmycli.io/renewed-at"
+	notAfterAnnotation  = "swissarmycli.io/not-after"
+	acmArnAnnotation    = "swissarmycli.io/acm-certificate-arn"
+)
+
+// TLSSecretOptions contains options for creating or renewing a TLS secret
+type TLSSecretOptions struct {
+	Namespace string
+	CertFile  string
+	KeyFile   string
+	ChainFile string
+	ACMArn    string // Pull certificate and chain from ACM instead of CertFile/ChainFile
+	Profile   string
+}
+
+// CreateTLSSecret validates a certificate, its key and chain, then creates or
+// updates a kubernetes.io/tls secret annotated with the renewal time.
+func CreateTLSSecret(secretName string, options TLSSecretOptions) error {
+	if options.KeyFile == "" {
+		return fmt.Errorf("--key is required")
+	}
+	if (options.CertFile == "") == (options.ACMArn == "") {
+		return fmt.Errorf("exactly one of --cert or --acm-arn is required")
+	}
+	namespace := options.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	var certPEM, chainPEM []byte
+	if options.ACMArn != "" {
+		fmt.Printf("Fetching certificate %s from ACM...\n", options.ACMArn)
+		cert, chain, err := awsutils.GetACMCertificate(options.ACMArn, options.Profile)
+		if err != nil {
+			return err
+		}
+		certPEM, chainPEM = []byte(cert), []byte(chain)
+	} else {
+		content, err := os.ReadFile(options.CertFile)
+		if err != nil {
+			return fmt.Errorf("failed to read certificate file '%s': %w", options.CertFile, err)
+		}
+		certPEM = content
+	}
+	if options.ChainFile != "" {
+		content, err := os.ReadFile(options.ChainFile)
+		if err != nil {
+			return fmt.Errorf("failed to read chain file '%s': %w", options.ChainFile, err)
+		}
+		chainPEM = content
+	}
+	keyPEM, err := os.ReadFile(options.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read key file '%s': %w", options.KeyFile, err)
+	}
+
+	// A certificate file may already be a full chain; split it so the leaf
+	// and intermediates are validated the same way either way.
+	certs, err := parsePEMCertificates(append(append([]byte{}, certPEM...), chainPEM...))
+	if err != nil {
+		return err
+	}
+	leaf, intermediates := certs[0], certs[1:]
+
+	if _, err := tls.X509KeyPair(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Raw}), keyPEM); err != nil {
+		return fmt.Errorf("private key does not match certificate: %w", err)
+	}
+	fmt.Println("✅ Private key matches certificate")
+
+	now := time.Now()
+	if now.After(leaf.NotAfter) {
+		return fmt.Errorf("certificate expired on %s", leaf.NotAfter.Format(time.RFC3339))
+	}
+	if now.Before(leaf.NotBefore) {
+		return fmt.Errorf("certificate is not valid until %s", leaf.NotBefore.Format(time.RFC3339))
+	}
+
+	if err := validateCertChain(leaf, intermediates); err != nil {
+		return err
+	}
+
+	var fullChain []byte
+	for _, cert := range certs {
+		fullChain = append(fullChain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	annotations := map[string]string{
+		renewedAtAnnotation: now.UTC().Format(time.RFC3339),
+		notAfterAnnotation:  leaf.NotAfter.UTC().Format(time.RFC3339),
+	}
+	if options.ACMArn != "" {
+		annotations[acmArnAnnotation] = options.ACMArn
+	}
+	data := map[string][]byte{
+		v1.TLSCertKey:       fullChain,
+		v1.TLSPrivateKeyKey: keyPEM,
+	}
+
+	secrets := clientset.CoreV1().Secrets(namespace)
+	secret, err := secrets.Get(context.TODO(), secretName, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		secret = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: namespace, Annotations: annotations},
+			Type:       v1.SecretTypeTLS,
+			Data:       data,
+		}
+		secret, err = secrets.Create(context.TODO(), secret, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create secret '%s': %w", secretName, err)
+		}
+		fmt.Printf("Created secret '%s' in namespace '%s'\n", secretName, namespace)
+	case err != nil:
+		return fmt.Errorf("failed to get secret '%s' in namespace '%s': %w", secretName, namespace, err)
+	default:
+		if secret.Type != v1.SecretTypeTLS {
+			return fmt.Errorf("secret '%s' exists with type %s, refusing to overwrite", secretName, secret.Type)
+		}
+		if secret.Annotations == nil {
+			secret.Annotations = make(map[string]string)
+		}
+		for key, value := range annotations {
+			secret.Annotations[key] = value
+		}
+		// Keep any extra keys such as ca.crt that other tools may have added
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
+		for key, value := range data {
+			secret.Data[key] = value
+		}
+		secret, err = secrets.Update(context.TODO(), secret, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to update secret '%s': %w", secretName, err)
+		}
+		fmt.Printf("Updated secret '%s' in namespace '%s'\n", secretName, namespace)
+	}
+
+	return printCertDetails(secret)
+}
+
+// parsePEMCertificates decodes every CERTIFICATE block in data, in order.
+func parsePEMCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no PEM certificates found")
+	}
+	return certs, nil
+}
+
+// validateCertChain checks that every certificate is signed by the next one
+// and that the chain ends at a root trusted by the system or a self-signed CA.
+func validateCertChain(leaf *x509.Certificate, intermediates []*x509.Certificate) error {
+	chain := append([]*x509.Certificate{leaf}, intermediates...)
+	for i := 0; i < len(chain)-1; i++ {
+		if err := chain[i].CheckSignatureFrom(chain[i+1]); err != nil {
+			return fmt.Errorf("chain is out of order or broken: '%s' is not signed by '%s'",
+				chain[i].Subject.CommonName, chain[i+1].Subject.CommonName)
+		}
+	}
+
+	pool := x509.NewCertPool()
+	for _, cert := range intermediates {
+		pool.AddCert(cert)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{Intermediates: pool}); err == nil {
+		fmt.Printf("✅ Chain complete (%d intermediate(s), trusted by system roots)\n", len(intermediates))
+		return nil
+	}
+
+	last := chain[len(chain)-1]
+	if last.CheckSignatureFrom(last) == nil {
+		fmt.Printf("⚠️  Chain ends at self-signed CA '%s', which is not in the system trust store\n", last.Subject.CommonName)
+		return nil
+	}
+
+	var names []string
+	for _, cert := range chain {
+		names = append(names, cert.Subject.CommonName)
+	}
+	return fmt.Errorf("chain is incomplete: missing issuer '%s' after %s", last.Issuer.CommonName, strings.Join(names, " -> "))
+}
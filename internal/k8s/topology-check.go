@@ -0,0 +1,284 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const zoneLabel = "topology.kubernetes.io/zone"
+
+// TopologyCheckOptions contains options for the topology check
+type TopologyCheckOptions struct {
+	Namespace string
+	Output    string // table or json
+	FailOn    string // Lowest severity that fails the run: error, warning, info or none
+}
+
+type topologyReport struct {
+	namespace   string
+	name        string
+	replicas    int32
+	zones       int
+	nodes       int
+	constraints []string
+	findings    []result.Finding
+}
+
+func (r *topologyReport) addFinding(check, severity, message string) {
+	r.findings = append(r.findings, result.Finding{
+		Check: check, Severity: severity, Resource: r.namespace + "/" + r.name, Message: message,
+	})
+}
+
+// CheckTopology audits pod anti-affinity and topology spread constraints of
+// every Deployment and reports replicas concentrated in a single zone or node.
+func CheckTopology(options TopologyCheckOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	namespace := options.Namespace
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	ctx := context.TODO()
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get nodes: %w", err)
+	}
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get deployments: %w", err)
+	}
+	replicaSets, err := clientset.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get replicasets: %w", err)
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get pods: %w", err)
+	}
+
+	nodeZones := make(map[string]string)
+	for _, node := range nodes.Items {
+		nodeZones[node.Name] = node.Labels[zoneLabel]
+	}
+
+	rsOwnerCache := make(map[string]string)
+	for _, rs := range replicaSets.Items {
+		for _, owner := range rs.OwnerReferences {
+			if owner.Kind == "Deployment" {
+				rsOwnerCache[rs.Namespace+"/"+rs.Name] = owner.Name
+			}
+		}
+	}
+
+	// Group scheduled pods by their owning deployment
+	podsByDeployment := make(map[string][]corev1.Pod)
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		owner, ownerType := getPodOwnerFast(&pod, rsOwnerCache)
+		if ownerType == "Deployment" {
+			key := pod.Namespace + "/" + owner
+			podsByDeployment[key] = append(podsByDeployment[key], pod)
+		}
+	}
+
+	var reports []topologyReport
+	var findings []result.Finding
+	for _, dep := range deployments.Items {
+		report := evaluateDeploymentTopology(dep, podsByDeployment[dep.Namespace+"/"+dep.Name], nodes.Items, nodeZones)
+		if len(report.findings) > 0 {
+			reports = append(reports, report)
+			findings = append(findings, report.findings...)
+		}
+	}
+
+	if options.Output == "json" {
+		if err := result.New("topology-check", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+	if len(reports) == 0 {
+		fmt.Printf("✅ Checked %d deployments, no topology issues found.\n", len(deployments.Items))
+		return nil
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].namespace != reports[j].namespace {
+			return reports[i].namespace < reports[j].namespace
+		}
+		return reports[i].name < reports[j].name
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tDEPLOYMENT\tREPLICAS\tZONES\tNODES\tCONSTRAINTS\tFINDINGS")
+	for _, report := range reports {
+		constraints := "none"
+		if len(report.constraints) > 0 {
+			constraints = strings.Join(report.constraints, ", ")
+		}
+		var messages []string
+		for _, finding := range report.findings {
+			messages = append(messages, finding.Message)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\t%s\n",
+			report.namespace, report.name, report.replicas, report.zones, report.nodes,
+			constraints, strings.Join(messages, "; "))
+	}
+	w.Flush()
+
+	fmt.Printf("\n⚠️  %d of %d deployments have topology issues.\n", len(reports), len(deployments.Items))
+	return result.Gate(findings, options.FailOn)
+}
+
+func evaluateDeploymentTopology(dep appsv1.Deployment, pods []corev1.Pod, nodes []corev1.Node, nodeZones map[string]string) topologyReport {
+	report := topologyReport{
+		namespace: dep.Namespace,
+		name:      dep.Name,
+	}
+	if dep.Spec.Replicas != nil {
+		report.replicas = *dep.Spec.Replicas
+	}
+
+	zones := make(map[string]bool)
+	nodeNames := make(map[string]bool)
+	for _, pod := range pods {
+		nodeNames[pod.Spec.NodeName] = true
+		if zone := nodeZones[pod.Spec.NodeName]; zone != "" {
+			zones[zone] = true
+		}
+	}
+	report.zones = len(zones)
+	report.nodes = len(nodeNames)
+
+	if len(pods) > 1 {
+		if len(nodeNames) == 1 {
+			report.addFinding("single-node", result.SeverityWarning, "all replicas on a single node")
+		} else if len(zones) == 1 {
+			report.addFinding("single-zone", result.SeverityWarning, "all replicas in a single AZ")
+		}
+	}
+
+	template := dep.Spec.Template
+	eligible := eligibleNodes(template.Spec, nodes)
+	podLabels := labels.Set(template.Labels)
+
+	if affinity := template.Spec.Affinity; affinity != nil && affinity.PodAntiAffinity != nil {
+		for _, term := range affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			report.constraints = append(report.constraints, "required anti-affinity on "+term.TopologyKey)
+			if !selectorMatches(term.LabelSelector, podLabels) {
+				continue
+			}
+			domains := countDomains(eligible, term.TopologyKey)
+			if int(report.replicas) > domains {
+				report.addFinding("anti-affinity-unsatisfiable", result.SeverityError, fmt.Sprintf(
+					"anti-affinity on %s needs %d domains, only %d available", term.TopologyKey, report.replicas, domains))
+			}
+		}
+		for _, term := range affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			report.constraints = append(report.constraints, "preferred anti-affinity on "+term.PodAffinityTerm.TopologyKey)
+		}
+	}
+
+	for _, constraint := range template.Spec.TopologySpreadConstraints {
+		report.constraints = append(report.constraints, fmt.Sprintf("spread on %s (maxSkew %d, %s)",
+			constraint.TopologyKey, constraint.MaxSkew, constraint.WhenUnsatisfiable))
+		if constraint.WhenUnsatisfiable != corev1.DoNotSchedule {
+			continue
+		}
+
+		domains := countDomains(eligible, constraint.TopologyKey)
+		if domains == 0 {
+			report.addFinding("spread-no-domains", result.SeverityError, fmt.Sprintf("no eligible nodes carry label %s", constraint.TopologyKey))
+			continue
+		}
+		// With fewer domains than minDomains the global minimum counts as zero,
+		// so each domain can hold at most maxSkew replicas.
+		if constraint.MinDomains != nil && domains < int(*constraint.MinDomains) {
+			if limit := domains * int(constraint.MaxSkew); int(report.replicas) > limit {
+				report.addFinding("spread-min-domains", result.SeverityError, fmt.Sprintf(
+					"spread on %s can place at most %d replicas (%d of minDomains %d)",
+					constraint.TopologyKey, limit, domains, *constraint.MinDomains))
+			}
+		}
+	}
+
+	return report
+}
+
+// eligibleNodes returns the schedulable nodes matching the pod's nodeSelector
+// whose NoSchedule/NoExecute taints are tolerated.
+func eligibleNodes(podSpec corev1.PodSpec, nodes []corev1.Node) []corev1.Node {
+	var eligible []corev1.Node
+	for _, node := range nodes {
+		if node.Spec.Unschedulable || getNodeReadyStatus(node) != "True" {
+			continue
+		}
+		if !labels.SelectorFromSet(podSpec.NodeSelector).Matches(labels.Set(node.Labels)) {
+			continue
+		}
+		if !toleratesNodeTaints(podSpec.Tolerations, node.Spec.Taints) {
+			continue
+		}
+		eligible = append(eligible, node)
+	}
+	return eligible
+}
+
+func toleratesNodeTaints(tolerations []corev1.Toleration, taints []corev1.Taint) bool {
+	for i := range taints {
+		taint := &taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for j := range tolerations {
+			if tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
+
+func countDomains(nodes []corev1.Node, topologyKey string) int {
+	domains := make(map[string]bool)
+	for _, node := range nodes {
+		if value, ok := node.Labels[topologyKey]; ok {
+			domains[value] = true
+		}
+	}
+	return len(domains)
+}
+
+func selectorMatches(selector *metav1.LabelSelector, podLabels labels.Set) bool {
+	if selector == nil {
+		return false
+	}
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false
+	}
+	return s.Matches(podLabels)
+}
@@ -0,0 +1,358 @@
+package k8s
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	"github.com/HighonAces/swissarmycli/internal/validator"
+	"gopkg.in/yaml.v3"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// ValuesCheckOptions contains options for checking the references of a
+// release before it is deployed
+type ValuesCheckOptions struct {
+	Chart      string   // Chart directory rendered with ValueFiles
+	ValueFiles []string // Values files; scanned on their own when Chart is empty
+	Kustomize  string   // Kustomize overlay directory
+	Namespace  string   // Namespace the release goes to
+	Output     string   // table or json
+	FailOn     string   // Lowest severity that fails the run: error, warning, info or none
+}
+
+// CheckValues renders a Helm chart with its values files, or builds a
+// kustomize overlay, and verifies that every ConfigMap, Secret and PVC the
+// workloads reference exists in the target namespace with the referenced
+// keys. Objects created by the manifests themselves count as existing.
+// Values files without a chart are scanned for the usual reference shapes
+// instead.
+func CheckValues(options ValuesCheckOptions) error {
+	if err := result.ValidateFlags(options.Output, options.FailOn); err != nil {
+		return err
+	}
+	if options.Kustomize != "" && (options.Chart != "" || len(options.ValueFiles) > 0) {
+		return fmt.Errorf("give either --kustomize or a chart and values files, not both")
+	}
+	if options.Kustomize == "" && options.Chart == "" && len(options.ValueFiles) == 0 {
+		return fmt.Errorf("give --chart, --values or --kustomize")
+	}
+	if options.Namespace == "" {
+		options.Namespace = "default"
+	}
+
+	var manifests []byte
+	var source string
+	switch {
+	case options.Kustomize != "":
+		rendered, err := buildKustomization(options.Kustomize)
+		if err != nil {
+			return err
+		}
+		manifests, source = rendered, "kustomize "+options.Kustomize
+	case options.Chart != "":
+		chartName, rendered, err := validator.RenderHelmChart(options.Chart, options.ValueFiles, options.Namespace)
+		if err != nil {
+			return err
+		}
+		for _, name := range sortedKeys(rendered) {
+			manifests = append(manifests, []byte("\n---\n"+rendered[name])...)
+		}
+		source = "chart " + chartName
+	}
+
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	index, err := buildRefIndex(context.TODO(), clientset, options.Namespace)
+	if err != nil {
+		return err
+	}
+
+	var broken []brokenRef
+	var checked int
+	if manifests != nil {
+		workloads, err := indexManifests(manifests, options.Namespace, index)
+		if err != nil {
+			return fmt.Errorf("failed to read manifests of %s: %w", source, err)
+		}
+		for _, workload := range workloads {
+			broken = append(broken, index.check(workload)...)
+		}
+		checked = len(workloads)
+	} else {
+		for _, file := range options.ValueFiles {
+			refs, err := scanValuesFile(file)
+			if err != nil {
+				return err
+			}
+			broken = append(broken, index.checkValuesRefs(options.Namespace, file, refs)...)
+			checked += len(refs)
+		}
+		source = "values " + strings.Join(options.ValueFiles, ", ")
+	}
+
+	findings := brokenRefFindings(broken)
+	if options.Output == "json" {
+		if err := result.New("values-check", findings).WriteJSON(sink.Output()); err != nil {
+			return err
+		}
+		return result.Gate(findings, options.FailOn)
+	}
+
+	if manifests != nil {
+		fmt.Printf("Checked %d workload(s) of %s against namespace %s.\n", checked, source, options.Namespace)
+	} else {
+		fmt.Printf("Checked %d reference(s) in %s against namespace %s.\n", checked, source, options.Namespace)
+	}
+	if len(broken) == 0 {
+		fmt.Println("✅ All references resolve.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WORKLOAD\tREFERENCE\tKEY\tUSED BY\tPROBLEM")
+	for _, ref := range broken {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t❌ %s\n", ref.workload, ref.target, valueOrDash(ref.key), ref.usedBy, ref.problem)
+	}
+	w.Flush()
+
+	fmt.Println("\n--- Values Check Summary ---")
+	fmt.Printf("Broken references: %d\n", len(broken))
+	fmt.Println("These would fail at deploy time with CreateContainerConfigError or a pod stuck in ContainerCreating.")
+	fmt.Println("----------------------------------------------------")
+	return result.Gate(findings, options.FailOn)
+}
+
+// buildKustomization runs kubectl kustomize, or the standalone kustomize
+// binary when kubectl isn't installed, and returns the rendered manifests.
+func buildKustomization(dir string) ([]byte, error) {
+	var cmd *exec.Cmd
+	if _, err := exec.LookPath("kubectl"); err == nil {
+		cmd = exec.Command("kubectl", "kustomize", dir)
+	} else if _, err := exec.LookPath("kustomize"); err == nil {
+		cmd = exec.Command("kustomize", "build", dir)
+	} else {
+		return nil, fmt.Errorf("neither kubectl nor kustomize found in PATH")
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build kustomization '%s': %w: %s", dir, err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+// indexManifests adds the ConfigMaps, Secrets and PVCs defined in the
+// manifests to index, so references to them resolve, and returns the pod
+// specs of the workloads. Objects without a namespace go to namespace.
+func indexManifests(manifests []byte, namespace string, index refIndex) ([]refWorkload, error) {
+	decoder := scheme.Codecs.UniversalDeserializer()
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(manifests)))
+	var workloads []refWorkload
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(string(doc)) == "" {
+			continue
+		}
+		// Custom resources and empty documents can't be decoded and carry no
+		// pod specs
+		obj, _, err := decoder.Decode(doc, nil, nil)
+		if err != nil {
+			continue
+		}
+
+		objectNamespace := func(meta interface{ GetNamespace() string }) string {
+			if meta.GetNamespace() != "" {
+				return meta.GetNamespace()
+			}
+			return namespace
+		}
+		switch o := obj.(type) {
+		case *corev1.ConfigMap:
+			keys := make(map[string]bool)
+			for key := range o.Data {
+				keys[key] = true
+			}
+			for key := range o.BinaryData {
+				keys[key] = true
+			}
+			index.configMaps[objectNamespace(o)+"/"+o.Name] = keys
+		case *corev1.Secret:
+			keys := make(map[string]bool)
+			for key := range o.Data {
+				keys[key] = true
+			}
+			for key := range o.StringData {
+				keys[key] = true
+			}
+			index.secrets[objectNamespace(o)+"/"+o.Name] = keys
+		case *corev1.PersistentVolumeClaim:
+			index.pvcs[objectNamespace(o)+"/"+o.Name] = true
+		case *corev1.Pod:
+			workloads = append(workloads, refWorkload{objectNamespace(o), "Pod/" + o.Name, o.Spec})
+		case *appsv1.Deployment:
+			workloads = append(workloads, refWorkload{objectNamespace(o), "Deployment/" + o.Name, o.Spec.Template.Spec})
+		case *appsv1.StatefulSet:
+			workloads = append(workloads, refWorkload{objectNamespace(o), "StatefulSet/" + o.Name, o.Spec.Template.Spec})
+			// Claims from volumeClaimTemplates are created with the pods
+			for _, claim := range o.Spec.VolumeClaimTemplates {
+				index.pvcs[objectNamespace(o)+"/"+claim.Name] = true
+			}
+		case *appsv1.DaemonSet:
+			workloads = append(workloads, refWorkload{objectNamespace(o), "DaemonSet/" + o.Name, o.Spec.Template.Spec})
+		case *batchv1.Job:
+			workloads = append(workloads, refWorkload{objectNamespace(o), "Job/" + o.Name, o.Spec.Template.Spec})
+		case *batchv1.CronJob:
+			workloads = append(workloads, refWorkload{objectNamespace(o), "CronJob/" + o.Name, o.Spec.JobTemplate.Spec.Template.Spec})
+		}
+	}
+	return workloads, nil
+}
+
+// valuesRef is a reference to a ConfigMap or Secret found in a values file
+type valuesRef struct {
+	path string // Dotted path of the reference in the values
+	kind string // ConfigMap or Secret
+	name string
+	key  string // Empty when the whole object is referenced
+}
+
+// scanValuesFile finds references in a values file without rendering the
+// chart, by the shapes charts commonly pass through to pod specs:
+// secretKeyRef and configMapKeyRef maps, secretRef and configMapRef maps of
+// envFrom, and existingSecret / existingConfigMap names with sibling
+// settings ending in Key naming keys of that Secret (as in
+// existingSecretPasswordKey).
+func scanValuesFile(file string) ([]valuesRef, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values file '%s': %w", file, err)
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return nil, fmt.Errorf("invalid values file '%s': %w", file, err)
+	}
+	var refs []valuesRef
+	scanValues(values, "", &refs)
+	sort.SliceStable(refs, func(i, j int) bool { return refs[i].path < refs[j].path })
+	return refs, nil
+}
+
+func scanValues(value interface{}, path string, refs *[]valuesRef) {
+	switch v := value.(type) {
+	case []interface{}:
+		for i, item := range v {
+			scanValues(item, fmt.Sprintf("%s[%d]", path, i), refs)
+		}
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := strings.TrimPrefix(path+"."+key, ".")
+			childMap, _ := child.(map[string]interface{})
+			switch key {
+			case "secretKeyRef", "configMapKeyRef":
+				kind := "Secret"
+				if key == "configMapKeyRef" {
+					kind = "ConfigMap"
+				}
+				if name, ok := childMap["name"].(string); ok && !isTemplated(name) {
+					ref := valuesRef{path: childPath, kind: kind, name: name}
+					if optional, _ := childMap["optional"].(bool); optional {
+						continue
+					}
+					if k, ok := childMap["key"].(string); ok && !isTemplated(k) {
+						ref.key = k
+					}
+					*refs = append(*refs, ref)
+				}
+				continue
+			case "secretRef", "configMapRef":
+				kind := "Secret"
+				if key == "configMapRef" {
+					kind = "ConfigMap"
+				}
+				optional, _ := childMap["optional"].(bool)
+				if name, ok := childMap["name"].(string); ok && !isTemplated(name) && !optional {
+					*refs = append(*refs, valuesRef{path: childPath, kind: kind, name: name})
+				}
+				continue
+			}
+
+			name, isString := child.(string)
+			if !isString || name == "" || isTemplated(name) {
+				scanValues(child, childPath, refs)
+				continue
+			}
+			lower := strings.ToLower(key)
+			var kind string
+			switch {
+			case strings.HasPrefix(lower, "existingsecret") && !strings.HasSuffix(lower, "key"):
+				kind = "Secret"
+			case strings.HasPrefix(lower, "existingconfigmap") && !strings.HasSuffix(lower, "key"):
+				kind = "ConfigMap"
+			default:
+				continue
+			}
+			*refs = append(*refs, valuesRef{path: childPath, kind: kind, name: name})
+			// Sibling settings like existingSecretPasswordKey: userPassword
+			// name keys of the same object
+			for sibling, siblingValue := range v {
+				siblingLower := strings.ToLower(sibling)
+				keyName, ok := siblingValue.(string)
+				if !ok || keyName == "" || isTemplated(keyName) || !strings.HasPrefix(siblingLower, lower) || !strings.HasSuffix(siblingLower, "key") {
+					continue
+				}
+				*refs = append(*refs, valuesRef{path: strings.TrimPrefix(path+"."+sibling, "."), kind: kind, name: name, key: keyName})
+			}
+		}
+	}
+}
+
+// isTemplated reports whether a value is filled in by a template, so it
+// can't be checked before rendering.
+func isTemplated(value string) bool {
+	return strings.Contains(value, "{{")
+}
+
+// checkValuesRefs checks references found in a values file, reported under
+// the file instead of a workload.
+func (idx refIndex) checkValuesRefs(namespace, file string, refs []valuesRef) []brokenRef {
+	var broken []brokenRef
+	for _, ref := range refs {
+		keys, exists := idx.configMaps[namespace+"/"+ref.name]
+		if ref.kind == "Secret" {
+			keys, exists = idx.secrets[namespace+"/"+ref.name]
+		}
+		target := ref.kind + "/" + ref.name
+		switch {
+		case !exists:
+			broken = append(broken, brokenRef{namespace, file, target, ref.key, ref.path, ref.kind + " not found"})
+		case ref.key != "" && !keys[ref.key]:
+			broken = append(broken, brokenRef{namespace, file, target, ref.key, ref.path, "key not found"})
+		}
+	}
+	return broken
+}
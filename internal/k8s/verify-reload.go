@@ -0,0 +1,417 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// kubeletSyncGrace is how long the kubelet may take to update a mounted
+// config map or secret: its sync period plus the cache TTL
+const kubeletSyncGrace = 2 * time.Minute
+
+// Reload statuses of a container, from worst to best
+const (
+	reloadStale      = "stale"
+	reloadPending    = "sync pending"
+	reloadUnverified = "unverified"
+	reloadCurrent    = "current"
+)
+
+var reloadStatusRank = map[string]int{reloadStale: 3, reloadPending: 2, reloadUnverified: 1, reloadCurrent: 0}
+
+// VerifyReloadOptions contains options for the config reload verification
+type VerifyReloadOptions struct {
+	Namespace string
+	ConfigMap string
+	Secret    string
+	Exec      bool // Read the mounted files in the containers to compare them
+}
+
+// configSource is the config map or secret being verified
+type configSource struct {
+	kind    string // ConfigMap or Secret
+	name    string
+	data    map[string][]byte
+	updated time.Time
+}
+
+// configMount is a volume of the source mounted in a container
+type configMount struct {
+	path    string
+	subPath string
+	files   map[string]string // Key to file path relative to the volume
+}
+
+// configUse is how one container of the pod template consumes the source
+type configUse struct {
+	container string
+	env       bool // Through env or envFrom, read once at start
+	mounts    []configMount
+}
+
+// VerifyReload checks whether the running pods of a deployment have picked
+// up the current content of a config map or secret. Values consumed as
+// environment variables or through subPath mounts are fixed when the
+// container starts, so containers started before the last write are stale.
+// Regular mounts are updated by the kubelet lazily, so with Exec the mounted
+// files are read and compared with the current data. Checksum annotations
+// of the pod template, as Helm charts set them, are compared as well.
+func VerifyReload(deploymentName string, options VerifyReloadOptions) error {
+	if (options.ConfigMap == "") == (options.Secret == "") {
+		return fmt.Errorf("exactly one of --configmap or --secret is required")
+	}
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+	deployment, err := clientset.AppsV1().Deployments(options.Namespace).Get(ctx, deploymentName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get deployment %s/%s: %w", options.Namespace, deploymentName, err)
+	}
+	source, err := getConfigSource(ctx, clientset, options)
+	if err != nil {
+		return err
+	}
+	uses := findConfigUses(deployment.Spec.Template.Spec, source)
+	if len(uses) == 0 {
+		return fmt.Errorf("deployment %s doesn't use %s %s", deploymentName, source.kind, source.name)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return fmt.Errorf("invalid selector of deployment %s: %w", deploymentName, err)
+	}
+	pods, err := clientset.CoreV1().Pods(options.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	var config *rest.Config
+	if options.Exec {
+		if config, err = common.GetRESTConfig(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Deployment: %s/%s, %s %s last written %s (%s ago)\n", options.Namespace, deploymentName, source.kind, source.name,
+		source.updated.Local().Format("2006-01-02 15:04:05"), time.Since(source.updated).Round(time.Second))
+	var consumed []string
+	for _, use := range uses {
+		if use.env {
+			consumed = append(consumed, fmt.Sprintf("env (%s)", use.container))
+		}
+		for _, mount := range use.mounts {
+			if mount.subPath != "" {
+				consumed = append(consumed, fmt.Sprintf("subPath %s (%s)", mount.path, use.container))
+			} else {
+				consumed = append(consumed, fmt.Sprintf("files in %s (%s)", mount.path, use.container))
+			}
+		}
+	}
+	fmt.Printf("Consumed as: %s\n\n", strings.Join(consumed, ", "))
+
+	checksums := make(map[string]string)
+	for key, value := range deployment.Spec.Template.Annotations {
+		if strings.Contains(key, "checksum") {
+			checksums[key] = value
+		}
+	}
+
+	counts := make(map[string]int)
+	var stalePods []string
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "POD\tCONTAINER\tSTARTED\tSTATUS\tDETAIL")
+	sort.Slice(pods.Items, func(i, j int) bool { return pods.Items[i].Name < pods.Items[j].Name })
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		podStatus := reloadCurrent
+		for _, key := range sortedKeys(checksums) {
+			if pod.Annotations[key] != checksums[key] {
+				podStatus = reloadStale
+				fmt.Fprintf(w, "%s\t-\t-\t❌ %s\tannotation %s is from an older pod template\n", pod.Name, reloadStale, key)
+			}
+		}
+		for _, use := range uses {
+			started := containerStartTime(pod, use.container)
+			if started.IsZero() {
+				fmt.Fprintf(w, "%s\t%s\t-\t-\tnot running\n", pod.Name, use.container)
+				continue
+			}
+			status, detail := verifyContainerReload(clientset, config, pod, use, source, started)
+			if reloadStatusRank[status] > reloadStatusRank[podStatus] {
+				podStatus = status
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s ago\t%s\t%s\n", pod.Name, use.container, time.Since(started).Round(time.Second),
+				reloadStatusLabel(status), detail)
+		}
+		counts[podStatus]++
+		if podStatus == reloadStale {
+			stalePods = append(stalePods, pod.Name)
+		}
+	}
+	w.Flush()
+
+	total := counts[reloadCurrent] + counts[reloadStale] + counts[reloadPending] + counts[reloadUnverified]
+	fmt.Println("\n--- Reload Summary ---")
+	fmt.Printf("Running pods: %d, current: %d, stale: %d, sync pending: %d, unverified: %d\n", total,
+		counts[reloadCurrent], counts[reloadStale], counts[reloadPending], counts[reloadUnverified])
+	switch {
+	case len(stalePods) > 0:
+		fmt.Printf("❌ %d pods run with old %s content: %s\n", len(stalePods), source.kind, truncateList(stalePods, 10))
+		fmt.Printf("   Restart them with: swissarmycli restart %s -n %s\n", deploymentName, options.Namespace)
+	case counts[reloadPending] > 0:
+		fmt.Printf("⚠️  The kubelet has not updated every mounted copy yet; check again in %s\n", kubeletSyncGrace)
+	case counts[reloadUnverified] > 0:
+		fmt.Println("⚠️  Some mounted files could not be read; their content is unverified")
+	default:
+		fmt.Printf("✅ Every running pod sees the current %s content\n", source.kind)
+	}
+	for _, use := range uses {
+		if hasRegularMount(use.mounts) {
+			fmt.Println("Note: updated files only take effect if the application watches them or is signalled to reload")
+			break
+		}
+	}
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// getConfigSource reads the current data and last write time of the config
+// map or secret.
+func getConfigSource(ctx context.Context, clientset *kubernetes.Clientset, options VerifyReloadOptions) (*configSource, error) {
+	if options.ConfigMap != "" {
+		configMap, err := clientset.CoreV1().ConfigMaps(options.Namespace).Get(ctx, options.ConfigMap, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get config map %s/%s: %w", options.Namespace, options.ConfigMap, err)
+		}
+		source := &configSource{kind: "ConfigMap", name: configMap.Name, data: make(map[string][]byte), updated: objectLastUpdated(configMap.ObjectMeta)}
+		for key, value := range configMap.Data {
+			source.data[key] = []byte(value)
+		}
+		for key, value := range configMap.BinaryData {
+			source.data[key] = value
+		}
+		return source, nil
+	}
+	secret, err := clientset.CoreV1().Secrets(options.Namespace).Get(ctx, options.Secret, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s/%s: %w", options.Namespace, options.Secret, err)
+	}
+	return &configSource{kind: "Secret", name: secret.Name, data: secret.Data, updated: objectLastUpdated(secret.ObjectMeta)}, nil
+}
+
+// findConfigUses lists, per container of the pod spec, how it consumes the
+// source. Init containers are skipped, they only run at start.
+func findConfigUses(spec corev1.PodSpec, source *configSource) []configUse {
+	volumes := make(map[string]map[string]string) // Volume name to its files
+	for _, volume := range spec.Volumes {
+		var files map[string]string
+		switch {
+		case volume.ConfigMap != nil && source.kind == "ConfigMap" && volume.ConfigMap.Name == source.name:
+			files = configVolumeFiles(volume.ConfigMap.Items, source)
+		case volume.Secret != nil && source.kind == "Secret" && volume.Secret.SecretName == source.name:
+			files = configVolumeFiles(volume.Secret.Items, source)
+		case volume.Projected != nil:
+			for _, projection := range volume.Projected.Sources {
+				var projected map[string]string
+				switch {
+				case projection.ConfigMap != nil && source.kind == "ConfigMap" && projection.ConfigMap.Name == source.name:
+					projected = configVolumeFiles(projection.ConfigMap.Items, source)
+				case projection.Secret != nil && source.kind == "Secret" && projection.Secret.Name == source.name:
+					projected = configVolumeFiles(projection.Secret.Items, source)
+				default:
+					continue
+				}
+				if files == nil {
+					files = make(map[string]string)
+				}
+				for key, file := range projected {
+					files[key] = file
+				}
+			}
+		}
+		if files != nil {
+			volumes[volume.Name] = files
+		}
+	}
+
+	var uses []configUse
+	for _, container := range spec.Containers {
+		use := configUse{container: container.Name}
+		for _, env := range container.Env {
+			if from := env.ValueFrom; from != nil {
+				use.env = use.env ||
+					(source.kind == "ConfigMap" && from.ConfigMapKeyRef != nil && from.ConfigMapKeyRef.Name == source.name) ||
+					(source.kind == "Secret" && from.SecretKeyRef != nil && from.SecretKeyRef.Name == source.name)
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			use.env = use.env ||
+				(source.kind == "ConfigMap" && envFrom.ConfigMapRef != nil && envFrom.ConfigMapRef.Name == source.name) ||
+				(source.kind == "Secret" && envFrom.SecretRef != nil && envFrom.SecretRef.Name == source.name)
+		}
+		for _, mount := range container.VolumeMounts {
+			if files, ok := volumes[mount.Name]; ok {
+				use.mounts = append(use.mounts, configMount{path: mount.MountPath, subPath: mount.SubPath, files: files})
+			}
+		}
+		if use.env || len(use.mounts) > 0 {
+			uses = append(uses, use)
+		}
+	}
+	return uses
+}
+
+// configVolumeFiles maps the keys a volume projects to their file paths:
+// the listed items, or every key under its own name.
+func configVolumeFiles(items []corev1.KeyToPath, source *configSource) map[string]string {
+	files := make(map[string]string)
+	if len(items) > 0 {
+		for _, item := range items {
+			files[item.Key] = item.Path
+		}
+		return files
+	}
+	for key := range source.data {
+		files[key] = key
+	}
+	return files
+}
+
+// verifyContainerReload decides whether one container sees the current
+// content and explains why not.
+func verifyContainerReload(clientset *kubernetes.Clientset, config *rest.Config, pod *corev1.Pod, use configUse, source *configSource, started time.Time) (string, string) {
+	startedBefore := started.Before(source.updated)
+	if use.env && startedBefore {
+		return reloadStale, "environment variables are read at start, before the last write"
+	}
+	status, detail := reloadCurrent, ""
+	for _, mount := range use.mounts {
+		if mount.subPath != "" {
+			if startedBefore {
+				return reloadStale, fmt.Sprintf("subPath mount %s is never updated", mount.path)
+			}
+			continue
+		}
+		if config == nil {
+			if time.Since(source.updated) < kubeletSyncGrace {
+				status, detail = reloadPending, fmt.Sprintf("written less than %s ago", kubeletSyncGrace)
+			}
+			continue
+		}
+		differing, err := compareMountedFiles(clientset, config, pod, use.container, mount, source)
+		switch {
+		case err != nil:
+			if reloadStatusRank[reloadUnverified] > reloadStatusRank[status] {
+				status, detail = reloadUnverified, fmt.Sprintf("could not read %s: %v", mount.path, err)
+			}
+		case len(differing) > 0 && time.Since(source.updated) < kubeletSyncGrace:
+			if reloadStatusRank[reloadPending] > reloadStatusRank[status] {
+				status, detail = reloadPending, fmt.Sprintf("%d files in %s not updated yet", len(differing), mount.path)
+			}
+		case len(differing) > 0:
+			return reloadStale, fmt.Sprintf("%s differ from the %s", truncateList(differing, 3), source.kind)
+		}
+	}
+	if status == reloadCurrent && len(use.mounts) > 0 && config != nil {
+		detail = "mounted files match"
+	}
+	return status, detail
+}
+
+// compareMountedFiles reads every file the mount projects in the container
+// and returns the paths whose content differs from the source.
+func compareMountedFiles(clientset *kubernetes.Clientset, config *rest.Config, pod *corev1.Pod, container string, mount configMount, source *configSource) ([]string, error) {
+	var differing []string
+	for _, key := range sortedKeys(mount.files) {
+		expected, ok := source.data[key]
+		if !ok {
+			continue // Optional keys missing from the source aren't projected
+		}
+		file := path.Join(mount.path, mount.files[key])
+		content, err := execInContainer(clientset, config, pod, container, []string{"cat", file})
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(content, expected) {
+			differing = append(differing, file)
+		}
+	}
+	return differing, nil
+}
+
+// execInContainer runs a command in a container and returns its stdout.
+func execInContainer(clientset *kubernetes.Clientset, config *rest.Config, pod *corev1.Pod, container string, command []string) ([]byte, error) {
+	request := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(config, "POST", request.URL())
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	if err := executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: &stdout, Stderr: &stderr}); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%s", message)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// containerStartTime returns when the running container last started, zero
+// when it isn't running.
+func containerStartTime(pod *corev1.Pod, container string) time.Time {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == container && status.State.Running != nil {
+			return status.State.Running.StartedAt.Time
+		}
+	}
+	return time.Time{}
+}
+
+func hasRegularMount(mounts []configMount) bool {
+	for _, mount := range mounts {
+		if mount.subPath == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func reloadStatusLabel(status string) string {
+	switch status {
+	case reloadStale:
+		return "❌ " + status
+	case reloadPending, reloadUnverified:
+		return "⚠️  " + status
+	}
+	return "✅ " + status
+}
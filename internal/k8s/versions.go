@@ -0,0 +1,370 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// VersionsOptions contains options for the image version matrix
+type VersionsOptions struct {
+	Namespace string   // All namespaces when empty
+	Contexts  []string // Kubeconfig context patterns to fan out over, the current context when empty
+	Container string   // Only report this container
+}
+
+// versionRow is the image one container of a workload runs in one
+// environment
+type versionRow struct {
+	context    string
+	namespace  string
+	workload   string // Kind/name
+	container  string
+	tag        string
+	ready      string
+	rollingOut bool
+	updated    time.Time // Last rollout of a Deployment, zero for other kinds
+}
+
+// ShowVersions reports which image tag each namespace, and with Contexts
+// each cluster, runs for an app, and flags the environments behind the
+// newest tag. The app is matched by its app.kubernetes.io/name or app label,
+// or given as a full label selector. Tags are ordered as versions when they
+// all parse as one, and otherwise by when each Deployment last rolled out.
+func ShowVersions(app string, options VersionsOptions) error {
+	selectors := []string{"app.kubernetes.io/name=" + app, "app=" + app}
+	if strings.ContainsAny(app, "=!,") {
+		selectors = []string{app}
+	}
+
+	contexts := []string{""}
+	if len(options.Contexts) > 0 {
+		all, err := common.ListContexts()
+		if err != nil {
+			return err
+		}
+		contexts = nil
+		for _, name := range all {
+			if _, ok := matchContextPattern(options.Contexts, name); ok {
+				contexts = append(contexts, name)
+			}
+		}
+		if len(contexts) == 0 {
+			return fmt.Errorf("no kubeconfig context matches %s", strings.Join(options.Contexts, ", "))
+		}
+		fmt.Printf("Checking %d contexts...\n", len(contexts))
+	}
+
+	results := make([][]versionRow, len(contexts))
+	errs := make([]error, len(contexts))
+	var wg sync.WaitGroup
+	for i, contextName := range contexts {
+		wg.Add(1)
+		go func(i int, contextName string) {
+			defer wg.Done()
+			results[i], errs[i] = collectVersionRows(contextName, selectors, options)
+		}(i, contextName)
+	}
+	wg.Wait()
+
+	var rows []versionRow
+	failed := 0
+	for i := range contexts {
+		if errs[i] != nil {
+			failed++
+			continue
+		}
+		rows = append(rows, results[i]...)
+	}
+	if failed == len(contexts) {
+		return errs[0]
+	}
+	for i, contextName := range contexts {
+		if errs[i] != nil {
+			fmt.Printf("❌ %s: %v\n", contextName, errs[i])
+		}
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("no Deployments, StatefulSets or DaemonSets match %s", strings.Join(selectors, " or "))
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.context != b.context {
+			return a.context < b.context
+		}
+		if a.namespace != b.namespace {
+			return a.namespace < b.namespace
+		}
+		if a.workload != b.workload {
+			return a.workload < b.workload
+		}
+		return a.container < b.container
+	})
+
+	byContainer := make(map[string][]versionRow)
+	for _, row := range rows {
+		byContainer[row.container] = append(byContainer[row.container], row)
+	}
+	latest := make(map[string]string)
+	for container, containerRows := range byContainer {
+		latest[container] = latestVersionTag(containerRows)
+	}
+
+	fanOut := len(options.Contexts) > 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if fanOut {
+		fmt.Fprint(w, "CONTEXT\t")
+	}
+	fmt.Fprintln(w, "NAMESPACE\tWORKLOAD\tCONTAINER\tTAG\tREADY\tSTATUS")
+	for _, row := range rows {
+		status := "-"
+		switch newest := latest[row.container]; {
+		case newest == "":
+		case row.tag == newest:
+			status = "✅ latest"
+		default:
+			status = "⚠️  behind " + newest
+		}
+		if row.rollingOut {
+			status += " (rolling out)"
+		}
+		if fanOut {
+			fmt.Fprintf(w, "%s\t", row.context)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", row.namespace, row.workload, row.container, row.tag, row.ready, status)
+	}
+	w.Flush()
+
+	fmt.Println("\n--- Versions Summary ---")
+	for _, container := range sortedKeys(byContainer) {
+		containerRows := byContainer[container]
+		newest := latest[container]
+		behind := 0
+		for _, row := range containerRows {
+			if newest != "" && row.tag != newest {
+				behind++
+			}
+		}
+		switch {
+		case newest == "":
+			fmt.Printf("%s: %d different tags, can't tell which is newest\n", container, len(distinctTags(containerRows)))
+		case behind == 0:
+			fmt.Printf("✅ %s: all %d environments run %s\n", container, len(containerRows), newest)
+		default:
+			fmt.Printf("⚠️  %s: %d of %d environments behind %s\n", container, behind, len(containerRows), newest)
+		}
+	}
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// collectVersionRows lists the workloads matching any of the selectors in
+// one context, the current one when contextName is empty.
+func collectVersionRows(contextName string, selectors []string, options VersionsOptions) ([]versionRow, error) {
+	var clientset *kubernetes.Clientset
+	var err error
+	if contextName == "" {
+		clientset, err = common.GetKubernetesClient()
+		if current, _, currentErr := common.CurrentContext(); currentErr == nil {
+			contextName = current
+		}
+	} else {
+		clientset, err = common.GetKubernetesClientForContext(contextName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var rows []versionRow
+	seen := make(map[string]bool)
+	add := func(namespace, workload string, spec corev1.PodSpec, ready string, rollingOut bool, updated time.Time) {
+		if seen[namespace+"/"+workload] {
+			return
+		}
+		seen[namespace+"/"+workload] = true
+		for _, container := range spec.Containers {
+			if options.Container != "" && container.Name != options.Container {
+				continue
+			}
+			rows = append(rows, versionRow{
+				context:    contextName,
+				namespace:  namespace,
+				workload:   workload,
+				container:  container.Name,
+				tag:        imageTag(container.Image),
+				ready:      ready,
+				rollingOut: rollingOut,
+				updated:    updated,
+			})
+		}
+	}
+
+	for _, selector := range selectors {
+		listOptions := metav1.ListOptions{LabelSelector: selector}
+		deployments, err := clientset.AppsV1().Deployments(options.Namespace).List(ctx, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list deployments: %w", err)
+		}
+		for _, deployment := range deployments.Items {
+			replicas := replicasOrOne(deployment.Spec.Replicas)
+			rollingOut := deployment.Status.UpdatedReplicas < replicas || deployment.Status.ObservedGeneration < deployment.Generation
+			add(deployment.Namespace, "Deployment/"+deployment.Name, deployment.Spec.Template.Spec,
+				fmt.Sprintf("%d/%d", deployment.Status.ReadyReplicas, replicas), rollingOut, deploymentRolloutTime(deployment))
+		}
+		statefulSets, err := clientset.AppsV1().StatefulSets(options.Namespace).List(ctx, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+		}
+		for _, statefulSet := range statefulSets.Items {
+			replicas := replicasOrOne(statefulSet.Spec.Replicas)
+			rollingOut := statefulSet.Status.UpdateRevision != "" && statefulSet.Status.UpdateRevision != statefulSet.Status.CurrentRevision
+			add(statefulSet.Namespace, "StatefulSet/"+statefulSet.Name, statefulSet.Spec.Template.Spec,
+				fmt.Sprintf("%d/%d", statefulSet.Status.ReadyReplicas, replicas), rollingOut, time.Time{})
+		}
+		daemonSets, err := clientset.AppsV1().DaemonSets(options.Namespace).List(ctx, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+		}
+		for _, daemonSet := range daemonSets.Items {
+			rollingOut := daemonSet.Status.UpdatedNumberScheduled < daemonSet.Status.DesiredNumberScheduled
+			add(daemonSet.Namespace, "DaemonSet/"+daemonSet.Name, daemonSet.Spec.Template.Spec,
+				fmt.Sprintf("%d/%d", daemonSet.Status.NumberReady, daemonSet.Status.DesiredNumberScheduled), rollingOut, time.Time{})
+		}
+	}
+	return rows, nil
+}
+
+func replicasOrOne(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+// deploymentRolloutTime is when the Deployment last got a new ReplicaSet, as
+// recorded by its Progressing condition.
+func deploymentRolloutTime(deployment appsv1.Deployment) time.Time {
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing {
+			return condition.LastUpdateTime.Time
+		}
+	}
+	return time.Time{}
+}
+
+// imageTag returns the tag of an image reference, the start of its digest
+// when it is pinned by digest only, or latest when it has neither.
+func imageTag(image string) string {
+	digest := ""
+	if i := strings.Index(image, "@"); i >= 0 {
+		image, digest = image[:i], strings.TrimPrefix(image[i+1:], "sha256:")
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	if digest != "" {
+		return "@" + digest[:min(12, len(digest))]
+	}
+	return "latest"
+}
+
+// latestVersionTag returns the newest tag of the rows: the highest version
+// when every tag parses as one, otherwise the tag of the most recently
+// rolled out Deployment. It is empty when neither can tell.
+func latestVersionTag(rows []versionRow) string {
+	tags := distinctTags(rows)
+	if len(tags) == 1 {
+		return tags[0]
+	}
+	newest, versions := "", true
+	for _, tag := range tags {
+		if _, _, ok := parseVersionTag(tag); !ok {
+			versions = false
+			break
+		}
+		if newest == "" || compareVersionTags(tag, newest) > 0 {
+			newest = tag
+		}
+	}
+	if versions {
+		return newest
+	}
+
+	newest = ""
+	var newestTime time.Time
+	for _, row := range rows {
+		if row.updated.After(newestTime) {
+			newest, newestTime = row.tag, row.updated
+		}
+	}
+	return newest
+}
+
+func distinctTags(rows []versionRow) []string {
+	set := make(map[string]bool)
+	for _, row := range rows {
+		set[row.tag] = true
+	}
+	return sortedKeys(set)
+}
+
+// parseVersionTag splits tags such as v1.4.2, 1.4 or 2.0.0-rc.1 into their
+// numeric parts and pre-release suffix.
+func parseVersionTag(tag string) ([]int, string, bool) {
+	core := strings.TrimPrefix(strings.TrimPrefix(tag, "v"), "V")
+	prerelease := ""
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core, prerelease = core[:i], core[i+1:]
+	}
+	var parts []int
+	for _, field := range strings.Split(core, ".") {
+		number, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, "", false
+		}
+		parts = append(parts, number)
+	}
+	return parts, prerelease, true
+}
+
+// compareVersionTags orders two tags accepted by parseVersionTag; a release
+// is newer than its pre-releases.
+func compareVersionTags(a, b string) int {
+	partsA, preA, _ := parseVersionTag(a)
+	partsB, preB, _ := parseVersionTag(b)
+	for i := 0; i < max(len(partsA), len(partsB)); i++ {
+		var x, y int
+		if i < len(partsA) {
+			x = partsA[i]
+		}
+		if i < len(partsB) {
+			y = partsB[i]
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	return strings.Compare(preA, preB)
+}
@@ -0,0 +1,120 @@
+package providerid
+
+import (
+	"strings"
+)
+
+// Providers a node can run on
+const (
+	AWS       = "aws"
+	GCE       = "gce"
+	Azure     = "azure"
+	BareMetal = "baremetal"
+)
+
+// Node is what a node's spec.providerID tells about the machine behind it
+type Node struct {
+	Provider      string // AWS, GCE, Azure, BareMetal, or the scheme of another provider
+	InstanceID    string // EC2 instance ID, GCE instance name or Azure VM name; empty on bare metal
+	Zone          string // Empty when the provider ID doesn't carry it, as on Azure
+	Region        string
+	Project       string // GCE only
+	ResourceGroup string // Azure only
+	ScaleSet      string // Azure only, when the VM is a scale set instance
+	ResourceID    string // Azure only, the VM's full resource ID
+}
+
+// Parser understands the provider IDs of one cloud provider
+type Parser interface {
+	// Scheme is the part of the provider ID before "://"
+	Scheme() string
+	// Parse reads a provider ID with the parser's scheme.
+	Parse(providerID string) Node
+}
+
+var parsers = map[string]Parser{}
+
+// Register adds a parser, replacing any registered for the same scheme.
+func Register(parser Parser) {
+	parsers[parser.Scheme()] = parser
+}
+
+func init() {
+	Register(awsParser{})
+	Register(gceParser{})
+	Register(azureParser{})
+}
+
+// Parse reads a node's provider ID with the parser registered for its
+// scheme. Nodes without a provider ID are bare metal, and unknown schemes
+// are returned as the provider with nothing else filled in.
+func Parse(providerID string) Node {
+	scheme, _, found := strings.Cut(providerID, "://")
+	if !found || scheme == "" {
+		return Node{Provider: BareMetal}
+	}
+	if parser, ok := parsers[scheme]; ok {
+		return parser.Parse(providerID)
+	}
+	return Node{Provider: scheme}
+}
+
+// awsParser reads aws:///us-west-2a/i-1234567890abcdef0
+type awsParser struct{}
+
+func (awsParser) Scheme() string { return "aws" }
+
+func (awsParser) Parse(providerID string) Node {
+	node := Node{Provider: AWS}
+	parts := strings.Split(providerID, "/")
+	if len(parts) >= 4 && len(parts[3]) > 1 {
+		node.Zone = parts[3]
+		node.Region = parts[3][:len(parts[3])-1] // Remove AZ suffix
+	}
+	if len(parts) >= 5 {
+		node.InstanceID = parts[4]
+	}
+	return node
+}
+
+// gceParser reads gce://my-project/us-central1-a/gke-pool-1234
+type gceParser struct{}
+
+func (gceParser) Scheme() string { return "gce" }
+
+func (gceParser) Parse(providerID string) Node {
+	node := Node{Provider: GCE}
+	parts := strings.Split(strings.TrimPrefix(providerID, "gce://"), "/")
+	if len(parts) != 3 {
+		return node
+	}
+	node.Project, node.Zone, node.InstanceID = parts[0], parts[1], parts[2]
+	if i := strings.LastIndex(node.Zone, "-"); i > 0 {
+		node.Region = node.Zone[:i]
+	}
+	return node
+}
+
+// azureParser reads
+// azure:///subscriptions/<id>/resourceGroups/<group>/providers/Microsoft.Compute/virtualMachines/<vm>
+// and the scale set form ending in
+// virtualMachineScaleSets/<scale set>/virtualMachines/<instance>
+type azureParser struct{}
+
+func (azureParser) Scheme() string { return "azure" }
+
+func (azureParser) Parse(providerID string) Node {
+	node := Node{Provider: Azure, ResourceID: strings.TrimPrefix(providerID, "azure://")}
+	parts := strings.Split(strings.Trim(node.ResourceID, "/"), "/")
+	for i := 0; i+1 < len(parts); i++ {
+		switch strings.ToLower(parts[i]) {
+		case "resourcegroups":
+			node.ResourceGroup = parts[i+1]
+		case "virtualmachinescalesets":
+			node.ScaleSet = parts[i+1]
+		case "virtualmachines":
+			node.InstanceID = parts[i+1]
+		}
+	}
+	return node
+}
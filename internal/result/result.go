@@ -0,0 +1,186 @@
+package result
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/HighonAces/swissarmycli/internal/apicalls"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+)
+
+// Exit codes shared by every command that reports findings
+const (
+	ExitOK       = 0
+	ExitError    = 1 // The command could not run
+	ExitFindings = 2 // The command ran and found problems at or above --fail-on
+)
+
+// Severities, from most to least severe
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Result statuses
+const (
+	StatusOK       = "ok"
+	StatusFindings = "findings"
+	StatusError    = "error"
+)
+
+var severityRank = map[string]int{SeverityError: 3, SeverityWarning: 2, SeverityInfo: 1}
+
+// Finding is a single problem reported by a command
+type Finding struct {
+	Check    string            `json:"check"`
+	Severity string            `json:"severity"`
+	Resource string            `json:"resource"`
+	Message  string            `json:"message"`
+	Details  map[string]string `json:"details,omitempty"`
+}
+
+// Result is the machine-readable output of a command run with --output json
+type Result struct {
+	Command  string         `json:"command"`
+	Status   string         `json:"status"`
+	Summary  map[string]int `json:"summary"` // Number of findings per severity
+	Findings []Finding      `json:"findings"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// FindingsError is returned when findings reach the --fail-on threshold. It
+// makes the command exit with ExitFindings instead of ExitError.
+type FindingsError struct {
+	Count     int
+	Threshold string
+}
+
+func (e *FindingsError) Error() string {
+	return fmt.Sprintf("%d finding(s) at or above %s severity", e.Count, e.Threshold)
+}
+
+// New builds the result of a successful run.
+func New(command string, findings []Finding) *Result {
+	r := &Result{
+		Command:  command,
+		Status:   StatusOK,
+		Summary:  map[string]int{SeverityError: 0, SeverityWarning: 0, SeverityInfo: 0},
+		Findings: findings,
+	}
+	if r.Findings == nil {
+		r.Findings = []Finding{}
+	}
+	for _, finding := range findings {
+		r.Summary[finding.Severity]++
+	}
+	if len(findings) > 0 {
+		r.Status = StatusFindings
+	}
+	return r
+}
+
+// WriteJSON writes the result as indented JSON.
+func (r *Result) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// Rank orders severities; unknown severities rank 0.
+func Rank(severity string) int {
+	return severityRank[severity]
+}
+
+// Label renders a severity for table output.
+func Label(severity string) string {
+	switch severity {
+	case SeverityError:
+		return "❌ error"
+	case SeverityWarning:
+		return "⚠️  warning"
+	default:
+		return "ℹ️  info"
+	}
+}
+
+// ValidateThreshold checks a --fail-on value.
+func ValidateThreshold(threshold string) error {
+	if threshold == "none" || Rank(threshold) > 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid --fail-on '%s' (must be error, warning, info or none)", threshold)
+}
+
+// ValidateFlags checks the --output and --fail-on values of a command that
+// supports table and json output.
+func ValidateFlags(output, threshold string) error {
+	if output != "" && output != "table" && output != "json" {
+		return fmt.Errorf("unsupported output format '%s' (must be table or json)", output)
+	}
+	return ValidateThreshold(threshold)
+}
+
+// Gate returns a FindingsError when any finding is at or above threshold.
+func Gate(findings []Finding, threshold string) error {
+	if threshold == "" || threshold == "none" {
+		return nil
+	}
+	count := 0
+	for _, finding := range findings {
+		if Rank(finding.Severity) >= Rank(threshold) {
+			count++
+		}
+	}
+	if count > 0 {
+		return &FindingsError{Count: count, Threshold: threshold}
+	}
+	return nil
+}
+
+// Exit reports err and exits with the code matching its kind. Execution
+// errors in json mode also emit an error result on stdout so scripts always
+// get a parseable document. Since exiting skips the root command's
+// post-run, the output is delivered to the --sink destinations and the API
+// calls counted with --show-api-calls are printed here.
+func Exit(command, output, prefix string, err error) {
+	var findingsErr *FindingsError
+	if errors.As(err, &findingsErr) {
+		flush()
+		fmt.Fprintf(os.Stderr, "%s: %v\n", prefix, err)
+		os.Exit(ExitFindings)
+	}
+
+	if output == "json" {
+		r := New(command, nil)
+		r.Status = StatusError
+		r.Error = err.Error()
+		r.WriteJSON(sink.Output())
+	}
+	flush()
+	fmt.Fprintf(os.Stderr, "%s: %v\n", prefix, err)
+	os.Exit(ExitError)
+}
+
+// Fail reports err of a command that doesn't report findings and exits with
+// ExitError. Like Exit, it delivers what the command wrote to the --sink
+// destinations and prints the API calls first.
+func Fail(prefix string, err error) {
+	if sink.Written() {
+		flush()
+	} else {
+		apicalls.Print(os.Stderr)
+	}
+	fmt.Fprintf(os.Stderr, "%s: %v\n", prefix, err)
+	os.Exit(ExitError)
+}
+
+func flush() {
+	if err := sink.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error delivering output: %v\n", err)
+	}
+	apicalls.Print(os.Stderr)
+}
@@ -0,0 +1,234 @@
+// Package server exposes read-only swissarmycli reports over an HTTP/JSON
+// API so dashboards and bots can reuse them without shelling out to the CLI.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/k8s"
+)
+
+// TokenEnv is the environment variable read when no token is given
+const TokenEnv = "SWISSARMYCLI_API_TOKEN"
+
+// Options contains options for the API server
+type Options struct {
+	Addr        string // Address to listen on, e.g. 127.0.0.1:8080
+	Token       string // Bearer token every /api request must present
+	SnapshotDir string // Directory snapshots triggered through the API are written to
+	TLSCert     string // PEM certificate file; with TLSKey the API is served over HTTPS
+	TLSKey      string // PEM private key file of TLSCert
+}
+
+// server holds the state shared by the handlers
+type server struct {
+	token        string
+	snapshotDir  string
+	snapshotting sync.Mutex // Only one snapshot runs at a time
+}
+
+// Run serves the API until interrupted.
+func Run(options Options) error {
+	if options.Token == "" {
+		options.Token = os.Getenv(TokenEnv)
+	}
+	if options.Token == "" {
+		return fmt.Errorf("an API token is required: pass --token or set %s", TokenEnv)
+	}
+	useTLS := options.TLSCert != "" || options.TLSKey != ""
+	if useTLS && (options.TLSCert == "" || options.TLSKey == "") {
+		return fmt.Errorf("--tls-cert and --tls-key must be given together")
+	}
+	// The bearer token would cross the network in the clear
+	if !useTLS && !isLoopback(options.Addr) {
+		return fmt.Errorf("refusing to serve on %s without TLS: pass --tls-cert and --tls-key, or listen on a loopback address such as 127.0.0.1:8080", options.Addr)
+	}
+	if options.SnapshotDir == "" {
+		options.SnapshotDir = "."
+	}
+	if err := os.MkdirAll(options.SnapshotDir, 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory '%s': %w", options.SnapshotDir, err)
+	}
+
+	s := &server{token: options.Token, snapshotDir: options.SnapshotDir}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.Handle("GET /api/v1/node-usage", s.authorized(s.nodeUsage))
+	mux.Handle("GET /api/v1/pod-density", s.authorized(s.podDensity))
+	mux.Handle("GET /api/v1/certificates", s.authorized(s.certificates))
+	mux.Handle("GET /api/v1/cost-estimate", s.authorized(s.costEstimate))
+	mux.Handle("POST /api/v1/snapshots", s.authorized(s.snapshot))
+
+	httpServer := &http.Server{
+		Addr:              options.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if useTLS {
+			serveErr <- httpServer.ListenAndServeTLS(options.TLSCert, options.TLSKey)
+		} else {
+			serveErr <- httpServer.ListenAndServe()
+		}
+	}()
+	scheme := "http"
+	if useTLS {
+		scheme = "https"
+	}
+	fmt.Printf("Serving API on %s://%s (Ctrl+C to stop)...\n", scheme, options.Addr)
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("API server failed: %w", err)
+	case <-stop:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to stop API server: %w", err)
+	}
+	fmt.Println("\nAPI server stopped.")
+	return nil
+}
+
+// isLoopback reports whether addr only accepts connections from this host.
+// An empty host listens on every interface.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// authorized rejects requests without the bearer token.
+func (s *server) authorized(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+		next(w, r)
+	})
+}
+
+func (s *server) nodeUsage(w http.ResponseWriter, r *http.Request) {
+	usage, err := k8s.CollectNodeUsage()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"nodes": usage})
+}
+
+func (s *server) podDensity(w http.ResponseWriter, r *http.Request) {
+	nodes, err := k8s.CollectPodDensity()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"nodes": nodes})
+}
+
+// certificates lists TLS secret certificates; ?expiring_within_days=N keeps
+// only those expiring within N days.
+func (s *server) certificates(w http.ResponseWriter, r *http.Request) {
+	within := -1
+	if value := r.URL.Query().Get("expiring_within_days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid expiring_within_days '%s'", value))
+			return
+		}
+		within = days
+	}
+
+	certificates, err := k8s.ListCertificates()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	filtered := []k8s.CertificateSummary{}
+	for _, certificate := range certificates {
+		if within < 0 || certificate.DaysUntilExpiry <= within {
+			filtered = append(filtered, certificate)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"certificates": filtered})
+}
+
+func (s *server) costEstimate(w http.ResponseWriter, r *http.Request) {
+	cost, err := k8s.CollectClusterCost()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, cost)
+}
+
+// snapshot writes a cluster snapshot to the snapshot directory; ?format=
+// selects yaml (default), txt or html and ?metrics=true adds resource usage.
+func (s *server) snapshot(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "yaml"
+	}
+	if format != "yaml" && format != "txt" && format != "html" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unsupported format '%s' (must be yaml, txt or html)", format))
+		return
+	}
+	if !s.snapshotting.TryLock() {
+		writeError(w, http.StatusConflict, errors.New("a snapshot is already in progress"))
+		return
+	}
+	defer s.snapshotting.Unlock()
+
+	path, err := k8s.CaptureClusterSnapshot(format, s.snapshotDir,
+		r.URL.Query().Get("metrics") == "true", r.URL.Query().Get("certificates") == "true")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{"path": path})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(body); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write response: %v\n", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
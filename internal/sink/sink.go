@@ -0,0 +1,326 @@
+package sink
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HighonAces/swissarmycli/internal/apicalls"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// Placeholders expanded in file paths, S3 keys and webhook URLs, so
+// scheduled runs don't overwrite each other's reports
+const (
+	placeholderCommand   = "{command}"
+	placeholderTimestamp = "{timestamp}"
+)
+
+// TokenEnv holds a bearer token sent with webhook requests
+const TokenEnv = "SWISSARMYCLI_SINK_TOKEN"
+
+// webhookAttempts is how often a webhook POST is tried before giving up
+const webhookAttempts = 3
+
+// Sink is a destination for a command's structured output
+type Sink interface {
+	Deliver(report Report) error
+	String() string
+}
+
+// Report is the structured output of one command run
+type Report struct {
+	Command   string // Command path with dashes, e.g. node-software
+	Format    string // json, csv, dot, ...
+	Timestamp time.Time
+	Data      []byte
+}
+
+// contentTypes maps output formats to the content type they are delivered with
+var contentTypes = map[string]string{
+	"json":    "application/json",
+	"csv":     "text/csv",
+	"dot":     "text/vnd.graphviz",
+	"mermaid": "text/plain",
+}
+
+func (r Report) contentType() string {
+	if contentType, ok := contentTypes[r.Format]; ok {
+		return contentType
+	}
+	return "text/plain"
+}
+
+// fileName is the name of a report written to a directory or S3 prefix
+func (r Report) fileName() string {
+	extension := r.Format
+	if extension == "" || contentTypes[extension] == "" {
+		extension = "txt"
+	}
+	return fmt.Sprintf("%s-%s.%s", r.Command, r.Timestamp.UTC().Format("20060102T150405Z"), extension)
+}
+
+// expand fills in the placeholders of a path, key or URL.
+func (r Report) expand(value string) string {
+	return strings.NewReplacer(
+		placeholderCommand, r.Command,
+		placeholderTimestamp, r.Timestamp.UTC().Format("20060102T150405Z"),
+	).Replace(value)
+}
+
+var (
+	mu      sync.Mutex
+	sinks   []Sink
+	buffer  bytes.Buffer
+	report  Report
+	flushed bool
+)
+
+// Parse turns a --sink value into a sink: stdout, file://PATH,
+// s3://BUCKET/KEY or https://URL. Paths and keys ending with a slash name
+// a directory or prefix the report is written into under a name made of
+// the command and the time.
+func Parse(value string) (Sink, error) {
+	if value == "stdout" || value == "-" || value == "stdout://" {
+		return stdoutSink{}, nil
+	}
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sink '%s': %w", value, err)
+	}
+	switch parsed.Scheme {
+	case "file":
+		path := strings.TrimPrefix(value, "file://")
+		if path == "" {
+			return nil, fmt.Errorf("invalid sink '%s': no path", value)
+		}
+		return fileSink{path: path}, nil
+	case "s3":
+		if parsed.Host == "" {
+			return nil, fmt.Errorf("invalid sink '%s': no bucket", value)
+		}
+		return s3Sink{bucket: parsed.Host, key: strings.TrimPrefix(parsed.Path, "/")}, nil
+	case "https":
+		return webhookSink{url: value}, nil
+	case "http":
+		// Reports can hold sensitive findings; only loopback may be plain HTTP
+		if ip := net.ParseIP(parsed.Hostname()); parsed.Hostname() != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return nil, fmt.Errorf("invalid sink '%s': use https:// (plain http is only allowed to localhost)", value)
+		}
+		return webhookSink{url: value}, nil
+	}
+	return nil, fmt.Errorf("unsupported sink '%s' (must be stdout, file://, s3:// or https://)", value)
+}
+
+// Configure directs the structured output of the command to the sinks
+// given with --sink. Without any, Output is stdout and nothing changes.
+func Configure(command, format string, values []string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, value := range values {
+		sink, err := Parse(value)
+		if err != nil {
+			return err
+		}
+		sinks = append(sinks, sink)
+	}
+	report = Report{Command: command, Format: format, Timestamp: time.Now()}
+	return nil
+}
+
+// Output returns where commands write their structured output: stdout, or
+// a buffer that Flush delivers to the configured sinks.
+func Output() io.Writer {
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sinks) == 0 {
+		return os.Stdout
+	}
+	return &buffer
+}
+
+// Written reports whether the command wrote structured output for the sinks.
+func Written() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return len(sinks) > 0 && buffer.Len() > 0
+}
+
+// Flush delivers the buffered output to every sink, once, and reports each
+// delivery on stderr. A failing sink doesn't stop delivery to the others.
+func Flush() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sinks) == 0 || flushed {
+		return nil
+	}
+	flushed = true
+	if buffer.Len() == 0 {
+		return fmt.Errorf("the command wrote no structured output to deliver; run it with --output json")
+	}
+	report.Data = buffer.Bytes()
+
+	var failed []string
+	for _, sink := range sinks {
+		if err := sink.Deliver(report); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to deliver the output to %s: %v\n", sink, err)
+			failed = append(failed, sink.String())
+			continue
+		}
+		if _, ok := sink.(stdoutSink); !ok {
+			fmt.Fprintf(os.Stderr, "✅ Delivered the output to %s\n", sink)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to deliver the output to %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// stdoutSink writes the output to stdout, to keep it next to other sinks
+type stdoutSink struct{}
+
+func (stdoutSink) Deliver(report Report) error {
+	_, err := os.Stdout.Write(report.Data)
+	return err
+}
+
+func (stdoutSink) String() string { return "stdout" }
+
+// fileSink writes the output to a local file, replacing it atomically
+type fileSink struct {
+	path string
+}
+
+func (s fileSink) Deliver(report Report) error {
+	path := report.expand(s.path)
+	if info, err := os.Stat(path); strings.HasSuffix(path, "/") || (err == nil && info.IsDir()) {
+		path = filepath.Join(path, report.fileName())
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create the directory of '%s': %w", path, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".swissarmycli-*")
+	if err != nil {
+		return fmt.Errorf("failed to write '%s': %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(report.Data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write '%s': %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write '%s': %w", path, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write '%s': %w", path, err)
+	}
+	return nil
+}
+
+func (s fileSink) String() string { return "file://" + s.path }
+
+// s3Sink uploads the output to an S3 object with the default credential
+// chain, or $AWS_PROFILE, in the bucket's own region.
+type s3Sink struct {
+	bucket string
+	key    string
+}
+
+func (s s3Sink) Deliver(report Report) error {
+	key := report.expand(s.key)
+	if key == "" || strings.HasSuffix(key, "/") {
+		key += report.fileName()
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{SharedConfigState: session.SharedConfigEnable})
+	if err != nil {
+		return fmt.Errorf("failed to create AWS session: %w", err)
+	}
+	apicalls.InstrumentSession(sess)
+	hint := aws.StringValue(sess.Config.Region)
+	if hint == "" {
+		hint = "us-east-1"
+	}
+	region, err := s3manager.GetBucketRegion(aws.BackgroundContext(), sess, s.bucket, hint)
+	if err != nil {
+		return fmt.Errorf("failed to find the region of bucket %s: %w", s.bucket, err)
+	}
+	sess.Config.Region = aws.String(region)
+	_, err = s3.New(sess).PutObject(&s3.PutObjectInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(key),
+		Body:                 bytes.NewReader(report.Data),
+		ContentType:          aws.String(report.contentType()),
+		ServerSideEncryption: aws.String(s3.ServerSideEncryptionAes256),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload to s3://%s/%s: %w", s.bucket, key, err)
+	}
+	return nil
+}
+
+func (s s3Sink) String() string { return "s3://" + s.bucket + "/" + s.key }
+
+// webhookSink POSTs the output to a URL. Network errors, 429 and 5xx
+// responses are retried with backoff.
+type webhookSink struct {
+	url string
+}
+
+func (s webhookSink) Deliver(report Report) error {
+	client := &http.Client{Timeout: 30 * time.Second}
+	target := report.expand(s.url)
+	var lastErr error
+	for attempt := 0; attempt < webhookAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(1<<attempt) * time.Second)
+		}
+		req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(report.Data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", report.contentType())
+		req.Header.Set("X-Swissarmycli-Command", report.Command)
+		req.Header.Set("X-Swissarmycli-Timestamp", report.Timestamp.UTC().Format(time.RFC3339))
+		if token := os.Getenv(TokenEnv); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+			break
+		}
+	}
+	return lastErr
+}
+
+func (s webhookSink) String() string {
+	// Don't print credentials or tokens that may be part of the URL
+	if parsed, err := url.Parse(s.url); err == nil {
+		return parsed.Scheme + "://" + parsed.Host + parsed.Path
+	}
+	return s.url
+}
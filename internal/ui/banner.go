@@ -0,0 +1,31 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+)
+
+// ANSI styles of the banner: black on yellow, and bold white on red for
+// protected contexts
+const (
+	bannerStyle       = "\x1b[30;43m"
+	bannerDangerStyle = "\x1b[1;97;41m"
+	bannerReset       = "\x1b[0m"
+)
+
+// Banner prints text as a highlighted line on stderr, so it stands out from
+// the command's own output without ending up in anything piped from stdout.
+// It's printed plain under --no-color, when NO_COLOR is set or when stderr
+// isn't a terminal.
+func Banner(text string, danger bool) {
+	info, err := os.Stderr.Stat()
+	if colorDisabled || os.Getenv("NO_COLOR") != "" || err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		fmt.Fprintln(os.Stderr, text)
+		return
+	}
+	style := bannerStyle
+	if danger {
+		style = bannerDangerStyle
+	}
+	fmt.Fprintf(os.Stderr, "%s %s %s\n", style, text, bannerReset)
+}
@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Selection controls how a choice between several matches is made when a
+// command can't decide on its own
+type Selection struct {
+	NonInteractive bool // Never prompt; fail unless Index picks a match
+	Index          int  // 1-based position of the match to use, 0 to prompt
+}
+
+// Choose returns the index of the item picked by selection.Index, or opens
+// the picker. Without a terminal, or in non-interactive mode, it fails with
+// the list of matches instead of waiting for input that will never come.
+func Choose(title string, items []Item, selection Selection) (int, error) {
+	if selection.Index != 0 {
+		if selection.Index < 1 || selection.Index > len(items) {
+			return -1, fmt.Errorf("--index %d is out of range, there are %d matches", selection.Index, len(items))
+		}
+		return selection.Index - 1, nil
+	}
+
+	if selection.NonInteractive || !IsInteractive() {
+		var labels []string
+		for i, item := range items {
+			labels = append(labels, fmt.Sprintf("  %d. %s", i+1, item.Label))
+		}
+		return -1, fmt.Errorf("%d matches and prompting is disabled, narrow the selection or use --index:\n%s",
+			len(items), strings.Join(labels, "\n"))
+	}
+	return Pick(title, items)
+}
+
+// IsInteractive reports whether stdin is a terminal a user can answer from.
+func IsInteractive() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
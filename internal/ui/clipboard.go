@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// clipboardCommands are tried in order until one is installed
+var clipboardCommands = map[string][][]string{
+	"darwin":  {{"pbcopy"}},
+	"windows": {{"clip"}},
+	"linux": {
+		{"wl-copy"},
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+		{"clip.exe"}, // WSL
+	},
+}
+
+// CopyToClipboard puts value on the system clipboard using the platform's
+// clipboard tool, so it never has to be printed.
+func CopyToClipboard(value []byte) error {
+	candidates := clipboardCommands[runtime.GOOS]
+	if runtime.GOOS == "linux" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		candidates = candidates[1:] // wl-copy needs a Wayland session
+	}
+	for _, candidate := range candidates {
+		path, err := exec.LookPath(candidate[0])
+		if err != nil {
+			continue
+		}
+		// Output isn't captured: xclip and xsel fork a process that keeps
+		// serving the clipboard, and would hold captured pipes open
+		cmd := exec.Command(path, candidate[1:]...)
+		cmd.Stdin = bytes.NewReader(value)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s failed: %w", candidate[0], err)
+		}
+		return nil
+	}
+	if len(candidates) == 0 {
+		return fmt.Errorf("copying to the clipboard is not supported on %s", runtime.GOOS)
+	}
+	var names []string
+	for _, candidate := range candidates {
+		names = append(names, candidate[0])
+	}
+	return fmt.Errorf("no clipboard tool found, install one of %v", names)
+}
@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ConfirmTyped guards destructive actions by making the user type expected
+// back, which is harder to do on autopilot than answering y. answer is the
+// value of a --confirm flag: when set it must equal expected and no prompt
+// is shown, which is the only way through in non-interactive mode. flag is
+// what the error suggests passing when prompting isn't possible.
+func ConfirmTyped(prompt, expected, answer, flag string, nonInteractive bool) error {
+	if answer == "" {
+		if nonInteractive || !IsInteractive() {
+			return fmt.Errorf("confirmation required and prompting is disabled, pass %s", flag)
+		}
+		fmt.Printf("%s\nType %q to continue: ", prompt, expected)
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read confirmation: %w", err)
+		}
+		answer = strings.TrimSpace(line)
+	}
+	if answer != expected {
+		return fmt.Errorf("confirmation '%s' does not match '%s', aborted", answer, expected)
+	}
+	return nil
+}
+
+// Answers to ConfirmStep
+const (
+	StepRun  = "run"
+	StepSkip = "skip"
+	StepQuit = "quit"
+)
+
+// ConfirmStep asks whether to carry out one step of a plan: y runs it, n
+// skips to the next one and q, or the end of input, stops.
+func ConfirmStep(prompt string) (string, error) {
+	if !IsInteractive() {
+		return "", fmt.Errorf("confirmation required and stdin is not a terminal, pass --yes")
+	}
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("%s [y/n/q]: ", prompt)
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return StepQuit, nil
+		}
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "y", "yes":
+			return StepRun, nil
+		case "n", "no":
+			return StepSkip, nil
+		case "q", "quit":
+			return StepQuit, nil
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Package ui holds interactive terminal components shared by commands.
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ErrCancelled is returned when the picker is closed without a choice
+var ErrCancelled = errors.New("selection cancelled")
+
+// Item is one choice offered by Pick
+type Item struct {
+	Label   string // Shown in the list and matched against the filter
+	Preview string // Shown next to the list while the item is highlighted
+}
+
+// Pick shows a full screen picker: typing filters the items with fuzzy
+// matching, the arrow keys move the selection and the preview pane shows
+// details of the highlighted item. It returns the index of the chosen item
+// in items, or ErrCancelled if the user pressed Esc or Ctrl+C.
+func Pick(title string, items []Item) (int, error) {
+	if len(items) == 0 {
+		return -1, fmt.Errorf("nothing to choose from")
+	}
+
+	theme, err := LoadTheme()
+	if err != nil {
+		return -1, err
+	}
+
+	app := tview.NewApplication()
+	filter := tview.NewInputField().
+		SetLabel("Filter: ").
+		SetFieldBackgroundColor(tcell.ColorDefault)
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true).
+		SetSelectedStyle(theme.SelectedStyle())
+	list.SetBorder(true).SetTitle(" " + title + " ")
+	preview := tview.NewTextView().
+		SetDynamicColors(false).
+		SetWordWrap(true)
+	preview.SetBorder(true).SetTitle(" Preview ")
+	help := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(theme.Paint(theme.Muted, "type to filter  ↑/↓ move  Enter select  Esc cancel"))
+
+	chosen := -1
+	var visible []int // Indexes into items of the entries currently in the list
+
+	showPreview := func(position int) {
+		preview.Clear()
+		if position >= 0 && position < len(visible) {
+			preview.SetText(items[visible[position]].Preview)
+			preview.ScrollToBeginning()
+		}
+	}
+	refresh := func(pattern string) {
+		visible = matchItems(items, pattern)
+		list.Clear()
+		for _, index := range visible {
+			list.AddItem(tview.Escape(items[index].Label), "", 0, nil)
+		}
+		list.SetTitle(fmt.Sprintf(" %s (%d/%d) ", title, len(visible), len(items)))
+		showPreview(0)
+	}
+
+	list.SetChangedFunc(func(position int, _ string, _ string, _ rune) {
+		showPreview(position)
+	})
+	filter.SetChangedFunc(refresh)
+
+	// Keys go to the filter field; navigation keys are forwarded to the list
+	filter.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		count := list.GetItemCount()
+		current := list.GetCurrentItem()
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyCtrlP:
+			if current > 0 {
+				list.SetCurrentItem(current - 1)
+			}
+			return nil
+		case tcell.KeyDown, tcell.KeyCtrlN:
+			if current < count-1 {
+				list.SetCurrentItem(current + 1)
+			}
+			return nil
+		case tcell.KeyPgUp:
+			list.SetCurrentItem(max(current-10, 0))
+			return nil
+		case tcell.KeyPgDn:
+			list.SetCurrentItem(min(current+10, count-1))
+			return nil
+		case tcell.KeyEnter:
+			if count > 0 {
+				chosen = visible[current]
+				app.Stop()
+			}
+			return nil
+		case tcell.KeyEscape, tcell.KeyCtrlC:
+			app.Stop()
+			return nil
+		}
+		return event
+	})
+
+	body := tview.NewFlex().
+		AddItem(list, 0, 1, false).
+		AddItem(preview, 0, 1, false)
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(filter, 1, 0, true).
+		AddItem(body, 0, 1, false).
+		AddItem(help, 1, 0, false)
+
+	refresh("")
+	if err := app.SetRoot(layout, true).SetFocus(filter).Run(); err != nil {
+		return -1, fmt.Errorf("failed to run picker: %w", err)
+	}
+	if chosen < 0 {
+		return -1, ErrCancelled
+	}
+	return chosen, nil
+}
+
+// matchItems returns the indexes of the items matching pattern, best
+// matches first. An empty pattern keeps every item in its original order.
+func matchItems(items []Item, pattern string) []int {
+	type match struct {
+		index int
+		score int
+	}
+	var matches []match
+	for i, item := range items {
+		if score, ok := fuzzyScore(pattern, item.Label); ok {
+			matches = append(matches, match{i, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	indexes := make([]int, len(matches))
+	for i, m := range matches {
+		indexes[i] = m.index
+	}
+	return indexes
+}
+
+// fuzzyScore reports whether every character of pattern appears in text in
+// order, ignoring case, and scores the match: consecutive characters and
+// characters at the start of a word score higher.
+func fuzzyScore(pattern, text string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	patternRunes := []rune(strings.ToLower(pattern))
+	textRunes := []rune(text)
+
+	score, matched, previous := 0, 0, -2
+	for i, r := range textRunes {
+		if matched == len(patternRunes) {
+			break
+		}
+		if unicode.ToLower(r) != patternRunes[matched] {
+			continue
+		}
+		score++
+		if i == previous+1 {
+			score += 3 // Consecutive
+		}
+		if i == 0 || strings.ContainsRune("-_./ :", textRunes[i-1]) {
+			score += 2 // Start of a word
+		}
+		previous = i
+		matched++
+	}
+	if matched < len(patternRunes) {
+		return 0, false
+	}
+	return score - len(textRunes)/10, true // Prefer shorter labels on ties
+}
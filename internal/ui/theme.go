@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/HighonAces/swissarmycli/internal/config"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Theme holds the tview style tags ("fg:bg:attributes", e.g. "red" or
+// "::b") used for each role of colored text. An empty style prints the text
+// in the terminal's own colors.
+type Theme struct {
+	Title   string
+	Muted   string // Timestamps and help text
+	OK      string
+	Warning string
+	Error   string
+}
+
+// Palettes are the themes that can be selected in the config file
+var Palettes = map[string]Theme{
+	// Readable on dark and light backgrounds alike
+	"default": {Title: "darkcyan::b", Muted: "gray", OK: "green", Warning: "darkorange", Error: "red"},
+	// States are told apart by attributes in the terminal's foreground color
+	"high-contrast": {Title: "::bu", OK: "::b", Warning: "::bu", Error: "::br"},
+	// Okabe-Ito colors, distinguishable with any form of color blindness
+	"colorblind": {Title: "#0072B2::b", Muted: "gray", OK: "#0072B2", Warning: "#E69F00", Error: "#D55E00::b"},
+	"none":       {},
+}
+
+// colorDisabled is set by --no-color
+var colorDisabled bool
+
+// DisableColor makes LoadTheme return the "none" palette whatever the config
+// file says.
+func DisableColor() {
+	colorDisabled = true
+}
+
+// LoadTheme returns the palette selected in the config file with its color
+// overrides applied, or no colors at all under --no-color or when NO_COLOR
+// is set (https://no-color.org). It also points tview's defaults at the
+// terminal's own colors instead of white on black.
+func LoadTheme() (Theme, error) {
+	tview.Styles.PrimitiveBackgroundColor = tcell.ColorDefault
+	tview.Styles.ContrastBackgroundColor = tcell.ColorDefault
+	tview.Styles.MoreContrastBackgroundColor = tcell.ColorDefault
+	tview.Styles.BorderColor = tcell.ColorDefault
+	tview.Styles.TitleColor = tcell.ColorDefault
+	tview.Styles.GraphicsColor = tcell.ColorDefault
+	tview.Styles.PrimaryTextColor = tcell.ColorDefault
+
+	if colorDisabled || os.Getenv("NO_COLOR") != "" {
+		return Palettes["none"], nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return Theme{}, err
+	}
+	themeConfig, err := cfg.Theme()
+	if err != nil {
+		return Theme{}, err
+	}
+
+	name := themeConfig.Palette
+	if name == "" {
+		name = "default"
+	}
+	theme, ok := Palettes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme palette '%s' (must be %s)", name, strings.Join(paletteNames(), ", "))
+	}
+	for role, style := range themeConfig.Colors {
+		switch role {
+		case "title":
+			theme.Title = style
+		case "muted":
+			theme.Muted = style
+		case "ok":
+			theme.OK = style
+		case "warning":
+			theme.Warning = style
+		case "error":
+			theme.Error = style
+		default:
+			return Theme{}, fmt.Errorf("unknown theme color '%s' (must be title, muted, ok, warning or error)", role)
+		}
+	}
+	return theme, nil
+}
+
+// Paint wraps already escaped text in a style tag for a view with dynamic
+// colors, resetting the style after it.
+func (t Theme) Paint(style, text string) string {
+	if style == "" {
+		return text
+	}
+	return "[" + style + "]" + text + "[-:-:-]"
+}
+
+// SelectedStyle is used for the highlighted entry of lists. Reversing the
+// terminal's colors keeps it visible on any background.
+func (t Theme) SelectedStyle() tcell.Style {
+	return tcell.StyleDefault.Reverse(true)
+}
+
+func paletteNames() []string {
+	names := make([]string, 0, len(Palettes))
+	for name := range Palettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
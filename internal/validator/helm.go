@@ -0,0 +1,148 @@
+package validator
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// strictDecoder rejects unknown fields and wrong types in built-in kinds
+var strictDecoder = serializer.NewCodecFactory(scheme.Scheme, serializer.EnableStrict).UniversalDeserializer()
+
+// ValidateHelmChart renders a chart in-process with the given values files,
+// later files taking precedence, and validates every produced manifest for
+// YAML syntax and Kubernetes schema.
+func ValidateHelmChart(chartDir string, valueFiles []string) error {
+	chartName, rendered, err := RenderHelmChart(chartDir, valueFiles, "default")
+	if err != nil {
+		return err
+	}
+
+	var templates []string
+	for name := range rendered {
+		templates = append(templates, name)
+	}
+	sort.Strings(templates)
+
+	failed, checked := 0, 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TEMPLATE\tRESOURCE\tSTATUS")
+	for _, name := range templates {
+		docs := releaseutil.SplitManifests(rendered[name])
+		var keys []string
+		for key := range docs {
+			keys = append(keys, key)
+		}
+		sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+		for _, key := range keys {
+			checked++
+			resource, err := validateManifest([]byte(docs[key]))
+			status := "✅ valid"
+			if err != nil {
+				failed++
+				status = "❌ " + err.Error()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", name, resource, status)
+		}
+	}
+	w.Flush()
+
+	fmt.Printf("\n%d manifest(s) checked, %d invalid.\n", checked, failed)
+	if failed > 0 {
+		return fmt.Errorf("chart '%s' produced %d invalid manifest(s)", chartName, failed)
+	}
+	return nil
+}
+
+// RenderHelmChart renders a chart in-process as a release in namespace with
+// the given values files, later files taking precedence. It returns the
+// chart name and the rendered manifests by template name, leaving out
+// partials, NOTES.txt and templates that render to nothing.
+func RenderHelmChart(chartDir string, valueFiles []string, namespace string) (string, map[string]string, error) {
+	chart, err := loader.Load(chartDir)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load chart '%s': %w", chartDir, err)
+	}
+
+	values := map[string]interface{}{}
+	for _, file := range valueFiles {
+		fileValues, err := chartutil.ReadValuesFile(file)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read values file '%s': %w", file, err)
+		}
+		values = chartutil.CoalesceTables(fileValues.AsMap(), values)
+	}
+
+	options := chartutil.ReleaseOptions{
+		Name:      "release-name",
+		Namespace: namespace,
+		Revision:  1,
+		IsInstall: true,
+	}
+	renderValues, err := chartutil.ToRenderValues(chart, values, options, chartutil.DefaultCapabilities)
+	if err != nil {
+		// Includes values.schema.json violations
+		return "", nil, fmt.Errorf("invalid values for chart '%s': %w", chart.Name(), err)
+	}
+
+	rendered, err := engine.Render(chart, renderValues)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to render chart '%s': %w", chart.Name(), err)
+	}
+	for name, content := range rendered {
+		if strings.HasPrefix(path.Base(name), "_") || strings.HasSuffix(name, "NOTES.txt") || strings.TrimSpace(content) == "" {
+			delete(rendered, name)
+		}
+	}
+	return chart.Name(), rendered, nil
+}
+
+// validateManifest checks a single YAML document for syntax and, for kinds
+// known to client-go, for schema errors. It returns a Kind/name description.
+func validateManifest(doc []byte) (string, error) {
+	var out map[string]interface{}
+	if err := yaml.Unmarshal(doc, &out); err != nil {
+		return "-", fmt.Errorf("invalid YAML: %w", err)
+	}
+
+	kind, _ := out["kind"].(string)
+	resource := kind
+	if metadata, ok := out["metadata"].(map[string]interface{}); ok {
+		if name, ok := metadata["name"].(string); ok {
+			resource = kind + "/" + name
+		}
+	}
+	if _, ok := out["apiVersion"].(string); !ok || kind == "" {
+		return valueOrDash(resource), fmt.Errorf("missing apiVersion or kind")
+	}
+
+	_, _, err := strictDecoder.Decode(doc, nil, nil)
+	if runtime.IsNotRegisteredError(err) {
+		// Custom resources have no schema available offline
+		return resource, nil
+	}
+	if err != nil {
+		return resource, fmt.Errorf("schema error: %w", err)
+	}
+	return resource, nil
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
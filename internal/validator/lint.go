@@ -0,0 +1,410 @@
+package validator
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/HighonAces/swissarmycli/internal/config"
+	"github.com/HighonAces/swissarmycli/internal/k8s/common"
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/HighonAces/swissarmycli/internal/sink"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// lintCheck is a best-practice rule applied to every container or pod spec
+type lintCheck struct {
+	id       string
+	severity string
+	// containerCheck returns a message when the container violates the rule
+	containerCheck func(container corev1.Container, workload lintObject) string
+	// podCheck returns a message when the pod spec violates the rule
+	podCheck func(spec corev1.PodSpec) string
+}
+
+var lintChecks = []lintCheck{
+	{id: "missing-requests", severity: result.SeverityWarning, containerCheck: func(c corev1.Container, _ lintObject) string {
+		if c.Resources.Requests.Cpu().IsZero() || c.Resources.Requests.Memory().IsZero() {
+			return "CPU or memory request not set"
+		}
+		return ""
+	}},
+	{id: "missing-limits", severity: result.SeverityWarning, containerCheck: func(c corev1.Container, _ lintObject) string {
+		if c.Resources.Limits.Memory().IsZero() {
+			return "memory limit not set"
+		}
+		return ""
+	}},
+	{id: "missing-liveness-probe", severity: result.SeverityWarning, containerCheck: func(c corev1.Container, w lintObject) string {
+		if c.LivenessProbe == nil && !w.batch {
+			return "no liveness probe"
+		}
+		return ""
+	}},
+	{id: "missing-readiness-probe", severity: result.SeverityWarning, containerCheck: func(c corev1.Container, w lintObject) string {
+		if c.ReadinessProbe == nil && !w.batch {
+			return "no readiness probe"
+		}
+		return ""
+	}},
+	{id: "privileged-container", severity: result.SeverityError, containerCheck: func(c corev1.Container, _ lintObject) string {
+		if c.SecurityContext != nil && c.SecurityContext.Privileged != nil && *c.SecurityContext.Privileged {
+			return "runs privileged"
+		}
+		return ""
+	}},
+	{id: "hostpath-mount", severity: result.SeverityError, podCheck: func(spec corev1.PodSpec) string {
+		var paths []string
+		for _, volume := range spec.Volumes {
+			if volume.HostPath != nil {
+				paths = append(paths, volume.HostPath.Path)
+			}
+		}
+		if len(paths) > 0 {
+			return "mounts hostPath " + strings.Join(paths, ", ")
+		}
+		return ""
+	}},
+	{id: "default-service-account", severity: result.SeverityInfo, podCheck: func(spec corev1.PodSpec) string {
+		if spec.ServiceAccountName == "" || spec.ServiceAccountName == "default" {
+			return "uses the default service account"
+		}
+		return ""
+	}},
+}
+
+// LintOptions contains options for the lint command
+type LintOptions struct {
+	Paths     []string // Manifest files or directories; live objects are checked when empty
+	Namespace string   // Namespace for live objects (default: all namespaces)
+	Output    string   // table or json
+	PolicyDir string   // Directory of Rego policies evaluated against every object
+	FailOn    string   // Lowest severity that fails the run: error, warning, info or none
+}
+
+// lintObject is a Kubernetes object from a file or the cluster
+type lintObject struct {
+	resource string                 // Kind/namespace/name
+	source   string                 // File path or "cluster"
+	raw      map[string]interface{} // Full object, used as policy input
+	spec     *corev1.PodSpec        // Pod template of workloads, nil otherwise
+	batch    bool                   // Jobs and CronJobs don't need probes
+}
+
+// Lint runs the best-practice checks against manifests or live workloads and
+// returns a result.FindingsError when a finding reaches the FailOn severity.
+func Lint(options LintOptions) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	lintConfig, err := cfg.Lint()
+	if err != nil {
+		return err
+	}
+	if options.FailOn == "" {
+		options.FailOn = result.SeverityError
+	}
+	if err := result.ValidateThreshold(options.FailOn); err != nil {
+		return err
+	}
+
+	var objects []lintObject
+	if len(options.Paths) > 0 {
+		objects, err = loadManifestObjects(options.Paths)
+	} else {
+		objects, err = loadLiveObjects(options.Namespace)
+	}
+	if err != nil {
+		return err
+	}
+
+	findings := runLintChecks(objects, lintConfig)
+	if options.PolicyDir != "" {
+		policyFindings, err := evaluatePolicies(options.PolicyDir, objects)
+		if err != nil {
+			return err
+		}
+		findings = append(findings, policyFindings...)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return result.Rank(findings[i].Severity) > result.Rank(findings[j].Severity)
+	})
+
+	if err := printLintFindings(findings, options.Output, len(objects)); err != nil {
+		return err
+	}
+	return result.Gate(findings, options.FailOn)
+}
+
+func runLintChecks(objects []lintObject, lintConfig config.LintConfig) []result.Finding {
+	disabled := make(map[string]bool)
+	for _, id := range lintConfig.Disabled {
+		disabled[id] = true
+	}
+
+	var findings []result.Finding
+	for _, check := range lintChecks {
+		if disabled[check.id] {
+			continue
+		}
+		severity := check.severity
+		if override, ok := lintConfig.Severities[check.id]; ok && result.Rank(override) > 0 {
+			severity = override
+		}
+
+		for _, object := range objects {
+			if object.spec == nil {
+				continue
+			}
+			newFinding := func(container, message string) result.Finding {
+				return newLintFinding(check.id, severity, object, container, message)
+			}
+			if check.podCheck != nil {
+				if message := check.podCheck(*object.spec); message != "" {
+					findings = append(findings, newFinding("", message))
+				}
+			}
+			if check.containerCheck != nil {
+				containers := append(append([]corev1.Container{}, object.spec.InitContainers...), object.spec.Containers...)
+				for _, container := range containers {
+					if message := check.containerCheck(container, object); message != "" {
+						findings = append(findings, newFinding(container.Name, message))
+					}
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// newLintFinding builds a finding whose details record the container, if any,
+// and the file or "cluster" the object came from.
+func newLintFinding(check, severity string, object lintObject, container, message string) result.Finding {
+	details := map[string]string{"source": object.source}
+	if container != "" {
+		details["container"] = container
+	}
+	return result.Finding{Check: check, Severity: severity, Resource: object.resource, Message: message, Details: details}
+}
+
+func printLintFindings(findings []result.Finding, output string, objectCount int) error {
+	switch output {
+	case "json":
+		return result.New("lint", findings).WriteJSON(sink.Output())
+	case "", "table":
+	default:
+		return fmt.Errorf("unsupported output format '%s' (must be table or json)", output)
+	}
+
+	counts := make(map[string]int)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SEVERITY\tCHECK\tRESOURCE\tCONTAINER\tMESSAGE\tSOURCE")
+	for _, finding := range findings {
+		counts[finding.Severity]++
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", result.Label(finding.Severity), finding.Check,
+			finding.Resource, valueOrDash(finding.Details["container"]), finding.Message, finding.Details["source"])
+	}
+	w.Flush()
+
+	fmt.Println("\n--- Lint Summary ---")
+	fmt.Printf("Objects checked: %d\n", objectCount)
+	fmt.Printf("Errors: %d\n", counts[result.SeverityError])
+	fmt.Printf("Warnings: %d\n", counts[result.SeverityWarning])
+	fmt.Printf("Info: %d\n", counts[result.SeverityInfo])
+	fmt.Println("----------------------------------------------------")
+	return nil
+}
+
+// loadManifestObjects reads every YAML document from the given files, or from
+// .yaml/.yml files under the given directories.
+func loadManifestObjects(paths []string) ([]lintObject, error) {
+	var files []string
+	for _, path := range paths {
+		err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			ext := filepath.Ext(file)
+			if !info.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read '%s': %w", path, err)
+		}
+	}
+
+	decoder := scheme.Codecs.UniversalDeserializer()
+	var objects []lintObject
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file '%s': %w", file, err)
+		}
+		reader := utilyaml.NewYAMLReader(bufio.NewReader(f))
+		for {
+			doc, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				f.Close()
+				return nil, fmt.Errorf("invalid YAML in '%s': %w", file, err)
+			}
+			if strings.TrimSpace(string(doc)) == "" {
+				continue
+			}
+
+			var raw map[string]interface{}
+			if err := sigsyaml.Unmarshal(doc, &raw); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("invalid YAML in '%s': %w", file, err)
+			}
+			if raw == nil {
+				continue
+			}
+
+			object := lintObject{resource: rawResource(raw), source: file, raw: raw}
+			// Custom resources can't be decoded and only go through policies
+			if obj, _, err := decoder.Decode(doc, nil, nil); err == nil {
+				if workload, ok := workloadFromObject(obj); ok {
+					object.spec, object.batch = workload.spec, workload.batch
+				}
+			}
+			objects = append(objects, object)
+		}
+		f.Close()
+	}
+	return objects, nil
+}
+
+// loadLiveObjects fetches workloads from the cluster. Pods owned by a
+// controller are skipped since their template is checked already.
+func loadLiveObjects(namespace string) ([]lintObject, error) {
+	clientset, err := common.GetKubernetesClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	ctx := context.TODO()
+	var items []runtime.Object
+
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	for i := range deployments.Items {
+		items = append(items, &deployments.Items[i])
+	}
+	statefulSets, err := clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	for i := range statefulSets.Items {
+		items = append(items, &statefulSets.Items[i])
+	}
+	daemonSets, err := clientset.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+	}
+	for i := range daemonSets.Items {
+		items = append(items, &daemonSets.Items[i])
+	}
+	cronJobs, err := clientset.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cronjobs: %w", err)
+	}
+	for i := range cronJobs.Items {
+		items = append(items, &cronJobs.Items[i])
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	for i := range pods.Items {
+		if metav1.GetControllerOf(&pods.Items[i]) == nil {
+			items = append(items, &pods.Items[i])
+		}
+	}
+	services, err := clientset.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	for i := range services.Items {
+		items = append(items, &services.Items[i])
+	}
+	ingresses, err := clientset.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses: %w", err)
+	}
+	for i := range ingresses.Items {
+		items = append(items, &ingresses.Items[i])
+	}
+
+	var objects []lintObject
+	for _, item := range items {
+		// List results have no apiVersion/kind set, which policies rely on
+		if gvks, _, err := scheme.Scheme.ObjectKinds(item); err == nil && len(gvks) > 0 {
+			item.GetObjectKind().SetGroupVersionKind(gvks[0])
+		}
+		raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert object: %w", err)
+		}
+
+		object := lintObject{resource: rawResource(raw), source: "cluster", raw: raw}
+		if workload, ok := workloadFromObject(item); ok {
+			object.spec, object.batch = workload.spec, workload.batch
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// workloadFromObject extracts the pod spec of the workload kinds lint knows.
+func workloadFromObject(obj runtime.Object) (lintObject, bool) {
+	switch o := obj.(type) {
+	case *corev1.Pod:
+		return lintObject{spec: &o.Spec}, true
+	case *appsv1.Deployment:
+		return lintObject{spec: &o.Spec.Template.Spec}, true
+	case *appsv1.StatefulSet:
+		return lintObject{spec: &o.Spec.Template.Spec}, true
+	case *appsv1.DaemonSet:
+		return lintObject{spec: &o.Spec.Template.Spec}, true
+	case *appsv1.ReplicaSet:
+		return lintObject{spec: &o.Spec.Template.Spec}, true
+	case *batchv1.Job:
+		return lintObject{spec: &o.Spec.Template.Spec, batch: true}, true
+	case *batchv1.CronJob:
+		return lintObject{spec: &o.Spec.JobTemplate.Spec.Template.Spec, batch: true}, true
+	}
+	return lintObject{}, false
+}
+
+// rawResource describes an object as Kind/namespace/name.
+func rawResource(raw map[string]interface{}) string {
+	kind, _ := raw["kind"].(string)
+	metadata, _ := raw["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+	if namespace == "" {
+		namespace = "default"
+	}
+	return valueOrDash(kind) + "/" + namespace + "/" + valueOrDash(name)
+}
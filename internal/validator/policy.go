@@ -0,0 +1,129 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/HighonAces/swissarmycli/internal/result"
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+)
+
+// policyRules maps the conftest-style rule names a policy package may define
+// to the severity of their findings.
+var policyRules = []struct{ name, severity string }{
+	{"deny", result.SeverityError},
+	{"violation", result.SeverityError},
+	{"warn", result.SeverityWarning},
+}
+
+// evaluatePolicies evaluates every Rego package in policyDir against each
+// object, passed as input. Each package may define deny, violation and warn
+// rules producing messages, or objects with a msg field.
+func evaluatePolicies(policyDir string, objects []lintObject) ([]result.Finding, error) {
+	packages, err := loadPolicyPackages(policyDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("no Rego policies found in '%s'", policyDir)
+	}
+
+	ctx := context.TODO()
+	var findings []result.Finding
+	var packageNames []string
+	for name := range packages {
+		packageNames = append(packageNames, name)
+	}
+	sort.Strings(packageNames)
+
+	for _, packagePath := range packageNames {
+		options := []func(*rego.Rego){rego.Query(packagePath)}
+		for _, module := range packages[packagePath] {
+			options = append(options, rego.ParsedModule(module))
+		}
+		query, err := rego.New(options...).PrepareForEval(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile policy %s: %w", packagePath, err)
+		}
+
+		for _, object := range objects {
+			results, err := query.Eval(ctx, rego.EvalInput(object.raw))
+			if err != nil {
+				return nil, fmt.Errorf("failed to evaluate policy %s on %s: %w", packagePath, object.resource, err)
+			}
+			if len(results) == 0 || len(results[0].Expressions) == 0 {
+				continue
+			}
+			document, ok := results[0].Expressions[0].Value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			for _, rule := range policyRules {
+				for _, message := range policyMessages(document[rule.name]) {
+					check := strings.TrimPrefix(packagePath, "data.") + "." + rule.name
+					findings = append(findings, newLintFinding(check, rule.severity, object, "", message))
+				}
+			}
+		}
+	}
+	return findings, nil
+}
+
+// loadPolicyPackages parses the .rego files under dir, skipping Rego unit
+// tests, and groups the modules by package path (e.g. data.main).
+func loadPolicyPackages(dir string) (map[string][]*ast.Module, error) {
+	packages := make(map[string][]*ast.Module)
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(file) != ".rego" || strings.HasSuffix(file, "_test.rego") {
+			return nil
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read policy '%s': %w", file, err)
+		}
+		module, err := ast.ParseModule(file, string(content))
+		if err != nil {
+			return fmt.Errorf("invalid policy '%s': %w", file, err)
+		}
+		packagePath := module.Package.Path.String()
+		packages[packagePath] = append(packages[packagePath], module)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return packages, nil
+}
+
+// policyMessages turns a rule's value (a set of strings or of objects with a
+// msg field) into messages.
+func policyMessages(value interface{}) []string {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var messages []string
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			messages = append(messages, v)
+		case map[string]interface{}:
+			if msg, ok := v["msg"].(string); ok {
+				messages = append(messages, msg)
+			}
+		}
+	}
+	sort.Strings(messages)
+	return messages
+}